@@ -0,0 +1,25 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// PlaybackMaxConcurrent is how many videos one account may stream at the same time
+var PlaybackMaxConcurrent = 2
+
+// PlaybackIdleTimeout frees a playback slot once its player stops requesting media
+var PlaybackIdleTimeout = 2 * time.Minute
+
+// InitPlayback reads PLAYBACK_MAX_CONCURRENT and PLAYBACK_IDLE_SECONDS
+func InitPlayback() {
+	if n, err := strconv.Atoi(os.Getenv("PLAYBACK_MAX_CONCURRENT")); err == nil && n > 0 {
+		PlaybackMaxConcurrent = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("PLAYBACK_IDLE_SECONDS")); err == nil && n > 0 {
+		PlaybackIdleTimeout = time.Duration(n) * time.Second
+	}
+	fmt.Printf("[PLAYBACK] ✅ Max %d concurrent streams per account\n", PlaybackMaxConcurrent)
+}
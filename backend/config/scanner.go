@@ -0,0 +1,30 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"BACKEND/filecheck"
+)
+
+// Scanner checks every upload for malware before it is stored
+var Scanner filecheck.Scanner = filecheck.Noop{}
+
+// InitScanner enables ClamAV scanning when CLAMAV_ADDRESS is set
+// ("unix:///run/clamav/clamd.ctl" or "tcp://clamav:3310")
+func InitScanner() {
+	address := os.Getenv("CLAMAV_ADDRESS")
+	if address == "" {
+		Scanner = filecheck.Noop{}
+		fmt.Println("[SCANNER] ⚠️ CLAMAV_ADDRESS not set, uploads are not scanned for malware")
+		return
+	}
+
+	clamav := filecheck.NewClamAV(address)
+	if n, err := strconv.ParseInt(os.Getenv("CLAMAV_MAX_BYTES"), 10, 64); err == nil && n > 0 {
+		clamav.MaxBytes = n
+	}
+	Scanner = clamav
+	fmt.Printf("[SCANNER] ✅ ClamAV scanning enabled (%s)\n", address)
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"BACKEND/storage"
+)
+
+// Storage is where uploaded media, thumbnails, logos and report photos live
+var Storage storage.Storage
+
+// MediaStorage is the private store for paid session videos and files. Its
+// objects are never publicly readable and are only streamed after an
+// entitlement check.
+var MediaStorage storage.Storage
+
+// InitStorage selects the storage driver from STORAGE_DRIVER (local, s3 or supabase).
+// Without it, Supabase is used when its credentials are set and local disk otherwise.
+func InitStorage() {
+	driver := strings.ToLower(strings.TrimSpace(os.Getenv("STORAGE_DRIVER")))
+	if driver == "" {
+		driver = "local"
+		if os.Getenv("SUPABASE_URL") != "" && os.Getenv("SUPABASE_KEY") != "" {
+			driver = "supabase"
+		}
+	}
+
+	switch driver {
+	case "s3":
+		s3cfg := storage.S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			PublicURL: os.Getenv("S3_PUBLIC_URL"),
+			PathStyle: os.Getenv("S3_PATH_STYLE") == "true",
+		}
+		Storage = storage.NewS3(s3cfg)
+
+		// The private bucket must not have a public-read policy, so it is never guessed
+		s3cfg.Bucket = strings.TrimSpace(os.Getenv("S3_PRIVATE_BUCKET"))
+		if s3cfg.Bucket == "" || s3cfg.Bucket == os.Getenv("S3_BUCKET") {
+			log.Fatal("❌ S3_PRIVATE_BUCKET must name a private bucket separate from S3_BUCKET")
+		}
+		s3cfg.PublicURL = ""
+		MediaStorage = storage.NewS3(s3cfg)
+	case "supabase":
+		bucket := os.Getenv("SUPABASE_BUCKET")
+		if bucket == "" {
+			bucket = "webbinar-storage"
+		}
+		Storage = storage.NewSupabase(storage.SupabaseConfig{
+			URL:    os.Getenv("SUPABASE_URL"),
+			Key:    os.Getenv("SUPABASE_KEY"),
+			Bucket: bucket,
+		})
+
+		privateBucket := os.Getenv("SUPABASE_PRIVATE_BUCKET")
+		if privateBucket == "" {
+			privateBucket = "webbinar-private"
+		}
+		MediaStorage = storage.NewSupabase(storage.SupabaseConfig{
+			URL:     os.Getenv("SUPABASE_URL"),
+			Key:     os.Getenv("SUPABASE_KEY"),
+			Bucket:  privateBucket,
+			Private: true,
+		})
+	default:
+		driver = "local"
+		Storage = NewLocalStorage()
+		MediaStorage = NewLocalMediaStorage()
+	}
+
+	fmt.Printf("[STORAGE] ✅ Using %s storage\n", driver)
+}
+
+// NewLocalStorage is the local disk driver; it also serves files uploaded before
+// another driver was configured. Stored URLs keep the old "uploads/..." form.
+func NewLocalStorage() *storage.Local {
+	root := os.Getenv("STORAGE_LOCAL_ROOT")
+	if root == "" {
+		root = "uploads"
+	}
+	baseURL := os.Getenv("STORAGE_LOCAL_URL")
+	if baseURL == "" {
+		baseURL = "uploads"
+	}
+	return storage.NewLocal(root, baseURL, "/api/storage", []byte(storageSigningSecret()))
+}
+
+// NewLocalMediaStorage keeps private media on local disk outside the statically
+// served uploads directory; stored URLs look like "private/videos/...".
+func NewLocalMediaStorage() *storage.Local {
+	root := os.Getenv("STORAGE_PRIVATE_ROOT")
+	if root == "" {
+		root = "private"
+	}
+	return storage.NewLocal(root, "private", "/api/storage", []byte(storageSigningSecret()))
+}
+
+func storageSigningSecret() string {
+	if secret := os.Getenv("STORAGE_SIGNING_SECRET"); secret != "" {
+		return secret
+	}
+	return os.Getenv("JWT_SECRET")
+}
+
+// UploadStagingDir holds partial resumable uploads until they are complete
+func UploadStagingDir() string {
+	if dir := os.Getenv("STORAGE_UPLOAD_STAGING"); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.TempDir(), "webbinar-uploads")
+}
@@ -0,0 +1,23 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"BACKEND/media"
+)
+
+// Transcoder turns uploaded session videos into HLS; nil when ffmpeg is not installed
+var Transcoder media.Transcoder
+
+// InitTranscoder enables HLS transcoding when ffmpeg and ffprobe are available
+// (FFMPEG_PATH / FFPROBE_PATH, otherwise looked up in PATH)
+func InitTranscoder() {
+	ffmpeg := media.NewFFmpeg(os.Getenv("FFMPEG_PATH"), os.Getenv("FFPROBE_PATH"))
+	if !ffmpeg.Available() {
+		fmt.Println("[TRANSCODE] ⚠️ ffmpeg/ffprobe not found, HLS transcoding disabled")
+		return
+	}
+	Transcoder = ffmpeg
+	fmt.Println("[TRANSCODE] ✅ ffmpeg found, HLS transcoding enabled")
+}
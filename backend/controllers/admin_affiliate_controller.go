@@ -2,10 +2,12 @@ package controllers
 
 import (
 	"BACKEND/config"
-	"BACKEND/utils"
+	"BACKEND/filecheck"
+	"BACKEND/media"
 	"fmt"
 	"net/http"
 	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -360,15 +362,20 @@ func ReviewAffiliateSubmission(c *gin.Context) {
 
 		// Insert all videos to session_videos
 		for i, video := range videos {
-			// Use the original URL from Supabase directly
+			// Paid sessions read from the private media storage; the submission keeps its copy
+			videoURL, copyErr := copyToMediaStorage(c.Request.Context(), video.URL, sessionOrganization(sessionID))
+			if copyErr != nil {
+				fmt.Printf("[APPROVE] ❌ Failed to copy video %d to private storage: %v\n", i+1, copyErr)
+				videoURL = video.URL
+			}
 			_, insertErr := config.DB.Exec(`
 				INSERT INTO session_videos (session_id, title, video_url, order_index)
 				VALUES (?, ?, ?, ?)
-			`, sessionID, video.Title, video.URL, i+1)
+			`, sessionID, video.Title, videoURL, i+1)
 			if insertErr != nil {
 				fmt.Printf("[APPROVE] Error inserting video %d: %v\n", i+1, insertErr)
 			} else {
-				fmt.Printf("[APPROVE] ✅ Video %d inserted: %s\n", i+1, video.URL)
+				fmt.Printf("[APPROVE] ✅ Video %d inserted: %s\n", i+1, videoURL)
 			}
 		}
 
@@ -400,18 +407,25 @@ func ReviewAffiliateSubmission(c *gin.Context) {
 
 		// Insert all files to session_files
 		for i, file := range files {
-			// Use the original URL from Supabase directly
+			fileURL, copyErr := copyToMediaStorage(c.Request.Context(), file.URL, sessionOrganization(sessionID))
+			if copyErr != nil {
+				fmt.Printf("[APPROVE] ❌ Failed to copy file %d to private storage: %v\n", i+1, copyErr)
+				fileURL = file.URL
+			}
 			_, insertErr := config.DB.Exec(`
 				INSERT INTO session_files (session_id, title, file_url, order_index)
 				VALUES (?, ?, ?, ?)
-			`, sessionID, file.Title, file.URL, i+1)
+			`, sessionID, file.Title, fileURL, i+1)
 			if insertErr != nil {
 				fmt.Printf("[APPROVE] Error inserting file %d: %v\n", i+1, insertErr)
 			} else {
-				fmt.Printf("[APPROVE] ✅ File %d inserted: %s\n", i+1, file.URL)
+				fmt.Printf("[APPROVE] ✅ File %d inserted: %s\n", i+1, fileURL)
 			}
 		}
 
+		// Video baru ditranscode ke HLS di background
+		go RunVideoTranscodeQueue()
+
 		// Update submission status
 		config.DB.Exec(`
 			UPDATE affiliate_submissions 
@@ -655,15 +669,23 @@ func UploadOfficialOrgLogo(c *gin.Context) {
 	filename := fmt.Sprintf("official_%d%s", time.Now().UnixNano(), filepath.Ext(fileHeader.Filename))
 	storagePath := "organization/" + filename
 
-	publicURL, err := utils.UploadFileHeaderToSupabase(storagePath, fileHeader)
+	var official struct {
+		ID      int64  `db:"id"`
+		LogoURL string `db:"logo_url"`
+	}
+	config.DB.Get(&official, `SELECT id, COALESCE(logo_url, '') AS logo_url FROM organizations WHERE is_official = 1 LIMIT 1`)
+	oldURL := official.LogoURL
+
+	publicURL, err := uploadFormFile(c, filecheck.Logo, storagePath, fileHeader, official.ID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal upload file"})
+		respondUploadError(c, err, "Gagal upload file")
 		return
 	}
 
 	config.DB.Exec(`UPDATE organizations SET logo_url = ? WHERE is_official = 1`, publicURL)
+	replaceStoredFile(c, oldURL, publicURL)
 
-	c.JSON(http.StatusOK, gin.H{"message": "Logo berhasil diupload", "logo_url": publicURL})
+	c.JSON(http.StatusOK, gin.H{"message": "Logo berhasil diupload", "logo_url": publicURL, "logo": attachImageVariants(c, publicURL, media.LogoVariants)})
 }
 
 // GetOfficialOrgEvents - Get all events under Official organization
@@ -833,9 +855,16 @@ func UploadOfficialOrgEventThumbnail(c *gin.Context) {
 	filename := fmt.Sprintf("%d_%s%s", time.Now().UnixNano(), eventID, filepath.Ext(fileHeader.Filename))
 	storagePath := "events/" + filename
 
-	publicURL, err := utils.UploadFileHeaderToSupabase(storagePath, fileHeader)
+	var event struct {
+		OrganizationID int64  `db:"organization_id"`
+		ThumbnailURL   string `db:"thumbnail_url"`
+	}
+	config.DB.Get(&event, `SELECT organization_id, COALESCE(thumbnail_url, '') AS thumbnail_url FROM events WHERE id = ?`, eventID)
+	oldURL := event.ThumbnailURL
+
+	publicURL, err := uploadFormFile(c, filecheck.Thumbnail, storagePath, fileHeader, event.OrganizationID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal upload thumbnail"})
+		respondUploadError(c, err, "Gagal upload thumbnail")
 		return
 	}
 
@@ -844,8 +873,9 @@ func UploadOfficialOrgEventThumbnail(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal update thumbnail"})
 		return
 	}
+	replaceStoredFile(c, oldURL, publicURL)
 
-	c.JSON(http.StatusOK, gin.H{"message": "Thumbnail berhasil diupdate", "thumbnail_url": publicURL})
+	c.JSON(http.StatusOK, gin.H{"message": "Thumbnail berhasil diupdate", "thumbnail_url": publicURL, "thumbnail": attachImageVariants(c, publicURL, media.EventThumbnailVariants)})
 }
 
 // UpdateOfficialOrgSession - Update session title, description, price
@@ -862,6 +892,9 @@ func UpdateOfficialOrgSession(c *gin.Context) {
 		return
 	}
 
+	var oldPrice int64
+	config.DB.Get(&oldPrice, "SELECT COALESCE(price, 0) FROM sessions WHERE id = ?", sessionID)
+
 	_, err := config.DB.Exec(`
 		UPDATE sessions 
 		SET title = ?, description = ?, price = ?
@@ -873,6 +906,10 @@ func UpdateOfficialOrgSession(c *gin.Context) {
 		return
 	}
 
+	if id, err := strconv.ParseInt(sessionID, 10, 64); err == nil {
+		go NotifyWishlistPriceDrop(id, oldPrice, input.Price)
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Session berhasil diupdate"})
 }
 
@@ -1185,16 +1222,17 @@ func UploadOfficialOrgSessionVideo(c *gin.Context) {
 	filename := fmt.Sprintf("official_%d_%s%s", time.Now().UnixNano(), sessionID, filepath.Ext(fileHeader.Filename))
 	storagePath := "videos/" + filename
 
-	publicURL, err := utils.UploadFileHeaderToSupabase(storagePath, fileHeader)
+	sid, _ := strconv.ParseInt(sessionID, 10, 64)
+	publicURL, size, err := uploadMediaFile(c, filecheck.Video, storagePath, fileHeader, sessionOrganization(sid))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal upload video"})
+		respondUploadError(c, err, "Gagal upload video")
 		return
 	}
 
 	result, err := config.DB.Exec(`
-		INSERT INTO session_videos (session_id, title, description, video_url)
-		VALUES (?, ?, ?, ?)
-	`, sessionID, title, description, publicURL)
+		INSERT INTO session_videos (session_id, title, description, video_url, size_bytes)
+		VALUES (?, ?, ?, ?, ?)
+	`, sessionID, title, description, publicURL, size)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal simpan ke database"})
@@ -1202,7 +1240,8 @@ func UploadOfficialOrgSessionVideo(c *gin.Context) {
 	}
 
 	videoID, _ := result.LastInsertId()
-	c.JSON(http.StatusCreated, gin.H{"message": "Video berhasil diupload", "video_id": videoID, "video_url": publicURL})
+	go RunVideoTranscodeQueue()
+	c.JSON(http.StatusCreated, gin.H{"message": "Video berhasil diupload", "video_id": videoID, "video_url": publicURL, "hls_status": "PENDING"})
 }
 
 // UploadOfficialOrgSessionFile - Upload file/module to session
@@ -1223,16 +1262,17 @@ func UploadOfficialOrgSessionFile(c *gin.Context) {
 	filename := fmt.Sprintf("official_%d_%s%s", time.Now().UnixNano(), sessionID, filepath.Ext(fileHeader.Filename))
 	storagePath := "files/" + filename
 
-	publicURL, err := utils.UploadFileHeaderToSupabase(storagePath, fileHeader)
+	sid, _ := strconv.ParseInt(sessionID, 10, 64)
+	publicURL, size, err := uploadMediaFile(c, filecheck.Document, storagePath, fileHeader, sessionOrganization(sid))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal upload file"})
+		respondUploadError(c, err, "Gagal upload file")
 		return
 	}
 
 	result, err := config.DB.Exec(`
-		INSERT INTO session_files (session_id, title, file_url)
-		VALUES (?, ?, ?)
-	`, sessionID, title, publicURL)
+		INSERT INTO session_files (session_id, title, file_url, size_bytes)
+		VALUES (?, ?, ?, ?)
+	`, sessionID, title, publicURL, size)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal simpan ke database"})
@@ -1256,11 +1296,12 @@ func GetOfficialOrgCertificateSettings(c *gin.Context) {
 		EventID         int64   `db:"event_id" json:"event_id"`
 		IsEnabled       bool    `db:"is_enabled" json:"is_enabled"`
 		MinScorePercent int     `db:"min_score_percent" json:"min_score_percent"`
+		MinWatchPercent int     `db:"min_watch_percent" json:"min_watch_percent"`
 		CertTitle       *string `db:"certificate_title" json:"certificate_title"`
 	}
 
 	err := config.DB.Get(&settings, `
-		SELECT id, event_id, is_enabled, min_score_percent, certificate_title
+		SELECT id, event_id, is_enabled, min_score_percent, min_watch_percent, certificate_title
 		FROM event_certificates WHERE event_id = ?
 	`, eventID)
 
@@ -1270,6 +1311,7 @@ func GetOfficialOrgCertificateSettings(c *gin.Context) {
 				"event_id":          eventID,
 				"is_enabled":        false,
 				"min_score_percent": 80,
+				"min_watch_percent": 0,
 				"certificate_title": nil,
 			},
 		})
@@ -1286,6 +1328,7 @@ func UpdateOfficialOrgCertificateSettings(c *gin.Context) {
 	var input struct {
 		IsEnabled       bool    `json:"is_enabled"`
 		MinScorePercent int     `json:"min_score_percent"`
+		MinWatchPercent *int    `json:"min_watch_percent"`
 		CertTitle       *string `json:"certificate_title"`
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -1296,14 +1339,19 @@ func UpdateOfficialOrgCertificateSettings(c *gin.Context) {
 	if input.MinScorePercent < 1 || input.MinScorePercent > 100 {
 		input.MinScorePercent = 80
 	}
+	if input.MinWatchPercent != nil && (*input.MinWatchPercent < 0 || *input.MinWatchPercent > 100) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Persentase menonton harus antara 0 dan 100"})
+		return
+	}
 
 	_, err := config.DB.Exec(`
-		INSERT INTO event_certificates (event_id, is_enabled, min_score_percent, certificate_title)
-		VALUES (?, ?, ?, ?)
+		INSERT INTO event_certificates (event_id, is_enabled, min_score_percent, min_watch_percent, certificate_title)
+		VALUES (?, ?, ?, COALESCE(?, 0), ?)
 		ON DUPLICATE KEY UPDATE is_enabled = VALUES(is_enabled), 
 		                        min_score_percent = VALUES(min_score_percent),
+		                        min_watch_percent = COALESCE(?, min_watch_percent),
 		                        certificate_title = VALUES(certificate_title)
-	`, eventID, input.IsEnabled, input.MinScorePercent, input.CertTitle)
+	`, eventID, input.IsEnabled, input.MinScorePercent, input.MinWatchPercent, input.CertTitle, input.MinWatchPercent)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan"})
@@ -5,11 +5,11 @@ import (
 	"net/http"
 	"path/filepath"
 	"strconv"
-	"strings"
 	"time"
 
 	"BACKEND/config"
-	"BACKEND/utils"
+	"BACKEND/filecheck"
+	"BACKEND/media"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -99,15 +99,15 @@ func CreateAdBanner(c *gin.Context) {
 		return
 	}
 
-	// Upload to Supabase
+	// Upload to storage
 	ext := filepath.Ext(fileHeader.Filename)
 	filename := fmt.Sprintf("ad_%d_%s%s", time.Now().UnixNano(), uuid.New().String()[:8], ext)
 	storagePath := "ads/" + filename
 
-	publicURL, err := utils.UploadFileHeaderToSupabase(storagePath, fileHeader)
+	publicURL, err := uploadFormFile(c, filecheck.AdBanner, storagePath, fileHeader, 0)
 	if err != nil {
-		fmt.Printf("❌ Supabase upload error: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal upload gambar"})
+		fmt.Printf("❌ Storage upload error: %v\n", err)
+		respondUploadError(c, err, "Gagal upload gambar")
 		return
 	}
 
@@ -134,6 +134,7 @@ func CreateAdBanner(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Banner berhasil dibuat",
 		"id":      adID,
+		"image":   attachImageVariants(c, publicURL, media.AdBannerVariants),
 	})
 }
 
@@ -165,9 +166,19 @@ func UpdateAdBanner(c *gin.Context) {
 		filename := fmt.Sprintf("ad_%d_%s%s", time.Now().UnixNano(), uuid.New().String()[:8], ext)
 		storagePath := "ads/" + filename
 
-		publicURL, uploadErr := utils.UploadFileHeaderToSupabase(storagePath, fileHeader)
+		publicURL, uploadErr := uploadFormFile(c, filecheck.AdBanner, storagePath, fileHeader, 0)
+		if isUploadRejected(uploadErr) {
+			respondUploadError(c, uploadErr, "Gagal upload gambar")
+			return
+		}
 		if uploadErr == nil {
-			config.DB.Exec("UPDATE ad_banners SET image_url = ? WHERE id = ?", publicURL, adID)
+			var oldURL string
+			config.DB.Get(&oldURL, "SELECT image_url FROM ad_banners WHERE id = ?", adID)
+
+			if _, err := config.DB.Exec("UPDATE ad_banners SET image_url = ? WHERE id = ?", publicURL, adID); err == nil {
+				replaceStoredFile(c, oldURL, publicURL)
+			}
+			attachImageVariants(c, publicURL, media.AdBannerVariants)
 		}
 	}
 
@@ -215,10 +226,8 @@ func DeleteAdBanner(c *gin.Context) {
 		return
 	}
 
-	// Remove image from Supabase
-	if imagePath != "" && strings.Contains(imagePath, "supabase") {
-		utils.DeleteFromSupabase(utils.GetStoragePathFromURL(imagePath))
-	}
+	// Remove image from storage
+	deleteStoredFile(c, imagePath)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Banner berhasil dihapus"})
 }
@@ -255,6 +264,8 @@ func GetPublicAds(c *gin.Context) {
 		TargetURL  *string `db:"target_url" json:"target_url"`
 		Placement  string  `db:"placement" json:"placement"`
 		OrderIndex int     `db:"order_index" json:"order_index"`
+
+		Image *ImageInfo `db:"-" json:"image"`
 	}
 
 	err := config.DB.Select(&ads, query, args...)
@@ -271,8 +282,20 @@ func GetPublicAds(c *gin.Context) {
 			TargetURL  *string `db:"target_url" json:"target_url"`
 			Placement  string  `db:"placement" json:"placement"`
 			OrderIndex int     `db:"order_index" json:"order_index"`
+
+			Image *ImageInfo `db:"-" json:"image"`
 		}{}
 	}
 
+	// Ukuran banner standar untuk frontend
+	urls := make([]string, len(ads))
+	for i := range ads {
+		urls[i] = ads[i].ImageURL
+	}
+	images := loadImageInfos(urls...)
+	for i := range ads {
+		ads[i].Image = images[ads[i].ImageURL]
+	}
+
 	c.JSON(http.StatusOK, ads)
 }
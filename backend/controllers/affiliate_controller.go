@@ -2,9 +2,9 @@ package controllers
 
 import (
 	"BACKEND/config"
+	"BACKEND/filecheck"
 	"fmt"
 	"net/http"
-	"os"
 	"path/filepath"
 	"strconv"
 	"time"
@@ -201,12 +201,13 @@ func SubmitAffiliateEvent(c *gin.Context) {
 	var posterURL string
 	posterFile, err := c.FormFile("poster")
 	if err == nil {
-		os.MkdirAll("uploads/posters", os.ModePerm)
 		posterFilename := fmt.Sprintf("%d_%s%s", time.Now().UnixNano(), uuid.New().String()[:8], filepath.Ext(posterFile.Filename))
-		posterPath := filepath.Join("uploads/posters", posterFilename)
-		if err := c.SaveUploadedFile(posterFile, posterPath); err == nil {
-			posterURL = posterPath
+		url, err := uploadFormFile(c, filecheck.Thumbnail, "posters/"+posterFilename, posterFile, 0)
+		if isUploadRejected(err) {
+			respondUploadError(c, err, "Gagal upload poster")
+			return
 		}
+		posterURL = url
 	}
 
 	// Handle multiple video uploads (max 3)
@@ -217,8 +218,6 @@ func SubmitAffiliateEvent(c *gin.Context) {
 	}
 	var uploadedVideos []uploadedMaterial
 	form, _ := c.MultipartForm()
-	os.MkdirAll("uploads/affiliate_videos", os.ModePerm)
-	os.MkdirAll("uploads/affiliate_files", os.ModePerm)
 
 	if form != nil {
 		videoFiles := form.File["videos"]
@@ -230,8 +229,12 @@ func SubmitAffiliateEvent(c *gin.Context) {
 				break // Max 3 videos
 			}
 			videoFilename := fmt.Sprintf("%d_%s%s", time.Now().UnixNano(), uuid.New().String()[:8], filepath.Ext(videoFile.Filename))
-			videoPath := filepath.Join("uploads/affiliate_videos", videoFilename)
-			if err := c.SaveUploadedFile(videoFile, videoPath); err == nil {
+			videoPath, err := uploadFormFile(c, filecheck.Video, "affiliate_videos/"+videoFilename, videoFile, 0)
+			if isUploadRejected(err) {
+				respondUploadError(c, err, "Gagal upload video")
+				return
+			}
+			if err == nil {
 				title := ""
 				if i < len(videoTitles) {
 					title = videoTitles[i]
@@ -260,8 +263,12 @@ func SubmitAffiliateEvent(c *gin.Context) {
 				break // Max 3 files
 			}
 			moduleFilename := fmt.Sprintf("%d_%s%s", time.Now().UnixNano(), uuid.New().String()[:8], filepath.Ext(moduleFile.Filename))
-			modulePath := filepath.Join("uploads/affiliate_files", moduleFilename)
-			if err := c.SaveUploadedFile(moduleFile, modulePath); err == nil {
+			modulePath, err := uploadFormFile(c, filecheck.Document, "affiliate_files/"+moduleFilename, moduleFile, 0)
+			if isUploadRejected(err) {
+				respondUploadError(c, err, "Gagal upload file")
+				return
+			}
+			if err == nil {
 				title := ""
 				if i < len(fileTitles) {
 					title = fileTitles[i]
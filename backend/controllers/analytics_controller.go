@@ -43,6 +43,13 @@ func GetAdminAnalytics(c *gin.Context) {
 		Commission float64 `db:"commission" json:"commission"`
 	}
 
+	type PaymentMethodStat struct {
+		Method  string  `db:"method" json:"method"`
+		Orders  int     `db:"orders" json:"orders"`
+		Revenue float64 `db:"revenue" json:"revenue"`
+		Fees    float64 `db:"fees" json:"fees"`
+	}
+
 	type WithdrawalItem struct {
 		ID            int64     `db:"id" json:"id"`
 		RequesterName string    `db:"requester_name" json:"requester_name"`
@@ -61,6 +68,18 @@ func GetAdminAnalytics(c *gin.Context) {
 	var totalTransactions int
 	config.DB.Get(&totalTransactions, `SELECT COUNT(*) FROM purchases WHERE status = 'PAID'`)
 
+	var platformFees struct {
+		PlatformFee float64 `db:"platform_fee"`
+		TaxAmount   float64 `db:"tax_amount"`
+	}
+	config.DB.Get(&platformFees, `
+		SELECT COALESCE(SUM(platform_fee), 0) as platform_fee, COALESCE(SUM(tax_amount), 0) as tax_amount
+		FROM purchases WHERE status = 'PAID'
+	`)
+
+	var totalPaymentFee float64
+	config.DB.Get(&totalPaymentFee, `SELECT COALESCE(SUM(payment_fee),0) FROM purchases WHERE status = 'PAID'`)
+
 	var totalUsers int
 	config.DB.Get(&totalUsers, `SELECT COUNT(*) FROM users`)
 
@@ -131,6 +150,23 @@ func GetAdminAnalytics(c *gin.Context) {
 		topAffiliates = []TopAffiliate{}
 	}
 
+	// Paid orders by payment method
+	var paymentMethods []PaymentMethodStat
+	config.DB.Select(&paymentMethods, `
+		SELECT
+			COALESCE(payment_method, 'unknown') as method,
+			COUNT(DISTINCT COALESCE(order_id, id)) as orders,
+			COALESCE(SUM(price_paid), 0) as revenue,
+			COALESCE(SUM(payment_fee), 0) as fees
+		FROM purchases
+		WHERE status = 'PAID'
+		GROUP BY COALESCE(payment_method, 'unknown')
+		ORDER BY revenue DESC
+	`)
+	if paymentMethods == nil {
+		paymentMethods = []PaymentMethodStat{}
+	}
+
 	// Recent Withdrawals (org + affiliate combined)
 	var recentWithdrawals []WithdrawalItem
 	config.DB.Select(&recentWithdrawals, `
@@ -156,12 +192,16 @@ func GetAdminAnalytics(c *gin.Context) {
 		"kpi": gin.H{
 			"total_revenue":      totalRevenue,
 			"total_transactions": totalTransactions,
+			"total_platform_fee": platformFees.PlatformFee,
+			"total_tax":          platformFees.TaxAmount,
+			"total_payment_fee":  totalPaymentFee,
 			"total_users":        totalUsers,
 			"total_orgs":         totalOrgs,
 		},
 		"top_events":         topEvents,
 		"top_orgs":           topOrgs,
 		"top_affiliates":     topAffiliates,
+		"payment_methods":    paymentMethods,
 		"recent_withdrawals": recentWithdrawals,
 	})
 }
@@ -212,8 +252,8 @@ func GetOrgAnalytics(c *gin.Context) {
 	config.DB.Get(&totalRevenue, `
 		SELECT COALESCE(SUM(p.price_paid), 0)
 		FROM purchases p
-		JOIN sessions s ON p.session_id = s.id
-		JOIN events e ON s.event_id = e.id
+		LEFT JOIN sessions s ON p.session_id = s.id
+		JOIN events e ON e.id = COALESCE(p.event_id, s.event_id)
 		WHERE e.organization_id = ? AND p.status = 'PAID'
 	`, orgID)
 
@@ -221,8 +261,8 @@ func GetOrgAnalytics(c *gin.Context) {
 	config.DB.Get(&totalBuyers, `
 		SELECT COUNT(DISTINCT p.user_id)
 		FROM purchases p
-		JOIN sessions s ON p.session_id = s.id
-		JOIN events e ON s.event_id = e.id
+		LEFT JOIN sessions s ON p.session_id = s.id
+		JOIN events e ON e.id = COALESCE(p.event_id, s.event_id)
 		WHERE e.organization_id = ? AND p.status = 'PAID'
 	`, orgID)
 
@@ -230,8 +270,8 @@ func GetOrgAnalytics(c *gin.Context) {
 	config.DB.Get(&totalSessions, `
 		SELECT COUNT(p.id)
 		FROM purchases p
-		JOIN sessions s ON p.session_id = s.id
-		JOIN events e ON s.event_id = e.id
+		LEFT JOIN sessions s ON p.session_id = s.id
+		JOIN events e ON e.id = COALESCE(p.event_id, s.event_id)
 		WHERE e.organization_id = ? AND p.status = 'PAID'
 	`, orgID)
 
@@ -240,12 +280,34 @@ func GetOrgAnalytics(c *gin.Context) {
 		SELECT COALESCE(SUM(p.price_paid * ap.commission_percentage / 100), 0)
 		FROM purchases p
 		JOIN affiliate_partnerships ap ON p.affiliate_code = ap.unique_code
-		JOIN sessions s ON p.session_id = s.id
-		JOIN events e ON s.event_id = e.id
+		LEFT JOIN sessions s ON p.session_id = s.id
+		JOIN events e ON e.id = COALESCE(p.event_id, s.event_id)
 		WHERE e.organization_id = ? AND p.status = 'PAID' AND ap.organization_id = ?
 	`, orgID, orgID)
 
-	netRevenue := totalRevenue - totalCommission
+	var fees struct {
+		PlatformFee float64 `db:"platform_fee"`
+		TaxAmount   float64 `db:"tax_amount"`
+	}
+	config.DB.Get(&fees, `
+		SELECT COALESCE(SUM(p.platform_fee), 0) as platform_fee, COALESCE(SUM(p.tax_amount), 0) as tax_amount
+		FROM purchases p
+		LEFT JOIN sessions s ON p.session_id = s.id
+		JOIN events e ON e.id = COALESCE(p.event_id, s.event_id)
+		WHERE e.organization_id = ? AND p.status = 'PAID'
+	`, orgID)
+
+	netRevenue := totalRevenue - totalCommission - fees.PlatformFee - fees.TaxAmount
+
+	// Free claims (zero-amount orders) still count as buyers and sessions
+	var freeClaims int
+	config.DB.Get(&freeClaims, `
+		SELECT COUNT(p.id)
+		FROM purchases p
+		LEFT JOIN sessions s ON p.session_id = s.id
+		JOIN events e ON e.id = COALESCE(p.event_id, s.event_id)
+		WHERE e.organization_id = ? AND p.status = 'PAID' AND p.price_paid = 0
+	`, orgID)
 
 	// Top Events by Buyers
 	var topEvents []TopEvent
@@ -254,9 +316,9 @@ func GetOrgAnalytics(c *gin.Context) {
 			e.id, e.title,
 			COUNT(DISTINCT p.user_id) as buyers,
 			COALESCE(SUM(p.price_paid), 0) as revenue
-		FROM events e
-		JOIN sessions s ON s.event_id = e.id
-		JOIN purchases p ON p.session_id = s.id
+		FROM purchases p
+		LEFT JOIN sessions s ON p.session_id = s.id
+		JOIN events e ON e.id = COALESCE(p.event_id, s.event_id)
 		WHERE e.organization_id = ? AND p.status = 'PAID'
 		GROUP BY e.id, e.title
 		ORDER BY buyers DESC
@@ -317,7 +379,10 @@ func GetOrgAnalytics(c *gin.Context) {
 			"total_buyers":     totalBuyers,
 			"total_sessions":   totalSessions,
 			"total_commission": totalCommission,
+			"platform_fee":     fees.PlatformFee,
+			"tax_amount":       fees.TaxAmount,
 			"net_revenue":      netRevenue,
+			"free_claims":      freeClaims,
 		},
 		"top_events":         topEvents,
 		"top_affiliates":     topAffiliates,
@@ -419,7 +484,7 @@ func GetAffiliateAnalytics(c *gin.Context) {
 			p.id as purchase_id,
 			COALESCE(u.name, 'Unknown') as buyer_name,
 			e.title as event_title,
-			s.title as session_title,
+			COALESCE(s.title, 'Paket Lengkap') as session_title,
 			p.price_paid as amount,
 			COALESCE(p.price_paid * ap.commission_percentage / 100, 0) as commission,
 			p.affiliate_code as code,
@@ -427,8 +492,8 @@ func GetAffiliateAnalytics(c *gin.Context) {
 		FROM purchases p
 		JOIN affiliate_partnerships ap ON p.affiliate_code = ap.unique_code
 		JOIN users u ON p.user_id = u.id
-		JOIN sessions s ON p.session_id = s.id
-		JOIN events e ON s.event_id = e.id
+		LEFT JOIN sessions s ON p.session_id = s.id
+		JOIN events e ON e.id = COALESCE(p.event_id, s.event_id)
 		WHERE ap.user_id = ? AND p.status = 'PAID'
 		ORDER BY p.created_at DESC
 		LIMIT 20
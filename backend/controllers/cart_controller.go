@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"BACKEND/config"
+	"BACKEND/entitlements"
+	"BACKEND/helpers"
 
 	"github.com/gin-gonic/gin"
 )
@@ -28,69 +31,130 @@ func GetCart(c *gin.Context) {
 		cartID, _ = result.LastInsertId()
 	}
 
-	// Get affiliate code if applied
-	var affiliateCode *string
-	config.DB.Get(&affiliateCode, "SELECT affiliate_code FROM carts WHERE id = ?", cartID)
+	// Get affiliate code and coupon if applied
+	var codes struct {
+		AffiliateCode *string `db:"affiliate_code"`
+		CouponCode    *string `db:"coupon_code"`
+	}
+	config.DB.Get(&codes, "SELECT affiliate_code, coupon_code FROM carts WHERE id = ?", cartID)
+
+	// Reprice and drop stale items before showing the cart
+	warnings := validateCart(cartID, userID)
 
 	// Get cart items with details
-	var items []struct {
-		ID           int64   `db:"id" json:"id"`
-		ItemType     string  `db:"item_type" json:"item_type"`
-		SessionID    *int64  `db:"session_id" json:"session_id"`
-		EventID      *int64  `db:"event_id" json:"event_id"`
-		Price        float64 `db:"price" json:"price"`
-		ItemTitle    string  `db:"item_title" json:"item_title"`
-		EventTitle   string  `db:"event_title" json:"event_title"`
-		ThumbnailURL *string `db:"thumbnail_url" json:"thumbnail_url"`
+	type CartItemView struct {
+		ID             int64   `db:"id" json:"id"`
+		ItemType       string  `db:"item_type" json:"item_type"`
+		SessionID      *int64  `db:"session_id" json:"session_id"`
+		EventID        *int64  `db:"event_id" json:"event_id"`
+		Price          int64   `db:"price" json:"price"`
+		Quantity       int     `db:"quantity" json:"quantity"`
+		IsGift         bool    `db:"is_gift" json:"is_gift"`
+		Recipients     *string `db:"recipient_emails" json:"recipient_emails"`
+		LineTotal      int64   `db:"-" json:"line_total"`
+		Discount       int64   `db:"-" json:"discount"`
+		ItemTitle      string  `db:"item_title" json:"item_title"`
+		EventTitle     string  `db:"event_title" json:"event_title"`
+		ThumbnailURL   *string `db:"thumbnail_url" json:"thumbnail_url"`
+		ItemEventID    int64   `db:"item_event_id" json:"-"`
+		OrganizationID int64   `db:"organization_id" json:"-"`
 	}
+	var items []CartItemView
 
 	config.DB.Select(&items, `
 		SELECT ci.id, ci.item_type, ci.session_id, ci.event_id, ci.price,
+			COALESCE(ci.quantity, 1) as quantity, COALESCE(ci.is_gift, 0) as is_gift, ci.recipient_emails,
 			CASE 
 				WHEN ci.item_type = 'SESSION' THEN s.title
-				ELSE CONCAT(e.title, ' (Full Package)')
+				WHEN ci.item_type = 'LICENSE_POOL' THEN lp.name
+				ELSE COALESCE(ep.title, CONCAT(e.title, ' (Paket Lengkap)'))
 			END as item_title,
 			e.title as event_title,
-			e.thumbnail_url
+			e.thumbnail_url,
+			COALESCE(e.id, 0) as item_event_id,
+			COALESCE(e.organization_id, 0) as organization_id
 		FROM cart_items ci
 		LEFT JOIN sessions s ON ci.session_id = s.id
 		LEFT JOIN events e ON COALESCE(ci.event_id, s.event_id) = e.id
+		LEFT JOIN event_packages ep ON ci.item_type = 'EVENT_PACKAGE' AND ep.event_id = ci.event_id
+		LEFT JOIN license_pools lp ON ci.license_pool_id = lp.id
 		WHERE ci.cart_id = ?
 		ORDER BY ci.added_at DESC
 	`, cartID)
 
-	// Calculate total
-	var total float64
-	for _, item := range items {
-		total += item.Price
+	// Calculate total (gift lines pay for every seat)
+	var total int64
+	for i := range items {
+		items[i].LineTotal = items[i].Price * int64(items[i].Quantity)
+		total += items[i].LineTotal
+	}
+
+	// Re-validate the coupon against the current cart content
+	var discountTotal int64
+	var couponError string
+	if codes.CouponCode != nil && len(items) > 0 {
+		lines := make([]couponLine, len(items))
+		for i, item := range items {
+			lines[i] = couponLine{
+				ItemType:       item.ItemType,
+				SessionID:      item.SessionID,
+				EventID:        item.ItemEventID,
+				OrganizationID: item.OrganizationID,
+				Price:          item.LineTotal,
+			}
+		}
+
+		result, err := evaluateCoupon(userID, *codes.CouponCode, lines)
+		if err != nil {
+			couponError = err.Error()
+		} else {
+			for i := range items {
+				items[i].Discount = result.ItemDiscounts[i]
+			}
+			discountTotal = result.TotalDiscount
+		}
 	}
 
 	if items == nil {
-		items = []struct {
-			ID           int64   `db:"id" json:"id"`
-			ItemType     string  `db:"item_type" json:"item_type"`
-			SessionID    *int64  `db:"session_id" json:"session_id"`
-			EventID      *int64  `db:"event_id" json:"event_id"`
-			Price        float64 `db:"price" json:"price"`
-			ItemTitle    string  `db:"item_title" json:"item_title"`
-			EventTitle   string  `db:"event_title" json:"event_title"`
-			ThumbnailURL *string `db:"thumbnail_url" json:"thumbnail_url"`
-		}{}
+		items = []CartItemView{}
+	}
+
+	// Methods every organization in the cart accepts, with the surcharge on the final price
+	orgIDs := make([]int64, 0, len(items))
+	for _, item := range items {
+		orgIDs = append(orgIDs, item.OrganizationID)
 	}
+	paymentMethods := paymentMethodOptions(orderPaymentMethods(orgIDs), total-discountTotal)
+
+	// Carts can mix organizations, so only the buyer's ?currency= choice is converted
+	rates := loadExchangeRates()
+	finalDisplay := displayPrice(total-discountTotal, displayCurrency(c, "", rates), rates)
 
 	c.JSON(http.StatusOK, gin.H{
-		"cart_id":        cartID,
-		"items":          items,
-		"total_price":    total,
-		"item_count":     len(items),
-		"affiliate_code": affiliateCode,
+		"cart_id":             cartID,
+		"items":               items,
+		"total_price":         total,
+		"discount_total":      discountTotal,
+		"final_price":         total - discountTotal,
+		"item_count":          len(items),
+		"affiliate_code":      codes.AffiliateCode,
+		"coupon_code":         codes.CouponCode,
+		"coupon_error":        couponError,
+		"warnings":            warnings,
+		"payment_methods":     paymentMethods,
+		"final_price_display": finalDisplay,
+		"currency":            helpers.BaseCurrency,
 	})
 }
 
 // AddToCartInput represents add to cart request
 type AddToCartInput struct {
-	SessionID *int64 `json:"session_id"` // For single session
-	EventID   *int64 `json:"event_id"`   // For event package (all sessions)
+	SessionID       *int64   `json:"session_id"`       // For single session
+	EventID         *int64   `json:"event_id"`         // For event package (all sessions)
+	IsGift          bool     `json:"is_gift"`          // Buy seats for others (redeemable gift codes)
+	Quantity        int      `json:"quantity"`         // Number of gifted seats
+	RecipientEmails []string `json:"recipient_emails"` // Optional, each email gets one code as an invite
+	LicensePoolID   *int64   `json:"license_pool_id"`  // Pay for a license pool offered by an organization
 }
 
 // AddToCart - Add session or event package to cart
@@ -104,11 +168,34 @@ func AddToCart(c *gin.Context) {
 		return
 	}
 
-	if input.SessionID == nil && input.EventID == nil {
+	if input.SessionID == nil && input.EventID == nil && input.LicensePoolID == nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "session_id atau event_id diperlukan"})
 		return
 	}
 
+	// Own purchases are always one seat; gifts can buy several, at least one per recipient
+	quantity := 1
+	var recipientEmails *string
+	if input.IsGift {
+		emails, err := helpers.CleanRecipientEmails(input.RecipientEmails)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		quantity = input.Quantity
+		if quantity < len(emails) {
+			quantity = len(emails)
+		}
+		if quantity < 1 || quantity > helpers.MaxGiftQuantity {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Jumlah hadiah harus antara 1 dan %d", helpers.MaxGiftQuantity)})
+			return
+		}
+		if len(emails) > 0 {
+			joined := strings.Join(emails, ",")
+			recipientEmails = &joined
+		}
+	}
+
 	// Get or create cart
 	var cartID int64
 	err := config.DB.Get(&cartID, "SELECT id FROM carts WHERE user_id = ?", userID)
@@ -117,13 +204,18 @@ func AddToCart(c *gin.Context) {
 		cartID, _ = result.LastInsertId()
 	}
 
+	if input.LicensePoolID != nil {
+		addLicensePoolToCart(c, userID, cartID, *input.LicensePoolID)
+		return
+	}
+
 	if input.SessionID != nil {
 		// Add single session
 		var session struct {
-			ID            int64   `db:"id"`
-			Price         float64 `db:"price"`
-			EventID       int64   `db:"event_id"`
-			PublishStatus string  `db:"publish_status"`
+			ID            int64  `db:"id"`
+			Price         int64  `db:"price"`
+			EventID       int64  `db:"event_id"`
+			PublishStatus string `db:"publish_status"`
 		}
 		err := config.DB.Get(&session, "SELECT id, price, event_id, publish_status FROM sessions WHERE id = ?", *input.SessionID)
 		if err != nil {
@@ -135,63 +227,73 @@ func AddToCart(c *gin.Context) {
 			return
 		}
 
-		// Check if already purchased
-		var purchased int
-		config.DB.Get(&purchased, "SELECT COUNT(*) FROM purchases WHERE user_id = ? AND session_id = ? AND status = 'PAID'", userID, *input.SessionID)
-		if purchased > 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Anda sudah membeli sesi ini"})
+		// Check if already owned (bought directly or through the event package)
+		if !input.IsGift && entitlements.HasAccess(userID, *input.SessionID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Anda sudah memiliki akses ke sesi ini"})
 			return
 		}
 
-		// Check if already in cart
+		// Check if already in cart (a gift line just gets its seats updated)
 		var inCart int
-		config.DB.Get(&inCart, "SELECT COUNT(*) FROM cart_items WHERE cart_id = ? AND session_id = ?", cartID, *input.SessionID)
-		if inCart > 0 {
+		config.DB.Get(&inCart, "SELECT COUNT(*) FROM cart_items WHERE cart_id = ? AND session_id = ? AND COALESCE(is_gift, 0) = ?", cartID, *input.SessionID, input.IsGift)
+		if inCart > 0 && !input.IsGift {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Sesi sudah ada di keranjang"})
 			return
 		}
+		if inCart > 0 {
+			config.DB.Exec("UPDATE cart_items SET quantity = ?, recipient_emails = ? WHERE cart_id = ? AND session_id = ? AND is_gift = 1", quantity, recipientEmails, cartID, *input.SessionID)
+			c.JSON(http.StatusOK, gin.H{"message": "Jumlah hadiah diperbarui"})
+			return
+		}
 
 		// Add to cart
 		_, err = config.DB.Exec(`
-			INSERT INTO cart_items (cart_id, item_type, session_id, price)
-			VALUES (?, 'SESSION', ?, ?)
-		`, cartID, *input.SessionID, session.Price)
+			INSERT INTO cart_items (cart_id, item_type, session_id, price, quantity, is_gift, recipient_emails)
+			VALUES (?, 'SESSION', ?, ?, ?, ?, ?)
+		`, cartID, *input.SessionID, session.Price, quantity, input.IsGift, recipientEmails)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menambahkan ke keranjang"})
 			return
 		}
 
 	} else if input.EventID != nil {
-		// Add event package (all sessions)
-		var event struct {
-			ID           int64    `db:"id"`
-			PackagePrice *float64 `db:"package_price"`
-			Title        string   `db:"title"`
-		}
-		err := config.DB.Get(&event, "SELECT id, package_price, title FROM events WHERE id = ? AND publish_status = 'PUBLISHED'", *input.EventID)
-		if err != nil {
+		// Add event package (all sessions, including ones added later)
+		var eventCount int
+		config.DB.Get(&eventCount, "SELECT COUNT(*) FROM events WHERE id = ? AND publish_status = 'PUBLISHED'", *input.EventID)
+		if eventCount == 0 {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Event tidak ditemukan"})
 			return
 		}
 
-		if event.PackagePrice == nil || *event.PackagePrice <= 0 {
+		pkg, err := getActiveEventPackage(*input.EventID)
+		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Event ini tidak memiliki paket bundling"})
 			return
 		}
 
-		// Check if already in cart
+		if !input.IsGift && entitlements.HasEventAccess(userID, *input.EventID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Anda sudah memiliki paket event ini"})
+			return
+		}
+
+		// Check if already in cart (a gift line just gets its seats updated)
 		var inCart int
-		config.DB.Get(&inCart, "SELECT COUNT(*) FROM cart_items WHERE cart_id = ? AND event_id = ? AND item_type = 'EVENT_PACKAGE'", cartID, *input.EventID)
-		if inCart > 0 {
+		config.DB.Get(&inCart, "SELECT COUNT(*) FROM cart_items WHERE cart_id = ? AND event_id = ? AND item_type = 'EVENT_PACKAGE' AND COALESCE(is_gift, 0) = ?", cartID, *input.EventID, input.IsGift)
+		if inCart > 0 && !input.IsGift {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Paket event sudah ada di keranjang"})
 			return
 		}
+		if inCart > 0 {
+			config.DB.Exec("UPDATE cart_items SET quantity = ?, recipient_emails = ? WHERE cart_id = ? AND event_id = ? AND item_type = 'EVENT_PACKAGE' AND is_gift = 1", quantity, recipientEmails, cartID, *input.EventID)
+			c.JSON(http.StatusOK, gin.H{"message": "Jumlah hadiah diperbarui"})
+			return
+		}
 
 		// Add package to cart
 		_, err = config.DB.Exec(`
-			INSERT INTO cart_items (cart_id, item_type, event_id, price)
-			VALUES (?, 'EVENT_PACKAGE', ?, ?)
-		`, cartID, *input.EventID, *event.PackagePrice)
+			INSERT INTO cart_items (cart_id, item_type, event_id, price, quantity, is_gift, recipient_emails)
+			VALUES (?, 'EVENT_PACKAGE', ?, ?, ?, ?, ?)
+		`, cartID, *input.EventID, pkg.Price, quantity, input.IsGift, recipientEmails)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menambahkan ke keranjang"})
 			return
@@ -294,7 +396,7 @@ func ClearCart(c *gin.Context) {
 	userID := c.GetInt64("user_id")
 
 	config.DB.Exec("DELETE ci FROM cart_items ci JOIN carts c ON ci.cart_id = c.id WHERE c.user_id = ?", userID)
-	config.DB.Exec("UPDATE carts SET affiliate_code = NULL WHERE user_id = ?", userID)
+	config.DB.Exec("UPDATE carts SET affiliate_code = NULL, coupon_code = NULL WHERE user_id = ?", userID)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Keranjang dikosongkan"})
 }
@@ -308,3 +410,99 @@ func ClearAffiliateCode(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Kode affiliate dihapus"})
 }
+
+// ===============================================
+// CART VALIDATION
+// ===============================================
+
+// validateCart re-checks every cart item against the current catalog: items that are no
+// longer sold, already owned or covered by a package in the same cart are removed, and
+// changed prices are written back. The returned warnings describe what changed.
+func validateCart(cartID, userID int64) []helpers.CartWarning {
+	var rows []struct {
+		ID            int64   `db:"id"`
+		ItemType      string  `db:"item_type"`
+		SessionID     *int64  `db:"session_id"`
+		EventID       int64   `db:"item_event_id"`
+		IsGift        bool    `db:"is_gift"`
+		Title         string  `db:"item_title"`
+		Price         int64   `db:"price"`
+		SessionPrice  *int64  `db:"session_price"`
+		SessionStatus *string `db:"session_status"`
+		EventStatus   *string `db:"event_status"`
+		PackagePrice  *int64  `db:"package_price"`
+		PoolStatus    *string `db:"pool_status"`
+		PoolPrice     *int64  `db:"pool_price"`
+	}
+	err := config.DB.Select(&rows, `
+		SELECT ci.id, ci.item_type, ci.session_id, COALESCE(e.id, ci.event_id, 0) as item_event_id,
+			COALESCE(ci.is_gift, 0) as is_gift, ci.price,
+			COALESCE(CASE
+				WHEN ci.item_type = 'SESSION' THEN s.title
+				WHEN ci.item_type = 'LICENSE_POOL' THEN lp.name
+				ELSE COALESCE(ep.title, CONCAT(e.title, ' (Paket Lengkap)'))
+			END, 'Item') as item_title,
+			s.price as session_price, s.publish_status as session_status,
+			e.publish_status as event_status, ep.price as package_price,
+			lp.status as pool_status, lp.unit_price as pool_price
+		FROM cart_items ci
+		LEFT JOIN sessions s ON ci.session_id = s.id
+		LEFT JOIN events e ON COALESCE(ci.event_id, s.event_id) = e.id
+		LEFT JOIN event_packages ep ON ci.item_type = 'EVENT_PACKAGE' AND ep.event_id = ci.event_id AND ep.is_active = 1
+		LEFT JOIN license_pools lp ON ci.license_pool_id = lp.id
+		WHERE ci.cart_id = ?
+	`, cartID)
+	if err != nil {
+		fmt.Printf("[CART] ❌ Error loading cart %d for validation: %v\n", cartID, err)
+		return []helpers.CartWarning{}
+	}
+
+	lines := make([]helpers.CartLine, len(rows))
+	for i, r := range rows {
+		line := helpers.CartLine{
+			ItemID:    r.ID,
+			ItemType:  r.ItemType,
+			EventID:   r.EventID,
+			IsGift:    r.IsGift,
+			Title:     r.Title,
+			CartPrice: r.Price,
+		}
+		eventLive := r.EventStatus != nil && *r.EventStatus == "PUBLISHED"
+
+		switch r.ItemType {
+		case "SESSION":
+			if r.SessionID != nil {
+				line.SessionID = *r.SessionID
+			}
+			if eventLive && r.SessionStatus != nil && *r.SessionStatus == "PUBLISHED" {
+				line.CurrentPrice = r.SessionPrice
+				line.Owned = !r.IsGift && entitlements.HasAccess(userID, line.SessionID)
+			}
+		case "EVENT_PACKAGE":
+			if eventLive && r.PackagePrice != nil {
+				line.CurrentPrice = r.PackagePrice
+				line.Owned = !r.IsGift && entitlements.HasEventAccess(userID, r.EventID)
+			}
+		case "LICENSE_POOL":
+			// The negotiated seat price is fixed for the offer; only its status matters
+			if r.EventStatus != nil && r.PoolStatus != nil && *r.PoolStatus == "OFFERED" {
+				line.CurrentPrice = r.PoolPrice
+			}
+		}
+		lines[i] = line
+	}
+
+	warnings := helpers.ValidateCartLines(lines)
+	for _, w := range warnings {
+		if w.Removed {
+			config.DB.Exec("DELETE FROM cart_items WHERE id = ? AND cart_id = ?", w.ItemID, cartID)
+		} else {
+			config.DB.Exec("UPDATE cart_items SET price = ? WHERE id = ? AND cart_id = ?", *w.NewPrice, w.ItemID, cartID)
+		}
+	}
+	if len(warnings) > 0 {
+		fmt.Printf("[CART] ⚠️ Cart %d adjusted: %d item(s) repriced or removed\n", cartID, len(warnings))
+	}
+
+	return warnings
+}
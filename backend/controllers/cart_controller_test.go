@@ -8,6 +8,7 @@ import (
 	"BACKEND/test/testutils"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
 )
 
 // ================================
@@ -315,3 +316,123 @@ func TestApplyAffiliateCode_InvalidCode(t *testing.T) {
 		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
 	}
 }
+
+// ================================
+// COUPON TESTS
+// ================================
+
+func setupCouponCart(db *sqlx.DB) {
+	db.MustExec(`INSERT INTO users (id, name, email, password_hash) VALUES (1, 'Test User', 'test@test.com', 'hash')`)
+	db.MustExec(`INSERT INTO organizations (id, user_id, owner_user_id, name) VALUES (1, 1, 1, 'Test Org')`)
+	db.MustExec(`INSERT INTO events (id, organization_id, title, publish_status) VALUES (1, 1, 'Test Event', 'PUBLISHED')`)
+	db.MustExec(`INSERT INTO sessions (id, event_id, title, price, publish_status) VALUES (1, 1, 'Session 1', 100000, 'PUBLISHED')`)
+	db.MustExec(`INSERT INTO carts (id, user_id) VALUES (1, 1)`)
+	db.MustExec(`INSERT INTO cart_items (id, cart_id, item_type, session_id, price) VALUES (1, 1, 'SESSION', 1, 100000)`)
+}
+
+func TestApplyCoupon_Success(t *testing.T) {
+	db := test.SetupTestDB()
+	defer test.TeardownTestDB(db)
+
+	setupCouponCart(db)
+	db.MustExec(`INSERT INTO coupons (id, organization_id, code, discount_type, discount_value, scope) VALUES (1, 1, 'HEMAT20', 'PERCENT', 20, 'ORGANIZATION')`)
+
+	body := map[string]interface{}{
+		"code": "hemat20",
+	}
+
+	c, w := testutils.CreateTestContextWithUserAndBody(1, body)
+	ApplyCoupon(c)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	response := testutils.GetJSONResponse(w)
+	if response["discount"] != float64(20000) {
+		t.Errorf("Expected discount 20000, got %v", response["discount"])
+	}
+}
+
+func TestApplyCoupon_Expired(t *testing.T) {
+	db := test.SetupTestDB()
+	defer test.TeardownTestDB(db)
+
+	setupCouponCart(db)
+	db.MustExec(`INSERT INTO coupons (id, organization_id, code, discount_type, discount_value, scope, expires_at) VALUES (1, 1, 'LAMA10', 'PERCENT', 10, 'ORGANIZATION', '2020-01-01')`)
+
+	body := map[string]interface{}{
+		"code": "LAMA10",
+	}
+
+	c, w := testutils.CreateTestContextWithUserAndBody(1, body)
+	ApplyCoupon(c)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestApplyCoupon_UsageLimitReached(t *testing.T) {
+	db := test.SetupTestDB()
+	defer test.TeardownTestDB(db)
+
+	setupCouponCart(db)
+	db.MustExec(`INSERT INTO users (id, name, email, password_hash) VALUES (2, 'Other User', 'other@test.com', 'hash')`)
+	db.MustExec(`INSERT INTO coupons (id, organization_id, code, discount_type, discount_value, scope, usage_limit) VALUES (1, 1, 'SEKALI', 'FIXED', 10000, 'ORGANIZATION', 1)`)
+	db.MustExec(`INSERT INTO coupon_redemptions (coupon_id, user_id, order_id, discount_amount, status) VALUES (1, 2, 'CART-1-2-2', 10000, 'USED')`)
+
+	body := map[string]interface{}{
+		"code": "SEKALI",
+	}
+
+	c, w := testutils.CreateTestContextWithUserAndBody(1, body)
+	ApplyCoupon(c)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestApplyCoupon_MinCartTotal(t *testing.T) {
+	db := test.SetupTestDB()
+	defer test.TeardownTestDB(db)
+
+	setupCouponCart(db)
+	db.MustExec(`INSERT INTO coupons (id, organization_id, code, discount_type, discount_value, scope, min_cart_total) VALUES (1, 1, 'BELANJA', 'FIXED', 50000, 'ORGANIZATION', 500000)`)
+
+	body := map[string]interface{}{
+		"code": "BELANJA",
+	}
+
+	c, w := testutils.CreateTestContextWithUserAndBody(1, body)
+	ApplyCoupon(c)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestGetCart_WithCoupon(t *testing.T) {
+	db := test.SetupTestDB()
+	defer test.TeardownTestDB(db)
+
+	setupCouponCart(db)
+	db.MustExec(`INSERT INTO coupons (id, organization_id, code, discount_type, discount_value, scope, session_id) VALUES (1, 1, 'SESI1', 'FIXED', 25000, 'SESSION', 1)`)
+	db.MustExec(`UPDATE carts SET coupon_code = 'SESI1' WHERE id = 1`)
+
+	c, w := testutils.CreateTestContextWithUserID(1)
+	GetCart(c)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	response := testutils.GetJSONResponse(w)
+	if response["discount_total"] != float64(25000) {
+		t.Errorf("Expected discount_total 25000, got %v", response["discount_total"])
+	}
+	if response["final_price"] != float64(75000) {
+		t.Errorf("Expected final_price 75000, got %v", response["final_price"])
+	}
+}
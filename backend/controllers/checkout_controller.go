@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"BACKEND/config"
+	"BACKEND/entitlements"
+	"BACKEND/helpers"
 
 	"github.com/gin-gonic/gin"
 	"github.com/midtrans/midtrans-go"
@@ -23,17 +25,35 @@ import (
 func CheckoutCart(c *gin.Context) {
 	userID := c.GetInt64("user_id")
 
+	// The body is optional, older clients send none and get the first offered method
+	var input struct {
+		PaymentMethod string `json:"payment_method"`
+	}
+	c.ShouldBindJSON(&input)
+
 	// Get cart
 	var cart struct {
 		ID            int64   `db:"id"`
 		AffiliateCode *string `db:"affiliate_code"`
+		CouponCode    *string `db:"coupon_code"`
 	}
-	err := config.DB.Get(&cart, "SELECT id, affiliate_code FROM carts WHERE user_id = ?", userID)
+	err := config.DB.Get(&cart, "SELECT id, affiliate_code, coupon_code FROM carts WHERE user_id = ?", userID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Keranjang kosong"})
 		return
 	}
 
+	// Never charge a stale cart: if validation repriced or removed anything,
+	// stop so the buyer can review the new total first
+	if warnings := validateCart(cart.ID, userID); len(warnings) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":        "Isi keranjang berubah, periksa kembali sebelum checkout",
+			"cart_changed": true,
+			"warnings":     warnings,
+		})
+		return
+	}
+
 	// Validate affiliate code if present - check if still active and not expired
 	if cart.AffiliateCode != nil && *cart.AffiliateCode != "" {
 		var validCode struct {
@@ -57,29 +77,113 @@ func CheckoutCart(c *gin.Context) {
 
 	// Get cart items
 	var items []struct {
-		ID        int64   `db:"id"`
-		ItemType  string  `db:"item_type"`
-		SessionID *int64  `db:"session_id"`
-		EventID   *int64  `db:"event_id"`
-		Price     float64 `db:"price"`
+		ID             int64   `db:"id"`
+		ItemType       string  `db:"item_type"`
+		SessionID      *int64  `db:"session_id"`
+		EventID        *int64  `db:"event_id"`
+		Price          int64   `db:"price"`
+		Quantity       int     `db:"quantity"`
+		IsGift         bool    `db:"is_gift"`
+		Recipients     *string `db:"recipient_emails"`
+		LicensePoolID  *int64  `db:"license_pool_id"`
+		ItemEventID    int64   `db:"item_event_id"`
+		OrganizationID int64   `db:"organization_id"`
 	}
-	config.DB.Select(&items, "SELECT id, item_type, session_id, event_id, price FROM cart_items WHERE cart_id = ?", cart.ID)
+	config.DB.Select(&items, `
+		SELECT ci.id, ci.item_type, ci.session_id, ci.event_id, ci.price,
+			COALESCE(ci.quantity, 1) as quantity, COALESCE(ci.is_gift, 0) as is_gift, ci.recipient_emails, ci.license_pool_id,
+			COALESCE(e.id, 0) as item_event_id, COALESCE(e.organization_id, 0) as organization_id
+		FROM cart_items ci
+		LEFT JOIN sessions s ON ci.session_id = s.id
+		LEFT JOIN events e ON COALESCE(ci.event_id, s.event_id) = e.id
+		WHERE ci.cart_id = ?
+	`, cart.ID)
 
 	if len(items) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Keranjang kosong"})
 		return
 	}
 
-	// Calculate total
-	var total float64
-	for _, item := range items {
-		total += item.Price
+	// Calculate total (gift lines pay for every seat)
+	var total int64
+	lineTotals := make([]int64, len(items))
+	for i, item := range items {
+		lineTotals[i] = item.Price * int64(item.Quantity)
+		total += lineTotals[i]
+	}
+
+	// Validate coupon if present - unlike affiliate codes it changes the price,
+	// so an invalid coupon stops the checkout instead of being dropped silently
+	itemDiscounts := make([]int64, len(items))
+	var discountTotal int64
+	var coupon *couponResult
+	if cart.CouponCode != nil && *cart.CouponCode != "" {
+		lines := make([]couponLine, len(items))
+		for i, item := range items {
+			lines[i] = couponLine{
+				ItemType:       item.ItemType,
+				SessionID:      item.SessionID,
+				EventID:        item.ItemEventID,
+				OrganizationID: item.OrganizationID,
+				Price:          lineTotals[i],
+			}
+		}
+
+		result, err := evaluateCoupon(userID, *cart.CouponCode, lines)
+		if err != nil {
+			config.DB.Exec("UPDATE carts SET coupon_code = NULL WHERE id = ?", cart.ID)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":          err.Error(),
+				"coupon_invalid": true,
+			})
+			return
+		}
+		coupon = result
+		itemDiscounts = result.ItemDiscounts
+		discountTotal = result.TotalDiscount
 	}
 
-	if total < 100 {
+	// Zero-amount orders (free sessions or fully discounted carts) skip the payment gateway
+	payable := total - discountTotal
+	isFree := payable <= 0
+	if !isFree && payable < 100 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Total minimal Rp 100 untuk pembayaran"})
 		return
 	}
+	if isFree {
+		if msg := checkFreeClaimLimit(userID); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+	}
+
+	// Payment method (and its surcharge) must be one every organization in the order accepts
+	var method PaymentMethodConfig
+	var paymentFee int64
+	lineFees := make([]int64, len(items))
+	if !isFree {
+		var orgIDs []int64
+		for _, item := range items {
+			orgIDs = append(orgIDs, item.OrganizationID)
+		}
+		chosen, err := choosePaymentMethod(input.PaymentMethod, orgIDs)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		method = chosen
+		paymentFee = helpers.PaymentMethodFee(payable, method.FlatFee, method.PercentFee)
+
+		linePaid := make([]int64, len(items))
+		for i := range items {
+			linePaid[i] = lineTotals[i] - itemDiscounts[i]
+		}
+		lineFees = helpers.SplitMinor(paymentFee, linePaid)
+	}
+	var paymentMethod *string
+	if method.Code != "" {
+		paymentMethod = &method.Code
+	}
 
 	// Get user details
 	var user struct {
@@ -90,8 +194,8 @@ func CheckoutCart(c *gin.Context) {
 	}
 	config.DB.Get(&user, "SELECT name, email, COALESCE(phone, '') as phone, COALESCE(username, '') as username FROM users WHERE id = ?", userID)
 
-	// Check profile completeness
-	if user.Name == "" || user.Email == "" || user.Phone == "" || user.Username == "" {
+	// Check profile completeness (needed by the payment gateway, not for free orders)
+	if !isFree && (user.Name == "" || user.Email == "" || user.Phone == "" || user.Username == "") {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":              "Lengkapi profil Anda terlebih dahulu",
 			"profile_incomplete": true,
@@ -100,41 +204,166 @@ func CheckoutCart(c *gin.Context) {
 	}
 
 	// Generate order ID - save base order ID for database updates
-	baseOrderID := fmt.Sprintf("CART-%d-%d-%d", time.Now().Unix(), cart.ID, userID)
+	orderPrefix := "CART"
+	purchaseStatus := "PENDING"
+	if isFree {
+		orderPrefix = "FREE"
+		purchaseStatus = "PAID"
+	}
+	baseOrderID := fmt.Sprintf("%s-%d-%d-%d", orderPrefix, time.Now().Unix(), cart.ID, userID)
 	orderID := baseOrderID
 
 	// Start transaction
 	tx, _ := config.DB.Beginx()
 	defer tx.Rollback()
 
-	// Create purchases for each item with affiliate code
-	for _, item := range items {
-		if item.ItemType == "SESSION" && item.SessionID != nil {
-			// Single session purchase - include affiliate_code
+	var couponCode *string
+	if coupon != nil && discountTotal > 0 {
+		couponCode = &coupon.Coupon.Code
+	}
+
+	// Unpaid orders whose lines this checkout takes over
+	var replacedOrders []string
+
+	// Create purchases for each item with affiliate code and coupon discount
+	// (price_paid is the amount actually paid, after discount)
+	for i, item := range items {
+		if item.IsGift && item.SessionID != nil {
+			// Gift seats: one line for N seats, codes are issued once paid
 			_, err := tx.Exec(`
-				INSERT INTO purchases (user_id, session_id, price_paid, status, order_id, affiliate_code)
-				VALUES (?, ?, ?, 'PENDING', ?, ?)
-				ON DUPLICATE KEY UPDATE status = 'PENDING', order_id = ?, price_paid = ?, affiliate_code = ?
-			`, userID, *item.SessionID, item.Price, baseOrderID, cart.AffiliateCode, baseOrderID, item.Price, cart.AffiliateCode)
+				INSERT INTO purchases (user_id, session_id, price_paid, status, order_id, affiliate_code, coupon_code, discount_amount, is_gift, quantity, gift_recipients, payment_method, payment_fee)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, 1, ?, ?, ?, ?)
+			`, userID, *item.SessionID, lineTotals[i]-itemDiscounts[i], purchaseStatus, baseOrderID, cart.AffiliateCode, couponCode, itemDiscounts[i], item.Quantity, item.Recipients, paymentMethod, lineFees[i])
+			if err != nil {
+				fmt.Printf("[CHECKOUT] Error creating gift purchase: %v\n", err)
+			}
+		} else if item.ItemType == "SESSION" && item.SessionID != nil {
+			// Single session purchase - include affiliate_code. The upsert takes over
+			// the buyer's unpaid line of this session from an earlier checkout.
+			var previousOrder string
+			err := tx.Get(&previousOrder, `
+				SELECT order_id FROM purchases
+				WHERE user_id = ? AND session_id = ? AND COALESCE(is_gift, 0) = 0 AND status = 'PENDING' AND order_id <> ?
+				LIMIT 1
+			`, userID, *item.SessionID, baseOrderID)
+			if err == nil {
+				replacedOrders = append(replacedOrders, previousOrder)
+			}
+
+			pricePaid := item.Price - itemDiscounts[i]
+			_, err = tx.Exec(`
+				INSERT INTO purchases (user_id, session_id, price_paid, status, order_id, affiliate_code, coupon_code, discount_amount, payment_method, payment_fee)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+				ON DUPLICATE KEY UPDATE status = ?, order_id = ?, price_paid = ?, affiliate_code = ?, coupon_code = ?, discount_amount = ?,
+					payment_method = ?, payment_fee = ?, purchased_at = CURRENT_TIMESTAMP
+			`, userID, *item.SessionID, pricePaid, purchaseStatus, baseOrderID, cart.AffiliateCode, couponCode, itemDiscounts[i], paymentMethod, lineFees[i],
+				purchaseStatus, baseOrderID, pricePaid, cart.AffiliateCode, couponCode, itemDiscounts[i], paymentMethod, lineFees[i])
 			if err != nil {
 				fmt.Printf("[CHECKOUT] Error creating purchase: %v\n", err)
 			}
 		} else if item.ItemType == "EVENT_PACKAGE" && item.EventID != nil {
-			// Package = all sessions in event
-			var sessionIDs []int64
-			config.DB.Select(&sessionIDs, "SELECT id FROM sessions WHERE event_id = ? AND publish_status = 'PUBLISHED'", *item.EventID)
-
-			pricePerSession := item.Price / float64(len(sessionIDs))
-			for _, sessID := range sessionIDs {
+			// Package = one purchase line for the whole event (no session), so it also
+			// covers sessions added later and does not depend on how many exist now
+			pkg, err := getActiveEventPackage(*item.EventID)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Paket event sudah tidak dijual, hapus dari keranjang"})
+				return
+			}
+			// A package checked out again replaces the buyer's earlier unpaid line,
+			// so abandoned checkouts do not pile up as PENDING package rows
+			if !item.IsGift {
+				var previousOrders []string
+				tx.Select(&previousOrders, `
+					SELECT DISTINCT order_id FROM purchases
+					WHERE user_id = ? AND event_id = ? AND package_id IS NOT NULL AND session_id IS NULL
+						AND is_gift = 0 AND status = 'PENDING'
+				`, userID, *item.EventID)
+				replacedOrders = append(replacedOrders, previousOrders...)
 				tx.Exec(`
-					INSERT INTO purchases (user_id, session_id, price_paid, status, order_id, affiliate_code)
-					VALUES (?, ?, ?, 'PENDING', ?, ?)
-					ON DUPLICATE KEY UPDATE status = 'PENDING', order_id = ?, price_paid = ?, affiliate_code = ?
-				`, userID, sessID, pricePerSession, baseOrderID, cart.AffiliateCode, baseOrderID, pricePerSession, cart.AffiliateCode)
+					UPDATE purchases SET status = 'CANCELLED'
+					WHERE user_id = ? AND event_id = ? AND package_id IS NOT NULL AND session_id IS NULL
+						AND is_gift = 0 AND status = 'PENDING'
+				`, userID, *item.EventID)
+			}
+			pricePaid := lineTotals[i] - itemDiscounts[i]
+			_, err = tx.Exec(`
+				INSERT INTO purchases (user_id, session_id, package_id, event_id, price_paid, status, order_id, affiliate_code, coupon_code, discount_amount, is_gift, quantity, gift_recipients, payment_method, payment_fee)
+				VALUES (?, NULL, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			`, userID, pkg.ID, *item.EventID, pricePaid, purchaseStatus, baseOrderID, cart.AffiliateCode, couponCode, itemDiscounts[i], item.IsGift, item.Quantity, item.Recipients, paymentMethod, lineFees[i])
+			if err != nil {
+				fmt.Printf("[CHECKOUT] Error creating package purchase: %v\n", err)
+			}
+		} else if item.ItemType == "LICENSE_POOL" && item.LicensePoolID != nil {
+			// License pool = one event-level line for all seats; seats are assigned after payment
+			var status string
+			tx.Get(&status, "SELECT status FROM license_pools WHERE id = ? AND owner_user_id = ? FOR UPDATE", *item.LicensePoolID, userID)
+			if status != "OFFERED" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Lisensi sudah dibayar atau dibatalkan, hapus dari keranjang"})
+				return
+			}
+			_, err := tx.Exec(`
+				INSERT INTO purchases (user_id, session_id, event_id, license_pool_id, price_paid, status, order_id, affiliate_code, coupon_code, discount_amount, quantity, payment_method, payment_fee)
+				VALUES (?, NULL, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			`, userID, *item.EventID, *item.LicensePoolID, lineTotals[i]-itemDiscounts[i], purchaseStatus, baseOrderID, cart.AffiliateCode, couponCode, itemDiscounts[i], item.Quantity, paymentMethod, lineFees[i])
+			if err != nil {
+				fmt.Printf("[CHECKOUT] Error creating license pool purchase: %v\n", err)
 			}
+			tx.Exec("UPDATE license_pools SET order_id = ? WHERE id = ?", baseOrderID, *item.LicensePoolID)
 		}
 	}
 
+	// Replaced orders give their coupon use back before this one is reserved
+	if err := releaseReplacedCouponRedemptions(tx, replacedOrders); err != nil {
+		fmt.Printf("[CART-CHECKOUT] ❌ Error releasing coupons of replaced orders %v: %v\n", replacedOrders, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memproses checkout"})
+		return
+	}
+
+	// Hold the coupon quota until the order is paid or fails
+	if couponCode != nil {
+		if err := reserveCouponRedemption(tx, coupon.Coupon.ID, userID, baseOrderID, discountTotal); err != nil {
+			config.DB.Exec("UPDATE carts SET coupon_code = NULL WHERE id = ?", cart.ID)
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "coupon_invalid": true})
+			return
+		}
+	}
+
+	// Free order: access is granted right away, nothing to split or pay
+	if isFree {
+		if err := entitlements.GrantOrderAccess(tx, baseOrderID); err != nil {
+			fmt.Printf("[CART-CHECKOUT] ❌ %v\n", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memproses pesanan gratis"})
+			return
+		}
+		issueGiftCodes(tx, baseOrderID)
+		activateLicensePools(tx, baseOrderID)
+		markCouponRedemptionUsed(tx, baseOrderID)
+		tx.Exec("DELETE FROM cart_items WHERE cart_id = ?", cart.ID)
+		tx.Exec("UPDATE carts SET affiliate_code = NULL, coupon_code = NULL WHERE id = ?", cart.ID)
+
+		if err := tx.Commit(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memproses pesanan gratis"})
+			return
+		}
+
+		go notifyFreeOrder(userID, baseOrderID)
+		go sendGiftCodeEmails(baseOrderID)
+
+		fmt.Printf("[CART-CHECKOUT] 🎁 Free order: %s, items=%d\n", baseOrderID, len(items))
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":     "Pesanan gratis berhasil, silakan akses konten Anda",
+			"free":        true,
+			"order_id":    baseOrderID,
+			"total":       0,
+			"subtotal":    total,
+			"discount":    discountTotal,
+			"coupon_code": couponCode,
+			"item_count":  len(items),
+		})
+		return
+	}
+
 	// Store affiliate code in order for later split payment (keep for backward compat)
 	if cart.AffiliateCode != nil {
 		orderID = fmt.Sprintf("%s-AFF-%s", baseOrderID, *cart.AffiliateCode)
@@ -148,10 +377,18 @@ func CheckoutCart(c *gin.Context) {
 			var title string
 			config.DB.Get(&title, "SELECT title FROM sessions WHERE id = ?", item.SessionID)
 			itemName = title
+		} else if item.ItemType == "LICENSE_POOL" {
+			var title string
+			config.DB.Get(&title, "SELECT name FROM license_pools WHERE id = ?", item.LicensePoolID)
+			itemName = "Lisensi: " + title
 		} else {
 			var title string
 			config.DB.Get(&title, "SELECT title FROM events WHERE id = ?", item.EventID)
-			itemName = fmt.Sprintf("%s (Package)", title)
+			pkg, _ := getActiveEventPackage(item.ItemEventID)
+			itemName = eventPackageTitle(pkg, title)
+		}
+		if item.IsGift {
+			itemName = "Hadiah: " + itemName
 		}
 		if len(itemName) > 50 {
 			itemName = itemName[:47] + "..."
@@ -160,7 +397,27 @@ func CheckoutCart(c *gin.Context) {
 		midtransItems = append(midtransItems, midtrans.ItemDetails{
 			ID:    strconv.Itoa(i + 1),
 			Name:  itemName,
-			Price: int64(item.Price),
+			Price: item.Price,
+			Qty:   int32(item.Quantity),
+		})
+	}
+
+	// Coupon discount as a negative line so item details still add up to the gross amount
+	if discountTotal > 0 {
+		midtransItems = append(midtransItems, midtrans.ItemDetails{
+			ID:    "DISCOUNT",
+			Name:  fmt.Sprintf("Diskon %s", *couponCode),
+			Price: -discountTotal,
+			Qty:   1,
+		})
+	}
+
+	// Method surcharge as its own line, paid on top of the order
+	if paymentFee > 0 {
+		midtransItems = append(midtransItems, midtrans.ItemDetails{
+			ID:    "PAYMENT_FEE",
+			Name:  "Biaya " + method.Label,
+			Price: paymentFee,
 			Qty:   1,
 		})
 	}
@@ -168,7 +425,7 @@ func CheckoutCart(c *gin.Context) {
 	snapReq := &snap.Request{
 		TransactionDetails: midtrans.TransactionDetails{
 			OrderID:  orderID,
-			GrossAmt: int64(total),
+			GrossAmt: midtransGrossAmount(midtransItems),
 		},
 		CustomerDetail: &midtrans.CustomerDetails{
 			FName: user.Name,
@@ -176,10 +433,8 @@ func CheckoutCart(c *gin.Context) {
 			Phone: user.Phone,
 		},
 		Items: &midtransItems,
-		EnabledPayments: []snap.SnapPaymentType{
-			"gopay",
-		},
 	}
+	applySnapPaymentMethod(snapReq, method)
 
 	snapResp, snapErr := config.SnapClient.CreateTransaction(snapReq)
 	if snapErr != nil {
@@ -200,7 +455,14 @@ func CheckoutCart(c *gin.Context) {
 		"redirect_url":      snapResp.RedirectURL,
 		"order_id":          baseOrderID, // For DB lookup
 		"midtrans_order_id": orderID,     // For Midtrans API check (includes affiliate code)
-		"total":             total,
+		"total":             payable + paymentFee,
+		"subtotal":          total,
+		"payment_method":    method.Code,
+		"payment_fee":       paymentFee,
+		"expiry_minutes":    method.ExpiryMinutes,
+		"currency":          helpers.BaseCurrency,
+		"discount":          discountTotal,
+		"coupon_code":       couponCode,
 		"item_count":        len(items),
 	})
 }
@@ -211,6 +473,7 @@ func CheckoutCart(c *gin.Context) {
 
 // ProcessCartPayment handles successful cart payment with split payments
 // Called from HandleMidtransNotification when order starts with "CART-"
+// (and from ProcessGuestPayment once a guest order has its purchases)
 func ProcessCartPayment(orderID string, grossAmount string) error {
 	fmt.Printf("[CART-PAYMENT] Processing order: %s, amount: %s\n", orderID, grossAmount)
 
@@ -228,31 +491,51 @@ func ProcessCartPayment(orderID string, grossAmount string) error {
 	tx, _ := config.DB.Beginx()
 	defer tx.Rollback()
 
-	// Update all purchases to PAID (use exact match since we store baseOrderID)
-	result, err := tx.Exec("UPDATE purchases SET status = 'PAID' WHERE order_id = ?", orderID)
+	// Update all purchases to PAID (use exact match since we store baseOrderID).
+	// Already-PAID rows are skipped so the webhook, status check and reconciler
+	// can all report the same payment without crediting wallets twice.
+	result, err := tx.Exec("UPDATE purchases SET status = 'PAID' WHERE order_id = ? AND status <> 'PAID'", orderID)
 	if err != nil {
 		return fmt.Errorf("failed to update purchases: %v", err)
 	}
 	rowsAffected, _ := result.RowsAffected()
 	fmt.Printf("[CART-PAYMENT] Updated %d purchases to PAID\n", rowsAffected)
+	if rowsAffected == 0 {
+		fmt.Printf("[CART-PAYMENT] Order %s already settled, skipping\n", orderID)
+		return nil
+	}
+
+	// Paid sessions become accessible through entitlements
+	if err := entitlements.GrantOrderAccess(tx, orderID); err != nil {
+		return err
+	}
+
+	// Gifted seats become redeemable codes, paid license pools become assignable
+	issueGiftCodes(tx, orderID)
+	activateLicensePools(tx, orderID)
+
+	// Coupon quota held by this order is now consumed
+	markCouponRedemptionUsed(tx, orderID)
 
 	// Get all purchases in this order - now include affiliate_code from purchases table!
 	var purchases []struct {
 		ID            int64   `db:"id"`
 		SessionID     int64   `db:"session_id"`
-		PricePaid     float64 `db:"price_paid"`
+		PackageID     *int64  `db:"package_id"`
+		LicensePoolID *int64  `db:"license_pool_id"`
+		PricePaid     int64   `db:"price_paid"`
 		EventID       int64   `db:"event_id"`
 		OrgID         int64   `db:"org_id"`
 		IsOfficial    bool    `db:"is_official"`
 		AffiliateCode *string `db:"affiliate_code"`
 	}
 	tx.Select(&purchases, `
-		SELECT p.id, p.session_id, p.price_paid, e.id as event_id, 
+		SELECT p.id, COALESCE(p.session_id, 0) as session_id, p.package_id, p.license_pool_id, p.price_paid, e.id as event_id, 
 			o.id as org_id, COALESCE(o.is_official, 0) as is_official,
 			p.affiliate_code
 		FROM purchases p
-		JOIN sessions s ON p.session_id = s.id
-		JOIN events e ON s.event_id = e.id
+		LEFT JOIN sessions s ON p.session_id = s.id
+		JOIN events e ON e.id = COALESCE(p.event_id, s.event_id)
 		JOIN organizations o ON e.organization_id = o.id
 		WHERE p.order_id = ?
 	`, orderID)
@@ -263,10 +546,27 @@ func ProcessCartPayment(orderID string, grossAmount string) error {
 	var buyerID int64
 	tx.Get(&buyerID, "SELECT user_id FROM purchases WHERE order_id = ? LIMIT 1", orderID)
 
+	// Fee settings are read once per order so every item uses the same rates
+	feeSettings := loadFeeSettings()
+
 	// Process each purchase with split payment
 	for _, purchase := range purchases {
+		item := fmt.Sprintf("session ID %d", purchase.SessionID)
+		if purchase.PackageID != nil {
+			// Package lines are split over the event's sessions for per-session reports
+			item = fmt.Sprintf("paket event ID %d", purchase.EventID)
+			allocatePackageRevenue(tx, purchase.ID, purchase.EventID, purchase.PricePaid)
+		} else if purchase.LicensePoolID != nil {
+			item = fmt.Sprintf("lisensi pool ID %d", *purchase.LicensePoolID)
+			allocatePackageRevenue(tx, purchase.ID, purchase.EventID, purchase.PricePaid)
+		}
+
 		if purchase.IsOfficial {
-			continue // Skip official org - no balance credit
+			// Official org is exempt from platform fee and gets no balance credit,
+			// but the tax portion is still recorded on the item
+			fb := helpers.CalculateFees(purchase.PricePaid, feeSettings.effectiveTaxPercent(), 0, 0)
+			recordPurchaseFees(tx, purchase.ID, item, orderID, fb)
+			continue
 		}
 
 		// Check if affiliate code is valid for this event
@@ -303,13 +603,21 @@ func ProcessCartPayment(orderID string, grossAmount string) error {
 			}
 		}
 
+		affiliatePct := 0.0
 		if hasAffiliate {
-			// Split payment: affiliate gets commission, org gets remainder
-			commission := purchase.PricePaid * (partnership.CommissionPercentage / 100)
-			orgAmount := purchase.PricePaid - commission
+			affiliatePct = partnership.CommissionPercentage
+		}
+
+		// Split: tax (PPN) -> platform fee -> affiliate commission -> org remainder
+		platformFeePct := getOrgPlatformFeePercent(tx, purchase.OrgID, feeSettings)
+		fb := helpers.CalculateFees(purchase.PricePaid, feeSettings.effectiveTaxPercent(), platformFeePct, affiliatePct)
+		recordPurchaseFees(tx, purchase.ID, item, orderID, fb)
 
-			fmt.Printf("[CART-PAYMENT] 💰 Splitting payment: total=%.0f, commission=%.0f (%.2f%%), org=%.0f\n",
-				purchase.PricePaid, commission, partnership.CommissionPercentage, orgAmount)
+		fmt.Printf("[CART-PAYMENT] 💰 Splitting payment: total=%d, tax=%d, platform=%d (%.2f%%), commission=%d (%.2f%%), org=%d\n",
+			fb.Gross, fb.TaxAmount, fb.PlatformFee, fb.PlatformFeePercent, fb.AffiliateCommission, fb.AffiliatePercent, fb.OrgAmount)
+
+		if hasAffiliate {
+			commission := fb.AffiliateCommission
 
 			// Credit affiliate balance
 			_, affErr := tx.Exec(`
@@ -323,53 +631,55 @@ func ProcessCartPayment(orderID string, grossAmount string) error {
 			if affErr != nil {
 				fmt.Printf("[CART-PAYMENT] ❌ Error crediting affiliate balance: %v\n", affErr)
 			} else {
-				fmt.Printf("[CART-PAYMENT] ✅ Credited Rp %.0f to affiliate user %d\n", commission, partnership.UserID)
+				fmt.Printf("[CART-PAYMENT] ✅ Credited Rp %d to affiliate user %d\n", commission, partnership.UserID)
 			}
 
 			// Record affiliate transaction
 			tx.Exec(`
 				INSERT INTO financial_transactions (transaction_type, entity_type, entity_id, amount, description, reference_id)
 				VALUES ('AFFILIATE_CREDIT', 'AFFILIATE', ?, ?, ?, ?)
-			`, partnership.UserID, commission, fmt.Sprintf("Komisi dari session ID %d", purchase.SessionID), orderID)
-
-			// Credit org balance (minus commission)
-			tx.Exec(`
-				INSERT INTO organization_balances (organization_id, balance, total_earned)
-				VALUES (?, ?, ?)
-				ON DUPLICATE KEY UPDATE 
-					balance = balance + ?,
-					total_earned = total_earned + ?
-			`, purchase.OrgID, orgAmount, orgAmount, orgAmount, orgAmount)
+			`, partnership.UserID, commission, fmt.Sprintf("Komisi dari %s", item), orderID)
 
 			// Notify affiliate
 			CreateNotification(
 				partnership.UserID,
 				"affiliate_sale",
 				"🛒 Penjualan dari Kode Promo!",
-				fmt.Sprintf("Anda mendapat komisi Rp %.0f dari penjualan", commission),
+				fmt.Sprintf("Anda mendapat komisi %s dari penjualan", helpers.FormatRupiah(commission)),
 			)
-
-		} else {
-			// No affiliate - full amount to org
-			tx.Exec(`
-				INSERT INTO organization_balances (organization_id, balance, total_earned)
-				VALUES (?, ?, ?)
-				ON DUPLICATE KEY UPDATE 
-					balance = balance + ?,
-					total_earned = total_earned + ?
-			`, purchase.OrgID, purchase.PricePaid, purchase.PricePaid, purchase.PricePaid, purchase.PricePaid)
 		}
 
+		// Credit org balance (after tax, platform fee and commission)
+		tx.Exec(`
+			INSERT INTO organization_balances (organization_id, balance, total_earned)
+			VALUES (?, ?, ?)
+			ON DUPLICATE KEY UPDATE 
+				balance = balance + ?,
+				total_earned = total_earned + ?
+		`, purchase.OrgID, fb.OrgAmount, fb.OrgAmount, fb.OrgAmount, fb.OrgAmount)
+
 		// Record org transaction
 		tx.Exec(`
 			INSERT INTO financial_transactions (transaction_type, entity_type, entity_id, amount, description, reference_id)
 			VALUES ('SALE', 'ORGANIZATION', ?, ?, ?, ?)
-		`, purchase.OrgID, purchase.PricePaid, fmt.Sprintf("Penjualan session ID %d", purchase.SessionID), orderID)
+		`, purchase.OrgID, purchase.PricePaid, fmt.Sprintf("Penjualan %s", item), orderID)
+	}
+
+	// Method surcharges are platform income, never part of the organization's share
+	var paymentFee int64
+	tx.Get(&paymentFee, "SELECT COALESCE(SUM(payment_fee), 0) FROM purchases WHERE order_id = ?", orderID)
+	if paymentFee > 0 {
+		tx.Exec(`
+			INSERT INTO financial_transactions (transaction_type, entity_type, entity_id, amount, description, reference_id)
+			VALUES ('PAYMENT_FEE', 'PLATFORM', 0, ?, ?, ?)
+		`, paymentFee, fmt.Sprintf("Biaya metode pembayaran pesanan %s", orderID), orderID)
 	}
 
-	// Clear cart
-	tx.Exec("DELETE ci FROM cart_items ci JOIN carts c ON ci.cart_id = c.id WHERE c.user_id = ?", buyerID)
-	tx.Exec("UPDATE carts SET affiliate_code = NULL WHERE user_id = ?", buyerID)
+	// Clear cart (guest orders never used the buyer's cart)
+	if strings.HasPrefix(orderID, "CART-") {
+		tx.Exec("DELETE ci FROM cart_items ci JOIN carts c ON ci.cart_id = c.id WHERE c.user_id = ?", buyerID)
+		tx.Exec("UPDATE carts SET affiliate_code = NULL, coupon_code = NULL WHERE user_id = ?", buyerID)
+	}
 
 	// Notify buyer
 	CreateNotification(
@@ -379,5 +689,13 @@ func ProcessCartPayment(orderID string, grossAmount string) error {
 		fmt.Sprintf("Pembelian %d item berhasil. Silakan akses konten Anda.", len(purchases)),
 	)
 
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// Invoice is issued and emailed once the order is committed as PAID
+	go sendInvoiceEmail(orderID)
+	go sendGiftCodeEmails(orderID)
+
+	return nil
 }
@@ -0,0 +1,658 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"BACKEND/config"
+	"BACKEND/helpers"
+	"BACKEND/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// ===============================================
+// COUPONS / DISCOUNT CODES
+// ===============================================
+
+const couponColumns = `
+	c.id, c.organization_id, c.code, c.description, c.discount_type, c.discount_value,
+	c.max_discount, c.scope, c.event_id, c.session_id, COALESCE(c.min_cart_total, 0) as min_cart_total,
+	c.usage_limit, c.per_user_limit, c.starts_at, c.expires_at,
+	COALESCE(c.is_active, 1) as is_active, c.created_at`
+
+// couponLine is one cart item as seen by coupon validation
+type couponLine struct {
+	ItemType       string `db:"item_type"`
+	SessionID      *int64 `db:"session_id"`
+	EventID        int64  `db:"event_id"`
+	OrganizationID int64  `db:"organization_id"`
+	Price          int64  `db:"price"`
+}
+
+// couponResult is the outcome of applying a coupon to a cart
+type couponResult struct {
+	Coupon        models.Coupon
+	ItemDiscounts []int64 // same order as the lines passed in
+	TotalDiscount int64
+}
+
+// couponAppliesTo checks whether a coupon covers a cart line
+func couponAppliesTo(coupon models.Coupon, line couponLine) bool {
+	// License pools are sold at a negotiated price, coupons never stack on top
+	if coupon.OrganizationID != line.OrganizationID || line.ItemType == "LICENSE_POOL" {
+		return false
+	}
+
+	switch coupon.Scope {
+	case "ORGANIZATION":
+		return true
+	case "EVENT":
+		return coupon.EventID != nil && *coupon.EventID == line.EventID
+	case "SESSION":
+		return line.ItemType == "SESSION" && line.SessionID != nil &&
+			coupon.SessionID != nil && *coupon.SessionID == *line.SessionID
+	}
+	return false
+}
+
+// evaluateCoupon validates a coupon code against a user's cart and computes the
+// discount for each line. The returned error message can be shown to the user.
+func evaluateCoupon(userID int64, code string, lines []couponLine) (*couponResult, error) {
+	code, ok := helpers.NormalizeCouponCode(code)
+	if !ok {
+		return nil, errors.New("Kode kupon tidak valid")
+	}
+
+	var coupon struct {
+		models.Coupon
+		NotStarted bool `db:"not_started"`
+		IsExpired  bool `db:"is_expired"`
+	}
+	err := config.DB.Get(&coupon, `
+		SELECT `+couponColumns+`,
+			CASE WHEN c.starts_at IS NOT NULL AND c.starts_at > NOW() THEN 1 ELSE 0 END as not_started,
+			CASE WHEN c.expires_at IS NOT NULL AND c.expires_at < NOW() THEN 1 ELSE 0 END as is_expired
+		FROM coupons c
+		WHERE c.code = ?
+	`, code)
+	if err != nil {
+		return nil, errors.New("Kode kupon tidak valid")
+	}
+
+	if !coupon.IsActive {
+		return nil, errors.New("Kode kupon sudah tidak aktif")
+	}
+	if coupon.NotStarted {
+		return nil, errors.New("Kode kupon belum berlaku")
+	}
+	if coupon.IsExpired {
+		return nil, errors.New("Kode kupon sudah kadaluarsa")
+	}
+
+	if err := checkCouponLimits(config.DB, coupon.Coupon, userID); err != nil {
+		return nil, err
+	}
+
+	var cartTotal int64
+	prices := make([]int64, len(lines))
+	eligible := make([]bool, len(lines))
+	hasEligible := false
+	for i, line := range lines {
+		cartTotal += line.Price
+		prices[i] = line.Price
+		eligible[i] = couponAppliesTo(coupon.Coupon, line)
+		if eligible[i] {
+			hasEligible = true
+		}
+	}
+
+	if !hasEligible {
+		return nil, errors.New("Kode kupon tidak berlaku untuk item di keranjang")
+	}
+	if cartTotal < coupon.MinCartTotal {
+		return nil, fmt.Errorf("Minimal belanja %s untuk kode kupon ini", helpers.FormatRupiah(coupon.MinCartTotal))
+	}
+
+	rule := helpers.CouponRule{
+		DiscountType:  coupon.DiscountType,
+		DiscountValue: coupon.DiscountValue,
+	}
+	if coupon.MaxDiscount != nil {
+		rule.MaxDiscount = *coupon.MaxDiscount
+	}
+
+	result := &couponResult{
+		Coupon:        coupon.Coupon,
+		ItemDiscounts: helpers.AllocateDiscount(prices, eligible, rule),
+	}
+	for _, d := range result.ItemDiscounts {
+		result.TotalDiscount += d
+	}
+
+	return result, nil
+}
+
+// checkCouponLimits counts paid orders and orders still waiting for payment
+// against the coupon's usage and per-user limits. Every unpaid order holds its
+// own redemption until it is paid, fails or expires, so opening several orders
+// with one coupon cannot exceed either limit.
+func checkCouponLimits(q sqlx.Queryer, coupon models.Coupon, userID int64) error {
+	if coupon.UsageLimit != nil {
+		var used int
+		err := sqlx.Get(q, &used, `
+			SELECT COUNT(*) FROM coupon_redemptions
+			WHERE coupon_id = ? AND status IN ('USED', 'PENDING')
+		`, coupon.ID)
+		if err != nil {
+			fmt.Printf("[COUPON] ❌ Error counting redemptions of coupon %d: %v\n", coupon.ID, err)
+			return errors.New("Gagal memeriksa kuota kode kupon")
+		}
+		if used >= *coupon.UsageLimit {
+			return errors.New("Kuota kode kupon sudah habis")
+		}
+	}
+
+	if coupon.PerUserLimit != nil {
+		var usedByUser int
+		err := sqlx.Get(q, &usedByUser, `
+			SELECT COUNT(*) FROM coupon_redemptions
+			WHERE coupon_id = ? AND user_id = ? AND status IN ('USED', 'PENDING')
+		`, coupon.ID, userID)
+		if err != nil {
+			fmt.Printf("[COUPON] ❌ Error counting redemptions of coupon %d by user %d: %v\n", coupon.ID, userID, err)
+			return errors.New("Gagal memeriksa kuota kode kupon")
+		}
+		if usedByUser >= *coupon.PerUserLimit {
+			return errors.New("Anda sudah mencapai batas pemakaian kode kupon ini (termasuk pesanan yang belum dibayar)")
+		}
+	}
+	return nil
+}
+
+// lockCoupon locks a coupon row so concurrent checkouts and settlements count
+// its redemptions one at a time
+func lockCoupon(tx *sqlx.Tx, couponID int64) (models.Coupon, error) {
+	var coupon models.Coupon
+	err := tx.Get(&coupon, `SELECT `+couponColumns+` FROM coupons c WHERE c.id = ? FOR UPDATE`, couponID)
+	return coupon, err
+}
+
+// reserveCouponRedemption records a pending coupon use for a new checkout. The
+// limits are checked again under the coupon lock, so two checkouts racing for
+// the last use cannot both get it.
+func reserveCouponRedemption(tx *sqlx.Tx, couponID, userID int64, orderID string, discount int64) error {
+	coupon, err := lockCoupon(tx, couponID)
+	if err != nil {
+		return errors.New("Kode kupon tidak valid")
+	}
+	if err := checkCouponLimits(tx, coupon, userID); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO coupon_redemptions (coupon_id, user_id, order_id, discount_amount, status)
+		VALUES (?, ?, ?, ?, 'PENDING')
+	`, couponID, userID, orderID, discount)
+	if err != nil {
+		fmt.Printf("[COUPON] ❌ Error reserving coupon %d for order %s: %v\n", couponID, orderID, err)
+		return errors.New("Gagal memakai kode kupon")
+	}
+	return nil
+}
+
+// markCouponRedemptionUsed confirms the coupon use once the order is paid.
+// A released (CANCELLED) redemption is revived too: the buyer may still pay
+// an order after it was expired locally, and the discount was honoured. Its
+// quota was given back meanwhile, so the limits are re-checked under the coupon
+// lock and an over-limit use is flagged for a discount refund.
+func markCouponRedemptionUsed(tx *sqlx.Tx, orderID string) {
+	var r struct {
+		ID       int64  `db:"id"`
+		CouponID int64  `db:"coupon_id"`
+		UserID   int64  `db:"user_id"`
+		Discount int64  `db:"discount_amount"`
+		Status   string `db:"status"`
+	}
+	err := tx.Get(&r, `
+		SELECT id, coupon_id, user_id, COALESCE(discount_amount, 0) AS discount_amount, status
+		FROM coupon_redemptions
+		WHERE order_id = ? AND status IN ('PENDING', 'CANCELLED')
+		LIMIT 1
+	`, orderID)
+	if err != nil {
+		return
+	}
+
+	if r.Status == "CANCELLED" {
+		coupon, err := lockCoupon(tx, r.CouponID)
+		if err == nil {
+			if limitErr := checkCouponLimits(tx, coupon, r.UserID); limitErr != nil {
+				fmt.Printf("[COUPON] ⚠️ Order %s paid with coupon %s over its limit\n", orderID, coupon.Code)
+				logReconcileResult(ReconcileResult{
+					OrderID:     orderID,
+					Action:      helpers.ReconcileReview,
+					Discrepancy: true,
+					Note: fmt.Sprintf("Kupon %s dibayar melebihi batas pemakaian (%s); diskon %s perlu direfund atau ditagihkan",
+						coupon.Code, limitErr.Error(), helpers.FormatRupiah(r.Discount)),
+				})
+			}
+		}
+	}
+
+	tx.Exec("UPDATE coupon_redemptions SET status = 'USED', used_at = NOW() WHERE id = ?", r.ID)
+}
+
+// releaseCouponRedemption frees the coupon quota held by an order that was not paid
+func releaseCouponRedemption(orderID string) {
+	config.DB.Exec(`
+		UPDATE coupon_redemptions SET status = 'CANCELLED'
+		WHERE order_id = ? AND status = 'PENDING'
+	`, orderID)
+}
+
+// releaseReplacedCouponRedemptions frees the coupon quota held by unpaid orders
+// whose lines a new checkout took over. Nothing settles those orders any more,
+// so their redemptions would otherwise stay PENDING and count against the limits.
+func releaseReplacedCouponRedemptions(tx *sqlx.Tx, orderIDs []string) error {
+	if len(orderIDs) == 0 {
+		return nil
+	}
+	query, args, err := sqlx.In(`
+		UPDATE coupon_redemptions SET status = 'CANCELLED'
+		WHERE order_id IN (?) AND status = 'PENDING'
+	`, orderIDs)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(tx.Rebind(query), args...)
+	return err
+}
+
+// ===============================================
+// USER: APPLY COUPON TO CART
+// ===============================================
+
+// ApplyCoupon - Apply organization coupon to cart
+// POST /user/cart/apply-coupon
+func ApplyCoupon(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	var input struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Kode kupon diperlukan"})
+		return
+	}
+
+	var cartID int64
+	config.DB.Get(&cartID, "SELECT id FROM carts WHERE user_id = ?", userID)
+	if cartID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Keranjang kosong"})
+		return
+	}
+
+	var lines []couponLine
+	config.DB.Select(&lines, `
+		SELECT ci.item_type, ci.session_id, e.id as event_id, e.organization_id, ci.price
+		FROM cart_items ci
+		LEFT JOIN sessions s ON ci.session_id = s.id
+		JOIN events e ON COALESCE(ci.event_id, s.event_id) = e.id
+		WHERE ci.cart_id = ?
+	`, cartID)
+	if len(lines) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Keranjang kosong"})
+		return
+	}
+
+	result, err := evaluateCoupon(userID, input.Code, lines)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	config.DB.Exec("UPDATE carts SET coupon_code = ? WHERE id = ?", result.Coupon.Code, cartID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  fmt.Sprintf("Kode kupon berhasil diterapkan (hemat %s)", helpers.FormatRupiah(result.TotalDiscount)),
+		"code":     result.Coupon.Code,
+		"discount": result.TotalDiscount,
+	})
+}
+
+// ClearCoupon - Remove coupon from cart (keep items)
+// DELETE /user/cart/clear-coupon OR POST /user/cart/clear-coupon
+func ClearCoupon(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	config.DB.Exec("UPDATE carts SET coupon_code = NULL WHERE user_id = ?", userID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Kode kupon dihapus"})
+}
+
+// ===============================================
+// ORGANIZATION: MANAGE COUPONS
+// ===============================================
+
+// CouponInput is the create/update payload for a coupon
+type CouponInput struct {
+	Code          string   `json:"code"`
+	Description   *string  `json:"description"`
+	DiscountType  string   `json:"discount_type"`  // PERCENT or FIXED
+	DiscountValue float64  `json:"discount_value"` // percent or rupiah
+	MaxDiscount   *float64 `json:"max_discount"`
+	Scope         string   `json:"scope"` // ORGANIZATION, EVENT or SESSION
+	EventID       *int64   `json:"event_id"`
+	SessionID     *int64   `json:"session_id"`
+	MinCartTotal  float64  `json:"min_cart_total"`
+	UsageLimit    *int     `json:"usage_limit"`
+	PerUserLimit  *int     `json:"per_user_limit"`
+	StartsAt      *string  `json:"starts_at"`  // Format: "2026-01-31" or "2026-01-31 08:00:00", empty = now
+	ExpiresAt     *string  `json:"expires_at"` // Format: "2026-01-31" or "2026-01-31 23:59:59", empty = never
+}
+
+// validateCouponInput normalizes the input and checks it against the organization's
+// events and sessions. Returns an error message, or "" when valid.
+func validateCouponInput(orgID int64, couponID int64, input *CouponInput) string {
+	code, ok := helpers.NormalizeCouponCode(input.Code)
+	if !ok {
+		return "Kode kupon harus 3-50 karakter (huruf, angka, - atau _)"
+	}
+	input.Code = code
+
+	var existing int
+	config.DB.Get(&existing, "SELECT COUNT(*) FROM coupons WHERE code = ? AND id != ?", code, couponID)
+	if existing > 0 {
+		return "Kode kupon sudah digunakan"
+	}
+
+	switch input.DiscountType {
+	case helpers.DiscountPercent:
+		if input.DiscountValue <= 0 || input.DiscountValue > 100 {
+			return "Diskon persen harus antara 1 - 100%"
+		}
+	case helpers.DiscountFixed:
+		if input.DiscountValue <= 0 {
+			return "Nominal diskon harus lebih dari 0"
+		}
+		input.MaxDiscount = nil
+	default:
+		return "Tipe diskon harus PERCENT atau FIXED"
+	}
+
+	if input.MaxDiscount != nil && *input.MaxDiscount <= 0 {
+		input.MaxDiscount = nil
+	}
+	if input.MinCartTotal < 0 {
+		return "Minimal belanja tidak boleh negatif"
+	}
+	if (input.DiscountType == helpers.DiscountFixed && !helpers.IsWholeRupiah(input.DiscountValue)) ||
+		(input.MaxDiscount != nil && !helpers.IsWholeRupiah(*input.MaxDiscount)) || !helpers.IsWholeRupiah(input.MinCartTotal) {
+		return "Nominal kupon harus dalam rupiah bulat"
+	}
+	if (input.UsageLimit != nil && *input.UsageLimit < 1) || (input.PerUserLimit != nil && *input.PerUserLimit < 1) {
+		return "Batas pemakaian minimal 1"
+	}
+
+	if input.Scope == "" {
+		input.Scope = "ORGANIZATION"
+	}
+	switch input.Scope {
+	case "ORGANIZATION":
+		input.EventID = nil
+		input.SessionID = nil
+	case "EVENT":
+		if input.EventID == nil {
+			return "event_id diperlukan untuk kupon event"
+		}
+		var count int
+		config.DB.Get(&count, "SELECT COUNT(*) FROM events WHERE id = ? AND organization_id = ?", *input.EventID, orgID)
+		if count == 0 {
+			return "Event tidak ditemukan"
+		}
+		input.SessionID = nil
+	case "SESSION":
+		if input.SessionID == nil {
+			return "session_id diperlukan untuk kupon sesi"
+		}
+		var eventID int64
+		err := config.DB.Get(&eventID, `
+			SELECT s.event_id FROM sessions s
+			JOIN events e ON s.event_id = e.id
+			WHERE s.id = ? AND e.organization_id = ?
+		`, *input.SessionID, orgID)
+		if err != nil {
+			return "Sesi tidak ditemukan"
+		}
+		input.EventID = &eventID
+	default:
+		return "Scope harus ORGANIZATION, EVENT atau SESSION"
+	}
+
+	if input.StartsAt != nil && *input.StartsAt == "" {
+		input.StartsAt = nil
+	}
+	if input.ExpiresAt != nil && *input.ExpiresAt == "" {
+		input.ExpiresAt = nil
+	}
+
+	return ""
+}
+
+// getOwnedCoupon loads a coupon and checks it belongs to the organization
+func getOwnedCoupon(c *gin.Context, orgID int64) (int64, bool) {
+	couponID, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	var ownerID int64
+	err := config.DB.Get(&ownerID, "SELECT organization_id FROM coupons WHERE id = ?", couponID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Kupon tidak ditemukan"})
+		return 0, false
+	}
+	if ownerID != orgID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Akses ditolak"})
+		return 0, false
+	}
+	return couponID, true
+}
+
+// GetOrgCoupons - List coupons of the organization with usage stats
+// GET /organization/coupons
+func GetOrgCoupons(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	orgID, err := getOrganizationIDByUser(userID)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Organisasi tidak ditemukan"})
+		return
+	}
+
+	type CouponWithStats struct {
+		models.Coupon
+		EventTitle    *string `db:"event_title" json:"event_title"`
+		SessionTitle  *string `db:"session_title" json:"session_title"`
+		UsedCount     int     `db:"used_count" json:"used_count"`
+		PendingCount  int     `db:"pending_count" json:"pending_count"`
+		TotalDiscount int64   `db:"total_discount" json:"total_discount"`
+	}
+
+	var coupons []CouponWithStats
+	err = config.DB.Select(&coupons, `
+		SELECT `+couponColumns+`,
+			e.title as event_title, s.title as session_title,
+			(SELECT COUNT(*) FROM coupon_redemptions r WHERE r.coupon_id = c.id AND r.status = 'USED') as used_count,
+			(SELECT COUNT(*) FROM coupon_redemptions r WHERE r.coupon_id = c.id AND r.status = 'PENDING') as pending_count,
+			(SELECT COALESCE(SUM(r.discount_amount), 0) FROM coupon_redemptions r WHERE r.coupon_id = c.id AND r.status = 'USED') as total_discount
+		FROM coupons c
+		LEFT JOIN events e ON c.event_id = e.id
+		LEFT JOIN sessions s ON c.session_id = s.id
+		WHERE c.organization_id = ?
+		ORDER BY c.created_at DESC
+	`, orgID)
+	if err != nil {
+		fmt.Printf("[COUPON] Error listing coupons: %v\n", err)
+	}
+	if coupons == nil {
+		coupons = []CouponWithStats{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"coupons": coupons})
+}
+
+// CreateCoupon - Create a new coupon
+// POST /organization/coupons
+func CreateCoupon(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	orgID, err := getOrganizationIDByUser(userID)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Organisasi tidak ditemukan"})
+		return
+	}
+
+	var input CouponInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid"})
+		return
+	}
+	if msg := validateCouponInput(orgID, 0, &input); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	result, err := config.DB.Exec(`
+		INSERT INTO coupons (organization_id, code, description, discount_type, discount_value, max_discount,
+			scope, event_id, session_id, min_cart_total, usage_limit, per_user_limit, starts_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, orgID, input.Code, input.Description, input.DiscountType, input.DiscountValue, input.MaxDiscount,
+		input.Scope, input.EventID, input.SessionID, input.MinCartTotal, input.UsageLimit, input.PerUserLimit,
+		input.StartsAt, input.ExpiresAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat kupon: " + err.Error()})
+		return
+	}
+
+	couponID, _ := result.LastInsertId()
+	fmt.Printf("[COUPON] ✅ Org %d created coupon %s (id=%d)\n", orgID, input.Code, couponID)
+
+	go notifyWishlistCoupon(couponID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Kupon berhasil dibuat",
+		"id":      couponID,
+		"code":    input.Code,
+	})
+}
+
+// UpdateCoupon - Update an existing coupon
+// PUT /organization/coupons/:id
+func UpdateCoupon(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	orgID, err := getOrganizationIDByUser(userID)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Organisasi tidak ditemukan"})
+		return
+	}
+
+	couponID, ok := getOwnedCoupon(c, orgID)
+	if !ok {
+		return
+	}
+
+	var input CouponInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid"})
+		return
+	}
+	if msg := validateCouponInput(orgID, couponID, &input); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	_, err = config.DB.Exec(`
+		UPDATE coupons
+		SET code = ?, description = ?, discount_type = ?, discount_value = ?, max_discount = ?,
+			scope = ?, event_id = ?, session_id = ?, min_cart_total = ?, usage_limit = ?, per_user_limit = ?,
+			starts_at = ?, expires_at = ?
+		WHERE id = ?
+	`, input.Code, input.Description, input.DiscountType, input.DiscountValue, input.MaxDiscount,
+		input.Scope, input.EventID, input.SessionID, input.MinCartTotal, input.UsageLimit, input.PerUserLimit,
+		input.StartsAt, input.ExpiresAt, couponID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal update: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Kupon berhasil diperbarui"})
+}
+
+// ToggleCouponActive - Activate or deactivate a coupon
+// PUT /organization/coupons/:id/toggle-active
+func ToggleCouponActive(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	orgID, err := getOrganizationIDByUser(userID)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Organisasi tidak ditemukan"})
+		return
+	}
+
+	couponID, ok := getOwnedCoupon(c, orgID)
+	if !ok {
+		return
+	}
+
+	config.DB.Exec("UPDATE coupons SET is_active = NOT COALESCE(is_active, 1) WHERE id = ?", couponID)
+
+	var isActive bool
+	config.DB.Get(&isActive, "SELECT COALESCE(is_active, 1) FROM coupons WHERE id = ?", couponID)
+
+	message := "Kupon dinonaktifkan"
+	if isActive {
+		message = "Kupon diaktifkan"
+		go notifyWishlistCoupon(couponID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   message,
+		"is_active": isActive,
+	})
+}
+
+// DeleteCoupon - Delete a coupon that has never been used
+// DELETE /organization/coupons/:id
+func DeleteCoupon(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	orgID, err := getOrganizationIDByUser(userID)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Organisasi tidak ditemukan"})
+		return
+	}
+
+	couponID, ok := getOwnedCoupon(c, orgID)
+	if !ok {
+		return
+	}
+
+	// Keep history of paid orders - used coupons can only be deactivated
+	var used int
+	config.DB.Get(&used, "SELECT COUNT(*) FROM coupon_redemptions WHERE coupon_id = ? AND status IN ('USED', 'PENDING')", couponID)
+	if used > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Kupon sudah pernah dipakai, nonaktifkan saja"})
+		return
+	}
+
+	_, err = config.DB.Exec("DELETE FROM coupons WHERE id = ?", couponID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus kupon"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Kupon berhasil dihapus"})
+}
@@ -0,0 +1,205 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"BACKEND/config"
+	"BACKEND/helpers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ===============================================
+// CURRENCIES & EXCHANGE RATES
+// ===============================================
+
+// PriceDisplay is a rupiah price converted for display; buyers are always charged in rupiah
+type PriceDisplay struct {
+	helpers.Money
+	Formatted string `json:"formatted"`
+}
+
+// ExchangeRate is the admin-maintained rate of one currency
+type ExchangeRate struct {
+	Currency      string    `db:"currency" json:"currency"`
+	RupiahPerUnit float64   `db:"rupiah_per_unit" json:"rupiah_per_unit"`
+	UpdatedBy     *int64    `db:"updated_by" json:"updated_by"`
+	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// loadExchangeRates returns rupiah per unit for every currency with a rate
+func loadExchangeRates() map[string]float64 {
+	var rows []ExchangeRate
+	config.DB.Select(&rows, "SELECT currency, rupiah_per_unit, updated_by, updated_at FROM exchange_rates")
+
+	rates := map[string]float64{helpers.BaseCurrency: 1}
+	for _, r := range rows {
+		if r.RupiahPerUnit > 0 {
+			rates[r.Currency] = r.RupiahPerUnit
+		}
+	}
+	return rates
+}
+
+// displayCurrency picks the currency to show prices in: the buyer's ?currency= choice,
+// otherwise the organization's currency, otherwise rupiah
+func displayCurrency(c *gin.Context, orgCurrency string, rates map[string]float64) string {
+	for _, code := range []string{c.Query("currency"), orgCurrency} {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if _, ok := helpers.FindCurrency(code); ok && rates[code] > 0 {
+			return code
+		}
+	}
+	return helpers.BaseCurrency
+}
+
+// displayPrice converts a rupiah price into the display currency
+func displayPrice(rupiah int64, currency string, rates map[string]float64) PriceDisplay {
+	cur, ok := helpers.FindCurrency(currency)
+	if !ok {
+		cur, _ = helpers.FindCurrency(helpers.BaseCurrency)
+	}
+	m := helpers.ConvertFromRupiah(rupiah, cur, rates[cur.Code])
+	return PriceDisplay{Money: m, Formatted: helpers.FormatMoney(m)}
+}
+
+// GetCurrencies - Supported display currencies and the currencies that have a rate
+// GET /currencies
+func GetCurrencies(c *gin.Context) {
+	rates := loadExchangeRates()
+
+	available := []helpers.Currency{}
+	for _, cur := range helpers.Currencies {
+		if rates[cur.Code] > 0 {
+			available = append(available, cur)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"base_currency": helpers.BaseCurrency,
+		"currencies":    available,
+		"rates":         rates,
+	})
+}
+
+// UpdateOrganizationCurrency - Choose the currency the organization's prices are shown in
+// PUT /organization/currency
+func UpdateOrganizationCurrency(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	var input struct {
+		Currency string `json:"currency"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid"})
+		return
+	}
+
+	cur, ok := helpers.FindCurrency(input.Currency)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Mata uang tidak didukung"})
+		return
+	}
+	if loadExchangeRates()[cur.Code] <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Kurs untuk mata uang ini belum tersedia"})
+		return
+	}
+
+	res, err := config.DB.Exec("UPDATE organizations SET currency = ? WHERE owner_user_id = ?", cur.Code, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan mata uang"})
+		return
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		var exists int
+		config.DB.Get(&exists, "SELECT COUNT(*) FROM organizations WHERE owner_user_id = ?", userID)
+		if exists == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Organisasi tidak ditemukan"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Mata uang tampilan berhasil diperbarui",
+		"currency": cur,
+	})
+}
+
+// ===============================================
+// ADMIN: EXCHANGE RATES
+// ===============================================
+
+// GetExchangeRates - Every supported currency with its current rate
+// GET /admin/exchange-rates
+func GetExchangeRates(c *gin.Context) {
+	var rates []ExchangeRate
+	config.DB.Select(&rates, "SELECT currency, rupiah_per_unit, updated_by, updated_at FROM exchange_rates ORDER BY currency")
+	if rates == nil {
+		rates = []ExchangeRate{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"base_currency": helpers.BaseCurrency,
+		"currencies":    helpers.Currencies,
+		"rates":         rates,
+	})
+}
+
+// UpdateExchangeRate - Set the rupiah rate of one currency
+// PUT /admin/exchange-rates/:currency
+func UpdateExchangeRate(c *gin.Context) {
+	adminID := c.GetInt64("user_id")
+
+	cur, ok := helpers.FindCurrency(c.Param("currency"))
+	if !ok || cur.Code == helpers.BaseCurrency {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Mata uang tidak didukung"})
+		return
+	}
+
+	var input struct {
+		RupiahPerUnit float64 `json:"rupiah_per_unit"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || input.RupiahPerUnit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Kurs harus lebih dari 0"})
+		return
+	}
+
+	_, err := config.DB.Exec(`
+		INSERT INTO exchange_rates (currency, rupiah_per_unit, updated_by) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE rupiah_per_unit = VALUES(rupiah_per_unit), updated_by = VALUES(updated_by)
+	`, cur.Code, input.RupiahPerUnit, adminID)
+	if err != nil {
+		fmt.Printf("[EXCHANGE-RATE] ❌ Error saving %s rate: %v\n", cur.Code, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan kurs"})
+		return
+	}
+
+	fmt.Printf("[EXCHANGE-RATE] ✅ %s set to Rp %.6f by admin %d\n", cur.Code, input.RupiahPerUnit, adminID)
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Kurs berhasil disimpan",
+		"currency":        cur.Code,
+		"rupiah_per_unit": input.RupiahPerUnit,
+	})
+}
+
+// DeleteExchangeRate - Remove a rate; organizations using that currency fall back to rupiah
+// DELETE /admin/exchange-rates/:currency
+func DeleteExchangeRate(c *gin.Context) {
+	code := strings.ToUpper(c.Param("currency"))
+
+	res, err := config.DB.Exec("DELETE FROM exchange_rates WHERE currency = ?", code)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus kurs"})
+		return
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Kurs tidak ditemukan"})
+		return
+	}
+	config.DB.Exec("UPDATE organizations SET currency = ? WHERE currency = ?", helpers.BaseCurrency, code)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Kurs berhasil dihapus"})
+}
@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"BACKEND/config"
+	"BACKEND/entitlements"
+	"BACKEND/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ===============================================
+// ADMIN: SESSION ACCESS (ENTITLEMENTS)
+// ===============================================
+
+// parseAccessExpiry reads an access end date (YYYY-MM-DD or RFC3339, empty = lifetime).
+// A plain date stays valid through the end of that day.
+func parseAccessExpiry(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t, err = time.ParseInLocation("2006-01-02", value, time.Local)
+		t = t.Add(24*time.Hour - time.Second)
+	}
+	if err != nil {
+		return nil, errors.New("Format expires_at tidak valid")
+	}
+	if t.Before(time.Now()) {
+		return nil, errors.New("Tanggal kedaluwarsa sudah lewat")
+	}
+	return &t, nil
+}
+
+// AdminGetEntitlements - List entitlements, filterable by user_id, session_id, event_id, source and active=1
+// GET /admin/entitlements
+func AdminGetEntitlements(c *gin.Context) {
+	query := `
+		SELECT id, user_id, session_id, event_id, organization_id, source, source_ref, starts_at, expires_at,
+			granted_by, note, revoked_at, revoked_by, revoke_reason, created_at
+		FROM entitlements WHERE 1=1`
+	var args []interface{}
+
+	if userID := c.Query("user_id"); userID != "" {
+		query += " AND user_id = ?"
+		args = append(args, userID)
+	}
+	if sessionID := c.Query("session_id"); sessionID != "" {
+		query += " AND session_id = ?"
+		args = append(args, sessionID)
+	}
+	if eventID := c.Query("event_id"); eventID != "" {
+		query += " AND event_id = ?"
+		args = append(args, eventID)
+	}
+	if source := strings.ToUpper(c.Query("source")); source != "" {
+		query += " AND source = ?"
+		args = append(args, source)
+	}
+	if c.Query("active") == "1" {
+		query += " AND revoked_at IS NULL AND starts_at <= NOW() AND (expires_at IS NULL OR expires_at > NOW())"
+	}
+	query += " ORDER BY created_at DESC, id DESC LIMIT 200"
+
+	list := []models.Entitlement{}
+	if err := config.DB.Select(&list, query, args...); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data akses"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entitlements": list})
+}
+
+// AdminGrantEntitlement - Manually give a user access to a session or a whole event (incl. future sessions)
+// POST /admin/entitlements
+func AdminGrantEntitlement(c *gin.Context) {
+	adminID := c.GetInt64("user_id")
+
+	var input struct {
+		UserID    int64  `json:"user_id" binding:"required"`
+		SessionID int64  `json:"session_id"`
+		EventID   int64  `json:"event_id"`   // grant the whole event instead of one session
+		ExpiresAt string `json:"expires_at"` // YYYY-MM-DD or RFC3339, empty = lifetime
+		Note      string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid"})
+		return
+	}
+	if input.SessionID == 0 && input.EventID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "session_id atau event_id wajib diisi"})
+		return
+	}
+
+	expiresAt, err := parseAccessExpiry(input.ExpiresAt)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var userExists int
+	config.DB.Get(&userExists, "SELECT COUNT(*) FROM users WHERE id = ?", input.UserID)
+	if userExists == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User tidak ditemukan"})
+		return
+	}
+
+	// Session grants win when both are given; event grants also cover sessions added later
+	grant := entitlements.Grant{
+		UserID:    input.UserID,
+		Source:    entitlements.SourceAdmin,
+		ExpiresAt: expiresAt,
+		GrantedBy: &adminID,
+		Note:      input.Note,
+	}
+	var title string
+	if input.SessionID > 0 {
+		grant.SessionID = input.SessionID
+		err = config.DB.Get(&title, "SELECT title FROM sessions WHERE id = ?", input.SessionID)
+	} else {
+		grant.EventID = input.EventID
+		err = config.DB.Get(&title, "SELECT title FROM events WHERE id = ?", input.EventID)
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sesi atau event tidak ditemukan"})
+		return
+	}
+
+	if err := entitlements.GrantAccess(config.DB, grant); err != nil {
+		fmt.Printf("[ENTITLEMENT] ❌ %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memberikan akses"})
+		return
+	}
+
+	CreateNotification(
+		input.UserID,
+		"access_granted",
+		"🎁 Akses Diberikan",
+		fmt.Sprintf("Admin memberikan Anda akses ke \"%s\". Silakan cek menu Kursus Saya.", title),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Akses berhasil diberikan",
+		"session_id": grant.SessionID,
+		"event_id":   grant.EventID,
+		"expires_at": expiresAt,
+	})
+}
+
+// AdminRevokeEntitlement - Revoke one entitlement
+// PUT /admin/entitlements/:id/revoke
+func AdminRevokeEntitlement(c *gin.Context) {
+	adminID := c.GetInt64("user_id")
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+
+	var input struct {
+		Reason string `json:"reason"`
+	}
+	c.ShouldBindJSON(&input)
+	if strings.TrimSpace(input.Reason) == "" {
+		input.Reason = "Dicabut oleh admin"
+	}
+
+	revoked, err := entitlements.Revoke(config.DB, id, &adminID, input.Reason)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mencabut akses"})
+		return
+	}
+	if !revoked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Akses tidak ditemukan atau sudah dicabut"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Akses berhasil dicabut"})
+}
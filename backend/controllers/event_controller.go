@@ -4,14 +4,14 @@ import (
 	"fmt"
 	"net/http"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"BACKEND/config"
+	"BACKEND/filecheck"
+	"BACKEND/media"
 	"BACKEND/models"
-	"BACKEND/utils"
 )
 
 // Helper
@@ -165,23 +165,23 @@ func DeleteEvent(c *gin.Context) {
 
 	if err == nil {
 		for _, sessID := range sessionIDs {
-			// A. Hapus Video (Supabase & DB)
+			// A. Hapus Video (Storage & DB)
+			var videoIDs []int64
+			config.DB.Select(&videoIDs, "SELECT id FROM session_videos WHERE session_id = ?", sessID)
+			deleteVideoRenditions(c, videoIDs...)
+
 			var videoPaths []string
 			config.DB.Select(&videoPaths, "SELECT video_url FROM session_videos WHERE session_id = ?", sessID)
 			for _, path := range videoPaths {
-				if strings.Contains(path, "supabase") {
-					utils.DeleteFromSupabase(utils.GetStoragePathFromURL(path))
-				}
+				deleteStoredFile(c, path)
 			}
 			config.DB.Exec("DELETE FROM session_videos WHERE session_id = ?", sessID)
 
-			// B. Hapus Files (Supabase & DB)
+			// B. Hapus Files (Storage & DB)
 			var filePaths []string
 			config.DB.Select(&filePaths, "SELECT file_url FROM session_files WHERE session_id = ?", sessID)
 			for _, path := range filePaths {
-				if strings.Contains(path, "supabase") {
-					utils.DeleteFromSupabase(utils.GetStoragePathFromURL(path))
-				}
+				deleteStoredFile(c, path)
 			}
 			config.DB.Exec("DELETE FROM session_files WHERE session_id = ?", sessID)
 
@@ -197,10 +197,8 @@ func DeleteEvent(c *gin.Context) {
 		}
 	}
 
-	// 4. Hapus Thumbnail Event dari Supabase
-	if thumbnailURL != "" && strings.Contains(thumbnailURL, "supabase") {
-		utils.DeleteFromSupabase(utils.GetStoragePathFromURL(thumbnailURL))
-	}
+	// 4. Hapus Thumbnail Event dari Storage
+	deleteStoredFile(c, thumbnailURL)
 
 	// 5. Akhirnya Hapus Event dari DB
 	_, err = config.DB.Exec("DELETE FROM events WHERE id = ? AND organization_id = ?", eventID, orgID)
@@ -294,22 +292,27 @@ func UploadEventThumbnail(c *gin.Context) {
 	filename := fmt.Sprintf("event_thumb_%s_%d%s", eventID, time.Now().Unix(), ext)
 	storagePath := "events/" + filename
 
-	publicURL, err := utils.UploadFileHeaderToSupabase(storagePath, fileHeader)
+	publicURL, err := uploadFormFile(c, filecheck.Thumbnail, storagePath, fileHeader, orgID)
 	if err != nil {
-		fmt.Printf("❌ Supabase upload error: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal upload gambar"})
+		fmt.Printf("❌ Storage upload error: %v\n", err)
+		respondUploadError(c, err, "Gagal upload gambar")
 		return
 	}
 
-	// Update DB with Supabase URL
+	var oldURL string
+	config.DB.Get(&oldURL, "SELECT COALESCE(thumbnail_url, '') FROM events WHERE id = ?", eventID)
+
+	// Update DB with storage URL
 	_, err = config.DB.Exec("UPDATE events SET thumbnail_url = ? WHERE id = ?", publicURL, eventID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal update database"})
 		return
 	}
+	replaceStoredFile(c, oldURL, publicURL)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":       "Thumbnail berhasil diupload",
 		"thumbnail_url": publicURL,
+		"thumbnail":     attachImageVariants(c, publicURL, media.EventThumbnailVariants),
 	})
 }
@@ -0,0 +1,245 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"BACKEND/config"
+	"BACKEND/helpers"
+	"BACKEND/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// ===============================================
+// EVENT PACKAGES
+// ===============================================
+
+const eventPackageColumns = `id, event_id, title, description, price, COALESCE(is_active, 1) as is_active, created_at, updated_at`
+
+// getActiveEventPackage returns the purchasable package of an event
+func getActiveEventPackage(eventID int64) (*models.EventPackage, error) {
+	var pkg models.EventPackage
+	err := config.DB.Get(&pkg, "SELECT "+eventPackageColumns+" FROM event_packages WHERE event_id = ? AND is_active = 1", eventID)
+	if err != nil {
+		return nil, err
+	}
+	return &pkg, nil
+}
+
+// eventPackageTitle is the display name of a package
+func eventPackageTitle(pkg *models.EventPackage, eventTitle string) string {
+	if pkg != nil && pkg.Title != nil && strings.TrimSpace(*pkg.Title) != "" {
+		return *pkg.Title
+	}
+	return eventTitle + " (Paket Lengkap)"
+}
+
+// allocatePackageRevenue spreads a paid package line over the sessions the event
+// has right now, weighted by each session's own price (evenly when all are free),
+// so per-session revenue reports add up to what was actually paid.
+// Events without sessions yet keep the revenue at event level only.
+func allocatePackageRevenue(tx *sqlx.Tx, purchaseID, eventID, amount int64) {
+	var sessions []struct {
+		ID    int64 `db:"id"`
+		Price int64 `db:"price"`
+	}
+	tx.Select(&sessions, "SELECT id, COALESCE(price, 0) as price FROM sessions WHERE event_id = ? ORDER BY order_index ASC, id ASC", eventID)
+	if len(sessions) == 0 {
+		return
+	}
+
+	prices := make([]int64, len(sessions))
+	for i, s := range sessions {
+		prices[i] = s.Price
+	}
+	shares := helpers.AllocateRevenue(amount, prices)
+
+	for i, s := range sessions {
+		_, err := tx.Exec(`
+			INSERT INTO package_revenue_allocations (purchase_id, session_id, amount)
+			VALUES (?, ?, ?)
+			ON DUPLICATE KEY UPDATE amount = VALUES(amount)
+		`, purchaseID, s.ID, shares[i])
+		if err != nil {
+			fmt.Printf("[PACKAGE] ❌ Error allocating revenue for purchase %d: %v\n", purchaseID, err)
+		}
+	}
+}
+
+// getOwnedEvent reads :eventID and checks that it belongs to the caller's organization
+func getOwnedEvent(c *gin.Context) (int64, bool) {
+	eventID, _ := strconv.ParseInt(c.Param("eventID"), 10, 64)
+	if !checkEventOwnedByUser(eventID, c.GetInt64("user_id")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Event tidak ditemukan atau bukan milik organisasi Anda"})
+		return 0, false
+	}
+	return eventID, true
+}
+
+// GetEventPackage - Get the package of an event (org view, includes inactive)
+// GET /organization/events/:eventID/package
+func GetEventPackage(c *gin.Context) {
+	eventID, ok := getOwnedEvent(c)
+	if !ok {
+		return
+	}
+
+	var pkg models.EventPackage
+	err := config.DB.Get(&pkg, "SELECT "+eventPackageColumns+" FROM event_packages WHERE event_id = ?", eventID)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"package": nil})
+		return
+	}
+
+	var sold int
+	config.DB.Get(&sold, "SELECT COUNT(*) FROM purchases WHERE package_id = ? AND status = 'PAID'", pkg.ID)
+
+	c.JSON(http.StatusOK, gin.H{"package": pkg, "sold": sold})
+}
+
+// UpsertEventPackage - Create or update the package of an event
+// PUT /organization/events/:eventID/package
+func UpsertEventPackage(c *gin.Context) {
+	eventID, ok := getOwnedEvent(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Title       string  `json:"title"`
+		Description string  `json:"description"`
+		Price       float64 `json:"price"`
+		IsActive    *bool   `json:"is_active"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid"})
+		return
+	}
+	if input.Price < 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Harga paket minimal Rp 100"})
+		return
+	}
+	if !helpers.IsWholeRupiah(input.Price) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Harga harus dalam rupiah bulat"})
+		return
+	}
+
+	isActive := true
+	if input.IsActive != nil {
+		isActive = *input.IsActive
+	}
+	var title, description *string
+	if t := strings.TrimSpace(input.Title); t != "" {
+		title = &t
+	}
+	if d := strings.TrimSpace(input.Description); d != "" {
+		description = &d
+	}
+
+	_, err := config.DB.Exec(`
+		INSERT INTO event_packages (event_id, title, description, price, is_active)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE title = VALUES(title), description = VALUES(description),
+			price = VALUES(price), is_active = VALUES(is_active)
+	`, eventID, title, description, input.Price, isActive)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan paket"})
+		return
+	}
+
+	// Keep carts in sync with the new price; an inactive package leaves every cart
+	config.DB.Exec("UPDATE cart_items SET price = ? WHERE item_type = 'EVENT_PACKAGE' AND event_id = ?", input.Price, eventID)
+	if !isActive {
+		config.DB.Exec("DELETE FROM cart_items WHERE item_type = 'EVENT_PACKAGE' AND event_id = ?", eventID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Paket event berhasil disimpan"})
+}
+
+// DeleteEventPackage - Stop selling the package (existing buyers keep access)
+// DELETE /organization/events/:eventID/package
+func DeleteEventPackage(c *gin.Context) {
+	eventID, ok := getOwnedEvent(c)
+	if !ok {
+		return
+	}
+
+	res, err := config.DB.Exec("UPDATE event_packages SET is_active = 0 WHERE event_id = ?", eventID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menonaktifkan paket"})
+		return
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Paket tidak ditemukan"})
+		return
+	}
+	config.DB.Exec("DELETE FROM cart_items WHERE item_type = 'EVENT_PACKAGE' AND event_id = ?", eventID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Paket event dinonaktifkan"})
+}
+
+// GetEventSessionRevenue - Revenue per session: direct sales plus allocated package revenue
+// GET /organization/events/:eventID/session-revenue
+func GetEventSessionRevenue(c *gin.Context) {
+	eventID, ok := getOwnedEvent(c)
+	if !ok {
+		return
+	}
+
+	type SessionRevenue struct {
+		SessionID      int64  `db:"session_id" json:"session_id"`
+		Title          string `db:"title" json:"title"`
+		DirectBuyers   int    `db:"direct_buyers" json:"direct_buyers"`
+		DirectRevenue  int64  `db:"direct_revenue" json:"direct_revenue"`
+		PackageRevenue int64  `db:"package_revenue" json:"package_revenue"`
+		TotalRevenue   int64  `db:"total_revenue" json:"total_revenue"`
+	}
+	rows := []SessionRevenue{}
+	err := config.DB.Select(&rows, `
+		SELECT s.id as session_id, s.title,
+			COALESCE(d.buyers, 0) as direct_buyers,
+			COALESCE(d.revenue, 0) as direct_revenue,
+			COALESCE(a.revenue, 0) as package_revenue,
+			COALESCE(d.revenue, 0) + COALESCE(a.revenue, 0) as total_revenue
+		FROM sessions s
+		LEFT JOIN (
+			SELECT session_id, COUNT(DISTINCT user_id) as buyers, SUM(price_paid) as revenue
+			FROM purchases WHERE status = 'PAID' GROUP BY session_id
+		) d ON d.session_id = s.id
+		LEFT JOIN (
+			SELECT pra.session_id, SUM(pra.amount) as revenue
+			FROM package_revenue_allocations pra
+			JOIN purchases p ON p.id = pra.purchase_id AND p.status = 'PAID'
+			GROUP BY pra.session_id
+		) a ON a.session_id = s.id
+		WHERE s.event_id = ?
+		ORDER BY s.order_index ASC, s.id ASC
+	`, eventID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil pendapatan sesi"})
+		return
+	}
+
+	// Package sales made before the event had any session are not allocated
+	var packageStats struct {
+		Buyers      int   `db:"buyers" json:"buyers"`
+		Revenue     int64 `db:"revenue" json:"revenue"`
+		Unallocated int64 `db:"unallocated" json:"unallocated"`
+	}
+	config.DB.Get(&packageStats, `
+		SELECT COUNT(DISTINCT p.user_id) as buyers, COALESCE(SUM(p.price_paid), 0) as revenue,
+			COALESCE(SUM(CASE WHEN NOT EXISTS (SELECT 1 FROM package_revenue_allocations pra WHERE pra.purchase_id = p.id)
+				THEN p.price_paid ELSE 0 END), 0) as unallocated
+		FROM purchases p
+		WHERE p.event_id = ? AND p.package_id IS NOT NULL AND p.status = 'PAID'
+	`, eventID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"sessions": rows,
+		"package":  packageStats,
+	})
+}
@@ -19,8 +19,15 @@ type PublicEventResponse struct {
 	ThumbnailURL     *string `db:"thumbnail_url" json:"thumbnail_url"`
 	OrganizationName string  `db:"organization_name" json:"organization_name"`
 	SessionCount     int     `db:"session_count" json:"session_count"`
-	MinPrice         float64 `db:"min_price" json:"min_price"`
+	MinPrice         int64   `db:"min_price" json:"min_price"`
 	PublishAt        *string `db:"publish_at" json:"publish_at"`
+	OrgCurrency      string  `db:"org_currency" json:"-"`
+
+	// Harga dalam mata uang tampilan (pembayaran tetap dalam rupiah)
+	DisplayMinPrice PriceDisplay `db:"-" json:"display_min_price"`
+
+	// Varian thumbnail (card/hero), blurhash dan warna dominan
+	Thumbnail *ImageInfo `db:"-" json:"thumbnail"`
 }
 
 // =========================================================
@@ -34,7 +41,8 @@ func ListPublicEvents(c *gin.Context) {
 			o.name AS organization_name,
 			(SELECT COUNT(*) FROM sessions s WHERE s.event_id = e.id) AS session_count,
 			(SELECT COALESCE(MIN(price), 0) FROM sessions s WHERE s.event_id = e.id) AS min_price,
-			e.publish_at
+			e.publish_at,
+			COALESCE(o.currency, 'IDR') AS org_currency
 		FROM events e
 		JOIN organizations o ON o.id = e.organization_id
 	`
@@ -53,6 +61,32 @@ func ListPublicEvents(c *gin.Context) {
 		upcomingEvents = []PublicEventResponse{}
 	}
 
+	// 3. Harga tampilan sesuai mata uang organisasi (atau ?currency= pilihan pembeli)
+	rates := loadExchangeRates()
+	for _, list := range [][]PublicEventResponse{publishedEvents, upcomingEvents} {
+		for i := range list {
+			list[i].DisplayMinPrice = displayPrice(list[i].MinPrice, displayCurrency(c, list[i].OrgCurrency, rates), rates)
+		}
+	}
+
+	// 4. Varian thumbnail
+	var thumbnailURLs []string
+	for _, list := range [][]PublicEventResponse{publishedEvents, upcomingEvents} {
+		for _, e := range list {
+			if e.ThumbnailURL != nil {
+				thumbnailURLs = append(thumbnailURLs, *e.ThumbnailURL)
+			}
+		}
+	}
+	thumbnails := loadImageInfos(thumbnailURLs...)
+	for _, list := range [][]PublicEventResponse{publishedEvents, upcomingEvents} {
+		for i := range list {
+			if list[i].ThumbnailURL != nil {
+				list[i].Thumbnail = thumbnails[*list[i].ThumbnailURL]
+			}
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"events":   publishedEvents,
 		"upcoming": upcomingEvents,
@@ -84,14 +118,18 @@ func GetEventDetail(c *gin.Context) {
 
 	// 2. Ambil data organisasi
 	var organization struct {
-		ID      int64  `db:"id" json:"id"`
-		Name    string `db:"name" json:"name"`
-		LogoURL string `db:"logo_url" json:"logo_url"`
+		ID       int64  `db:"id" json:"id"`
+		Name     string `db:"name" json:"name"`
+		LogoURL  string `db:"logo_url" json:"logo_url"`
+		Currency string `db:"currency" json:"currency"`
+
+		Logo *ImageInfo `db:"-" json:"logo"`
 	}
 	config.DB.Get(&organization, `
-		SELECT id, name, COALESCE(logo_url, '') as logo_url
+		SELECT id, name, COALESCE(logo_url, '') as logo_url, COALESCE(currency, 'IDR') as currency
 		FROM organizations WHERE id = ?
 	`, event.OrganizationID)
+	organization.Logo = loadImageInfo(organization.LogoURL)
 
 	// 3. Ambil Sesi (Hanya yang tidak DRAFT)
 	var sessions []models.Session
@@ -109,9 +147,37 @@ func GetEventDetail(c *gin.Context) {
 		sessions = []models.Session{}
 	}
 
+	// 4. Paket event (semua sesi, termasuk sesi yang ditambahkan nanti)
+	pkg, _ := getActiveEventPackage(event.ID)
+
+	// 5. Harga tampilan (informasi saja, pembayaran tetap dalam rupiah)
+	rates := loadExchangeRates()
+	currency := displayCurrency(c, organization.Currency, rates)
+	sessionPrices := map[int64]PriceDisplay{}
+	for _, s := range sessions {
+		sessionPrices[s.ID] = displayPrice(s.Price, currency, rates)
+	}
+	var packagePrice *PriceDisplay
+	if pkg != nil {
+		p := displayPrice(pkg.Price, currency, rates)
+		packagePrice = &p
+	}
+
+	var thumbnail *ImageInfo
+	if event.ThumbnailURL != nil {
+		thumbnail = loadImageInfo(*event.ThumbnailURL)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"event":        event,
+		"thumbnail":    thumbnail,
 		"sessions":     sessions,
 		"organization": organization,
+		"package":      pkg,
+		"display": gin.H{
+			"currency":       currency,
+			"session_prices": sessionPrices,
+			"package_price":  packagePrice,
+		},
 	})
 }
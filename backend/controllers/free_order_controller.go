@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"BACKEND/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ===============================================
+// FREE (ZERO-AMOUNT) ORDERS
+// ===============================================
+
+// defaultFreeClaimDailyLimit is used when platform_settings has no value
+const defaultFreeClaimDailyLimit = 5
+
+// getFreeClaimDailyLimit returns how many free orders one user may claim per 24 hours (0 = unlimited)
+func getFreeClaimDailyLimit() int {
+	limit, err := strconv.Atoi(getPlatformSetting("free_claim_daily_limit", strconv.Itoa(defaultFreeClaimDailyLimit)))
+	if err != nil || limit < 0 {
+		return defaultFreeClaimDailyLimit
+	}
+	return limit
+}
+
+// checkFreeClaimLimit returns a user-facing message when the user has used up their
+// free claims for the last 24 hours, or "" when another free order is allowed
+func checkFreeClaimLimit(userID int64) string {
+	limit := getFreeClaimDailyLimit()
+	if limit == 0 {
+		return ""
+	}
+
+	var claims int
+	config.DB.Get(&claims, `
+		SELECT COUNT(DISTINCT order_id) FROM purchases
+		WHERE user_id = ? AND status = 'PAID' AND order_id LIKE 'FREE-%'
+			AND purchased_at >= DATE_SUB(NOW(), INTERVAL 1 DAY)
+	`, userID)
+
+	if claims >= limit {
+		return fmt.Sprintf("Batas klaim gratis tercapai (%d per hari). Silakan coba lagi besok", limit)
+	}
+	return ""
+}
+
+// notifyFreeOrder tells the buyer and the organizations involved about a free order
+func notifyFreeOrder(userID int64, orderID string) {
+	var buyerName string
+	config.DB.Get(&buyerName, "SELECT name FROM users WHERE id = ?", userID)
+
+	var sessions []struct {
+		SessionTitle string `db:"session_title"`
+		EventTitle   string `db:"event_title"`
+		OwnerID      int64  `db:"owner_id"`
+	}
+	config.DB.Select(&sessions, `
+		SELECT COALESCE(s.title, 'Paket Lengkap') as session_title, e.title as event_title, COALESCE(o.owner_user_id, 0) as owner_id
+		FROM purchases p
+		LEFT JOIN sessions s ON p.session_id = s.id
+		JOIN events e ON e.id = COALESCE(p.event_id, s.event_id)
+		JOIN organizations o ON e.organization_id = o.id
+		WHERE p.order_id = ?
+	`, orderID)
+
+	CreateNotification(
+		userID,
+		"purchase_success",
+		"✅ Akses Gratis Berhasil!",
+		fmt.Sprintf("Anda mendapatkan akses ke %d sesi secara gratis. Selamat belajar!", len(sessions)),
+	)
+
+	for _, s := range sessions {
+		if s.OwnerID > 0 {
+			CreateNotification(
+				s.OwnerID,
+				"new_purchase",
+				"🎁 Klaim Sesi Gratis",
+				buyerName+" mengklaim sesi \""+s.SessionTitle+"\" dari event \""+s.EventTitle+"\"",
+			)
+		}
+	}
+}
+
+// GetFreeClaimSettings - Get per-user free claim limit
+// GET /admin/free-claim-settings
+func GetFreeClaimSettings(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"free_claim_daily_limit": getFreeClaimDailyLimit(),
+	})
+}
+
+// UpdateFreeClaimSettings - Update per-user free claim limit
+// PUT /admin/free-claim-settings
+func UpdateFreeClaimSettings(c *gin.Context) {
+	var input struct {
+		FreeClaimDailyLimit int `json:"free_claim_daily_limit"` // 0 = unlimited
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid"})
+		return
+	}
+	if input.FreeClaimDailyLimit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Batas klaim tidak boleh negatif"})
+		return
+	}
+
+	if err := setPlatformSetting("free_claim_daily_limit", strconv.Itoa(input.FreeClaimDailyLimit)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan pengaturan"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":                "Pengaturan klaim gratis berhasil disimpan",
+		"free_claim_daily_limit": input.FreeClaimDailyLimit,
+	})
+}
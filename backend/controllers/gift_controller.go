@@ -0,0 +1,300 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"BACKEND/config"
+	"BACKEND/entitlements"
+	"BACKEND/helpers"
+	"BACKEND/models"
+	"BACKEND/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// ===============================================
+// GIFT PURCHASES & REDEEM CODES
+// ===============================================
+
+const giftCodeColumns = `id, code, purchase_id, order_id, buyer_user_id, session_id, event_id,
+	recipient_email, status, redeemed_by, redeemed_at, created_at`
+
+// giftItemTitleSQL names what a code unlocks: the session, or the event package
+const giftItemTitleSQL = `COALESCE(s.title, ep.title, CONCAT(e.title, ' (Paket Lengkap)'))`
+
+// GiftCodeView is a gift code with the names needed to show it in lists
+type GiftCodeView struct {
+	ID              int64      `db:"id" json:"id"`
+	Code            string     `db:"code" json:"code"`
+	OrderID         string     `db:"order_id" json:"order_id"`
+	SessionID       *int64     `db:"session_id" json:"session_id"`
+	EventID         int64      `db:"event_id" json:"event_id"`
+	ItemTitle       string     `db:"item_title" json:"item_title"`
+	EventTitle      string     `db:"event_title" json:"event_title"`
+	BuyerName       string     `db:"buyer_name" json:"buyer_name"`
+	BuyerEmail      string     `db:"buyer_email" json:"buyer_email"`
+	RecipientEmail  *string    `db:"recipient_email" json:"recipient_email"`
+	Status          string     `db:"status" json:"status"`
+	RedeemedByName  *string    `db:"redeemed_by_name" json:"redeemed_by_name"`
+	RedeemedByEmail *string    `db:"redeemed_by_email" json:"redeemed_by_email"`
+	RedeemedAt      *time.Time `db:"redeemed_at" json:"redeemed_at"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+}
+
+const giftCodeViewQuery = `
+	SELECT gc.id, gc.code, gc.order_id, gc.session_id, gc.event_id,
+		` + giftItemTitleSQL + ` as item_title, e.title as event_title,
+		b.name as buyer_name, b.email as buyer_email, gc.recipient_email,
+		COALESCE(gc.status, 'UNREDEEMED') as status,
+		r.name as redeemed_by_name, r.email as redeemed_by_email,
+		gc.redeemed_at, gc.created_at
+	FROM gift_codes gc
+	JOIN events e ON e.id = gc.event_id
+	LEFT JOIN sessions s ON s.id = gc.session_id
+	LEFT JOIN event_packages ep ON gc.session_id IS NULL AND ep.event_id = gc.event_id
+	JOIN users b ON b.id = gc.buyer_user_id
+	LEFT JOIN users r ON r.id = gc.redeemed_by`
+
+// issueGiftCodes creates one redeemable code per gifted seat of a paid order.
+// Lines that already have codes are skipped, so settling twice issues nothing new.
+func issueGiftCodes(tx *sqlx.Tx, orderID string) {
+	var lines []struct {
+		ID         int64   `db:"id"`
+		UserID     int64   `db:"user_id"`
+		SessionID  *int64  `db:"session_id"`
+		EventID    int64   `db:"event_id"`
+		Quantity   int     `db:"quantity"`
+		Recipients *string `db:"gift_recipients"`
+	}
+	err := tx.Select(&lines, `
+		SELECT p.id, p.user_id, p.session_id, COALESCE(p.event_id, s.event_id) as event_id,
+			COALESCE(p.quantity, 1) as quantity, p.gift_recipients
+		FROM purchases p
+		LEFT JOIN sessions s ON p.session_id = s.id
+		WHERE p.order_id = ? AND p.status = 'PAID' AND COALESCE(p.is_gift, 0) = 1
+			AND NOT EXISTS (SELECT 1 FROM gift_codes gc WHERE gc.purchase_id = p.id)
+	`, orderID)
+	if err != nil {
+		fmt.Printf("[GIFT] ❌ Error loading gift lines of %s: %v\n", orderID, err)
+		return
+	}
+
+	for _, line := range lines {
+		var emails []string
+		if line.Recipients != nil && *line.Recipients != "" {
+			emails = strings.Split(*line.Recipients, ",")
+		}
+
+		for i := 0; i < line.Quantity; i++ {
+			var recipient *string
+			if i < len(emails) {
+				recipient = &emails[i]
+			}
+
+			// Codes are random, a collision just gets a fresh code
+			for attempt := 0; attempt < 3; attempt++ {
+				_, err = tx.Exec(`
+					INSERT INTO gift_codes (code, purchase_id, order_id, buyer_user_id, session_id, event_id, recipient_email)
+					VALUES (?, ?, ?, ?, ?, ?, ?)
+				`, helpers.GenerateGiftCode(), line.ID, orderID, line.UserID, line.SessionID, line.EventID, recipient)
+				if err == nil {
+					break
+				}
+			}
+			if err != nil {
+				fmt.Printf("[GIFT] ❌ Error issuing gift code for purchase %d: %v\n", line.ID, err)
+			}
+		}
+		fmt.Printf("[GIFT] 🎁 Issued %d gift codes for purchase %d\n", line.Quantity, line.ID)
+	}
+}
+
+// sendGiftCodeEmails emails invites to known recipients and sends the buyer every code of the order
+func sendGiftCodeEmails(orderID string) {
+	var codes []GiftCodeView
+	config.DB.Select(&codes, giftCodeViewQuery+" WHERE gc.order_id = ? ORDER BY gc.id", orderID)
+	if len(codes) == 0 {
+		return
+	}
+
+	lines := make([]utils.GiftCodeLine, len(codes))
+	for i, gc := range codes {
+		lines[i] = utils.GiftCodeLine{Code: gc.Code, ItemTitle: gc.ItemTitle}
+		if gc.RecipientEmail != nil {
+			lines[i].RecipientEmail = *gc.RecipientEmail
+			if err := utils.SendGiftInviteEmail(*gc.RecipientEmail, gc.BuyerName, gc.ItemTitle, gc.Code); err != nil {
+				fmt.Printf("[GIFT] ❌ Failed to send invite %s to %s: %v\n", gc.Code, *gc.RecipientEmail, err)
+			}
+		}
+	}
+
+	if err := utils.SendGiftCodesEmail(codes[0].BuyerEmail, codes[0].BuyerName, lines); err != nil {
+		fmt.Printf("[GIFT] ❌ Failed to send gift codes of %s: %v\n", orderID, err)
+	}
+}
+
+// getEventGiftCodes lists the gift codes of an event with a redeemed/unredeemed summary
+func getEventGiftCodes(eventID interface{}) ([]GiftCodeView, gin.H) {
+	codes := []GiftCodeView{}
+	config.DB.Select(&codes, giftCodeViewQuery+" WHERE gc.event_id = ? ORDER BY gc.created_at DESC, gc.id DESC", eventID)
+
+	var redeemed, unredeemed, revoked int
+	for _, gc := range codes {
+		switch gc.Status {
+		case "REDEEMED":
+			redeemed++
+		case "REVOKED":
+			revoked++
+		default:
+			unredeemed++
+		}
+	}
+
+	return codes, gin.H{
+		"total":      len(codes),
+		"redeemed":   redeemed,
+		"unredeemed": unredeemed,
+		"revoked":    revoked,
+	}
+}
+
+// RedeemGiftCode - Redeem a gift code and get the gifted access
+// POST /user/redeem
+func RedeemGiftCode(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	var input struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Kode hadiah wajib diisi"})
+		return
+	}
+	code, ok := helpers.NormalizeGiftCode(input.Code)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format kode hadiah tidak valid"})
+		return
+	}
+
+	tx, _ := config.DB.Beginx()
+	defer tx.Rollback()
+
+	// Lock the code so two people cannot redeem the same seat at once
+	var gift models.GiftCode
+	if err := tx.Get(&gift, "SELECT "+giftCodeColumns+" FROM gift_codes WHERE code = ? FOR UPDATE", code); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Kode hadiah tidak ditemukan"})
+		return
+	}
+	switch gift.Status {
+	case "REDEEMED":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Kode hadiah sudah digunakan"})
+		return
+	case "REVOKED":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Kode hadiah sudah tidak berlaku"})
+		return
+	}
+
+	// Keep the seat for someone else if the user already has this access
+	grant := entitlements.Grant{UserID: userID, Source: entitlements.SourceGift, SourceRef: gift.Code}
+	var hasAccess bool
+	if gift.SessionID != nil {
+		grant.SessionID = *gift.SessionID
+		hasAccess = entitlements.HasAccess(userID, *gift.SessionID)
+	} else {
+		grant.EventID = gift.EventID
+		hasAccess = entitlements.HasEventAccess(userID, gift.EventID)
+	}
+	if hasAccess {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Anda sudah memiliki akses ini, berikan kode kepada orang lain"})
+		return
+	}
+
+	if err := entitlements.GrantAccess(tx, grant); err != nil {
+		fmt.Printf("[GIFT] ❌ %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menukarkan kode hadiah"})
+		return
+	}
+	tx.Exec("UPDATE gift_codes SET status = 'REDEEMED', redeemed_by = ?, redeemed_at = NOW() WHERE id = ?", userID, gift.ID)
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menukarkan kode hadiah"})
+		return
+	}
+
+	var itemTitle string
+	config.DB.Get(&itemTitle, `
+		SELECT `+giftItemTitleSQL+`
+		FROM events e
+		LEFT JOIN sessions s ON s.id = ?
+		LEFT JOIN event_packages ep ON ep.event_id = e.id
+		WHERE e.id = ?
+	`, gift.SessionID, gift.EventID)
+
+	CreateNotification(
+		userID,
+		"access_granted",
+		"🎁 Hadiah Berhasil Ditukarkan",
+		fmt.Sprintf("Anda sekarang memiliki akses ke \"%s\". Silakan cek menu Kursus Saya.", itemTitle),
+	)
+	if gift.BuyerUserID != userID {
+		CreateNotification(
+			gift.BuyerUserID,
+			"gift_redeemed",
+			"🎁 Kode Hadiah Digunakan",
+			fmt.Sprintf("Kode %s untuk \"%s\" telah ditukarkan.", gift.Code, itemTitle),
+		)
+	}
+
+	fmt.Printf("[GIFT] ✅ Code %s redeemed by user %d\n", gift.Code, userID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Kode hadiah berhasil ditukarkan",
+		"title":      itemTitle,
+		"session_id": gift.SessionID,
+		"event_id":   gift.EventID,
+	})
+}
+
+// GetMyGiftCodes - Gift codes bought by the current user and whether they were redeemed
+// GET /user/gifts
+func GetMyGiftCodes(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	codes := []GiftCodeView{}
+	err := config.DB.Select(&codes, giftCodeViewQuery+" WHERE gc.buyer_user_id = ? ORDER BY gc.created_at DESC, gc.id DESC", userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil kode hadiah"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"gift_codes": codes})
+}
+
+// AdminGetGiftCodes - List gift codes, filterable by event_id and status
+// GET /admin/gift-codes
+func AdminGetGiftCodes(c *gin.Context) {
+	query := giftCodeViewQuery + " WHERE 1=1"
+	var args []interface{}
+
+	if eventID := c.Query("event_id"); eventID != "" {
+		query += " AND gc.event_id = ?"
+		args = append(args, eventID)
+	}
+	if status := strings.ToUpper(c.Query("status")); status != "" {
+		query += " AND gc.status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY gc.created_at DESC, gc.id DESC LIMIT 200"
+
+	codes := []GiftCodeView{}
+	if err := config.DB.Select(&codes, query, args...); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil kode hadiah"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"gift_codes": codes})
+}
@@ -0,0 +1,503 @@
+package controllers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"BACKEND/config"
+	"BACKEND/entitlements"
+	"BACKEND/helpers"
+	"BACKEND/models"
+	"BACKEND/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/midtrans/midtrans-go"
+	"github.com/midtrans/midtrans-go/snap"
+)
+
+// ===============================================
+// GUEST CHECKOUT
+// ===============================================
+
+// guestSetPasswordTTL is how long the set-password code of an account created for a guest stays valid
+const guestSetPasswordTTL = 72 * time.Hour
+
+const guestOrderColumns = `id, order_id, midtrans_order_id, email, name, phone, affiliate_code, total,
+	status, user_id, account_created, payment_method, payment_fee, created_at, paid_at`
+
+// GuestCheckoutInput represents a checkout without an account
+type GuestCheckoutInput struct {
+	Name          string  `json:"name"`
+	Email         string  `json:"email"`
+	Phone         string  `json:"phone"`
+	AffiliateCode *string `json:"affiliate_code"`
+	PaymentMethod string  `json:"payment_method"`
+	Items         []struct {
+		SessionID *int64 `json:"session_id"` // For single session
+		EventID   *int64 `json:"event_id"`   // For event package
+	} `json:"items"`
+}
+
+// GuestCheckout - Pay for sessions or event packages with only a name, email and phone
+// POST /guest/checkout
+func GuestCheckout(c *gin.Context) {
+	var input GuestCheckoutInput
+	if err := c.ShouldBindJSON(&input); err != nil || len(input.Items) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Data pesanan tidak valid"})
+		return
+	}
+	if len(input.Items) > helpers.MaxGuestOrderItems {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Maksimal %d item per pesanan", helpers.MaxGuestOrderItems)})
+		return
+	}
+
+	contact, err := helpers.NormalizeGuestContact(input.Name, input.Email, input.Phone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Prices always come from the catalog, never from the client
+	items := make([]models.GuestOrderItem, 0, len(input.Items))
+	titles := make([]string, 0, len(input.Items))
+	seenSessions := map[int64]bool{}
+	packagedEvents := map[int64]bool{}
+	for _, in := range input.Items {
+		if in.SessionID == nil && in.EventID != nil {
+			if packagedEvents[*in.EventID] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Item duplikat dalam pesanan"})
+				return
+			}
+			var eventTitle string
+			err := config.DB.Get(&eventTitle, "SELECT title FROM events WHERE id = ? AND publish_status = 'PUBLISHED'", *in.EventID)
+			if err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Event tidak ditemukan"})
+				return
+			}
+			pkg, err := getActiveEventPackage(*in.EventID)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Event ini tidak memiliki paket bundling"})
+				return
+			}
+			packagedEvents[*in.EventID] = true
+			items = append(items, models.GuestOrderItem{ItemType: "EVENT_PACKAGE", EventID: *in.EventID, PackageID: &pkg.ID, Price: pkg.Price})
+			titles = append(titles, eventPackageTitle(pkg, eventTitle))
+			continue
+		}
+		if in.SessionID == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "session_id atau event_id diperlukan"})
+			return
+		}
+
+		var session struct {
+			ID      int64  `db:"id"`
+			Title   string `db:"title"`
+			Price   int64  `db:"price"`
+			EventID int64  `db:"event_id"`
+		}
+		err := config.DB.Get(&session, `
+			SELECT s.id, s.title, s.price, s.event_id FROM sessions s
+			JOIN events e ON e.id = s.event_id
+			WHERE s.id = ? AND s.publish_status = 'PUBLISHED' AND e.publish_status = 'PUBLISHED'
+		`, *in.SessionID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Sesi tidak ditemukan"})
+			return
+		}
+		if seenSessions[session.ID] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Item duplikat dalam pesanan"})
+			return
+		}
+		seenSessions[session.ID] = true
+		sessionID := session.ID
+		items = append(items, models.GuestOrderItem{ItemType: "SESSION", SessionID: &sessionID, EventID: session.EventID, Price: session.Price})
+		titles = append(titles, session.Title)
+	}
+
+	var total int64
+	for _, item := range items {
+		if item.ItemType == "SESSION" && packagedEvents[item.EventID] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Sesi sudah termasuk dalam paket event di pesanan ini"})
+			return
+		}
+		total += item.Price
+	}
+
+	// An account registered with this email must not pay again for what it already owns
+	var ownerID int64
+	if err := config.DB.Get(&ownerID, "SELECT id FROM users WHERE email = ?", contact.Email); err == nil {
+		for i, item := range items {
+			if guestItemOwned(ownerID, item) {
+				c.JSON(http.StatusConflict, gin.H{
+					"error": fmt.Sprintf("Email ini sudah memiliki akses ke \"%s\", silakan login untuk mengaksesnya", titles[i]),
+				})
+				return
+			}
+		}
+	}
+
+	// Free items are claimed through an account (they count against the daily free claim limit)
+	if total <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Item gratis memerlukan akun, silakan daftar atau login terlebih dahulu"})
+		return
+	}
+	if total < 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Total minimal Rp 100 untuk pembayaran"})
+		return
+	}
+
+	// Payment method (and its surcharge) must be one every organization in the order accepts
+	eventIDs := make([]int64, len(items))
+	for i, item := range items {
+		eventIDs[i] = item.EventID
+	}
+	var orgIDs []int64
+	if query, args, err := sqlx.In("SELECT DISTINCT organization_id FROM events WHERE id IN (?)", eventIDs); err == nil {
+		config.DB.Select(&orgIDs, config.DB.Rebind(query), args...)
+	}
+	method, err := choosePaymentMethod(input.PaymentMethod, orgIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	paymentFee := helpers.PaymentMethodFee(total, method.FlatFee, method.PercentFee)
+
+	// Affiliate codes that are no longer valid are dropped, like in the cart checkout
+	var affiliateCode *string
+	if input.AffiliateCode != nil && strings.TrimSpace(*input.AffiliateCode) != "" {
+		code := strings.TrimSpace(*input.AffiliateCode)
+		var valid int
+		config.DB.Get(&valid, `
+			SELECT COUNT(*) FROM affiliate_partnerships
+			WHERE unique_code = ? AND status = 'APPROVED' AND COALESCE(is_active, 1) = 1
+				AND (expires_at IS NULL OR expires_at >= NOW())
+		`, code)
+		if valid > 0 {
+			affiliateCode = &code
+		}
+	}
+
+	tx, _ := config.DB.Beginx()
+	defer tx.Rollback()
+
+	// The order ID needs the row ID, so the row starts with a placeholder
+	res, err := tx.Exec(`
+		INSERT INTO guest_orders (order_id, email, name, phone, affiliate_code, total, payment_method, payment_fee)
+		VALUES (UUID(), ?, ?, ?, ?, ?, ?, ?)
+	`, contact.Email, contact.Name, contact.Phone, affiliateCode, total, method.Code, paymentFee)
+	if err != nil {
+		fmt.Printf("[GUEST-CHECKOUT] ❌ Error creating order: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat pesanan"})
+		return
+	}
+	guestOrderID, _ := res.LastInsertId()
+
+	baseOrderID := fmt.Sprintf("GUEST-%d-%d", time.Now().Unix(), guestOrderID)
+	orderID := baseOrderID
+	if affiliateCode != nil {
+		orderID = fmt.Sprintf("%s-AFF-%s", baseOrderID, *affiliateCode)
+	}
+	tx.Exec("UPDATE guest_orders SET order_id = ?, midtrans_order_id = ? WHERE id = ?", baseOrderID, orderID, guestOrderID)
+
+	for _, item := range items {
+		_, err := tx.Exec(`
+			INSERT INTO guest_order_items (guest_order_id, item_type, session_id, event_id, package_id, price)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, guestOrderID, item.ItemType, item.SessionID, item.EventID, item.PackageID, item.Price)
+		if err != nil {
+			fmt.Printf("[GUEST-CHECKOUT] ❌ Error creating order item: %v\n", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat pesanan"})
+			return
+		}
+	}
+
+	// Create Midtrans payment
+	var midtransItems []midtrans.ItemDetails
+	for i, item := range items {
+		itemName := titles[i]
+		if len(itemName) > 50 {
+			itemName = itemName[:47] + "..."
+		}
+		midtransItems = append(midtransItems, midtrans.ItemDetails{
+			ID:    strconv.Itoa(i + 1),
+			Name:  itemName,
+			Price: item.Price,
+			Qty:   1,
+		})
+	}
+
+	if paymentFee > 0 {
+		midtransItems = append(midtransItems, midtrans.ItemDetails{
+			ID:    "PAYMENT_FEE",
+			Name:  "Biaya " + method.Label,
+			Price: paymentFee,
+			Qty:   1,
+		})
+	}
+
+	snapReq := &snap.Request{
+		TransactionDetails: midtrans.TransactionDetails{
+			OrderID:  orderID,
+			GrossAmt: midtransGrossAmount(midtransItems),
+		},
+		CustomerDetail: &midtrans.CustomerDetails{
+			FName: contact.Name,
+			Email: contact.Email,
+			Phone: contact.Phone,
+		},
+		Items: &midtransItems,
+	}
+	applySnapPaymentMethod(snapReq, method)
+
+	snapResp, snapErr := config.SnapClient.CreateTransaction(snapReq)
+	if snapErr != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create payment: " + snapErr.Message})
+		return
+	}
+
+	tx.Exec("UPDATE guest_orders SET snap_token = ? WHERE id = ?", snapResp.Token, guestOrderID)
+	tx.Commit()
+
+	fmt.Printf("[GUEST-CHECKOUT] ✅ Created order: base=%s, midtrans=%s, items=%d\n", baseOrderID, orderID, len(items))
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":             snapResp.Token,
+		"redirect_url":      snapResp.RedirectURL,
+		"order_id":          baseOrderID,
+		"midtrans_order_id": orderID,
+		"total":             total + paymentFee,
+		"subtotal":          total,
+		"payment_method":    method.Code,
+		"payment_fee":       paymentFee,
+		"expiry_minutes":    method.ExpiryMinutes,
+		"currency":          helpers.BaseCurrency,
+		"item_count":        len(items),
+	})
+}
+
+// GetGuestOrderStatus - Payment status of a guest order (the buyer's email must match)
+// GET /guest/orders/:orderID?email=
+func GetGuestOrderStatus(c *gin.Context) {
+	email := strings.ToLower(strings.TrimSpace(c.Query("email")))
+
+	var order models.GuestOrder
+	err := config.DB.Get(&order, "SELECT "+guestOrderColumns+" FROM guest_orders WHERE order_id = ? AND email = ?", c.Param("orderID"), email)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pesanan tidak ditemukan"})
+		return
+	}
+
+	message := "Menunggu pembayaran"
+	switch order.Status {
+	case "PAID":
+		message = "Pembayaran berhasil, silakan login untuk mengakses konten Anda"
+		if order.AccountCreated {
+			message = "Pembayaran berhasil, cek email Anda untuk membuat password akun"
+		}
+	case "FAILED", "EXPIRED":
+		message = "Pembayaran tidak berhasil, silakan checkout ulang"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"order_id":        order.OrderID,
+		"status":          order.Status,
+		"total":           order.Total,
+		"account_created": order.AccountCreated,
+		"message":         message,
+	})
+}
+
+// ===============================================
+// GUEST PAYMENT SETTLEMENT
+// ===============================================
+
+// ProcessGuestPayment settles a paid guest order: the buyer's account is matched by email
+// (or created), the order lines become purchases and the regular cart settlement does the
+// rest. Called from HandleMidtransNotification when order starts with "GUEST-".
+func ProcessGuestPayment(orderID string, grossAmount string) error {
+	baseOrderID := strings.Split(orderID, "-AFF-")[0]
+	fmt.Printf("[GUEST-PAYMENT] Processing order: %s, amount: %s\n", baseOrderID, grossAmount)
+
+	tx, _ := config.DB.Beginx()
+	defer tx.Rollback()
+
+	var order models.GuestOrder
+	if err := tx.Get(&order, "SELECT "+guestOrderColumns+" FROM guest_orders WHERE order_id = ? FOR UPDATE", baseOrderID); err != nil {
+		return fmt.Errorf("guest order %s not found: %v", baseOrderID, err)
+	}
+
+	midtransOrderID := baseOrderID
+	if order.MidtransOrderID != nil && *order.MidtransOrderID != "" {
+		midtransOrderID = *order.MidtransOrderID
+	}
+
+	// A repeated notification skips straight to the (idempotent) cart settlement,
+	// which also finishes an earlier attempt that stopped half-way
+	setPasswordCode := ""
+	var ownedLines []string
+	if order.Status != "PAID" {
+		userID, code, err := provisionGuestAccount(tx, order)
+		if err != nil {
+			return err
+		}
+		setPasswordCode = code
+
+		var items []models.GuestOrderItem
+		tx.Select(&items, "SELECT id, guest_order_id, item_type, session_id, event_id, package_id, price FROM guest_order_items WHERE guest_order_id = ?", order.ID)
+
+		// The method surcharge is split over the lines, like in the cart checkout
+		prices := make([]int64, len(items))
+		for i, item := range items {
+			prices[i] = item.Price
+		}
+		lineFees := helpers.SplitMinor(order.PaymentFee, prices)
+
+		for i, item := range items {
+			// An existing account may have bought the item since checkout; its paid
+			// purchase is kept as it is and the line is left for a refund or credit
+			if setPasswordCode == "" && guestLineOwned(tx, userID, item) {
+				ownedLines = append(ownedLines, fmt.Sprintf("%s %d (%s)", item.ItemType, guestItemTarget(item), helpers.FormatRupiah(item.Price+lineFees[i])))
+				continue
+			}
+
+			var err error
+			if item.ItemType == "SESSION" {
+				_, err = tx.Exec(`
+					INSERT INTO purchases (user_id, session_id, amount, price_paid, status, order_id, midtrans_order_id, affiliate_code, payment_method, payment_fee)
+					VALUES (?, ?, ?, ?, 'PENDING', ?, ?, ?, ?, ?)
+					ON DUPLICATE KEY UPDATE status = 'PENDING', order_id = ?, midtrans_order_id = ?, price_paid = ?, affiliate_code = ?,
+						coupon_code = NULL, discount_amount = 0, payment_method = ?, payment_fee = ?, purchased_at = CURRENT_TIMESTAMP
+				`, userID, item.SessionID, item.Price, item.Price, baseOrderID, midtransOrderID, order.AffiliateCode, order.PaymentMethod, lineFees[i],
+					baseOrderID, midtransOrderID, item.Price, order.AffiliateCode, order.PaymentMethod, lineFees[i])
+			} else {
+				_, err = tx.Exec(`
+					INSERT INTO purchases (user_id, session_id, package_id, event_id, amount, price_paid, status, order_id, midtrans_order_id, affiliate_code, payment_method, payment_fee)
+					VALUES (?, NULL, ?, ?, ?, ?, 'PENDING', ?, ?, ?, ?, ?)
+				`, userID, item.PackageID, item.EventID, item.Price, item.Price, baseOrderID, midtransOrderID, order.AffiliateCode, order.PaymentMethod, lineFees[i])
+			}
+			if err != nil {
+				return fmt.Errorf("failed to create purchase for guest order %s: %v", baseOrderID, err)
+			}
+		}
+
+		tx.Exec(`
+			UPDATE guest_orders SET status = 'PAID', user_id = ?, account_created = ?, paid_at = NOW() WHERE id = ?
+		`, userID, setPasswordCode != "", order.ID)
+
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		fmt.Printf("[GUEST-PAYMENT] ✅ Order %s assigned to user %d (new account: %v)\n", baseOrderID, userID, setPasswordCode != "")
+
+		if len(ownedLines) > 0 {
+			fmt.Printf("[GUEST-PAYMENT] ⚠️ Order %s paid for %d item(s) user %d already owns\n", baseOrderID, len(ownedLines), userID)
+			logReconcileResult(ReconcileResult{
+				OrderID:     baseOrderID,
+				LocalTotal:  order.Total + order.PaymentFee,
+				Action:      helpers.ReconcileReview,
+				Discrepancy: true,
+				Note: fmt.Sprintf("Pembeli tamu (user %d) sudah memiliki: %s; perlu direfund atau dijadikan kredit",
+					userID, strings.Join(ownedLines, ", ")),
+			})
+		}
+	}
+
+	if err := ProcessCartPayment(midtransOrderID, grossAmount); err != nil {
+		return err
+	}
+
+	if setPasswordCode != "" {
+		go func(email, name, code string) {
+			if err := utils.SendGuestAccountEmail(email, name, code); err != nil {
+				fmt.Printf("[GUEST-PAYMENT] ⚠️ Failed to send account email to %s: %v\n", email, err)
+			}
+		}(order.Email, order.Name, setPasswordCode)
+	}
+
+	return nil
+}
+
+// guestItemOwned reports whether the user already has access to a guest order line
+func guestItemOwned(userID int64, item models.GuestOrderItem) bool {
+	if item.ItemType == "SESSION" && item.SessionID != nil {
+		return entitlements.HasAccess(userID, *item.SessionID)
+	}
+	return entitlements.HasEventAccess(userID, item.EventID)
+}
+
+// guestLineOwned is guestItemOwned at settlement: the user's purchase row for the
+// session is locked first, so a PAID purchase is never rewritten by the guest order
+func guestLineOwned(tx *sqlx.Tx, userID int64, item models.GuestOrderItem) bool {
+	if item.ItemType == "SESSION" && item.SessionID != nil {
+		var status string
+		err := tx.Get(&status, "SELECT status FROM purchases WHERE user_id = ? AND session_id = ? FOR UPDATE", userID, *item.SessionID)
+		if err == nil && status == "PAID" {
+			return true
+		}
+	}
+	return guestItemOwned(userID, item)
+}
+
+// guestItemTarget is the session or event a guest order line is for
+func guestItemTarget(item models.GuestOrderItem) int64 {
+	if item.ItemType == "SESSION" && item.SessionID != nil {
+		return *item.SessionID
+	}
+	return item.EventID
+}
+
+// provisionGuestAccount returns the account registered with the guest's email, or creates one.
+// For a new account it also returns the code the buyer uses to set the password.
+func provisionGuestAccount(tx *sqlx.Tx, order models.GuestOrder) (int64, string, error) {
+	var userID int64
+	err := tx.Get(&userID, "SELECT id FROM users WHERE email = ?", order.Email)
+	if err == nil {
+		return userID, "", nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, "", err
+	}
+
+	// Nobody knows this password; the buyer sets their own with the emailed code
+	hash, err := helpers.HashPassword(helpers.GenerateSetPasswordCode() + helpers.GenerateSetPasswordCode())
+	if err != nil {
+		return 0, "", err
+	}
+	res, err := tx.Exec(`
+		INSERT INTO users (name, email, password_hash, phone) VALUES (?, ?, ?, ?)
+	`, order.Name, order.Email, hash, order.Phone)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to create account for %s: %v", order.Email, err)
+	}
+	userID, _ = res.LastInsertId()
+
+	// Same default role as Register
+	tx.Exec("INSERT INTO user_roles (user_id, role_id) VALUES (?, 1)", userID)
+
+	// The regular reset password flow (email + code) sets the first password
+	code := helpers.GenerateSetPasswordCode()
+	_, err = tx.Exec(`
+		INSERT INTO password_reset_tokens (user_id, token, expires_at) VALUES (?, ?, ?)
+	`, userID, code, time.Now().Add(guestSetPasswordTTL))
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to create set-password code for %s: %v", order.Email, err)
+	}
+
+	return userID, code, nil
+}
+
+// closeGuestOrder marks an unpaid guest order FAILED or EXPIRED (no purchases exist yet)
+func closeGuestOrder(orderID, status string) (int64, error) {
+	res, err := config.DB.Exec(`
+		UPDATE guest_orders SET status = ? WHERE order_id = ? AND status = 'PENDING'
+	`, status, strings.Split(orderID, "-AFF-")[0])
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,228 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"BACKEND/config"
+	"BACKEND/media"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// ===============================================
+// IMAGE VARIANTS
+// ===============================================
+
+// ImageInfo describes an uploaded image and its resized variants so clients can
+// pick a size and show a placeholder while it loads
+type ImageInfo struct {
+	URL           string            `json:"url"`
+	Width         int               `json:"width"`
+	Height        int               `json:"height"`
+	Blurhash      string            `json:"blurhash"`
+	DominantColor string            `json:"dominant_color"`
+	Variants      map[string]string `json:"variants"`
+}
+
+// maxImageSourceBytes bounds how much of an original is read for processing
+const maxImageSourceBytes = 32 << 20
+
+// variantKey places a variant next to its original: events/a.png -> events/a_card.jpg
+func variantKey(key, name, ext string) string {
+	return strings.TrimSuffix(key, path.Ext(key)) + "_" + name + ext
+}
+
+// processImageVariants renders the variants of a stored image into the same
+// storage and records them
+func processImageVariants(ctx context.Context, sourceURL string, variants []media.ImageVariant) (*ImageInfo, error) {
+	s, key, ok := resolveStoredObject(sourceURL)
+	if !ok {
+		return nil, fmt.Errorf("unknown storage URL %s", sourceURL)
+	}
+	rc, _, err := s.Get(ctx, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(rc, maxImageSourceBytes))
+	rc.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	processed, err := media.ProcessImage(data, variants)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &ImageInfo{
+		URL:           sourceURL,
+		Width:         processed.Width,
+		Height:        processed.Height,
+		Blurhash:      processed.Blurhash,
+		DominantColor: processed.DominantColor,
+		Variants:      map[string]string{},
+	}
+
+	// Replace variants of an earlier run (their extension may differ)
+	deleteImageVariants(ctx, sourceURL)
+	orgID := storedObjectOrg(s, key)
+
+	for _, v := range processed.Variants {
+		vkey := variantKey(key, v.Name, v.Ext)
+		if err := s.Put(ctx, vkey, bytes.NewReader(v.Data), int64(len(v.Data)), v.ContentType); err != nil {
+			return nil, err
+		}
+		recordStoredObject(s, vkey, orgID, int64(len(v.Data)))
+		url := s.URL(vkey)
+		info.Variants[v.Name] = url
+		config.DB.Exec(`
+			INSERT INTO image_variants (source_url, name, url, width, height, size_bytes)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, sourceURL, v.Name, url, v.Width, v.Height, len(v.Data))
+	}
+
+	_, err = config.DB.Exec(`
+		INSERT INTO image_assets (source_url, width, height, blurhash, dominant_color)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE width = VALUES(width), height = VALUES(height),
+			blurhash = VALUES(blurhash), dominant_color = VALUES(dominant_color)
+	`, sourceURL, info.Width, info.Height, info.Blurhash, info.DominantColor)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// attachImageVariants processes a freshly uploaded image. Failures are logged
+// and return nil: the original stays usable on its own.
+func attachImageVariants(c *gin.Context, sourceURL string, variants []media.ImageVariant) *ImageInfo {
+	info, err := processImageVariants(c.Request.Context(), sourceURL, variants)
+	if err != nil {
+		fmt.Printf("[IMAGE] ❌ Variants for %s failed: %v\n", sourceURL, err)
+		return nil
+	}
+	fmt.Printf("[IMAGE] ✅ %d variants for %s\n", len(info.Variants), sourceURL)
+	return info
+}
+
+// deleteImageVariants removes the variant objects and records of an image
+func deleteImageVariants(ctx context.Context, sourceURL string) {
+	var urls []string
+	config.DB.Select(&urls, "SELECT url FROM image_variants WHERE source_url = ?", sourceURL)
+	for _, url := range urls {
+		if s, key, ok := resolveStoredObject(url); ok {
+			if err := s.Delete(ctx, key); err != nil {
+				fmt.Printf("[IMAGE] ❌ Delete %s failed: %v\n", key, err)
+				continue
+			}
+			forgetStoredObject(s, key)
+		}
+	}
+	config.DB.Exec("DELETE FROM image_variants WHERE source_url = ?", sourceURL)
+	config.DB.Exec("DELETE FROM image_assets WHERE source_url = ?", sourceURL)
+}
+
+// loadImageInfos returns the processed images among urls, keyed by URL
+func loadImageInfos(urls ...string) map[string]*ImageInfo {
+	out := map[string]*ImageInfo{}
+	var wanted []string
+	for _, u := range urls {
+		if u != "" {
+			wanted = append(wanted, u)
+		}
+	}
+	if len(wanted) == 0 {
+		return out
+	}
+
+	var assets []struct {
+		SourceURL     string `db:"source_url"`
+		Width         int    `db:"width"`
+		Height        int    `db:"height"`
+		Blurhash      string `db:"blurhash"`
+		DominantColor string `db:"dominant_color"`
+	}
+	query, args, err := sqlx.In("SELECT source_url, width, height, blurhash, dominant_color FROM image_assets WHERE source_url IN (?)", wanted)
+	if err != nil || config.DB.Select(&assets, query, args...) != nil {
+		return out
+	}
+	for _, a := range assets {
+		out[a.SourceURL] = &ImageInfo{URL: a.SourceURL, Width: a.Width, Height: a.Height, Blurhash: a.Blurhash, DominantColor: a.DominantColor, Variants: map[string]string{}}
+	}
+
+	var variants []struct {
+		SourceURL string `db:"source_url"`
+		Name      string `db:"name"`
+		URL       string `db:"url"`
+	}
+	query, args, _ = sqlx.In("SELECT source_url, name, url FROM image_variants WHERE source_url IN (?)", wanted)
+	config.DB.Select(&variants, query, args...)
+	for _, v := range variants {
+		if info := out[v.SourceURL]; info != nil {
+			info.Variants[v.Name] = v.URL
+		}
+	}
+	return out
+}
+
+// loadImageInfo is loadImageInfos for a single, possibly empty, URL
+func loadImageInfo(url string) *ImageInfo {
+	return loadImageInfos(url)[url]
+}
+
+// imageBackfillSources lists the image columns that get variants
+var imageBackfillSources = []struct {
+	Table    string
+	Column   string
+	Variants []media.ImageVariant
+}{
+	{"events", "thumbnail_url", media.EventThumbnailVariants},
+	{"organizations", "logo_url", media.LogoVariants},
+	{"users", "profile_img", media.ProfileImageVariants},
+	{"ad_banners", "image_url", media.AdBannerVariants},
+}
+
+// ProcessMissingImageVariants - Render variants for images uploaded before variants existed
+// POST /admin/storage/process-images
+func ProcessMissingImageVariants(c *gin.Context) {
+	var input struct {
+		Limit int `json:"limit"`
+	}
+	c.ShouldBindJSON(&input)
+	if input.Limit <= 0 || input.Limit > 500 {
+		input.Limit = 50
+	}
+
+	processed, failed := 0, []gin.H{}
+	for _, src := range imageBackfillSources {
+		remaining := input.Limit - processed - len(failed)
+		if remaining <= 0 {
+			break
+		}
+		var urls []string
+		config.DB.Select(&urls, fmt.Sprintf(`
+			SELECT DISTINCT %[2]s FROM %[1]s
+			WHERE %[2]s IS NOT NULL AND %[2]s != ''
+			AND %[2]s NOT IN (SELECT source_url FROM image_assets)
+			LIMIT ?
+		`, src.Table, src.Column), remaining)
+
+		for _, url := range urls {
+			if _, err := processImageVariants(c.Request.Context(), url, src.Variants); err != nil {
+				failed = append(failed, gin.H{"url": url, "error": err.Error()})
+				continue
+			}
+			processed++
+		}
+	}
+
+	fmt.Printf("[IMAGE] ✅ Backfill processed %d images, %d failed\n", processed, len(failed))
+	c.JSON(http.StatusOK, gin.H{"processed": processed, "failed": failed})
+}
@@ -0,0 +1,361 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"BACKEND/config"
+	"BACKEND/helpers"
+	"BACKEND/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ===============================================
+// INVOICES
+// ===============================================
+
+// invoiceRecord is a row of the invoices table (buyer data is a snapshot taken at issue time)
+type invoiceRecord struct {
+	ID            int64      `db:"id"`
+	InvoiceNumber string     `db:"invoice_number"`
+	OrderID       string     `db:"order_id"`
+	UserID        int64      `db:"user_id"`
+	BuyerName     string     `db:"buyer_name"`
+	BuyerEmail    string     `db:"buyer_email"`
+	BuyerPhone    string     `db:"buyer_phone"`
+	TotalAmount   int64      `db:"total_amount"`
+	IssuedAt      time.Time  `db:"issued_at"`
+	EmailedAt     *time.Time `db:"emailed_at"`
+}
+
+const invoiceColumns = `id, invoice_number, order_id, user_id, buyer_name, buyer_email,
+	COALESCE(buyer_phone, '') as buyer_phone, total_amount, issued_at, emailed_at`
+
+// ensureInvoice returns the invoice of a paid order, issuing the next number of
+// the current year on first use
+func ensureInvoice(orderID string) (*invoiceRecord, error) {
+	var inv invoiceRecord
+	err := config.DB.Get(&inv, "SELECT "+invoiceColumns+" FROM invoices WHERE order_id = ?", orderID)
+	if err == nil {
+		return &inv, nil
+	}
+
+	var buyer struct {
+		UserID int64  `db:"user_id"`
+		Name   string `db:"name"`
+		Email  string `db:"email"`
+		Phone  string `db:"phone"`
+	}
+	err = config.DB.Get(&buyer, `
+		SELECT p.user_id, u.name, u.email, COALESCE(u.phone, '') as phone
+		FROM purchases p
+		JOIN users u ON p.user_id = u.id
+		WHERE p.order_id = ? AND p.status = 'PAID'
+		LIMIT 1
+	`, orderID)
+	if err != nil {
+		return nil, errors.New("order belum dibayar")
+	}
+
+	var totals struct {
+		Subtotal int64 `db:"subtotal"`
+		Discount int64 `db:"discount"`
+		Total    int64 `db:"total"`
+		Tax      int64 `db:"tax"`
+	}
+	config.DB.Get(&totals, `
+		SELECT COALESCE(SUM(price_paid + COALESCE(discount_amount, 0) + COALESCE(payment_fee, 0)), 0) as subtotal,
+			COALESCE(SUM(discount_amount), 0) as discount,
+			COALESCE(SUM(price_paid + COALESCE(payment_fee, 0)), 0) as total,
+			COALESCE(SUM(tax_amount), 0) as tax
+		FROM purchases
+		WHERE order_id = ? AND status = 'PAID'
+	`, orderID)
+
+	tx, err := config.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// The sequence row stays locked until commit, so numbers are gapless per year
+	year := time.Now().Year()
+	_, err = tx.Exec(`
+		INSERT INTO invoice_sequences (year, last_number) VALUES (?, 1)
+		ON DUPLICATE KEY UPDATE last_number = last_number + 1
+	`, year)
+	if err != nil {
+		return nil, err
+	}
+	var sequence int64
+	if err := tx.Get(&sequence, "SELECT last_number FROM invoice_sequences WHERE year = ?", year); err != nil {
+		return nil, err
+	}
+
+	number := helpers.FormatInvoiceNumber(year, sequence)
+	_, err = tx.Exec(`
+		INSERT INTO invoices (invoice_number, invoice_year, sequence_number, order_id, user_id,
+			buyer_name, buyer_email, buyer_phone, subtotal, discount_amount, total_amount, tax_amount)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, number, year, sequence, orderID, buyer.UserID, buyer.Name, buyer.Email, buyer.Phone,
+		totals.Subtotal, totals.Discount, totals.Total, totals.Tax)
+	if err != nil {
+		// Another request issued the invoice first - use that one (our number is rolled back)
+		tx.Rollback()
+		if getErr := config.DB.Get(&inv, "SELECT "+invoiceColumns+" FROM invoices WHERE order_id = ?", orderID); getErr == nil {
+			return &inv, nil
+		}
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("[INVOICE] ✅ Issued %s for order %s\n", number, orderID)
+
+	err = config.DB.Get(&inv, "SELECT "+invoiceColumns+" FROM invoices WHERE order_id = ?", orderID)
+	return &inv, err
+}
+
+// buildInvoice collects the line items of an invoice. When orgID > 0 only the
+// items sold by that organization are included (seller copy).
+func buildInvoice(inv *invoiceRecord, orgID int64) helpers.Invoice {
+	var rows []struct {
+		SessionTitle  string `db:"session_title"`
+		EventTitle    string `db:"event_title"`
+		OrgID         int64  `db:"org_id"`
+		OrgName       string `db:"org_name"`
+		OrgEmail      string `db:"org_email"`
+		OrgPhone      string `db:"org_phone"`
+		OrgAddress    string `db:"org_address"`
+		PricePaid     int64  `db:"price_paid"`
+		Discount      int64  `db:"discount_amount"`
+		TaxAmount     int64  `db:"tax_amount"`
+		CouponCode    string `db:"coupon_code"`
+		AffiliateCode string `db:"affiliate_code"`
+		IsGift        bool   `db:"is_gift"`
+		Quantity      int    `db:"quantity"`
+		IsLicense     bool   `db:"is_license"`
+	}
+
+	query := `
+		SELECT COALESCE(s.title, lp.name, 'Paket Lengkap') as session_title, e.title as event_title,
+			o.id as org_id, COALESCE(o.name, '') as org_name, COALESCE(o.email, '') as org_email,
+			COALESCE(o.phone, '') as org_phone, COALESCE(o.address, '') as org_address,
+			p.price_paid, COALESCE(p.discount_amount, 0) as discount_amount, COALESCE(p.tax_amount, 0) as tax_amount,
+			COALESCE(p.coupon_code, '') as coupon_code, COALESCE(p.affiliate_code, '') as affiliate_code,
+			COALESCE(p.is_gift, 0) as is_gift, COALESCE(p.quantity, 1) as quantity,
+			p.license_pool_id IS NOT NULL as is_license
+		FROM purchases p
+		LEFT JOIN sessions s ON p.session_id = s.id
+		LEFT JOIN license_pools lp ON p.license_pool_id = lp.id
+		JOIN events e ON e.id = COALESCE(p.event_id, s.event_id)
+		JOIN organizations o ON e.organization_id = o.id
+		WHERE p.order_id = ? AND p.status = 'PAID'`
+	args := []interface{}{inv.OrderID}
+	if orgID > 0 {
+		query += " AND o.id = ?"
+		args = append(args, orgID)
+	}
+	config.DB.Select(&rows, query+" ORDER BY p.id", args...)
+
+	invoice := helpers.Invoice{
+		Number:   inv.InvoiceNumber,
+		OrderID:  inv.OrderID,
+		IssuedAt: inv.IssuedAt,
+		Buyer: helpers.InvoiceParty{
+			Name:  inv.BuyerName,
+			Email: inv.BuyerEmail,
+			Phone: inv.BuyerPhone,
+		},
+	}
+	if orgID > 0 {
+		invoice.CopyLabel = "Salinan Penjual"
+	}
+
+	seenOrg := map[int64]bool{}
+	for _, r := range rows {
+		description := r.EventTitle + " - " + r.SessionTitle
+		if r.IsGift {
+			description = fmt.Sprintf("Hadiah %dx: %s", r.Quantity, description)
+		} else if r.IsLicense {
+			description = fmt.Sprintf("Lisensi %d kursi: %s", r.Quantity, description)
+		}
+		invoice.Lines = append(invoice.Lines, helpers.InvoiceLine{
+			Description: description,
+			Seller:      r.OrgName,
+			UnitPrice:   r.PricePaid + r.Discount,
+			Discount:    r.Discount,
+			Total:       r.PricePaid,
+		})
+		invoice.TaxAmount += r.TaxAmount
+
+		if !seenOrg[r.OrgID] {
+			seenOrg[r.OrgID] = true
+			invoice.Sellers = append(invoice.Sellers, helpers.InvoiceParty{
+				Name:    r.OrgName,
+				Email:   r.OrgEmail,
+				Phone:   r.OrgPhone,
+				Address: r.OrgAddress,
+			})
+		}
+		if r.CouponCode != "" {
+			invoice.CouponCode = r.CouponCode
+		}
+		if r.AffiliateCode != "" {
+			invoice.AffiliateCode = r.AffiliateCode
+		}
+	}
+
+	// PPN is included in the price, so the rate is tax / (total - tax)
+	_, _, total := invoice.Totals()
+	if invoice.TaxAmount > 0 && total > invoice.TaxAmount {
+		invoice.TaxPercent = math.Round(float64(invoice.TaxAmount) / float64(total-invoice.TaxAmount) * 100)
+	}
+
+	// The payment method surcharge is charged by the platform, so only the buyer copy shows it
+	if orgID == 0 {
+		var fee struct {
+			Method string `db:"payment_method"`
+			Amount int64  `db:"payment_fee"`
+		}
+		config.DB.Get(&fee, `
+			SELECT COALESCE(MAX(payment_method), '') as payment_method, COALESCE(SUM(payment_fee), 0) as payment_fee
+			FROM purchases WHERE order_id = ? AND status = 'PAID'
+		`, inv.OrderID)
+		if fee.Amount > 0 {
+			label := fee.Method
+			if m, ok := helpers.FindPaymentMethod(fee.Method); ok {
+				label = m.Label
+			}
+			invoice.Lines = append(invoice.Lines, helpers.InvoiceLine{
+				Description: "Biaya metode pembayaran " + label,
+				Seller:      "Webbinar",
+				UnitPrice:   fee.Amount,
+				Total:       fee.Amount,
+			})
+		}
+	}
+
+	return invoice
+}
+
+// writeInvoicePDF renders the invoice and sends it as a file download
+func writeInvoicePDF(c *gin.Context, invoice helpers.Invoice) {
+	pdf, err := helpers.RenderInvoicePDF(invoice)
+	if err != nil {
+		fmt.Printf("[INVOICE] ❌ Error rendering %s: %v\n", invoice.Number, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat invoice"})
+		return
+	}
+
+	fileName := strings.ReplaceAll(invoice.Number, "/", "-") + ".pdf"
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", fileName))
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+// sendInvoiceEmail issues the invoice of a paid order and emails it to the buyer once
+func sendInvoiceEmail(orderID string) {
+	inv, err := ensureInvoice(orderID)
+	if err != nil {
+		fmt.Printf("[INVOICE] ⚠️ Cannot issue invoice for %s: %v\n", orderID, err)
+		return
+	}
+
+	// Claim the send so concurrent payment callbacks don't email twice
+	result, err := config.DB.Exec("UPDATE invoices SET emailed_at = NOW() WHERE id = ? AND emailed_at IS NULL", inv.ID)
+	if err != nil {
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return
+	}
+
+	invoice := buildInvoice(inv, 0)
+	pdf, err := helpers.RenderInvoicePDF(invoice)
+	if err == nil {
+		_, _, total := invoice.Totals()
+		err = utils.SendInvoiceEmail(inv.BuyerEmail, inv.BuyerName, inv.InvoiceNumber, helpers.FormatRupiah(total), pdf)
+	}
+	if err != nil {
+		fmt.Printf("[INVOICE] ❌ Failed to email %s: %v\n", inv.InvoiceNumber, err)
+		config.DB.Exec("UPDATE invoices SET emailed_at = NULL WHERE id = ?", inv.ID)
+	}
+}
+
+// DownloadPaymentInvoice - Download invoice PDF for one of the user's paid payments
+// GET /user/payments/:id/invoice
+func DownloadPaymentInvoice(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	paymentID := c.Param("id")
+
+	var payment struct {
+		Status  string  `db:"status"`
+		OrderID *string `db:"order_id"`
+	}
+	err := config.DB.Get(&payment, "SELECT status, order_id FROM purchases WHERE id = ? AND user_id = ?", paymentID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payment not found"})
+		return
+	}
+	if payment.Status != "PAID" || payment.OrderID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invoice hanya tersedia untuk pembayaran yang sudah lunas"})
+		return
+	}
+
+	inv, err := ensureInvoice(*payment.OrderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat invoice"})
+		return
+	}
+
+	writeInvoicePDF(c, buildInvoice(inv, 0))
+}
+
+// DownloadBuyerInvoice - Download seller copy of a buyer's invoice (only this org's items)
+// GET /organization/events/:eventID/buyers/:purchaseID/invoice
+func DownloadBuyerInvoice(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	eventID := c.Param("eventID")
+	purchaseID := c.Param("purchaseID")
+
+	orgID, err := getOrganizationIDByUser(userID)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Organisasi tidak ditemukan"})
+		return
+	}
+
+	var purchase struct {
+		Status  string  `db:"status"`
+		OrderID *string `db:"order_id"`
+	}
+	err = config.DB.Get(&purchase, `
+		SELECT p.status, p.order_id
+		FROM purchases p
+		LEFT JOIN sessions s ON p.session_id = s.id
+		JOIN events e ON e.id = COALESCE(p.event_id, s.event_id)
+		WHERE p.id = ? AND e.id = ? AND e.organization_id = ?
+	`, purchaseID, eventID, orgID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pembelian tidak ditemukan"})
+		return
+	}
+	if purchase.Status != "PAID" || purchase.OrderID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invoice hanya tersedia untuk pembayaran yang sudah lunas"})
+		return
+	}
+
+	inv, err := ensureInvoice(*purchase.OrderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat invoice"})
+		return
+	}
+
+	writeInvoicePDF(c, buildInvoice(inv, orgID))
+}
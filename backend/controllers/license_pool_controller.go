@@ -0,0 +1,513 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"BACKEND/config"
+	"BACKEND/entitlements"
+	"BACKEND/helpers"
+	"BACKEND/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// ===============================================
+// LICENSE POOLS (B2B BULK SEATS)
+// ===============================================
+
+const licensePoolColumns = `id, organization_id, owner_user_id, event_id, name, seats_total, unit_price,
+	COALESCE(status, 'OFFERED') as status, order_id, expires_at, note, created_by, paid_at, created_at, updated_at`
+
+// LicensePoolView is a pool with names and seat usage for lists
+type LicensePoolView struct {
+	models.LicensePool
+	EventTitle     string `db:"event_title" json:"event_title"`
+	OrgName        string `db:"org_name" json:"org_name"`
+	BuyerName      string `db:"buyer_name" json:"buyer_name"`
+	BuyerEmail     string `db:"buyer_email" json:"buyer_email"`
+	SeatsUsed      int    `db:"seats_used" json:"seats_used"`
+	SeatsAvailable int    `db:"-" json:"seats_available"`
+}
+
+const licensePoolViewQuery = `
+	SELECT lp.id, lp.organization_id, lp.owner_user_id, lp.event_id, lp.name, lp.seats_total, lp.unit_price,
+		COALESCE(lp.status, 'OFFERED') as status, lp.order_id, lp.expires_at, lp.note, lp.created_by,
+		lp.paid_at, lp.created_at, lp.updated_at,
+		e.title as event_title, COALESCE(o.name, '') as org_name,
+		u.name as buyer_name, u.email as buyer_email,
+		(SELECT COUNT(*) FROM license_seats ls WHERE ls.pool_id = lp.id AND ls.unassigned_at IS NULL) as seats_used
+	FROM license_pools lp
+	JOIN events e ON e.id = lp.event_id
+	JOIN organizations o ON o.id = lp.organization_id
+	JOIN users u ON u.id = lp.owner_user_id`
+
+// licensePoolRef is the entitlement source_ref shared by every seat of a pool
+func licensePoolRef(poolID int64) string {
+	return fmt.Sprintf("POOL-%d", poolID)
+}
+
+// selectLicensePools runs the view query and fills in the free seats
+func selectLicensePools(where string, args ...interface{}) ([]LicensePoolView, error) {
+	pools := []LicensePoolView{}
+	err := config.DB.Select(&pools, licensePoolViewQuery+" WHERE "+where+" ORDER BY lp.created_at DESC, lp.id DESC", args...)
+	for i := range pools {
+		pools[i].SeatsAvailable = pools[i].SeatsTotal - pools[i].SeatsUsed
+	}
+	return pools, err
+}
+
+// getBuyerLicensePool reads :id and returns it when the caller owns the pool
+func getBuyerLicensePool(c *gin.Context) (*LicensePoolView, bool) {
+	poolID, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	pools, err := selectLicensePools("lp.id = ? AND lp.owner_user_id = ?", poolID, c.GetInt64("user_id"))
+	if err != nil || len(pools) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lisensi tidak ditemukan"})
+		return nil, false
+	}
+	return &pools[0], true
+}
+
+// activateLicensePools marks the pools paid by an order as ACTIVE so seats can be assigned
+func activateLicensePools(tx *sqlx.Tx, orderID string) {
+	_, err := tx.Exec(`
+		UPDATE license_pools lp
+		JOIN purchases p ON p.license_pool_id = lp.id
+		SET lp.status = 'ACTIVE', lp.paid_at = NOW()
+		WHERE p.order_id = ? AND p.status = 'PAID' AND lp.status = 'OFFERED'
+	`, orderID)
+	if err != nil {
+		fmt.Printf("[LICENSE] ❌ Error activating pools of %s: %v\n", orderID, err)
+	}
+}
+
+// addLicensePoolToCart puts an offered pool in the buyer's cart at its negotiated price
+func addLicensePoolToCart(c *gin.Context, userID, cartID, poolID int64) {
+	var pool models.LicensePool
+	err := config.DB.Get(&pool, "SELECT "+licensePoolColumns+" FROM license_pools WHERE id = ? AND owner_user_id = ?", poolID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lisensi tidak ditemukan"})
+		return
+	}
+	if pool.Status != "OFFERED" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Lisensi ini sudah dibayar atau dibatalkan"})
+		return
+	}
+
+	var inCart int
+	config.DB.Get(&inCart, "SELECT COUNT(*) FROM cart_items WHERE cart_id = ? AND license_pool_id = ?", cartID, poolID)
+	if inCart > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Lisensi sudah ada di keranjang"})
+		return
+	}
+
+	// Seats are the quantity, the negotiated seat price is the unit price
+	_, err = config.DB.Exec(`
+		INSERT INTO cart_items (cart_id, item_type, event_id, license_pool_id, price, quantity)
+		VALUES (?, 'LICENSE_POOL', ?, ?, ?, ?)
+	`, cartID, pool.EventID, pool.ID, pool.UnitPrice, pool.SeatsTotal)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menambahkan ke keranjang"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Lisensi ditambahkan ke keranjang"})
+}
+
+// ===============================================
+// ORGANIZATION: OFFER POOLS
+// ===============================================
+
+// OrgCreateLicensePool - Offer a block of seats at a negotiated price to a buyer account
+// POST /organization/license-pools
+func OrgCreateLicensePool(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	var input struct {
+		BuyerEmail string  `json:"buyer_email" binding:"required"`
+		EventID    int64   `json:"event_id" binding:"required"`
+		Name       string  `json:"name"`
+		Seats      int     `json:"seats" binding:"required"`
+		UnitPrice  float64 `json:"unit_price" binding:"required"`
+		ExpiresAt  string  `json:"expires_at"` // seat access end, empty = lifetime
+		Note       string  `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid"})
+		return
+	}
+
+	orgID, err := getOrganizationIDByUser(userID)
+	if err != nil || !checkEventOwnedByUser(input.EventID, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Event tidak ditemukan atau bukan milik organisasi Anda"})
+		return
+	}
+	if input.Seats < helpers.MinLicenseSeats || input.Seats > helpers.MaxLicenseSeats {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Jumlah kursi harus antara %d dan %d", helpers.MinLicenseSeats, helpers.MaxLicenseSeats)})
+		return
+	}
+	if input.UnitPrice <= 0 || input.UnitPrice*float64(input.Seats) < 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Total harga lisensi minimal Rp 100"})
+		return
+	}
+	if !helpers.IsWholeRupiah(input.UnitPrice) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Harga harus dalam rupiah bulat"})
+		return
+	}
+	expiresAt, err := parseAccessExpiry(input.ExpiresAt)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var buyer struct {
+		ID   int64  `db:"id"`
+		Name string `db:"name"`
+	}
+	if err := config.DB.Get(&buyer, "SELECT id, name FROM users WHERE email = ?", strings.ToLower(strings.TrimSpace(input.BuyerEmail))); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Akun pembeli tidak ditemukan, minta pembeli mendaftar terlebih dahulu"})
+		return
+	}
+
+	var eventTitle string
+	config.DB.Get(&eventTitle, "SELECT title FROM events WHERE id = ?", input.EventID)
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		name = fmt.Sprintf("%s - %d kursi", eventTitle, input.Seats)
+	}
+	var note *string
+	if n := strings.TrimSpace(input.Note); n != "" {
+		note = &n
+	}
+
+	result, err := config.DB.Exec(`
+		INSERT INTO license_pools (organization_id, owner_user_id, event_id, name, seats_total, unit_price, expires_at, note, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, orgID, buyer.ID, input.EventID, name, input.Seats, input.UnitPrice, expiresAt, note, userID)
+	if err != nil {
+		fmt.Printf("[LICENSE] ❌ Error creating pool: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat lisensi"})
+		return
+	}
+	poolID, _ := result.LastInsertId()
+
+	CreateNotification(
+		buyer.ID,
+		"license_offer",
+		"📄 Penawaran Lisensi",
+		fmt.Sprintf("Anda mendapat penawaran %d kursi \"%s\" seharga %s. Tambahkan ke keranjang dari menu Lisensi.",
+			input.Seats, eventTitle, helpers.FormatRupiah(helpers.Rupiah(input.UnitPrice)*int64(input.Seats))),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Penawaran lisensi berhasil dibuat",
+		"id":      poolID,
+	})
+}
+
+// OrgGetLicensePools - List the organization's license pools with seat usage
+// GET /organization/license-pools
+func OrgGetLicensePools(c *gin.Context) {
+	orgID, err := getOrganizationIDByUser(c.GetInt64("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Organisasi tidak ditemukan"})
+		return
+	}
+
+	pools, err := selectLicensePools("lp.organization_id = ?", orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data lisensi"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"license_pools": pools})
+}
+
+// OrgCancelLicensePool - Withdraw an offer that has not been paid yet
+// PUT /organization/license-pools/:id/cancel
+func OrgCancelLicensePool(c *gin.Context) {
+	orgID, err := getOrganizationIDByUser(c.GetInt64("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Organisasi tidak ditemukan"})
+		return
+	}
+	poolID, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	res, err := config.DB.Exec(`
+		UPDATE license_pools SET status = 'CANCELLED'
+		WHERE id = ? AND organization_id = ? AND status = 'OFFERED'
+	`, poolID, orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membatalkan penawaran lisensi"})
+		return
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Lisensi tidak ditemukan atau sudah dibayar"})
+		return
+	}
+	config.DB.Exec("DELETE FROM cart_items WHERE license_pool_id = ?", poolID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Penawaran lisensi dibatalkan"})
+}
+
+// ===============================================
+// BUYER: MANAGE SEATS
+// ===============================================
+
+// GetMyLicensePools - License pools owned by the current user
+// GET /user/license-pools
+func GetMyLicensePools(c *gin.Context) {
+	pools, err := selectLicensePools("lp.owner_user_id = ? AND lp.status <> 'CANCELLED'", c.GetInt64("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data lisensi"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"license_pools": pools})
+}
+
+// GetLicensePoolDetail - Seat usage plus quiz/certificate progress of every assignee
+// GET /user/license-pools/:id
+func GetLicensePoolDetail(c *gin.Context) {
+	pool, ok := getBuyerLicensePool(c)
+	if !ok {
+		return
+	}
+
+	type SeatView struct {
+		UserID           int64     `db:"user_id" json:"user_id"`
+		Name             string    `db:"name" json:"name"`
+		Email            string    `db:"email" json:"email"`
+		AssignedAt       time.Time `db:"assigned_at" json:"assigned_at"`
+		QuizzesCompleted int       `db:"-" json:"quizzes_completed"`
+		ProgressPercent  float64   `db:"-" json:"progress_percent"`
+		Certified        bool      `db:"-" json:"certified"`
+	}
+	seats := []SeatView{}
+	config.DB.Select(&seats, `
+		SELECT ls.user_id, u.name, u.email, ls.assigned_at
+		FROM license_seats ls
+		JOIN users u ON u.id = ls.user_id
+		WHERE ls.pool_id = ? AND ls.unassigned_at IS NULL
+		ORDER BY ls.assigned_at ASC
+	`, pool.ID)
+
+	// Best score per quiz of every assignee, same rule as the learner's own progress page
+	var totalQuizzes int
+	config.DB.Get(&totalQuizzes, `
+		SELECT COUNT(*) FROM session_quizzes sq
+		JOIN sessions s ON s.id = sq.session_id
+		WHERE s.event_id = ? AND sq.is_enabled = 1
+	`, pool.EventID)
+
+	var scores []struct {
+		UserID int64   `db:"user_id"`
+		Best   float64 `db:"best"`
+	}
+	config.DB.Select(&scores, `
+		SELECT qa.user_id, MAX(qa.score_percent) as best
+		FROM quiz_attempts qa
+		JOIN session_quizzes sq ON sq.id = qa.quiz_id AND sq.is_enabled = 1
+		JOIN sessions s ON s.id = sq.session_id
+		JOIN license_seats ls ON ls.user_id = qa.user_id AND ls.pool_id = ? AND ls.unassigned_at IS NULL
+		WHERE s.event_id = ?
+		GROUP BY qa.user_id, qa.quiz_id
+	`, pool.ID, pool.EventID)
+	bestScores := map[int64][]float64{}
+	for _, s := range scores {
+		bestScores[s.UserID] = append(bestScores[s.UserID], s.Best)
+	}
+
+	var certified []int64
+	config.DB.Select(&certified, `
+		SELECT uc.user_id FROM user_certificates uc
+		JOIN license_seats ls ON ls.user_id = uc.user_id AND ls.pool_id = ? AND ls.unassigned_at IS NULL
+		WHERE uc.event_id = ?
+	`, pool.ID, pool.EventID)
+	certifiedUsers := map[int64]bool{}
+	for _, id := range certified {
+		certifiedUsers[id] = true
+	}
+
+	progress := make([]helpers.SeatProgress, len(seats))
+	for i := range seats {
+		seats[i].QuizzesCompleted = len(bestScores[seats[i].UserID])
+		seats[i].ProgressPercent = helpers.EventProgressPercent(bestScores[seats[i].UserID], totalQuizzes)
+		seats[i].Certified = certifiedUsers[seats[i].UserID]
+		progress[i] = helpers.SeatProgress{
+			ProgressPercent:  seats[i].ProgressPercent,
+			QuizzesCompleted: seats[i].QuizzesCompleted,
+			Certified:        seats[i].Certified,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"license_pool":  pool,
+		"seats":         seats,
+		"total_quizzes": totalQuizzes,
+		"progress":      helpers.SummarizePoolProgress(progress),
+	})
+}
+
+// AssignLicenseSeats - Give seats of a paid pool to registered users by email
+// POST /user/license-pools/:id/seats
+func AssignLicenseSeats(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	poolID, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	var input struct {
+		Emails []string `json:"emails" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Daftar email wajib diisi"})
+		return
+	}
+	emails, err := helpers.CleanRecipientEmails(input.Emails)
+	if err != nil || len(emails) == 0 {
+		msg := "Daftar email wajib diisi"
+		if err != nil {
+			msg = err.Error()
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	tx, _ := config.DB.Beginx()
+	defer tx.Rollback()
+
+	// Lock the pool so parallel requests cannot hand out more seats than were bought
+	var pool models.LicensePool
+	err = tx.Get(&pool, "SELECT "+licensePoolColumns+" FROM license_pools WHERE id = ? AND owner_user_id = ? FOR UPDATE", poolID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lisensi tidak ditemukan"})
+		return
+	}
+	if pool.Status != "ACTIVE" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Lisensi belum dibayar atau sudah dibatalkan"})
+		return
+	}
+	if pool.ExpiresAt != nil && pool.ExpiresAt.Before(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Masa berlaku lisensi sudah habis"})
+		return
+	}
+
+	var used int
+	tx.Get(&used, "SELECT COUNT(*) FROM license_seats WHERE pool_id = ? AND unassigned_at IS NULL", pool.ID)
+
+	assigned, alreadyAssigned, notFound, noSeat := []string{}, []string{}, []string{}, []string{}
+	var assignedUsers []int64
+	for _, email := range emails {
+		var assigneeID int64
+		if err := tx.Get(&assigneeID, "SELECT id FROM users WHERE email = ?", email); err != nil {
+			notFound = append(notFound, email)
+			continue
+		}
+
+		var active int
+		tx.Get(&active, "SELECT COUNT(*) FROM license_seats WHERE pool_id = ? AND user_id = ? AND unassigned_at IS NULL", pool.ID, assigneeID)
+		if active > 0 {
+			alreadyAssigned = append(alreadyAssigned, email)
+			continue
+		}
+		if used >= pool.SeatsTotal {
+			noSeat = append(noSeat, email)
+			continue
+		}
+
+		_, err := tx.Exec(`
+			INSERT INTO license_seats (pool_id, user_id, assigned_by)
+			VALUES (?, ?, ?)
+			ON DUPLICATE KEY UPDATE unassigned_at = NULL, assigned_at = NOW(), assigned_by = VALUES(assigned_by)
+		`, pool.ID, assigneeID, userID)
+		if err == nil {
+			err = entitlements.GrantAccess(tx, entitlements.Grant{
+				UserID:    assigneeID,
+				EventID:   pool.EventID,
+				Source:    entitlements.SourceLicense,
+				SourceRef: licensePoolRef(pool.ID),
+				ExpiresAt: pool.ExpiresAt,
+				Note:      pool.Name,
+			})
+		}
+		if err != nil {
+			fmt.Printf("[LICENSE] ❌ Error assigning seat of pool %d to user %d: %v\n", pool.ID, assigneeID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memberikan kursi lisensi"})
+			return
+		}
+
+		used++
+		assigned = append(assigned, email)
+		assignedUsers = append(assignedUsers, assigneeID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memberikan kursi lisensi"})
+		return
+	}
+
+	var eventTitle string
+	config.DB.Get(&eventTitle, "SELECT title FROM events WHERE id = ?", pool.EventID)
+	for _, id := range assignedUsers {
+		CreateNotification(
+			id,
+			"access_granted",
+			"🎟️ Akses Lisensi Diberikan",
+			fmt.Sprintf("Anda mendapat akses ke \"%s\" melalui lisensi %s. Silakan cek menu Kursus Saya.", eventTitle, pool.Name),
+		)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":          fmt.Sprintf("%d kursi berhasil diberikan", len(assigned)),
+		"assigned":         assigned,
+		"already_assigned": alreadyAssigned,
+		"not_found":        notFound,
+		"no_seat":          noSeat,
+		"seats_used":       used,
+		"seats_total":      pool.SeatsTotal,
+	})
+}
+
+// UnassignLicenseSeat - Take a seat back so it can be given to someone else
+// DELETE /user/license-pools/:id/seats/:userID
+func UnassignLicenseSeat(c *gin.Context) {
+	pool, ok := getBuyerLicensePool(c)
+	if !ok {
+		return
+	}
+	assigneeID, _ := strconv.ParseInt(c.Param("userID"), 10, 64)
+
+	tx, err := config.DB.Beginx()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal melepas kursi lisensi"})
+		return
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE license_seats SET unassigned_at = NOW()
+		WHERE pool_id = ? AND user_id = ? AND unassigned_at IS NULL
+	`, pool.ID, assigneeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal melepas kursi lisensi"})
+		return
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Kursi tidak ditemukan"})
+		return
+	}
+	if _, err := entitlements.RevokeUserBySource(tx, assigneeID, entitlements.SourceLicense, licensePoolRef(pool.ID), "Kursi lisensi dilepas oleh pemilik"); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal melepas kursi lisensi"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal melepas kursi lisensi"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Kursi lisensi dilepas"})
+}
@@ -209,8 +209,8 @@ func CreateSession(c *gin.Context) {
 		config.DB.Select(&buyerIDs, `
 			SELECT DISTINCT p.user_id 
 			FROM purchases p
-			JOIN sessions s ON p.session_id = s.id
-			WHERE s.event_id = ?
+			LEFT JOIN sessions s ON p.session_id = s.id
+			WHERE COALESCE(p.event_id, s.event_id) = ? AND COALESCE(p.is_gift, 0) = 0 AND p.license_pool_id IS NULL
 		`, eventID)
 
 		// Send notification to each buyer
@@ -9,8 +9,9 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"BACKEND/config"
+	"BACKEND/filecheck"
+	"BACKEND/media"
 	"BACKEND/models"
-	"BACKEND/utils"
 )
 
 // =======================================
@@ -29,6 +30,8 @@ func GetPublicOrganizations(c *gin.Context) {
 		SocialLink  *string `db:"social_link" json:"social_link"`
 		Address     *string `db:"address" json:"address"`
 		EventCount  int     `db:"event_count" json:"event_count"`
+
+		Logo *ImageInfo `db:"-" json:"logo"`
 	}
 
 	err := config.DB.Select(&organizations, `
@@ -45,6 +48,19 @@ func GetPublicOrganizations(c *gin.Context) {
 		return
 	}
 
+	var logoURLs []string
+	for _, o := range organizations {
+		if o.LogoURL != nil {
+			logoURLs = append(logoURLs, *o.LogoURL)
+		}
+	}
+	logos := loadImageInfos(logoURLs...)
+	for i := range organizations {
+		if organizations[i].LogoURL != nil {
+			organizations[i].Logo = logos[*organizations[i].LogoURL]
+		}
+	}
+
 	c.JSON(200, gin.H{"organizations": organizations})
 }
 
@@ -85,6 +101,7 @@ func GetOrganizationProfile(c *gin.Context) {
 		 COALESCE(social_link, '') AS social_link,
 		 COALESCE(address, '') AS address,
 		 COALESCE(is_official, 0) AS is_official,
+		 COALESCE(currency, 'IDR') AS currency,
 		 created_at
 		FROM organizations WHERE owner_user_id = ?
 	`, userID)
@@ -208,13 +225,15 @@ func GetOrganizationReport(c *gin.Context) {
 		Buyers              int     `json:"buyers"`
 		GrossRevenue        float64 `json:"gross_revenue"`
 		AffiliateCommission float64 `json:"affiliate_commission"`
+		PlatformFee         float64 `json:"platform_fee"`
+		TaxAmount           float64 `json:"tax_amount"`
 		NetRevenue          float64 `json:"net_revenue"`
 		CreatedAt           string  `json:"created_at"`
 	}
 
 	var events []EventStat
 	var totalBuyers int
-	var totalGrossRevenue, totalAffiliateCommission, totalNetRevenue float64
+	var totalGrossRevenue, totalAffiliateCommission, totalPlatformFee, totalTax, totalNetRevenue float64
 
 	for _, eb := range eventsBasic {
 		var buyers int
@@ -224,26 +243,38 @@ func GetOrganizationReport(c *gin.Context) {
 		config.DB.Get(&buyers, `
 			SELECT COUNT(DISTINCT p.user_id) 
 			FROM purchases p 
-			JOIN sessions s ON p.session_id = s.id 
-			WHERE s.event_id = ? AND p.status = 'PAID'
+			LEFT JOIN sessions s ON p.session_id = s.id 
+			WHERE COALESCE(p.event_id, s.event_id) = ? AND p.status = 'PAID'
 		`, eb.ID)
 
 		config.DB.Get(&grossRevenue, `
 			SELECT COALESCE(SUM(p.price_paid), 0) 
 			FROM purchases p 
-			JOIN sessions s ON p.session_id = s.id 
-			WHERE s.event_id = ? AND p.status = 'PAID'
+			LEFT JOIN sessions s ON p.session_id = s.id 
+			WHERE COALESCE(p.event_id, s.event_id) = ? AND p.status = 'PAID'
 		`, eb.ID)
 
 		config.DB.Get(&affiliateCommission, `
 			SELECT COALESCE(SUM(p.price_paid * COALESCE(ap.commission_percentage, 0) / 100), 0)
 			FROM purchases p 
-			JOIN sessions s ON p.session_id = s.id 
-			LEFT JOIN affiliate_partnerships ap ON p.affiliate_code = ap.unique_code AND ap.event_id = s.event_id
-			WHERE s.event_id = ? AND p.status = 'PAID'
+			LEFT JOIN sessions s ON p.session_id = s.id 
+			LEFT JOIN affiliate_partnerships ap ON p.affiliate_code = ap.unique_code AND ap.event_id = COALESCE(p.event_id, s.event_id)
+			WHERE COALESCE(p.event_id, s.event_id) = ? AND p.status = 'PAID'
 		`, eb.ID)
 
-		netRevenue := grossRevenue - affiliateCommission
+		// Platform fee & tax breakdown stored per purchase when paid
+		var fees struct {
+			PlatformFee float64 `db:"platform_fee"`
+			TaxAmount   float64 `db:"tax_amount"`
+		}
+		config.DB.Get(&fees, `
+			SELECT COALESCE(SUM(p.platform_fee), 0) as platform_fee, COALESCE(SUM(p.tax_amount), 0) as tax_amount
+			FROM purchases p 
+			LEFT JOIN sessions s ON p.session_id = s.id 
+			WHERE COALESCE(p.event_id, s.event_id) = ? AND p.status = 'PAID'
+		`, eb.ID)
+
+		netRevenue := grossRevenue - affiliateCommission - fees.PlatformFee - fees.TaxAmount
 
 		events = append(events, EventStat{
 			ID:                  eb.ID,
@@ -252,6 +283,8 @@ func GetOrganizationReport(c *gin.Context) {
 			Buyers:              buyers,
 			GrossRevenue:        grossRevenue,
 			AffiliateCommission: affiliateCommission,
+			PlatformFee:         fees.PlatformFee,
+			TaxAmount:           fees.TaxAmount,
 			NetRevenue:          netRevenue,
 			CreatedAt:           eb.CreatedAt,
 		})
@@ -259,6 +292,8 @@ func GetOrganizationReport(c *gin.Context) {
 		totalBuyers += buyers
 		totalGrossRevenue += grossRevenue
 		totalAffiliateCommission += affiliateCommission
+		totalPlatformFee += fees.PlatformFee
+		totalTax += fees.TaxAmount
 		totalNetRevenue += netRevenue
 	}
 
@@ -282,6 +317,9 @@ func GetOrganizationReport(c *gin.Context) {
 		"events":               events,
 		"gross_revenue":        totalGrossRevenue,
 		"affiliate_commission": totalAffiliateCommission,
+		"platform_fee":         totalPlatformFee,
+		"tax_amount":           totalTax,
+		"platform_fee_percent": getOrgPlatformFeePercent(config.DB, orgID, loadFeeSettings()),
 		"net_revenue":          totalNetRevenue,
 		"available_balance":    balance.AvailableBalance,
 		"total_withdrawn":      balance.TotalWithdrawn,
@@ -301,12 +339,6 @@ func UploadOrganizationLogo(c *gin.Context) {
 		return
 	}
 
-	// Validate file size (max 2MB)
-	if fileHeader.Size > 2*1024*1024 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "File size must be less than 2MB"})
-		return
-	}
-
 	// Get extension
 	ext := filepath.Ext(fileHeader.Filename)
 	if ext == "" {
@@ -325,23 +357,28 @@ func UploadOrganizationLogo(c *gin.Context) {
 	filename := fmt.Sprintf("org_logo_%d_%d%s", orgID, time.Now().UnixNano(), ext)
 	storagePath := "organization/" + filename
 
-	publicURL, err := utils.UploadFileHeaderToSupabase(storagePath, fileHeader)
+	publicURL, err := uploadFormFile(c, filecheck.Logo, storagePath, fileHeader, orgID)
 	if err != nil {
-		fmt.Printf("❌ Supabase upload error: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload logo"})
+		fmt.Printf("❌ Storage upload error: %v\n", err)
+		respondUploadError(c, err, "Failed to upload logo")
 		return
 	}
 
+	var oldURL string
+	config.DB.Get(&oldURL, `SELECT COALESCE(logo_url, '') FROM organizations WHERE id = ?`, orgID)
+
 	// Update database
 	_, err = config.DB.Exec(`UPDATE organizations SET logo_url = ? WHERE owner_user_id = ?`, publicURL, userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update logo URL"})
 		return
 	}
+	replaceStoredFile(c, oldURL, publicURL)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":  "Logo uploaded successfully",
 		"logo_url": publicURL,
+		"logo":     attachImageVariants(c, publicURL, media.LogoVariants),
 	})
 }
 
@@ -398,6 +435,9 @@ func GetEventBuyers(c *gin.Context) {
 		NetAmount        float64 `db:"net_amount" json:"net_amount"`
 		PurchasedAt      string  `db:"purchased_at" json:"purchased_at"`
 		PaymentStatus    string  `db:"payment_status" json:"payment_status"`
+		InvoiceNumber    *string `db:"invoice_number" json:"invoice_number"`
+		IsGift           bool    `db:"is_gift" json:"is_gift"`
+		Quantity         int     `db:"quantity" json:"quantity"`
 	}
 
 	var purchases []PurchaseDetail
@@ -406,8 +446,8 @@ func GetEventBuyers(c *gin.Context) {
 	var rawCount int
 	config.DB.Get(&rawCount, `
 		SELECT COUNT(*) FROM purchases p 
-		JOIN sessions s ON p.session_id = s.id 
-		WHERE s.event_id = ?
+		LEFT JOIN sessions s ON p.session_id = s.id 
+		WHERE COALESCE(p.event_id, s.event_id) = ?
 	`, eventID)
 	fmt.Printf("[EVENT-BUYERS] Raw purchase count for eventID=%s: %d\n", eventID, rawCount)
 
@@ -415,8 +455,8 @@ func GetEventBuyers(c *gin.Context) {
 	var debugPaidCount int
 	config.DB.Get(&debugPaidCount, `
 		SELECT COUNT(*) FROM purchases p 
-		JOIN sessions s ON p.session_id = s.id 
-		WHERE s.event_id = ? AND p.status = 'PAID'
+		LEFT JOIN sessions s ON p.session_id = s.id 
+		WHERE COALESCE(p.event_id, s.event_id) = ? AND p.status = 'PAID'
 	`, eventID)
 	fmt.Printf("[EVENT-BUYERS] Paid purchase count for eventID=%s: %d\n", eventID, debugPaidCount)
 
@@ -428,8 +468,8 @@ func GetEventBuyers(c *gin.Context) {
 			u.name as user_name,
 			u.email as user_email,
 			COALESCE(u.phone, '') as user_phone,
-			p.session_id,
-			s.title as session_title,
+			COALESCE(p.session_id, 0) as session_id,
+			COALESCE(s.title, 'Paket Lengkap') as session_title,
 			p.price_paid,
 			COALESCE(p.affiliate_code, '') as affiliate_code,
 			COALESCE(aff_user.name, '') as affiliate_name,
@@ -445,13 +485,17 @@ func GetEventBuyers(c *gin.Context) {
 				ELSE p.price_paid 
 			END as net_amount,
 			p.purchased_at as purchased_at,
-			COALESCE(p.status, 'PENDING') as payment_status
+			COALESCE(p.status, 'PENDING') as payment_status,
+			i.invoice_number,
+			COALESCE(p.is_gift, 0) as is_gift,
+			COALESCE(p.quantity, 1) as quantity
 		FROM purchases p
 		JOIN users u ON p.user_id = u.id
-		JOIN sessions s ON p.session_id = s.id
-		LEFT JOIN affiliate_partnerships ap ON p.affiliate_code = ap.unique_code AND ap.event_id = s.event_id
+		LEFT JOIN sessions s ON p.session_id = s.id
+		LEFT JOIN invoices i ON i.order_id = p.order_id
+		LEFT JOIN affiliate_partnerships ap ON p.affiliate_code = ap.unique_code AND ap.event_id = COALESCE(p.event_id, s.event_id)
 		LEFT JOIN users aff_user ON ap.user_id = aff_user.id
-		WHERE s.event_id = ?
+		WHERE COALESCE(p.event_id, s.event_id) = ?
 		ORDER BY p.purchased_at DESC
 	`
 	err = config.DB.Select(&purchases, query, eventID)
@@ -471,8 +515,8 @@ func GetEventBuyers(c *gin.Context) {
 				u.name as user_name,
 				u.email as user_email,
 				COALESCE(u.phone, '') as user_phone,
-				p.session_id,
-				s.title as session_title,
+				COALESCE(p.session_id, 0) as session_id,
+				COALESCE(s.title, 'Paket Lengkap') as session_title,
 				p.price_paid,
 				COALESCE(p.affiliate_code, '') as affiliate_code,
 				'' as affiliate_name,
@@ -480,11 +524,14 @@ func GetEventBuyers(c *gin.Context) {
 				0 as commission_amount,
 				p.price_paid as net_amount,
 				p.purchased_at as purchased_at,
-				COALESCE(p.status, 'PENDING') as payment_status
+				COALESCE(p.status, 'PENDING') as payment_status,
+				NULL as invoice_number,
+				COALESCE(p.is_gift, 0) as is_gift,
+				COALESCE(p.quantity, 1) as quantity
 			FROM purchases p
 			JOIN users u ON p.user_id = u.id
-			JOIN sessions s ON p.session_id = s.id
-			WHERE s.event_id = ?
+			LEFT JOIN sessions s ON p.session_id = s.id
+			WHERE COALESCE(p.event_id, s.event_id) = ?
 			ORDER BY p.purchased_at DESC
 		`
 		err = config.DB.Select(&purchases, fallbackQuery, eventID)
@@ -509,6 +556,9 @@ func GetEventBuyers(c *gin.Context) {
 		}
 	}
 
+	// Gifted seats: which codes were redeemed and by whom
+	giftCodes, giftSummary := getEventGiftCodes(eventID)
+
 	c.JSON(200, gin.H{
 		"purchases": purchases,
 		"summary": gin.H{
@@ -519,6 +569,8 @@ func GetEventBuyers(c *gin.Context) {
 			"total_commission": totalCommission,
 			"net_revenue":      totalNetRevenue,
 		},
+		"gift_codes":   giftCodes,
+		"gift_summary": giftSummary,
 	})
 }
 
@@ -556,8 +608,8 @@ func GetOrganizationBalance(c *gin.Context) {
 		config.DB.Get(&balance.TotalEarned, `
 			SELECT COALESCE(SUM(p.price_paid), 0)
 			FROM purchases p
-			JOIN sessions s ON p.session_id = s.id
-			JOIN events e ON s.event_id = e.id
+			LEFT JOIN sessions s ON p.session_id = s.id
+			JOIN events e ON e.id = COALESCE(p.event_id, s.event_id)
 			WHERE e.organization_id = ? AND p.status = 'PAID'
 		`, orgID)
 		balance.TotalWithdrawn = 0
@@ -615,8 +667,8 @@ func SimulateOrgWithdraw(c *gin.Context) {
 		config.DB.Get(&balance.TotalEarned, `
 			SELECT COALESCE(SUM(p.price_paid), 0)
 			FROM purchases p
-			JOIN sessions s ON p.session_id = s.id
-			JOIN events e ON s.event_id = e.id
+			LEFT JOIN sessions s ON p.session_id = s.id
+			JOIN events e ON e.id = COALESCE(p.event_id, s.event_id)
 			WHERE e.organization_id = ? AND p.status = 'PAID'
 		`, orgID)
 	}
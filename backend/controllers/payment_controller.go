@@ -11,6 +11,8 @@ import (
 	"time"
 
 	"BACKEND/config"
+	"BACKEND/entitlements"
+	"BACKEND/helpers"
 
 	"github.com/gin-gonic/gin"
 	"github.com/midtrans/midtrans-go"
@@ -61,14 +63,8 @@ func GetPaymentToken(c *gin.Context) {
 		return
 	}
 
-	// Check if already purchased
-	var count int
-	config.DB.Get(&count, `
-		SELECT COUNT(*) FROM purchases 
-		WHERE user_id = ? AND session_id = ? AND status = 'PAID'
-	`, userID, input.SessionID)
-
-	if count > 0 {
+	// Check if user already has access (bought the session or its event package)
+	if entitlements.HasAccess(userID, input.SessionID) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "You already purchased this session"})
 		return
 	}
@@ -227,6 +223,12 @@ func HandleMidtransNotification(c *gin.Context) {
 		if strings.HasPrefix(notification.OrderID, "CART-") {
 			// Cart checkout order
 			err = ProcessCartPayment(notification.OrderID, notification.GrossAmount)
+		} else if strings.HasPrefix(notification.OrderID, "SUB-") {
+			// Subscription billing period
+			err = ProcessSubscriptionPayment(notification.OrderID, notification.GrossAmount)
+		} else if strings.HasPrefix(notification.OrderID, "GUEST-") {
+			// Guest checkout, the account is matched or created now
+			err = ProcessGuestPayment(notification.OrderID, notification.GrossAmount)
 		} else {
 			// Single session order (legacy)
 			err = processSuccessfulPayment(notification.OrderID, notification.GrossAmount)
@@ -242,6 +244,21 @@ func HandleMidtransNotification(c *gin.Context) {
 
 	case "deny", "cancel", "expire":
 		// Payment failed
+		if strings.HasPrefix(notification.OrderID, "SUB-") {
+			if err := failSubscriptionPayment(notification.OrderID); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update subscription payment"})
+				return
+			}
+			break
+		}
+		if strings.HasPrefix(notification.OrderID, "GUEST-") {
+			if _, err := closeGuestOrder(notification.OrderID, "FAILED"); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update guest order"})
+				return
+			}
+			break
+		}
+
 		_, err := config.DB.Exec(`
 			UPDATE purchases SET status = 'FAILED' WHERE order_id = ?
 		`, notification.OrderID)
@@ -249,6 +266,9 @@ func HandleMidtransNotification(c *gin.Context) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update purchase status"})
 			return
 		}
+
+		// Free the coupon quota held by this order
+		releaseCouponRedemption(strings.Split(notification.OrderID, "-AFF-")[0])
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "OK"})
@@ -274,13 +294,23 @@ func processSuccessfulPayment(orderID string, grossAmount string) error {
 	}
 	defer tx.Rollback()
 
-	// Update purchase status to PAID
-	_, err = tx.Exec(`
-		UPDATE purchases SET status = 'PAID' WHERE order_id = ?
+	// Update purchase status to PAID. Only rows that are not PAID yet are touched,
+	// so a repeated webhook / status check does not credit the wallets twice.
+	result, err := tx.Exec(`
+		UPDATE purchases SET status = 'PAID' WHERE order_id = ? AND status <> 'PAID'
 	`, orderID)
 	if err != nil {
 		return fmt.Errorf("failed to update purchase status")
 	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		fmt.Printf("[PAYMENT] Order %s already settled, skipping\n", orderID)
+		return nil
+	}
+
+	// Paid session becomes accessible through entitlements
+	if err := entitlements.GrantOrderAccess(tx, orderID); err != nil {
+		return err
+	}
 
 	// Check if this session belongs to an affiliate event
 	var affiliateInfo struct {
@@ -366,7 +396,8 @@ func processSuccessfulPayment(orderID string, grossAmount string) error {
 		}
 	} else {
 		// Regular organization event - credit to organization balance
-		amount, _ := strconv.ParseFloat(grossAmount, 64)
+		gross, _ := strconv.ParseFloat(grossAmount, 64)
+		amount := helpers.Rupiah(gross)
 
 		// Get organization ID from session
 		var orgInfo struct {
@@ -381,21 +412,35 @@ func processSuccessfulPayment(orderID string, grossAmount string) error {
 			WHERE s.id = ?
 		`, sessionID)
 
+		// Store fee breakdown on the purchase (official org is exempt from platform fee)
+		var purchaseID int64
+		tx.Get(&purchaseID, "SELECT id FROM purchases WHERE order_id = ? LIMIT 1", orderID)
+
+		feeSettings := loadFeeSettings()
+		platformFeePct := 0.0
+		if orgInfo.OrgID > 0 && !orgInfo.IsOfficial {
+			platformFeePct = getOrgPlatformFeePercent(tx, orgInfo.OrgID, feeSettings)
+		}
+		fb := helpers.CalculateFees(amount, feeSettings.effectiveTaxPercent(), platformFeePct, 0)
+		if purchaseID > 0 {
+			recordPurchaseFees(tx, purchaseID, fmt.Sprintf("session ID %d", sessionID), orderID, fb)
+		}
+
 		// Only credit if it's NOT official org (regular org)
 		if orgInfo.OrgID > 0 && !orgInfo.IsOfficial {
-			// Upsert organization balance
+			// Upsert organization balance (after tax and platform fee)
 			_, err = tx.Exec(`
 				INSERT INTO organization_balances (organization_id, balance, total_earned)
 				VALUES (?, ?, ?)
 				ON DUPLICATE KEY UPDATE 
 					balance = balance + ?,
 					total_earned = total_earned + ?
-			`, orgInfo.OrgID, amount, amount, amount, amount)
+			`, orgInfo.OrgID, fb.OrgAmount, fb.OrgAmount, fb.OrgAmount, fb.OrgAmount)
 
 			if err != nil {
 				fmt.Printf("[PAYMENT] Error crediting org balance: %v\n", err)
 			} else {
-				fmt.Printf("[PAYMENT] ✅ Credited Rp %.0f to organization %d\n", amount, orgInfo.OrgID)
+				fmt.Printf("[PAYMENT] ✅ Credited Rp %d to organization %d (gross %d, tax %d, fee %d)\n", fb.OrgAmount, orgInfo.OrgID, amount, fb.TaxAmount, fb.PlatformFee)
 			}
 
 			// Record financial transaction
@@ -447,7 +492,14 @@ func processSuccessfulPayment(orderID string, grossAmount string) error {
 		}
 	}()
 
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// Invoice is issued and emailed once the order is committed as PAID
+	go sendInvoiceEmail(orderID)
+
+	return nil
 }
 
 // ===============================================
@@ -548,6 +600,7 @@ func CheckPaymentStatus(c *gin.Context) {
 	} else if status == "deny" || status == "cancel" || status == "expire" {
 		// Mark as FAILED
 		config.DB.Exec("UPDATE purchases SET status = 'FAILED' WHERE order_id = ?", input.OrderID)
+		releaseCouponRedemption(input.OrderID)
 		purchase.Status = "FAILED"
 	} else if status == "pending" {
 		fmt.Printf("[CHECK-STATUS] Payment still pending\n")
@@ -574,11 +627,49 @@ func SimulatePaymentSuccess(c *gin.Context) {
 
 	fmt.Printf("[SIMULATE] Simulating payment for order: %s\n", input.OrderID)
 
+	// Subscription billing orders live in their own table
+	if strings.HasPrefix(input.OrderID, "SUB-") {
+		var amount int64
+		if err := config.DB.Get(&amount, "SELECT amount FROM subscription_payments WHERE order_id = ?", input.OrderID); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
+		}
+		if err := ProcessSubscriptionPayment(input.OrderID, fmt.Sprintf("%d.00", amount)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process payment: " + err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message":  "Pembayaran berhasil disimulasikan (SANDBOX ONLY)",
+			"order_id": input.OrderID,
+			"status":   "PAID",
+		})
+		return
+	}
+
+	// Guest orders have no purchases until they are paid
+	if strings.HasPrefix(input.OrderID, "GUEST-") {
+		var total int64
+		if err := config.DB.Get(&total, "SELECT total + COALESCE(payment_fee, 0) FROM guest_orders WHERE order_id = ?", input.OrderID); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
+		}
+		if err := ProcessGuestPayment(input.OrderID, fmt.Sprintf("%d.00", total)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process payment: " + err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message":  "Pembayaran berhasil disimulasikan (SANDBOX ONLY)",
+			"order_id": input.OrderID,
+			"status":   "PAID",
+		})
+		return
+	}
+
 	// Check if purchase exists and is PENDING
 	var purchase struct {
-		ID        int64   `db:"id"`
-		Status    string  `db:"status"`
-		PricePaid float64 `db:"price_paid"`
+		ID        int64  `db:"id"`
+		Status    string `db:"status"`
+		PricePaid int64  `db:"price_paid"`
 	}
 	err := config.DB.Get(&purchase, "SELECT id, status, price_paid FROM purchases WHERE order_id = ? LIMIT 1", input.OrderID)
 	if err != nil {
@@ -592,14 +683,14 @@ func SimulatePaymentSuccess(c *gin.Context) {
 	}
 
 	// Get total amount for cart orders (may have multiple items)
-	var totalAmount float64
-	config.DB.Get(&totalAmount, "SELECT COALESCE(SUM(price_paid), 0) FROM purchases WHERE order_id = ?", input.OrderID)
+	var totalAmount int64
+	config.DB.Get(&totalAmount, "SELECT COALESCE(SUM(price_paid + COALESCE(payment_fee, 0)), 0) FROM purchases WHERE order_id = ?", input.OrderID)
 	if totalAmount == 0 {
 		totalAmount = purchase.PricePaid
 	}
 
 	// Simulate successful payment - route to correct handler based on order type
-	grossAmount := fmt.Sprintf("%.2f", totalAmount)
+	grossAmount := fmt.Sprintf("%d.00", totalAmount)
 
 	if strings.HasPrefix(input.OrderID, "CART-") {
 		// Cart order - use ProcessCartPayment
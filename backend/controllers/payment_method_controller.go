@@ -0,0 +1,358 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"BACKEND/config"
+	"BACKEND/helpers"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/midtrans/midtrans-go"
+	"github.com/midtrans/midtrans-go/snap"
+)
+
+// ===============================================
+// PAYMENT METHODS
+// ===============================================
+
+// PaymentMethodConfig is a supported payment method with its current platform settings
+type PaymentMethodConfig struct {
+	helpers.PaymentMethodInfo
+	Enabled       bool    `json:"enabled"`
+	FlatFee       int64   `json:"flat_fee"`
+	PercentFee    float64 `json:"percent_fee"`
+	ExpiryMinutes int     `json:"expiry_minutes"`
+}
+
+// PaymentMethodOption is a method offered for one order, with the surcharge for that order
+type PaymentMethodOption struct {
+	Code                 string `json:"code"`
+	Label                string `json:"label"`
+	Group                string `json:"group"`
+	Fee                  int64  `json:"fee"`
+	ExpiryMinutes        int    `json:"expiry_minutes"`
+	InstallmentAvailable bool   `json:"installment_available"`
+}
+
+// loadPaymentMethodConfigs returns every supported method with its settings from platform_settings
+func loadPaymentMethodConfigs() []PaymentMethodConfig {
+	enabled := map[string]bool{}
+	for _, code := range helpers.ParsePaymentMethodList(getPlatformSetting("payment_methods_enabled", helpers.DefaultPaymentMethod)) {
+		enabled[code] = true
+	}
+
+	configs := make([]PaymentMethodConfig, len(helpers.PaymentMethods))
+	for i, m := range helpers.PaymentMethods {
+		flat, _ := strconv.ParseFloat(getPlatformSetting("payment_fee_flat_"+m.Code, "0"), 64)
+		pct, _ := strconv.ParseFloat(getPlatformSetting("payment_fee_percent_"+m.Code, "0"), 64)
+		expiry, err := strconv.Atoi(getPlatformSetting("payment_expiry_minutes_"+m.Code, ""))
+		if err != nil || expiry <= 0 {
+			expiry = m.DefaultExpiryMinutes
+		}
+		configs[i] = PaymentMethodConfig{
+			PaymentMethodInfo: m,
+			Enabled:           enabled[m.Code],
+			FlatFee:           helpers.Rupiah(flat),
+			PercentFee:        pct,
+			ExpiryMinutes:     expiry,
+		}
+	}
+	return configs
+}
+
+// loadInstallmentSettings returns the credit card installment terms (empty = disabled)
+// and the minimum order amount for installments
+func loadInstallmentSettings() ([]int8, int64) {
+	terms := []int8{}
+	for _, part := range strings.Split(getPlatformSetting("credit_card_installment_terms", ""), ",") {
+		if n, err := strconv.Atoi(strings.TrimSpace(part)); err == nil && n > 1 && n <= 36 {
+			terms = append(terms, int8(n))
+		}
+	}
+	minAmount, _ := strconv.ParseFloat(getPlatformSetting("credit_card_installment_min_amount", "500000"), 64)
+	return terms, helpers.Rupiah(minAmount)
+}
+
+// orderPaymentMethods returns the enabled methods every organization in an order accepts
+func orderPaymentMethods(orgIDs []int64) []PaymentMethodConfig {
+	configs := loadPaymentMethodConfigs()
+
+	global := []string{}
+	for _, m := range configs {
+		if m.Enabled {
+			global = append(global, m.Code)
+		}
+	}
+
+	var orgLists [][]string
+	if len(orgIDs) > 0 {
+		query, args, err := sqlx.In("SELECT payment_methods FROM organizations WHERE id IN (?)", orgIDs)
+		if err == nil {
+			var rows []*string
+			config.DB.Select(&rows, config.DB.Rebind(query), args...)
+			for _, row := range rows {
+				if row == nil {
+					orgLists = append(orgLists, nil)
+				} else {
+					orgLists = append(orgLists, helpers.ParsePaymentMethodList(*row))
+				}
+			}
+		}
+	}
+
+	allowed := map[string]bool{}
+	for _, code := range helpers.AllowedPaymentMethods(global, orgLists) {
+		allowed[code] = true
+	}
+
+	methods := []PaymentMethodConfig{}
+	for _, m := range configs {
+		if allowed[m.Code] {
+			methods = append(methods, m)
+		}
+	}
+	return methods
+}
+
+// paymentMethodOptions prices every method for an order amount
+func paymentMethodOptions(methods []PaymentMethodConfig, amount int64) []PaymentMethodOption {
+	terms, minAmount := loadInstallmentSettings()
+
+	options := make([]PaymentMethodOption, len(methods))
+	for i, m := range methods {
+		fee := helpers.PaymentMethodFee(amount, m.FlatFee, m.PercentFee)
+		options[i] = PaymentMethodOption{
+			Code:                 m.Code,
+			Label:                m.Label,
+			Group:                m.Group,
+			Fee:                  fee,
+			ExpiryMinutes:        m.ExpiryMinutes,
+			InstallmentAvailable: m.Code == "credit_card" && len(terms) > 0 && amount+fee >= minAmount,
+		}
+	}
+	return options
+}
+
+// choosePaymentMethod picks the buyer's method for an order; an empty code picks the first one offered
+func choosePaymentMethod(code string, orgIDs []int64) (PaymentMethodConfig, error) {
+	methods := orderPaymentMethods(orgIDs)
+	if len(methods) == 0 {
+		return PaymentMethodConfig{}, errors.New("Tidak ada metode pembayaran yang tersedia untuk pesanan ini")
+	}
+
+	code = strings.ToLower(strings.TrimSpace(code))
+	if code == "" {
+		return methods[0], nil
+	}
+	for _, m := range methods {
+		if m.Code == code {
+			return m, nil
+		}
+	}
+	return PaymentMethodConfig{}, errors.New("Metode pembayaran tidak tersedia untuk pesanan ini")
+}
+
+// midtransGrossAmount is the gross amount of a Snap transaction: the sum of its
+// whole-rupiah item lines, so Midtrans always sees item details that add up
+func midtransGrossAmount(items []midtrans.ItemDetails) int64 {
+	var gross int64
+	for _, item := range items {
+		gross += item.Price * int64(item.Qty)
+	}
+	return gross
+}
+
+// applySnapPaymentMethod limits a Snap transaction to the chosen method and its expiry;
+// credit cards get 3-D Secure and, for large enough orders, the configured installment terms
+func applySnapPaymentMethod(req *snap.Request, m PaymentMethodConfig) {
+	req.EnabledPayments = nil
+	for _, t := range m.SnapTypes {
+		req.EnabledPayments = append(req.EnabledPayments, snap.SnapPaymentType(t))
+	}
+	req.Expiry = &snap.ExpiryDetails{Unit: "minute", Duration: int64(m.ExpiryMinutes)}
+
+	if m.Code == "credit_card" {
+		req.CreditCard = &snap.CreditCardDetails{Secure: true}
+		terms, minAmount := loadInstallmentSettings()
+		if len(terms) > 0 && req.TransactionDetails.GrossAmt >= minAmount {
+			req.CreditCard.Installment = &snap.InstallmentDetail{
+				Terms: &snap.InstallmentTermsDetail{Bca: terms, Bni: terms, Mandiri: terms, Bri: terms},
+			}
+		}
+	}
+}
+
+// GetPaymentMethods - Globally enabled payment methods and their fees (before organization restrictions)
+// GET /payment-methods
+func GetPaymentMethods(c *gin.Context) {
+	methods := []PaymentMethodConfig{}
+	for _, m := range loadPaymentMethodConfigs() {
+		if m.Enabled {
+			methods = append(methods, m)
+		}
+	}
+	terms, minAmount := loadInstallmentSettings()
+
+	c.JSON(http.StatusOK, gin.H{
+		"methods":                methods,
+		"installment_terms":      terms,
+		"installment_min_amount": minAmount,
+	})
+}
+
+// ===============================================
+// ADMIN: PAYMENT METHOD SETTINGS
+// ===============================================
+
+// GetPaymentMethodSettings - All supported methods with settings, plus organization restrictions
+// GET /admin/payment-methods
+func GetPaymentMethodSettings(c *gin.Context) {
+	type OrgOverride struct {
+		ID             int64  `db:"id" json:"id"`
+		Name           string `db:"name" json:"name"`
+		PaymentMethods string `db:"payment_methods" json:"payment_methods"`
+	}
+
+	var overrides []OrgOverride
+	config.DB.Select(&overrides, `
+		SELECT id, COALESCE(name, '') as name, payment_methods
+		FROM organizations
+		WHERE payment_methods IS NOT NULL
+		ORDER BY name
+	`)
+	if overrides == nil {
+		overrides = []OrgOverride{}
+	}
+
+	terms, minAmount := loadInstallmentSettings()
+	c.JSON(http.StatusOK, gin.H{
+		"methods":                loadPaymentMethodConfigs(),
+		"installment_terms":      terms,
+		"installment_min_amount": minAmount,
+		"org_overrides":          overrides,
+	})
+}
+
+// UpdatePaymentMethodSettings - Enable methods and set their fee and expiry
+// PUT /admin/payment-methods
+func UpdatePaymentMethodSettings(c *gin.Context) {
+	var input struct {
+		Methods []struct {
+			Code          string  `json:"code"`
+			Enabled       bool    `json:"enabled"`
+			FlatFee       float64 `json:"flat_fee"`
+			PercentFee    float64 `json:"percent_fee"`
+			ExpiryMinutes int     `json:"expiry_minutes"` // 0 = method default
+		} `json:"methods"`
+		InstallmentTerms     []int   `json:"installment_terms"`
+		InstallmentMinAmount float64 `json:"installment_min_amount"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid"})
+		return
+	}
+
+	settings := map[string]string{}
+	enabled := []string{}
+	for _, m := range input.Methods {
+		if _, ok := helpers.FindPaymentMethod(m.Code); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Metode pembayaran %s tidak dikenal", m.Code)})
+			return
+		}
+		if m.FlatFee < 0 || !helpers.IsWholeRupiah(m.FlatFee) || m.PercentFee < 0 || m.PercentFee > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Biaya metode pembayaran tidak valid"})
+			return
+		}
+		if m.ExpiryMinutes != 0 && (m.ExpiryMinutes < 5 || m.ExpiryMinutes > 10080) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Batas waktu pembayaran harus antara 5 menit dan 7 hari"})
+			return
+		}
+		if m.Enabled {
+			enabled = append(enabled, m.Code)
+		}
+		settings["payment_fee_flat_"+m.Code] = strconv.FormatInt(helpers.Rupiah(m.FlatFee), 10)
+		settings["payment_fee_percent_"+m.Code] = strconv.FormatFloat(m.PercentFee, 'f', 2, 64)
+		settings["payment_expiry_minutes_"+m.Code] = strconv.Itoa(m.ExpiryMinutes)
+	}
+	if len(enabled) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Minimal satu metode pembayaran harus aktif"})
+		return
+	}
+	settings["payment_methods_enabled"] = strings.Join(enabled, ",")
+
+	terms := []string{}
+	for _, t := range input.InstallmentTerms {
+		if t < 2 || t > 36 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Tenor cicilan harus antara 2 dan 36 bulan"})
+			return
+		}
+		terms = append(terms, strconv.Itoa(t))
+	}
+	settings["credit_card_installment_terms"] = strings.Join(terms, ",")
+	if input.InstallmentMinAmount < 0 || !helpers.IsWholeRupiah(input.InstallmentMinAmount) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Minimal transaksi cicilan tidak valid"})
+		return
+	}
+	settings["credit_card_installment_min_amount"] = strconv.FormatInt(helpers.Rupiah(input.InstallmentMinAmount), 10)
+
+	for key, value := range settings {
+		if err := setPlatformSetting(key, value); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan pengaturan"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Pengaturan metode pembayaran berhasil disimpan",
+		"methods": loadPaymentMethodConfigs(),
+	})
+}
+
+// UpdateOrganizationPaymentMethods - Restrict or reset the payment methods of one organization
+// PUT /admin/organizations/:id/payment-methods
+func UpdateOrganizationPaymentMethods(c *gin.Context) {
+	orgID := c.Param("id")
+
+	var input struct {
+		Methods []string `json:"methods"` // null or empty = every globally enabled method
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid"})
+		return
+	}
+
+	var value *string
+	if len(input.Methods) > 0 {
+		codes := helpers.ParsePaymentMethodList(strings.Join(input.Methods, ","))
+		if len(codes) != len(input.Methods) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Metode pembayaran tidak dikenal atau duplikat"})
+			return
+		}
+		joined := strings.Join(codes, ",")
+		value = &joined
+	}
+
+	res, err := config.DB.Exec("UPDATE organizations SET payment_methods = ? WHERE id = ?", value, orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan metode pembayaran organisasi"})
+		return
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		var exists int
+		config.DB.Get(&exists, "SELECT COUNT(*) FROM organizations WHERE id = ?", orgID)
+		if exists == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Organisasi tidak ditemukan"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Metode pembayaran organisasi berhasil diperbarui",
+		"payment_methods": value,
+	})
+}
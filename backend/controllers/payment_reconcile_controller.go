@@ -0,0 +1,306 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"BACKEND/config"
+	"BACKEND/helpers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ===============================================
+// PENDING ORDER RECONCILIATION
+// ===============================================
+
+// defaultPendingOrderTTLMinutes matches the default Snap transaction expiry (24 hours)
+const defaultPendingOrderTTLMinutes = 1440
+
+// reconcileBatchSize caps how many stale orders one run checks against Midtrans
+const reconcileBatchSize = 100
+
+// reconcileMu keeps the scheduled job and a manual admin run from overlapping
+var reconcileMu sync.Mutex
+
+// getPendingOrderTTL returns how long an order may stay PENDING before it is reconciled
+func getPendingOrderTTL() time.Duration {
+	minutes, err := strconv.Atoi(getPlatformSetting("pending_order_ttl_minutes", strconv.Itoa(defaultPendingOrderTTLMinutes)))
+	if err != nil || minutes <= 0 {
+		minutes = defaultPendingOrderTTLMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// ReconcileResult is the outcome for one stale order
+type ReconcileResult struct {
+	OrderID        string `json:"order_id"`
+	LocalTotal     int64  `json:"local_total"`
+	ProviderStatus string `json:"provider_status"`
+	ProviderAmount string `json:"provider_amount"`
+	Action         string `json:"action"`
+	Discrepancy    bool   `json:"discrepancy"`
+	Note           string `json:"note"`
+}
+
+// ReconcileReport summarises one reconciliation run
+type ReconcileReport struct {
+	StartedAt     time.Time         `json:"started_at"`
+	Checked       int               `json:"checked"`
+	Settled       int               `json:"settled"`
+	Failed        int               `json:"failed"`
+	Expired       int               `json:"expired"`
+	StillPending  int               `json:"still_pending"`
+	Discrepancies int               `json:"discrepancies"`
+	Results       []ReconcileResult `json:"results"`
+}
+
+// staleOrder is one PENDING order (possibly several purchase rows) past its TTL
+type staleOrder struct {
+	OrderID         string  `db:"order_id"`
+	MidtransOrderID *string `db:"midtrans_order_id"`
+	UserID          int64   `db:"user_id"`
+	Total           int64   `db:"total"`
+}
+
+// ReconcilePendingOrders checks every stale PENDING order against Midtrans:
+// missed payments are settled, failed/expired ones are closed and their coupon
+// quota released, and anything unexpected is logged as a discrepancy.
+func ReconcilePendingOrders() ReconcileReport {
+	reconcileMu.Lock()
+	defer reconcileMu.Unlock()
+
+	report := ReconcileReport{StartedAt: time.Now(), Results: []ReconcileResult{}}
+	ttl := getPendingOrderTTL()
+
+	// FREE- orders never go through Midtrans and are created as PAID anyway
+	var orders []staleOrder
+	err := config.DB.Select(&orders, `
+		SELECT order_id, MAX(midtrans_order_id) as midtrans_order_id, MIN(user_id) as user_id,
+			COALESCE(SUM(price_paid + COALESCE(payment_fee, 0)), 0) as total
+		FROM purchases
+		WHERE status = 'PENDING' AND order_id IS NOT NULL AND order_id <> ''
+			AND order_id NOT LIKE 'FREE-%'
+			AND purchased_at < DATE_SUB(NOW(), INTERVAL ? MINUTE)
+		GROUP BY order_id
+		ORDER BY MIN(purchased_at) ASC
+		LIMIT ?
+	`, int(ttl.Minutes()), reconcileBatchSize)
+	if err != nil {
+		fmt.Printf("[RECONCILE] ❌ Error fetching stale orders: %v\n", err)
+		return report
+	}
+
+	// Guest orders keep their lines in guest_orders until paid (no user to notify yet)
+	var guestOrders []staleOrder
+	config.DB.Select(&guestOrders, `
+		SELECT order_id, midtrans_order_id, 0 as user_id, total + COALESCE(payment_fee, 0) as total
+		FROM guest_orders
+		WHERE status = 'PENDING' AND created_at < DATE_SUB(NOW(), INTERVAL ? MINUTE)
+		ORDER BY created_at ASC
+		LIMIT ?
+	`, int(ttl.Minutes()), reconcileBatchSize)
+	orders = append(orders, guestOrders...)
+
+	for _, order := range orders {
+		result := reconcileOrder(order)
+		report.Checked++
+		switch result.Action {
+		case helpers.ReconcileSettle:
+			report.Settled++
+		case helpers.ReconcileFail:
+			report.Failed++
+		case helpers.ReconcileExpire:
+			report.Expired++
+		case helpers.ReconcileWait:
+			report.StillPending++
+		}
+		if result.Discrepancy {
+			report.Discrepancies++
+		}
+		if result.Action != helpers.ReconcileWait {
+			logReconcileResult(result)
+		}
+		report.Results = append(report.Results, result)
+	}
+
+	if report.Checked > 0 {
+		fmt.Printf("[RECONCILE] Checked %d order(s): %d settled, %d failed, %d expired, %d pending, %d discrepancies\n",
+			report.Checked, report.Settled, report.Failed, report.Expired, report.StillPending, report.Discrepancies)
+	}
+	return report
+}
+
+// reconcileOrder asks Midtrans about one order and applies the matching action
+func reconcileOrder(order staleOrder) ReconcileResult {
+	result := ReconcileResult{OrderID: order.OrderID, LocalTotal: order.Total}
+
+	// Cart orders with an affiliate code are registered at Midtrans under a longer ID
+	midtransOrderID := order.OrderID
+	if order.MidtransOrderID != nil && *order.MidtransOrderID != "" {
+		midtransOrderID = *order.MidtransOrderID
+	}
+
+	found := true
+	resp, midtransErr := config.CoreClient.CheckTransaction(midtransOrderID)
+	if midtransErr != nil {
+		if midtransErr.StatusCode != http.StatusNotFound {
+			// Provider unreachable or erroring: leave the order alone and retry next run
+			result.Action = "ERROR"
+			result.Discrepancy = true
+			result.Note = "Gagal cek status ke Midtrans: " + midtransErr.GetMessage()
+			return result
+		}
+		// No transaction at all: the buyer never picked a payment method
+		found = false
+	}
+
+	if found && resp != nil {
+		result.ProviderStatus = resp.TransactionStatus
+		result.ProviderAmount = resp.GrossAmount
+		result.Action = helpers.ClassifyProviderStatus(true, resp.TransactionStatus, resp.PaymentType, resp.FraudStatus)
+	} else {
+		result.ProviderStatus = "not_found"
+		result.Action = helpers.ClassifyProviderStatus(false, "", "", "")
+	}
+
+	switch result.Action {
+	case helpers.ReconcileSettle:
+		// Never settle an order for a different amount than we charged
+		if !helpers.AmountMatches(resp.GrossAmount, order.Total) {
+			result.Action = helpers.ReconcileReview
+			result.Discrepancy = true
+			result.Note = fmt.Sprintf("Nominal berbeda: Midtrans %s, lokal %d", resp.GrossAmount, order.Total)
+			return result
+		}
+
+		var err error
+		if strings.HasPrefix(order.OrderID, "CART-") {
+			err = ProcessCartPayment(midtransOrderID, resp.GrossAmount)
+		} else if strings.HasPrefix(order.OrderID, "GUEST-") {
+			err = ProcessGuestPayment(midtransOrderID, resp.GrossAmount)
+		} else {
+			err = processSuccessfulPayment(order.OrderID, resp.GrossAmount)
+		}
+		result.Discrepancy = true
+		if err != nil {
+			result.Action = "ERROR"
+			result.Note = "Gagal memproses pembayaran: " + err.Error()
+			return result
+		}
+		result.Note = "Sudah dibayar di Midtrans tetapi webhook tidak diterima"
+
+	case helpers.ReconcileFail, helpers.ReconcileExpire:
+		newStatus := "FAILED"
+		title := "❌ Pembayaran Gagal"
+		message := fmt.Sprintf("Pembayaran untuk pesanan %s gagal. Silakan checkout ulang jika masih ingin membeli.", order.OrderID)
+		if result.Action == helpers.ReconcileExpire {
+			newStatus = "EXPIRED"
+			title = "⌛ Pesanan Kedaluwarsa"
+			message = fmt.Sprintf("Pesanan %s kedaluwarsa karena belum dibayar. Silakan checkout ulang jika masih ingin membeli.", order.OrderID)
+		}
+
+		if strings.HasPrefix(order.OrderID, "GUEST-") {
+			if _, err := closeGuestOrder(order.OrderID, newStatus); err != nil {
+				result.Action = "ERROR"
+				result.Discrepancy = true
+				result.Note = "Gagal memperbarui status: " + err.Error()
+				return result
+			}
+			result.Note = "Ditutup sebagai " + newStatus
+			break
+		}
+
+		// Only rows still PENDING are closed, in case a webhook landed meanwhile
+		res, err := config.DB.Exec(`
+			UPDATE purchases SET status = ? WHERE order_id = ? AND status = 'PENDING'
+		`, newStatus, order.OrderID)
+		if err != nil {
+			result.Action = "ERROR"
+			result.Discrepancy = true
+			result.Note = "Gagal memperbarui status: " + err.Error()
+			return result
+		}
+		releaseCouponRedemption(order.OrderID)
+
+		if rows, _ := res.RowsAffected(); rows > 0 {
+			CreateNotification(order.UserID, "payment_expired", title, message)
+		}
+		result.Note = "Ditutup sebagai " + newStatus
+
+	case helpers.ReconcileWait:
+		result.Note = "Masih menunggu pembayaran di Midtrans"
+
+	default:
+		result.Discrepancy = true
+		result.Note = fmt.Sprintf("Status Midtrans tidak terduga untuk pesanan PENDING: %s", result.ProviderStatus)
+	}
+
+	return result
+}
+
+// logReconcileResult stores a reconciliation outcome for the admin report
+func logReconcileResult(r ReconcileResult) {
+	_, err := config.DB.Exec(`
+		INSERT INTO payment_reconciliation_logs
+			(order_id, local_total, provider_status, provider_amount, action, is_discrepancy, note)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, r.OrderID, r.LocalTotal, r.ProviderStatus, r.ProviderAmount, r.Action, r.Discrepancy, r.Note)
+	if err != nil {
+		fmt.Printf("[RECONCILE] ❌ Error logging result for %s: %v\n", r.OrderID, err)
+	}
+}
+
+// ===============================================
+// ADMIN: RECONCILIATION
+// ===============================================
+
+// RunPaymentReconciliation - Run the pending order reconciler now
+// POST /admin/payments/reconcile
+func RunPaymentReconciliation(c *gin.Context) {
+	report := ReconcilePendingOrders()
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Rekonsiliasi selesai",
+		"report":  report,
+	})
+}
+
+// GetPaymentReconciliationLogs - List reconciliation outcomes (discrepancies only with ?discrepancy=1)
+// GET /admin/payments/reconciliation-logs
+func GetPaymentReconciliationLogs(c *gin.Context) {
+	type LogRow struct {
+		ID             int64   `db:"id" json:"id"`
+		OrderID        string  `db:"order_id" json:"order_id"`
+		LocalTotal     int64   `db:"local_total" json:"local_total"`
+		ProviderStatus *string `db:"provider_status" json:"provider_status"`
+		ProviderAmount *string `db:"provider_amount" json:"provider_amount"`
+		Action         string  `db:"action" json:"action"`
+		IsDiscrepancy  bool    `db:"is_discrepancy" json:"is_discrepancy"`
+		Note           *string `db:"note" json:"note"`
+		CreatedAt      string  `db:"created_at" json:"created_at"`
+	}
+
+	query := `
+		SELECT id, order_id, local_total, provider_status, provider_amount, action,
+			is_discrepancy, note, created_at
+		FROM payment_reconciliation_logs`
+	if c.Query("discrepancy") == "1" {
+		query += " WHERE is_discrepancy = 1"
+	}
+	query += " ORDER BY created_at DESC, id DESC LIMIT 200"
+
+	logs := []LogRow{}
+	if err := config.DB.Select(&logs, query); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil log rekonsiliasi"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"logs":                      logs,
+		"pending_order_ttl_minutes": int(getPendingOrderTTL().Minutes()),
+	})
+}
@@ -0,0 +1,227 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"BACKEND/config"
+	"BACKEND/helpers"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// ===============================================
+// PLATFORM FEE & TAX SETTINGS
+// ===============================================
+
+// FeeSettings holds the global fee configuration from platform_settings
+type FeeSettings struct {
+	PlatformFeePercent float64 `json:"platform_fee_percent"`
+	TaxEnabled         bool    `json:"tax_enabled"`
+	TaxPercent         float64 `json:"tax_percent"`
+}
+
+// getPlatformSetting reads a single value from platform_settings, falling back to def
+func getPlatformSetting(key string, def string) string {
+	var value string
+	err := config.DB.Get(&value, "SELECT setting_value FROM platform_settings WHERE setting_key = ?", key)
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// setPlatformSetting upserts a single value into platform_settings
+func setPlatformSetting(key string, value string) error {
+	_, err := config.DB.Exec(`
+		INSERT INTO platform_settings (setting_key, setting_value)
+		VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE setting_value = VALUES(setting_value)
+	`, key, value)
+	return err
+}
+
+// loadFeeSettings returns the current global fee configuration
+func loadFeeSettings() FeeSettings {
+	feePct, _ := strconv.ParseFloat(getPlatformSetting("platform_fee_percent", "0"), 64)
+	taxPct, _ := strconv.ParseFloat(getPlatformSetting("tax_percent", "11"), 64)
+
+	return FeeSettings{
+		PlatformFeePercent: feePct,
+		TaxEnabled:         getPlatformSetting("tax_enabled", "0") == "1",
+		TaxPercent:         taxPct,
+	}
+}
+
+// effectiveTaxPercent returns the tax percent to apply, 0 when tax is disabled
+func (s FeeSettings) effectiveTaxPercent() float64 {
+	if !s.TaxEnabled {
+		return 0
+	}
+	return s.TaxPercent
+}
+
+// getOrgPlatformFeePercent returns the fee percent charged to an organization.
+// Official orgs are exempt; a per-org override takes precedence over the global value.
+func getOrgPlatformFeePercent(q sqlx.Queryer, orgID int64, settings FeeSettings) float64 {
+	var org struct {
+		IsOfficial         bool     `db:"is_official"`
+		PlatformFeePercent *float64 `db:"platform_fee_percent"`
+	}
+	err := sqlx.Get(q, &org, `
+		SELECT COALESCE(is_official, 0) as is_official, platform_fee_percent
+		FROM organizations WHERE id = ?
+	`, orgID)
+	if err != nil {
+		return settings.PlatformFeePercent
+	}
+
+	if org.IsOfficial {
+		return 0
+	}
+	if org.PlatformFeePercent != nil {
+		return *org.PlatformFeePercent
+	}
+	return settings.PlatformFeePercent
+}
+
+// recordPurchaseFees stores the fee breakdown on the purchase row and books the
+// platform fee and tax into financial_transactions
+func recordPurchaseFees(tx *sqlx.Tx, purchaseID int64, item string, orderID string, fb helpers.FeeBreakdown) {
+	_, err := tx.Exec(`
+		UPDATE purchases
+		SET tax_amount = ?, platform_fee = ?, affiliate_commission = ?, org_amount = ?
+		WHERE id = ?
+	`, fb.TaxAmount, fb.PlatformFee, fb.AffiliateCommission, fb.OrgAmount, purchaseID)
+	if err != nil {
+		fmt.Printf("[FEES] ❌ Error storing fee breakdown for purchase %d: %v\n", purchaseID, err)
+	}
+
+	recordFeeTransactions(tx, item, orderID, fb)
+}
+
+// recordFeeTransactions books the platform fee and tax of one sold item into financial_transactions
+func recordFeeTransactions(tx *sqlx.Tx, item string, orderID string, fb helpers.FeeBreakdown) {
+	if fb.PlatformFee > 0 {
+		tx.Exec(`
+			INSERT INTO financial_transactions (transaction_type, entity_type, entity_id, amount, description, reference_id)
+			VALUES ('PLATFORM_FEE', 'PLATFORM', 0, ?, ?, ?)
+		`, fb.PlatformFee, fmt.Sprintf("Fee platform %.2f%% dari %s", fb.PlatformFeePercent, item), orderID)
+	}
+
+	if fb.TaxAmount > 0 {
+		tx.Exec(`
+			INSERT INTO financial_transactions (transaction_type, entity_type, entity_id, amount, description, reference_id)
+			VALUES ('TAX', 'PLATFORM', 0, ?, ?, ?)
+		`, fb.TaxAmount, fmt.Sprintf("PPN %.2f%% dari %s", fb.TaxPercent, item), orderID)
+	}
+}
+
+// GetFeeSettings - Get global platform fee & tax settings
+// GET /admin/fee-settings
+func GetFeeSettings(c *gin.Context) {
+	type OrgOverride struct {
+		ID                 int64   `db:"id" json:"id"`
+		Name               string  `db:"name" json:"name"`
+		PlatformFeePercent float64 `db:"platform_fee_percent" json:"platform_fee_percent"`
+	}
+
+	var overrides []OrgOverride
+	config.DB.Select(&overrides, `
+		SELECT id, COALESCE(name, '') as name, platform_fee_percent
+		FROM organizations
+		WHERE platform_fee_percent IS NOT NULL AND COALESCE(is_official, 0) = 0
+		ORDER BY name
+	`)
+	if overrides == nil {
+		overrides = []OrgOverride{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"settings":      loadFeeSettings(),
+		"org_overrides": overrides,
+	})
+}
+
+// UpdateFeeSettings - Update global platform fee & tax settings
+// PUT /admin/fee-settings
+func UpdateFeeSettings(c *gin.Context) {
+	var input FeeSettings
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid"})
+		return
+	}
+
+	if input.PlatformFeePercent < 0 || input.PlatformFeePercent > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Fee platform harus antara 0 - 100%"})
+		return
+	}
+	if input.TaxPercent < 0 || input.TaxPercent > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Persentase pajak harus antara 0 - 100%"})
+		return
+	}
+
+	taxEnabled := "0"
+	if input.TaxEnabled {
+		taxEnabled = "1"
+	}
+
+	for key, value := range map[string]string{
+		"platform_fee_percent": strconv.FormatFloat(input.PlatformFeePercent, 'f', 2, 64),
+		"tax_enabled":          taxEnabled,
+		"tax_percent":          strconv.FormatFloat(input.TaxPercent, 'f', 2, 64),
+	} {
+		if err := setPlatformSetting(key, value); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan pengaturan"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Pengaturan fee berhasil disimpan",
+		"settings": loadFeeSettings(),
+	})
+}
+
+// UpdateOrganizationFee - Set or clear the platform fee override for one organization
+// PUT /admin/organizations/:id/platform-fee
+func UpdateOrganizationFee(c *gin.Context) {
+	orgID := c.Param("id")
+
+	var input struct {
+		PlatformFeePercent *float64 `json:"platform_fee_percent"` // null = use global setting
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid"})
+		return
+	}
+
+	if input.PlatformFeePercent != nil && (*input.PlatformFeePercent < 0 || *input.PlatformFeePercent > 100) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Fee platform harus antara 0 - 100%"})
+		return
+	}
+
+	var isOfficial bool
+	err := config.DB.Get(&isOfficial, "SELECT COALESCE(is_official, 0) FROM organizations WHERE id = ?", orgID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Organisasi tidak ditemukan"})
+		return
+	}
+	if isOfficial {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Organisasi official tidak dikenakan fee platform"})
+		return
+	}
+
+	_, err = config.DB.Exec("UPDATE organizations SET platform_fee_percent = ? WHERE id = ?", input.PlatformFeePercent, orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan fee organisasi"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":              "Fee organisasi berhasil diperbarui",
+		"platform_fee_percent": input.PlatformFeePercent,
+	})
+}
@@ -0,0 +1,337 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"BACKEND/config"
+	"BACKEND/helpers"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// ===============================================
+// PLAYBACK SESSIONS (CONCURRENT STREAMS & WATERMARKS)
+// ===============================================
+
+// watermarkPositionCount and watermarkIntervalSeconds drive the moving overlay
+const (
+	watermarkPositionCount   = 8
+	watermarkIntervalSeconds = 20
+)
+
+// playbackTouchInterval throttles last_seen_at writes from segment requests
+const playbackTouchInterval = 15
+
+// PlaybackSession is one viewing of a video by an account
+type PlaybackSession struct {
+	ID            string     `db:"id" json:"playback_id"`
+	UserID        int64      `db:"user_id" json:"user_id"`
+	VideoID       int64      `db:"video_id" json:"video_id"`
+	SessionID     int64      `db:"session_id" json:"session_id"`
+	VideoTitle    string     `db:"video_title" json:"video_title"`
+	WatermarkCode string     `db:"watermark_code" json:"watermark_code"`
+	IPAddress     string     `db:"ip_address" json:"ip_address"`
+	LastIP        string     `db:"last_ip" json:"last_ip"`
+	UserAgent     *string    `db:"user_agent" json:"user_agent"`
+	StartedAt     time.Time  `db:"started_at" json:"started_at"`
+	LastSeenAt    time.Time  `db:"last_seen_at" json:"last_seen_at"`
+	EndedAt       *time.Time `db:"ended_at" json:"ended_at"`
+}
+
+// Watermark is what the player overlays on a paid video
+type Watermark struct {
+	Text            string                      `json:"text"`
+	Code            string                      `json:"code"`
+	Positions       []helpers.WatermarkPosition `json:"positions"`
+	IntervalSeconds int                         `json:"interval_seconds"`
+}
+
+// playbackResource binds a signed stream URL to one playback
+func playbackResource(resource, playbackID string) string {
+	return resource + "|" + playbackID
+}
+
+func idleSeconds() int {
+	return int(config.PlaybackIdleTimeout / time.Second)
+}
+
+// activePlaybacks lists the playbacks holding one of a user's stream slots
+func activePlaybacks(userID int64) []PlaybackSession {
+	list := []PlaybackSession{}
+	config.DB.Select(&list, `
+		SELECT p.id, p.user_id, p.video_id, p.session_id, COALESCE(v.title, '') AS video_title, p.watermark_code,
+			p.ip_address, p.last_ip, p.user_agent, p.started_at, p.last_seen_at, p.ended_at
+		FROM playback_sessions p
+		LEFT JOIN session_videos v ON v.id = p.video_id
+		WHERE p.user_id = ? AND p.ended_at IS NULL AND p.last_seen_at >= NOW() - INTERVAL ? SECOND
+		ORDER BY p.started_at ASC
+	`, userID, idleSeconds())
+	return list
+}
+
+// countOtherActivePlaybacks counts a user's live playbacks besides playbackID
+func countOtherActivePlaybacks(q sqlx.Queryer, userID int64, playbackID string) int {
+	var n int
+	sqlx.Get(q, &n, `
+		SELECT COUNT(*) FROM playback_sessions
+		WHERE user_id = ? AND id != ? AND ended_at IS NULL AND last_seen_at >= NOW() - INTERVAL ? SECOND
+	`, userID, playbackID, idleSeconds())
+	return n
+}
+
+// lockPlaybackSlots locks the user's row until tx ends, so parallel requests
+// count and take the user's playback slots one at a time
+func lockPlaybackSlots(tx *sqlx.Tx, userID int64) error {
+	var id int64
+	return tx.Get(&id, "SELECT id FROM users WHERE id = ? FOR UPDATE", userID)
+}
+
+// respondPlaybackLimit tells the viewer which streams hold their slots, so
+// the player can offer to stop one of them
+func respondPlaybackLimit(c *gin.Context, userID int64) {
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":            fmt.Sprintf("Akun ini sudah memutar %d video bersamaan. Hentikan salah satu untuk melanjutkan.", config.PlaybackMaxConcurrent),
+		"max_concurrent":   config.PlaybackMaxConcurrent,
+		"active_playbacks": activePlaybacks(userID),
+	})
+}
+
+// startPlayback opens a playback for a freshly minted stream URL. A player
+// reloading the page passes its previous playback_id so it does not hold a
+// second slot. Responds and returns false when every slot is taken.
+func startPlayback(c *gin.Context, userID, videoID, sessionID int64) (string, string, bool) {
+	tx, err := config.DB.Beginx()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memulai pemutaran"})
+		return "", "", false
+	}
+	defer tx.Rollback()
+
+	if err := lockPlaybackSlots(tx, userID); err != nil {
+		fmt.Printf("[PLAYBACK] ❌ Locking playbacks of user %d failed: %v\n", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memulai pemutaran"})
+		return "", "", false
+	}
+
+	if previous := c.Query("playback_id"); previous != "" {
+		tx.Exec(`
+			UPDATE playback_sessions SET ended_at = NOW(), end_reason = 'REPLACED'
+			WHERE id = ? AND user_id = ? AND ended_at IS NULL
+		`, previous, userID)
+	}
+
+	if countOtherActivePlaybacks(tx, userID, "") >= config.PlaybackMaxConcurrent {
+		tx.Rollback()
+		fmt.Printf("[PLAYBACK] ⚠️ User %d hit the concurrent stream limit\n", userID)
+		respondPlaybackLimit(c, userID)
+		return "", "", false
+	}
+
+	id := helpers.GeneratePlaybackID()
+	code := helpers.WatermarkCode(userID, videoID, id)
+	ua := c.Request.UserAgent()
+	if len(ua) > 255 {
+		ua = ua[:255]
+	}
+	_, err = tx.Exec(`
+		INSERT INTO playback_sessions (id, user_id, video_id, session_id, watermark_code, ip_address, last_ip, user_agent)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, id, userID, videoID, sessionID, code, c.ClientIP(), c.ClientIP(), ua)
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		fmt.Printf("[PLAYBACK] ❌ Start playback user=%d video=%d failed: %v\n", userID, videoID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memulai pemutaran"})
+		return "", "", false
+	}
+	return id, code, true
+}
+
+// touchPlayback is run by the stream endpoints on every request. It refuses
+// ended playbacks, and a playback that went idle only resumes while a slot is free.
+func touchPlayback(c *gin.Context, userID, videoID int64, playbackID string) (*PlaybackSession, bool) {
+	var p struct {
+		PlaybackSession
+		Idle bool `db:"idle"`
+	}
+	err := config.DB.Get(&p, `
+		SELECT id, user_id, video_id, session_id, watermark_code, ip_address, last_ip, user_agent,
+			started_at, last_seen_at, ended_at, last_seen_at < NOW() - INTERVAL ? SECOND AS idle
+		FROM playback_sessions WHERE id = ?
+	`, idleSeconds(), playbackID)
+	if err != nil || p.UserID != userID || p.VideoID != videoID || p.EndedAt != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Sesi pemutaran sudah berakhir, muat ulang video"})
+		return nil, false
+	}
+
+	ip := c.ClientIP()
+	touch := `
+		UPDATE playback_sessions SET last_seen_at = NOW(), last_ip = ?
+		WHERE id = ? AND (last_seen_at < NOW() - INTERVAL ? SECOND OR last_ip != ?)
+	`
+	if p.Idle {
+		// Resuming takes a slot again, under the same lock as starting a playback
+		if !resumePlayback(userID, playbackID, touch, ip) {
+			respondPlaybackLimit(c, userID)
+			return nil, false
+		}
+	} else {
+		config.DB.Exec(touch, ip, playbackID, playbackTouchInterval, ip)
+	}
+	if p.LastIP != ip {
+		fmt.Printf("[PLAYBACK] ⚠️ Playback %s of user %d moved from %s to %s\n", playbackID, userID, p.LastIP, ip)
+	}
+	return &p.PlaybackSession, true
+}
+
+// resumePlayback touches an idle playback when the user still has a free slot
+func resumePlayback(userID int64, playbackID, touch, ip string) bool {
+	tx, err := config.DB.Beginx()
+	if err != nil {
+		return false
+	}
+	defer tx.Rollback()
+
+	if err := lockPlaybackSlots(tx, userID); err != nil {
+		return false
+	}
+	if countOtherActivePlaybacks(tx, userID, playbackID) >= config.PlaybackMaxConcurrent {
+		return false
+	}
+	if _, err := tx.Exec(touch, ip, playbackID, playbackTouchInterval, ip); err != nil {
+		return false
+	}
+	return tx.Commit() == nil
+}
+
+// playbackWatermark builds the overlay for a playback
+func playbackWatermark(userID int64, code string) Watermark {
+	var email string
+	config.DB.Get(&email, "SELECT email FROM users WHERE id = ?", userID)
+	return Watermark{
+		Text:            helpers.WatermarkText(email, userID, code),
+		Code:            code,
+		Positions:       helpers.WatermarkPositions(code, watermarkPositionCount),
+		IntervalSeconds: watermarkIntervalSeconds,
+	}
+}
+
+// GetMyPlaybacks - Streams currently holding the account's playback slots
+// GET /user/playbacks
+func GetMyPlaybacks(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	c.JSON(http.StatusOK, gin.H{
+		"playbacks":      activePlaybacks(userID),
+		"max_concurrent": config.PlaybackMaxConcurrent,
+	})
+}
+
+// EndPlayback - Stop a stream (player closed, or freeing a slot for another device)
+// DELETE /user/playbacks/:playbackID
+func EndPlayback(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	res, err := config.DB.Exec(`
+		UPDATE playback_sessions SET ended_at = NOW(), end_reason = 'CLOSED'
+		WHERE id = ? AND user_id = ? AND ended_at IS NULL
+	`, c.Param("playbackID"), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghentikan pemutaran"})
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sesi pemutaran tidak ditemukan"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Pemutaran dihentikan"})
+}
+
+// SuspiciousPlaybackAccount is an account streaming from more IPs than expected
+type SuspiciousPlaybackAccount struct {
+	UserID     int64     `db:"user_id" json:"user_id"`
+	Name       string    `db:"name" json:"name"`
+	Email      string    `db:"email" json:"email"`
+	Playbacks  int       `db:"playbacks" json:"playbacks"`
+	IPCount    int       `db:"ip_count" json:"ip_count"`
+	IPSwitches int       `db:"ip_switches" json:"ip_switches"`
+	IPs        string    `db:"ips" json:"ips"`
+	LastSeenAt time.Time `db:"last_seen_at" json:"last_seen_at"`
+}
+
+// AdminGetSuspiciousPlaybacks - Accounts streaming from many IPs, or whose
+// stream URLs moved to another IP mid-playback
+// GET /admin/playbacks/suspicious?hours=24&min_ips=3
+func AdminGetSuspiciousPlaybacks(c *gin.Context) {
+	hours, _ := strconv.Atoi(c.DefaultQuery("hours", "24"))
+	if hours <= 0 || hours > 24*30 {
+		hours = 24
+	}
+	minIPs, _ := strconv.Atoi(c.DefaultQuery("min_ips", "3"))
+	if minIPs < 2 {
+		minIPs = 3
+	}
+
+	accounts := []SuspiciousPlaybackAccount{}
+	err := config.DB.Select(&accounts, `
+		SELECT ips.user_id, u.name, u.email,
+			COUNT(DISTINCT ips.playback_id) AS playbacks,
+			COUNT(DISTINCT ips.ip) AS ip_count,
+			COALESCE(MAX(sw.switches), 0) AS ip_switches,
+			GROUP_CONCAT(DISTINCT ips.ip ORDER BY ips.ip SEPARATOR ',') AS ips,
+			MAX(ips.last_seen_at) AS last_seen_at
+		FROM (
+			SELECT user_id, id AS playback_id, ip_address AS ip, last_seen_at FROM playback_sessions
+			WHERE started_at >= NOW() - INTERVAL ? HOUR
+			UNION ALL
+			SELECT user_id, id, last_ip, last_seen_at FROM playback_sessions
+			WHERE started_at >= NOW() - INTERVAL ? HOUR
+		) ips
+		JOIN users u ON u.id = ips.user_id
+		LEFT JOIN (
+			SELECT user_id, COUNT(*) AS switches FROM playback_sessions
+			WHERE started_at >= NOW() - INTERVAL ? HOUR AND last_ip != ip_address
+			GROUP BY user_id
+		) sw ON sw.user_id = ips.user_id
+		GROUP BY ips.user_id, u.name, u.email
+		HAVING ip_count >= ? OR ip_switches > 0
+		ORDER BY ip_count DESC, ip_switches DESC
+		LIMIT 200
+	`, hours, hours, hours, minIPs)
+	if err != nil {
+		fmt.Printf("[PLAYBACK] ❌ Suspicious accounts query failed: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memuat data pemutaran"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"accounts": accounts, "hours": hours, "min_ips": minIPs})
+}
+
+// AdminLookupWatermark - Find the account behind a watermark code seen in a leaked recording
+// GET /admin/playbacks/watermarks/:code
+func AdminLookupWatermark(c *gin.Context) {
+	code := helpers.NormalizeWatermarkCode(c.Param("code"))
+
+	var p struct {
+		PlaybackSession
+		UserName  string `db:"user_name" json:"user_name"`
+		UserEmail string `db:"user_email" json:"user_email"`
+	}
+	err := config.DB.Get(&p, `
+		SELECT p.id, p.user_id, p.video_id, COALESCE(v.title, '') AS video_title, p.watermark_code,
+			p.ip_address, p.last_ip, p.user_agent, p.started_at, p.last_seen_at, p.ended_at,
+			p.session_id, u.name AS user_name, u.email AS user_email
+		FROM playback_sessions p
+		JOIN users u ON u.id = p.user_id
+		LEFT JOIN session_videos v ON v.id = p.video_id
+		WHERE p.watermark_code = ?
+	`, code)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Kode watermark tidak ditemukan"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"playback": p})
+}
@@ -1,9 +1,12 @@
 package controllers
 
 import (
+	"fmt"
 	"strconv"
+	"time"
 
 	"BACKEND/config"
+	"BACKEND/entitlements"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,7 +26,7 @@ func BuySession(c *gin.Context) {
 	}
 
 	// Check session exists and published
-	var price float64
+	var price int64
 	var publishStatus string
 
 	err = config.DB.Get(&price, `
@@ -42,29 +45,35 @@ func BuySession(c *gin.Context) {
 		return
 	}
 
-	// Check if user already bought session
-	var count int
-	config.DB.Get(&count, `
-		SELECT COUNT(*) FROM purchases 
-		WHERE user_id = ? AND session_id = ?
-	`, userID, sessionID)
+	// Paid sessions must go through the payment gateway (cart checkout or /payment/token)
+	if price > 0 {
+		c.JSON(400, gin.H{"error": "Sesi ini berbayar, silakan lakukan pembayaran melalui checkout"})
+		return
+	}
 
-	if count > 0 {
+	// Check if user already has access (bought the session or its event package)
+	if entitlements.HasAccess(userID, sessionID) {
 		c.JSON(400, gin.H{"error": "You already purchased this session"})
 		return
 	}
 
-	// Insert new purchase record - FREE sessions get PAID status immediately
-	status := "PAID" // For free sessions, mark as PAID immediately
-	if price > 0 {
-		status = "PENDING" // For paid sessions, will be updated by Midtrans webhook
+	// Free claims are limited per user to prevent abuse
+	if msg := checkFreeClaimLimit(userID); msg != "" {
+		c.JSON(400, gin.H{"error": msg})
+		return
 	}
 
+	// FREE session - mark as PAID immediately with price 0
+	orderID := fmt.Sprintf("FREE-%d-%d-%d", time.Now().Unix(), sessionID, userID)
 	_, err = config.DB.Exec(`
-		INSERT INTO purchases (user_id, session_id, price_paid, status) 
-		VALUES (?, ?, ?, ?)
-	`, userID, sessionID, price, status)
+		INSERT INTO purchases (user_id, session_id, price_paid, status, order_id) 
+		VALUES (?, ?, 0, 'PAID', ?)
+		ON DUPLICATE KEY UPDATE status = 'PAID', order_id = ?, price_paid = 0, purchased_at = CURRENT_TIMESTAMP
+	`, userID, sessionID, orderID, orderID)
 
+	if err == nil {
+		err = entitlements.GrantOrderAccess(config.DB, orderID)
+	}
 	if err != nil {
 		c.JSON(500, gin.H{"error": "Failed to complete purchase"})
 		return
@@ -112,7 +121,8 @@ func BuySession(c *gin.Context) {
 	c.JSON(200, gin.H{
 		"message":    "Purchase successful",
 		"session_id": sessionID,
-		"price_paid": price,
+		"price_paid": 0,
+		"order_id":   orderID,
 	})
 }
 
@@ -127,20 +137,24 @@ func MyPurchases(c *gin.Context) {
 		PurchaseID   int64   `db:"id" json:"id"`
 		SessionID    int64   `db:"session_id" json:"session_id"`
 		SessionTitle string  `db:"session_title" json:"session_title"`
-		PricePaid    float64 `db:"price_paid" json:"price_paid"`
+		PricePaid    int64   `db:"price_paid" json:"price_paid"`
 		EventID      int64   `db:"event_id" json:"event_id"`
 		EventTitle   string  `db:"event_title" json:"event_title"`
 		EventThumb   *string `db:"thumbnail_url" json:"thumbnail_url"`
 	}
 
-	// Return purchases with session + event info so frontend dapat menampilkan grouped view
+	// Return purchases with session + event info so frontend dapat menampilkan grouped view.
+	// An event package expands to every published session of the event (also ones added later).
 	err := config.DB.Select(&purchases, `
-		SELECT p.id, p.session_id, s.title as session_title, p.price_paid,
+		SELECT p.id, s.id as session_id, s.title as session_title,
+			   CASE WHEN p.session_id IS NULL THEN COALESCE(pra.amount, 0) ELSE p.price_paid END as price_paid,
 			   e.id as event_id, e.title as event_title, e.thumbnail_url
 		FROM purchases p
-		JOIN sessions s ON p.session_id = s.id
+		JOIN sessions s ON s.id = p.session_id
+			OR (p.session_id IS NULL AND s.event_id = p.event_id AND s.publish_status = 'PUBLISHED')
 		JOIN events e ON s.event_id = e.id
-		WHERE p.user_id = ? AND p.status = 'PAID'
+		LEFT JOIN package_revenue_allocations pra ON pra.purchase_id = p.id AND pra.session_id = s.id
+		WHERE p.user_id = ? AND p.status = 'PAID' AND COALESCE(p.is_gift, 0) = 0 AND p.license_pool_id IS NULL
 		ORDER BY p.purchased_at DESC
 	`, userID)
 
@@ -174,20 +188,8 @@ func CheckSessionPurchase(c *gin.Context) {
 		return
 	}
 
-	// 2. Cek apakah user sudah membeli DAN status PAID
-	var count int
-	err = config.DB.Get(&count, `
-		SELECT COUNT(*) FROM purchases 
-		WHERE user_id = ? AND session_id = ? AND status = 'PAID'
-	`, userID, sessionID)
-
-	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to check purchase status"})
-		return
-	}
-
-	// Jika count > 0 berarti sudah beli (true), jika 0 belum (false)
-	hasPurchased := count > 0
+	// 2. Cek akses aktif (beli sesi, paket event, hadiah, dll)
+	hasPurchased := entitlements.HasAccess(userID, sessionID)
 
 	c.JSON(200, gin.H{
 		"session_id":    sessionID,
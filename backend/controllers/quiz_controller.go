@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"BACKEND/config"
+	"BACKEND/entitlements"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
@@ -32,11 +33,12 @@ func GetCertificateSettings(c *gin.Context) {
 		EventID          int64   `db:"event_id" json:"event_id"`
 		IsEnabled        bool    `db:"is_enabled" json:"is_enabled"`
 		MinScorePercent  int     `db:"min_score_percent" json:"min_score_percent"`
+		MinWatchPercent  int     `db:"min_watch_percent" json:"min_watch_percent"`
 		CertificateTitle *string `db:"certificate_title" json:"certificate_title"`
 	}
 
 	err := config.DB.Get(&settings, `
-		SELECT id, event_id, is_enabled, min_score_percent, certificate_title
+		SELECT id, event_id, is_enabled, min_score_percent, min_watch_percent, certificate_title
 		FROM event_certificates WHERE event_id = ?
 	`, eventID)
 
@@ -47,6 +49,7 @@ func GetCertificateSettings(c *gin.Context) {
 				"event_id":          eventID,
 				"is_enabled":        false,
 				"min_score_percent": 80,
+				"min_watch_percent": 0,
 				"certificate_title": nil,
 			},
 		})
@@ -69,6 +72,7 @@ func UpdateCertificateSettings(c *gin.Context) {
 	var input struct {
 		IsEnabled        bool    `json:"is_enabled"`
 		MinScorePercent  int     `json:"min_score_percent"`
+		MinWatchPercent  *int    `json:"min_watch_percent"` // omitted keeps the current rule
 		CertificateTitle *string `json:"certificate_title"`
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -79,15 +83,20 @@ func UpdateCertificateSettings(c *gin.Context) {
 	if input.MinScorePercent < 1 || input.MinScorePercent > 100 {
 		input.MinScorePercent = 80
 	}
+	if input.MinWatchPercent != nil && (*input.MinWatchPercent < 0 || *input.MinWatchPercent > 100) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "min_watch_percent must be between 0 and 100"})
+		return
+	}
 
 	// Upsert
 	_, err := config.DB.Exec(`
-		INSERT INTO event_certificates (event_id, is_enabled, min_score_percent, certificate_title)
-		VALUES (?, ?, ?, ?)
+		INSERT INTO event_certificates (event_id, is_enabled, min_score_percent, min_watch_percent, certificate_title)
+		VALUES (?, ?, ?, COALESCE(?, 0), ?)
 		ON DUPLICATE KEY UPDATE is_enabled = VALUES(is_enabled), 
 		                        min_score_percent = VALUES(min_score_percent),
+		                        min_watch_percent = COALESCE(?, min_watch_percent),
 		                        certificate_title = VALUES(certificate_title)
-	`, eventID, input.IsEnabled, input.MinScorePercent, input.CertificateTitle)
+	`, eventID, input.IsEnabled, input.MinScorePercent, input.MinWatchPercent, input.CertificateTitle, input.MinWatchPercent)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save"})
@@ -272,6 +281,7 @@ func GetUserEventProgress(c *gin.Context) {
 	var totalScore float64
 	var progress []gin.H
 
+	allWatched := true
 	for _, q := range quizzes {
 		var attempt struct {
 			ScorePercent float64 `db:"score_percent"`
@@ -289,15 +299,20 @@ func GetUserEventProgress(c *gin.Context) {
 			totalScore += contribution
 		}
 
+		watchPercent, _, watchMet := checkWatchRequirement(userID, q.SessionID)
+		allWatched = allWatched && watchMet
+
 		progress = append(progress, gin.H{
-			"session_id":   q.SessionID,
-			"session_name": q.SessionName,
-			"quiz_id":      q.QuizID,
-			"score":        attempt.ScorePercent,
-			"passed":       attempt.Passed,
-			"weight":       weightPerQuiz,
-			"contribution": contribution,
-			"completed":    err == nil,
+			"session_id":    q.SessionID,
+			"session_name":  q.SessionName,
+			"quiz_id":       q.QuizID,
+			"score":         attempt.ScorePercent,
+			"passed":        attempt.Passed,
+			"weight":        weightPerQuiz,
+			"contribution":  contribution,
+			"completed":     err == nil,
+			"watch_percent": watchPercent,
+			"watch_met":     watchMet,
 		})
 	}
 
@@ -307,13 +322,16 @@ func GetUserEventProgress(c *gin.Context) {
 	if minScore == 0 {
 		minScore = 80
 	}
+	var minWatch int
+	config.DB.Get(&minWatch, "SELECT min_watch_percent FROM event_certificates WHERE event_id = ?", eventID)
 
 	c.JSON(http.StatusOK, gin.H{
 		"has_quizzes":        true,
 		"progress":           progress,
 		"total_percent":      totalScore,
 		"min_score_required": minScore,
-		"can_certificate":    totalScore >= float64(minScore),
+		"min_watch_percent":  minWatch,
+		"can_certificate":    totalScore >= float64(minScore) && allWatched,
 	})
 }
 
@@ -322,13 +340,15 @@ func GetQuizForUser(c *gin.Context) {
 	sessionID := c.Param("sessionID")
 	userID := c.GetInt64("user_id")
 
-	// Check if user has purchased this session
-	var purchased int
-	config.DB.Get(&purchased, "SELECT COUNT(*) FROM purchases WHERE user_id = ? AND session_id = ?", userID, sessionID)
-	if purchased == 0 {
+	// Check if user has access to this session
+	sessID, _ := strconv.ParseInt(sessionID, 10, 64)
+	if !entitlements.HasAccess(userID, sessID) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Please purchase this session first"})
 		return
 	}
+	if !requireWatchedSession(c, userID, sessID) {
+		return
+	}
 
 	var quiz struct {
 		ID    int64  `db:"id" json:"id"`
@@ -375,6 +395,10 @@ func SubmitQuiz(c *gin.Context) {
 
 	fmt.Printf("[DEBUG] Answers received: %v\n", input.Answers)
 
+	if !requireWatchedSession(c, userID, mustParseInt64(sessionID)) {
+		return
+	}
+
 	// Get quiz
 	var quizID int64
 	err := config.DB.Get(&quizID, "SELECT id FROM session_quizzes WHERE session_id = ? AND is_enabled = 1", sessionID)
@@ -492,10 +516,12 @@ func GetUserCertificate(c *gin.Context) {
 
 	// Check eligibility
 	var quizzes []struct {
-		QuizID int64 `db:"quiz_id"`
+		QuizID      int64  `db:"quiz_id"`
+		SessionID   int64  `db:"session_id"`
+		SessionName string `db:"session_name"`
 	}
 	config.DB.Select(&quizzes, `
-		SELECT sq.id as quiz_id FROM session_quizzes sq
+		SELECT sq.id as quiz_id, s.id as session_id, s.title as session_name FROM session_quizzes sq
 		JOIN sessions s ON sq.session_id = s.id
 		WHERE s.event_id = ? AND sq.is_enabled = 1
 	`, eventID)
@@ -535,6 +561,22 @@ func GetUserCertificate(c *gin.Context) {
 		return
 	}
 
+	// Every quiz session must also have been watched far enough
+	for _, q := range quizzes {
+		if percent, minWatch, ok := checkWatchRequirement(userID, q.SessionID); !ok {
+			c.JSON(http.StatusOK, gin.H{
+				"has_certificate":   false,
+				"total_score":       totalScore,
+				"min_required":      minScore,
+				"session_id":        q.SessionID,
+				"watch_percent":     percent,
+				"min_watch_percent": minWatch,
+				"message":           fmt.Sprintf("You need to watch %d%% of %s, current: %.2f%%", minWatch, q.SessionName, percent),
+			})
+			return
+		}
+	}
+
 	// Generate certificate
 	certCode := generateCertCode()
 	config.DB.Exec(`
@@ -569,6 +611,20 @@ func GetUserCertificate(c *gin.Context) {
 	})
 }
 
+// requireWatchedSession blocks a quiz until the event's watch rule is met
+func requireWatchedSession(c *gin.Context, userID, sessionID int64) bool {
+	percent, minWatch, ok := checkWatchRequirement(userID, sessionID)
+	if ok {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":             fmt.Sprintf("Watch at least %d%% of this session's videos to unlock the quiz", minWatch),
+		"watch_percent":     percent,
+		"min_watch_percent": minWatch,
+	})
+	return false
+}
+
 // Helper functions
 func mustParseInt64(s string) int64 {
 	v, _ := strconv.ParseInt(s, 10, 64)
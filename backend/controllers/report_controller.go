@@ -2,9 +2,9 @@ package controllers
 
 import (
 	"BACKEND/config"
+	"BACKEND/filecheck"
 	"fmt"
 	"net/http"
-	"os"
 	"path/filepath"
 	"time"
 
@@ -34,12 +34,13 @@ func SubmitReport(c *gin.Context) {
 	// Handle photo upload
 	var photoURL string
 	if file, err := c.FormFile("photo"); err == nil {
-		os.MkdirAll("uploads/reports", os.ModePerm)
 		filename := fmt.Sprintf("report_%d_%s%s", time.Now().UnixNano(), uuid.New().String()[:8], filepath.Ext(file.Filename))
-		photoPath := filepath.Join("uploads/reports", filename)
-		if err := c.SaveUploadedFile(file, photoPath); err == nil {
-			photoURL = photoPath
+		url, err := uploadFormFile(c, filecheck.ReportPhoto, "reports/"+filename, file, 0)
+		if isUploadRejected(err) {
+			respondUploadError(c, err, "Gagal upload foto")
+			return
 		}
+		photoURL = url
 	}
 
 	_, err := config.DB.Exec(`
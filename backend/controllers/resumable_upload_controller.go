@@ -0,0 +1,522 @@
+package controllers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"BACKEND/config"
+	"BACKEND/filecheck"
+	"BACKEND/helpers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ===============================================
+// RESUMABLE VIDEO UPLOADS
+// ===============================================
+// Create -> append chunks (PATCH with Upload-Offset, like tus) -> complete.
+// Chunks are staged on local disk; the finished file is streamed into the
+// private media storage and becomes a session video.
+
+// resumableUploadTTL is how long an upload may sit idle before it is cleaned up
+const resumableUploadTTL = 24 * time.Hour
+
+// statusChecksumMismatch is the tus status for a chunk whose checksum did not match
+const statusChecksumMismatch = 460
+
+// uploadLocks keeps two requests from appending to the same upload at once
+var uploadLocks sync.Map
+
+// ResumableUpload is one row of resumable_uploads
+type ResumableUpload struct {
+	ID             string    `db:"id" json:"upload_id"`
+	UserID         int64     `db:"user_id" json:"-"`
+	OrganizationID int64     `db:"organization_id" json:"organization_id"`
+	SessionID      int64     `db:"session_id" json:"session_id"`
+	Filename       string    `db:"filename" json:"filename"`
+	Title          string    `db:"title" json:"title"`
+	Description    *string   `db:"description" json:"description"`
+	ContentType    string    `db:"content_type" json:"content_type"`
+	SizeBytes      int64     `db:"size_bytes" json:"size"`
+	OffsetBytes    int64     `db:"offset_bytes" json:"offset"`
+	ChecksumSHA256 *string   `db:"checksum_sha256" json:"checksum_sha256"`
+	Status         string    `db:"status" json:"status"`
+	VideoID        *int64    `db:"video_id" json:"video_id"`
+	ExpiresAt      time.Time `db:"expires_at" json:"expires_at"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+}
+
+// stagingPath is where the chunks of an upload are collected
+func (u ResumableUpload) stagingPath() string {
+	return filepath.Join(config.UploadStagingDir(), u.ID+".part")
+}
+
+func (u ResumableUpload) progress() gin.H {
+	return gin.H{
+		"upload_id":  u.ID,
+		"status":     u.Status,
+		"offset":     u.OffsetBytes,
+		"size":       u.SizeBytes,
+		"progress":   helpers.UploadProgress(u.OffsetBytes, u.SizeBytes),
+		"expires_at": u.ExpiresAt,
+		"video_id":   u.VideoID,
+	}
+}
+
+const resumableUploadColumns = `id, user_id, organization_id, session_id, filename, title, description, content_type,
+	size_bytes, offset_bytes, checksum_sha256, status, video_id, expires_at, created_at`
+
+// loadResumableUpload returns the caller's upload or writes a 404
+func loadResumableUpload(c *gin.Context) (ResumableUpload, bool) {
+	var u ResumableUpload
+	err := config.DB.Get(&u, "SELECT "+resumableUploadColumns+" FROM resumable_uploads WHERE id = ? AND user_id = ?",
+		c.Param("uploadID"), c.GetInt64("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload tidak ditemukan"})
+		return u, false
+	}
+	return u, true
+}
+
+// CreateResumableUpload - Start a resumable session video upload
+// POST /organization/sessions/:sessionID/uploads
+func CreateResumableUpload(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	sessionID, err := strconv.ParseInt(c.Param("sessionID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+	if !checkSessionOwnedByUser(sessionID, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't own this session"})
+		return
+	}
+
+	var input struct {
+		Filename       string `json:"filename" binding:"required"`
+		Size           int64  `json:"size" binding:"required"`
+		ContentType    string `json:"content_type"`
+		Title          string `json:"title"`
+		Description    string `json:"description"`
+		ChecksumSHA256 string `json:"checksum_sha256"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || input.Size <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "filename dan size wajib diisi"})
+		return
+	}
+	if !helpers.IsResumableVideo(input.Filename) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format video tidak didukung (" + strings.Join(helpers.ResumableVideoExtensions, ", ") + ")"})
+		return
+	}
+	if input.Size > filecheck.Video.MaxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Ukuran video maksimal " + helpers.FormatBytes(filecheck.Video.MaxBytes)})
+		return
+	}
+
+	var checksum *string
+	if input.ChecksumSHA256 != "" {
+		sum := strings.ToLower(input.ChecksumSHA256)
+		if b, err := hex.DecodeString(sum); err != nil || len(b) != sha256.Size {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "checksum_sha256 harus berupa hex sha256"})
+			return
+		}
+		checksum = &sum
+	}
+	if input.ContentType == "" {
+		input.ContentType = "application/octet-stream"
+	}
+	if input.Title == "" {
+		input.Title = input.Filename
+	}
+
+	var orgID int64
+	config.DB.Get(&orgID, "SELECT e.organization_id FROM sessions s JOIN events e ON e.id = s.event_id WHERE s.id = ?", sessionID)
+
+	// The size is reserved while the organization row is locked, so parallel
+	// uploads are checked against each other's reservations
+	tx, err := config.DB.Beginx()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat upload"})
+		return
+	}
+	defer tx.Rollback()
+
+	used, err := lockOrgStorageUsage(tx, orgID)
+	if err != nil {
+		fmt.Printf("[UPLOAD] ❌ Failed to lock organization %d: %v\n", orgID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat upload"})
+		return
+	}
+	quota := orgStorageQuota(orgID)
+	if !helpers.QuotaAllows(used, input.Size, quota) {
+		respondStorageQuota(c, used, quota)
+		return
+	}
+
+	u := ResumableUpload{
+		ID:             helpers.GenerateUploadID(),
+		OrganizationID: orgID,
+		SessionID:      sessionID,
+		SizeBytes:      input.Size,
+		Status:         "UPLOADING",
+		ExpiresAt:      time.Now().Add(resumableUploadTTL),
+	}
+	if err := os.MkdirAll(config.UploadStagingDir(), os.ModePerm); err != nil {
+		fmt.Printf("[UPLOAD] ❌ Staging dir: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyiapkan upload"})
+		return
+	}
+	if err := os.WriteFile(u.stagingPath(), nil, 0o600); err != nil {
+		fmt.Printf("[UPLOAD] ❌ Staging file: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyiapkan upload"})
+		return
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO resumable_uploads (id, user_id, organization_id, session_id, filename, title, description,
+			content_type, size_bytes, checksum_sha256, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, u.ID, userID, orgID, sessionID, input.Filename, input.Title, input.Description,
+		input.ContentType, input.Size, checksum, u.ExpiresAt)
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		os.Remove(u.stagingPath())
+		fmt.Printf("[UPLOAD] ❌ Failed to create upload: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat upload"})
+		return
+	}
+
+	fmt.Printf("[UPLOAD] ✅ Upload %s created for session %d (%s)\n", u.ID, sessionID, helpers.FormatBytes(input.Size))
+	c.Header("Location", "/api/organization/uploads/"+u.ID)
+	response := u.progress()
+	response["chunk_size"] = helpers.DefaultUploadChunkSize
+	response["max_chunk_size"] = helpers.MaxUploadChunkSize
+	c.JSON(http.StatusCreated, response)
+}
+
+// GetResumableUpload - Upload progress; clients resume from the returned offset
+// GET /organization/uploads/:uploadID
+func GetResumableUpload(c *gin.Context) {
+	u, ok := loadResumableUpload(c)
+	if !ok {
+		return
+	}
+	c.Header("Upload-Offset", strconv.FormatInt(u.OffsetBytes, 10))
+	c.Header("Upload-Length", strconv.FormatInt(u.SizeBytes, 10))
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, u.progress())
+}
+
+// AppendResumableUpload - Append one chunk at Upload-Offset, verified against Upload-Checksum
+// PATCH /organization/uploads/:uploadID
+func AppendResumableUpload(c *gin.Context) {
+	u, ok := loadResumableUpload(c)
+	if !ok {
+		return
+	}
+	if u.Status != "UPLOADING" || time.Now().After(u.ExpiresAt) {
+		c.JSON(http.StatusGone, gin.H{"error": "Upload sudah tidak aktif", "status": u.Status})
+		return
+	}
+
+	lock, _ := uploadLocks.LoadOrStore(u.ID, &sync.Mutex{})
+	if !lock.(*sync.Mutex).TryLock() {
+		c.JSON(http.StatusLocked, gin.H{"error": "Chunk lain sedang diunggah untuk upload ini"})
+		return
+	}
+	defer lock.(*sync.Mutex).Unlock()
+
+	// Re-read under the lock; the offset may have moved since
+	if err := config.DB.Get(&u.OffsetBytes, "SELECT offset_bytes FROM resumable_uploads WHERE id = ?", u.ID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload tidak ditemukan"})
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset != u.OffsetBytes {
+		c.Header("Upload-Offset", strconv.FormatInt(u.OffsetBytes, 10))
+		c.JSON(http.StatusConflict, gin.H{"error": "Upload-Offset tidak sesuai", "offset": u.OffsetBytes})
+		return
+	}
+
+	length := c.Request.ContentLength
+	if length <= 0 || length > helpers.MaxUploadChunkSize || offset+length > u.SizeBytes {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":          "Ukuran chunk tidak valid",
+			"max_chunk_size": helpers.MaxUploadChunkSize,
+			"remaining":      u.SizeBytes - offset,
+		})
+		return
+	}
+
+	var expected []byte
+	if header := c.GetHeader("Upload-Checksum"); header != "" {
+		if expected, err = helpers.ParseUploadChecksum(header); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	f, err := os.OpenFile(u.stagingPath(), os.O_WRONLY, 0o600)
+	if err != nil {
+		fmt.Printf("[UPLOAD] ❌ Staging file for %s: %v\n", u.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "File sementara upload tidak ditemukan"})
+		return
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	written, err := io.Copy(io.MultiWriter(&offsetWriter{f: f, off: offset}, hasher), io.LimitReader(c.Request.Body, length))
+	if err != nil || written != length {
+		f.Truncate(offset)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Chunk tidak lengkap, ulangi dari offset terakhir", "offset": offset})
+		return
+	}
+	if expected != nil && !bytes.Equal(hasher.Sum(nil), expected) {
+		f.Truncate(offset)
+		c.JSON(statusChecksumMismatch, gin.H{"error": "Checksum chunk tidak cocok", "offset": offset})
+		return
+	}
+
+	newOffset := offset + length
+	expiresAt := time.Now().Add(resumableUploadTTL)
+	if _, err := config.DB.Exec("UPDATE resumable_uploads SET offset_bytes = ?, expires_at = ? WHERE id = ?", newOffset, expiresAt, u.ID); err != nil {
+		f.Truncate(offset)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan progres upload"})
+		return
+	}
+
+	u.OffsetBytes, u.ExpiresAt = newOffset, expiresAt
+	c.Header("Upload-Offset", strconv.FormatInt(newOffset, 10))
+	c.JSON(http.StatusOK, u.progress())
+}
+
+// offsetWriter writes sequentially starting at a file offset
+type offsetWriter struct {
+	f   *os.File
+	off int64
+}
+
+func (w *offsetWriter) Write(p []byte) (int, error) {
+	n, err := w.f.WriteAt(p, w.off)
+	w.off += int64(n)
+	return n, err
+}
+
+// CompleteResumableUpload - Verify the whole file and turn it into a session video
+// POST /organization/uploads/:uploadID/complete
+func CompleteResumableUpload(c *gin.Context) {
+	u, ok := loadResumableUpload(c)
+	if !ok {
+		return
+	}
+	if u.Status == "COMPLETED" {
+		c.JSON(http.StatusOK, u.progress())
+		return
+	}
+	if u.Status != "UPLOADING" {
+		c.JSON(http.StatusGone, gin.H{"error": "Upload sudah tidak aktif", "status": u.Status})
+		return
+	}
+
+	lock, _ := uploadLocks.LoadOrStore(u.ID, &sync.Mutex{})
+	if !lock.(*sync.Mutex).TryLock() {
+		c.JSON(http.StatusLocked, gin.H{"error": "Upload sedang diproses"})
+		return
+	}
+	defer lock.(*sync.Mutex).Unlock()
+
+	config.DB.Get(&u.OffsetBytes, "SELECT offset_bytes FROM resumable_uploads WHERE id = ?", u.ID)
+	if u.OffsetBytes != u.SizeBytes {
+		c.JSON(http.StatusConflict, gin.H{"error": "Upload belum lengkap", "offset": u.OffsetBytes, "size": u.SizeBytes})
+		return
+	}
+
+	f, err := os.Open(u.stagingPath())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "File sementara upload tidak ditemukan"})
+		return
+	}
+	defer f.Close()
+
+	if u.ChecksumSHA256 != nil {
+		hasher := sha256.New()
+		if _, err := io.Copy(hasher, f); err != nil || hex.EncodeToString(hasher.Sum(nil)) != *u.ChecksumSHA256 {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Checksum file tidak cocok, silakan upload ulang"})
+			return
+		}
+		f.Seek(0, io.SeekStart)
+	}
+
+	checked, err := checkUpload(c, filecheck.Video, u.Filename, f, u.SizeBytes)
+	if err != nil {
+		// Ditolak karena isinya: mengulang upload yang sama tidak akan berhasil
+		var rejected *filecheck.Error
+		if errors.As(err, &rejected) && rejected.Status != http.StatusServiceUnavailable {
+			config.DB.Exec("UPDATE resumable_uploads SET status = 'ABORTED' WHERE id = ?", u.ID)
+			f.Close()
+			os.Remove(u.stagingPath())
+		}
+		respondUploadError(c, err, "Gagal memeriksa video")
+		return
+	}
+
+	// The quota may have shrunk, or other uploads filled it, since the upload was created.
+	// This upload's own reservation stays counted until the video is recorded.
+	if err := checkResumableUploadQuota(u); err != nil {
+		respondUploadError(c, err, "Gagal memeriksa kuota penyimpanan")
+		return
+	}
+
+	ext := strings.ToLower(filepath.Ext(u.Filename))
+	key := fmt.Sprintf("videos/session_%d_%d%s", u.SessionID, time.Now().Unix(), ext)
+	if err := config.MediaStorage.Put(c.Request.Context(), key, checked.Body, checked.Size, checked.ContentType); err != nil {
+		fmt.Printf("[UPLOAD] ❌ Storing %s failed: %v\n", u.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan video"})
+		return
+	}
+	videoURL := config.MediaStorage.URL(key)
+
+	description := ""
+	if u.Description != nil {
+		description = *u.Description
+	}
+	result, err := config.DB.Exec(`
+		INSERT INTO session_videos (session_id, title, description, video_url, size_bytes)
+		VALUES (?, ?, ?, ?, ?)
+	`, u.SessionID, u.Title, description, videoURL, u.SizeBytes)
+	if err != nil {
+		fmt.Printf("[UPLOAD] ❌ Failed to insert video for %s: %v\n", u.ID, err)
+		config.MediaStorage.Delete(c.Request.Context(), key)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan data video"})
+		return
+	}
+	videoID, _ := result.LastInsertId()
+
+	// Recorded before the reservation is released, so usage is never undercounted
+	recordStoredObject(config.MediaStorage, key, u.OrganizationID, checked.Size)
+	config.DB.Exec("UPDATE resumable_uploads SET status = 'COMPLETED', video_id = ? WHERE id = ?", videoID, u.ID)
+	f.Close()
+	os.Remove(u.stagingPath())
+	uploadLocks.Delete(u.ID)
+
+	go RunVideoTranscodeQueue()
+
+	fmt.Printf("[UPLOAD] ✅ Upload %s completed as video %d\n", u.ID, videoID)
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Video uploaded successfully",
+		"upload_id":  u.ID,
+		"video_id":   videoID,
+		"video_url":  videoURL,
+		"hls_status": "PENDING",
+	})
+}
+
+// checkResumableUploadQuota re-checks a finished upload against the organization's
+// quota under the organization lock, leaving out the upload's own reservation
+func checkResumableUploadQuota(u ResumableUpload) error {
+	tx, err := config.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	used, err := lockOrgStorageUsage(tx, u.OrganizationID)
+	if err != nil {
+		return err
+	}
+	used -= u.SizeBytes
+	quota := orgStorageQuota(u.OrganizationID)
+	if helpers.QuotaAllows(used, u.SizeBytes, quota) {
+		return nil
+	}
+	return &filecheck.Error{
+		Status:  http.StatusRequestEntityTooLarge,
+		Message: fmt.Sprintf("Kuota penyimpanan tidak cukup (terpakai %s dari %s)", helpers.FormatBytes(used), helpers.FormatBytes(quota)),
+	}
+}
+
+// respondStorageQuota refuses an upload that does not fit in the organization's quota
+func respondStorageQuota(c *gin.Context, used, quota int64) {
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error":       fmt.Sprintf("Kuota penyimpanan tidak cukup (terpakai %s dari %s)", helpers.FormatBytes(used), helpers.FormatBytes(quota)),
+		"used_bytes":  used,
+		"quota_bytes": quota,
+	})
+}
+
+// AbortResumableUpload - Cancel an upload and discard its chunks
+// DELETE /organization/uploads/:uploadID
+func AbortResumableUpload(c *gin.Context) {
+	u, ok := loadResumableUpload(c)
+	if !ok {
+		return
+	}
+	if u.Status != "UPLOADING" {
+		c.JSON(http.StatusGone, gin.H{"error": "Upload sudah tidak aktif", "status": u.Status})
+		return
+	}
+
+	// A chunk or the completion still running would write into the removed file
+	lock, _ := uploadLocks.LoadOrStore(u.ID, &sync.Mutex{})
+	if !lock.(*sync.Mutex).TryLock() {
+		c.JSON(http.StatusLocked, gin.H{"error": "Upload sedang diproses"})
+		return
+	}
+	defer lock.(*sync.Mutex).Unlock()
+
+	// Re-read under the lock; a completion may have finished in between
+	config.DB.Get(&u.Status, "SELECT status FROM resumable_uploads WHERE id = ?", u.ID)
+	if u.Status != "UPLOADING" {
+		c.JSON(http.StatusGone, gin.H{"error": "Upload sudah tidak aktif", "status": u.Status})
+		return
+	}
+
+	config.DB.Exec("UPDATE resumable_uploads SET status = 'ABORTED' WHERE id = ?", u.ID)
+	os.Remove(u.stagingPath())
+	uploadLocks.Delete(u.ID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Upload dibatalkan"})
+}
+
+// CleanupAbandonedUploads expires uploads idle past their expiry and removes their
+// chunks; it returns how many were cleaned up
+func CleanupAbandonedUploads() int {
+	var uploads []ResumableUpload
+	config.DB.Select(&uploads, "SELECT "+resumableUploadColumns+" FROM resumable_uploads WHERE status = 'UPLOADING' AND expires_at < NOW()")
+
+	cleaned := 0
+	for _, u := range uploads {
+		res, err := config.DB.Exec("UPDATE resumable_uploads SET status = 'EXPIRED' WHERE id = ? AND status = 'UPLOADING'", u.ID)
+		if err != nil {
+			fmt.Printf("[UPLOAD] ❌ Failed to expire %s: %v\n", u.ID, err)
+			continue
+		}
+		if rows, _ := res.RowsAffected(); rows == 0 {
+			continue
+		}
+		if err := os.Remove(u.stagingPath()); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("[UPLOAD] ❌ Failed to remove chunks of %s: %v\n", u.ID, err)
+		}
+		uploadLocks.Delete(u.ID)
+		cleaned++
+	}
+
+	if cleaned > 0 {
+		fmt.Printf("[UPLOAD] ✅ Cleaned up %d abandoned upload(s)\n", cleaned)
+	}
+	return cleaned
+}
@@ -3,7 +3,6 @@ package controllers
 import (
 	"BACKEND/config"
 	"net/http"
-	"os"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -60,6 +59,9 @@ func UpdateSession(c *gin.Context) {
 		return
 	}
 
+	var oldPrice int64
+	config.DB.Get(&oldPrice, "SELECT COALESCE(price, 0) FROM sessions WHERE id = ?", sessionID)
+
 	updateQuery := `UPDATE sessions SET title = ?, description = ?, price = ? WHERE id = ?`
 	_, err = config.DB.Exec(updateQuery, input.Title, input.Description, input.Price, sessionID)
 	if err != nil {
@@ -67,6 +69,9 @@ func UpdateSession(c *gin.Context) {
 		return
 	}
 
+	// Tell users who wishlisted this session when it got cheaper
+	go NotifyWishlistPriceDrop(sessionID, oldPrice, input.Price)
+
 	c.JSON(http.StatusOK, gin.H{"message": "Sesi berhasil diperbarui"})
 }
 
@@ -92,7 +97,11 @@ func DeleteSession(c *gin.Context) {
 		return
 	}
 
-	// 2. Hapus File Fisik (Video & Modul)
+	// 2. Hapus File dari Storage (Video & Modul)
+	var videoIDs []int64
+	config.DB.Select(&videoIDs, "SELECT id FROM session_videos WHERE session_id = ?", sessionID)
+	deleteVideoRenditions(c, videoIDs...)
+
 	var filePaths []string
 	config.DB.Select(&filePaths, "SELECT video_url FROM session_videos WHERE session_id = ?", sessionID)
 	var docPaths []string
@@ -100,7 +109,7 @@ func DeleteSession(c *gin.Context) {
 	filePaths = append(filePaths, docPaths...)
 
 	for _, path := range filePaths {
-		if path != "" { os.Remove(path) }
+		deleteStoredFile(c, path)
 	}
 
 	// 3. Hapus DATA ANAK Dulu (Video, File, Purchase) dari Database
@@ -4,14 +4,14 @@ import (
 	"fmt"
 	"net/http"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"BACKEND/config"
+	"BACKEND/entitlements"
+	"BACKEND/filecheck"
 	"BACKEND/models"
-	"BACKEND/utils"
 )
 
 // NOTE: Fungsi checkSessionOwnedByUser diasumsikan ada di helpers.go
@@ -52,17 +52,17 @@ func UploadSessionVideo(c *gin.Context) {
 	uniqueName := fmt.Sprintf("session_%d_%d%s", sessionID, time.Now().Unix(), ext)
 	storagePath := "videos/" + uniqueName
 
-	publicURL, err := utils.UploadFileHeaderToSupabase(storagePath, fileHeader)
+	publicURL, size, err := uploadMediaFile(c, filecheck.Video, storagePath, fileHeader, sessionOrganization(sessionID))
 	if err != nil {
-		fmt.Printf("[UPLOAD_VIDEO_ERROR] Supabase upload: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload video"})
+		fmt.Printf("[UPLOAD_VIDEO_ERROR] Storage upload: %v\n", err)
+		respondUploadError(c, err, "Failed to upload video")
 		return
 	}
 
 	_, err = config.DB.Exec(`
-		INSERT INTO session_videos (session_id, title, description, video_url)
-		VALUES (?, ?, ?, ?)
-	`, sessionID, finalTitle, descriptionInput, publicURL)
+		INSERT INTO session_videos (session_id, title, description, video_url, size_bytes)
+		VALUES (?, ?, ?, ?, ?)
+	`, sessionID, finalTitle, descriptionInput, publicURL, size)
 
 	if err != nil {
 		fmt.Printf("[UPLOAD_VIDEO_ERROR] Failed to insert: %v\n", err)
@@ -70,7 +70,10 @@ func UploadSessionVideo(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Video uploaded successfully"})
+	// Renditions HLS dibuat di background; video asli bisa diputar sampai selesai
+	go RunVideoTranscodeQueue()
+
+	c.JSON(http.StatusOK, gin.H{"message": "Video uploaded successfully", "hls_status": "PENDING"})
 }
 
 // =======================================
@@ -98,12 +101,6 @@ func UploadSessionFile(c *gin.Context) {
 	}
 
 	ext := filepath.Ext(fileHeader.Filename)
-	switch ext {
-	case ".pdf", ".ppt", ".pptx", ".doc", ".docx":
-	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Only pdf/ppt/pptx/doc/docx allowed"})
-		return
-	}
 
 	titleInput := c.PostForm("title")
 
@@ -115,17 +112,17 @@ func UploadSessionFile(c *gin.Context) {
 	filename := fmt.Sprintf("session_file_%d_%d%s", sessionID, time.Now().Unix(), ext)
 	storagePath := "files/" + filename
 
-	publicURL, err := utils.UploadFileHeaderToSupabase(storagePath, fileHeader)
+	publicURL, size, err := uploadMediaFile(c, filecheck.Document, storagePath, fileHeader, sessionOrganization(sessionID))
 	if err != nil {
-		fmt.Printf("[UPLOAD_FILE_ERROR] Supabase upload: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload file"})
+		fmt.Printf("[UPLOAD_FILE_ERROR] Storage upload: %v\n", err)
+		respondUploadError(c, err, "Failed to upload file")
 		return
 	}
 
 	_, err = config.DB.Exec(`
-		INSERT INTO session_files (session_id, title, file_url)
-		VALUES (?, ?, ?)
-	`, sessionID, finalTitle, publicURL)
+		INSERT INTO session_files (session_id, title, file_url, size_bytes)
+		VALUES (?, ?, ?, ?)
+	`, sessionID, finalTitle, publicURL, size)
 
 	if err != nil {
 		fmt.Printf("[UPLOAD_FILE_ERROR] DB insert: %v\n", err)
@@ -233,11 +230,9 @@ func DeleteSessionVideo(c *gin.Context) {
 		return
 	}
 
-	// 2. Hapus File dari Supabase
-	if videoPath != "" && strings.Contains(videoPath, "supabase") {
-		sp := utils.GetStoragePathFromURL(videoPath)
-		utils.DeleteFromSupabase(sp)
-	}
+	// 2. Hapus File dari Storage (termasuk renditions HLS & poster)
+	deleteVideoRenditions(c, mediaID)
+	deleteStoredFile(c, videoPath)
 
 	// 3. Hapus Record DB
 	_, err = config.DB.Exec("DELETE FROM session_videos WHERE id = ?", mediaID)
@@ -274,11 +269,8 @@ func DeleteSessionFile(c *gin.Context) {
 		return
 	}
 
-	// 2. Hapus File dari Supabase
-	if filePath != "" && strings.Contains(filePath, "supabase") {
-		sp := utils.GetStoragePathFromURL(filePath)
-		utils.DeleteFromSupabase(sp)
-	}
+	// 2. Hapus File dari Storage
+	deleteStoredFile(c, filePath)
 
 	// 3. Hapus Record DB
 	_, err = config.DB.Exec("DELETE FROM session_files WHERE id = ?", mediaID)
@@ -303,7 +295,7 @@ func GetSessionMedia(c *gin.Context) {
 	}
 	var videos []models.SessionVideo
 	// Added description
-	config.DB.Select(&videos, `SELECT id, session_id, title, COALESCE(description, '') as description, video_url FROM session_videos WHERE session_id = ? ORDER BY id ASC`, sessionID)
+	config.DB.Select(&videos, `SELECT id, session_id, title, COALESCE(description, '') as description, video_url, hls_status, duration_seconds, poster_url FROM session_videos WHERE session_id = ? ORDER BY id ASC`, sessionID)
 	if videos == nil {
 		videos = []models.SessionVideo{}
 	}
@@ -321,9 +313,7 @@ func GetUserSessionMedia(c *gin.Context) {
 	sessionIDStr := c.Param("sessionID")
 	var sessionID int64
 	fmt.Sscan(sessionIDStr, &sessionID)
-	var count int
-	config.DB.Get(&count, `SELECT COUNT(*) FROM purchases WHERE user_id = ? AND session_id = ?`, userID, sessionID)
-	if count == 0 {
+	if !entitlements.HasAccess(userID, sessionID) {
 		c.JSON(403, gin.H{"error": "Not Purchased"})
 		return
 	}
@@ -335,7 +325,7 @@ func GetUserSessionMedia(c *gin.Context) {
 	}
 	var videos []models.SessionVideo
 	// Added description
-	config.DB.Select(&videos, `SELECT id, session_id, title, COALESCE(description, '') as description, video_url FROM session_videos WHERE session_id = ? ORDER BY id ASC`, sessionID)
+	config.DB.Select(&videos, `SELECT id, session_id, title, COALESCE(description, '') as description, video_url, hls_status, duration_seconds, poster_url FROM session_videos WHERE session_id = ? ORDER BY id ASC`, sessionID)
 	if videos == nil {
 		videos = []models.SessionVideo{}
 	}
@@ -2,9 +2,6 @@ package controllers
 
 import (
 	"fmt"
-	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
 	"time"
 
@@ -35,19 +32,24 @@ func StreamSessionVideo(c *gin.Context) {
 		return
 	}
 
-	// 2. Validasi Token
+	// 2. Validasi Token (terikat ke sesi pemutaran)
 	userID, _ := strconv.ParseInt(uidStr, 10, 64)
-	if !helpers.ValidateSignedToken(userID, filename, exp, token) {
+	playbackID := c.Query("psid")
+	if playbackID == "" || !helpers.ValidateSignedToken(userID, playbackResource(filename, playbackID), exp, token) {
 		fmt.Println("❌ Error: Invalid Token Signature")
 		c.JSON(403, gin.H{"error": "Invalid token signature"})
 		return
 	}
 
 	// 3. Cek Database (Pakai LIKE agar lebih aman)
-	var sessionID int64
+	var video struct {
+		ID        int64  `db:"id"`
+		SessionID int64  `db:"session_id"`
+		VideoURL  string `db:"video_url"`
+	}
 	// Mencari video yang URL-nya MENGANDUNG nama file ini
-	err := config.DB.Get(&sessionID,
-		"SELECT session_id FROM session_videos WHERE video_url LIKE ?",
+	err := config.DB.Get(&video,
+		"SELECT id, session_id, video_url FROM session_videos WHERE video_url LIKE ?",
 		"%"+filename,
 	)
 	if err != nil {
@@ -56,32 +58,31 @@ func StreamSessionVideo(c *gin.Context) {
 		return
 	}
 
-	// 4. Cek Pembelian
-	var count int
-	config.DB.Get(&count,
-		"SELECT COUNT(*) FROM purchases WHERE user_id=? AND session_id=?",
-		userID, sessionID,
-	)
-	if count == 0 {
-		fmt.Println("❌ Error: User belum beli sesi ini. SessionID:", sessionID)
+	// 4. Cek Hak Akses
+	if !canAccessSessionMedia(userID, video.SessionID) {
+		fmt.Println("❌ Error: User belum beli sesi ini. SessionID:", video.SessionID)
 		c.JSON(403, gin.H{"error": "Unauthorized access (not purchased)"})
 		return
 	}
 
-	// 5. Cek Fisik File
-	fullPath := filepath.Join("uploads/videos", filename)
-	fmt.Println("Mencari file fisik di:", fullPath)
+	// 5. Cek Sesi Pemutaran (batas streaming bersamaan)
+	if _, ok := touchPlayback(c, userID, video.ID, playbackID); !ok {
+		fmt.Println("❌ Error: Sesi pemutaran berakhir atau batas streaming tercapai:", playbackID)
+		return
+	}
 
-	if _, err := os.Stat(fullPath); os.IsNotExist(err) {
-		fmt.Println("❌ Error: File fisik tidak ditemukan di server!")
-		// Coba cari di folder files barangkali salah upload
+	// 6. Cari file di storage (lokal, S3 atau Supabase)
+	store, key, ok := resolveStoredObject(video.VideoURL)
+	if !ok {
+		fmt.Println("❌ Error: Lokasi video tidak dikenali:", video.VideoURL)
 		c.JSON(404, gin.H{"error": "Video file not found on server"})
 		return
 	}
 
-	// 6. Serve File
-	fmt.Println("✅ Sukses! Memulai streaming...")
-	http.ServeFile(c.Writer, c.Request, fullPath)
+	// 7. Serve File (mendukung Range untuk seek)
+	fmt.Println("✅ Sukses! Memulai streaming:", key)
+	sent := serveStoredObject(c, store, key)
+	recordBandwidth(sessionOrganization(video.SessionID), video.SessionID, sent)
 }
 
 // =============================================================
@@ -108,9 +109,12 @@ func StreamSessionFile(c *gin.Context) {
 		return
 	}
 
-	var sessionID int64
-	err := config.DB.Get(&sessionID,
-		"SELECT session_id FROM session_files WHERE file_url LIKE ?",
+	var file struct {
+		SessionID int64  `db:"session_id"`
+		FileURL   string `db:"file_url"`
+	}
+	err := config.DB.Get(&file,
+		"SELECT session_id, file_url FROM session_files WHERE file_url LIKE ?",
 		"%"+filename,
 	)
 	if err != nil {
@@ -119,23 +123,19 @@ func StreamSessionFile(c *gin.Context) {
 		return
 	}
 
-	var count int
-	config.DB.Get(&count,
-		"SELECT COUNT(*) FROM purchases WHERE user_id=? AND session_id=?",
-		userID, sessionID,
-	)
-	if count == 0 {
+	if !canAccessSessionMedia(userID, file.SessionID) {
 		c.JSON(403, gin.H{"error": "Unauthorized"})
 		return
 	}
 
-	fullPath := filepath.Join("uploads/files", filename)
-	if _, err := os.Stat(fullPath); os.IsNotExist(err) {
-		fmt.Println("❌ Error: File fisik tidak ditemukan:", fullPath)
+	store, key, ok := resolveStoredObject(file.FileURL)
+	if !ok {
+		fmt.Println("❌ Error: Lokasi file tidak dikenali:", file.FileURL)
 		c.JSON(404, gin.H{"error": "File not found"})
 		return
 	}
 
-	fmt.Println("✅ Sukses! Membuka file...")
-	http.ServeFile(c.Writer, c.Request, fullPath)
+	fmt.Println("✅ Sukses! Membuka file:", key)
+	sent := serveStoredObject(c, store, key)
+	recordBandwidth(sessionOrganization(file.SessionID), file.SessionID, sent)
 }
@@ -3,49 +3,111 @@ package controllers
 import (
 	"fmt"
 	"net/http"
-	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"BACKEND/config"
+	"BACKEND/entitlements"
 	"BACKEND/helpers"
+	"BACKEND/media"
 )
 
+// canAccessSessionMedia - Buyers with an active entitlement and the organizer
+// who owns the session may watch or download its media
+func canAccessSessionMedia(userID, sessionID int64) bool {
+	return entitlements.HasAccess(userID, sessionID) || checkSessionOwnedByUser(sessionID, userID)
+}
+
+// fileSessionID finds the session a stored file belongs to by filename
+func fileSessionID(filename string) (int64, bool) {
+	if filename == "" {
+		return 0, false
+	}
+	var sessionID int64
+	if err := config.DB.Get(&sessionID, "SELECT session_id FROM session_files WHERE file_url LIKE ? LIMIT 1", "%"+filename); err != nil {
+		return 0, false
+	}
+	return sessionID, true
+}
+
+// GetSignedVideoURL - Short-lived streaming URL, only minted after an entitlement check
+// GET /user/sessions/signed-video/:filename
 func GetSignedVideoURL(c *gin.Context) {
 	userID := c.GetInt64("user_id")
 	filename := c.Param("filename")
 
-	// 1. Coba cari URL asli di database (untuk support Supabase)
-	var videoURL string
-	// Gunakan LIKE %filename karena filename mungkin hanya bagian akhir dari URL
-	err := config.DB.Get(&videoURL, "SELECT video_url FROM session_videos WHERE video_url LIKE ?", "%"+filename)
+	var video struct {
+		ID              int64  `db:"id"`
+		SessionID       int64  `db:"session_id"`
+		HLSStatus       string `db:"hls_status"`
+		DurationSeconds *int   `db:"duration_seconds"`
+	}
+	err := config.DB.Get(&video, "SELECT id, session_id, hls_status, duration_seconds FROM session_videos WHERE video_url LIKE ? LIMIT 1", "%"+filename)
+	if filename == "" || err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Video tidak ditemukan"})
+		return
+	}
+	if !canAccessSessionMedia(userID, video.SessionID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Anda belum memiliki akses ke sesi ini"})
+		return
+	}
+	// URL baru tidak dibuat lagi setelah kuota bandwidth bulanan organisasi habis
+	if orgOverBandwidth(sessionOrganization(video.SessionID)) {
+		respondBandwidthQuota(c)
+		return
+	}
 
-	if err == nil && strings.HasPrefix(videoURL, "http") {
-		// Jika ketemu dan itu URL eksternal (Supabase), kembalikan langsung
-		c.JSON(http.StatusOK, gin.H{"url": videoURL})
+	// Setiap pemutaran memakai satu slot dari batas streaming bersamaan akun
+	playbackID, watermarkCode, ok := startPlayback(c, userID, video.ID, video.SessionID)
+	if !ok {
 		return
 	}
 
-	// 2. Jika tidak ketemu atau file lokal, gunakan mekanisme legacy (Signed URL ke Stream Controller)
-	token, exp := helpers.GenerateSignedToken(userID, filename)
+	// Semua video (lokal, S3 atau Supabase) dialirkan lewat Stream Controller,
+	// yang memeriksa token dan hak akses sebelum membaca dari storage
+	token, exp := helpers.GenerateSignedToken(userID, playbackResource(filename, playbackID))
 
 	// Masukkan uid ke dalam URL agar controller stream tau siapa yang nonton
-	signedURL := fmt.Sprintf("/api/user/sessions/video/%s?token=%s&exp=%d&uid=%d",
-		filename, token, exp, userID)
+	signedURL := fmt.Sprintf("/api/user/sessions/video/%s?token=%s&exp=%d&uid=%d&psid=%s",
+		filename, token, exp, userID, playbackID)
+
+	response := gin.H{
+		"url":         signedURL,
+		"expires_at":  exp,
+		"hls_status":  video.HLSStatus,
+		"playback_id": playbackID,
+		"watermark":   playbackWatermark(userID, watermarkCode),
+	}
+
+	// Player sebaiknya memakai HLS begitu renditions siap; url di atas tetap jadi fallback
+	if video.HLSStatus == "READY" {
+		hlsExp := time.Now().Add(hlsTokenTTL(video.DurationSeconds)).Unix()
+		response["hls_url"] = signedHLSURL(userID, video.ID, media.MasterPlaylistName, playbackID, hlsExp)
+		response["hls_expires_at"] = hlsExp
+		response["duration_seconds"] = video.DurationSeconds
+	}
 
-	c.JSON(http.StatusOK, gin.H{"url": signedURL})
+	c.JSON(http.StatusOK, response)
 }
 
+// GetSignedFileURL - Short-lived download URL, only minted after an entitlement check
+// GET /user/sessions/signed-file/:filename
 func GetSignedFileURL(c *gin.Context) {
 	userID := c.GetInt64("user_id")
 	filename := c.Param("filename")
 
-	// 1. Coba cari URL asli di database
-	var fileURL string
-	err := config.DB.Get(&fileURL, "SELECT file_url FROM session_files WHERE file_url LIKE ?", "%"+filename)
-
-	if err == nil && strings.HasPrefix(fileURL, "http") {
-		c.JSON(http.StatusOK, gin.H{"url": fileURL})
+	sessionID, ok := fileSessionID(filename)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File tidak ditemukan"})
+		return
+	}
+	if !canAccessSessionMedia(userID, sessionID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Anda belum memiliki akses ke sesi ini"})
+		return
+	}
+	if orgOverBandwidth(sessionOrganization(sessionID)) {
+		respondBandwidthQuota(c)
 		return
 	}
 
@@ -54,5 +116,5 @@ func GetSignedFileURL(c *gin.Context) {
 	signedURL := fmt.Sprintf("/api/user/sessions/file/%s?token=%s&exp=%d&uid=%d",
 		filename, token, exp, userID)
 
-	c.JSON(http.StatusOK, gin.H{"url": signedURL})
+	c.JSON(http.StatusOK, gin.H{"url": signedURL, "expires_at": exp})
 }
@@ -0,0 +1,379 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"BACKEND/config"
+	"BACKEND/filecheck"
+	"BACKEND/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ===============================================
+// STORAGE
+// ===============================================
+
+// checkUpload runs the shared upload validation (content sniffing, size limit,
+// metadata stripping and malware scan) for one upload slot
+func checkUpload(c *gin.Context, kind filecheck.Kind, filename string, src io.ReadSeeker, size int64) (*filecheck.File, error) {
+	checked, err := filecheck.Check(c.Request.Context(), kind, config.Scanner, filename, src, size)
+	if err != nil {
+		fmt.Printf("[UPLOAD] ❌ Rejected %s %q: %v\n", kind.Label, filename, err)
+		return nil, err
+	}
+	return checked, nil
+}
+
+// putFormFile validates an uploaded form file, checks it against the
+// organization's storage quota (orgID 0 = platform content) and stores it in s.
+// It returns the URL and the stored size.
+func putFormFile(c *gin.Context, s storage.Storage, kind filecheck.Kind, key string, fileHeader *multipart.FileHeader, orgID int64) (string, int64, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	checked, err := checkUpload(c, kind, fileHeader.Filename, file, fileHeader.Size)
+	if err != nil {
+		return "", 0, err
+	}
+	if orgID > 0 {
+		tx, err := config.DB.Beginx()
+		if err != nil {
+			return "", 0, err
+		}
+		defer tx.Rollback()
+		if err := checkStorageQuota(tx, orgID, checked.Size); err != nil {
+			return "", 0, err
+		}
+	}
+	if err := s.Put(c.Request.Context(), key, checked.Body, checked.Size, checked.ContentType); err != nil {
+		return "", 0, err
+	}
+	recordStoredObject(s, key, orgID, checked.Size)
+	return s.URL(key), checked.Size, nil
+}
+
+// uploadFormFile validates an uploaded form file, streams it into the configured
+// storage and returns the URL to store in the database
+func uploadFormFile(c *gin.Context, kind filecheck.Kind, key string, fileHeader *multipart.FileHeader, orgID int64) (string, error) {
+	url, size, err := putFormFile(c, config.Storage, kind, key, fileHeader, orgID)
+	if err != nil {
+		fmt.Printf("[STORAGE] ❌ Upload %s failed: %v\n", key, err)
+		return "", err
+	}
+	fmt.Printf("[STORAGE] ✅ Uploaded %s (%d bytes)\n", key, size)
+	return url, nil
+}
+
+// uploadMediaFile stores a paid session video or file in the private media
+// storage and returns its URL and stored size
+func uploadMediaFile(c *gin.Context, kind filecheck.Kind, key string, fileHeader *multipart.FileHeader, orgID int64) (string, int64, error) {
+	url, size, err := putFormFile(c, config.MediaStorage, kind, key, fileHeader, orgID)
+	if err != nil {
+		fmt.Printf("[STORAGE] ❌ Private upload %s failed: %v\n", key, err)
+		return "", 0, err
+	}
+	fmt.Printf("[STORAGE] ✅ Uploaded %s to private storage (%d bytes)\n", key, size)
+	return url, size, nil
+}
+
+// isUploadRejected reports whether err is a validation rejection rather than a storage failure
+func isUploadRejected(err error) bool {
+	var rejected *filecheck.Error
+	return errors.As(err, &rejected)
+}
+
+// respondUploadError answers a failed upload: validation rejections keep their
+// status and message, anything else is a 500 with the handler's message
+func respondUploadError(c *gin.Context, err error, message string) {
+	var rejected *filecheck.Error
+	if errors.As(err, &rejected) {
+		c.JSON(rejected.Status, gin.H{"error": rejected.Message})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
+
+// resolveStoredObject maps a stored URL to the storage holding it and its key.
+// Files uploaded to local disk before another driver was configured stay readable.
+func resolveStoredObject(url string) (storage.Storage, string, bool) {
+	if url == "" {
+		return nil, "", false
+	}
+	for _, s := range []storage.Storage{config.MediaStorage, config.Storage} {
+		if s == nil {
+			continue
+		}
+		if key, ok := s.KeyFromURL(url); ok {
+			return s, key, true
+		}
+	}
+	if _, isLocal := config.Storage.(*storage.Local); !isLocal {
+		legacy := config.NewLocalStorage()
+		if key, ok := legacy.KeyFromURL(url); ok {
+			return legacy, key, true
+		}
+	}
+	return nil, "", false
+}
+
+// deleteStoredFile removes the object behind a stored URL together with any
+// image variants; unknown URLs are left alone
+func deleteStoredFile(c *gin.Context, url string) {
+	s, key, ok := resolveStoredObject(url)
+	if !ok {
+		return
+	}
+	deleteImageVariants(c.Request.Context(), url)
+	if err := s.Delete(c.Request.Context(), key); err != nil {
+		fmt.Printf("[STORAGE] ❌ Delete %s failed: %v\n", key, err)
+		return
+	}
+	forgetStoredObject(s, key)
+	fmt.Printf("[STORAGE] ✅ Deleted %s\n", key)
+}
+
+// serveStoredObject streams an object to the client, honouring a single Range
+// header, and returns the number of body bytes sent
+func serveStoredObject(c *gin.Context, s storage.Storage, key string) int64 {
+	ctx := c.Request.Context()
+
+	info, err := s.Stat(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File tidak ditemukan"})
+		return 0
+	}
+	if err != nil {
+		fmt.Printf("[STORAGE] ❌ Stat %s failed: %v\n", key, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Gagal membaca file"})
+		return 0
+	}
+
+	rng, err := storage.ParseRange(c.GetHeader("Range"), info.Size)
+	if err != nil {
+		c.Header("Content-Range", fmt.Sprintf("bytes */%d", info.Size))
+		c.Status(http.StatusRequestedRangeNotSatisfiable)
+		return 0
+	}
+
+	body, _, err := s.Get(ctx, key, rng)
+	if err != nil {
+		fmt.Printf("[STORAGE] ❌ Get %s failed: %v\n", key, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Gagal membaca file"})
+		return 0
+	}
+	defer body.Close()
+
+	c.Header("Accept-Ranges", "bytes")
+	c.Header("Content-Type", info.ContentType)
+	if !info.ModTime.IsZero() {
+		c.Header("Last-Modified", info.ModTime.UTC().Format(http.TimeFormat))
+	}
+
+	status := http.StatusOK
+	length := info.Size
+	if rng != nil {
+		status = http.StatusPartialContent
+		length = rng.Length(info.Size)
+		c.Header("Content-Range", fmt.Sprintf("bytes %d-%d/%d", rng.Start, rng.Start+length-1, info.Size))
+	}
+	c.Header("Content-Length", strconv.FormatInt(length, 10))
+	c.Status(status)
+
+	if c.Request.Method == http.MethodHead {
+		return 0
+	}
+	sent, _ := io.Copy(c.Writer, body)
+	return sent
+}
+
+// ServeSignedStorageObject - Serve a local-disk object from a signed URL
+// GET /storage/*key
+func ServeSignedStorageObject(c *gin.Context) {
+	local, ok := config.Storage.(*storage.Local)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File tidak ditemukan"})
+		return
+	}
+
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	exp, _ := strconv.ParseInt(c.Query("exp"), 10, 64)
+	if !local.VerifySignature(key, exp, c.Query("sig")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "URL tidak valid atau sudah kedaluwarsa"})
+		return
+	}
+
+	sent := serveStoredObject(c, local, key)
+	recordBandwidth(storedObjectOrg(local, key), 0, sent)
+}
+
+// copyObjectToMedia copies one object into the private media storage under the
+// same key and books the copy to orgID
+func copyObjectToMedia(ctx context.Context, src storage.Storage, key string, orgID int64) (string, error) {
+	body, info, err := src.Get(ctx, key, nil)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	if err := config.MediaStorage.Put(ctx, key, body, info.Size, info.ContentType); err != nil {
+		return "", err
+	}
+	recordStoredObject(config.MediaStorage, key, orgID, info.Size)
+	return config.MediaStorage.URL(key), nil
+}
+
+// copyToMediaStorage makes sure a stored video or file lives in the private media
+// storage and returns its private URL; the source object is left in place
+func copyToMediaStorage(ctx context.Context, url string, orgID int64) (string, error) {
+	src, key, ok := resolveStoredObject(url)
+	if !ok {
+		return "", fmt.Errorf("lokasi file tidak dikenali: %s", url)
+	}
+	if src == config.MediaStorage {
+		return url, nil
+	}
+	return copyObjectToMedia(ctx, src, key, orgID)
+}
+
+// ===============================================
+// ADMIN: PRIVATE MEDIA MIGRATION
+// ===============================================
+
+// MediaMigrationItem is one session video or file the migration touched
+type MediaMigrationItem struct {
+	Table string `json:"table"`
+	ID    int64  `json:"id"`
+	From  string `json:"from"`
+	To    string `json:"to,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// MediaMigrationReport summarizes a run of MigrateMediaToPrivate
+type MediaMigrationReport struct {
+	DryRun         bool                 `json:"dry_run"`
+	Scanned        int                  `json:"scanned"`
+	Migrated       int                  `json:"migrated"`
+	AlreadyPrivate int                  `json:"already_private"`
+	Failed         int                  `json:"failed"`
+	SourcesDeleted int                  `json:"sources_deleted"`
+	Items          []MediaMigrationItem `json:"items"`
+}
+
+// migrateMediaToPrivate copies every session video and file that is not yet in
+// the private media storage, rewrites its URL and then removes the public copy
+func migrateMediaToPrivate(ctx context.Context, dryRun, keepSource bool) MediaMigrationReport {
+	report := MediaMigrationReport{DryRun: dryRun, Items: []MediaMigrationItem{}}
+
+	type source struct {
+		store storage.Storage
+		key   string
+	}
+	sources := map[string]source{}
+	// URLs still referenced by a row that could not be rewritten
+	failed := map[string]bool{}
+
+	for _, t := range []struct{ table, column string }{
+		{"session_videos", "video_url"},
+		{"session_files", "file_url"},
+	} {
+		var rows []struct {
+			ID  int64  `db:"id"`
+			URL string `db:"url"`
+		}
+		query := fmt.Sprintf("SELECT id, %s AS url FROM %s WHERE %s IS NOT NULL AND %s <> '' ORDER BY id", t.column, t.table, t.column, t.column)
+		if err := config.DB.Select(&rows, query); err != nil {
+			fmt.Printf("[MEDIA-MIGRATION] ❌ Error reading %s: %v\n", t.table, err)
+			continue
+		}
+
+		for _, row := range rows {
+			report.Scanned++
+			item := MediaMigrationItem{Table: t.table, ID: row.ID, From: row.URL}
+
+			src, key, ok := resolveStoredObject(row.URL)
+			if !ok {
+				item.Error = "Lokasi file tidak dikenali"
+				report.Failed++
+				report.Items = append(report.Items, item)
+				continue
+			}
+			if src == config.MediaStorage {
+				report.AlreadyPrivate++
+				continue
+			}
+
+			if dryRun {
+				item.To = config.MediaStorage.URL(key)
+				report.Migrated++
+				report.Items = append(report.Items, item)
+				continue
+			}
+
+			privateURL, err := copyObjectToMedia(ctx, src, key, storedObjectOrg(src, key))
+			if err == nil {
+				query := fmt.Sprintf("UPDATE %s SET %s = ? WHERE id = ?", t.table, t.column)
+				_, err = config.DB.Exec(query, privateURL, row.ID)
+			}
+			if err != nil {
+				fmt.Printf("[MEDIA-MIGRATION] ❌ %s #%d: %v\n", t.table, row.ID, err)
+				item.Error = err.Error()
+				failed[row.URL] = true
+				report.Failed++
+				report.Items = append(report.Items, item)
+				continue
+			}
+
+			item.To = privateURL
+			report.Migrated++
+			report.Items = append(report.Items, item)
+			sources[row.URL] = source{store: src, key: key}
+		}
+	}
+
+	// Public copies are only removed once every row pointing at them was rewritten
+	if !dryRun && !keepSource {
+		for url, s := range sources {
+			if failed[url] {
+				fmt.Printf("[MEDIA-MIGRATION] ⚠️ Keeping public copy %s, a row still points at it\n", s.key)
+				continue
+			}
+			if err := s.store.Delete(ctx, s.key); err != nil {
+				fmt.Printf("[MEDIA-MIGRATION] ❌ Failed to delete public copy %s: %v\n", s.key, err)
+				continue
+			}
+			forgetStoredObject(s.store, s.key)
+			report.SourcesDeleted++
+		}
+	}
+
+	fmt.Printf("[MEDIA-MIGRATION] ✅ scanned=%d migrated=%d already_private=%d failed=%d dry_run=%v\n",
+		report.Scanned, report.Migrated, report.AlreadyPrivate, report.Failed, dryRun)
+	return report
+}
+
+// MigrateMediaToPrivate - Move public session videos and files to the private media storage
+// POST /admin/storage/migrate-private-media
+func MigrateMediaToPrivate(c *gin.Context) {
+	var input struct {
+		DryRun     *bool `json:"dry_run"`
+		KeepSource bool  `json:"keep_source"`
+	}
+	c.ShouldBindJSON(&input)
+
+	// Tanpa dry_run eksplisit hanya laporan yang dibuat
+	dryRun := input.DryRun == nil || *input.DryRun
+
+	report := migrateMediaToPrivate(c.Request.Context(), dryRun, input.KeepSource)
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
@@ -0,0 +1,457 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"BACKEND/config"
+	"BACKEND/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ===============================================
+// STORAGE GARBAGE COLLECTION
+// ===============================================
+
+// Storage GC modes: report only lists orphans, quarantine moves them under
+// storage.QuarantinePrefix, delete removes them
+const (
+	StorageGCModeReport     = "report"
+	StorageGCModeQuarantine = "quarantine"
+	StorageGCModeDelete     = "delete"
+)
+
+// Defaults for the platform settings that tune the collector
+const (
+	defaultStorageGCGraceHours     = 24
+	defaultStorageGCQuarantineDays = 30
+)
+
+// storageGCOrphanLimit caps how many orphans one report lists
+const storageGCOrphanLimit = 500
+
+// storageGCMu keeps the scheduled job, manual admin runs and usage rebuilds
+// from overlapping
+var storageGCMu sync.Mutex
+
+// storageReferenceSources lists every column that stores the URL of an uploaded object
+var storageReferenceSources = []struct {
+	Table  string
+	Column string
+}{
+	{"users", "profile_img"},
+	{"organizations", "logo_url"},
+	{"organization_applications", "org_logo_url"},
+	{"events", "thumbnail_url"},
+	{"session_videos", "video_url"},
+	{"session_videos", "poster_url"},
+	{"session_files", "file_url"},
+	{"reports", "photo_url"},
+	{"ad_banners", "image_url"},
+	{"affiliate_submissions", "poster_url"},
+	{"affiliate_submissions", "video_url"},
+	{"affiliate_submissions", "file_url"},
+	{"affiliate_submission_videos", "url"},
+	{"affiliate_submission_files", "url"},
+	{"image_variants", "url"},
+}
+
+// StorageGCOptions tunes one collector run
+type StorageGCOptions struct {
+	Mode            string
+	GracePeriod     time.Duration // objects younger than this are never collected
+	QuarantineUntil time.Duration // quarantined objects older than this are purged
+}
+
+// StorageGCOrphan is one object nothing in the database points at
+type StorageGCOrphan struct {
+	Storage string    `json:"storage"`
+	Key     string    `json:"key"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modified_at"`
+	Action  string    `json:"action,omitempty"` // QUARANTINED, DELETED or FAILED
+	Error   string    `json:"error,omitempty"`
+}
+
+// StorageGCReport summarises one collector run
+type StorageGCReport struct {
+	RunID             int64             `json:"run_id,omitempty"`
+	Mode              string            `json:"mode"`
+	StartedAt         time.Time         `json:"started_at"`
+	Aborted           string            `json:"aborted,omitempty"`
+	References        int               `json:"references"`
+	Scanned           int               `json:"scanned"`
+	Orphans           int               `json:"orphans"`
+	OrphanBytes       int64             `json:"orphan_bytes"`
+	Quarantined       int               `json:"quarantined"`
+	Deleted           int               `json:"deleted"`
+	ExpiredQuarantine int               `json:"expired_quarantine"`
+	Purged            int               `json:"purged"`
+	Failed            int               `json:"failed"`
+	Unresolved        []string          `json:"unresolved_urls"`
+	Items             []StorageGCOrphan `json:"orphan_items"`
+	Truncated         bool              `json:"orphan_items_truncated"`
+}
+
+// gcStore is one storage the collector lists, with the keys the database still uses in it
+type gcStore struct {
+	name  string
+	store storage.Storage
+	refs  *storage.References
+}
+
+// storageGCStores returns the storages to collect. Local disk is included even
+// when another driver is configured, because files uploaded before the switch
+// may still be there.
+func storageGCStores() []*gcStore {
+	stores := []*gcStore{
+		{name: "public", store: config.Storage, refs: storage.NewReferences()},
+		{name: "private", store: config.MediaStorage, refs: storage.NewReferences()},
+	}
+	if _, isLocal := config.Storage.(*storage.Local); !isLocal {
+		stores = append(stores, &gcStore{name: "legacy", store: config.NewLocalStorage(), refs: storage.NewReferences()})
+	}
+	return stores
+}
+
+// addStorageReference marks a stored URL as in use in every storage that
+// recognises it; it reports false when none does
+func addStorageReference(stores []*gcStore, url string) bool {
+	found := false
+	for _, s := range stores {
+		if key, ok := keyForStoredURL(s.store, url); ok {
+			s.refs.AddKey(key)
+			found = true
+		}
+	}
+	return found
+}
+
+// keyForStoredURL resolves a stored URL to its key in one storage
+func keyForStoredURL(s storage.Storage, url string) (string, bool) {
+	if key, ok := s.KeyFromURL(url); ok {
+		return key, true
+	}
+	// Older rows sometimes hold an absolute URL to the statically served uploads
+	if u, err := neturl.Parse(url); err == nil && u.Host != "" && u.Path != "" {
+		return s.KeyFromURL(strings.TrimPrefix(u.Path, "/"))
+	}
+	return "", false
+}
+
+// collectStorageReferences loads every stored URL into the stores' reference
+// sets. Any failed query aborts the run: a missing reference would make a live
+// object look orphaned.
+func collectStorageReferences(stores []*gcStore, report *StorageGCReport) error {
+	for _, src := range storageReferenceSources {
+		var urls []string
+		query := fmt.Sprintf("SELECT DISTINCT %[2]s FROM %[1]s WHERE %[2]s IS NOT NULL AND %[2]s <> ''", src.Table, src.Column)
+		if err := config.DB.Select(&urls, query); err != nil {
+			return fmt.Errorf("read %s.%s: %v", src.Table, src.Column, err)
+		}
+		for _, url := range urls {
+			if !addStorageReference(stores, url) && len(report.Unresolved) < storageGCOrphanLimit {
+				report.Unresolved = append(report.Unresolved, url)
+			}
+		}
+	}
+
+	// HLS renditions are referenced by folder, not by URL
+	var prefixes []string
+	if err := config.DB.Select(&prefixes, "SELECT DISTINCT hls_prefix FROM session_videos WHERE hls_prefix IS NOT NULL AND hls_prefix <> ''"); err != nil {
+		return fmt.Errorf("read session_videos.hls_prefix: %v", err)
+	}
+	for _, s := range stores {
+		if s.store == config.MediaStorage {
+			for _, prefix := range prefixes {
+				s.refs.AddPrefix(prefix)
+			}
+		}
+	}
+
+	for _, s := range stores {
+		report.References += s.refs.Len()
+	}
+	return nil
+}
+
+// quarantineObject moves an orphan aside so it can still be restored
+func quarantineObject(ctx context.Context, s storage.Storage, key string, now time.Time) error {
+	body, info, err := s.Get(ctx, key, nil)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	if err := s.Put(ctx, storage.QuarantineKey(key, now), body, info.Size, info.ContentType); err != nil {
+		return err
+	}
+	return s.Delete(ctx, key)
+}
+
+// CollectOrphanedStorage lists every stored object, compares it against the URL
+// columns in the database and reports, quarantines or deletes the orphans.
+// Quarantined objects past their retention are purged in the non-report modes.
+func CollectOrphanedStorage(ctx context.Context, opts StorageGCOptions) StorageGCReport {
+	report := StorageGCReport{
+		Mode:       opts.Mode,
+		StartedAt:  time.Now(),
+		Unresolved: []string{},
+		Items:      []StorageGCOrphan{},
+	}
+
+	stores := storageGCStores()
+	if err := collectStorageReferences(stores, &report); err != nil {
+		report.Aborted = err.Error()
+		fmt.Printf("[STORAGE-GC] ❌ Aborted, nothing was collected: %v\n", err)
+		logStorageGCRun(&report)
+		return report
+	}
+
+	cutoff := report.StartedAt.Add(-opts.GracePeriod)
+	purgeBefore := report.StartedAt.Add(-opts.QuarantineUntil)
+
+	for _, s := range stores {
+		// Orphans are collected first so the listing never sees its own quarantine copies
+		var orphans, expired []storage.ObjectInfo
+		err := s.store.List(ctx, "", func(obj storage.ObjectInfo) error {
+			report.Scanned++
+			if day, _, ok := storage.QuarantinedAt(obj.Key); ok {
+				if day.Before(purgeBefore) {
+					expired = append(expired, obj)
+				}
+				return nil
+			}
+			if storage.IsOrphan(obj, s.refs, cutoff) {
+				orphans = append(orphans, obj)
+			}
+			return nil
+		})
+		if err != nil {
+			// A partial listing is still safe to act on: only listed objects are touched
+			fmt.Printf("[STORAGE-GC] ❌ Listing %s storage failed: %v\n", s.name, err)
+			report.Failed++
+		}
+
+		for _, obj := range orphans {
+			report.Orphans++
+			report.OrphanBytes += obj.Size
+			item := StorageGCOrphan{Storage: s.name, Key: obj.Key, Size: obj.Size, ModTime: obj.ModTime}
+
+			var actErr error
+			switch opts.Mode {
+			case StorageGCModeQuarantine:
+				if actErr = quarantineObject(ctx, s.store, obj.Key, report.StartedAt); actErr == nil {
+					forgetStoredObject(s.store, obj.Key)
+					item.Action = "QUARANTINED"
+					report.Quarantined++
+				}
+			case StorageGCModeDelete:
+				if actErr = s.store.Delete(ctx, obj.Key); actErr == nil {
+					forgetStoredObject(s.store, obj.Key)
+					item.Action = "DELETED"
+					report.Deleted++
+				}
+			}
+			if actErr != nil {
+				fmt.Printf("[STORAGE-GC] ❌ %s %s: %v\n", opts.Mode, obj.Key, actErr)
+				item.Action = "FAILED"
+				item.Error = actErr.Error()
+				report.Failed++
+			}
+
+			if len(report.Items) < storageGCOrphanLimit {
+				report.Items = append(report.Items, item)
+			} else {
+				report.Truncated = true
+			}
+		}
+
+		report.ExpiredQuarantine += len(expired)
+		if opts.Mode == StorageGCModeReport {
+			continue
+		}
+		for _, obj := range expired {
+			if err := s.store.Delete(ctx, obj.Key); err != nil {
+				fmt.Printf("[STORAGE-GC] ❌ Purge %s: %v\n", obj.Key, err)
+				report.Failed++
+				continue
+			}
+			report.Purged++
+		}
+	}
+
+	fmt.Printf("[STORAGE-GC] ✅ mode=%s scanned=%d orphans=%d (%d bytes) quarantined=%d deleted=%d purged=%d failed=%d\n",
+		report.Mode, report.Scanned, report.Orphans, report.OrphanBytes, report.Quarantined, report.Deleted, report.Purged, report.Failed)
+	logStorageGCRun(&report)
+	return report
+}
+
+// logStorageGCRun stores a run summary for the admin history
+func logStorageGCRun(report *StorageGCReport) {
+	var aborted *string
+	if report.Aborted != "" {
+		aborted = &report.Aborted
+	}
+	res, err := config.DB.Exec(`
+		INSERT INTO storage_gc_runs (mode, scanned, orphans, orphan_bytes, quarantined, deleted, purged, failed, aborted_reason, started_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, report.Mode, report.Scanned, report.Orphans, report.OrphanBytes, report.Quarantined, report.Deleted,
+		report.Purged, report.Failed, aborted, report.StartedAt)
+	if err != nil {
+		fmt.Printf("[STORAGE-GC] ❌ Failed to log run: %v\n", err)
+		return
+	}
+	report.RunID, _ = res.LastInsertId()
+}
+
+// storageGCSettings reads the collector defaults from platform settings
+func storageGCSettings() StorageGCOptions {
+	opts := StorageGCOptions{Mode: getPlatformSetting("storage_gc_mode", StorageGCModeReport)}
+	hours, err := strconv.Atoi(getPlatformSetting("storage_gc_grace_hours", strconv.Itoa(defaultStorageGCGraceHours)))
+	if err != nil || hours < 1 {
+		hours = defaultStorageGCGraceHours
+	}
+	days, err := strconv.Atoi(getPlatformSetting("storage_gc_quarantine_days", strconv.Itoa(defaultStorageGCQuarantineDays)))
+	if err != nil || days < 1 {
+		days = defaultStorageGCQuarantineDays
+	}
+	opts.GracePeriod = time.Duration(hours) * time.Hour
+	opts.QuarantineUntil = time.Duration(days) * 24 * time.Hour
+	return opts
+}
+
+func validStorageGCMode(mode string) bool {
+	return mode == StorageGCModeReport || mode == StorageGCModeQuarantine || mode == StorageGCModeDelete
+}
+
+// RunScheduledStorageGC runs the collector in the mode set in platform settings
+func RunScheduledStorageGC() {
+	if !storageGCMu.TryLock() {
+		return
+	}
+	defer storageGCMu.Unlock()
+
+	opts := storageGCSettings()
+	if !validStorageGCMode(opts.Mode) {
+		fmt.Printf("[STORAGE-GC] ⚠️ Unknown storage_gc_mode %q, only reporting\n", opts.Mode)
+		opts.Mode = StorageGCModeReport
+	}
+	CollectOrphanedStorage(context.Background(), opts)
+}
+
+// ===============================================
+// REPLACED UPLOADS
+// ===============================================
+
+// storedURLInUse reports whether any URL column still points at url; on a
+// failed query it assumes the object is in use
+func storedURLInUse(url string) bool {
+	for _, src := range storageReferenceSources {
+		var count int
+		query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s = ?", src.Table, src.Column)
+		if err := config.DB.Get(&count, query, url); err != nil || count > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// replaceStoredFile removes the object a row pointed at before a new upload
+// replaced it, unless another row still uses it
+func replaceStoredFile(c *gin.Context, oldURL, newURL string) {
+	if oldURL == "" || oldURL == newURL || storedURLInUse(oldURL) {
+		return
+	}
+	deleteStoredFile(c, oldURL)
+}
+
+// ===============================================
+// ADMIN: STORAGE GC
+// ===============================================
+
+// RunStorageGC - Find orphaned storage objects and report, quarantine or delete them
+// POST /admin/storage/gc
+func RunStorageGC(c *gin.Context) {
+	var input struct {
+		Mode           string `json:"mode"`
+		GraceHours     int    `json:"grace_hours"`
+		QuarantineDays int    `json:"quarantine_days"`
+	}
+	c.ShouldBindJSON(&input)
+
+	opts := storageGCSettings()
+	// Tanpa mode eksplisit hanya laporan yang dibuat
+	opts.Mode = StorageGCModeReport
+	if input.Mode != "" {
+		opts.Mode = input.Mode
+	}
+	if !validStorageGCMode(opts.Mode) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Mode harus report, quarantine atau delete"})
+		return
+	}
+	if input.GraceHours > 0 {
+		opts.GracePeriod = time.Duration(input.GraceHours) * time.Hour
+	}
+	if input.QuarantineDays > 0 {
+		opts.QuarantineUntil = time.Duration(input.QuarantineDays) * 24 * time.Hour
+	}
+
+	if !storageGCMu.TryLock() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Pembersihan storage sedang berjalan"})
+		return
+	}
+	defer storageGCMu.Unlock()
+
+	report := CollectOrphanedStorage(c.Request.Context(), opts)
+	if report.Aborted != "" {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Pembersihan dibatalkan, referensi file tidak bisa dibaca", "report": report})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Pembersihan storage selesai", "report": report})
+}
+
+// GetStorageGCRuns - List recent storage GC runs
+// GET /admin/storage/gc-runs
+func GetStorageGCRuns(c *gin.Context) {
+	type RunRow struct {
+		ID            int64   `db:"id" json:"id"`
+		Mode          string  `db:"mode" json:"mode"`
+		Scanned       int     `db:"scanned" json:"scanned"`
+		Orphans       int     `db:"orphans" json:"orphans"`
+		OrphanBytes   int64   `db:"orphan_bytes" json:"orphan_bytes"`
+		Quarantined   int     `db:"quarantined" json:"quarantined"`
+		Deleted       int     `db:"deleted" json:"deleted"`
+		Purged        int     `db:"purged" json:"purged"`
+		Failed        int     `db:"failed" json:"failed"`
+		AbortedReason *string `db:"aborted_reason" json:"aborted_reason"`
+		StartedAt     string  `db:"started_at" json:"started_at"`
+		FinishedAt    string  `db:"finished_at" json:"finished_at"`
+	}
+
+	runs := []RunRow{}
+	if err := config.DB.Select(&runs, `
+		SELECT id, mode, scanned, orphans, orphan_bytes, quarantined, deleted, purged, failed,
+			aborted_reason, started_at, finished_at
+		FROM storage_gc_runs
+		ORDER BY started_at DESC, id DESC LIMIT 100
+	`); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil riwayat pembersihan storage"})
+		return
+	}
+
+	opts := storageGCSettings()
+	c.JSON(http.StatusOK, gin.H{
+		"runs":            runs,
+		"scheduled_mode":  opts.Mode,
+		"grace_hours":     int(opts.GracePeriod.Hours()),
+		"quarantine_days": int(opts.QuarantineUntil.Hours() / 24),
+	})
+}
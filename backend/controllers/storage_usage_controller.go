@@ -0,0 +1,636 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"BACKEND/config"
+	"BACKEND/filecheck"
+	"BACKEND/helpers"
+	"BACKEND/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// ===============================================
+// STORAGE & BANDWIDTH ACCOUNTING
+// ===============================================
+
+// storageName identifies a storage in storage_objects
+func storageName(s storage.Storage) string {
+	switch s {
+	case config.MediaStorage:
+		return "private"
+	case config.Storage:
+		return "public"
+	}
+	return "legacy"
+}
+
+// recordStoredObject books a stored object to an organization (0 = platform content)
+func recordStoredObject(s storage.Storage, key string, orgID, size int64) {
+	var org *int64
+	if orgID > 0 {
+		org = &orgID
+	}
+	_, err := config.DB.Exec(`
+		INSERT INTO storage_objects (storage, object_key, organization_id, size_bytes)
+		VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE organization_id = VALUES(organization_id), size_bytes = VALUES(size_bytes)
+	`, storageName(s), key, org, size)
+	if err != nil {
+		fmt.Printf("[USAGE] ❌ Failed to record %s: %v\n", key, err)
+	}
+}
+
+// forgetStoredObject removes a deleted object from the accounting
+func forgetStoredObject(s storage.Storage, key string) {
+	config.DB.Exec("DELETE FROM storage_objects WHERE storage = ? AND object_key = ?", storageName(s), key)
+}
+
+// storedObjectOrg is the organization a stored object is billed to, 0 if none
+func storedObjectOrg(s storage.Storage, key string) int64 {
+	var orgID int64
+	config.DB.Get(&orgID, "SELECT COALESCE(organization_id, 0) FROM storage_objects WHERE storage = ? AND object_key = ?", storageName(s), key)
+	return orgID
+}
+
+// sessionOrgCache remembers which organization a session belongs to; streaming
+// looks it up for every HLS segment
+var sessionOrgCache sync.Map
+
+// sessionOrganization returns the organization that owns a session, 0 if unknown
+func sessionOrganization(sessionID int64) int64 {
+	if orgID, ok := sessionOrgCache.Load(sessionID); ok {
+		return orgID.(int64)
+	}
+	var orgID int64
+	if err := config.DB.Get(&orgID, "SELECT e.organization_id FROM sessions s JOIN events e ON e.id = s.event_id WHERE s.id = ?", sessionID); err != nil {
+		return 0
+	}
+	sessionOrgCache.Store(sessionID, orgID)
+	return orgID
+}
+
+// recordBandwidth adds bytes streamed for a session to its organization's daily total
+func recordBandwidth(orgID, sessionID, bytes int64) {
+	if orgID <= 0 || bytes <= 0 {
+		return
+	}
+	_, err := config.DB.Exec(`
+		INSERT INTO bandwidth_usage (organization_id, session_id, usage_date, bytes_sent, requests)
+		VALUES (?, ?, CURDATE(), ?, 1)
+		ON DUPLICATE KEY UPDATE bytes_sent = bytes_sent + VALUES(bytes_sent), requests = requests + 1
+	`, orgID, sessionID, bytes)
+	if err != nil {
+		fmt.Printf("[USAGE] ❌ Failed to record bandwidth for org %d: %v\n", orgID, err)
+	}
+}
+
+// orgStoredBytes is the size of every object billed to an organization. Until the
+// accounting has been built for it, the sizes recorded on its session videos and files are used.
+func orgStoredBytes(q sqlx.Queryer, orgID int64) int64 {
+	var objects struct {
+		Count int   `db:"objects"`
+		Bytes int64 `db:"bytes"`
+	}
+	sqlx.Get(q, &objects, "SELECT COUNT(*) AS objects, COALESCE(SUM(size_bytes), 0) AS bytes FROM storage_objects WHERE organization_id = ?", orgID)
+	if objects.Count > 0 {
+		return objects.Bytes
+	}
+
+	var used int64
+	sqlx.Get(q, &used, `
+		SELECT
+			(SELECT COALESCE(SUM(v.size_bytes), 0) FROM session_videos v
+				JOIN sessions s ON s.id = v.session_id JOIN events e ON e.id = s.event_id WHERE e.organization_id = ?)
+			+ (SELECT COALESCE(SUM(f.size_bytes), 0) FROM session_files f
+				JOIN sessions s ON s.id = f.session_id JOIN events e ON e.id = s.event_id WHERE e.organization_id = ?)
+	`, orgID, orgID)
+	return used
+}
+
+// orgReservedBytes is what an organization's unfinished resumable uploads reserve
+func orgReservedBytes(q sqlx.Queryer, orgID int64) int64 {
+	var reserved int64
+	sqlx.Get(q, &reserved, "SELECT COALESCE(SUM(size_bytes), 0) FROM resumable_uploads WHERE organization_id = ? AND status = 'UPLOADING'", orgID)
+	return reserved
+}
+
+// lockOrgStorageUsage is what an organization stores plus what its unfinished uploads
+// reserve, with the organization row locked until tx ends, so two uploads can't both
+// reserve the same free space
+func lockOrgStorageUsage(tx *sqlx.Tx, orgID int64) (int64, error) {
+	var id int64
+	if err := tx.Get(&id, "SELECT id FROM organizations WHERE id = ? FOR UPDATE", orgID); err != nil {
+		return 0, err
+	}
+	return orgStoredBytes(tx, orgID) + orgReservedBytes(tx, orgID), nil
+}
+
+// orgBandwidthUsage is what an organization streamed in [from, to)
+func orgBandwidthUsage(orgID int64, from, to time.Time) int64 {
+	var used int64
+	config.DB.Get(&used, `
+		SELECT COALESCE(SUM(bytes_sent), 0) FROM bandwidth_usage
+		WHERE organization_id = ? AND usage_date >= ? AND usage_date < ?
+	`, orgID, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	return used
+}
+
+// StoragePlan is a hosting tier with its quotas in bytes (0 = unlimited)
+type StoragePlan struct {
+	ID                  int64  `db:"id" json:"id"`
+	Code                string `db:"code" json:"code"`
+	Name                string `db:"name" json:"name"`
+	StorageQuotaBytes   int64  `db:"storage_quota_bytes" json:"storage_quota_bytes"`
+	BandwidthQuotaBytes int64  `db:"bandwidth_quota_bytes" json:"bandwidth_quota_bytes"`
+}
+
+// orgQuotaRow is an organization's plan and its own quota overrides
+type orgQuotaRow struct {
+	StorageOverride   *int64  `db:"storage_quota_bytes"`
+	BandwidthOverride *int64  `db:"bandwidth_quota_bytes"`
+	PlanID            *int64  `db:"plan_id"`
+	PlanCode          *string `db:"plan_code"`
+	PlanName          *string `db:"plan_name"`
+	PlanStorage       *int64  `db:"plan_storage_quota_bytes"`
+	PlanBandwidth     *int64  `db:"plan_bandwidth_quota_bytes"`
+}
+
+func loadOrgQuota(orgID int64) orgQuotaRow {
+	var row orgQuotaRow
+	config.DB.Get(&row, `
+		SELECT o.storage_quota_bytes, o.bandwidth_quota_bytes, p.id AS plan_id, p.code AS plan_code, p.name AS plan_name,
+			p.storage_quota_bytes AS plan_storage_quota_bytes, p.bandwidth_quota_bytes AS plan_bandwidth_quota_bytes
+		FROM organizations o
+		LEFT JOIN storage_plans p ON p.id = o.storage_plan_id
+		WHERE o.id = ?
+	`, orgID)
+	return row
+}
+
+// plan returns the organization's storage plan, nil when it has none
+func (q orgQuotaRow) plan() *StoragePlan {
+	if q.PlanID == nil {
+		return nil
+	}
+	return &StoragePlan{ID: *q.PlanID, Code: *q.PlanCode, Name: *q.PlanName, StorageQuotaBytes: *q.PlanStorage, BandwidthQuotaBytes: *q.PlanBandwidth}
+}
+
+// platformQuota reads a byte quota from platform settings
+func platformQuota(key string, def int64) int64 {
+	value, err := strconv.ParseInt(getPlatformSetting(key, strconv.FormatInt(def, 10)), 10, 64)
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// resolveQuota picks the organization's own quota, then its plan's, then the platform default
+func resolveQuota(override, plan *int64, settingKey string, def int64) int64 {
+	if override != nil {
+		return *override
+	}
+	if plan != nil {
+		return *plan
+	}
+	return platformQuota(settingKey, def)
+}
+
+// orgStorageQuota is the organization's storage quota in bytes
+func orgStorageQuota(orgID int64) int64 {
+	q := loadOrgQuota(orgID)
+	return resolveQuota(q.StorageOverride, q.PlanStorage, "storage_quota_bytes", helpers.DefaultStorageQuotaBytes)
+}
+
+// orgBandwidthQuota is the organization's monthly streaming quota in bytes
+func orgBandwidthQuota(orgID int64) int64 {
+	q := loadOrgQuota(orgID)
+	return resolveQuota(q.BandwidthOverride, q.PlanBandwidth, "bandwidth_quota_bytes", helpers.DefaultBandwidthQuotaBytes)
+}
+
+// orgOverBandwidth reports whether an organization streamed past this month's quota
+func orgOverBandwidth(orgID int64) bool {
+	if orgID <= 0 {
+		return false
+	}
+	quota := orgBandwidthQuota(orgID)
+	if quota <= 0 {
+		return false
+	}
+	monthStart, monthEnd := helpers.UsageMonth(time.Now())
+	return helpers.OverQuota(orgBandwidthUsage(orgID, monthStart, monthEnd), quota)
+}
+
+// respondBandwidthQuota refuses new stream URLs of an organization over its bandwidth quota
+func respondBandwidthQuota(c *gin.Context) {
+	c.JSON(http.StatusForbidden, gin.H{
+		"error": "Kuota bandwidth penyelenggara untuk bulan ini sudah habis, silakan hubungi penyelenggara",
+		"code":  "bandwidth_quota_exceeded",
+	})
+}
+
+// checkStorageQuota refuses an upload that does not fit in the organization's quota.
+// The organization stays locked until tx ends, so the upload is recorded before
+// another one is checked.
+func checkStorageQuota(tx *sqlx.Tx, orgID, size int64) error {
+	used, err := lockOrgStorageUsage(tx, orgID)
+	if err != nil {
+		return err
+	}
+	quota := orgStorageQuota(orgID)
+	if helpers.QuotaAllows(used, size, quota) {
+		return nil
+	}
+	return &filecheck.Error{
+		Status:  http.StatusRequestEntityTooLarge,
+		Message: fmt.Sprintf("Kuota penyimpanan tidak cukup (terpakai %s dari %s)", helpers.FormatBytes(used), helpers.FormatBytes(quota)),
+	}
+}
+
+// ===============================================
+// USAGE REBUILD
+// ===============================================
+
+// orgObjectSources map stored URLs to the organization they are billed to
+var orgObjectSources = []string{
+	`SELECT id AS organization_id, logo_url AS url FROM organizations WHERE logo_url IS NOT NULL AND logo_url <> ''`,
+	`SELECT organization_id, thumbnail_url AS url FROM events WHERE thumbnail_url IS NOT NULL AND thumbnail_url <> ''`,
+	`SELECT e.organization_id, v.video_url AS url FROM session_videos v
+		JOIN sessions s ON s.id = v.session_id JOIN events e ON e.id = s.event_id`,
+	`SELECT e.organization_id, v.poster_url AS url FROM session_videos v
+		JOIN sessions s ON s.id = v.session_id JOIN events e ON e.id = s.event_id
+		WHERE v.poster_url IS NOT NULL AND v.poster_url <> ''`,
+	`SELECT e.organization_id, f.file_url AS url FROM session_files f
+		JOIN sessions s ON s.id = f.session_id JOIN events e ON e.id = s.event_id`,
+}
+
+// StorageUsageRebuildReport summarises a rebuild of the object accounting
+type StorageUsageRebuildReport struct {
+	Objects     int      `json:"objects"`
+	Bytes       int64    `json:"bytes"`
+	Attributed  int      `json:"attributed"`
+	SizesFilled int      `json:"sizes_filled"`
+	Failed      []string `json:"failed"`
+}
+
+// orgRef is a stored URL or HLS folder and the organization it belongs to
+type orgRef struct {
+	OrganizationID int64  `db:"organization_id"`
+	URL            string `db:"url"`
+}
+
+// rebuildStorageUsage lists every storage and rewrites storage_objects from it,
+// attributing objects to organizations through the rows that reference them.
+// It also fills session_videos/session_files.size_bytes where it is missing.
+func rebuildStorageUsage(ctx context.Context) StorageUsageRebuildReport {
+	report := StorageUsageRebuildReport{Failed: []string{}}
+
+	urlOrg := map[string]int64{}
+	for _, query := range orgObjectSources {
+		var refs []orgRef
+		if err := config.DB.Select(&refs, query); err != nil {
+			report.Failed = append(report.Failed, err.Error())
+			continue
+		}
+		for _, r := range refs {
+			urlOrg[r.URL] = r.OrganizationID
+		}
+	}
+	// Variants belong to whoever owns their original
+	var variants []struct {
+		SourceURL string `db:"source_url"`
+		URL       string `db:"url"`
+	}
+	config.DB.Select(&variants, "SELECT source_url, url FROM image_variants")
+	for _, v := range variants {
+		if orgID, ok := urlOrg[v.SourceURL]; ok {
+			urlOrg[v.URL] = orgID
+		}
+	}
+	var hlsRefs []orgRef
+	config.DB.Select(&hlsRefs, `
+		SELECT e.organization_id, v.hls_prefix AS url FROM session_videos v
+		JOIN sessions s ON s.id = v.session_id JOIN events e ON e.id = s.event_id
+		WHERE v.hls_prefix IS NOT NULL AND v.hls_prefix <> ''
+	`)
+
+	sizes := map[string]map[string]int64{}
+	for _, gs := range storageGCStores() {
+		keyOrg := map[string]int64{}
+		for url, orgID := range urlOrg {
+			if key, ok := keyForStoredURL(gs.store, url); ok {
+				keyOrg[key] = orgID
+			}
+		}
+
+		var objects []storage.ObjectInfo
+		err := gs.store.List(ctx, "", func(obj storage.ObjectInfo) error {
+			if !storage.IsQuarantined(obj.Key) {
+				objects = append(objects, obj)
+			}
+			return nil
+		})
+		if err != nil {
+			// Keep the old accounting of a storage that could not be listed
+			fmt.Printf("[USAGE] ❌ Listing %s storage failed: %v\n", gs.name, err)
+			report.Failed = append(report.Failed, gs.name+": "+err.Error())
+			continue
+		}
+
+		config.DB.Exec("DELETE FROM storage_objects WHERE storage = ?", storageName(gs.store))
+		sizes[gs.name] = map[string]int64{}
+		for _, obj := range objects {
+			orgID, ok := keyOrg[obj.Key]
+			if !ok && gs.store == config.MediaStorage {
+				for _, r := range hlsRefs {
+					if strings.HasPrefix(obj.Key, r.URL+"/") {
+						orgID, ok = r.OrganizationID, true
+						break
+					}
+				}
+			}
+			recordStoredObject(gs.store, obj.Key, orgID, obj.Size)
+			sizes[gs.name][obj.Key] = obj.Size
+			report.Objects++
+			report.Bytes += obj.Size
+			if ok {
+				report.Attributed++
+			}
+		}
+	}
+
+	// Rows uploaded before sizes were recorded
+	for _, t := range []struct{ table, column string }{
+		{"session_videos", "video_url"},
+		{"session_files", "file_url"},
+	} {
+		var rows []struct {
+			ID  int64  `db:"id"`
+			URL string `db:"url"`
+		}
+		config.DB.Select(&rows, fmt.Sprintf("SELECT id, %s AS url FROM %s WHERE COALESCE(size_bytes, 0) = 0", t.column, t.table))
+		for _, row := range rows {
+			for _, gs := range storageGCStores() {
+				key, ok := keyForStoredURL(gs.store, row.URL)
+				if !ok {
+					continue
+				}
+				if size, found := sizes[gs.name][key]; found {
+					config.DB.Exec(fmt.Sprintf("UPDATE %s SET size_bytes = ? WHERE id = ?", t.table), size, row.ID)
+					report.SizesFilled++
+					break
+				}
+			}
+		}
+	}
+
+	fmt.Printf("[USAGE] ✅ Rebuilt storage accounting: %d objects (%d bytes), %d attributed, %d sizes filled\n",
+		report.Objects, report.Bytes, report.Attributed, report.SizesFilled)
+	return report
+}
+
+// BackfillStorageUsage builds the object accounting once when nothing has been
+// recorded yet, so organizations that existed before it was deployed see their usage
+func BackfillStorageUsage() {
+	var objects int
+	if err := config.DB.Get(&objects, "SELECT COUNT(*) FROM storage_objects"); err != nil || objects > 0 {
+		return
+	}
+	fmt.Println("[USAGE] ⚠️ No storage accounting yet, rebuilding from storage")
+	rebuildStorageUsage(context.Background())
+}
+
+// ===============================================
+// ORGANIZATION: USAGE DASHBOARD
+// ===============================================
+
+// GetOrganizationStorageUsage - Storage and bandwidth usage against the organization's quotas
+// GET /organization/storage-usage
+func GetOrganizationStorageUsage(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	orgID, err := getOrganizationIDByUser(userID)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Akses ditolak"})
+		return
+	}
+
+	q := loadOrgQuota(orgID)
+	stored, reserved := orgStoredBytes(config.DB, orgID), orgReservedBytes(config.DB, orgID)
+	storageQuota := orgStorageQuota(orgID)
+
+	type CategoryRow struct {
+		Category string `db:"category" json:"category"`
+		Objects  int    `db:"objects" json:"objects"`
+		Bytes    int64  `db:"bytes" json:"bytes"`
+	}
+	var keys []struct {
+		Key  string `db:"object_key"`
+		Size int64  `db:"size_bytes"`
+	}
+	config.DB.Select(&keys, "SELECT object_key, size_bytes FROM storage_objects WHERE organization_id = ?", orgID)
+	byCategory := map[string]*CategoryRow{}
+	categories := []*CategoryRow{}
+	for _, k := range keys {
+		name := helpers.StorageCategory(k.Key)
+		row, ok := byCategory[name]
+		if !ok {
+			row = &CategoryRow{Category: name}
+			byCategory[name] = row
+			categories = append(categories, row)
+		}
+		row.Objects++
+		row.Bytes += k.Size
+	}
+
+	monthStart, monthEnd := helpers.UsageMonth(time.Now())
+	bandwidth := orgBandwidthUsage(orgID, monthStart, monthEnd)
+	bandwidthQuota := orgBandwidthQuota(orgID)
+
+	type DailyRow struct {
+		Date     string `db:"usage_date" json:"date"`
+		Bytes    int64  `db:"bytes" json:"bytes"`
+		Requests int    `db:"requests" json:"requests"`
+	}
+	daily := []DailyRow{}
+	config.DB.Select(&daily, `
+		SELECT DATE_FORMAT(usage_date, '%Y-%m-%d') AS usage_date, SUM(bytes_sent) AS bytes, SUM(requests) AS requests
+		FROM bandwidth_usage
+		WHERE organization_id = ? AND usage_date >= ? AND usage_date < ?
+		GROUP BY usage_date ORDER BY usage_date
+	`, orgID, monthStart.Format("2006-01-02"), monthEnd.Format("2006-01-02"))
+
+	type SessionRow struct {
+		SessionID int64   `db:"session_id" json:"session_id"`
+		Title     *string `db:"title" json:"title"`
+		Bytes     int64   `db:"bytes" json:"bytes"`
+		Requests  int     `db:"requests" json:"requests"`
+	}
+	topSessions := []SessionRow{}
+	config.DB.Select(&topSessions, `
+		SELECT b.session_id, s.title, SUM(b.bytes_sent) AS bytes, SUM(b.requests) AS requests
+		FROM bandwidth_usage b
+		LEFT JOIN sessions s ON s.id = b.session_id
+		WHERE b.organization_id = ? AND b.usage_date >= ? AND b.usage_date < ?
+		GROUP BY b.session_id, s.title
+		ORDER BY bytes DESC LIMIT 10
+	`, orgID, monthStart.Format("2006-01-02"), monthEnd.Format("2006-01-02"))
+
+	c.JSON(http.StatusOK, gin.H{
+		"plan": q.plan(),
+		"storage": gin.H{
+			"used_bytes":     stored,
+			"reserved_bytes": reserved,
+			"quota_bytes":    storageQuota,
+			"percent":        helpers.UsagePercent(stored+reserved, storageQuota),
+			"over_quota":     helpers.OverQuota(stored+reserved, storageQuota),
+			"used_label":     helpers.FormatBytes(stored + reserved),
+			"quota_label":    helpers.FormatBytes(storageQuota),
+			"by_category":    categories,
+		},
+		"bandwidth": gin.H{
+			"month":        monthStart.Format("2006-01"),
+			"used_bytes":   bandwidth,
+			"quota_bytes":  bandwidthQuota,
+			"percent":      helpers.UsagePercent(bandwidth, bandwidthQuota),
+			"over_quota":   helpers.OverQuota(bandwidth, bandwidthQuota),
+			"used_label":   helpers.FormatBytes(bandwidth),
+			"quota_label":  helpers.FormatBytes(bandwidthQuota),
+			"daily":        daily,
+			"top_sessions": topSessions,
+		},
+	})
+}
+
+// ===============================================
+// ADMIN: USAGE & PLANS
+// ===============================================
+
+// AdminGetStorageUsage - Storage and this month's bandwidth of every organization, heaviest first
+// GET /admin/storage/usage
+func AdminGetStorageUsage(c *gin.Context) {
+	monthStart, monthEnd := helpers.UsageMonth(time.Now())
+
+	type OrgUsage struct {
+		OrganizationID int64   `db:"id" json:"organization_id"`
+		Name           string  `db:"name" json:"name"`
+		PlanCode       *string `db:"plan_code" json:"plan_code"`
+		StorageBytes   int64   `db:"storage_bytes" json:"storage_bytes"`
+		BandwidthBytes int64   `db:"bandwidth_bytes" json:"bandwidth_bytes"`
+		StorageQuota   int64   `db:"-" json:"storage_quota_bytes"`
+		BandwidthQuota int64   `db:"-" json:"bandwidth_quota_bytes"`
+		StoragePct     float64 `db:"-" json:"storage_percent"`
+		BandwidthPct   float64 `db:"-" json:"bandwidth_percent"`
+		OverQuota      bool    `db:"-" json:"over_quota"`
+	}
+	orgs := []OrgUsage{}
+	err := config.DB.Select(&orgs, `
+		SELECT o.id, o.name, p.code AS plan_code,
+			COALESCE((SELECT SUM(so.size_bytes) FROM storage_objects so WHERE so.organization_id = o.id), 0) AS storage_bytes,
+			COALESCE((SELECT SUM(b.bytes_sent) FROM bandwidth_usage b
+				WHERE b.organization_id = o.id AND b.usage_date >= ? AND b.usage_date < ?), 0) AS bandwidth_bytes
+		FROM organizations o
+		LEFT JOIN storage_plans p ON p.id = o.storage_plan_id
+		ORDER BY storage_bytes DESC, bandwidth_bytes DESC
+	`, monthStart.Format("2006-01-02"), monthEnd.Format("2006-01-02"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil pemakaian storage"})
+		return
+	}
+	for i := range orgs {
+		o := &orgs[i]
+		o.StorageQuota = orgStorageQuota(o.OrganizationID)
+		o.BandwidthQuota = orgBandwidthQuota(o.OrganizationID)
+		o.StoragePct = helpers.UsagePercent(o.StorageBytes, o.StorageQuota)
+		o.BandwidthPct = helpers.UsagePercent(o.BandwidthBytes, o.BandwidthQuota)
+		o.OverQuota = helpers.OverQuota(o.StorageBytes, o.StorageQuota) || helpers.OverQuota(o.BandwidthBytes, o.BandwidthQuota)
+	}
+
+	plans := []StoragePlan{}
+	config.DB.Select(&plans, "SELECT id, code, name, storage_quota_bytes, bandwidth_quota_bytes FROM storage_plans ORDER BY storage_quota_bytes")
+
+	var unattributed int64
+	config.DB.Get(&unattributed, "SELECT COALESCE(SUM(size_bytes), 0) FROM storage_objects WHERE organization_id IS NULL")
+
+	c.JSON(http.StatusOK, gin.H{
+		"month":             monthStart.Format("2006-01"),
+		"organizations":     orgs,
+		"plans":             plans,
+		"platform_bytes":    unattributed,
+		"default_quota":     platformQuota("storage_quota_bytes", helpers.DefaultStorageQuotaBytes),
+		"default_bandwidth": platformQuota("bandwidth_quota_bytes", helpers.DefaultBandwidthQuotaBytes),
+	})
+}
+
+// UpdateOrganizationStoragePlan - Set an organization's storage plan and quota overrides
+// PUT /admin/organizations/:id/storage-plan
+func UpdateOrganizationStoragePlan(c *gin.Context) {
+	orgID, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	var input struct {
+		PlanCode            *string `json:"plan_code"`             // null = platform default
+		StorageQuotaBytes   *int64  `json:"storage_quota_bytes"`   // null = from the plan
+		BandwidthQuotaBytes *int64  `json:"bandwidth_quota_bytes"` // null = from the plan
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid"})
+		return
+	}
+	if (input.StorageQuotaBytes != nil && *input.StorageQuotaBytes < 0) || (input.BandwidthQuotaBytes != nil && *input.BandwidthQuotaBytes < 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Kuota tidak boleh negatif"})
+		return
+	}
+
+	var exists int
+	config.DB.Get(&exists, "SELECT COUNT(*) FROM organizations WHERE id = ?", orgID)
+	if exists == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Organisasi tidak ditemukan"})
+		return
+	}
+
+	var planID *int64
+	if input.PlanCode != nil && *input.PlanCode != "" {
+		var id int64
+		if err := config.DB.Get(&id, "SELECT id FROM storage_plans WHERE code = ?", strings.ToUpper(*input.PlanCode)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Paket storage tidak ditemukan"})
+			return
+		}
+		planID = &id
+	}
+
+	_, err := config.DB.Exec(`
+		UPDATE organizations SET storage_plan_id = ?, storage_quota_bytes = ?, bandwidth_quota_bytes = ?
+		WHERE id = ?
+	`, planID, input.StorageQuotaBytes, input.BandwidthQuotaBytes, orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan paket storage"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":               "Paket storage organisasi berhasil diperbarui",
+		"plan":                  loadOrgQuota(orgID).plan(),
+		"storage_quota_bytes":   orgStorageQuota(orgID),
+		"bandwidth_quota_bytes": orgBandwidthQuota(orgID),
+	})
+}
+
+// RebuildStorageUsage - Recount every stored object and attribute it to its organization
+// POST /admin/storage/usage/rebuild
+func RebuildStorageUsage(c *gin.Context) {
+	if !storageGCMu.TryLock() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Pemindaian storage sedang berjalan"})
+		return
+	}
+	defer storageGCMu.Unlock()
+
+	report := rebuildStorageUsage(c.Request.Context())
+	c.JSON(http.StatusOK, gin.H{"message": "Pemakaian storage dihitung ulang", "report": report})
+}
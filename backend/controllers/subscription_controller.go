@@ -0,0 +1,834 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"BACKEND/config"
+	"BACKEND/entitlements"
+	"BACKEND/helpers"
+	"BACKEND/models"
+	"BACKEND/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/midtrans/midtrans-go"
+	"github.com/midtrans/midtrans-go/snap"
+)
+
+// ===============================================
+// SUBSCRIPTION PLANS (ORGANIZATION CATALOG MEMBERSHIP)
+// ===============================================
+
+const subscriptionPlanColumns = `id, organization_id, name, description, billing_interval, price, grace_days,
+	COALESCE(is_active, 1) as is_active, created_at, updated_at`
+
+const subscriptionColumns = `id, plan_id, user_id, organization_id, COALESCE(status, 'PENDING') as status,
+	current_period_start, current_period_end, COALESCE(cancel_at_period_end, 0) as cancel_at_period_end,
+	cancelled_at, reminder_sent_at, created_at, updated_at`
+
+// subscriptionRenewWindowDays is how early before the period end a renewal can be paid
+const subscriptionRenewWindowDays = 7
+
+// subscriptionReminderDays is how long before the period end the renewal reminder is sent
+const subscriptionReminderDays = 3
+
+// maxSubscriptionGraceDays caps the grace period an organization can configure
+const maxSubscriptionGraceDays = 30
+
+// subscriptionBillingMu keeps two billing runs from sending the same reminder twice
+var subscriptionBillingMu sync.Mutex
+
+// subscriptionRef is the entitlement source_ref of a subscription
+func subscriptionRef(subscriptionID int64) string {
+	return fmt.Sprintf("SUB-%d", subscriptionID)
+}
+
+// subscriptionPeriodLabel formats a billing period for transaction descriptions
+func subscriptionPeriodLabel(start, end time.Time) string {
+	return start.Format("02/01/2006") + " - " + end.Format("02/01/2006")
+}
+
+// SubscriptionPlanInput is the org form for a plan
+type SubscriptionPlanInput struct {
+	Name            string  `json:"name" binding:"required"`
+	Description     string  `json:"description"`
+	BillingInterval string  `json:"billing_interval" binding:"required"` // MONTHLY, ANNUAL
+	Price           float64 `json:"price" binding:"required"`
+	GraceDays       *int    `json:"grace_days"`
+	IsActive        *bool   `json:"is_active"`
+}
+
+// validateSubscriptionPlanInput returns an error message, or "" when the plan is valid
+func validateSubscriptionPlanInput(input *SubscriptionPlanInput) string {
+	input.Name = strings.TrimSpace(input.Name)
+	input.BillingInterval = strings.ToUpper(strings.TrimSpace(input.BillingInterval))
+
+	if input.Name == "" {
+		return "Nama paket langganan wajib diisi"
+	}
+	if !helpers.ValidBillingInterval(input.BillingInterval) {
+		return "Periode tagihan harus MONTHLY atau ANNUAL"
+	}
+	if input.Price < 100 {
+		return "Harga langganan minimal Rp 100"
+	}
+	if !helpers.IsWholeRupiah(input.Price) {
+		return "Harga harus dalam rupiah bulat"
+	}
+	if input.GraceDays != nil && (*input.GraceDays < 0 || *input.GraceDays > maxSubscriptionGraceDays) {
+		return fmt.Sprintf("Masa tenggang harus antara 0 dan %d hari", maxSubscriptionGraceDays)
+	}
+	return ""
+}
+
+// OrgGetSubscriptionPlans - List the organization's plans with subscriber counts
+// GET /organization/subscription-plans
+func OrgGetSubscriptionPlans(c *gin.Context) {
+	orgID, err := getOrganizationIDByUser(c.GetInt64("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Organisasi tidak ditemukan"})
+		return
+	}
+
+	type PlanView struct {
+		models.SubscriptionPlan
+		ActiveSubscribers int `db:"active_subscribers" json:"active_subscribers"`
+	}
+	plans := []PlanView{}
+	config.DB.Select(&plans, `
+		SELECT sp.id, sp.organization_id, sp.name, sp.description, sp.billing_interval, sp.price, sp.grace_days,
+			COALESCE(sp.is_active, 1) as is_active, sp.created_at, sp.updated_at,
+			(SELECT COUNT(*) FROM subscriptions s WHERE s.plan_id = sp.id AND s.status IN ('ACTIVE', 'PAST_DUE')) as active_subscribers
+		FROM subscription_plans sp
+		WHERE sp.organization_id = ?
+		ORDER BY sp.created_at DESC
+	`, orgID)
+
+	c.JSON(http.StatusOK, gin.H{"plans": plans})
+}
+
+// OrgCreateSubscriptionPlan - Create a membership plan for the organization's catalog
+// POST /organization/subscription-plans
+func OrgCreateSubscriptionPlan(c *gin.Context) {
+	orgID, err := getOrganizationIDByUser(c.GetInt64("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Organisasi tidak ditemukan"})
+		return
+	}
+
+	var input SubscriptionPlanInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid"})
+		return
+	}
+	if msg := validateSubscriptionPlanInput(&input); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	graceDays := 3
+	if input.GraceDays != nil {
+		graceDays = *input.GraceDays
+	}
+	isActive := true
+	if input.IsActive != nil {
+		isActive = *input.IsActive
+	}
+	var description *string
+	if d := strings.TrimSpace(input.Description); d != "" {
+		description = &d
+	}
+
+	result, err := config.DB.Exec(`
+		INSERT INTO subscription_plans (organization_id, name, description, billing_interval, price, grace_days, is_active)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, orgID, input.Name, description, input.BillingInterval, input.Price, graceDays, isActive)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat paket langganan"})
+		return
+	}
+	planID, _ := result.LastInsertId()
+
+	c.JSON(http.StatusOK, gin.H{"message": "Paket langganan berhasil dibuat", "id": planID})
+}
+
+// OrgUpdateSubscriptionPlan - Update a plan; new prices apply from the next renewal
+// PUT /organization/subscription-plans/:id
+func OrgUpdateSubscriptionPlan(c *gin.Context) {
+	orgID, err := getOrganizationIDByUser(c.GetInt64("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Organisasi tidak ditemukan"})
+		return
+	}
+	planID, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	var plan models.SubscriptionPlan
+	if err := config.DB.Get(&plan, "SELECT "+subscriptionPlanColumns+" FROM subscription_plans WHERE id = ? AND organization_id = ?", planID, orgID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Paket langganan tidak ditemukan"})
+		return
+	}
+
+	var input SubscriptionPlanInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid"})
+		return
+	}
+	if msg := validateSubscriptionPlanInput(&input); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	graceDays := plan.GraceDays
+	if input.GraceDays != nil {
+		graceDays = *input.GraceDays
+	}
+	isActive := plan.IsActive
+	if input.IsActive != nil {
+		isActive = *input.IsActive
+	}
+	var description *string
+	if d := strings.TrimSpace(input.Description); d != "" {
+		description = &d
+	}
+
+	_, err = config.DB.Exec(`
+		UPDATE subscription_plans
+		SET name = ?, description = ?, billing_interval = ?, price = ?, grace_days = ?, is_active = ?
+		WHERE id = ?
+	`, input.Name, description, input.BillingInterval, input.Price, graceDays, isActive, plan.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan paket langganan"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Paket langganan berhasil disimpan"})
+}
+
+// OrgGetSubscribers - Subscribers of the organization's plans
+// GET /organization/subscribers
+func OrgGetSubscribers(c *gin.Context) {
+	orgID, err := getOrganizationIDByUser(c.GetInt64("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Organisasi tidak ditemukan"})
+		return
+	}
+
+	type SubscriberView struct {
+		ID                int64      `db:"id" json:"id"`
+		PlanName          string     `db:"plan_name" json:"plan_name"`
+		UserName          string     `db:"user_name" json:"user_name"`
+		UserEmail         string     `db:"user_email" json:"user_email"`
+		Status            string     `db:"status" json:"status"`
+		CurrentPeriodEnd  *time.Time `db:"current_period_end" json:"current_period_end"`
+		CancelAtPeriodEnd bool       `db:"cancel_at_period_end" json:"cancel_at_period_end"`
+		TotalPaid         int64      `db:"total_paid" json:"total_paid"`
+	}
+	subscribers := []SubscriberView{}
+	config.DB.Select(&subscribers, `
+		SELECT s.id, sp.name as plan_name, u.name as user_name, u.email as user_email,
+			COALESCE(s.status, 'PENDING') as status, s.current_period_end,
+			COALESCE(s.cancel_at_period_end, 0) as cancel_at_period_end,
+			(SELECT COALESCE(SUM(amount), 0) FROM subscription_payments p WHERE p.subscription_id = s.id AND p.status = 'PAID') as total_paid
+		FROM subscriptions s
+		JOIN subscription_plans sp ON sp.id = s.plan_id
+		JOIN users u ON u.id = s.user_id
+		WHERE s.organization_id = ? AND s.status <> 'PENDING'
+		ORDER BY s.current_period_end DESC
+	`, orgID)
+
+	c.JSON(http.StatusOK, gin.H{"subscribers": subscribers})
+}
+
+// OrgGetSubscriptionRevenue - Subscription revenue recognized per billing period (month of period start)
+// GET /organization/subscription-revenue
+func OrgGetSubscriptionRevenue(c *gin.Context) {
+	orgID, err := getOrganizationIDByUser(c.GetInt64("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Organisasi tidak ditemukan"})
+		return
+	}
+
+	type PeriodRevenue struct {
+		Period      string `db:"period" json:"period"`
+		Payments    int    `db:"payments" json:"payments"`
+		Gross       int64  `db:"gross" json:"gross"`
+		TaxAmount   int64  `db:"tax_amount" json:"tax_amount"`
+		PlatformFee int64  `db:"platform_fee" json:"platform_fee"`
+		OrgAmount   int64  `db:"org_amount" json:"org_amount"`
+	}
+	rows := []PeriodRevenue{}
+	config.DB.Select(&rows, `
+		SELECT DATE_FORMAT(p.period_start, '%Y-%m') as period, COUNT(*) as payments,
+			COALESCE(SUM(p.amount), 0) as gross, COALESCE(SUM(p.tax_amount), 0) as tax_amount,
+			COALESCE(SUM(p.platform_fee), 0) as platform_fee, COALESCE(SUM(p.org_amount), 0) as org_amount
+		FROM subscription_payments p
+		JOIN subscriptions s ON s.id = p.subscription_id
+		WHERE s.organization_id = ? AND p.status = 'PAID'
+		GROUP BY period
+		ORDER BY period DESC
+	`, orgID)
+
+	c.JSON(http.StatusOK, gin.H{"periods": rows})
+}
+
+// GetOrganizationSubscriptionPlans - Active plans of an organization (public)
+// GET /api/organizations/:id/subscription-plans
+func GetOrganizationSubscriptionPlans(c *gin.Context) {
+	orgID, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	plans := []models.SubscriptionPlan{}
+	config.DB.Select(&plans, "SELECT "+subscriptionPlanColumns+" FROM subscription_plans WHERE organization_id = ? AND is_active = 1 ORDER BY price ASC", orgID)
+
+	c.JSON(http.StatusOK, gin.H{"plans": plans})
+}
+
+// ===============================================
+// SUBSCRIBER: SUBSCRIBE, RENEW, CANCEL
+// ===============================================
+
+// GetMySubscriptions - Subscriptions of the current user
+// GET /user/subscriptions
+func GetMySubscriptions(c *gin.Context) {
+	type MySubscription struct {
+		models.Subscription
+		PlanName        string     `db:"plan_name" json:"plan_name"`
+		OrgName         string     `db:"org_name" json:"org_name"`
+		BillingInterval string     `db:"billing_interval" json:"billing_interval"`
+		Price           int64      `db:"price" json:"price"`
+		GraceDays       int        `db:"grace_days" json:"grace_days"`
+		AccessUntil     *time.Time `db:"-" json:"access_until"`
+	}
+	subs := []MySubscription{}
+	config.DB.Select(&subs, `
+		SELECT s.id, s.plan_id, s.user_id, s.organization_id, COALESCE(s.status, 'PENDING') as status,
+			s.current_period_start, s.current_period_end, COALESCE(s.cancel_at_period_end, 0) as cancel_at_period_end,
+			s.cancelled_at, s.reminder_sent_at, s.created_at, s.updated_at,
+			sp.name as plan_name, COALESCE(o.name, '') as org_name, sp.billing_interval, sp.price, sp.grace_days
+		FROM subscriptions s
+		JOIN subscription_plans sp ON sp.id = s.plan_id
+		JOIN organizations o ON o.id = s.organization_id
+		WHERE s.user_id = ? AND s.status <> 'PENDING'
+		ORDER BY s.updated_at DESC
+	`, c.GetInt64("user_id"))
+
+	for i := range subs {
+		if subs[i].CurrentPeriodEnd == nil {
+			continue
+		}
+		until := *subs[i].CurrentPeriodEnd
+		if !subs[i].CancelAtPeriodEnd {
+			until = helpers.AccessUntil(until, subs[i].GraceDays)
+		}
+		subs[i].AccessUntil = &until
+	}
+
+	c.JSON(http.StatusOK, gin.H{"subscriptions": subs})
+}
+
+// Subscribe - Start (or renew) a subscription and get a payment token for its billing period
+// POST /user/subscriptions
+func Subscribe(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	var input struct {
+		PlanID int64 `json:"plan_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "plan_id diperlukan"})
+		return
+	}
+
+	var plan models.SubscriptionPlan
+	if err := config.DB.Get(&plan, "SELECT "+subscriptionPlanColumns+" FROM subscription_plans WHERE id = ? AND is_active = 1", input.PlanID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Paket langganan tidak ditemukan"})
+		return
+	}
+
+	config.DB.Exec(`
+		INSERT INTO subscriptions (plan_id, user_id, organization_id, status)
+		VALUES (?, ?, ?, 'PENDING')
+		ON DUPLICATE KEY UPDATE id = id
+	`, plan.ID, userID, plan.OrganizationID)
+
+	var sub models.Subscription
+	if err := config.DB.Get(&sub, "SELECT "+subscriptionColumns+" FROM subscriptions WHERE user_id = ? AND plan_id = ?", userID, plan.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat langganan"})
+		return
+	}
+
+	startSubscriptionPayment(c, userID, &sub, &plan)
+}
+
+// RenewSubscription - Pay the next billing period (manual renewal)
+// POST /user/subscriptions/:id/renew
+func RenewSubscription(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	subID, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	var sub models.Subscription
+	if err := config.DB.Get(&sub, "SELECT "+subscriptionColumns+" FROM subscriptions WHERE id = ? AND user_id = ?", subID, userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Langganan tidak ditemukan"})
+		return
+	}
+
+	var plan models.SubscriptionPlan
+	if err := config.DB.Get(&plan, "SELECT "+subscriptionPlanColumns+" FROM subscription_plans WHERE id = ? AND is_active = 1", sub.PlanID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Paket langganan sudah tidak dijual"})
+		return
+	}
+
+	startSubscriptionPayment(c, userID, &sub, &plan)
+}
+
+// startSubscriptionPayment creates the pending payment of the next billing period
+// and its Snap transaction. An unpaid token of the same subscription is reused.
+func startSubscriptionPayment(c *gin.Context, userID int64, sub *models.Subscription, plan *models.SubscriptionPlan) {
+	// Only the last days of a paid period can be renewed, so periods do not pile up
+	if sub.CurrentPeriodEnd != nil && time.Until(*sub.CurrentPeriodEnd) > subscriptionRenewWindowDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Langganan masih aktif hingga %s, perpanjangan dibuka %d hari sebelum berakhir",
+				sub.CurrentPeriodEnd.Format("02/01/2006"), subscriptionRenewWindowDays),
+		})
+		return
+	}
+
+	var pending struct {
+		OrderID   string  `db:"order_id"`
+		SnapToken *string `db:"snap_token"`
+		Amount    int64   `db:"amount"`
+	}
+	err := config.DB.Get(&pending, `
+		SELECT order_id, snap_token, amount FROM subscription_payments
+		WHERE subscription_id = ? AND status = 'PENDING' AND snap_token IS NOT NULL
+			AND amount = ? AND created_at > DATE_SUB(NOW(), INTERVAL ? MINUTE)
+		ORDER BY id DESC LIMIT 1
+	`, sub.ID, plan.Price, int(getPendingOrderTTL().Minutes()))
+	if err == nil {
+		c.JSON(http.StatusOK, gin.H{
+			"token":           *pending.SnapToken,
+			"order_id":        pending.OrderID,
+			"subscription_id": sub.ID,
+			"total":           pending.Amount,
+		})
+		return
+	}
+
+	var user struct {
+		Name     string `db:"name"`
+		Email    string `db:"email"`
+		Phone    string `db:"phone"`
+		Username string `db:"username"`
+	}
+	config.DB.Get(&user, "SELECT name, email, COALESCE(phone, '') as phone, COALESCE(username, '') as username FROM users WHERE id = ?", userID)
+	if user.Name == "" || user.Email == "" || user.Phone == "" || user.Username == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":              "Lengkapi profil Anda terlebih dahulu",
+			"profile_incomplete": true,
+		})
+		return
+	}
+
+	orderID := fmt.Sprintf("SUB-%d-%d-%d", time.Now().Unix(), sub.ID, userID)
+	_, err = config.DB.Exec(`
+		INSERT INTO subscription_payments (subscription_id, order_id, amount, status)
+		VALUES (?, ?, ?, 'PENDING')
+	`, sub.ID, orderID, plan.Price)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat tagihan langganan"})
+		return
+	}
+
+	itemName := "Langganan: " + plan.Name
+	if len(itemName) > 50 {
+		itemName = itemName[:47] + "..."
+	}
+	snapReq := &snap.Request{
+		TransactionDetails: midtrans.TransactionDetails{
+			OrderID:  orderID,
+			GrossAmt: plan.Price,
+		},
+		CustomerDetail: &midtrans.CustomerDetails{
+			FName: user.Name,
+			Email: user.Email,
+			Phone: user.Phone,
+		},
+		Items: &[]midtrans.ItemDetails{{
+			ID:    fmt.Sprintf("PLAN-%d", plan.ID),
+			Name:  itemName,
+			Price: plan.Price,
+			Qty:   1,
+		}},
+		EnabledPayments: []snap.SnapPaymentType{
+			"gopay",
+		},
+	}
+
+	snapResp, snapErr := config.SnapClient.CreateTransaction(snapReq)
+	if snapErr != nil {
+		config.DB.Exec("UPDATE subscription_payments SET status = 'FAILED' WHERE order_id = ?", orderID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create payment: " + snapErr.Message})
+		return
+	}
+	config.DB.Exec("UPDATE subscription_payments SET snap_token = ? WHERE order_id = ?", snapResp.Token, orderID)
+
+	fmt.Printf("[SUBSCRIPTION] ✅ Created billing order %s for subscription %d\n", orderID, sub.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":           snapResp.Token,
+		"redirect_url":    snapResp.RedirectURL,
+		"order_id":        orderID,
+		"subscription_id": sub.ID,
+		"total":           plan.Price,
+	})
+}
+
+// CancelSubscription - Stop renewing; access stays until the paid period ends
+// PUT /user/subscriptions/:id/cancel
+func CancelSubscription(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	subID, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	var sub models.Subscription
+	if err := config.DB.Get(&sub, "SELECT "+subscriptionColumns+" FROM subscriptions WHERE id = ? AND user_id = ?", subID, userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Langganan tidak ditemukan"})
+		return
+	}
+	if (sub.Status != helpers.SubscriptionActive && sub.Status != helpers.SubscriptionPastDue) || sub.CancelAtPeriodEnd {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Langganan tidak aktif atau sudah dibatalkan"})
+		return
+	}
+
+	tx, _ := config.DB.Beginx()
+	defer tx.Rollback()
+
+	// No grace period once cancelled: access ends with the paid period
+	// (right away when the period is already over)
+	status := helpers.SubscriptionActive
+	if sub.Status == helpers.SubscriptionPastDue {
+		status = helpers.SubscriptionCancelled
+	}
+	tx.Exec(`
+		UPDATE subscriptions SET status = ?, cancel_at_period_end = 1, cancelled_at = NOW()
+		WHERE id = ?
+	`, status, sub.ID)
+
+	var err error
+	if status == helpers.SubscriptionCancelled {
+		_, err = entitlements.RevokeUserBySource(tx, userID, entitlements.SourceSubscription, subscriptionRef(sub.ID), "Langganan dibatalkan")
+	} else {
+		_, err = tx.Exec(`
+			UPDATE entitlements SET expires_at = ?
+			WHERE user_id = ? AND source = ? AND source_ref = ? AND revoked_at IS NULL
+		`, sub.CurrentPeriodEnd, userID, entitlements.SourceSubscription, subscriptionRef(sub.ID))
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membatalkan langganan"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membatalkan langganan"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Langganan dibatalkan, akses berlaku hingga akhir periode",
+		"status":       status,
+		"access_until": sub.CurrentPeriodEnd,
+	})
+}
+
+// ResumeSubscription - Undo a cancellation before the paid period ends
+// PUT /user/subscriptions/:id/resume
+func ResumeSubscription(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	subID, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	var sub models.Subscription
+	err := config.DB.Get(&sub, "SELECT "+subscriptionColumns+" FROM subscriptions WHERE id = ? AND user_id = ?", subID, userID)
+	if err != nil || sub.Status != helpers.SubscriptionActive || !sub.CancelAtPeriodEnd || sub.CurrentPeriodEnd == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Langganan tidak dapat dilanjutkan, silakan berlangganan kembali"})
+		return
+	}
+
+	var graceDays int
+	config.DB.Get(&graceDays, "SELECT grace_days FROM subscription_plans WHERE id = ?", sub.PlanID)
+
+	config.DB.Exec("UPDATE subscriptions SET cancel_at_period_end = 0, cancelled_at = NULL WHERE id = ?", sub.ID)
+	config.DB.Exec(`
+		UPDATE entitlements SET expires_at = ?
+		WHERE user_id = ? AND source = ? AND source_ref = ? AND revoked_at IS NULL
+	`, helpers.AccessUntil(*sub.CurrentPeriodEnd, graceDays), userID, entitlements.SourceSubscription, subscriptionRef(sub.ID))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Langganan dilanjutkan"})
+}
+
+// ===============================================
+// BILLING: PAYMENTS, REMINDERS, LIFECYCLE
+// ===============================================
+
+// ProcessSubscriptionPayment settles one billing period: the subscription moves to
+// the new period, the org-level entitlement is extended and the period's revenue
+// is credited to the organization. Repeated notifications are ignored.
+func ProcessSubscriptionPayment(orderID string, grossAmount string) error {
+	fmt.Printf("[SUBSCRIPTION] Processing order: %s, amount: %s\n", orderID, grossAmount)
+
+	tx, err := config.DB.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var payment models.SubscriptionPayment
+	err = tx.Get(&payment, `
+		SELECT id, subscription_id, order_id, amount, period_start, period_end, status,
+			COALESCE(tax_amount, 0) as tax_amount, COALESCE(platform_fee, 0) as platform_fee,
+			COALESCE(org_amount, 0) as org_amount, paid_at, created_at
+		FROM subscription_payments WHERE order_id = ? FOR UPDATE
+	`, orderID)
+	if err != nil {
+		return fmt.Errorf("subscription payment not found: %s", orderID)
+	}
+	if payment.Status == "PAID" {
+		fmt.Printf("[SUBSCRIPTION] Order %s already settled, skipping\n", orderID)
+		return nil
+	}
+
+	var sub models.Subscription
+	if err := tx.Get(&sub, "SELECT "+subscriptionColumns+" FROM subscriptions WHERE id = ? FOR UPDATE", payment.SubscriptionID); err != nil {
+		return fmt.Errorf("subscription not found: %v", err)
+	}
+	var plan models.SubscriptionPlan
+	if err := tx.Get(&plan, "SELECT "+subscriptionPlanColumns+" FROM subscription_plans WHERE id = ?", sub.PlanID); err != nil {
+		return fmt.Errorf("subscription plan not found: %v", err)
+	}
+
+	paidAt := time.Now()
+	periodStart := helpers.RenewalPeriodStart(sub.CurrentPeriodEnd, plan.GraceDays, paidAt)
+	periodEnd := helpers.BillingPeriodEnd(periodStart, plan.BillingInterval)
+	accessUntil := helpers.AccessUntil(periodEnd, plan.GraceDays)
+
+	// Split: tax (PPN) -> platform fee -> org remainder (no affiliates on subscriptions).
+	// Official org is exempt from the platform fee, only the tax is recorded.
+	feeSettings := loadFeeSettings()
+	var isOfficial bool
+	tx.Get(&isOfficial, "SELECT COALESCE(is_official, 0) FROM organizations WHERE id = ?", sub.OrganizationID)
+	platformFeePercent := 0.0
+	if !isOfficial {
+		platformFeePercent = getOrgPlatformFeePercent(tx, sub.OrganizationID, feeSettings)
+	}
+	fb := helpers.CalculateFees(payment.Amount, feeSettings.effectiveTaxPercent(), platformFeePercent, 0)
+
+	_, err = tx.Exec(`
+		UPDATE subscription_payments
+		SET status = 'PAID', paid_at = ?, period_start = ?, period_end = ?, tax_amount = ?, platform_fee = ?, org_amount = ?
+		WHERE id = ?
+	`, paidAt, periodStart, periodEnd, fb.TaxAmount, fb.PlatformFee, fb.OrgAmount, payment.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update subscription payment: %v", err)
+	}
+
+	// Paying again also undoes a pending cancellation
+	_, err = tx.Exec(`
+		UPDATE subscriptions
+		SET status = 'ACTIVE', current_period_start = ?, current_period_end = ?,
+			cancel_at_period_end = 0, cancelled_at = NULL, reminder_sent_at = NULL
+		WHERE id = ?
+	`, periodStart, periodEnd, sub.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update subscription: %v", err)
+	}
+
+	err = entitlements.GrantAccess(tx, entitlements.Grant{
+		UserID:         sub.UserID,
+		OrganizationID: sub.OrganizationID,
+		Source:         entitlements.SourceSubscription,
+		SourceRef:      subscriptionRef(sub.ID),
+		ExpiresAt:      &accessUntil,
+		Note:           plan.Name,
+	})
+	if err != nil {
+		return err
+	}
+
+	item := fmt.Sprintf("langganan %s periode %s", plan.Name, subscriptionPeriodLabel(periodStart, periodEnd))
+	recordFeeTransactions(tx, item, orderID, fb)
+
+	fmt.Printf("[SUBSCRIPTION] 💰 Splitting payment: total=%d, tax=%d, platform=%d (%.2f%%), org=%d\n",
+		fb.Gross, fb.TaxAmount, fb.PlatformFee, fb.PlatformFeePercent, fb.OrgAmount)
+
+	// Official org gets no balance credit
+	if !isOfficial {
+		_, err = tx.Exec(`
+			INSERT INTO organization_balances (organization_id, balance, total_earned)
+			VALUES (?, ?, ?)
+			ON DUPLICATE KEY UPDATE
+				balance = balance + ?,
+				total_earned = total_earned + ?
+		`, sub.OrganizationID, fb.OrgAmount, fb.OrgAmount, fb.OrgAmount, fb.OrgAmount)
+		if err != nil {
+			return fmt.Errorf("failed to credit organization balance: %v", err)
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO financial_transactions (transaction_type, entity_type, entity_id, amount, description, reference_id)
+			VALUES ('SALE', 'ORGANIZATION', ?, ?, ?, ?)
+		`, sub.OrganizationID, payment.Amount, "Penjualan "+item, orderID)
+		if err != nil {
+			return fmt.Errorf("failed to record sale: %v", err)
+		}
+	}
+
+	CreateNotification(
+		sub.UserID,
+		"subscription_active",
+		"✅ Langganan Aktif!",
+		fmt.Sprintf("Langganan %s aktif hingga %s. Semua event organisasi dapat Anda akses.", plan.Name, periodEnd.Format("02/01/2006")),
+	)
+
+	return tx.Commit()
+}
+
+// failSubscriptionPayment closes a billing order the provider reported as failed
+func failSubscriptionPayment(orderID string) error {
+	_, err := config.DB.Exec("UPDATE subscription_payments SET status = 'FAILED' WHERE order_id = ? AND status = 'PENDING'", orderID)
+	return err
+}
+
+// SubscriptionBillingReport summarises one billing run
+type SubscriptionBillingReport struct {
+	RemindersSent int `json:"reminders_sent"`
+	PastDue       int `json:"past_due"`
+	Cancelled     int `json:"cancelled"`
+	Expired       int `json:"expired"`
+	StalePayments int `json:"stale_payments"`
+}
+
+// RunSubscriptionBilling sends renewal reminders and moves subscriptions whose
+// period ended to PAST_DUE (grace), CANCELLED or EXPIRED. Access itself already
+// ends through the entitlement expiry, the status is for the subscriber and reports.
+func RunSubscriptionBilling() SubscriptionBillingReport {
+	subscriptionBillingMu.Lock()
+	defer subscriptionBillingMu.Unlock()
+
+	var report SubscriptionBillingReport
+
+	// Renewal reminders, once per period
+	var due []struct {
+		ID        int64     `db:"id"`
+		UserID    int64     `db:"user_id"`
+		Name      string    `db:"name"`
+		Email     string    `db:"email"`
+		PlanName  string    `db:"plan_name"`
+		OrgName   string    `db:"org_name"`
+		Price     int64     `db:"price"`
+		PeriodEnd time.Time `db:"current_period_end"`
+	}
+	config.DB.Select(&due, `
+		SELECT s.id, s.user_id, u.name, u.email, sp.name as plan_name, COALESCE(o.name, '') as org_name,
+			sp.price, s.current_period_end
+		FROM subscriptions s
+		JOIN subscription_plans sp ON sp.id = s.plan_id
+		JOIN organizations o ON o.id = s.organization_id
+		JOIN users u ON u.id = s.user_id
+		WHERE s.status = 'ACTIVE' AND s.cancel_at_period_end = 0 AND s.reminder_sent_at IS NULL
+			AND s.current_period_end > NOW()
+			AND s.current_period_end <= DATE_ADD(NOW(), INTERVAL ? DAY)
+	`, subscriptionReminderDays)
+
+	for _, s := range due {
+		res, err := config.DB.Exec("UPDATE subscriptions SET reminder_sent_at = NOW() WHERE id = ? AND reminder_sent_at IS NULL", s.ID)
+		if err != nil {
+			fmt.Printf("[SUBSCRIPTION] ❌ Failed to mark reminder for subscription %d: %v\n", s.ID, err)
+			continue
+		}
+		if rows, _ := res.RowsAffected(); rows == 0 {
+			continue
+		}
+		periodEnd := s.PeriodEnd.Format("02/01/2006")
+		CreateNotification(
+			s.UserID,
+			"subscription_renewal",
+			"🔔 Langganan Segera Berakhir",
+			fmt.Sprintf("Langganan %s berakhir pada %s. Perpanjang agar akses tidak terputus.", s.PlanName, periodEnd),
+		)
+		if err := utils.SendSubscriptionRenewalEmail(s.Email, s.Name, s.PlanName, s.OrgName, periodEnd, helpers.FormatRupiah(s.Price)); err != nil {
+			fmt.Printf("[SUBSCRIPTION] ⚠️ Failed to send renewal reminder to %s: %v\n", s.Email, err)
+		}
+		report.RemindersSent++
+	}
+
+	// Periods that are over
+	var ended []struct {
+		ID                int64     `db:"id"`
+		UserID            int64     `db:"user_id"`
+		Status            string    `db:"status"`
+		PeriodEnd         time.Time `db:"current_period_end"`
+		CancelAtPeriodEnd bool      `db:"cancel_at_period_end"`
+		GraceDays         int       `db:"grace_days"`
+		PlanName          string    `db:"plan_name"`
+	}
+	config.DB.Select(&ended, `
+		SELECT s.id, s.user_id, s.status, s.current_period_end, COALESCE(s.cancel_at_period_end, 0) as cancel_at_period_end,
+			sp.grace_days, sp.name as plan_name
+		FROM subscriptions s
+		JOIN subscription_plans sp ON sp.id = s.plan_id
+		WHERE s.status IN ('ACTIVE', 'PAST_DUE') AND s.current_period_end <= NOW()
+	`)
+
+	now := time.Now()
+	for _, s := range ended {
+		state := helpers.SubscriptionStateAt(s.PeriodEnd, s.GraceDays, s.CancelAtPeriodEnd, now)
+		if state == s.Status {
+			continue
+		}
+		config.DB.Exec("UPDATE subscriptions SET status = ? WHERE id = ? AND status = ?", state, s.ID, s.Status)
+
+		switch state {
+		case helpers.SubscriptionPastDue:
+			report.PastDue++
+			CreateNotification(
+				s.UserID,
+				"subscription_past_due",
+				"⏳ Masa Tenggang Langganan",
+				fmt.Sprintf("Periode langganan %s sudah berakhir. Akses tetap terbuka selama %d hari, segera perpanjang.", s.PlanName, s.GraceDays),
+			)
+		case helpers.SubscriptionCancelled:
+			report.Cancelled++
+		case helpers.SubscriptionExpired:
+			report.Expired++
+			CreateNotification(
+				s.UserID,
+				"subscription_expired",
+				"⌛ Langganan Berakhir",
+				fmt.Sprintf("Langganan %s berakhir karena tidak diperpanjang.", s.PlanName),
+			)
+		}
+	}
+
+	// Billing orders nobody paid are closed like stale cart orders
+	res, err := config.DB.Exec(`
+		UPDATE subscription_payments SET status = 'FAILED'
+		WHERE status = 'PENDING' AND created_at < DATE_SUB(NOW(), INTERVAL ? MINUTE)
+	`, int(getPendingOrderTTL().Minutes()))
+	if err == nil {
+		rows, _ := res.RowsAffected()
+		report.StalePayments = int(rows)
+	}
+
+	if report != (SubscriptionBillingReport{}) {
+		fmt.Printf("[SUBSCRIPTION] 🔁 Billing run: reminders=%d, past_due=%d, cancelled=%d, expired=%d, stale=%d\n",
+			report.RemindersSent, report.PastDue, report.Cancelled, report.Expired, report.StalePayments)
+	}
+	return report
+}
+
+// AdminRunSubscriptionBilling - Run the subscription billing job now
+// POST /admin/subscriptions/run-billing
+func AdminRunSubscriptionBilling(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"report": RunSubscriptionBilling()})
+}
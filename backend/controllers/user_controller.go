@@ -387,10 +387,10 @@ func GetUserByID(c *gin.Context) {
 
 	// 3. Get events joined (purchases)
 	type EventJoined struct {
-		EventID       int64   `db:"event_id" json:"event_id"`
-		EventTitle    string  `db:"event_title" json:"event_title"`
-		SessionsCount int     `db:"sessions_count" json:"sessions_count"`
-		TotalPaid     float64 `db:"total_paid" json:"total_paid"`
+		EventID       int64  `db:"event_id" json:"event_id"`
+		EventTitle    string `db:"event_title" json:"event_title"`
+		SessionsCount int    `db:"sessions_count" json:"sessions_count"`
+		TotalPaid     int64  `db:"total_paid" json:"total_paid"`
 	}
 	var eventsJoined []EventJoined
 	config.DB.Select(&eventsJoined, `
@@ -400,8 +400,8 @@ func GetUserByID(c *gin.Context) {
 			COUNT(p.id) as sessions_count,
 			SUM(p.price_paid) as total_paid
 		FROM purchases p
-		JOIN sessions s ON p.session_id = s.id
-		JOIN events e ON s.event_id = e.id
+		LEFT JOIN sessions s ON p.session_id = s.id
+		JOIN events e ON e.id = COALESCE(p.event_id, s.event_id)
 		WHERE p.user_id = ?
 		GROUP BY e.id, e.title
 		ORDER BY total_paid DESC
@@ -464,7 +464,7 @@ func GetUserByID(c *gin.Context) {
 					e.title,
 					COALESCE(e.publish_status, 'DRAFT') as publish_status,
 					(SELECT COUNT(*) FROM sessions WHERE event_id = e.id) as sessions_count,
-					(SELECT COUNT(DISTINCT p.user_id) FROM purchases p JOIN sessions s ON p.session_id = s.id WHERE s.event_id = e.id) as buyers_count
+					(SELECT COUNT(DISTINCT p.user_id) FROM purchases p LEFT JOIN sessions s ON p.session_id = s.id WHERE COALESCE(p.event_id, s.event_id) = e.id) as buyers_count
 				FROM events e
 				WHERE e.organization_id = ?
 				ORDER BY e.created_at DESC
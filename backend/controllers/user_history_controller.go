@@ -55,27 +55,32 @@ func GetMyPayments(c *gin.Context) {
 	userID := c.GetInt64("user_id")
 
 	type PaymentRow struct {
-		ID           int64   `db:"id" json:"id"`
-		SessionID    int64   `db:"session_id" json:"session_id"`
-		SessionTitle string  `db:"session_title" json:"session_title"`
-		EventID      int64   `db:"event_id" json:"event_id"`
-		EventTitle   string  `db:"event_title" json:"event_title"`
-		Amount       float64 `db:"amount" json:"amount"`
-		Status       string  `db:"status" json:"status"`
-		OrderID      *string `db:"order_id" json:"order_id"`
-		SnapToken    *string `db:"snap_token" json:"snap_token"`
-		CreatedAt    string  `db:"created_at" json:"created_at"`
+		ID            int64   `db:"id" json:"id"`
+		SessionID     int64   `db:"session_id" json:"session_id"`
+		SessionTitle  string  `db:"session_title" json:"session_title"`
+		EventID       int64   `db:"event_id" json:"event_id"`
+		EventTitle    string  `db:"event_title" json:"event_title"`
+		Amount        int64   `db:"amount" json:"amount"`
+		Status        string  `db:"status" json:"status"`
+		OrderID       *string `db:"order_id" json:"order_id"`
+		SnapToken     *string `db:"snap_token" json:"snap_token"`
+		InvoiceNumber *string `db:"invoice_number" json:"invoice_number"`
+		IsGift        bool    `db:"is_gift" json:"is_gift"`
+		Quantity      int     `db:"quantity" json:"quantity"`
+		CreatedAt     string  `db:"created_at" json:"created_at"`
 	}
 
 	var payments []PaymentRow
 	err := config.DB.Select(&payments, `
-		SELECT p.id, p.session_id, s.title as session_title, 
+		SELECT p.id, COALESCE(p.session_id, 0) as session_id, COALESCE(s.title, 'Paket Lengkap') as session_title, 
 		       e.id as event_id, e.title as event_title,
 		       p.price_paid as amount, p.status, p.order_id, p.snap_token,
+		       i.invoice_number, COALESCE(p.is_gift, 0) as is_gift, COALESCE(p.quantity, 1) as quantity,
 		       p.purchased_at as created_at
 		FROM purchases p
-		JOIN sessions s ON p.session_id = s.id
-		JOIN events e ON s.event_id = e.id
+		LEFT JOIN sessions s ON p.session_id = s.id
+		JOIN events e ON e.id = COALESCE(p.event_id, s.event_id)
+		LEFT JOIN invoices i ON i.order_id = p.order_id
 		WHERE p.user_id = ?
 		ORDER BY p.purchased_at DESC
 	`, userID)
@@ -101,9 +106,12 @@ func CancelPayment(c *gin.Context) {
 	paymentID := c.Param("id")
 
 	// Check if payment exists and belongs to user
-	var status string
-	err := config.DB.Get(&status, `
-		SELECT status FROM purchases WHERE id = ? AND user_id = ?
+	var payment struct {
+		Status  string  `db:"status"`
+		OrderID *string `db:"order_id"`
+	}
+	err := config.DB.Get(&payment, `
+		SELECT status, order_id FROM purchases WHERE id = ? AND user_id = ?
 	`, paymentID, userID)
 
 	if err != nil {
@@ -111,7 +119,7 @@ func CancelPayment(c *gin.Context) {
 		return
 	}
 
-	if status != "PENDING" {
+	if payment.Status != "PENDING" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Only pending payments can be cancelled"})
 		return
 	}
@@ -126,5 +134,14 @@ func CancelPayment(c *gin.Context) {
 		return
 	}
 
+	// Free the coupon quota once nothing in the order is left to pay
+	if payment.OrderID != nil {
+		var stillPending int
+		config.DB.Get(&stillPending, "SELECT COUNT(*) FROM purchases WHERE order_id = ? AND status = 'PENDING'", *payment.OrderID)
+		if stillPending == 0 {
+			releaseCouponRedemption(*payment.OrderID)
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Payment cancelled successfully"})
 }
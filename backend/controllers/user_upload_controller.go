@@ -9,7 +9,8 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"BACKEND/config"
-	"BACKEND/utils"
+	"BACKEND/filecheck"
+	"BACKEND/media"
 )
 
 // =======================================
@@ -41,12 +42,8 @@ func UploadProfileImage(c *gin.Context) {
 		return
 	}
 
-	// Validasi ekstensi file
+	// Format, ukuran dan isi file divalidasi saat upload (filecheck.ProfileImage)
 	ext := filepath.Ext(file.Filename)
-	if ext != ".jpg" && ext != ".jpeg" && ext != ".png" && ext != ".webp" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Only JPG, JPEG, PNG, WEBP allowed"})
-		return
-	}
 
 	// Nama file unik: user_{id}_{timestamp}.{ext}
 	filename := fmt.Sprintf("user_%d_%d%s", userID, time.Now().Unix(), ext)
@@ -54,10 +51,10 @@ func UploadProfileImage(c *gin.Context) {
 	storagePath := "profile/" + filename
 
 	// Upload to Supabase using Header helper
-	publicURL, err := utils.UploadFileHeaderToSupabase(storagePath, file)
+	publicURL, err := uploadFormFile(c, filecheck.ProfileImage, storagePath, file, 0)
 	if err != nil {
-		fmt.Printf("❌ Supabase upload error for user %d: %v\n", userID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload image to storage: " + err.Error()})
+		fmt.Printf("❌ Storage upload error for user %d: %v\n", userID, err)
+		respondUploadError(c, err, "Failed to upload image to storage")
 		return
 	}
 
@@ -67,6 +64,9 @@ func UploadProfileImage(c *gin.Context) {
 		return
 	}
 
+	var oldURL string
+	config.DB.Get(&oldURL, "SELECT COALESCE(profile_img, '') FROM users WHERE id = ?", userID)
+
 	// Simpan URL ke database
 	_, err = config.DB.Exec(`
 		UPDATE users SET profile_img = ? WHERE id = ?
@@ -79,10 +79,12 @@ func UploadProfileImage(c *gin.Context) {
 		return
 	}
 
+	replaceStoredFile(c, oldURL, publicURL)
 	fmt.Printf("✅ Profile image updated for user %d: %s\n", userID, publicURL)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Profile image uploaded successfully",
 		"url":     publicURL,
+		"image":   attachImageVariants(c, publicURL, media.ProfileImageVariants),
 	})
 }
@@ -0,0 +1,405 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"BACKEND/config"
+	"BACKEND/helpers"
+	"BACKEND/media"
+	"BACKEND/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ===============================================
+// HLS TRANSCODING
+// ===============================================
+
+// transcodeTimeout bounds a single video's ffmpeg work
+const transcodeTimeout = 3 * time.Hour
+
+// transcodeMu keeps the scheduled job and upload-triggered runs from transcoding in parallel
+var transcodeMu sync.Mutex
+
+// hlsPrefix is where a video's renditions live in the private media storage
+func hlsPrefix(videoID int64) string {
+	return fmt.Sprintf("hls/%d", videoID)
+}
+
+// RunVideoTranscodeQueue transcodes PENDING videos one at a time until none are
+// left. When another run is already busy it returns immediately; that run will
+// pick up newly queued videos.
+func RunVideoTranscodeQueue() {
+	if config.Transcoder == nil || !transcodeMu.TryLock() {
+		return
+	}
+	defer transcodeMu.Unlock()
+
+	for {
+		var videoID int64
+		if err := config.DB.Get(&videoID, "SELECT id FROM session_videos WHERE hls_status = 'PENDING' ORDER BY id ASC LIMIT 1"); err != nil {
+			return
+		}
+
+		res, err := config.DB.Exec("UPDATE session_videos SET hls_status = 'PROCESSING', transcode_error = NULL WHERE id = ? AND hls_status = 'PENDING'", videoID)
+		if err != nil {
+			fmt.Printf("[TRANSCODE] ❌ Failed to claim video %d: %v\n", videoID, err)
+			return
+		}
+		if rows, _ := res.RowsAffected(); rows == 0 {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), transcodeTimeout)
+		err = transcodeSessionVideo(ctx, videoID)
+		cancel()
+		if err != nil {
+			fmt.Printf("[TRANSCODE] ❌ Video %d: %v\n", videoID, err)
+			config.DB.Exec("UPDATE session_videos SET hls_status = 'FAILED', transcode_error = ? WHERE id = ?", err.Error(), videoID)
+		}
+	}
+}
+
+// ResetStaleTranscodes requeues videos that were PROCESSING when the server stopped
+func ResetStaleTranscodes() {
+	res, err := config.DB.Exec("UPDATE session_videos SET hls_status = 'PENDING' WHERE hls_status = 'PROCESSING'")
+	if err != nil {
+		fmt.Printf("[TRANSCODE] ❌ Failed to requeue stale videos: %v\n", err)
+		return
+	}
+	if rows, _ := res.RowsAffected(); rows > 0 {
+		fmt.Printf("[TRANSCODE] ✅ Requeued %d interrupted video(s)\n", rows)
+	}
+}
+
+// transcodeSessionVideo produces the HLS renditions, master playlist and poster of one video
+func transcodeSessionVideo(ctx context.Context, videoID int64) error {
+	var video struct {
+		SessionID int64   `db:"session_id"`
+		VideoURL  string  `db:"video_url"`
+		PosterURL *string `db:"poster_url"`
+	}
+	if err := config.DB.Get(&video, "SELECT session_id, video_url, poster_url FROM session_videos WHERE id = ?", videoID); err != nil {
+		return fmt.Errorf("video not found: %v", err)
+	}
+
+	src, key, ok := resolveStoredObject(video.VideoURL)
+	if !ok {
+		return fmt.Errorf("unknown video location %s", video.VideoURL)
+	}
+
+	workDir, err := os.MkdirTemp("", "transcode-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+
+	input := filepath.Join(workDir, "source"+path.Ext(key))
+	if err := downloadObject(ctx, src, key, input); err != nil {
+		return fmt.Errorf("download: %v", err)
+	}
+
+	probe, err := config.Transcoder.Probe(ctx, input)
+	if err != nil {
+		return fmt.Errorf("probe: %v", err)
+	}
+
+	renditions := media.SelectRenditions(probe.Height, media.DefaultRenditions)
+	outDir := filepath.Join(workDir, "hls")
+	if err := config.Transcoder.Transcode(ctx, input, outDir, renditions); err != nil {
+		return fmt.Errorf("transcode: %v", err)
+	}
+
+	variants := make([]media.Variant, len(renditions))
+	names := make([]string, len(renditions))
+	for i, r := range renditions {
+		variants[i] = media.Variant{Rendition: r, Width: media.ScaledWidth(probe.Width, probe.Height, r.Height), URI: r.Name + "/index.m3u8"}
+		names[i] = r.Name
+	}
+	master := filepath.Join(outDir, media.MasterPlaylistName)
+	if err := os.WriteFile(master, []byte(media.MasterPlaylist(variants)), 0o644); err != nil {
+		return err
+	}
+
+	// Renditions and poster count against the organization's storage
+	orgID := sessionOrganization(video.SessionID)
+	prefix := hlsPrefix(videoID)
+	if err := uploadDirectory(ctx, config.MediaStorage, outDir, prefix, orgID); err != nil {
+		return fmt.Errorf("upload renditions: %v", err)
+	}
+
+	// A missing poster does not make the video unplayable
+	posterURL := video.PosterURL
+	posterPath := filepath.Join(workDir, "poster.jpg")
+	if err := config.Transcoder.Thumbnail(ctx, input, posterPath, media.PosterTime(probe.Duration)); err != nil {
+		fmt.Printf("[TRANSCODE] ⚠️ Video %d poster: %v\n", videoID, err)
+	} else if url, err := uploadLocalFile(ctx, config.Storage, fmt.Sprintf("video_posters/video_%d.jpg", videoID), posterPath, orgID); err != nil {
+		fmt.Printf("[TRANSCODE] ⚠️ Video %d poster upload: %v\n", videoID, err)
+	} else {
+		posterURL = &url
+	}
+
+	_, err = config.DB.Exec(`
+		UPDATE session_videos
+		SET hls_status = 'READY', hls_prefix = ?, hls_renditions = ?, duration_seconds = ?,
+			width = ?, height = ?, poster_url = ?, transcode_error = NULL, transcoded_at = NOW()
+		WHERE id = ?
+	`, prefix, strings.Join(names, ","), int(math.Round(probe.Duration)), probe.Width, probe.Height, posterURL, videoID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("[TRANSCODE] ✅ Video %d ready: %s (%.0fs)\n", videoID, strings.Join(names, ", "), probe.Duration)
+	return nil
+}
+
+// downloadObject copies a stored object to a local file for ffmpeg
+func downloadObject(ctx context.Context, s storage.Storage, key, dest string) error {
+	body, _, err := s.Get(ctx, key, nil)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	f, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, body); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// uploadLocalFile stores a file from disk, books it to orgID and returns its URL
+func uploadLocalFile(ctx context.Context, s storage.Storage, key, src string, orgID int64) (string, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	if err := s.Put(ctx, key, f, info.Size(), media.ContentType(src)); err != nil {
+		return "", err
+	}
+	recordStoredObject(s, key, orgID, info.Size())
+	return s.URL(key), nil
+}
+
+// uploadDirectory stores every file below dir under prefix, keeping relative paths
+func uploadDirectory(ctx context.Context, s storage.Storage, dir, prefix string, orgID int64) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		_, err = uploadLocalFile(ctx, s, prefix+"/"+filepath.ToSlash(rel), p, orgID)
+		return err
+	})
+}
+
+// deleteHLSObjects removes a video's playlists and segments by walking its playlists
+func deleteHLSObjects(ctx context.Context, prefix string) {
+	keys := []string{}
+	queue := []string{media.MasterPlaylistName}
+	seen := map[string]bool{}
+
+	for len(queue) > 0 {
+		rel := queue[0]
+		queue = queue[1:]
+		if seen[rel] {
+			continue
+		}
+		seen[rel] = true
+		keys = append(keys, prefix+"/"+rel)
+
+		if !strings.HasSuffix(rel, ".m3u8") {
+			continue
+		}
+		body, _, err := config.MediaStorage.Get(ctx, prefix+"/"+rel, nil)
+		if err != nil {
+			continue
+		}
+		data, _ := io.ReadAll(io.LimitReader(body, 4<<20))
+		body.Close()
+		queue = append(queue, media.PlaylistURIs(rel, string(data))...)
+	}
+
+	for _, key := range keys {
+		if err := config.MediaStorage.Delete(ctx, key); err != nil {
+			fmt.Printf("[TRANSCODE] ❌ Failed to delete %s: %v\n", key, err)
+			continue
+		}
+		forgetStoredObject(config.MediaStorage, key)
+	}
+}
+
+// deleteVideoRenditions removes the HLS output and poster of videos about to be deleted
+func deleteVideoRenditions(c *gin.Context, videoIDs ...int64) {
+	for _, id := range videoIDs {
+		var v struct {
+			HLSPrefix *string `db:"hls_prefix"`
+			PosterURL *string `db:"poster_url"`
+		}
+		if err := config.DB.Get(&v, "SELECT hls_prefix, poster_url FROM session_videos WHERE id = ?", id); err != nil {
+			continue
+		}
+		if v.HLSPrefix != nil && *v.HLSPrefix != "" {
+			deleteHLSObjects(c.Request.Context(), *v.HLSPrefix)
+		}
+		if v.PosterURL != nil {
+			deleteStoredFile(c, *v.PosterURL)
+		}
+	}
+}
+
+// RetrySessionVideoTranscode - Queue a video for transcoding again (e.g. after FAILED)
+// POST /organization/sessions/:sessionID/videos/:mediaID/transcode
+func RetrySessionVideoTranscode(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	sessionID, _ := strconv.ParseInt(c.Param("sessionID"), 10, 64)
+	mediaID, _ := strconv.ParseInt(c.Param("mediaID"), 10, 64)
+
+	if !checkSessionOwnedByUser(sessionID, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Akses Ditolak"})
+		return
+	}
+
+	res, err := config.DB.Exec(`
+		UPDATE session_videos SET hls_status = 'PENDING', transcode_error = NULL
+		WHERE id = ? AND session_id = ? AND hls_status <> 'PROCESSING'
+	`, mediaID, sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengantrikan video"})
+		return
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Video tidak ditemukan atau sedang diproses"})
+		return
+	}
+
+	go RunVideoTranscodeQueue()
+	c.JSON(http.StatusOK, gin.H{"message": "Video masuk antrean transcoding", "hls_status": "PENDING"})
+}
+
+// ===============================================
+// HLS PLAYBACK
+// ===============================================
+
+// hlsTokenTTL covers a whole viewing: every URL in the playlist chain shares the
+// expiry minted with the master playlist
+func hlsTokenTTL(durationSeconds *int) time.Duration {
+	ttl := time.Hour
+	if durationSeconds != nil {
+		ttl += time.Duration(*durationSeconds) * time.Second
+	}
+	return ttl
+}
+
+// signedHLSURL signs one file of a video's HLS output for a user's playback
+func signedHLSURL(userID, videoID int64, rel, playbackID string, exp int64) string {
+	token := helpers.SignToken(userID, playbackResource(hlsPrefix(videoID)+"/"+rel, playbackID), exp)
+	return fmt.Sprintf("/api/user/sessions/hls/%d/%s?token=%s&exp=%d&uid=%d&psid=%s", videoID, rel, token, exp, userID, playbackID)
+}
+
+// StreamSessionHLS - Serve HLS playlists (with every URI signed) and segments
+// GET /user/sessions/hls/:videoID/*path
+func StreamSessionHLS(c *gin.Context) {
+	videoID, err := strconv.ParseInt(c.Param("videoID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+		return
+	}
+	rel := strings.TrimPrefix(c.Param("path"), "/")
+	exp, _ := strconv.ParseInt(c.Query("exp"), 10, 64)
+	userID, _ := strconv.ParseInt(c.Query("uid"), 10, 64)
+	playbackID := c.Query("psid")
+
+	key := hlsPrefix(videoID) + "/" + rel
+	if playbackID == "" || !helpers.ValidateSignedToken(userID, playbackResource(key, playbackID), exp, c.Query("token")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "URL tidak valid atau sudah kedaluwarsa"})
+		return
+	}
+
+	// Every request keeps the playback alive and counts against the stream limit
+	playback, ok := touchPlayback(c, userID, videoID, playbackID)
+	if !ok {
+		return
+	}
+
+	// Segments are covered by the signature of the playlist that listed them
+	if !strings.HasSuffix(rel, ".m3u8") {
+		sent := serveStoredObject(c, config.MediaStorage, key)
+		recordBandwidth(sessionOrganization(playback.SessionID), playback.SessionID, sent)
+		return
+	}
+
+	// Playlists re-check access, so a revoked entitlement stops playback
+	var video struct {
+		SessionID int64  `db:"session_id"`
+		HLSStatus string `db:"hls_status"`
+	}
+	if err := config.DB.Get(&video, "SELECT session_id, hls_status FROM session_videos WHERE id = ?", videoID); err != nil || video.HLSStatus != "READY" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Video tidak ditemukan"})
+		return
+	}
+	if !canAccessSessionMedia(userID, video.SessionID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Anda belum memiliki akses ke sesi ini"})
+		return
+	}
+
+	body, _, err := config.MediaStorage.Get(c.Request.Context(), key, nil)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Playlist tidak ditemukan"})
+		return
+	}
+	if err != nil {
+		fmt.Printf("[HLS] ❌ Get %s failed: %v\n", key, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Gagal membaca playlist"})
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(body, 4<<20))
+	body.Close()
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Gagal membaca playlist"})
+		return
+	}
+
+	playlist := media.RewritePlaylist(string(data), func(uri string) string {
+		target, ok := media.ResolveURI(rel, uri)
+		if !ok {
+			return uri
+		}
+		return signedHLSURL(userID, videoID, target, playbackID, exp)
+	})
+
+	// Players and recorders that read session data carry the viewer's watermark
+	if rel == media.MasterPlaylistName {
+		playlist = media.AddSessionData(playlist, media.WatermarkDataID, playbackWatermark(userID, playback.WatermarkCode).Text)
+	}
+
+	c.Header("Cache-Control", "private, no-store")
+	c.Data(http.StatusOK, media.ContentType(rel), []byte(playlist))
+	recordBandwidth(sessionOrganization(video.SessionID), video.SessionID, int64(len(playlist)))
+}
@@ -0,0 +1,235 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"BACKEND/config"
+	"BACKEND/helpers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ===============================================
+// VIDEO WATCH PROGRESS
+// ===============================================
+
+// WatchProgress is a viewer's progress on one video
+type WatchProgress struct {
+	VideoID         int64   `db:"video_id" json:"video_id"`
+	SessionID       int64   `db:"session_id" json:"session_id"`
+	PositionSeconds float64 `db:"position_seconds" json:"position_seconds"`
+	WatchedSeconds  float64 `db:"watched_seconds" json:"watched_seconds"`
+	DurationSeconds float64 `db:"duration_seconds" json:"duration_seconds"`
+	Percent         float64 `db:"percent" json:"percent"`
+	Completed       bool    `db:"completed" json:"completed"`
+}
+
+// sessionWatchProgress returns a viewer's progress on every video of a session
+// and the duration-weighted share watched. measurable is false when no video
+// has a known length, in which case watch rules cannot apply.
+func sessionWatchProgress(userID, sessionID int64) (videos []WatchProgress, percent float64, measurable bool) {
+	videos = []WatchProgress{}
+	config.DB.Select(&videos, `
+		SELECT v.id AS video_id, v.session_id,
+			COALESCE(p.position_seconds, 0) AS position_seconds,
+			COALESCE(p.watched_seconds, 0) AS watched_seconds,
+			COALESCE(NULLIF(v.duration_seconds, 0), p.duration_seconds, 0) AS duration_seconds,
+			COALESCE(p.percent, 0) AS percent,
+			p.completed_at IS NOT NULL AS completed
+		FROM session_videos v
+		LEFT JOIN video_watch_progress p ON p.video_id = v.id AND p.user_id = ?
+		WHERE v.session_id = ?
+		ORDER BY v.id ASC
+	`, userID, sessionID)
+
+	watches := make([]helpers.VideoWatch, 0, len(videos))
+	for _, v := range videos {
+		if v.DurationSeconds > 0 {
+			measurable = true
+		}
+		watches = append(watches, helpers.VideoWatch{DurationSeconds: v.DurationSeconds, WatchedSeconds: v.WatchedSeconds})
+	}
+	return videos, helpers.SessionWatchPercent(watches), measurable
+}
+
+// sessionMinWatchPercent is the event's watch rule for a session; 0 when unset
+func sessionMinWatchPercent(sessionID int64) int {
+	var minPercent int
+	config.DB.Get(&minPercent, `
+		SELECT COALESCE(ec.min_watch_percent, 0) FROM event_certificates ec
+		JOIN sessions s ON s.event_id = ec.event_id
+		WHERE s.id = ?
+	`, sessionID)
+	return minPercent
+}
+
+// checkWatchRequirement reports whether a viewer has watched enough of a
+// session to take its quiz or count it towards a certificate
+func checkWatchRequirement(userID, sessionID int64) (percent float64, minPercent int, ok bool) {
+	minPercent = sessionMinWatchPercent(sessionID)
+	if minPercent <= 0 {
+		return 0, 0, true
+	}
+	_, percent, measurable := sessionWatchProgress(userID, sessionID)
+	if !measurable {
+		return percent, minPercent, true
+	}
+	return percent, minPercent, helpers.WatchRequirementMet(percent, minPercent)
+}
+
+// loadWatchVideo finds a video and checks the viewer may watch it
+func loadWatchVideo(c *gin.Context) (videoID, sessionID int64, durationSeconds float64, ok bool) {
+	userID := c.GetInt64("user_id")
+	videoID, err := strconv.ParseInt(c.Param("videoID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID video tidak valid"})
+		return 0, 0, 0, false
+	}
+
+	var video struct {
+		SessionID       int64 `db:"session_id"`
+		DurationSeconds *int  `db:"duration_seconds"`
+	}
+	if err := config.DB.Get(&video, "SELECT session_id, duration_seconds FROM session_videos WHERE id = ?", videoID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Video tidak ditemukan"})
+		return 0, 0, 0, false
+	}
+	if !canAccessSessionMedia(userID, video.SessionID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Anda belum membeli sesi ini"})
+		return 0, 0, 0, false
+	}
+	if video.DurationSeconds != nil {
+		durationSeconds = float64(*video.DurationSeconds)
+	}
+	return videoID, video.SessionID, durationSeconds, true
+}
+
+// RecordWatchProgress - Playback heartbeat, sent by the player every few seconds
+// POST /user/videos/:videoID/progress
+func RecordWatchProgress(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	videoID, sessionID, duration, ok := loadWatchVideo(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		PositionSeconds float64 `json:"position_seconds"`
+		DurationSeconds float64 `json:"duration_seconds"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || input.PositionSeconds < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Posisi pemutaran tidak valid"})
+		return
+	}
+
+	// The transcoder's duration wins over what the player reports
+	if duration <= 0 && input.DurationSeconds > 0 {
+		duration = input.DurationSeconds
+	}
+	position := input.PositionSeconds
+	if duration > 0 && position > duration {
+		position = duration
+	}
+
+	var prev struct {
+		PositionSeconds float64 `db:"position_seconds"`
+		WatchedSeconds  float64 `db:"watched_seconds"`
+		Elapsed         float64 `db:"elapsed"`
+		Completed       bool    `db:"completed"`
+	}
+	config.DB.Get(&prev, `
+		SELECT position_seconds, watched_seconds, completed_at IS NOT NULL AS completed,
+			TIMESTAMPDIFF(SECOND, updated_at, NOW()) AS elapsed
+		FROM video_watch_progress WHERE user_id = ? AND video_id = ?
+	`, userID, videoID)
+
+	watched := prev.WatchedSeconds + helpers.CreditWatchTime(prev.PositionSeconds, position, prev.Elapsed)
+	if duration > 0 && watched > duration {
+		watched = duration
+	}
+	percent := helpers.WatchPercent(watched, duration)
+	completed := prev.Completed || percent >= helpers.WatchCompletePercent
+
+	_, err := config.DB.Exec(`
+		INSERT INTO video_watch_progress
+			(user_id, video_id, session_id, position_seconds, watched_seconds, duration_seconds, percent, completed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, IF(?, NOW(), NULL))
+		ON DUPLICATE KEY UPDATE position_seconds = VALUES(position_seconds),
+			watched_seconds = VALUES(watched_seconds), duration_seconds = VALUES(duration_seconds),
+			percent = VALUES(percent), completed_at = COALESCE(completed_at, VALUES(completed_at)),
+			updated_at = NOW()
+	`, userID, videoID, sessionID, position, watched, duration, percent, completed)
+	if err != nil {
+		fmt.Printf("[WATCH] ❌ Save progress user=%d video=%d failed: %v\n", userID, videoID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan progres menonton"})
+		return
+	}
+
+	if completed && !prev.Completed {
+		fmt.Printf("[WATCH] ✅ User %d completed video %d\n", userID, videoID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"progress": WatchProgress{
+			VideoID:         videoID,
+			SessionID:       sessionID,
+			PositionSeconds: position,
+			WatchedSeconds:  watched,
+			DurationSeconds: duration,
+			Percent:         percent,
+			Completed:       completed,
+		},
+	})
+}
+
+// GetWatchProgress - Resume position and progress of one video
+// GET /user/videos/:videoID/progress
+func GetWatchProgress(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	videoID, sessionID, duration, ok := loadWatchVideo(c)
+	if !ok {
+		return
+	}
+
+	progress := WatchProgress{VideoID: videoID, SessionID: sessionID, DurationSeconds: duration}
+	config.DB.Get(&progress, `
+		SELECT video_id, session_id, position_seconds, watched_seconds, duration_seconds, percent,
+			completed_at IS NOT NULL AS completed
+		FROM video_watch_progress WHERE user_id = ? AND video_id = ?
+	`, userID, videoID)
+
+	// A video watched to the end starts over
+	resume := progress.PositionSeconds
+	if progress.DurationSeconds > 0 && resume >= progress.DurationSeconds-1 {
+		resume = 0
+	}
+
+	c.JSON(http.StatusOK, gin.H{"progress": progress, "resume_seconds": resume})
+}
+
+// GetSessionWatchProgress - Progress on every video of a session and whether its quiz is unlocked
+// GET /user/sessions/:sessionID/watch-progress
+func GetSessionWatchProgress(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	sessionID, err := strconv.ParseInt(c.Param("sessionID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID sesi tidak valid"})
+		return
+	}
+	if !canAccessSessionMedia(userID, sessionID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Anda belum membeli sesi ini"})
+		return
+	}
+
+	videos, percent, _ := sessionWatchProgress(userID, sessionID)
+	_, minPercent, met := checkWatchRequirement(userID, sessionID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"videos":            videos,
+		"watch_percent":     percent,
+		"min_watch_percent": minPercent,
+		"quiz_unlocked":     met,
+	})
+}
@@ -0,0 +1,243 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"BACKEND/config"
+	"BACKEND/helpers"
+	"BACKEND/models"
+	"BACKEND/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ===============================================
+// WISHLIST
+// ===============================================
+
+// Wishlist alert types (one alert per item, type and ref)
+const (
+	wishlistAlertPublished = "PUBLISHED"
+	wishlistAlertPriceDrop = "PRICE_DROP"
+	wishlistAlertCoupon    = "COUPON"
+)
+
+// GetMyWishlist - Wishlisted events and sessions of the current user
+// GET /user/wishlist
+func GetMyWishlist(c *gin.Context) {
+	type WishlistView struct {
+		models.WishlistItem
+		ItemTitle     string  `db:"item_title" json:"item_title"`
+		EventTitle    string  `db:"event_title" json:"event_title"`
+		ThumbnailURL  *string `db:"thumbnail_url" json:"thumbnail_url"`
+		Price         *int64  `db:"price" json:"price"` // session price, or the active package price of an event
+		PublishStatus string  `db:"publish_status" json:"publish_status"`
+		PublishAt     *string `db:"publish_at" json:"publish_at"`
+	}
+	items := []WishlistView{}
+	config.DB.Select(&items, `
+		SELECT w.id, w.user_id, w.event_id, w.session_id, w.created_at,
+			COALESCE(s.title, e.title) as item_title, e.title as event_title, e.thumbnail_url,
+			CASE WHEN w.session_id IS NULL THEN ep.price ELSE s.price END as price,
+			COALESCE(CASE WHEN w.session_id IS NULL THEN e.publish_status ELSE s.publish_status END, 'DRAFT') as publish_status,
+			CAST(CASE WHEN w.session_id IS NULL THEN e.publish_at ELSE s.publish_at END AS CHAR) as publish_at
+		FROM wishlists w
+		JOIN events e ON e.id = w.event_id
+		LEFT JOIN sessions s ON s.id = w.session_id
+		LEFT JOIN event_packages ep ON ep.event_id = w.event_id AND ep.is_active = 1
+		WHERE w.user_id = ?
+		ORDER BY w.created_at DESC
+	`, c.GetInt64("user_id"))
+
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// AddToWishlist - Follow an event or a session (also SCHEDULED ones, to hear when they go live)
+// POST /user/wishlist
+func AddToWishlist(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	var input struct {
+		SessionID *int64 `json:"session_id"`
+		EventID   *int64 `json:"event_id"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || (input.SessionID == nil && input.EventID == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "session_id atau event_id diperlukan"})
+		return
+	}
+
+	var eventID int64
+	var status string
+	var err error
+	if input.SessionID != nil {
+		err = config.DB.QueryRow(`
+			SELECT e.id, COALESCE(e.publish_status, 'DRAFT') FROM sessions s JOIN events e ON e.id = s.event_id
+			WHERE s.id = ?
+		`, *input.SessionID).Scan(&eventID, &status)
+	} else {
+		err = config.DB.QueryRow("SELECT id, COALESCE(publish_status, 'DRAFT') FROM events WHERE id = ?", *input.EventID).Scan(&eventID, &status)
+	}
+	if err != nil || status == "DRAFT" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event atau sesi tidak ditemukan"})
+		return
+	}
+
+	_, err = config.DB.Exec(`
+		INSERT INTO wishlists (user_id, event_id, session_id) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE id = id
+	`, userID, eventID, input.SessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menambahkan ke wishlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Ditambahkan ke wishlist"})
+}
+
+// RemoveFromWishlist - Stop following a wishlist item
+// DELETE /user/wishlist/:id
+func RemoveFromWishlist(c *gin.Context) {
+	itemID, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	res, err := config.DB.Exec("DELETE FROM wishlists WHERE id = ? AND user_id = ?", itemID, c.GetInt64("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus item wishlist"})
+		return
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item wishlist tidak ditemukan"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Dihapus dari wishlist"})
+}
+
+// ===============================================
+// WISHLIST ALERTS
+// ===============================================
+
+// wishlistTarget is one wishlist item to alert
+type wishlistTarget struct {
+	WishlistID int64  `db:"wishlist_id"`
+	UserID     int64  `db:"user_id"`
+	Name       string `db:"name"`
+	Email      string `db:"email"`
+	ItemTitle  string `db:"item_title"`
+}
+
+const wishlistTargetQuery = `
+	SELECT w.id as wishlist_id, w.user_id, u.name, u.email, COALESCE(s.title, e.title) as item_title
+	FROM wishlists w
+	JOIN users u ON u.id = w.user_id
+	JOIN events e ON e.id = w.event_id
+	LEFT JOIN sessions s ON s.id = w.session_id`
+
+// sendWishlistAlerts notifies every target once per alert type and ref (in-app and email)
+func sendWishlistAlerts(targets []wishlistTarget, alertType, ref, heading string, message func(itemTitle string) string) int {
+	sent := 0
+	for _, t := range targets {
+		res, err := config.DB.Exec(`
+			INSERT IGNORE INTO wishlist_alerts (wishlist_id, alert_type, ref) VALUES (?, ?, ?)
+		`, t.WishlistID, alertType, ref)
+		if err != nil {
+			fmt.Printf("[WISHLIST] ❌ Error recording alert for wishlist %d: %v\n", t.WishlistID, err)
+			continue
+		}
+		if rows, _ := res.RowsAffected(); rows == 0 {
+			continue // already told about this one
+		}
+
+		msg := message(t.ItemTitle)
+		CreateNotification(t.UserID, "wishlist_"+alertTypeSlug(alertType), heading, msg)
+		if err := utils.SendWishlistAlertEmail(t.Email, t.Name, heading, msg); err != nil {
+			fmt.Printf("[WISHLIST] ⚠️ Failed to email %s: %v\n", t.Email, err)
+		}
+		sent++
+	}
+	return sent
+}
+
+// alertTypeSlug turns an alert type into the notification type suffix
+func alertTypeSlug(alertType string) string {
+	switch alertType {
+	case wishlistAlertPublished:
+		return "published"
+	case wishlistAlertPriceDrop:
+		return "price_drop"
+	default:
+		return "coupon"
+	}
+}
+
+// NotifyWishlistPublished alerts users whose wishlisted events or sessions were just published
+// (called by the auto-publish job)
+func NotifyWishlistPublished(eventIDs, sessionIDs []int64) {
+	for _, eventID := range eventIDs {
+		var targets []wishlistTarget
+		config.DB.Select(&targets, wishlistTargetQuery+" WHERE w.event_id = ?", eventID)
+		sendWishlistAlerts(targets, wishlistAlertPublished, "", "🎉 Wishlist Anda Sudah Tersedia", func(title string) string {
+			return fmt.Sprintf("\"%s\" sudah dipublikasikan dan bisa dibeli sekarang.", title)
+		})
+	}
+
+	for _, sessionID := range sessionIDs {
+		var targets []wishlistTarget
+		config.DB.Select(&targets, wishlistTargetQuery+" WHERE w.session_id = ? AND e.publish_status = 'PUBLISHED'", sessionID)
+		sendWishlistAlerts(targets, wishlistAlertPublished, "", "🎉 Wishlist Anda Sudah Tersedia", func(title string) string {
+			return fmt.Sprintf("Sesi \"%s\" sudah dipublikasikan dan bisa dibeli sekarang.", title)
+		})
+	}
+}
+
+// NotifyWishlistPriceDrop alerts users who wishlisted a session whose price went down
+// (ignored when the price did not drop)
+func NotifyWishlistPriceDrop(sessionID, oldPrice, newPrice int64) {
+	if newPrice >= oldPrice {
+		return
+	}
+
+	var targets []wishlistTarget
+	config.DB.Select(&targets, wishlistTargetQuery+`
+		WHERE w.session_id = ? AND s.publish_status = 'PUBLISHED' AND e.publish_status = 'PUBLISHED'
+	`, sessionID)
+	sent := sendWishlistAlerts(targets, wishlistAlertPriceDrop, fmt.Sprintf("%d", newPrice), "💸 Harga Turun", func(title string) string {
+		return fmt.Sprintf("Harga \"%s\" turun dari %s menjadi %s.", title, helpers.FormatRupiah(oldPrice), helpers.FormatRupiah(newPrice))
+	})
+	if sent > 0 {
+		fmt.Printf("[WISHLIST] 💸 Price drop of session %d sent to %d user(s)\n", sessionID, sent)
+	}
+}
+
+// notifyWishlistCoupon alerts users whose wishlisted items a newly available coupon applies to
+func notifyWishlistCoupon(couponID int64) {
+	var coupon models.Coupon
+	err := config.DB.Get(&coupon, "SELECT "+couponColumns+" FROM coupons c WHERE c.id = ?", couponID)
+	if err != nil || !coupon.IsActive || (coupon.ExpiresAt != nil && coupon.ExpiresAt.Before(time.Now())) {
+		return
+	}
+
+	var targets []wishlistTarget
+	config.DB.Select(&targets, wishlistTargetQuery+`
+		WHERE e.organization_id = ? AND e.publish_status = 'PUBLISHED'
+			AND (? = 'ORGANIZATION' OR (? = 'EVENT' AND w.event_id = ?) OR (? = 'SESSION' AND w.session_id = ?))
+	`, coupon.OrganizationID, coupon.Scope, coupon.Scope, coupon.EventID, coupon.Scope, coupon.SessionID)
+
+	discount := fmt.Sprintf("%.0f%%", coupon.DiscountValue)
+	if coupon.DiscountType == "FIXED" {
+		discount = fmt.Sprintf("Rp %.0f", coupon.DiscountValue)
+	}
+	from := ""
+	if coupon.StartsAt != nil && coupon.StartsAt.After(time.Now()) {
+		from = " mulai " + coupon.StartsAt.Format("02/01/2006")
+	}
+
+	sent := sendWishlistAlerts(targets, wishlistAlertCoupon, strconv.FormatInt(coupon.ID, 10), "🏷️ Kupon Untuk Wishlist Anda", func(title string) string {
+		return fmt.Sprintf("Gunakan kupon %s untuk diskon %s pada \"%s\"%s.", coupon.Code, discount, title, from)
+	})
+	if sent > 0 {
+		fmt.Printf("[WISHLIST] 🏷️ Coupon %s sent to %d user(s)\n", coupon.Code, sent)
+	}
+}
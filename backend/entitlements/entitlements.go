@@ -0,0 +1,205 @@
+// Package entitlements decides who may open a session's content.
+//
+// Every way of getting access (buying, packages, gifts, subscriptions, manual
+// admin grants) writes an entitlement row, and every access check goes
+// through HasAccess, so PENDING or FAILED purchases never unlock content.
+package entitlements
+
+import (
+	"fmt"
+	"time"
+
+	"BACKEND/config"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Grant sources
+const (
+	SourcePurchase     = "PURCHASE"
+	SourcePackage      = "PACKAGE"
+	SourceGift         = "GIFT"
+	SourceAdmin        = "ADMIN"
+	SourceSubscription = "SUBSCRIPTION"
+	SourceLicense      = "LICENSE"
+)
+
+// Grant describes access being given to one user for one session, for a
+// whole event (every session, including ones added later) when EventID is set,
+// or for every published event of an organization when OrganizationID is set
+type Grant struct {
+	UserID         int64
+	SessionID      int64
+	EventID        int64
+	OrganizationID int64
+	Source         string
+	SourceRef      string     // order ID, gift code, ... (used to revoke by source later)
+	ExpiresAt      *time.Time // nil = lifetime access
+	GrantedBy      *int64     // admin user ID for manual grants
+	Note           string
+}
+
+// HasAccess reports whether the user holds an active (started, not expired,
+// not revoked) entitlement for the session, for the event it belongs to when
+// the session is published, or for the organization running that event when
+// both the event and the session are published
+func HasAccess(userID, sessionID int64) bool {
+	var count int
+	err := config.DB.Get(&count, `
+		SELECT COUNT(*) FROM entitlements
+		WHERE user_id = ?
+			AND (session_id = ?
+				OR event_id = (SELECT event_id FROM sessions WHERE id = ? AND publish_status = 'PUBLISHED')
+				OR organization_id = (
+					SELECT e.organization_id FROM sessions s JOIN events e ON e.id = s.event_id
+					WHERE s.id = ? AND s.publish_status = 'PUBLISHED' AND e.publish_status = 'PUBLISHED'
+				))
+			AND revoked_at IS NULL
+			AND starts_at <= NOW()
+			AND (expires_at IS NULL OR expires_at > NOW())
+	`, userID, sessionID, sessionID, sessionID)
+	if err != nil {
+		fmt.Printf("[ENTITLEMENT] ❌ Error checking access user=%d session=%d: %v\n", userID, sessionID, err)
+		return false
+	}
+	return count > 0
+}
+
+// GrantAccess creates (or re-activates) an entitlement. Granting the same
+// target and source/ref twice is a no-op apart from refreshing expiry, so
+// payment handlers can call it safely on retries.
+func GrantAccess(db sqlx.Ext, g Grant) error {
+	var note *string
+	if g.Note != "" {
+		note = &g.Note
+	}
+	sessionID, eventID, orgID := nullableID(g.SessionID), nullableID(g.EventID), nullableID(g.OrganizationID)
+
+	// Event- and organization-level rows have a NULL session_id, which a UNIQUE
+	// key cannot dedupe, so look the row up with the null-safe <=> first
+	var existingID int64
+	err := sqlx.Get(db, &existingID, `
+		SELECT id FROM entitlements
+		WHERE user_id = ? AND session_id <=> ? AND event_id <=> ? AND organization_id <=> ? AND source = ? AND source_ref = ?
+		LIMIT 1
+	`, g.UserID, sessionID, eventID, orgID, g.Source, g.SourceRef)
+	if err == nil {
+		_, err = db.Exec(`
+			UPDATE entitlements
+			SET expires_at = ?, granted_by = ?, note = ?, revoked_at = NULL, revoked_by = NULL, revoke_reason = NULL
+			WHERE id = ?
+		`, g.ExpiresAt, g.GrantedBy, note, existingID)
+		if err != nil {
+			return fmt.Errorf("failed to grant access: %v", err)
+		}
+		return nil
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO entitlements (user_id, session_id, event_id, organization_id, source, source_ref, starts_at, expires_at, granted_by, note)
+		VALUES (?, ?, ?, ?, ?, ?, NOW(), ?, ?, ?)
+	`, g.UserID, sessionID, eventID, orgID, g.Source, g.SourceRef, g.ExpiresAt, g.GrantedBy, note)
+	if err != nil {
+		return fmt.Errorf("failed to grant access: %v", err)
+	}
+	return nil
+}
+
+// nullableID maps an unset (zero) ID to NULL
+func nullableID(id int64) *int64 {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
+
+// GrantOrderAccess grants entitlements for every PAID purchase line of an order:
+// PURCHASE for single sessions and event-level PACKAGE for event packages.
+// Gift and license pool lines are skipped, their seats are granted when a gift
+// code is redeemed or a seat is assigned.
+func GrantOrderAccess(db sqlx.Execer, orderID string) error {
+	_, err := db.Exec(`
+		INSERT INTO entitlements (user_id, session_id, source, source_ref, starts_at)
+		SELECT user_id, session_id, ?, order_id, NOW()
+		FROM purchases
+		WHERE order_id = ? AND status = 'PAID' AND session_id IS NOT NULL AND COALESCE(is_gift, 0) = 0
+		ON DUPLICATE KEY UPDATE revoked_at = NULL, revoked_by = NULL, revoke_reason = NULL
+	`, SourcePurchase, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to grant order access: %v", err)
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO entitlements (user_id, event_id, source, source_ref, starts_at)
+		SELECT p.user_id, p.event_id, ?, p.order_id, NOW()
+		FROM purchases p
+		WHERE p.order_id = ? AND p.status = 'PAID' AND p.session_id IS NULL AND p.event_id IS NOT NULL
+			AND COALESCE(p.is_gift, 0) = 0 AND p.license_pool_id IS NULL
+			AND NOT EXISTS (
+				SELECT 1 FROM entitlements en
+				WHERE en.user_id = p.user_id AND en.event_id = p.event_id
+					AND en.source = ? AND en.source_ref = p.order_id
+			)
+	`, SourcePackage, orderID, SourcePackage)
+	if err != nil {
+		return fmt.Errorf("failed to grant package access: %v", err)
+	}
+	return nil
+}
+
+// HasEventAccess reports whether the user holds an active event-level entitlement
+// (e.g. already owns the event package) or a subscription to its organization
+func HasEventAccess(userID, eventID int64) bool {
+	var count int
+	config.DB.Get(&count, `
+		SELECT COUNT(*) FROM entitlements
+		WHERE user_id = ?
+			AND (event_id = ? OR organization_id = (
+				SELECT organization_id FROM events WHERE id = ? AND publish_status = 'PUBLISHED'
+			))
+			AND revoked_at IS NULL
+			AND starts_at <= NOW()
+			AND (expires_at IS NULL OR expires_at > NOW())
+	`, userID, eventID, eventID)
+	return count > 0
+}
+
+// Revoke revokes one entitlement by ID. It returns false when the entitlement
+// does not exist or was already revoked.
+func Revoke(db sqlx.Execer, id int64, revokedBy *int64, reason string) (bool, error) {
+	res, err := db.Exec(`
+		UPDATE entitlements SET revoked_at = NOW(), revoked_by = ?, revoke_reason = ?
+		WHERE id = ? AND revoked_at IS NULL
+	`, revokedBy, reason, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke access: %v", err)
+	}
+	rows, _ := res.RowsAffected()
+	return rows > 0, nil
+}
+
+// RevokeBySource revokes every entitlement created by one source reference
+// (e.g. all sessions of a refunded order)
+func RevokeBySource(db sqlx.Execer, source, sourceRef, reason string) (int64, error) {
+	res, err := db.Exec(`
+		UPDATE entitlements SET revoked_at = NOW(), revoke_reason = ?
+		WHERE source = ? AND source_ref = ? AND revoked_at IS NULL
+	`, reason, source, sourceRef)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke access: %v", err)
+	}
+	return res.RowsAffected()
+}
+
+// RevokeUserBySource revokes one user's entitlements from a source reference
+// (e.g. a seat taken back from a license pool)
+func RevokeUserBySource(db sqlx.Execer, userID int64, source, sourceRef, reason string) (int64, error) {
+	res, err := db.Exec(`
+		UPDATE entitlements SET revoked_at = NOW(), revoke_reason = ?
+		WHERE user_id = ? AND source = ? AND source_ref = ? AND revoked_at IS NULL
+	`, reason, userID, source, sourceRef)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke access: %v", err)
+	}
+	return res.RowsAffected()
+}
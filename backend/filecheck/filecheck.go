@@ -0,0 +1,159 @@
+package filecheck
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"BACKEND/helpers"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// Kind describes what an upload slot accepts
+type Kind struct {
+	Label         string
+	MaxBytes      int64
+	Types         []string
+	Extensions    []string
+	StripMetadata bool
+}
+
+var imageTypes = []string{"image/jpeg", "image/png", "image/webp"}
+var imageExtensions = []string{".jpg", ".jpeg", ".png", ".webp"}
+
+func imageKind(label string, maxBytes int64) Kind {
+	return Kind{Label: label, MaxBytes: maxBytes, Types: imageTypes, Extensions: imageExtensions, StripMetadata: true}
+}
+
+var (
+	Video = Kind{
+		Label:      "video",
+		MaxBytes:   5 << 30,
+		Types:      []string{"video/mp4", "video/quicktime", "video/x-m4v", "video/x-matroska", "video/webm"},
+		Extensions: helpers.ResumableVideoExtensions,
+	}
+	Document = Kind{
+		Label:    "dokumen",
+		MaxBytes: 50 << 20,
+		Types: []string{
+			"application/pdf",
+			"application/msword",
+			"application/vnd.ms-powerpoint",
+			"application/x-ole-storage",
+			"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+			"application/vnd.openxmlformats-officedocument.presentationml.presentation",
+		},
+		Extensions: []string{".pdf", ".ppt", ".pptx", ".doc", ".docx"},
+	}
+	Thumbnail    = imageKind("thumbnail", 5<<20)
+	Logo         = imageKind("logo", 2<<20)
+	ProfileImage = imageKind("foto profil", 2<<20)
+	AdBanner     = imageKind("banner iklan", 5<<20)
+	ReportPhoto  = imageKind("foto laporan", 5<<20)
+)
+
+// Error is a rejected upload; Message is safe to show to the user
+type Error struct {
+	Status  int
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// File is an upload that passed Check and can be stored as is
+type File struct {
+	Body        io.Reader
+	Size        int64
+	ContentType string
+}
+
+func (k Kind) allowsExtension(ext string) bool {
+	for _, allowed := range k.Extensions {
+		if ext == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func (k Kind) allowsType(m *mimetype.MIME) bool {
+	for _, t := range k.Types {
+		if m.Is(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check sniffs the content of src, enforces the limits of kind, strips image
+// metadata and runs the scanner. The client's filename must carry an extension
+// the kind accepts (a missing one is refused too) but its Content-Type header is
+// never trusted.
+func Check(ctx context.Context, kind Kind, scanner Scanner, filename string, src io.ReadSeeker, size int64) (*File, error) {
+	if size <= 0 {
+		return nil, &Error{Status: http.StatusBadRequest, Message: "File kosong"}
+	}
+	if size > kind.MaxBytes {
+		return nil, &Error{Status: http.StatusRequestEntityTooLarge, Message: fmt.Sprintf("Ukuran %s maksimal %s", kind.Label, helpers.FormatBytes(kind.MaxBytes))}
+	}
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	if !kind.allowsExtension(ext) {
+		return nil, &Error{Status: http.StatusUnsupportedMediaType, Message: fmt.Sprintf("Format %s tidak didukung (%s)", kind.Label, strings.Join(kind.Extensions, ", "))}
+	}
+
+	detected, err := mimetype.DetectReader(src)
+	if err != nil {
+		return nil, &Error{Status: http.StatusBadRequest, Message: "File tidak bisa dibaca", Err: err}
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if !kind.allowsType(detected) {
+		return nil, &Error{Status: http.StatusUnsupportedMediaType, Message: fmt.Sprintf("Isi file bukan %s yang valid (terdeteksi %s)", kind.Label, detected.String())}
+	}
+	contentType, _, _ := strings.Cut(detected.String(), ";")
+
+	body := src
+	if kind.StripMetadata {
+		data, err := io.ReadAll(io.LimitReader(src, kind.MaxBytes))
+		if err != nil {
+			return nil, err
+		}
+		cleaned, err := StripMetadata(contentType, data)
+		if err != nil {
+			return nil, &Error{Status: http.StatusUnsupportedMediaType, Message: "Gambar rusak atau tidak bisa dibaca", Err: err}
+		}
+		body = bytes.NewReader(cleaned)
+		size = int64(len(cleaned))
+	}
+
+	if scanner != nil {
+		err := scanner.Scan(ctx, body)
+		if errors.Is(err, ErrInfected) {
+			return nil, &Error{Status: http.StatusUnprocessableEntity, Message: "File terdeteksi mengandung malware dan ditolak", Err: err}
+		}
+		if err != nil {
+			return nil, &Error{Status: http.StatusServiceUnavailable, Message: "Pemindaian file sedang tidak tersedia, coba lagi nanti", Err: err}
+		}
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+
+	return &File{Body: body, Size: size, ContentType: contentType}, nil
+}
@@ -0,0 +1,253 @@
+package filecheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4)))
+	return buf.Bytes()
+}
+
+func testJPEG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4)), nil)
+	return buf.Bytes()
+}
+
+func checkStatus(t *testing.T, err error, want int) {
+	t.Helper()
+	var fe *Error
+	if !errors.As(err, &fe) || fe.Status != want {
+		t.Errorf("Expected a %d rejection, got %v", want, err)
+	}
+}
+
+func TestCheck_SniffsContent(t *testing.T) {
+	ctx := context.Background()
+	img := testPNG(t)
+
+	f, err := Check(ctx, Thumbnail, nil, "poster.png", bytes.NewReader(img), int64(len(img)))
+	if err != nil || f.ContentType != "image/png" {
+		t.Fatalf("Expected a valid PNG, got %+v (%v)", f, err)
+	}
+
+	// Extension and Content-Type lie about the content
+	script := []byte("#!/bin/sh\nrm -rf /\n")
+	_, err = Check(ctx, Thumbnail, nil, "poster.jpg", bytes.NewReader(script), int64(len(script)))
+	checkStatus(t, err, http.StatusUnsupportedMediaType)
+
+	_, err = Check(ctx, Document, nil, "modul.pdf", bytes.NewReader(img), int64(len(img)))
+	checkStatus(t, err, http.StatusUnsupportedMediaType)
+
+	_, err = Check(ctx, Thumbnail, nil, "poster.exe", bytes.NewReader(img), int64(len(img)))
+	checkStatus(t, err, http.StatusUnsupportedMediaType)
+
+	// Without an extension there is nothing to match the content against
+	_, err = Check(ctx, Thumbnail, nil, "poster", bytes.NewReader(img), int64(len(img)))
+	checkStatus(t, err, http.StatusUnsupportedMediaType)
+
+	pdf := []byte("%PDF-1.4\n1 0 obj\n<<>>\nendobj\n%%EOF\n")
+	if _, err := Check(ctx, Document, nil, "modul.pdf", bytes.NewReader(pdf), int64(len(pdf))); err != nil {
+		t.Errorf("Expected a PDF to pass, got %v", err)
+	}
+
+	mp4 := append([]byte("\x00\x00\x00\x18ftypisom\x00\x00\x02\x00isomiso2"), make([]byte, 64)...)
+	if _, err := Check(ctx, Video, nil, "kuliah.mp4", bytes.NewReader(mp4), int64(len(mp4))); err != nil {
+		t.Errorf("Expected an MP4 to pass, got %v", err)
+	}
+}
+
+func TestCheck_SizeLimits(t *testing.T) {
+	img := testPNG(t)
+	small := imageKind("logo", int64(len(img)-1))
+
+	_, err := Check(context.Background(), small, nil, "logo.png", bytes.NewReader(img), int64(len(img)))
+	checkStatus(t, err, http.StatusRequestEntityTooLarge)
+
+	_, err = Check(context.Background(), Logo, nil, "logo.png", bytes.NewReader(nil), 0)
+	checkStatus(t, err, http.StatusBadRequest)
+}
+
+// withJPEGSegments inserts raw segments right after SOI
+func withJPEGSegments(img []byte, segments ...[]byte) []byte {
+	out := append([]byte{}, img[:2]...)
+	for _, s := range segments {
+		out = append(out, s...)
+	}
+	return append(out, img[2:]...)
+}
+
+func jpegSegment(marker byte, payload []byte) []byte {
+	s := []byte{0xFF, marker, 0, 0}
+	binary.BigEndian.PutUint16(s[2:], uint16(len(payload)+2))
+	return append(s, payload...)
+}
+
+func TestStripMetadata_JPEG(t *testing.T) {
+	// Little-endian EXIF with orientation 6 and a GPS-like string
+	exif := []byte("Exif\x00\x00II\x2A\x00\x08\x00\x00\x00\x01\x00\x12\x01\x03\x00\x01\x00\x00\x00\x06\x00\x00\x00\x00\x00\x00\x00-6.2088,106.8456")
+	img := withJPEGSegments(testJPEG(t),
+		jpegSegment(0xE1, exif),
+		jpegSegment(0xFE, []byte("Kamera milik Budi")),
+	)
+	img = append(img, []byte("trailing payload")...)
+
+	out, err := StripMetadata("image/jpeg", img)
+	if err != nil {
+		t.Fatalf("StripMetadata: %v", err)
+	}
+	for _, secret := range []string{"106.8456", "Budi", "trailing payload"} {
+		if bytes.Contains(out, []byte(secret)) {
+			t.Errorf("Expected %q to be removed", secret)
+		}
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(out)); err != nil {
+		t.Fatalf("Expected a decodable JPEG, got %v", err)
+	}
+	if o := exifOrientation(out[6:]); o != 6 {
+		t.Errorf("Expected the orientation to be kept, got %d", o)
+	}
+
+	if _, err := StripMetadata("image/jpeg", img[:40]); err == nil {
+		t.Error("Expected a truncated JPEG to be rejected")
+	}
+}
+
+func TestStripMetadata_PNG(t *testing.T) {
+	img := testPNG(t)
+	text := []byte{0, 0, 0, 11}
+	text = append(text, []byte("tEXtAuthor\x00Budi")...)
+	text = append(text, 0, 0, 0, 0)
+	// After the 8 byte signature and the 25 byte IHDR chunk
+	img = append(append(append([]byte{}, img[:33]...), text...), img[33:]...)
+
+	out, err := StripMetadata("image/png", img)
+	if err != nil {
+		t.Fatalf("StripMetadata: %v", err)
+	}
+	if bytes.Contains(out, []byte("Budi")) {
+		t.Error("Expected the text chunk to be removed")
+	}
+	if _, err := png.Decode(bytes.NewReader(out)); err != nil {
+		t.Fatalf("Expected a decodable PNG, got %v", err)
+	}
+}
+
+func TestStripMetadata_WebP(t *testing.T) {
+	vp8x := []byte("VP8X\x0A\x00\x00\x00\x08\x00\x00\x00\x03\x00\x00\x03\x00\x00")
+	exif := []byte("EXIF\x05\x00\x00\x00Budi!\x00")
+	frame := []byte("VP8L\x04\x00\x00\x00abcd")
+	body := append(append(append([]byte("WEBP"), vp8x...), exif...), frame...)
+	img := append([]byte("RIFF\x00\x00\x00\x00"), body...)
+	binary.LittleEndian.PutUint32(img[4:], uint32(len(body)))
+
+	out, err := StripMetadata("image/webp", img)
+	if err != nil {
+		t.Fatalf("StripMetadata: %v", err)
+	}
+	if bytes.Contains(out, []byte("Budi")) || out[20]&0x08 != 0 {
+		t.Errorf("Expected the EXIF chunk and flag to be removed, got %q", out)
+	}
+	if int(binary.LittleEndian.Uint32(out[4:])) != len(out)-8 {
+		t.Error("Expected the RIFF size to be updated")
+	}
+}
+
+// fakeClamd answers INSTREAM requests, flagging streams that contain "EICAR"
+func fakeClamd(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				cmd := make([]byte, len("zINSTREAM\x00"))
+				if _, err := io.ReadFull(conn, cmd); err != nil || string(cmd) != "zINSTREAM\x00" {
+					conn.Write([]byte("UNKNOWN COMMAND\x00"))
+					return
+				}
+				var data []byte
+				for {
+					var size uint32
+					if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+						return
+					}
+					if size == 0 {
+						break
+					}
+					chunk := make([]byte, size)
+					io.ReadFull(conn, chunk)
+					data = append(data, chunk...)
+				}
+				if bytes.Contains(data, []byte("EICAR")) {
+					conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+					return
+				}
+				conn.Write([]byte("stream: OK\x00"))
+			}(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestClamAV_Scan(t *testing.T) {
+	ctx := context.Background()
+	scanner := NewClamAV("tcp://" + fakeClamd(t))
+
+	if err := scanner.Scan(ctx, strings.NewReader(strings.Repeat("bersih ", 20000))); err != nil {
+		t.Errorf("Expected a clean stream, got %v", err)
+	}
+
+	err := scanner.Scan(ctx, strings.NewReader("X5O!P%@AP[4\\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*"))
+	if !errors.Is(err, ErrInfected) || !strings.Contains(err.Error(), "Eicar-Test-Signature") {
+		t.Errorf("Expected an infected stream, got %v", err)
+	}
+
+	// Only the first MaxBytes are sent
+	scanner.MaxBytes = 10
+	if err := scanner.Scan(ctx, strings.NewReader("0123456789EICAR")); err != nil {
+		t.Errorf("Expected the tail past MaxBytes to be skipped, got %v", err)
+	}
+}
+
+func TestCheck_RunsScanner(t *testing.T) {
+	pdf := []byte("%PDF-1.4\nEICAR\n%%EOF\n")
+	_, err := Check(context.Background(), Document, NewClamAV(fakeClamd(t)), "modul.pdf", bytes.NewReader(pdf), int64(len(pdf)))
+	checkStatus(t, err, http.StatusUnprocessableEntity)
+
+	down := NewClamAV("unix:///nonexistent/clamd.sock")
+	_, err = Check(context.Background(), Document, down, "modul.pdf", bytes.NewReader(pdf), int64(len(pdf)))
+	checkStatus(t, err, http.StatusServiceUnavailable)
+
+	f, err := Check(context.Background(), Document, Noop{}, "modul.pdf", bytes.NewReader(pdf), int64(len(pdf)))
+	if err != nil {
+		t.Fatalf("Expected the no-op scanner to accept, got %v", err)
+	}
+	if body, _ := io.ReadAll(f.Body); !bytes.Equal(body, pdf) {
+		t.Errorf("Expected the body to be rewound after scanning, got %q", body)
+	}
+}
@@ -0,0 +1,225 @@
+package filecheck
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+// ErrCorruptImage is returned when an image cannot be parsed for metadata removal
+var ErrCorruptImage = errors.New("corrupt image")
+
+// StripMetadata removes EXIF, XMP and text metadata (GPS position, camera,
+// author) from JPEG, PNG and WebP images. Pixel data is copied untouched; a
+// JPEG keeps only its orientation so it still displays the right way up.
+// Other content types are returned unchanged.
+func StripMetadata(contentType string, data []byte) ([]byte, error) {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEG(data)
+	case "image/png":
+		return stripPNG(data)
+	case "image/webp":
+		return stripWebP(data)
+	}
+	return data, nil
+}
+
+func stripJPEG(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, ErrCorruptImage
+	}
+
+	var kept []byte
+	orientation := 0
+	i := 2
+	for {
+		if i+1 >= len(data) || data[i] != 0xFF {
+			return nil, ErrCorruptImage
+		}
+		// Fill bytes may pad a marker
+		if data[i+1] == 0xFF {
+			i++
+			continue
+		}
+		marker := data[i+1]
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			kept = append(kept, 0xFF, marker)
+			i += 2
+			continue
+		}
+		if marker == 0xD9 {
+			kept = append(kept, 0xFF, 0xD9)
+			break
+		}
+		if i+4 > len(data) {
+			return nil, ErrCorruptImage
+		}
+		end := i + 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+		if end < i+4 || end > len(data) {
+			return nil, ErrCorruptImage
+		}
+
+		switch marker {
+		case 0xE1: // EXIF or XMP
+			if o := exifOrientation(data[i+4 : end]); o > 1 {
+				orientation = o
+			}
+		case 0xED, 0xFE: // Photoshop/IPTC, comment
+		case 0xDA:
+			// Entropy-coded data runs to the last EOI; anything appended after it is dropped
+			eoi := bytes.LastIndex(data[i:], []byte{0xFF, 0xD9})
+			if eoi < 0 {
+				return nil, ErrCorruptImage
+			}
+			kept = append(kept, data[i:i+eoi+2]...)
+			return assembleJPEG(kept, orientation), nil
+		default:
+			kept = append(kept, data[i:end]...)
+		}
+		i = end
+	}
+	return assembleJPEG(kept, orientation), nil
+}
+
+func assembleJPEG(segments []byte, orientation int) []byte {
+	out := make([]byte, 0, len(segments)+40)
+	out = append(out, 0xFF, 0xD8)
+	if orientation > 1 {
+		out = append(out, orientationSegment(orientation)...)
+	}
+	return append(out, segments...)
+}
+
+// JPEGOrientation returns the EXIF orientation (1-8) of a JPEG; 1 when absent
+func JPEGOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data) && data[i] == 0xFF; {
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 {
+			break
+		}
+		end := i + 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+		if end < i+4 || end > len(data) {
+			break
+		}
+		if marker == 0xE1 {
+			if o := exifOrientation(data[i+4 : end]); o > 0 {
+				return o
+			}
+		}
+		i = end
+	}
+	return 1
+}
+
+// exifOrientation reads the orientation tag from an APP1 EXIF payload; 0 when absent
+func exifOrientation(payload []byte) int {
+	if !bytes.HasPrefix(payload, []byte("Exif\x00\x00")) {
+		return 0
+	}
+	tiff := payload[6:]
+	if len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 0
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for k := 0; k < count; k++ {
+		entry := ifd + 2 + 12*k
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			if v := int(order.Uint16(tiff[entry+8:])); v >= 1 && v <= 8 {
+				return v
+			}
+		}
+	}
+	return 0
+}
+
+// orientationSegment is an APP1 EXIF segment holding nothing but the orientation tag
+func orientationSegment(orientation int) []byte {
+	payload := []byte("Exif\x00\x00MM\x00\x2A\x00\x00\x00\x08")
+	payload = append(payload, 0x00, 0x01) // one IFD entry
+	payload = append(payload, 0x01, 0x12, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01)
+	payload = append(payload, 0x00, byte(orientation), 0x00, 0x00)
+	payload = append(payload, 0x00, 0x00, 0x00, 0x00) // no next IFD
+
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, ErrCorruptImage
+	}
+	out := append([]byte{}, pngSignature...)
+	i := len(pngSignature)
+	for i+12 <= len(data) {
+		end := i + 12 + int(binary.BigEndian.Uint32(data[i:]))
+		if end < i+12 || end > len(data) {
+			return nil, ErrCorruptImage
+		}
+		chunk := string(data[i+4 : i+8])
+		switch chunk {
+		case "eXIf", "tEXt", "zTXt", "iTXt", "tIME":
+		default:
+			out = append(out, data[i:end]...)
+		}
+		if chunk == "IEND" {
+			return out, nil
+		}
+		i = end
+	}
+	return nil, ErrCorruptImage
+}
+
+func stripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, ErrCorruptImage
+	}
+	out := append([]byte{}, data[:12]...)
+	i := 12
+	for i+8 <= len(data) {
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		end := i + 8 + size + size%2
+		if end > len(data) && i+8+size == len(data) {
+			end = len(data) // missing pad byte on the last chunk
+		}
+		if end < i+8 || end > len(data) {
+			return nil, ErrCorruptImage
+		}
+		switch string(data[i : i+4]) {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := append([]byte{}, data[i:end]...)
+			if size > 0 {
+				chunk[8] &^= 0x0C // clear the EXIF and XMP flags
+			}
+			out = append(out, chunk...)
+		default:
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, nil
+}
@@ -0,0 +1,122 @@
+package filecheck
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// ErrInfected is returned (wrapped with the signature name) when a scanner finds malware
+var ErrInfected = errors.New("malware detected")
+
+// Scanner inspects an upload before it is stored
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) error
+}
+
+// Noop accepts everything; used when no scanner is configured
+type Noop struct{}
+
+func (Noop) Scan(ctx context.Context, r io.Reader) error { return nil }
+
+// DefaultClamAVMaxBytes matches clamd's default StreamMaxLength (25 MB)
+const DefaultClamAVMaxBytes = 25 << 20
+
+const clamAVChunkSize = 64 << 10
+
+// ClamAV streams uploads to clamd with the INSTREAM command. Only the first
+// MaxBytes are sent so large videos don't hit clamd's StreamMaxLength; keep it
+// at or below that setting.
+type ClamAV struct {
+	Network  string
+	Address  string
+	Timeout  time.Duration
+	MaxBytes int64
+}
+
+// NewClamAV parses a clamd address: "unix:///run/clamav/clamd.ctl",
+// "tcp://clamav:3310" or a bare "host:port"
+func NewClamAV(address string) *ClamAV {
+	c := &ClamAV{Network: "tcp", Address: address, Timeout: 30 * time.Second, MaxBytes: DefaultClamAVMaxBytes}
+	if rest, ok := strings.CutPrefix(address, "unix://"); ok {
+		c.Network, c.Address = "unix", rest
+	} else if rest, ok := strings.CutPrefix(address, "tcp://"); ok {
+		c.Address = rest
+	}
+	return c
+}
+
+func (c *ClamAV) Scan(ctx context.Context, r io.Reader) error {
+	dialer := net.Dialer{Timeout: c.Timeout}
+	conn, err := dialer.DialContext(ctx, c.Network, c.Address)
+	if err != nil {
+		return fmt.Errorf("clamav: %v", err)
+	}
+	defer conn.Close()
+
+	deadline := func() {
+		if d, ok := ctx.Deadline(); ok {
+			conn.SetDeadline(d)
+		} else if c.Timeout > 0 {
+			conn.SetDeadline(time.Now().Add(c.Timeout))
+		}
+	}
+	deadline()
+
+	writeErr := c.stream(conn, r, deadline)
+
+	// clamd answers (and closes) early when it rejects the stream, so read the
+	// reply even if writing failed
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	reply = strings.TrimRight(reply, "\x00\n")
+	if reply == "" {
+		if writeErr != nil {
+			return fmt.Errorf("clamav: %v", writeErr)
+		}
+		return fmt.Errorf("clamav: no reply: %v", err)
+	}
+
+	switch {
+	case strings.HasSuffix(reply, " OK"):
+		return nil
+	case strings.HasSuffix(reply, " FOUND"):
+		signature := strings.TrimSuffix(strings.TrimPrefix(reply, "stream: "), " FOUND")
+		return fmt.Errorf("%w: %s", ErrInfected, signature)
+	}
+	return fmt.Errorf("clamav: %s", reply)
+}
+
+func (c *ClamAV) stream(conn net.Conn, r io.Reader, deadline func()) error {
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return err
+	}
+	if c.MaxBytes > 0 {
+		r = io.LimitReader(r, c.MaxBytes)
+	}
+
+	buf := make([]byte, 4+clamAVChunkSize)
+	for {
+		n, err := io.ReadFull(r, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf, uint32(n))
+			deadline()
+			if _, werr := conn.Write(buf[:4+n]); werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err := conn.Write([]byte{0, 0, 0, 0})
+	return err
+}
@@ -0,0 +1,78 @@
+package helpers
+
+import "fmt"
+
+// Cart warning codes returned to the client after validation
+const (
+	CartItemUnavailable      = "UNAVAILABLE"        // unpublished, deleted or no longer sold: removed
+	CartItemAlreadyOwned     = "ALREADY_OWNED"      // buyer already has access: removed
+	CartItemCoveredByPackage = "COVERED_BY_PACKAGE" // the event package in the same cart covers it: removed
+	CartItemPriceChanged     = "PRICE_CHANGED"      // repriced to the current catalog price
+)
+
+// CartLine is one cart item next to the current catalog state
+type CartLine struct {
+	ItemID       int64
+	ItemType     string // SESSION, EVENT_PACKAGE, LICENSE_POOL
+	SessionID    int64
+	EventID      int64
+	IsGift       bool
+	Title        string
+	CartPrice    int64  // price stored when the item was added, in minor units
+	CurrentPrice *int64 // nil when the item can no longer be bought
+	Owned        bool   // buyer already has access (own purchases only)
+}
+
+// CartWarning tells the client what validation changed in the cart
+type CartWarning struct {
+	ItemID   int64  `json:"item_id"`
+	Code     string `json:"code"`
+	Title    string `json:"title"`
+	Message  string `json:"message"`
+	OldPrice *int64 `json:"old_price,omitempty"` // set on price changes only, a new price of 0 means free
+	NewPrice *int64 `json:"new_price,omitempty"`
+	Removed  bool   `json:"removed"`
+}
+
+// ValidateCartLines decides, for every line, whether it is removed, repriced or kept.
+// Only lines with a warning change; the rest of the cart stays as it is.
+func ValidateCartLines(lines []CartLine) []CartWarning {
+	warnings := []CartWarning{}
+
+	// Events whose package is bought for the buyer themselves in this cart
+	packagedEvents := map[int64]bool{}
+	for _, l := range lines {
+		if l.ItemType == "EVENT_PACKAGE" && !l.IsGift && l.CurrentPrice != nil && !l.Owned {
+			packagedEvents[l.EventID] = true
+		}
+	}
+
+	for _, l := range lines {
+		switch {
+		case l.CurrentPrice == nil:
+			warnings = append(warnings, CartWarning{
+				ItemID: l.ItemID, Code: CartItemUnavailable, Title: l.Title, Removed: true,
+				Message: fmt.Sprintf("\"%s\" sudah tidak tersedia dan dihapus dari keranjang", l.Title),
+			})
+		case l.Owned && !l.IsGift:
+			warnings = append(warnings, CartWarning{
+				ItemID: l.ItemID, Code: CartItemAlreadyOwned, Title: l.Title, Removed: true,
+				Message: fmt.Sprintf("Anda sudah memiliki akses ke \"%s\", item dihapus dari keranjang", l.Title),
+			})
+		case l.ItemType == "SESSION" && !l.IsGift && packagedEvents[l.EventID]:
+			warnings = append(warnings, CartWarning{
+				ItemID: l.ItemID, Code: CartItemCoveredByPackage, Title: l.Title, Removed: true,
+				Message: fmt.Sprintf("\"%s\" sudah termasuk dalam paket event di keranjang, item dihapus", l.Title),
+			})
+		case *l.CurrentPrice != l.CartPrice:
+			oldPrice, newPrice := l.CartPrice, *l.CurrentPrice
+			warnings = append(warnings, CartWarning{
+				ItemID: l.ItemID, Code: CartItemPriceChanged, Title: l.Title,
+				OldPrice: &oldPrice, NewPrice: &newPrice,
+				Message: fmt.Sprintf("Harga \"%s\" berubah dari %s menjadi %s", l.Title, FormatRupiah(l.CartPrice), FormatRupiah(*l.CurrentPrice)),
+			})
+		}
+	}
+
+	return warnings
+}
@@ -0,0 +1,70 @@
+package helpers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func priceOf(v int64) *int64 { return &v }
+
+func TestValidateCartLines(t *testing.T) {
+	lines := []CartLine{
+		{ItemID: 1, ItemType: "SESSION", SessionID: 1, EventID: 1, Title: "Unchanged", CartPrice: 50000, CurrentPrice: priceOf(50000)},
+		{ItemID: 2, ItemType: "SESSION", SessionID: 2, EventID: 1, Title: "Repriced", CartPrice: 50000, CurrentPrice: priceOf(40000)},
+		{ItemID: 3, ItemType: "SESSION", SessionID: 3, EventID: 1, Title: "Unpublished", CartPrice: 50000},
+		{ItemID: 4, ItemType: "SESSION", SessionID: 4, EventID: 1, Title: "Owned", CartPrice: 50000, CurrentPrice: priceOf(50000), Owned: true},
+		{ItemID: 5, ItemType: "SESSION", SessionID: 4, EventID: 1, Title: "Owned gift", IsGift: true, CartPrice: 50000, CurrentPrice: priceOf(50000), Owned: true},
+	}
+
+	warnings := ValidateCartLines(lines)
+	if len(warnings) != 3 {
+		t.Fatalf("Expected 3 warnings, got %d: %+v", len(warnings), warnings)
+	}
+
+	expected := map[int64]string{2: CartItemPriceChanged, 3: CartItemUnavailable, 4: CartItemAlreadyOwned}
+	for _, w := range warnings {
+		if expected[w.ItemID] != w.Code {
+			t.Errorf("Item %d: expected %s, got %s", w.ItemID, expected[w.ItemID], w.Code)
+		}
+		if w.Removed != (w.Code != CartItemPriceChanged) {
+			t.Errorf("Item %d: unexpected removed=%v for %s", w.ItemID, w.Removed, w.Code)
+		}
+	}
+	if warnings[0].OldPrice == nil || *warnings[0].OldPrice != 50000 || warnings[0].NewPrice == nil || *warnings[0].NewPrice != 40000 {
+		t.Errorf("Expected old/new price on the reprice warning, got %+v", warnings[0])
+	}
+	if warnings[1].NewPrice != nil {
+		t.Errorf("Expected no price on a removal warning, got %+v", warnings[1])
+	}
+}
+
+func TestValidateCartLines_RepricedToFree(t *testing.T) {
+	lines := []CartLine{
+		{ItemID: 1, ItemType: "SESSION", SessionID: 1, EventID: 1, Title: "Now free", CartPrice: 50000, CurrentPrice: priceOf(0)},
+	}
+
+	warnings := ValidateCartLines(lines)
+	if len(warnings) != 1 || warnings[0].NewPrice == nil || *warnings[0].NewPrice != 0 {
+		t.Fatalf("Expected a reprice warning to 0, got %+v", warnings)
+	}
+
+	body, _ := json.Marshal(warnings[0])
+	if !strings.Contains(string(body), `"new_price":0`) {
+		t.Errorf("Expected new_price 0 in the response, got %s", body)
+	}
+}
+
+func TestValidateCartLines_PackageCoversSessions(t *testing.T) {
+	lines := []CartLine{
+		{ItemID: 1, ItemType: "SESSION", SessionID: 1, EventID: 1, Title: "Session", CartPrice: 50000, CurrentPrice: priceOf(50000)},
+		{ItemID: 2, ItemType: "SESSION", SessionID: 1, EventID: 1, Title: "Gifted session", IsGift: true, CartPrice: 50000, CurrentPrice: priceOf(50000)},
+		{ItemID: 3, ItemType: "EVENT_PACKAGE", EventID: 1, Title: "Package", CartPrice: 120000, CurrentPrice: priceOf(120000)},
+		{ItemID: 4, ItemType: "SESSION", SessionID: 9, EventID: 2, Title: "Other event", CartPrice: 50000, CurrentPrice: priceOf(50000)},
+	}
+
+	warnings := ValidateCartLines(lines)
+	if len(warnings) != 1 || warnings[0].ItemID != 1 || warnings[0].Code != CartItemCoveredByPackage {
+		t.Fatalf("Expected only the own session of the packaged event to be removed, got %+v", warnings)
+	}
+}
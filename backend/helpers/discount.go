@@ -0,0 +1,87 @@
+package helpers
+
+import (
+	"math"
+	"strings"
+)
+
+// Discount types supported by coupons
+const (
+	DiscountPercent = "PERCENT"
+	DiscountFixed   = "FIXED"
+)
+
+// CouponRule describes how a coupon reduces the price of eligible items
+type CouponRule struct {
+	DiscountType  string  // PERCENT or FIXED
+	DiscountValue float64 // percent (0-100) or rupiah amount
+	MaxDiscount   int64   // cap for PERCENT coupons in minor units, 0 = no cap
+}
+
+// CouponDiscountTotal returns the discount, in minor units, for a given eligible subtotal
+func CouponDiscountTotal(eligibleTotal int64, rule CouponRule) int64 {
+	if eligibleTotal <= 0 || rule.DiscountValue <= 0 {
+		return 0
+	}
+
+	var discount int64
+	switch rule.DiscountType {
+	case DiscountPercent:
+		discount = PercentOf(eligibleTotal, math.Min(rule.DiscountValue, 100))
+		if rule.MaxDiscount > 0 && discount > rule.MaxDiscount {
+			discount = rule.MaxDiscount
+		}
+	case DiscountFixed:
+		discount = Rupiah(rule.DiscountValue)
+	default:
+		return 0
+	}
+
+	if discount > eligibleTotal {
+		discount = eligibleTotal
+	}
+	return discount
+}
+
+// AllocateDiscount computes the coupon discount for a cart and spreads it over
+// the eligible items in proportion to their price (see SplitMinor). The
+// per-item amounts are minor units and always sum to the total discount.
+func AllocateDiscount(prices []int64, eligible []bool, rule CouponRule) []int64 {
+	weights := make([]int64, len(prices))
+	var eligibleTotal int64
+	for i, p := range prices {
+		if i < len(eligible) && eligible[i] && p > 0 {
+			weights[i] = p
+			eligibleTotal += p
+		}
+	}
+
+	return SplitMinor(CouponDiscountTotal(eligibleTotal, rule), weights)
+}
+
+// SplitEvenly divides an amount of minor units into n parts that differ by at most 1
+func SplitEvenly(amount int64, n int) []int64 {
+	if n <= 0 {
+		return nil
+	}
+	weights := make([]int64, n)
+	for i := range weights {
+		weights[i] = 1
+	}
+	return SplitMinor(amount, weights)
+}
+
+// NormalizeCouponCode uppercases and trims a coupon code and reports whether it
+// is usable: 3-50 characters of letters, digits, dash or underscore
+func NormalizeCouponCode(code string) (string, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) < 3 || len(code) > 50 {
+		return code, false
+	}
+	for _, r := range code {
+		if !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9') && r != '-' && r != '_' {
+			return code, false
+		}
+	}
+	return code, true
+}
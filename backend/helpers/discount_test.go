@@ -0,0 +1,103 @@
+package helpers
+
+import "testing"
+
+func sumParts(parts []int64) int64 {
+	var total int64
+	for _, p := range parts {
+		total += p
+	}
+	return total
+}
+
+func TestCouponDiscountTotal_Percent(t *testing.T) {
+	got := CouponDiscountTotal(150000, CouponRule{DiscountType: DiscountPercent, DiscountValue: 20})
+	if got != 30000 {
+		t.Errorf("Expected 30000, got %d", got)
+	}
+}
+
+func TestCouponDiscountTotal_PercentCapped(t *testing.T) {
+	got := CouponDiscountTotal(500000, CouponRule{DiscountType: DiscountPercent, DiscountValue: 50, MaxDiscount: 75000})
+	if got != 75000 {
+		t.Errorf("Expected discount capped at 75000, got %d", got)
+	}
+}
+
+func TestCouponDiscountTotal_FixedNeverExceedsTotal(t *testing.T) {
+	got := CouponDiscountTotal(20000, CouponRule{DiscountType: DiscountFixed, DiscountValue: 50000})
+	if got != 20000 {
+		t.Errorf("Expected discount limited to 20000, got %d", got)
+	}
+}
+
+func TestCouponDiscountTotal_UnknownType(t *testing.T) {
+	got := CouponDiscountTotal(20000, CouponRule{DiscountType: "BOGUS", DiscountValue: 10})
+	if got != 0 {
+		t.Errorf("Expected 0 for unknown type, got %d", got)
+	}
+}
+
+func TestAllocateDiscount_OnlyEligibleItems(t *testing.T) {
+	prices := []int64{100000, 50000, 50000}
+	eligible := []bool{true, false, true}
+
+	parts := AllocateDiscount(prices, eligible, CouponRule{DiscountType: DiscountPercent, DiscountValue: 10})
+
+	if parts[1] != 0 {
+		t.Errorf("Expected ineligible item to get no discount, got %d", parts[1])
+	}
+	if parts[0] != 10000 || parts[2] != 5000 {
+		t.Errorf("Expected 10000 and 5000, got %v", parts)
+	}
+}
+
+func TestAllocateDiscount_Reconciles(t *testing.T) {
+	prices := []int64{33333, 33333, 33334}
+	eligible := []bool{true, true, true}
+
+	parts := AllocateDiscount(prices, eligible, CouponRule{DiscountType: DiscountFixed, DiscountValue: 10000})
+
+	if sumParts(parts) != 10000 {
+		t.Errorf("Expected parts to sum to 10000, got %d (%v)", sumParts(parts), parts)
+	}
+}
+
+func TestSplitEvenly(t *testing.T) {
+	parts := SplitEvenly(100000, 3)
+
+	if len(parts) != 3 || sumParts(parts) != 100000 {
+		t.Fatalf("Expected 3 parts summing to 100000, got %v", parts)
+	}
+	for _, p := range parts {
+		if p != 33333 && p != 33334 {
+			t.Errorf("Expected parts of 33333/33334, got %v", parts)
+		}
+	}
+}
+
+func TestSplitEvenly_NoParts(t *testing.T) {
+	if parts := SplitEvenly(100000, 0); parts != nil {
+		t.Errorf("Expected nil for zero parts, got %v", parts)
+	}
+}
+
+func TestNormalizeCouponCode(t *testing.T) {
+	cases := map[string]bool{
+		" hemat50 ":   true,
+		"NEW-YEAR_26": true,
+		"AB":          false,
+		"DISKON 10":   false,
+		"PROMO!":      false,
+	}
+	for input, wantOK := range cases {
+		code, ok := NormalizeCouponCode(input)
+		if ok != wantOK {
+			t.Errorf("%q: expected ok=%v, got %v (%q)", input, wantOK, ok, code)
+		}
+	}
+
+	if code, _ := NormalizeCouponCode(" hemat50 "); code != "HEMAT50" {
+		t.Errorf("Expected HEMAT50, got %q", code)
+	}
+}
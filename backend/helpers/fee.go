@@ -0,0 +1,81 @@
+package helpers
+
+import "math"
+
+// FeeBreakdown is the split of one paid order item between tax, platform,
+// affiliate and organization. All amounts are in minor units of the base
+// currency (whole rupiah).
+type FeeBreakdown struct {
+	Gross               int64   `json:"gross"`
+	TaxPercent          float64 `json:"tax_percent"`
+	TaxAmount           int64   `json:"tax_amount"`
+	PlatformFeePercent  float64 `json:"platform_fee_percent"`
+	PlatformFee         int64   `json:"platform_fee"`
+	AffiliatePercent    float64 `json:"affiliate_percent"`
+	AffiliateCommission int64   `json:"affiliate_commission"`
+	OrgAmount           int64   `json:"org_amount"`
+}
+
+// CalculateFees splits a gross item price.
+//
+// Tax (PPN) is treated as already included in the price, so it is carved out
+// of the gross instead of being added on top. The platform fee is charged on
+// the price after tax, and the affiliate commission stays based on the gross
+// price as it always has been. Whatever remains goes to the organization, so
+// the four parts always add back up to the gross.
+func CalculateFees(gross int64, taxPercent, platformFeePercent, affiliatePercent float64) FeeBreakdown {
+	fb := FeeBreakdown{
+		Gross:              gross,
+		TaxPercent:         clampPercent(taxPercent),
+		PlatformFeePercent: clampPercent(platformFeePercent),
+		AffiliatePercent:   clampPercent(affiliatePercent),
+	}
+	if gross <= 0 {
+		fb.Gross = 0
+		return fb
+	}
+
+	// Every cut is rounded to a minor unit on its own; the organization takes the remainder
+	if fb.TaxPercent > 0 {
+		fb.TaxAmount = int64(math.Round(float64(gross) * fb.TaxPercent / (100 + fb.TaxPercent)))
+	}
+	fb.PlatformFee = PercentOf(gross-fb.TaxAmount, fb.PlatformFeePercent)
+	fb.AffiliateCommission = PercentOf(gross, fb.AffiliatePercent)
+
+	fb.OrgAmount = gross - fb.TaxAmount - fb.PlatformFee - fb.AffiliateCommission
+	if fb.OrgAmount < 0 {
+		// Misconfigured percentages: affiliate gives up what the org can't cover
+		fb.AffiliateCommission += fb.OrgAmount
+		if fb.AffiliateCommission < 0 {
+			fb.AffiliateCommission = 0
+		}
+		fb.OrgAmount = 0
+	}
+	return fb
+}
+
+func clampPercent(p float64) float64 {
+	if p < 0 {
+		return 0
+	}
+	if p > 100 {
+		return 100
+	}
+	return p
+}
+
+// AllocateRevenue spreads a paid amount over items weighted by their list
+// prices, falling back to an even split when every item is free. The parts
+// always add back up to the amount.
+func AllocateRevenue(amount int64, prices []int64) []int64 {
+	var totalWeight int64
+	for _, p := range prices {
+		if p > 0 {
+			totalWeight += p
+		}
+	}
+	if totalWeight <= 0 {
+		return SplitEvenly(amount, len(prices))
+	}
+	return SplitMinor(amount, prices)
+}
@@ -0,0 +1,91 @@
+package helpers
+
+import "testing"
+
+func TestCalculateFees_NoFees(t *testing.T) {
+	fb := CalculateFees(50000, 0, 0, 0)
+
+	if fb.OrgAmount != 50000 {
+		t.Errorf("Expected org to receive full 50000, got %d", fb.OrgAmount)
+	}
+	if fb.TaxAmount != 0 || fb.PlatformFee != 0 || fb.AffiliateCommission != 0 {
+		t.Errorf("Expected no deductions, got %+v", fb)
+	}
+}
+
+func TestCalculateFees_PlatformAndAffiliate(t *testing.T) {
+	fb := CalculateFees(100000, 0, 5, 10)
+
+	if fb.PlatformFee != 5000 {
+		t.Errorf("Expected platform fee 5000, got %d", fb.PlatformFee)
+	}
+	if fb.AffiliateCommission != 10000 {
+		t.Errorf("Expected affiliate commission 10000, got %d", fb.AffiliateCommission)
+	}
+	if fb.OrgAmount != 85000 {
+		t.Errorf("Expected org amount 85000, got %d", fb.OrgAmount)
+	}
+}
+
+func TestCalculateFees_TaxInclusive(t *testing.T) {
+	// 111000 includes 11% PPN -> 11000 tax, 100000 net
+	fb := CalculateFees(111000, 11, 10, 0)
+
+	if fb.TaxAmount != 11000 {
+		t.Errorf("Expected tax 11000, got %d", fb.TaxAmount)
+	}
+	if fb.PlatformFee != 10000 {
+		t.Errorf("Expected platform fee 10000 (10%% of net), got %d", fb.PlatformFee)
+	}
+	if fb.OrgAmount != 90000 {
+		t.Errorf("Expected org amount 90000, got %d", fb.OrgAmount)
+	}
+}
+
+func TestCalculateFees_AlwaysReconciles(t *testing.T) {
+	prices := []int64{1, 99, 10000, 33333, 49999, 123457}
+	for _, price := range prices {
+		fb := CalculateFees(price, 11, 7.5, 12.5)
+		sum := fb.TaxAmount + fb.PlatformFee + fb.AffiliateCommission + fb.OrgAmount
+		if sum != fb.Gross {
+			t.Errorf("Price %d: parts sum to %d, expected %d (%+v)", price, sum, fb.Gross, fb)
+		}
+	}
+}
+
+func TestCalculateFees_OverAllocatedPercentages(t *testing.T) {
+	fb := CalculateFees(10000, 0, 50, 80)
+
+	if fb.OrgAmount != 0 {
+		t.Errorf("Expected org amount clamped to 0, got %d", fb.OrgAmount)
+	}
+	if fb.PlatformFee+fb.AffiliateCommission != 10000 {
+		t.Errorf("Expected deductions capped at gross, got %+v", fb)
+	}
+}
+
+func TestCalculateFees_ZeroPrice(t *testing.T) {
+	fb := CalculateFees(0, 11, 10, 10)
+
+	if fb.TaxAmount != 0 || fb.PlatformFee != 0 || fb.AffiliateCommission != 0 || fb.OrgAmount != 0 {
+		t.Errorf("Expected all zero for free item, got %+v", fb)
+	}
+}
+
+func TestAllocateRevenue(t *testing.T) {
+	// Weighted by session price: 100k + 50k sessions sold in a 120k package
+	parts := AllocateRevenue(120000, []int64{100000, 50000})
+	if parts[0] != 80000 || parts[1] != 40000 {
+		t.Errorf("Expected 80000/40000, got %d/%d", parts[0], parts[1])
+	}
+
+	// All sessions free: split evenly, remainder still fully allocated
+	parts = AllocateRevenue(100000, []int64{0, 0, 0})
+	if parts[0]+parts[1]+parts[2] != 100000 {
+		t.Errorf("Expected parts to add up to 100000, got %v", parts)
+	}
+
+	if len(AllocateRevenue(50000, nil)) != 0 {
+		t.Error("Expected no parts without sessions")
+	}
+}
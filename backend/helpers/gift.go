@@ -0,0 +1,70 @@
+package helpers
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+// MaxGiftQuantity is the most seats one cart line can buy for others
+const MaxGiftQuantity = 100
+
+// giftCodeAlphabet leaves out look-alike characters (0/O, 1/I/L) so codes can be typed from paper
+const giftCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+// GenerateGiftCode returns a random redeemable code like GIFT-7KQ2-M9XA-P3TD
+func GenerateGiftCode() string {
+	buf := make([]byte, 12)
+	rand.Read(buf)
+
+	var sb strings.Builder
+	sb.WriteString("GIFT")
+	for i, b := range buf {
+		if i%4 == 0 {
+			sb.WriteByte('-')
+		}
+		sb.WriteByte(giftCodeAlphabet[int(b)%len(giftCodeAlphabet)])
+	}
+	return sb.String()
+}
+
+// NormalizeGiftCode uppercases a code typed by a user and strips spaces.
+// It returns false when the result cannot be a gift code.
+func NormalizeGiftCode(code string) (string, bool) {
+	code = strings.ToUpper(strings.Join(strings.Fields(code), ""))
+	if !strings.HasPrefix(code, "GIFT-") || len(code) != len("GIFT-XXXX-XXXX-XXXX") {
+		return code, false
+	}
+	for i, r := range code[len("GIFT-"):] {
+		if (i+1)%5 == 0 {
+			if r != '-' {
+				return code, false
+			}
+			continue
+		}
+		if !strings.ContainsRune(giftCodeAlphabet, r) {
+			return code, false
+		}
+	}
+	return code, true
+}
+
+// CleanRecipientEmails trims, lowercases and de-duplicates gift recipient emails
+func CleanRecipientEmails(emails []string) ([]string, error) {
+	seen := map[string]bool{}
+	cleaned := []string{}
+	for _, e := range emails {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if e == "" || seen[e] {
+			continue
+		}
+		addr, err := mail.ParseAddress(e)
+		if err != nil || addr.Address != e {
+			return nil, fmt.Errorf("email tidak valid: %s", e)
+		}
+		seen[e] = true
+		cleaned = append(cleaned, e)
+	}
+	return cleaned, nil
+}
@@ -0,0 +1,44 @@
+package helpers
+
+import "testing"
+
+func TestGenerateGiftCode_IsValidAndUnique(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 50; i++ {
+		code := GenerateGiftCode()
+		if _, ok := NormalizeGiftCode(code); !ok {
+			t.Fatalf("Generated code %s is not valid", code)
+		}
+		if seen[code] {
+			t.Fatalf("Duplicate code %s", code)
+		}
+		seen[code] = true
+	}
+}
+
+func TestNormalizeGiftCode(t *testing.T) {
+	got, ok := NormalizeGiftCode("  gift-7kq2-m9xa -p3td ")
+	if !ok || got != "GIFT-7KQ2-M9XA-P3TD" {
+		t.Errorf("Expected GIFT-7KQ2-M9XA-P3TD, got %s (ok=%v)", got, ok)
+	}
+
+	for _, bad := range []string{"", "PROMO-7KQ2-M9XA-P3TD", "GIFT-7KQ2-M9XA", "GIFT-7KQ2-M9XA-P3T0", "GIFT-7KQ2M-9XA-P3TD"} {
+		if _, ok := NormalizeGiftCode(bad); ok {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestCleanRecipientEmails(t *testing.T) {
+	got, err := CleanRecipientEmails([]string{" A@Corp.com", "a@corp.com", "", "b@corp.com"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(got) != 2 || got[0] != "a@corp.com" || got[1] != "b@corp.com" {
+		t.Errorf("Expected [a@corp.com b@corp.com], got %v", got)
+	}
+
+	if _, err := CleanRecipientEmails([]string{"bukan-email"}); err == nil {
+		t.Error("Expected error for invalid email")
+	}
+}
@@ -0,0 +1,70 @@
+package helpers
+
+import (
+	"crypto/rand"
+	"errors"
+	"net/mail"
+	"strings"
+)
+
+// MaxGuestOrderItems is the most lines one guest checkout can buy
+const MaxGuestOrderItems = 20
+
+// GuestContact is the buyer data a guest checkout needs (the payment gateway requires all three)
+type GuestContact struct {
+	Name  string
+	Email string
+	Phone string
+}
+
+// NormalizeGuestContact trims and validates guest buyer data. Emails are lowercased
+// so the account is matched on payment regardless of how the address was typed;
+// phones keep digits only, with an optional leading +.
+func NormalizeGuestContact(name, email, phone string) (GuestContact, error) {
+	contact := GuestContact{Name: strings.TrimSpace(name)}
+	if contact.Name == "" {
+		return contact, errors.New("Nama wajib diisi")
+	}
+
+	email = strings.ToLower(strings.TrimSpace(email))
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return contact, errors.New("Email tidak valid")
+	}
+	contact.Email = email
+
+	var sb strings.Builder
+	for i, r := range strings.TrimSpace(phone) {
+		switch {
+		case r >= '0' && r <= '9':
+			sb.WriteRune(r)
+		case r == '+' && i == 0:
+			sb.WriteRune(r)
+		case r == ' ' || r == '-' || r == '(' || r == ')':
+			// formatting only
+		default:
+			return contact, errors.New("Nomor telepon tidak valid")
+		}
+	}
+	digits := strings.TrimPrefix(sb.String(), "+")
+	if len(digits) < 8 || len(digits) > 15 {
+		return contact, errors.New("Nomor telepon tidak valid")
+	}
+	contact.Phone = sb.String()
+
+	return contact, nil
+}
+
+// GenerateSetPasswordCode returns a random code for a guest buyer to set the password
+// of the account created for them. It is longer than the 6-digit reset code because
+// it stays valid for days instead of minutes.
+func GenerateSetPasswordCode() string {
+	buf := make([]byte, 10)
+	rand.Read(buf)
+
+	code := make([]byte, len(buf))
+	for i, b := range buf {
+		code[i] = giftCodeAlphabet[int(b)%len(giftCodeAlphabet)]
+	}
+	return string(code)
+}
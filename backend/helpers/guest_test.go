@@ -0,0 +1,49 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeGuestContact(t *testing.T) {
+	contact, err := NormalizeGuestContact("  Budi  ", " Budi@Example.COM ", "+62 812-3456-7890")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if contact.Name != "Budi" || contact.Email != "budi@example.com" || contact.Phone != "+6281234567890" {
+		t.Errorf("Unexpected normalized contact: %+v", contact)
+	}
+}
+
+func TestNormalizeGuestContact_Invalid(t *testing.T) {
+	cases := []struct {
+		name, email, phone string
+	}{
+		{"", "a@b.com", "081234567890"},
+		{"Budi", "not-an-email", "081234567890"},
+		{"Budi", "Budi <a@b.com>", "081234567890"},
+		{"Budi", "a@b.com", "12345"},
+		{"Budi", "a@b.com", "0812-ABCD-7890"},
+		{"Budi", "a@b.com", "0812+34567890"},
+	}
+	for _, tc := range cases {
+		if _, err := NormalizeGuestContact(tc.name, tc.email, tc.phone); err == nil {
+			t.Errorf("Expected error for %+v", tc)
+		}
+	}
+}
+
+func TestGenerateSetPasswordCode(t *testing.T) {
+	code := GenerateSetPasswordCode()
+	if len(code) != 10 {
+		t.Fatalf("Expected 10 characters, got %q", code)
+	}
+	for _, r := range code {
+		if !strings.ContainsRune(giftCodeAlphabet, r) {
+			t.Errorf("Unexpected character %q in %q", r, code)
+		}
+	}
+	if code == GenerateSetPasswordCode() {
+		t.Error("Expected different codes on each call")
+	}
+}
@@ -0,0 +1,243 @@
+package helpers
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// InvoiceParty is the buyer or a seller shown on an invoice
+type InvoiceParty struct {
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Phone   string `json:"phone"`
+	Address string `json:"address"`
+}
+
+// InvoiceLine is one purchased item on an invoice
+type InvoiceLine struct {
+	Description string `json:"description"`
+	Seller      string `json:"seller"`
+	UnitPrice   int64  `json:"unit_price"` // price before discount
+	Discount    int64  `json:"discount"`
+	Total       int64  `json:"total"` // amount actually paid
+}
+
+// Invoice holds everything needed to render an invoice PDF
+type Invoice struct {
+	Number        string         `json:"number"`
+	OrderID       string         `json:"order_id"`
+	IssuedAt      time.Time      `json:"issued_at"`
+	Buyer         InvoiceParty   `json:"buyer"`
+	Sellers       []InvoiceParty `json:"sellers"`
+	Lines         []InvoiceLine  `json:"lines"`
+	CouponCode    string         `json:"coupon_code"`
+	AffiliateCode string         `json:"affiliate_code"`
+	TaxPercent    float64        `json:"tax_percent"`
+	TaxAmount     int64          `json:"tax_amount"` // PPN included in the total
+	CopyLabel     string         `json:"copy_label"` // e.g. "Salinan Penjual", empty for buyer
+}
+
+// Totals returns subtotal (before discount), total discount and total paid
+func (inv Invoice) Totals() (subtotal, discount, total int64) {
+	for _, l := range inv.Lines {
+		subtotal += l.UnitPrice
+		discount += l.Discount
+		total += l.Total
+	}
+	return subtotal, discount, total
+}
+
+// FormatInvoiceNumber builds the public invoice number, e.g. INV/2026/000042
+func FormatInvoiceNumber(year int, sequence int64) string {
+	return fmt.Sprintf("INV/%d/%06d", year, sequence)
+}
+
+// FormatRupiah formats a whole-rupiah amount with Indonesian thousand separators, e.g. Rp 1.250.000
+func FormatRupiah(amount int64) string {
+	n := amount
+	sign := ""
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+
+	digits := fmt.Sprintf("%d", n)
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(d)
+	}
+	return sign + "Rp " + b.String()
+}
+
+// truncateText shortens s to max runes so it fits in a table cell
+func truncateText(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max-3]) + "..."
+}
+
+// RenderInvoicePDF renders an invoice as an A4 PDF document
+func RenderInvoicePDF(inv Invoice) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 20)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.SetTextColor(120, 120, 120)
+		pdf.CellFormat(0, 5, tr("Dokumen ini dibuat otomatis oleh sistem Webbinar dan sah tanpa tanda tangan."), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	// Header
+	pdf.SetFont("Helvetica", "B", 20)
+	pdf.SetTextColor(30, 64, 175)
+	pdf.CellFormat(100, 10, "Webbinar", "", 0, "L", false, 0, "")
+	pdf.SetTextColor(30, 41, 59)
+	pdf.CellFormat(80, 10, "INVOICE", "", 1, "R", false, 0, "")
+
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.SetTextColor(100, 116, 139)
+	pdf.CellFormat(100, 5, "Platform Webinar & Kursus Online", "", 0, "L", false, 0, "")
+	pdf.CellFormat(80, 5, tr(inv.Number), "", 1, "R", false, 0, "")
+	if inv.CopyLabel != "" {
+		pdf.CellFormat(180, 5, tr(inv.CopyLabel), "", 1, "R", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	// Invoice meta
+	pdf.SetTextColor(30, 41, 59)
+	meta := [][2]string{
+		{"Tanggal", inv.IssuedAt.Format("02-01-2006 15:04")},
+		{"No. Pesanan", inv.OrderID},
+		{"Status", "LUNAS"},
+	}
+	for _, m := range meta {
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(35, 6, m[0], "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.CellFormat(145, 6, tr(": "+m[1]), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	// Buyer & sellers
+	partyY := pdf.GetY()
+	writeParty := func(x float64, title string, p InvoiceParty) {
+		pdf.SetXY(x, pdf.GetY())
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(85, 6, title, "", 2, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 9)
+		for _, line := range []string{p.Name, p.Email, p.Phone, p.Address} {
+			if line != "" {
+				pdf.CellFormat(85, 5, tr(truncateText(line, 55)), "", 2, "L", false, 0, "")
+			}
+		}
+	}
+
+	pdf.SetY(partyY)
+	writeParty(15, "Ditagihkan Kepada", inv.Buyer)
+	buyerEnd := pdf.GetY()
+
+	pdf.SetY(partyY)
+	for i, seller := range inv.Sellers {
+		title := "Penjual"
+		if len(inv.Sellers) > 1 {
+			title = fmt.Sprintf("Penjual %d", i+1)
+		}
+		writeParty(110, title, seller)
+		pdf.Ln(2)
+	}
+	pdf.SetY(math.Max(buyerEnd, pdf.GetY()) + 4)
+
+	// Line items
+	cols := []struct {
+		title string
+		width float64
+		align string
+	}{
+		{"No", 10, "C"},
+		{"Item", 70, "L"},
+		{"Penjual", 35, "L"},
+		{"Harga", 22, "R"},
+		{"Diskon", 20, "R"},
+		{"Jumlah", 23, "R"},
+	}
+
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetFillColor(241, 245, 249)
+	for _, col := range cols {
+		pdf.CellFormat(col.width, 8, col.title, "1", 0, col.align, true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 8)
+	for i, l := range inv.Lines {
+		discount := "-"
+		if l.Discount > 0 {
+			discount = FormatRupiah(-l.Discount)
+		}
+		values := []string{
+			fmt.Sprintf("%d", i+1),
+			truncateText(l.Description, 48),
+			truncateText(l.Seller, 22),
+			FormatRupiah(l.UnitPrice),
+			discount,
+			FormatRupiah(l.Total),
+		}
+		for j, col := range cols {
+			pdf.CellFormat(col.width, 7, tr(values[j]), "1", 0, col.align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	pdf.Ln(4)
+
+	// Summary
+	subtotal, discount, total := inv.Totals()
+	writeSummary := func(label, value string, bold bool) {
+		style := ""
+		if bold {
+			style = "B"
+		}
+		pdf.SetFont("Helvetica", style, 10)
+		pdf.CellFormat(135, 6, tr(label), "", 0, "R", false, 0, "")
+		pdf.CellFormat(45, 6, tr(value), "", 1, "R", false, 0, "")
+	}
+
+	writeSummary("Subtotal", FormatRupiah(subtotal), false)
+	if discount > 0 {
+		label := "Diskon"
+		if inv.CouponCode != "" {
+			label = fmt.Sprintf("Diskon Kupon (%s)", inv.CouponCode)
+		}
+		writeSummary(label, FormatRupiah(-discount), false)
+	}
+	writeSummary("Total Dibayar", FormatRupiah(total), true)
+	if inv.TaxAmount > 0 {
+		writeSummary(fmt.Sprintf("Termasuk PPN %.0f%%", inv.TaxPercent), FormatRupiah(inv.TaxAmount), false)
+	}
+
+	if inv.AffiliateCode != "" {
+		pdf.Ln(4)
+		pdf.SetFont("Helvetica", "I", 9)
+		pdf.SetTextColor(100, 116, 139)
+		pdf.CellFormat(180, 5, tr("Kode affiliate yang digunakan: "+inv.AffiliateCode), "", 1, "L", false, 0, "")
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
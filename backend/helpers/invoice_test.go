@@ -0,0 +1,68 @@
+package helpers
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestFormatInvoiceNumber(t *testing.T) {
+	got := FormatInvoiceNumber(2026, 42)
+	if got != "INV/2026/000042" {
+		t.Errorf("Expected INV/2026/000042, got %s", got)
+	}
+}
+
+func TestFormatRupiah(t *testing.T) {
+	cases := map[int64]string{
+		0:       "Rp 0",
+		500:     "Rp 500",
+		1000:    "Rp 1.000",
+		1250000: "Rp 1.250.000",
+		-25000:  "-Rp 25.000",
+	}
+	for amount, want := range cases {
+		if got := FormatRupiah(amount); got != want {
+			t.Errorf("FormatRupiah(%d): expected %q, got %q", amount, want, got)
+		}
+	}
+}
+
+func TestInvoiceTotals(t *testing.T) {
+	inv := Invoice{
+		Lines: []InvoiceLine{
+			{UnitPrice: 100000, Discount: 20000, Total: 80000},
+			{UnitPrice: 50000, Discount: 0, Total: 50000},
+		},
+	}
+
+	subtotal, discount, total := inv.Totals()
+	if subtotal != 150000 || discount != 20000 || total != 130000 {
+		t.Errorf("Expected 150000/20000/130000, got %d/%d/%d", subtotal, discount, total)
+	}
+}
+
+func TestRenderInvoicePDF(t *testing.T) {
+	inv := Invoice{
+		Number:   FormatInvoiceNumber(2026, 1),
+		OrderID:  "CART-1760000000-1-1",
+		IssuedAt: time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC),
+		Buyer:    InvoiceParty{Name: "Budi Santoso", Email: "budi@example.com"},
+		Sellers:  []InvoiceParty{{Name: "Ulbi Academy", Address: "Jalan Sarijadi"}},
+		Lines: []InvoiceLine{
+			{Description: "Belajar Golang - Sesi 1: Pengenalan dan Instalasi Toolchain", Seller: "Ulbi Academy", UnitPrice: 100000, Discount: 10000, Total: 90000},
+		},
+		CouponCode:    "HEMAT10",
+		AffiliateCode: "PROMO123",
+		TaxPercent:    11,
+		TaxAmount:     8919,
+	}
+
+	pdf, err := RenderInvoicePDF(inv)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !bytes.HasPrefix(pdf, []byte("%PDF-")) {
+		t.Errorf("Expected PDF output, got %q", pdf[:min(len(pdf), 8)])
+	}
+}
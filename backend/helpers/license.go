@@ -0,0 +1,60 @@
+package helpers
+
+import "math"
+
+// License pool limits; a single seat for someone else is a gift, not a pool
+const (
+	MinLicenseSeats = 2
+	MaxLicenseSeats = 10000
+)
+
+// EventProgressPercent is a user's event progress the same way the learner sees it:
+// the best score of every enabled quiz, each quiz weighing the same, missing quizzes count 0
+func EventProgressPercent(bestScores []float64, totalQuizzes int) float64 {
+	if totalQuizzes <= 0 {
+		return 0
+	}
+	var sum float64
+	for _, s := range bestScores {
+		sum += s
+	}
+	return math.Round(sum/float64(totalQuizzes)*100) / 100
+}
+
+// SeatProgress is the learning progress of one assigned seat
+type SeatProgress struct {
+	ProgressPercent  float64
+	QuizzesCompleted int
+	Certified        bool
+}
+
+// PoolProgressSummary aggregates the progress of every assigned seat of a pool
+type PoolProgressSummary struct {
+	Assigned        int     `json:"assigned"`
+	Started         int     `json:"started"`   // completed at least one quiz
+	Certified       int     `json:"certified"` // received the event certificate
+	AverageProgress float64 `json:"average_progress"`
+	CompletionRate  float64 `json:"completion_rate"` // certified / assigned, in percent
+}
+
+// SummarizePoolProgress aggregates seat progress for the pool buyer's report
+func SummarizePoolProgress(seats []SeatProgress) PoolProgressSummary {
+	summary := PoolProgressSummary{Assigned: len(seats)}
+	if len(seats) == 0 {
+		return summary
+	}
+
+	var total float64
+	for _, s := range seats {
+		total += s.ProgressPercent
+		if s.QuizzesCompleted > 0 {
+			summary.Started++
+		}
+		if s.Certified {
+			summary.Certified++
+		}
+	}
+	summary.AverageProgress = math.Round(total/float64(len(seats))*100) / 100
+	summary.CompletionRate = math.Round(float64(summary.Certified)/float64(len(seats))*10000) / 100
+	return summary
+}
@@ -0,0 +1,41 @@
+package helpers
+
+import "testing"
+
+func TestEventProgressPercent(t *testing.T) {
+	// Three quizzes, two attempted: (90 + 60 + 0) / 3
+	got := EventProgressPercent([]float64{90, 60}, 3)
+	if got != 50 {
+		t.Errorf("Expected 50, got %.2f", got)
+	}
+
+	if got := EventProgressPercent([]float64{100}, 0); got != 0 {
+		t.Errorf("Expected 0 when the event has no quizzes, got %.2f", got)
+	}
+}
+
+func TestSummarizePoolProgress(t *testing.T) {
+	summary := SummarizePoolProgress([]SeatProgress{
+		{ProgressPercent: 100, QuizzesCompleted: 3, Certified: true},
+		{ProgressPercent: 50, QuizzesCompleted: 2},
+		{ProgressPercent: 0},
+		{ProgressPercent: 0},
+	})
+
+	if summary.Assigned != 4 || summary.Started != 2 || summary.Certified != 1 {
+		t.Errorf("Unexpected counts: %+v", summary)
+	}
+	if summary.AverageProgress != 37.5 {
+		t.Errorf("Expected average 37.5, got %.2f", summary.AverageProgress)
+	}
+	if summary.CompletionRate != 25 {
+		t.Errorf("Expected completion rate 25, got %.2f", summary.CompletionRate)
+	}
+}
+
+func TestSummarizePoolProgress_Empty(t *testing.T) {
+	summary := SummarizePoolProgress(nil)
+	if summary.Assigned != 0 || summary.AverageProgress != 0 || summary.CompletionRate != 0 {
+		t.Errorf("Expected zero summary, got %+v", summary)
+	}
+}
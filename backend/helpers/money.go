@@ -0,0 +1,163 @@
+package helpers
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"strings"
+)
+
+// BaseCurrency is the currency every price is stored in and charged in.
+// Rupiah has no minor unit, so order amounts are stored and computed as int64
+// whole rupiah; Rupiah converts decimal input at the edges.
+const BaseCurrency = "IDR"
+
+// Currency describes a supported currency; Exponent is the number of minor unit digits
+type Currency struct {
+	Code     string `json:"code"`
+	Symbol   string `json:"symbol"`
+	Name     string `json:"name"`
+	Exponent int    `json:"exponent"`
+}
+
+// Currencies lists the currencies organizations can display their prices in
+var Currencies = []Currency{
+	{Code: "IDR", Symbol: "Rp", Name: "Rupiah Indonesia", Exponent: 0},
+	{Code: "USD", Symbol: "US$", Name: "Dolar Amerika Serikat", Exponent: 2},
+	{Code: "SGD", Symbol: "S$", Name: "Dolar Singapura", Exponent: 2},
+	{Code: "MYR", Symbol: "RM", Name: "Ringgit Malaysia", Exponent: 2},
+	{Code: "AUD", Symbol: "A$", Name: "Dolar Australia", Exponent: 2},
+	{Code: "EUR", Symbol: "€", Name: "Euro", Exponent: 2},
+	{Code: "JPY", Symbol: "¥", Name: "Yen Jepang", Exponent: 0},
+}
+
+// FindCurrency looks up a supported currency by its ISO code (case-insensitive)
+func FindCurrency(code string) (Currency, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	for _, cur := range Currencies {
+		if cur.Code == code {
+			return cur, true
+		}
+	}
+	return Currency{}, false
+}
+
+// Money is an amount in the minor units of its currency
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// ToMinor converts a decimal amount to minor units, rounding half away from zero
+func ToMinor(amount float64, exponent int) int64 {
+	return int64(math.Round(amount * math.Pow10(exponent)))
+}
+
+// FromMinor converts minor units back to a decimal amount
+func FromMinor(minor int64, exponent int) float64 {
+	return float64(minor) / math.Pow10(exponent)
+}
+
+// Rupiah rounds an amount to whole rupiah, the minor unit of the base currency
+func Rupiah(amount float64) int64 {
+	return ToMinor(amount, 0)
+}
+
+// IsWholeRupiah reports whether an amount has no fraction of a rupiah
+func IsWholeRupiah(amount float64) bool {
+	return amount == math.Trunc(amount)
+}
+
+// PercentOf returns a percentage of a minor-unit amount, rounded half away from zero
+func PercentOf(amount int64, percent float64) int64 {
+	return int64(math.Round(float64(amount) * percent / 100))
+}
+
+// SplitMinor divides an amount of minor units by weight using the largest
+// remainder method: every part is floored, then the leftover units go to the
+// largest remainders (earliest index first on ties). The parts always sum back
+// to the amount; non-positive weights get nothing.
+func SplitMinor(amount int64, weights []int64) []int64 {
+	parts := make([]int64, len(weights))
+
+	var totalWeight uint64
+	for _, w := range weights {
+		if w > 0 {
+			totalWeight += uint64(w)
+		}
+	}
+	if amount <= 0 || totalWeight == 0 {
+		return parts
+	}
+
+	remainders := make([]uint64, len(weights))
+	var allocated int64
+	for i, w := range weights {
+		if w <= 0 {
+			continue
+		}
+		// amount * w can overflow 64 bits; w <= totalWeight keeps the quotient in range
+		hi, lo := bits.Mul64(uint64(amount), uint64(w))
+		q, r := bits.Div64(hi, lo, totalWeight)
+		parts[i] = int64(q)
+		remainders[i] = r
+		allocated += parts[i]
+	}
+
+	for left := amount - allocated; left > 0; left-- {
+		best := -1
+		for i, w := range weights {
+			if w <= 0 || remainders[i] == math.MaxUint64 {
+				continue
+			}
+			if best == -1 || remainders[i] > remainders[best] {
+				best = i
+			}
+		}
+		parts[best]++
+		remainders[best] = math.MaxUint64
+	}
+
+	return parts
+}
+
+// ConvertFromRupiah converts whole rupiah to another currency for display.
+// rate is the number of rupiah per one unit of the target currency; the result
+// is rounded half away from zero to the target's minor unit.
+func ConvertFromRupiah(rupiah int64, cur Currency, rate float64) Money {
+	if cur.Code == BaseCurrency || rate <= 0 {
+		return Money{Amount: rupiah, Currency: BaseCurrency}
+	}
+	return Money{Amount: ToMinor(float64(rupiah)/rate, cur.Exponent), Currency: cur.Code}
+}
+
+// FormatMoney renders an amount the Indonesian way: "Rp 150.000", "US$ 10,50"
+func FormatMoney(m Money) string {
+	cur, ok := FindCurrency(m.Currency)
+	if !ok {
+		cur = Currency{Code: m.Currency, Symbol: m.Currency}
+	}
+
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	scale := int64(math.Pow10(cur.Exponent))
+	whole := fmt.Sprintf("%d", amount/scale)
+	var grouped strings.Builder
+	for i, d := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped.WriteByte('.')
+		}
+		grouped.WriteRune(d)
+	}
+
+	out := fmt.Sprintf("%s%s %s", sign, cur.Symbol, grouped.String())
+	if cur.Exponent > 0 {
+		out += fmt.Sprintf(",%0*d", cur.Exponent, amount%scale)
+	}
+	return out
+}
@@ -0,0 +1,64 @@
+package helpers
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSplitMinor_Reconciles(t *testing.T) {
+	parts := SplitMinor(100, []int64{1, 1, 1})
+	if parts[0] != 34 || parts[1] != 33 || parts[2] != 33 {
+		t.Errorf("Expected 34/33/33 with the leftover on the first part, got %v", parts)
+	}
+
+	// Large amounts and weights must not overflow
+	big := int64(math.MaxInt64 / 4)
+	parts = SplitMinor(big, []int64{big, big, 0, -5})
+	if parts[0]+parts[1] != big || parts[2] != 0 || parts[3] != 0 {
+		t.Errorf("Expected the amount split over positive weights only, got %v", parts)
+	}
+}
+
+func TestSplitMinor_NothingToSplit(t *testing.T) {
+	for _, parts := range [][]int64{SplitMinor(0, []int64{1, 2}), SplitMinor(100, []int64{0, 0})} {
+		if parts[0] != 0 || parts[1] != 0 {
+			t.Errorf("Expected no allocation, got %v", parts)
+		}
+	}
+}
+
+func TestConvertFromRupiah(t *testing.T) {
+	usd, _ := FindCurrency("usd")
+	m := ConvertFromRupiah(150000, usd, 16000)
+	if m.Currency != "USD" || m.Amount != 938 {
+		t.Errorf("Expected USD 9.38 (938 cents), got %+v", m)
+	}
+
+	idr, _ := FindCurrency("IDR")
+	if m := ConvertFromRupiah(150000, idr, 1); m.Amount != 150000 || m.Currency != BaseCurrency {
+		t.Errorf("Expected rupiah unchanged, got %+v", m)
+	}
+}
+
+func TestFormatMoney(t *testing.T) {
+	cases := map[string]Money{
+		"Rp 1.500.000": {Amount: 1500000, Currency: "IDR"},
+		"Rp 0":         {Amount: 0, Currency: "IDR"},
+		"US$ 1.234,05": {Amount: 123405, Currency: "USD"},
+		"-RM 0,50":     {Amount: -50, Currency: "MYR"},
+	}
+	for want, m := range cases {
+		if got := FormatMoney(m); got != want {
+			t.Errorf("FormatMoney(%+v) = %q, expected %q", m, got, want)
+		}
+	}
+}
+
+func TestRupiahRounding(t *testing.T) {
+	if Rupiah(10.5) != 11 || Rupiah(10.49) != 10 {
+		t.Errorf("Expected half-up rounding to whole rupiah")
+	}
+	if !IsWholeRupiah(15000) || IsWholeRupiah(15000.5) {
+		t.Errorf("Expected only whole amounts to be whole rupiah")
+	}
+}
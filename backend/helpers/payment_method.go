@@ -0,0 +1,97 @@
+package helpers
+
+import "strings"
+
+// PaymentMethodInfo is a payment method the platform can offer through Midtrans Snap
+type PaymentMethodInfo struct {
+	Code                 string   `json:"code"`
+	Label                string   `json:"label"`
+	Group                string   `json:"group"` // EWALLET, QRIS, BANK_TRANSFER, CARD
+	SnapTypes            []string `json:"-"`
+	DefaultExpiryMinutes int      `json:"-"`
+}
+
+// PaymentMethods lists every supported method in display order
+var PaymentMethods = []PaymentMethodInfo{
+	{Code: "gopay", Label: "GoPay", Group: "EWALLET", SnapTypes: []string{"gopay"}, DefaultExpiryMinutes: 15},
+	{Code: "shopeepay", Label: "ShopeePay", Group: "EWALLET", SnapTypes: []string{"shopeepay"}, DefaultExpiryMinutes: 15},
+	{Code: "qris", Label: "QRIS", Group: "QRIS", SnapTypes: []string{"other_qris"}, DefaultExpiryMinutes: 15},
+	{Code: "bca_va", Label: "BCA Virtual Account", Group: "BANK_TRANSFER", SnapTypes: []string{"bca_va"}, DefaultExpiryMinutes: 1440},
+	{Code: "bni_va", Label: "BNI Virtual Account", Group: "BANK_TRANSFER", SnapTypes: []string{"bni_va"}, DefaultExpiryMinutes: 1440},
+	{Code: "bri_va", Label: "BRI Virtual Account", Group: "BANK_TRANSFER", SnapTypes: []string{"bri_va"}, DefaultExpiryMinutes: 1440},
+	{Code: "permata_va", Label: "Permata Virtual Account", Group: "BANK_TRANSFER", SnapTypes: []string{"permata_va"}, DefaultExpiryMinutes: 1440},
+	{Code: "mandiri_bill", Label: "Mandiri Bill Payment", Group: "BANK_TRANSFER", SnapTypes: []string{"echannel"}, DefaultExpiryMinutes: 1440},
+	{Code: "credit_card", Label: "Kartu Kredit", Group: "CARD", SnapTypes: []string{"credit_card"}, DefaultExpiryMinutes: 60},
+}
+
+// DefaultPaymentMethod is offered when admins have not enabled anything else
+const DefaultPaymentMethod = "gopay"
+
+// FindPaymentMethod looks up a supported method by code
+func FindPaymentMethod(code string) (PaymentMethodInfo, bool) {
+	for _, m := range PaymentMethods {
+		if m.Code == code {
+			return m, true
+		}
+	}
+	return PaymentMethodInfo{}, false
+}
+
+// ParsePaymentMethodList turns a comma separated setting into known method codes,
+// in catalog order and without duplicates
+func ParsePaymentMethodList(value string) []string {
+	wanted := map[string]bool{}
+	for _, part := range strings.Split(value, ",") {
+		wanted[strings.ToLower(strings.TrimSpace(part))] = true
+	}
+
+	codes := []string{}
+	for _, m := range PaymentMethods {
+		if wanted[m.Code] {
+			codes = append(codes, m.Code)
+		}
+	}
+	return codes
+}
+
+// AllowedPaymentMethods keeps the globally enabled methods every organization in the
+// order accepts. A nil organization list means that organization follows the global setting.
+func AllowedPaymentMethods(global []string, orgLists [][]string) []string {
+	allowed := []string{}
+	for _, code := range global {
+		ok := true
+		for _, list := range orgLists {
+			if list == nil {
+				continue
+			}
+			found := false
+			for _, c := range list {
+				if c == code {
+					found = true
+					break
+				}
+			}
+			if !found {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			allowed = append(allowed, code)
+		}
+	}
+	return allowed
+}
+
+// PaymentMethodFee is the surcharge for paying amount with a method
+// (flat fee plus a percentage rounded to a minor unit)
+func PaymentMethodFee(amount, flatFee int64, percentFee float64) int64 {
+	if amount <= 0 {
+		return 0
+	}
+	fee := flatFee + PercentOf(amount, clampPercent(percentFee))
+	if fee < 0 {
+		return 0
+	}
+	return fee
+}
@@ -0,0 +1,51 @@
+package helpers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePaymentMethodList(t *testing.T) {
+	got := ParsePaymentMethodList(" QRIS, gopay,unknown,gopay ,bca_va")
+	want := []string{"gopay", "qris", "bca_va"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if got := ParsePaymentMethodList(""); len(got) != 0 {
+		t.Errorf("Expected no methods, got %v", got)
+	}
+}
+
+func TestAllowedPaymentMethods(t *testing.T) {
+	global := []string{"gopay", "qris", "bca_va", "credit_card"}
+
+	// Org 1 follows the global setting, org 2 only takes QRIS and cards
+	got := AllowedPaymentMethods(global, [][]string{nil, {"qris", "credit_card", "shopeepay"}})
+	want := []string{"qris", "credit_card"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	if got := AllowedPaymentMethods(global, [][]string{{"qris"}, {"bca_va"}}); len(got) != 0 {
+		t.Errorf("Expected no common method, got %v", got)
+	}
+}
+
+func TestPaymentMethodFee(t *testing.T) {
+	tests := []struct {
+		amount, flat int64
+		pct          float64
+		want         int64
+	}{
+		{100000, 4000, 0, 4000},
+		{100000, 0, 2.9, 2900},
+		{150000, 2000, 0.7, 3050},
+		{0, 4000, 0, 0},
+		{100000, 0, 150, 100000},
+	}
+	for _, tt := range tests {
+		if got := PaymentMethodFee(tt.amount, tt.flat, tt.pct); got != tt.want {
+			t.Errorf("PaymentMethodFee(%d, %d, %.1f) = %d, want %d", tt.amount, tt.flat, tt.pct, got, tt.want)
+		}
+	}
+}
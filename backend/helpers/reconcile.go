@@ -0,0 +1,49 @@
+package helpers
+
+import "strconv"
+
+// Actions the pending-order reconciler can take for one order
+const (
+	ReconcileSettle = "SETTLE" // provider says paid, webhook was missed
+	ReconcileFail   = "FAIL"   // denied / cancelled by the provider
+	ReconcileExpire = "EXPIRE" // expired at the provider or never started
+	ReconcileWait   = "WAIT"   // still pending at the provider
+	ReconcileReview = "REVIEW" // unexpected state, needs a human
+)
+
+// ClassifyProviderStatus maps a Midtrans transaction status to a reconciler action.
+// found is false when the provider has no transaction for the order at all,
+// which happens when the buyer closed the Snap popup without choosing a method.
+func ClassifyProviderStatus(found bool, transactionStatus, paymentType, fraudStatus string) string {
+	if !found {
+		return ReconcileExpire
+	}
+
+	switch transactionStatus {
+	case "capture", "settlement":
+		// Card payments are only final once fraud screening accepts them
+		if paymentType == "credit_card" && fraudStatus != "" && fraudStatus != "accept" {
+			return ReconcileReview
+		}
+		return ReconcileSettle
+	case "deny", "cancel", "failure":
+		return ReconcileFail
+	case "expire":
+		return ReconcileExpire
+	case "pending", "authorize":
+		return ReconcileWait
+	default:
+		// refund, partial_refund, chargeback, ... on an order we never marked paid
+		return ReconcileReview
+	}
+}
+
+// AmountMatches compares the provider gross amount (a decimal string) with the
+// local order total in whole rupiah.
+func AmountMatches(providerGross string, localTotal int64) bool {
+	gross, err := strconv.ParseFloat(providerGross, 64)
+	if err != nil {
+		return false
+	}
+	return Rupiah(gross) == localTotal
+}
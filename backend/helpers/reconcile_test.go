@@ -0,0 +1,43 @@
+package helpers
+
+import "testing"
+
+func TestClassifyProviderStatus(t *testing.T) {
+	cases := []struct {
+		found       bool
+		status      string
+		paymentType string
+		fraud       string
+		want        string
+	}{
+		{false, "", "", "", ReconcileExpire},
+		{true, "settlement", "bank_transfer", "", ReconcileSettle},
+		{true, "capture", "credit_card", "accept", ReconcileSettle},
+		{true, "capture", "credit_card", "challenge", ReconcileReview},
+		{true, "deny", "credit_card", "deny", ReconcileFail},
+		{true, "cancel", "gopay", "", ReconcileFail},
+		{true, "expire", "bank_transfer", "", ReconcileExpire},
+		{true, "pending", "bank_transfer", "", ReconcileWait},
+		{true, "refund", "gopay", "", ReconcileReview},
+	}
+
+	for _, tc := range cases {
+		got := ClassifyProviderStatus(tc.found, tc.status, tc.paymentType, tc.fraud)
+		if got != tc.want {
+			t.Errorf("ClassifyProviderStatus(%v, %q, %q, %q): expected %s, got %s",
+				tc.found, tc.status, tc.paymentType, tc.fraud, tc.want, got)
+		}
+	}
+}
+
+func TestAmountMatches(t *testing.T) {
+	if !AmountMatches("150000.00", 150000) {
+		t.Error("Expected 150000.00 to match 150000")
+	}
+	if AmountMatches("150000.00", 120000) {
+		t.Error("Expected 150000.00 not to match 120000")
+	}
+	if AmountMatches("abc", 0) {
+		t.Error("Expected invalid amount not to match")
+	}
+}
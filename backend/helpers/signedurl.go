@@ -15,14 +15,17 @@ func GenerateSignedToken(userID int64, filename string) (string, int64) {
 
 	exp := time.Now().Add(1 * time.Minute).Unix() // expired 1 menit
 
+	return SignToken(userID, filename, exp), exp
+}
+
+// SignToken signs a filename for a user until exp (unix seconds)
+func SignToken(userID int64, filename string, exp int64) string {
 	data := fmt.Sprintf("%d|%s|%d", userID, filename, exp)
 
 	h := hmac.New(sha256.New, SignedSecret)
 	h.Write([]byte(data))
 
-	token := hex.EncodeToString(h.Sum(nil))
-
-	return token, exp
+	return hex.EncodeToString(h.Sum(nil))
 }
 
 // Validate signature
@@ -32,12 +35,7 @@ func ValidateSignedToken(userID int64, filename string, exp int64, token string)
 		return false
 	}
 
-	data := fmt.Sprintf("%d|%s|%d", userID, filename, exp)
-
-	h := hmac.New(sha256.New, SignedSecret)
-	h.Write([]byte(data))
-
-	expected := hex.EncodeToString(h.Sum(nil))
+	expected := SignToken(userID, filename, exp)
 
 	return hmac.Equal([]byte(expected), []byte(token))
 }
@@ -0,0 +1,75 @@
+package helpers
+
+import "time"
+
+// Subscription billing intervals
+const (
+	BillingMonthly = "MONTHLY"
+	BillingAnnual  = "ANNUAL"
+)
+
+// Subscription states
+const (
+	SubscriptionPending   = "PENDING"   // first period not paid yet
+	SubscriptionActive    = "ACTIVE"    // inside a paid period
+	SubscriptionPastDue   = "PAST_DUE"  // period ended, still inside the grace period
+	SubscriptionCancelled = "CANCELLED" // cancelled and the paid period is over
+	SubscriptionExpired   = "EXPIRED"   // not renewed within the grace period
+)
+
+// ValidBillingInterval reports whether the interval is supported
+func ValidBillingInterval(interval string) bool {
+	return interval == BillingMonthly || interval == BillingAnnual
+}
+
+// BillingPeriodEnd returns the end of a billing period starting at start.
+// Month ends are clamped (Jan 31 + 1 month = Feb 28/29) instead of spilling into the next month.
+func BillingPeriodEnd(start time.Time, interval string) time.Time {
+	months := 1
+	if interval == BillingAnnual {
+		months = 12
+	}
+
+	end := start.AddDate(0, months, 0)
+	if end.Day() != start.Day() {
+		// AddDate normalised an overflowing day, step back to the last day of the intended month
+		end = end.AddDate(0, 0, -end.Day())
+	}
+	return end
+}
+
+// RenewalPeriodStart decides where a newly paid period starts. Renewing before the
+// grace period is over continues right after the current period, so early payers
+// lose no days; a lapsed (or first) subscription starts when the payment arrives.
+func RenewalPeriodStart(currentEnd *time.Time, graceDays int, paidAt time.Time) time.Time {
+	if currentEnd == nil {
+		return paidAt
+	}
+	if paidAt.Before(AccessUntil(*currentEnd, graceDays)) {
+		// Also when paid during the grace period: the days in grace were already used
+		return *currentEnd
+	}
+	return paidAt
+}
+
+// AccessUntil is when a subscriber loses access if the period is not renewed
+func AccessUntil(periodEnd time.Time, graceDays int) time.Time {
+	if graceDays < 0 {
+		graceDays = 0
+	}
+	return periodEnd.AddDate(0, 0, graceDays)
+}
+
+// SubscriptionStateAt derives the state of a paid subscription at now
+func SubscriptionStateAt(periodEnd time.Time, graceDays int, cancelAtPeriodEnd bool, now time.Time) string {
+	if now.Before(periodEnd) {
+		return SubscriptionActive
+	}
+	if cancelAtPeriodEnd {
+		return SubscriptionCancelled
+	}
+	if now.Before(AccessUntil(periodEnd, graceDays)) {
+		return SubscriptionPastDue
+	}
+	return SubscriptionExpired
+}
@@ -0,0 +1,68 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBillingPeriodEnd(t *testing.T) {
+	start := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+	if got := BillingPeriodEnd(start, BillingMonthly); !got.Equal(time.Date(2026, 2, 15, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected Feb 15, got %v", got)
+	}
+	if got := BillingPeriodEnd(start, BillingAnnual); !got.Equal(time.Date(2027, 1, 15, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected Jan 15 next year, got %v", got)
+	}
+
+	// Month ends are clamped instead of rolling into March
+	endOfJan := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+	if got := BillingPeriodEnd(endOfJan, BillingMonthly); !got.Equal(time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected Feb 28, got %v", got)
+	}
+}
+
+func TestRenewalPeriodStart(t *testing.T) {
+	end := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	if got := RenewalPeriodStart(nil, 3, end); !got.Equal(end) {
+		t.Errorf("Expected first period to start at payment, got %v", got)
+	}
+
+	early := end.AddDate(0, 0, -5)
+	if got := RenewalPeriodStart(&end, 3, early); !got.Equal(end) {
+		t.Errorf("Expected early renewal to continue after the current period, got %v", got)
+	}
+
+	inGrace := end.AddDate(0, 0, 2)
+	if got := RenewalPeriodStart(&end, 3, inGrace); !got.Equal(end) {
+		t.Errorf("Expected renewal in grace to continue after the current period, got %v", got)
+	}
+
+	lapsed := end.AddDate(0, 0, 10)
+	if got := RenewalPeriodStart(&end, 3, lapsed); !got.Equal(lapsed) {
+		t.Errorf("Expected lapsed renewal to start at payment, got %v", got)
+	}
+}
+
+func TestSubscriptionStateAt(t *testing.T) {
+	end := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		now    time.Time
+		cancel bool
+		want   string
+	}{
+		{"inside period", end.AddDate(0, 0, -1), false, SubscriptionActive},
+		{"cancelled but still paid", end.AddDate(0, 0, -1), true, SubscriptionActive},
+		{"in grace", end.AddDate(0, 0, 1), false, SubscriptionPastDue},
+		{"cancelled after period", end.AddDate(0, 0, 1), true, SubscriptionCancelled},
+		{"grace over", end.AddDate(0, 0, 4), false, SubscriptionExpired},
+	}
+
+	for _, tt := range tests {
+		if got := SubscriptionStateAt(end, 3, tt.cancel, tt.now); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package helpers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultUploadChunkSize is the chunk size suggested to clients of the resumable upload API
+const DefaultUploadChunkSize = 8 << 20
+
+// MaxUploadChunkSize is the largest chunk one append request may carry
+const MaxUploadChunkSize = 32 << 20
+
+// DefaultStorageQuotaBytes applies to organizations without their own quota (10 GB)
+const DefaultStorageQuotaBytes = 10 << 30
+
+// ResumableVideoExtensions are the session video formats accepted by resumable uploads
+var ResumableVideoExtensions = []string{".mp4", ".mov", ".m4v", ".mkv", ".webm"}
+
+// GenerateUploadID returns a random, URL-safe upload identifier
+func GenerateUploadID() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// IsResumableVideo reports whether a filename has an accepted video extension
+func IsResumableVideo(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	for _, allowed := range ResumableVideoExtensions {
+		if ext == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseUploadChecksum parses a tus Upload-Checksum header ("sha256 <base64 digest>").
+// Only sha256 is supported.
+func ParseUploadChecksum(header string) ([]byte, error) {
+	algo, encoded, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || strings.ToLower(algo) != "sha256" {
+		return nil, errors.New("Checksum harus berformat 'sha256 <base64>'")
+	}
+	sum, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(sum) != 32 {
+		return nil, errors.New("Checksum sha256 tidak valid")
+	}
+	return sum, nil
+}
+
+// UploadProgress is the uploaded share in percent, rounded to two decimals
+func UploadProgress(offset, size int64) float64 {
+	if size <= 0 {
+		return 0
+	}
+	return math.Round(float64(offset)/float64(size)*10000) / 100
+}
+
+// QuotaAllows reports whether size more bytes fit next to what is already used
+// or reserved. A quota of zero or less means unlimited.
+func QuotaAllows(used, size, quota int64) bool {
+	return quota <= 0 || used+size <= quota
+}
+
+// FormatBytes renders a byte count in Indonesian notation, e.g. "1,5 GB"
+func FormatBytes(n int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	value := float64(n)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", n)
+	}
+	s := fmt.Sprintf("%.1f", value)
+	s = strings.TrimSuffix(s, ".0")
+	return strings.Replace(s, ".", ",", 1) + " " + units[unit]
+}
@@ -0,0 +1,71 @@
+package helpers
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+)
+
+func TestGenerateUploadID(t *testing.T) {
+	a, b := GenerateUploadID(), GenerateUploadID()
+	if len(a) != 32 || a == b {
+		t.Errorf("Expected two distinct 32 character IDs, got %s and %s", a, b)
+	}
+}
+
+func TestIsResumableVideo(t *testing.T) {
+	for _, ok := range []string{"kuliah.mp4", "REKAMAN.MOV", "a.webm"} {
+		if !IsResumableVideo(ok) {
+			t.Errorf("Expected %s to be accepted", ok)
+		}
+	}
+	for _, bad := range []string{"modul.pdf", "video", "video.mp4.exe"} {
+		if IsResumableVideo(bad) {
+			t.Errorf("Expected %s to be rejected", bad)
+		}
+	}
+}
+
+func TestParseUploadChecksum(t *testing.T) {
+	sum := sha256.Sum256([]byte("chunk"))
+	got, err := ParseUploadChecksum("sha256 " + base64.StdEncoding.EncodeToString(sum[:]))
+	if err != nil || string(got) != string(sum[:]) {
+		t.Fatalf("Expected the digest back, got %x (%v)", got, err)
+	}
+
+	for _, bad := range []string{"", "md5 abc", "sha256", "sha256 not-base64!", "sha256 " + base64.StdEncoding.EncodeToString([]byte("short"))} {
+		if _, err := ParseUploadChecksum(bad); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestUploadProgressAndQuota(t *testing.T) {
+	if p := UploadProgress(1, 3); p != 33.33 {
+		t.Errorf("Expected 33.33, got %v", p)
+	}
+	if p := UploadProgress(0, 0); p != 0 {
+		t.Errorf("Expected 0 for an empty upload, got %v", p)
+	}
+
+	if !QuotaAllows(5, 5, 10) || QuotaAllows(6, 5, 10) {
+		t.Error("Expected the quota to allow exactly up to the limit")
+	}
+	if !QuotaAllows(1<<40, 1<<40, 0) {
+		t.Error("Expected a zero quota to mean unlimited")
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	cases := map[int64]string{
+		512:             "512 B",
+		1536:            "1,5 KB",
+		10 << 30:        "10 GB",
+		1<<30 + 512<<20: "1,5 GB",
+	}
+	for n, want := range cases {
+		if got := FormatBytes(n); got != want {
+			t.Errorf("FormatBytes(%d) = %s, want %s", n, got, want)
+		}
+	}
+}
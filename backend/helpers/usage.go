@@ -0,0 +1,41 @@
+package helpers
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// DefaultBandwidthQuotaBytes is the monthly streaming allowance of organizations
+// without a plan or their own quota (100 GB)
+const DefaultBandwidthQuotaBytes = 100 << 30
+
+// UsagePercent is used as a share of quota in percent, rounded to two decimals.
+// An unlimited quota (zero or less) reports 0.
+func UsagePercent(used, quota int64) float64 {
+	if quota <= 0 {
+		return 0
+	}
+	return math.Round(float64(used)/float64(quota)*10000) / 100
+}
+
+// OverQuota reports whether usage went past a limited quota
+func OverQuota(used, quota int64) bool {
+	return quota > 0 && used > quota
+}
+
+// UsageMonth returns the calendar month t falls in, as [start, end)
+func UsageMonth(t time.Time) (time.Time, time.Time) {
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	return start, start.AddDate(0, 1, 0)
+}
+
+// StorageCategory groups a storage key for the usage breakdown by its top folder:
+// "videos/session_1.mp4" -> "videos", "hls/12/720p/seg1.ts" -> "hls"
+func StorageCategory(key string) string {
+	folder, _, ok := strings.Cut(key, "/")
+	if !ok || folder == "" {
+		return "other"
+	}
+	return folder
+}
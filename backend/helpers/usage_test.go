@@ -0,0 +1,41 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUsagePercent(t *testing.T) {
+	if got := UsagePercent(1, 3); got != 33.33 {
+		t.Errorf("Expected 33.33, got %v", got)
+	}
+	if got := UsagePercent(150, 100); got != 150 {
+		t.Errorf("Expected usage past the quota to show, got %v", got)
+	}
+	if got := UsagePercent(5, 0); got != 0 {
+		t.Errorf("Expected 0 for an unlimited quota, got %v", got)
+	}
+	if !OverQuota(101, 100) || OverQuota(100, 100) || OverQuota(1<<40, 0) {
+		t.Error("Unexpected OverQuota result")
+	}
+}
+
+func TestUsageMonth(t *testing.T) {
+	wib := time.FixedZone("WIB", 7*3600)
+	start, end := UsageMonth(time.Date(2026, 12, 19, 15, 4, 0, 0, wib))
+	if !start.Equal(time.Date(2026, 12, 1, 0, 0, 0, 0, wib)) || !end.Equal(time.Date(2027, 1, 1, 0, 0, 0, 0, wib)) {
+		t.Errorf("Unexpected month %v - %v", start, end)
+	}
+}
+
+func TestStorageCategory(t *testing.T) {
+	for key, want := range map[string]string{
+		"videos/session_1.mp4": "videos",
+		"hls/12/720p/seg1.ts":  "hls",
+		"loose.png":            "other",
+	} {
+		if got := StorageCategory(key); got != want {
+			t.Errorf("StorageCategory(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package helpers
+
+import "math"
+
+// WatchHeartbeatSlack is the extra time (in seconds) granted on top of the wall
+// clock between two heartbeats, for network jitter and a late first beat
+const WatchHeartbeatSlack = 5
+
+// WatchMaxPlaybackRate is the fastest playback speed the player offers
+const WatchMaxPlaybackRate = 2
+
+// WatchCompletePercent marks a single video as completed; credits and the last
+// few seconds are usually skipped
+const WatchCompletePercent = 95
+
+// CreditWatchTime returns how many seconds of a heartbeat count as watched.
+// Only forward playback counts, and never more than the player could have
+// played since the previous heartbeat, so seeking ahead earns nothing.
+func CreditWatchTime(prevPosition, position, elapsedSeconds float64) float64 {
+	delta := position - prevPosition
+	if delta <= 0 || elapsedSeconds < 0 {
+		return 0
+	}
+	limit := (elapsedSeconds + WatchHeartbeatSlack) * WatchMaxPlaybackRate
+	if delta > limit {
+		return 0
+	}
+	return delta
+}
+
+// WatchPercent is watched time over duration, capped at 100 and rounded to 2 decimals
+func WatchPercent(watchedSeconds, durationSeconds float64) float64 {
+	if durationSeconds <= 0 || watchedSeconds <= 0 {
+		return 0
+	}
+	p := math.Min(100, watchedSeconds/durationSeconds*100)
+	return math.Round(p*100) / 100
+}
+
+// VideoWatch is one video's duration and a viewer's watched time
+type VideoWatch struct {
+	DurationSeconds float64
+	WatchedSeconds  float64
+}
+
+// SessionWatchPercent weighs each video by its duration. Watched time beyond a
+// video's length (rewatching) does not make up for another video.
+func SessionWatchPercent(videos []VideoWatch) float64 {
+	var total, watched float64
+	for _, v := range videos {
+		if v.DurationSeconds <= 0 {
+			continue
+		}
+		total += v.DurationSeconds
+		watched += math.Min(math.Max(v.WatchedSeconds, 0), v.DurationSeconds)
+	}
+	return WatchPercent(watched, total)
+}
+
+// WatchRequirementMet reports whether a session's watch percentage satisfies
+// the event's minimum; a minimum of 0 disables the rule
+func WatchRequirementMet(percent float64, minPercent int) bool {
+	return minPercent <= 0 || percent >= float64(minPercent)
+}
@@ -0,0 +1,64 @@
+package helpers
+
+import "testing"
+
+func TestCreditWatchTime(t *testing.T) {
+	cases := []struct {
+		prev, pos, elapsed float64
+		want               float64
+	}{
+		{0, 15, 15, 15},  // normal playback
+		{10, 40, 15, 30}, // 2x speed
+		{0, 300, 15, 0},  // seek ahead
+		{120, 60, 15, 0}, // seek back
+		{30, 30, 15, 0},  // paused
+		{0, 10, 0, 10},   // first beat arrives right after the start
+		{0, 15, -1, 0},   // clock skew
+	}
+	for _, tc := range cases {
+		if got := CreditWatchTime(tc.prev, tc.pos, tc.elapsed); got != tc.want {
+			t.Errorf("CreditWatchTime(%v, %v, %v): expected %v, got %v", tc.prev, tc.pos, tc.elapsed, tc.want, got)
+		}
+	}
+}
+
+func TestWatchPercent(t *testing.T) {
+	if p := WatchPercent(30, 120); p != 25 {
+		t.Errorf("Expected 25, got %v", p)
+	}
+	if p := WatchPercent(200, 120); p != 100 {
+		t.Errorf("Expected the percentage to be capped at 100, got %v", p)
+	}
+	if p := WatchPercent(10, 0); p != 0 {
+		t.Errorf("Expected 0 without a duration, got %v", p)
+	}
+	if p := WatchPercent(1, 3); p != 33.33 {
+		t.Errorf("Expected 33.33, got %v", p)
+	}
+}
+
+func TestSessionWatchPercent(t *testing.T) {
+	videos := []VideoWatch{
+		{DurationSeconds: 300, WatchedSeconds: 900}, // rewatched, still counts once
+		{DurationSeconds: 100, WatchedSeconds: 0},
+		{DurationSeconds: 0, WatchedSeconds: 50}, // unknown length is ignored
+	}
+	if p := SessionWatchPercent(videos); p != 75 {
+		t.Errorf("Expected 75, got %v", p)
+	}
+	if p := SessionWatchPercent(nil); p != 0 {
+		t.Errorf("Expected 0 for a session without videos, got %v", p)
+	}
+}
+
+func TestWatchRequirementMet(t *testing.T) {
+	if !WatchRequirementMet(0, 0) {
+		t.Error("Expected a 0% minimum to always pass")
+	}
+	if WatchRequirementMet(79.9, 80) {
+		t.Error("Expected 79.9% to miss an 80% minimum")
+	}
+	if !WatchRequirementMet(80, 80) {
+		t.Error("Expected 80% to meet an 80% minimum")
+	}
+}
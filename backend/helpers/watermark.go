@@ -0,0 +1,67 @@
+package helpers
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// GeneratePlaybackID returns a random identifier for one viewing of a video
+func GeneratePlaybackID() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// WatermarkCode is a short code shown in a playback's overlay. It identifies
+// the playback (and so the account) behind a leaked screen recording.
+func WatermarkCode(userID, videoID int64, playbackID string) string {
+	h := hmac.New(sha256.New, SignedSecret)
+	fmt.Fprintf(h, "watermark|%d|%d|%s", userID, videoID, playbackID)
+	code := base32.StdEncoding.EncodeToString(h.Sum(nil))[:12]
+	return code[:4] + "-" + code[4:8] + "-" + code[8:]
+}
+
+// NormalizeWatermarkCode accepts a code as typed from a recording: any case,
+// with or without dashes
+func NormalizeWatermarkCode(code string) string {
+	code = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	if len(code) != 12 {
+		return code
+	}
+	return code[:4] + "-" + code[4:8] + "-" + code[8:]
+}
+
+// WatermarkText is the visible overlay line: who is watching and the playback code
+func WatermarkText(email string, userID int64, code string) string {
+	return fmt.Sprintf("%s · #%d · %s", email, userID, code)
+}
+
+// WatermarkPosition is where the overlay sits, in percent of the frame
+type WatermarkPosition struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// WatermarkPositions returns n overlay positions the player cycles through, so
+// the mark cannot be cropped or blurred out at a fixed spot. They are derived
+// from the code, so a frame's position also hints at which playback it is.
+func WatermarkPositions(code string, n int) []WatermarkPosition {
+	positions := make([]WatermarkPosition, 0, n)
+	for i := 0; i < n; i++ {
+		h := hmac.New(sha256.New, SignedSecret)
+		fmt.Fprintf(h, "position|%s|%d", code, i)
+		sum := h.Sum(nil)
+		// Keep the text inside the frame: 5-75% across, 5-90% down
+		positions = append(positions, WatermarkPosition{
+			X: 5 + int(binary.BigEndian.Uint32(sum[0:4])%71),
+			Y: 5 + int(binary.BigEndian.Uint32(sum[4:8])%86),
+		})
+	}
+	return positions
+}
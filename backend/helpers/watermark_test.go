@@ -0,0 +1,48 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWatermarkCode(t *testing.T) {
+	code := WatermarkCode(42, 7, "abc")
+	if len(code) != 14 || code[4] != '-' || code[9] != '-' {
+		t.Fatalf("Expected a XXXX-XXXX-XXXX code, got %q", code)
+	}
+	if WatermarkCode(42, 7, "abc") != code {
+		t.Error("Expected the code to be stable")
+	}
+	if WatermarkCode(43, 7, "abc") == code || WatermarkCode(42, 7, "abd") == code {
+		t.Error("Expected another user or playback to get another code")
+	}
+	if got := NormalizeWatermarkCode(strings.ToLower(strings.ReplaceAll(code, "-", ""))); got != code {
+		t.Errorf("Expected %s, got %s", code, got)
+	}
+}
+
+func TestWatermarkText(t *testing.T) {
+	if got := WatermarkText("budi@mail.com", 42, "ABCD-EFGH-IJKL"); got != "budi@mail.com · #42 · ABCD-EFGH-IJKL" {
+		t.Errorf("Unexpected watermark text %q", got)
+	}
+}
+
+func TestWatermarkPositions(t *testing.T) {
+	positions := WatermarkPositions("ABCD-EFGH-IJKL", 8)
+	if len(positions) != 8 {
+		t.Fatalf("Expected 8 positions, got %d", len(positions))
+	}
+	distinct := map[WatermarkPosition]bool{}
+	for _, p := range positions {
+		if p.X < 5 || p.X > 75 || p.Y < 5 || p.Y > 90 {
+			t.Errorf("Position %+v is outside the frame", p)
+		}
+		distinct[p] = true
+	}
+	if len(distinct) < 2 {
+		t.Error("Expected the overlay to move around")
+	}
+	if WatermarkPositions("ABCD-EFGH-IJKL", 8)[3] != positions[3] {
+		t.Error("Expected positions to be stable for a code")
+	}
+}
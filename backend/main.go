@@ -8,6 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"BACKEND/config"
+	"BACKEND/controllers"
 	"BACKEND/routes"
 )
 
@@ -17,6 +18,17 @@ func startAutoPublishJob() {
 		defer ticker.Stop()
 
 		for range ticker.C {
+			// Catat yang akan dipublish, untuk notifikasi wishlist
+			var eventIDs, sessionIDs []int64
+			config.DB.Select(&eventIDs, `
+				SELECT id FROM events
+				WHERE publish_status = 'SCHEDULED' AND publish_at IS NOT NULL AND publish_at <= NOW()
+			`)
+			config.DB.Select(&sessionIDs, `
+				SELECT id FROM sessions
+				WHERE publish_status = 'SCHEDULED' AND publish_at <= NOW()
+			`)
+
 			// Update semua event yang statusnya SCHEDULED
 			// dan waktu publish_at sudah lewat / sama dengan sekarang
 			res, err := config.DB.Exec(`
@@ -50,6 +62,89 @@ func startAutoPublishJob() {
 			if affected > 0 {
 				log.Printf("✅ Auto publish: %d event(s) changed to PUBLISHED\n", affected)
 			}
+
+			if len(eventIDs) > 0 || len(sessionIDs) > 0 {
+				go controllers.NotifyWishlistPublished(eventIDs, sessionIDs)
+			}
+		}
+	}()
+}
+
+// startPendingOrderReconcileJob closes or settles PENDING orders whose webhook never arrived
+func startPendingOrderReconcileJob() {
+	if os.Getenv("MIDTRANS_SERVER_KEY") == "" {
+		log.Println("⚠️ MIDTRANS_SERVER_KEY not set, pending order reconciler disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(15 * time.Minute) // cek tiap 15 menit
+		defer ticker.Stop()
+
+		for range ticker.C {
+			report := controllers.ReconcilePendingOrders()
+			if report.Discrepancies > 0 {
+				log.Printf("⚠️ Reconciler: %d discrepancy(ies) found, see /api/admin/payments/reconciliation-logs\n", report.Discrepancies)
+			}
+		}
+	}()
+}
+
+// startSubscriptionBillingJob sends renewal reminders and closes subscription periods
+func startSubscriptionBillingJob() {
+	go func() {
+		ticker := time.NewTicker(1 * time.Hour) // cek tiap 1 jam
+		defer ticker.Stop()
+
+		for range ticker.C {
+			controllers.RunSubscriptionBilling()
+		}
+	}()
+}
+
+// startVideoTranscodeJob turns queued session videos into HLS renditions
+func startVideoTranscodeJob() {
+	if config.Transcoder == nil {
+		log.Println("⚠️ ffmpeg not available, video transcode job disabled")
+		return
+	}
+	controllers.ResetStaleTranscodes()
+
+	go func() {
+		ticker := time.NewTicker(1 * time.Minute) // cek tiap 1 menit
+		defer ticker.Stop()
+
+		for ; ; <-ticker.C {
+			controllers.RunVideoTranscodeQueue()
+		}
+	}()
+}
+
+// startUploadCleanupJob removes resumable uploads that were abandoned half way
+func startUploadCleanupJob() {
+	go func() {
+		ticker := time.NewTicker(1 * time.Hour) // cek tiap 1 jam
+		defer ticker.Stop()
+
+		for range ticker.C {
+			controllers.CleanupAbandonedUploads()
+		}
+	}()
+}
+
+// startStorageUsageBackfill builds the storage accounting once after it is deployed
+func startStorageUsageBackfill() {
+	go controllers.BackfillStorageUsage()
+}
+
+// startStorageGCJob looks for orphaned storage objects once a day
+func startStorageGCJob() {
+	go func() {
+		ticker := time.NewTicker(24 * time.Hour) // cek tiap hari
+		defer ticker.Stop()
+
+		for range ticker.C {
+			controllers.RunScheduledStorageGC()
 		}
 	}()
 }
@@ -60,10 +155,32 @@ func main() {
 	config.ConnectDB()
 	config.SetupCORS(r)
 	config.InitMidtrans() // Initialize Midtrans
+	config.InitStorage()  // Local disk, S3-compatible atau Supabase
+	config.InitTranscoder()
+	config.InitScanner()
+	config.InitPlayback()
 
 	// Jalankan cron auto publish
 	startAutoPublishJob()
 
+	// Jalankan rekonsiliasi pesanan PENDING yang menggantung
+	startPendingOrderReconcileJob()
+
+	// Jalankan pengingat & status langganan
+	startSubscriptionBillingJob()
+
+	// Jalankan antrean transcoding HLS
+	startVideoTranscodeJob()
+
+	// Bersihkan upload video yang ditinggalkan
+	startUploadCleanupJob()
+
+	// Cari file storage yang tidak lagi dipakai
+	startStorageGCJob()
+
+	// Hitung pemakaian storage organisasi yang sudah ada sebelum akunting storage
+	startStorageUsageBackfill()
+
 	// --- PENTING: Serve Static Files (Untuk Thumbnail) ---
 	// Ini agar URL seperti http://localhost:8080/uploads/events/xxx.jpg bisa dibuka
 	r.Static("/uploads", "./uploads")
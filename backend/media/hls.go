@@ -0,0 +1,163 @@
+package media
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// MasterPlaylistName is the entry point of a transcoded video
+const MasterPlaylistName = "master.m3u8"
+
+// Variant is a rendition as it ended up after scaling
+type Variant struct {
+	Rendition
+	Width int
+	URI   string // relative to the master playlist, e.g. "720p/index.m3u8"
+}
+
+// SelectRenditions keeps the renditions that do not upscale the source.
+// A source smaller than every rendition still gets the smallest one.
+func SelectRenditions(sourceHeight int, all []Rendition) []Rendition {
+	var out []Rendition
+	for _, r := range all {
+		if r.Height <= sourceHeight {
+			out = append(out, r)
+		}
+	}
+	if len(out) == 0 && len(all) > 0 {
+		smallest := all[0]
+		for _, r := range all[1:] {
+			if r.Height < smallest.Height {
+				smallest = r
+			}
+		}
+		out = []Rendition{smallest}
+	}
+	return out
+}
+
+// ScaledWidth is the width ffmpeg's scale=-2:height produces (aspect kept, rounded to even)
+func ScaledWidth(srcWidth, srcHeight, height int) int {
+	if srcWidth <= 0 || srcHeight <= 0 {
+		return 0
+	}
+	w := (srcWidth*height + srcHeight/2) / srcHeight
+	if w%2 == 1 {
+		w++
+	}
+	return w
+}
+
+// PosterTime picks the frame for the poster: 10% into the video, at most 5 seconds in
+func PosterTime(duration float64) float64 {
+	at := duration * 0.1
+	if at > 5 {
+		at = 5
+	}
+	return at
+}
+
+// MasterPlaylist renders the master playlist listing every variant
+func MasterPlaylist(variants []Variant) string {
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n")
+	for _, v := range variants {
+		bandwidth := (v.VideoBitrate + v.AudioBitrate) * 1000
+		fmt.Fprintf(&b, "#EXT-X-STREAM-INF:BANDWIDTH=%d,AVERAGE-BANDWIDTH=%d", bandwidth*107/100, bandwidth)
+		if v.Width > 0 {
+			fmt.Fprintf(&b, ",RESOLUTION=%dx%d", v.Width, v.Height)
+		}
+		fmt.Fprintf(&b, ",NAME=\"%s\"\n%s\n", v.Name, v.URI)
+	}
+	return b.String()
+}
+
+// WatermarkDataID names the EXT-X-SESSION-DATA entry carrying a viewer's watermark
+const WatermarkDataID = "com.webbinar.watermark"
+
+// AddSessionData adds an EXT-X-SESSION-DATA tag to a master playlist, right
+// after its header lines. Quotes and line breaks cannot appear in a quoted
+// attribute, so they are dropped from value.
+func AddSessionData(playlist, dataID, value string) string {
+	value = strings.NewReplacer(`"`, "", "\n", " ", "\r", " ").Replace(value)
+	tag := fmt.Sprintf("#EXT-X-SESSION-DATA:DATA-ID=\"%s\",VALUE=\"%s\"", dataID, value)
+
+	lines := strings.Split(playlist, "\n")
+	at := 0
+	for at < len(lines) {
+		l := strings.TrimSpace(lines[at])
+		if l != "#EXTM3U" && !strings.HasPrefix(l, "#EXT-X-VERSION") && !strings.HasPrefix(l, "#EXT-X-INDEPENDENT-SEGMENTS") {
+			break
+		}
+		at++
+	}
+	lines = append(lines[:at], append([]string{tag}, lines[at:]...)...)
+	return strings.Join(lines, "\n")
+}
+
+var uriAttr = regexp.MustCompile(`URI="([^"]*)"`)
+
+// RewritePlaylist passes every URI in a playlist (segment and playlist lines as
+// well as URI="..." attributes of tags such as EXT-X-MAP and EXT-X-KEY) through
+// rewrite, so each one can carry its own signature
+func RewritePlaylist(playlist string, rewrite func(uri string) string) string {
+	lines := strings.Split(playlist, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, "#"):
+			lines[i] = uriAttr.ReplaceAllStringFunc(line, func(m string) string {
+				return `URI="` + rewrite(uriAttr.FindStringSubmatch(m)[1]) + `"`
+			})
+		default:
+			lines[i] = rewrite(trimmed)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ResolveURI resolves a URI found in the playlist at playlistPath into a path
+// relative to the video's HLS root. Absolute and external URIs are rejected.
+func ResolveURI(playlistPath, uri string) (string, bool) {
+	if uri == "" || strings.Contains(uri, "://") || strings.HasPrefix(uri, "/") {
+		return "", false
+	}
+	if i := strings.IndexAny(uri, "?#"); i >= 0 {
+		uri = uri[:i]
+	}
+	resolved := path.Join(path.Dir(playlistPath), uri)
+	if resolved == "." || resolved == ".." || strings.HasPrefix(resolved, "../") {
+		return "", false
+	}
+	return resolved, true
+}
+
+// PlaylistURIs lists the resolved URIs a playlist refers to
+func PlaylistURIs(playlistPath, playlist string) []string {
+	var out []string
+	RewritePlaylist(playlist, func(uri string) string {
+		if p, ok := ResolveURI(playlistPath, uri); ok {
+			out = append(out, p)
+		}
+		return uri
+	})
+	return out
+}
+
+// ContentType of an HLS output file
+func ContentType(name string) string {
+	switch path.Ext(name) {
+	case ".m3u8":
+		return "application/vnd.apple.mpegurl"
+	case ".ts":
+		return "video/mp2t"
+	case ".m4s", ".mp4":
+		return "video/mp4"
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,346 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"strings"
+
+	_ "image/gif"
+
+	"BACKEND/filecheck"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// ImageFit decides how an image is sized into a variant's box
+type ImageFit int
+
+const (
+	// FitCover fills the box and crops the overflow around the centre
+	FitCover ImageFit = iota
+	// FitContain fits the whole image inside the box
+	FitContain
+)
+
+// ImageVariant is one standard size derived from an uploaded image.
+// Images are never upscaled.
+type ImageVariant struct {
+	Name   string
+	Width  int
+	Height int
+	Fit    ImageFit
+}
+
+var (
+	CardVariant   = ImageVariant{Name: "card", Width: 640, Height: 360, Fit: FitCover}
+	HeroVariant   = ImageVariant{Name: "hero", Width: 1600, Height: 900, Fit: FitCover}
+	AvatarVariant = ImageVariant{Name: "avatar", Width: 256, Height: 256, Fit: FitCover}
+)
+
+// Variant sets per upload slot
+var (
+	EventThumbnailVariants = []ImageVariant{CardVariant, HeroVariant}
+	ProfileImageVariants   = []ImageVariant{AvatarVariant}
+	// Logos are never cropped
+	LogoVariants = []ImageVariant{{Name: "avatar", Width: 256, Height: 256, Fit: FitContain}}
+	// Banners keep their own aspect ratio per placement
+	AdBannerVariants = []ImageVariant{
+		{Name: "card", Width: 640, Height: 360, Fit: FitContain},
+		{Name: "hero", Width: 1600, Height: 900, Fit: FitContain},
+	}
+)
+
+// JPEGQuality is used for opaque variants; images with transparency are encoded as PNG
+const JPEGQuality = 82
+
+// MaxImagePixels guards against decompression bombs (about 40 megapixels)
+const MaxImagePixels = 40_000_000
+
+// EncodedImage is one rendered variant
+type EncodedImage struct {
+	Name        string
+	Data        []byte
+	ContentType string
+	Ext         string
+	Width       int
+	Height      int
+}
+
+// ProcessedImage is the result of ProcessImage
+type ProcessedImage struct {
+	Width         int
+	Height        int
+	Blurhash      string
+	DominantColor string
+	Variants      []EncodedImage
+}
+
+// ProcessImage decodes a JPEG, PNG, GIF or WebP image, applies its EXIF
+// orientation and renders the given variants plus a blurhash placeholder and
+// dominant colour
+func ProcessImage(data []byte, variants []ImageVariant) (*ProcessedImage, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %v", err)
+	}
+	if cfg.Width*cfg.Height > MaxImagePixels {
+		return nil, errors.New("image too large to process")
+	}
+	src, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %v", err)
+	}
+	if format == "jpeg" {
+		src = orient(src, filecheck.JPEGOrientation(data))
+	}
+
+	b := src.Bounds()
+	out := &ProcessedImage{Width: b.Dx(), Height: b.Dy()}
+	opaque := isOpaque(src)
+
+	small := resize(src, b, fitSize(b.Dx(), b.Dy(), 64, 64), draw.ApproxBiLinear)
+	out.Blurhash = Blurhash(small, 4, 3)
+	out.DominantColor = DominantColor(small)
+
+	for _, v := range variants {
+		crop, size := variantRect(b, v)
+		img := resize(src, crop, size, draw.CatmullRom)
+		encoded, err := encodeImage(img, opaque)
+		if err != nil {
+			return nil, err
+		}
+		encoded.Name = v.Name
+		encoded.Width, encoded.Height = size.X, size.Y
+		out.Variants = append(out.Variants, encoded)
+	}
+	return out, nil
+}
+
+// variantRect returns the source rectangle to use and the output size
+func variantRect(b image.Rectangle, v ImageVariant) (image.Rectangle, image.Point) {
+	w, h := b.Dx(), b.Dy()
+	if v.Fit == FitContain {
+		return b, fitSize(w, h, v.Width, v.Height)
+	}
+
+	// Crop the centre to the box's aspect ratio, then scale down
+	cw, ch := w, h
+	if w*v.Height > h*v.Width {
+		cw = max(1, int(math.Round(float64(h)*float64(v.Width)/float64(v.Height))))
+	} else {
+		ch = max(1, int(math.Round(float64(w)*float64(v.Height)/float64(v.Width))))
+	}
+	x0 := b.Min.X + (w-cw)/2
+	y0 := b.Min.Y + (h-ch)/2
+	crop := image.Rect(x0, y0, x0+cw, y0+ch)
+	if cw <= v.Width {
+		return crop, image.Pt(cw, ch)
+	}
+	return crop, image.Pt(v.Width, v.Height)
+}
+
+// fitSize scales w x h down to fit inside maxW x maxH, keeping the aspect ratio
+func fitSize(w, h, maxW, maxH int) image.Point {
+	scale := math.Min(1, math.Min(float64(maxW)/float64(w), float64(maxH)/float64(h)))
+	return image.Pt(max(1, int(math.Round(float64(w)*scale))), max(1, int(math.Round(float64(h)*scale))))
+}
+
+func resize(src image.Image, from image.Rectangle, size image.Point, scaler draw.Scaler) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+	scaler.Scale(dst, dst.Bounds(), src, from, draw.Src, nil)
+	return dst
+}
+
+func encodeImage(img image.Image, opaque bool) (EncodedImage, error) {
+	var buf bytes.Buffer
+	if opaque {
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: JPEGQuality}); err != nil {
+			return EncodedImage{}, err
+		}
+		return EncodedImage{Data: buf.Bytes(), ContentType: "image/jpeg", Ext: ".jpg"}, nil
+	}
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := enc.Encode(&buf, img); err != nil {
+		return EncodedImage{}, err
+	}
+	return EncodedImage{Data: buf.Bytes(), ContentType: "image/png", Ext: ".png"}, nil
+}
+
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a != 0xffff {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// orient applies an EXIF orientation (1-8) so the image displays upright
+func orient(src image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirrored
+				dx, dy = w-1-x, y
+			case 3: // rotated 180°
+				dx, dy = w-1-x, h-1-y
+			case 4: // flipped
+				dx, dy = x, h-1-y
+			case 5: // transposed
+				dx, dy = y, x
+			case 6: // rotated 90° clockwise
+				dx, dy = h-1-y, x
+			case 7: // transversed
+				dx, dy = h-1-y, w-1-x
+			case 8: // rotated 90° counter-clockwise
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, src.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+// DominantColor is the most common colour of an image as "#rrggbb", averaged
+// within a 4-bit-per-channel bucket. Fully transparent pixels are ignored.
+func DominantColor(img image.Image) string {
+	type bucket struct{ n, r, g, b int }
+	buckets := map[int]*bucket{}
+	var best *bucket
+
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if c.A == 0 {
+				continue
+			}
+			key := int(c.R>>4)<<8 | int(c.G>>4)<<4 | int(c.B>>4)
+			bk := buckets[key]
+			if bk == nil {
+				bk = &bucket{}
+				buckets[key] = bk
+			}
+			bk.n++
+			bk.r += int(c.R)
+			bk.g += int(c.G)
+			bk.b += int(c.B)
+			if best == nil || bk.n > best.n {
+				best = bk
+			}
+		}
+	}
+	if best == nil {
+		return "#000000"
+	}
+	return fmt.Sprintf("#%02x%02x%02x", best.r/best.n, best.g/best.n, best.b/best.n)
+}
+
+const base83Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+func encode83(value, length int) string {
+	var sb strings.Builder
+	for i := 1; i <= length; i++ {
+		digit := (value / int(math.Pow(83, float64(length-i)))) % 83
+		sb.WriteByte(base83Chars[digit])
+	}
+	return sb.String()
+}
+
+func srgbToLinear(v uint8) float64 {
+	f := float64(v) / 255
+	if f <= 0.04045 {
+		return f / 12.92
+	}
+	return math.Pow((f+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(v float64) int {
+	v = math.Max(0, math.Min(1, v))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(v, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(v), exp), v)
+}
+
+// Blurhash encodes img as a blurhash (https://blurha.sh) with xComp x yComp
+// components (1-9 each). Pass a small image; the cost grows with its pixel count.
+func Blurhash(img image.Image, xComp, yComp int) string {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	factors := make([][3]float64, 0, xComp*yComp)
+	for j := 0; j < yComp; j++ {
+		for i := 0; i < xComp; i++ {
+			var r, g, bl float64
+			for y := 0; y < h; y++ {
+				for x := 0; x < w; x++ {
+					basis := math.Cos(math.Pi*float64(i)*float64(x)/float64(w)) * math.Cos(math.Pi*float64(j)*float64(y)/float64(h))
+					c := color.NRGBAModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA)
+					r += basis * srgbToLinear(c.R)
+					g += basis * srgbToLinear(c.G)
+					bl += basis * srgbToLinear(c.B)
+				}
+			}
+			norm := 2.0
+			if i == 0 && j == 0 {
+				norm = 1
+			}
+			scale := norm / float64(w*h)
+			factors = append(factors, [3]float64{r * scale, g * scale, bl * scale})
+		}
+	}
+
+	dc, ac := factors[0], factors[1:]
+	hash := encode83((xComp-1)+(yComp-1)*9, 1)
+
+	maxValue := 1.0
+	if len(ac) > 0 {
+		actualMax := 0.0
+		for _, f := range ac {
+			actualMax = math.Max(actualMax, math.Max(math.Abs(f[0]), math.Max(math.Abs(f[1]), math.Abs(f[2]))))
+		}
+		quantisedMax := int(math.Max(0, math.Min(82, math.Floor(actualMax*166-0.5))))
+		maxValue = float64(quantisedMax+1) / 166
+		hash += encode83(quantisedMax, 1)
+	} else {
+		hash += encode83(0, 1)
+	}
+
+	hash += encode83(linearToSRGB(dc[0])<<16|linearToSRGB(dc[1])<<8|linearToSRGB(dc[2]), 4)
+	for _, f := range ac {
+		q := func(v float64) int {
+			return int(math.Max(0, math.Min(18, math.Floor(signPow(v/maxValue, 0.5)*9+9.5))))
+		}
+		hash += encode83(q(f[0])*19*19+q(f[1])*19+q(f[2]), 2)
+	}
+	return hash
+}
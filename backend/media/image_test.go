@@ -0,0 +1,125 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func solidImage(w, h int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), &image.Uniform{c}, image.Point{}, draw.Src)
+	return img
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("jpeg.Encode: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func variantSizes(p *ProcessedImage) map[string]image.Point {
+	out := map[string]image.Point{}
+	for _, v := range p.Variants {
+		out[v.Name] = image.Pt(v.Width, v.Height)
+	}
+	return out
+}
+
+func TestProcessImage_Variants(t *testing.T) {
+	p, err := ProcessImage(encodeJPEG(t, solidImage(2000, 1000, color.RGBA{200, 10, 10, 255})), EventThumbnailVariants)
+	if err != nil {
+		t.Fatalf("ProcessImage: %v", err)
+	}
+	sizes := variantSizes(p)
+	if sizes["card"] != image.Pt(640, 360) || sizes["hero"] != image.Pt(1600, 900) {
+		t.Errorf("Unexpected variant sizes %v", sizes)
+	}
+	for _, v := range p.Variants {
+		if v.ContentType != "image/jpeg" || v.Ext != ".jpg" {
+			t.Errorf("Expected opaque variants as JPEG, got %s", v.ContentType)
+		}
+		decoded, err := jpeg.Decode(bytes.NewReader(v.Data))
+		if err != nil || decoded.Bounds().Dx() != v.Width {
+			t.Errorf("Expected a decodable %dpx JPEG for %s (%v)", v.Width, v.Name, err)
+		}
+	}
+	if !strings.HasPrefix(p.DominantColor, "#c") {
+		t.Errorf("Expected a red dominant colour, got %s", p.DominantColor)
+	}
+
+	// Small sources are cropped but never upscaled
+	p, _ = ProcessImage(encodeJPEG(t, solidImage(300, 200, color.White)), EventThumbnailVariants)
+	if sizes := variantSizes(p); sizes["card"] != image.Pt(300, 169) || sizes["hero"] != image.Pt(300, 169) {
+		t.Errorf("Expected 300x169 crops, got %v", sizes)
+	}
+}
+
+func TestProcessImage_TransparentLogoStaysPNG(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, solidImage(512, 256, color.RGBA{0, 0, 0, 0}))
+
+	p, err := ProcessImage(buf.Bytes(), LogoVariants)
+	if err != nil {
+		t.Fatalf("ProcessImage: %v", err)
+	}
+	v := p.Variants[0]
+	if v.ContentType != "image/png" || v.Width != 256 || v.Height != 128 {
+		t.Errorf("Expected a 256x128 PNG, got %s %dx%d", v.ContentType, v.Width, v.Height)
+	}
+}
+
+func TestProcessImage_AppliesOrientation(t *testing.T) {
+	img := encodeJPEG(t, solidImage(40, 20, color.White))
+	// APP1 EXIF with orientation 6 (rotate 90° clockwise)
+	exif := []byte("\xFF\xE1\x00\x22Exif\x00\x00MM\x00\x2A\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01\x00\x06\x00\x00\x00\x00\x00\x00")
+	rotated := append(append([]byte{0xFF, 0xD8}, exif...), img[2:]...)
+
+	p, err := ProcessImage(rotated, nil)
+	if err != nil {
+		t.Fatalf("ProcessImage: %v", err)
+	}
+	if p.Width != 20 || p.Height != 40 {
+		t.Errorf("Expected a 20x40 upright image, got %dx%d", p.Width, p.Height)
+	}
+}
+
+func TestProcessImage_RejectsGarbage(t *testing.T) {
+	if _, err := ProcessImage([]byte("not an image"), EventThumbnailVariants); err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestBlurhash(t *testing.T) {
+	// "L" is 4x3 components, "TSUA" the white DC colour
+	white := Blurhash(solidImage(16, 16, color.White), 4, 3)
+	if len(white) != 28 || white[0] != 'L' || white[2:6] != "TSUA" {
+		t.Errorf("Unexpected blurhash for a white image: %s", white)
+	}
+
+	// 4x3 components give 1+1+4+2*11 characters
+	img := solidImage(16, 16, color.White)
+	draw.Draw(img, image.Rect(0, 0, 8, 16), &image.Uniform{color.Black}, image.Point{}, draw.Src)
+	if h := Blurhash(img, 4, 3); len(h) != 28 || h == white {
+		t.Errorf("Unexpected blurhash %q", h)
+	}
+}
+
+func TestDominantColor(t *testing.T) {
+	img := solidImage(10, 10, color.RGBA{200, 10, 10, 255})
+	draw.Draw(img, image.Rect(0, 0, 10, 3), &image.Uniform{color.RGBA{0, 0, 255, 255}}, image.Point{}, draw.Src)
+	if c := DominantColor(img); c != "#c80a0a" {
+		t.Errorf("Expected #c80a0a, got %s", c)
+	}
+	if c := DominantColor(solidImage(2, 2, color.Transparent)); c != "#000000" {
+		t.Errorf("Expected black for a transparent image, got %s", c)
+	}
+}
@@ -0,0 +1,133 @@
+package media
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSelectRenditions(t *testing.T) {
+	names := func(rs []Rendition) []string {
+		var out []string
+		for _, r := range rs {
+			out = append(out, r.Name)
+		}
+		return out
+	}
+
+	if got := names(SelectRenditions(720, DefaultRenditions)); !reflect.DeepEqual(got, []string{"360p", "480p", "720p"}) {
+		t.Errorf("720p source: got %v", got)
+	}
+	if got := names(SelectRenditions(2160, DefaultRenditions)); len(got) != len(DefaultRenditions) {
+		t.Errorf("4K source should get every rendition, got %v", got)
+	}
+	if got := names(SelectRenditions(240, DefaultRenditions)); !reflect.DeepEqual(got, []string{"360p"}) {
+		t.Errorf("Tiny source should still get the smallest rendition, got %v", got)
+	}
+}
+
+func TestScaledWidthAndPosterTime(t *testing.T) {
+	if w := ScaledWidth(1920, 1080, 720); w != 1280 {
+		t.Errorf("Expected 1280, got %d", w)
+	}
+	if w := ScaledWidth(1000, 750, 360); w%2 != 0 || w != 480 {
+		t.Errorf("Expected an even 480, got %d", w)
+	}
+	if at := PosterTime(20); at != 2 {
+		t.Errorf("Expected 2s into a 20s video, got %v", at)
+	}
+	if at := PosterTime(3600); at != 5 {
+		t.Errorf("Expected the poster time to be capped at 5s, got %v", at)
+	}
+}
+
+func TestMasterPlaylist(t *testing.T) {
+	pl := MasterPlaylist([]Variant{
+		{Rendition: DefaultRenditions[0], Width: 640, URI: "360p/index.m3u8"},
+		{Rendition: DefaultRenditions[2], Width: 1280, URI: "720p/index.m3u8"},
+	})
+	want := "#EXTM3U\n#EXT-X-VERSION:3\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=958720,AVERAGE-BANDWIDTH=896000,RESOLUTION=640x360,NAME=\"360p\"\n360p/index.m3u8\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=3132960,AVERAGE-BANDWIDTH=2928000,RESOLUTION=1280x720,NAME=\"720p\"\n720p/index.m3u8\n"
+	if pl != want {
+		t.Errorf("Unexpected master playlist:\n%s", pl)
+	}
+}
+
+func TestAddSessionData(t *testing.T) {
+	pl := "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-STREAM-INF:BANDWIDTH=1\n360p/index.m3u8\n"
+	out := AddSessionData(pl, WatermarkDataID, "budi \"x\"\n#42")
+	want := "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-SESSION-DATA:DATA-ID=\"com.webbinar.watermark\",VALUE=\"budi x #42\"\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=1\n360p/index.m3u8\n"
+	if out != want {
+		t.Errorf("Unexpected playlist:\n%s", out)
+	}
+}
+
+func TestRewritePlaylistAndURIs(t *testing.T) {
+	pl := "#EXTM3U\n#EXT-X-MAP:URI=\"init.mp4\"\n#EXTINF:6.0,\nseg_00000.ts\n#EXTINF:4.2,\nseg_00001.ts\n#EXT-X-ENDLIST\n"
+
+	out := RewritePlaylist(pl, func(uri string) string { return uri + "?sig=x" })
+	if !strings.Contains(out, "\nseg_00000.ts?sig=x\n") || !strings.Contains(out, `URI="init.mp4?sig=x"`) {
+		t.Errorf("Expected every URI to be rewritten:\n%s", out)
+	}
+	if !strings.Contains(out, "#EXTINF:6.0,\n") || !strings.HasSuffix(out, "#EXT-X-ENDLIST\n") {
+		t.Errorf("Expected tags to be preserved:\n%s", out)
+	}
+
+	got := PlaylistURIs("720p/index.m3u8", pl)
+	if !reflect.DeepEqual(got, []string{"720p/init.mp4", "720p/seg_00000.ts", "720p/seg_00001.ts"}) {
+		t.Errorf("Unexpected URIs %v", got)
+	}
+}
+
+func TestResolveURI(t *testing.T) {
+	if p, ok := ResolveURI(MasterPlaylistName, "720p/index.m3u8?x=1"); !ok || p != "720p/index.m3u8" {
+		t.Errorf("Got %q %v", p, ok)
+	}
+	for _, bad := range []string{"../../secret.ts", "/abs.ts", "https://evil.example/a.ts", ""} {
+		if _, ok := ResolveURI("720p/index.m3u8", bad); ok {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+	if _, ok := ResolveURI("index.m3u8", "../secret.ts"); ok {
+		t.Error("Expected escaping the HLS root to be rejected")
+	}
+}
+
+func TestFFmpeg_ProbeAndTranscodeArgs(t *testing.T) {
+	var calls [][]string
+	f := NewFFmpeg("", "")
+	f.run = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		calls = append(calls, append([]string{name}, args...))
+		if name == "ffprobe" {
+			return []byte(`{"streams":[{"width":1920,"height":1080}],"format":{"duration":"125.400000"}}`), nil
+		}
+		return nil, nil
+	}
+
+	p, err := f.Probe(context.Background(), "in.mp4")
+	if err != nil || p.Duration != 125.4 || p.Width != 1920 || p.Height != 1080 {
+		t.Fatalf("Unexpected probe %+v (%v)", p, err)
+	}
+
+	out := t.TempDir()
+	if err := f.Transcode(context.Background(), "in.mp4", out, DefaultRenditions[:2]); err != nil {
+		t.Fatalf("Transcode: %v", err)
+	}
+	if len(calls) != 3 {
+		t.Fatalf("Expected probe plus one ffmpeg run per rendition, got %d calls", len(calls))
+	}
+	args := strings.Join(calls[2], " ")
+	for _, want := range []string{"scale=-2:480", "-b:v 1400k", "-hls_time 6", filepath.Join(out, "480p", "index.m3u8")} {
+		if !strings.Contains(args, want) {
+			t.Errorf("Expected %q in %s", want, args)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(out, "360p")); err != nil {
+		t.Errorf("Expected rendition directory to be created: %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+// Package media turns uploaded session videos into HLS renditions. The actual
+// encoding is done by a locally installed ffmpeg behind the Transcoder
+// interface so the pipeline can be faked in tests.
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+)
+
+// Rendition is one HLS quality level
+type Rendition struct {
+	Name         string `json:"name"`          // directory name, e.g. "720p"
+	Height       int    `json:"height"`        // output height in pixels
+	VideoBitrate int    `json:"video_bitrate"` // kbps
+	AudioBitrate int    `json:"audio_bitrate"` // kbps
+}
+
+// DefaultRenditions are produced for every video, skipping those taller than the source
+var DefaultRenditions = []Rendition{
+	{Name: "360p", Height: 360, VideoBitrate: 800, AudioBitrate: 96},
+	{Name: "480p", Height: 480, VideoBitrate: 1400, AudioBitrate: 128},
+	{Name: "720p", Height: 720, VideoBitrate: 2800, AudioBitrate: 128},
+	{Name: "1080p", Height: 1080, VideoBitrate: 5000, AudioBitrate: 192},
+}
+
+// Probe is the metadata of a source video
+type Probe struct {
+	Duration float64 // seconds
+	Width    int
+	Height   int
+}
+
+// Transcoder encodes a local video file
+type Transcoder interface {
+	// Probe reads duration and dimensions
+	Probe(ctx context.Context, input string) (Probe, error)
+	// Transcode writes outDir/<rendition>/index.m3u8 and its segments for every rendition
+	Transcode(ctx context.Context, input, outDir string, renditions []Rendition) error
+	// Thumbnail writes a JPEG frame taken at the given second
+	Thumbnail(ctx context.Context, input, output string, at float64) error
+}
+
+// FFmpeg runs the ffmpeg and ffprobe binaries
+type FFmpeg struct {
+	FFmpegPath     string
+	FFprobePath    string
+	SegmentSeconds int
+
+	// run executes a command and returns its stdout; replaced in tests
+	run func(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+// NewFFmpeg creates an FFmpeg transcoder using the given binaries (looked up in PATH when empty)
+func NewFFmpeg(ffmpegPath, ffprobePath string) *FFmpeg {
+	if ffmpegPath == "" {
+		ffmpegPath = "ffmpeg"
+	}
+	if ffprobePath == "" {
+		ffprobePath = "ffprobe"
+	}
+	return &FFmpeg{FFmpegPath: ffmpegPath, FFprobePath: ffprobePath, SegmentSeconds: 6, run: runCommand}
+}
+
+// Available reports whether both binaries can be found
+func (f *FFmpeg) Available() bool {
+	_, err1 := exec.LookPath(f.FFmpegPath)
+	_, err2 := exec.LookPath(f.FFprobePath)
+	return err1 == nil && err2 == nil
+}
+
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := stderr.String()
+		if len(msg) > 500 {
+			msg = msg[len(msg)-500:]
+		}
+		return nil, fmt.Errorf("%s failed: %v: %s", filepath.Base(name), err, msg)
+	}
+	return stdout.Bytes(), nil
+}
+
+// Probe runs ffprobe on the first video stream
+func (f *FFmpeg) Probe(ctx context.Context, input string) (Probe, error) {
+	out, err := f.run(ctx, f.FFprobePath,
+		"-v", "error",
+		"-select_streams", "v:0",
+		"-show_entries", "stream=width,height:format=duration",
+		"-of", "json",
+		input,
+	)
+	if err != nil {
+		return Probe{}, err
+	}
+
+	var parsed struct {
+		Streams []struct {
+			Width  int `json:"width"`
+			Height int `json:"height"`
+		} `json:"streams"`
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal(out, &parsed); err != nil {
+		return Probe{}, fmt.Errorf("ffprobe output: %v", err)
+	}
+	if len(parsed.Streams) == 0 {
+		return Probe{}, fmt.Errorf("no video stream found")
+	}
+
+	duration, _ := strconv.ParseFloat(parsed.Format.Duration, 64)
+	return Probe{Duration: duration, Width: parsed.Streams[0].Width, Height: parsed.Streams[0].Height}, nil
+}
+
+// Transcode runs one ffmpeg pass per rendition
+func (f *FFmpeg) Transcode(ctx context.Context, input, outDir string, renditions []Rendition) error {
+	for _, r := range renditions {
+		dir := filepath.Join(outDir, r.Name)
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return err
+		}
+		if _, err := f.run(ctx, f.FFmpegPath, f.renditionArgs(input, dir, r)...); err != nil {
+			return fmt.Errorf("rendition %s: %v", r.Name, err)
+		}
+	}
+	return nil
+}
+
+func (f *FFmpeg) renditionArgs(input, dir string, r Rendition) []string {
+	segment := f.SegmentSeconds
+	if segment <= 0 {
+		segment = 6
+	}
+	return []string{
+		"-y", "-i", input,
+		"-vf", fmt.Sprintf("scale=-2:%d", r.Height),
+		"-c:v", "libx264", "-preset", "veryfast", "-profile:v", "main",
+		"-b:v", fmt.Sprintf("%dk", r.VideoBitrate),
+		"-maxrate", fmt.Sprintf("%dk", r.VideoBitrate*107/100),
+		"-bufsize", fmt.Sprintf("%dk", r.VideoBitrate*3/2),
+		// Keyframe every segment so every rendition can switch on segment boundaries
+		"-force_key_frames", fmt.Sprintf("expr:gte(t,n_forced*%d)", segment),
+		"-c:a", "aac", "-b:a", fmt.Sprintf("%dk", r.AudioBitrate), "-ac", "2",
+		"-f", "hls",
+		"-hls_time", strconv.Itoa(segment),
+		"-hls_playlist_type", "vod",
+		"-hls_segment_filename", filepath.Join(dir, "seg_%05d.ts"),
+		filepath.Join(dir, "index.m3u8"),
+	}
+}
+
+// Thumbnail grabs a single frame scaled to 720 pixels high
+func (f *FFmpeg) Thumbnail(ctx context.Context, input, output string, at float64) error {
+	_, err := f.run(ctx, f.FFmpegPath,
+		"-y",
+		"-ss", strconv.FormatFloat(at, 'f', 2, 64),
+		"-i", input,
+		"-frames:v", "1",
+		"-vf", "scale=-2:720",
+		"-q:v", "3",
+		output,
+	)
+	return err
+}
@@ -2,27 +2,21 @@ package middlewares
 
 import (
 	"net/http"
-	"BACKEND/config"
+	"strconv"
+
+	"BACKEND/entitlements"
 
 	"github.com/gin-gonic/gin"
 )
 
-// Check if user has purchased the session
+// Check if user has an active entitlement for the session
 func SessionAccessRequired() gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		userID := c.GetInt64("user_id")
-		sessionID := c.Param("sessionID")
-
-		var count int
-
-		// Check purchase
-		err := config.DB.Get(&count, `
-			SELECT COUNT(*) FROM purchases 
-			WHERE user_id = ? AND session_id = ?
-		`, userID, sessionID)
+		sessionID, err := strconv.ParseInt(c.Param("sessionID"), 10, 64)
 
-		if err != nil || count == 0 {
+		if err != nil || !entitlements.HasAccess(userID, sessionID) {
 			c.JSON(http.StatusForbidden, gin.H{
 				"error": "You do not have access to this session",
 			})
@@ -0,0 +1,50 @@
+-- Organization Coupons / Discount Codes
+-- Created: 2026-10-19
+
+-- Vouchers created by organizations (independent of affiliate codes)
+CREATE TABLE IF NOT EXISTS coupons (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  organization_id BIGINT NOT NULL,
+  code VARCHAR(50) NOT NULL UNIQUE,
+  description VARCHAR(255) DEFAULT NULL,
+  discount_type ENUM('PERCENT', 'FIXED') NOT NULL DEFAULT 'PERCENT',
+  discount_value DECIMAL(15,2) NOT NULL,
+  max_discount BIGINT DEFAULT NULL,                 -- cap for PERCENT coupons
+  scope ENUM('ORGANIZATION', 'EVENT', 'SESSION') NOT NULL DEFAULT 'ORGANIZATION',
+  event_id BIGINT DEFAULT NULL,                     -- required when scope = EVENT
+  session_id BIGINT DEFAULT NULL,                   -- required when scope = SESSION
+  min_cart_total BIGINT DEFAULT 0,
+  usage_limit INT DEFAULT NULL,                     -- NULL = unlimited
+  per_user_limit INT DEFAULT NULL,                  -- NULL = unlimited
+  starts_at DATETIME DEFAULT NULL,
+  expires_at DATETIME DEFAULT NULL,
+  is_active TINYINT(1) DEFAULT 1,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+  FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE,
+  FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);
+
+-- One row per checkout that used a coupon.
+-- PENDING while waiting for payment, USED once paid, CANCELLED if payment failed.
+CREATE TABLE IF NOT EXISTS coupon_redemptions (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  coupon_id BIGINT NOT NULL,
+  user_id BIGINT NOT NULL,
+  order_id VARCHAR(255) NOT NULL,
+  discount_amount BIGINT NOT NULL DEFAULT 0,
+  status ENUM('PENDING', 'USED', 'CANCELLED') DEFAULT 'PENDING',
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  used_at TIMESTAMP NULL DEFAULT NULL,
+  FOREIGN KEY (coupon_id) REFERENCES coupons(id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  INDEX idx_coupon_redemptions_order (order_id)
+);
+
+-- Coupon applied to the cart (next to affiliate_code)
+ALTER TABLE carts ADD COLUMN coupon_code VARCHAR(50) DEFAULT NULL;
+
+-- Coupon used and discount given per order item (price_paid is after discount)
+ALTER TABLE purchases ADD COLUMN coupon_code VARCHAR(50) DEFAULT NULL;
+ALTER TABLE purchases ADD COLUMN discount_amount BIGINT DEFAULT 0;
@@ -0,0 +1,34 @@
+-- Currencies and exchange rates
+-- Created: 2026-10-19
+
+-- Order amounts are stored as BIGINT minor units of their currency (whole
+-- rupiah for IDR, the only currency anything is charged in). Tables created by
+-- this release already use BIGINT; the older order columns are converted here,
+-- rounding away any leftover fraction. Balances, withdrawals and the
+-- financial_transactions ledger keep their DECIMAL columns.
+UPDATE sessions SET price = ROUND(price) WHERE price <> ROUND(price);
+ALTER TABLE sessions MODIFY COLUMN price BIGINT DEFAULT 0;
+
+UPDATE cart_items SET price = ROUND(price) WHERE price <> ROUND(price);
+ALTER TABLE cart_items MODIFY COLUMN price BIGINT NOT NULL;
+
+UPDATE purchases SET price_paid = ROUND(price_paid) WHERE price_paid <> ROUND(price_paid);
+ALTER TABLE purchases MODIFY COLUMN price_paid BIGINT NOT NULL;
+
+-- Currency of the recorded amounts
+ALTER TABLE cart_items ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'IDR';
+ALTER TABLE purchases ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'IDR';
+ALTER TABLE guest_orders ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'IDR';
+ALTER TABLE financial_transactions ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'IDR';
+
+-- Currency an organization shows its prices in (display only, buyers still pay rupiah)
+ALTER TABLE organizations ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'IDR';
+
+-- Exchange rates maintained by admins: rupiah per one unit of the currency
+CREATE TABLE IF NOT EXISTS exchange_rates (
+  currency CHAR(3) PRIMARY KEY,
+  rupiah_per_unit DECIMAL(18,6) NOT NULL,
+  updated_by BIGINT DEFAULT NULL,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  FOREIGN KEY (updated_by) REFERENCES users(id) ON DELETE SET NULL
+);
@@ -0,0 +1,30 @@
+-- Session Access Entitlements
+-- Created: 2026-10-19
+
+-- Access to session content is checked against this table only.
+-- Purchases, packages, gifts, subscriptions and admin grants each create rows here.
+CREATE TABLE IF NOT EXISTS entitlements (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  user_id BIGINT NOT NULL,
+  session_id BIGINT NOT NULL,
+  source ENUM('PURCHASE', 'PACKAGE', 'GIFT', 'ADMIN', 'SUBSCRIPTION') NOT NULL,
+  source_ref VARCHAR(255) NOT NULL DEFAULT '',     -- order ID, gift code, subscription ID, ...
+  starts_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  expires_at TIMESTAMP NULL DEFAULT NULL,          -- NULL = lifetime access
+  granted_by BIGINT DEFAULT NULL,                  -- admin user for manual grants
+  note VARCHAR(255) DEFAULT NULL,
+  revoked_at TIMESTAMP NULL DEFAULT NULL,
+  revoked_by BIGINT DEFAULT NULL,
+  revoke_reason VARCHAR(255) DEFAULT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY unique_entitlement (user_id, session_id, source, source_ref),
+  INDEX idx_entitlements_access (user_id, session_id, revoked_at),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);
+
+-- Backfill: every PAID purchase keeps its access
+INSERT IGNORE INTO entitlements (user_id, session_id, source, source_ref, starts_at)
+SELECT user_id, session_id, 'PURCHASE', COALESCE(order_id, ''), COALESCE(purchased_at, CURRENT_TIMESTAMP)
+FROM purchases
+WHERE status = 'PAID';
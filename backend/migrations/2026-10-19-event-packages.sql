@@ -0,0 +1,45 @@
+-- Event Packages (buy all sessions of an event, including future ones)
+-- Created: 2026-10-19
+
+-- One package per event, priced by the organization
+CREATE TABLE IF NOT EXISTS event_packages (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  event_id BIGINT NOT NULL UNIQUE,
+  title VARCHAR(255) DEFAULT NULL,                 -- NULL = "<event title> (Paket Lengkap)"
+  description TEXT,
+  price BIGINT NOT NULL,
+  is_active BOOLEAN DEFAULT TRUE,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE
+);
+
+-- Carry over the old events.package_price bundles
+INSERT IGNORE INTO event_packages (event_id, price)
+SELECT id, package_price FROM events WHERE package_price IS NOT NULL AND package_price > 0;
+
+-- A package sale is a single purchase line without a session
+ALTER TABLE purchases MODIFY session_id BIGINT NULL;
+ALTER TABLE purchases ADD COLUMN package_id BIGINT DEFAULT NULL;
+ALTER TABLE purchases ADD COLUMN event_id BIGINT DEFAULT NULL;   -- set for package lines only
+CREATE INDEX idx_purchases_package ON purchases (package_id);
+CREATE INDEX idx_purchases_event ON purchases (event_id);
+
+-- Package revenue spread over the event's sessions at payment time (weighted by session price),
+-- used for per-session revenue reports
+CREATE TABLE IF NOT EXISTS package_revenue_allocations (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  purchase_id BIGINT NOT NULL,
+  session_id BIGINT NOT NULL,
+  amount BIGINT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY unique_allocation (purchase_id, session_id),
+  FOREIGN KEY (purchase_id) REFERENCES purchases(id) ON DELETE CASCADE,
+  FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);
+
+-- Event-level entitlements cover every session of the event, now and later
+ALTER TABLE entitlements MODIFY session_id BIGINT NULL;
+ALTER TABLE entitlements ADD COLUMN event_id BIGINT DEFAULT NULL AFTER session_id;
+ALTER TABLE entitlements ADD CONSTRAINT fk_entitlements_event FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE;
+CREATE INDEX idx_entitlements_event_access ON entitlements (user_id, event_id, revoked_at);
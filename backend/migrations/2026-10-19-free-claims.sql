@@ -0,0 +1,10 @@
+-- Free Sessions / Zero-Amount Orders
+-- Created: 2026-10-19
+
+-- Free orders are stored as PAID purchases with price_paid = 0 and order_id 'FREE-...'.
+-- Max free orders one user can claim per rolling 24 hours (0 = unlimited)
+INSERT IGNORE INTO platform_settings (setting_key, setting_value) VALUES
+  ('free_claim_daily_limit', '5');
+
+-- Speeds up the per-user claim limit lookup
+CREATE INDEX idx_purchases_user_status_time ON purchases (user_id, status, purchased_at);
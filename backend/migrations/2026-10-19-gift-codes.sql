@@ -0,0 +1,40 @@
+-- Gift Purchases (buy seats of a session or event package for others)
+-- Created: 2026-10-19
+
+-- Cart lines can buy several seats as gifts, optionally for known recipients
+ALTER TABLE cart_items ADD COLUMN quantity INT NOT NULL DEFAULT 1;
+ALTER TABLE cart_items ADD COLUMN is_gift BOOLEAN DEFAULT FALSE;
+ALTER TABLE cart_items ADD COLUMN recipient_emails TEXT;          -- comma separated, one invite per email
+
+-- A gift line is one purchase row for N seats; the buyer does not get access from it
+ALTER TABLE purchases ADD COLUMN is_gift BOOLEAN DEFAULT FALSE;
+ALTER TABLE purchases ADD COLUMN quantity INT NOT NULL DEFAULT 1;
+ALTER TABLE purchases ADD COLUMN gift_recipients TEXT;           -- copied from the cart line
+
+-- One own purchase per session is still enforced, gift rows are left out of the key
+ALTER TABLE purchases ADD COLUMN self_session_id BIGINT AS (IF(is_gift, NULL, session_id)) VIRTUAL;
+ALTER TABLE purchases ADD UNIQUE KEY unique_self_purchase (user_id, self_session_id);
+ALTER TABLE purchases DROP INDEX user_id;
+
+-- One redeemable code per gifted seat, issued when the order is paid
+CREATE TABLE IF NOT EXISTS gift_codes (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  code VARCHAR(32) NOT NULL UNIQUE,
+  purchase_id BIGINT NOT NULL,
+  order_id VARCHAR(255) NOT NULL,
+  buyer_user_id BIGINT NOT NULL,
+  session_id BIGINT DEFAULT NULL,                  -- NULL for event package gifts
+  event_id BIGINT NOT NULL,
+  recipient_email VARCHAR(255) DEFAULT NULL,       -- set when the code was emailed as an invite
+  status ENUM('UNREDEEMED', 'REDEEMED', 'REVOKED') DEFAULT 'UNREDEEMED',
+  redeemed_by BIGINT DEFAULT NULL,
+  redeemed_at TIMESTAMP NULL DEFAULT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (purchase_id) REFERENCES purchases(id) ON DELETE CASCADE,
+  FOREIGN KEY (buyer_user_id) REFERENCES users(id) ON DELETE CASCADE,
+  FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE,
+  FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE,
+  FOREIGN KEY (redeemed_by) REFERENCES users(id) ON DELETE SET NULL,
+  INDEX idx_gift_codes_event (event_id, status),
+  INDEX idx_gift_codes_order (order_id)
+);
@@ -0,0 +1,35 @@
+-- Guest checkout
+-- Created: 2026-10-19
+
+-- Orders placed without an account. The buyer's account is matched by email
+-- (or created) only once the order is paid; purchases are written at that point.
+CREATE TABLE IF NOT EXISTS guest_orders (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  order_id VARCHAR(255) NOT NULL UNIQUE,           -- GUEST-{ts}-{id}
+  midtrans_order_id VARCHAR(255),                  -- with -AFF-{code} when an affiliate code is used
+  email VARCHAR(255) NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  phone VARCHAR(50) NOT NULL,
+  affiliate_code VARCHAR(50),
+  total BIGINT NOT NULL DEFAULT 0,
+  status ENUM('PENDING', 'PAID', 'FAILED', 'EXPIRED') DEFAULT 'PENDING',
+  snap_token VARCHAR(500),
+  user_id BIGINT DEFAULT NULL,                     -- set on payment
+  account_created BOOLEAN DEFAULT FALSE,           -- TRUE when the payment created the account
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  paid_at TIMESTAMP NULL DEFAULT NULL,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL,
+  INDEX idx_guest_orders_status (status, created_at),
+  INDEX idx_guest_orders_email (email)
+);
+
+CREATE TABLE IF NOT EXISTS guest_order_items (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  guest_order_id BIGINT NOT NULL,
+  item_type ENUM('SESSION', 'EVENT_PACKAGE') NOT NULL,
+  session_id BIGINT DEFAULT NULL,
+  event_id BIGINT NOT NULL,
+  package_id BIGINT DEFAULT NULL,
+  price BIGINT NOT NULL,
+  FOREIGN KEY (guest_order_id) REFERENCES guest_orders(id) ON DELETE CASCADE
+);
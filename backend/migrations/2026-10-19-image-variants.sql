@@ -0,0 +1,28 @@
+-- Resized variants, blurhash and dominant colour for uploaded images
+-- Created: 2026-10-19
+
+-- One row per processed image (event thumbnail, logo, profile image, ad banner),
+-- keyed by the URL stored on the owning row
+CREATE TABLE IF NOT EXISTS image_assets (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  source_url VARCHAR(512) NOT NULL,
+  width INT NOT NULL,
+  height INT NOT NULL,
+  blurhash VARCHAR(64) NOT NULL,
+  dominant_color CHAR(7) NOT NULL,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY uniq_image_assets_source (source_url)
+);
+
+-- Standard sizes (card, hero, avatar) rendered from the original
+CREATE TABLE IF NOT EXISTS image_variants (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  source_url VARCHAR(512) NOT NULL,
+  name VARCHAR(20) NOT NULL,
+  url VARCHAR(512) NOT NULL,
+  width INT NOT NULL,
+  height INT NOT NULL,
+  size_bytes BIGINT NOT NULL DEFAULT 0,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY uniq_image_variants (source_url, name)
+);
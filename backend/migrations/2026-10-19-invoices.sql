@@ -0,0 +1,29 @@
+-- Invoices / Receipts
+-- Created: 2026-10-19
+
+-- Last issued invoice number per year (row is locked while issuing, so numbers are gapless)
+CREATE TABLE IF NOT EXISTS invoice_sequences (
+  year INT PRIMARY KEY,
+  last_number BIGINT NOT NULL DEFAULT 0
+);
+
+-- One invoice per paid order. Buyer data is a snapshot taken when the invoice is issued.
+CREATE TABLE IF NOT EXISTS invoices (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  invoice_number VARCHAR(30) NOT NULL UNIQUE,      -- e.g. INV/2026/000042
+  invoice_year INT NOT NULL,
+  sequence_number BIGINT NOT NULL,
+  order_id VARCHAR(255) NOT NULL UNIQUE,
+  user_id BIGINT NOT NULL,
+  buyer_name VARCHAR(255) DEFAULT NULL,
+  buyer_email VARCHAR(255) DEFAULT NULL,
+  buyer_phone VARCHAR(50) DEFAULT NULL,
+  subtotal BIGINT DEFAULT 0,                       -- before coupon discount
+  discount_amount BIGINT DEFAULT 0,
+  total_amount BIGINT DEFAULT 0,                   -- amount paid
+  tax_amount BIGINT DEFAULT 0,                     -- PPN included in total
+  currency CHAR(3) NOT NULL DEFAULT 'IDR',
+  issued_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  emailed_at TIMESTAMP NULL DEFAULT NULL,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,49 @@
+-- License Pools (bulk seats of an event for B2B customers)
+-- Created: 2026-10-19
+
+-- An organization offers a block of seats at a negotiated price to one buyer account.
+-- The buyer pays through the normal cart checkout and then assigns the seats to people.
+CREATE TABLE IF NOT EXISTS license_pools (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  organization_id BIGINT NOT NULL,
+  owner_user_id BIGINT NOT NULL,                   -- buyer account managing the seats
+  event_id BIGINT NOT NULL,                        -- seats cover every session of the event
+  name VARCHAR(255) NOT NULL,
+  seats_total INT NOT NULL,
+  unit_price BIGINT NOT NULL,                      -- negotiated price per seat
+  status ENUM('OFFERED', 'ACTIVE', 'CANCELLED') DEFAULT 'OFFERED',
+  order_id VARCHAR(255) DEFAULT NULL,              -- latest checkout of this pool
+  expires_at TIMESTAMP NULL DEFAULT NULL,          -- seat access ends here, NULL = lifetime
+  note TEXT,
+  created_by BIGINT DEFAULT NULL,
+  paid_at TIMESTAMP NULL DEFAULT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+  FOREIGN KEY (owner_user_id) REFERENCES users(id) ON DELETE CASCADE,
+  FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE,
+  INDEX idx_license_pools_owner (owner_user_id, status),
+  INDEX idx_license_pools_org (organization_id, status)
+);
+
+-- Seat assignments; an unassigned seat keeps its row and can be given to the same person again
+CREATE TABLE IF NOT EXISTS license_seats (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  pool_id BIGINT NOT NULL,
+  user_id BIGINT NOT NULL,
+  assigned_by BIGINT DEFAULT NULL,
+  assigned_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  unassigned_at TIMESTAMP NULL DEFAULT NULL,
+  UNIQUE KEY unique_license_seat (pool_id, user_id),
+  FOREIGN KEY (pool_id) REFERENCES license_pools(id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Pools are paid through the cart like any other item
+ALTER TABLE cart_items MODIFY item_type ENUM('SESSION', 'EVENT_PACKAGE', 'LICENSE_POOL') DEFAULT 'SESSION';
+ALTER TABLE cart_items ADD COLUMN license_pool_id BIGINT DEFAULT NULL;
+ALTER TABLE purchases ADD COLUMN license_pool_id BIGINT DEFAULT NULL;
+CREATE INDEX idx_purchases_license_pool ON purchases (license_pool_id);
+
+-- Assigned seats are event-level entitlements with source LICENSE
+ALTER TABLE entitlements MODIFY source ENUM('PURCHASE', 'PACKAGE', 'GIFT', 'ADMIN', 'SUBSCRIPTION', 'LICENSE') NOT NULL;
@@ -0,0 +1,25 @@
+-- Payment methods
+-- Created: 2026-10-19
+
+-- Globally enabled methods (comma separated codes) and per-method fee/expiry.
+-- Method-specific keys follow the pattern payment_fee_flat_{code},
+-- payment_fee_percent_{code} and payment_expiry_minutes_{code}; missing keys mean
+-- no fee and the method's default expiry.
+INSERT IGNORE INTO platform_settings (setting_key, setting_value) VALUES
+  ('payment_methods_enabled', 'gopay'),
+  ('credit_card_installment_terms', ''),
+  ('credit_card_installment_min_amount', '500000');
+
+-- Per-organization restriction (NULL = every globally enabled method)
+ALTER TABLE organizations ADD COLUMN payment_methods VARCHAR(255) DEFAULT NULL;
+
+-- Chosen method and its surcharge, stored per order item (the fee is split over the lines)
+ALTER TABLE purchases ADD COLUMN payment_method VARCHAR(50) DEFAULT NULL;
+ALTER TABLE purchases ADD COLUMN payment_fee BIGINT DEFAULT 0;
+ALTER TABLE guest_orders ADD COLUMN payment_method VARCHAR(50) DEFAULT NULL;
+ALTER TABLE guest_orders ADD COLUMN payment_fee BIGINT DEFAULT 0;
+CREATE INDEX idx_purchases_payment_method ON purchases(payment_method, status);
+
+-- Payment method surcharges collected by the platform
+ALTER TABLE financial_transactions
+  MODIFY transaction_type ENUM('SALE', 'AFFILIATE_CREDIT', 'PLATFORM_FEE', 'TAX', 'WITHDRAWAL', 'PAYMENT_FEE') NOT NULL;
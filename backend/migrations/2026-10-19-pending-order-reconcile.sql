@@ -0,0 +1,30 @@
+-- Pending Order Reconciliation
+-- Created: 2026-10-19
+
+-- Orders still PENDING after this many minutes are checked against Midtrans
+-- (default 24 hours, the Snap transaction expiry)
+INSERT IGNORE INTO platform_settings (setting_key, setting_value) VALUES
+  ('pending_order_ttl_minutes', '1440');
+
+-- Expired orders are closed as EXPIRED, and users cancel their own unpaid orders
+-- (CANCELLED); the original enum only knew PENDING, PAID and FAILED
+ALTER TABLE purchases MODIFY status ENUM('PENDING', 'PAID', 'FAILED', 'CANCELLED', 'EXPIRED') DEFAULT 'PAID';
+
+-- Outcome of every reconciled order; is_discrepancy marks missed webhooks,
+-- amount mismatches and provider errors for admin review
+CREATE TABLE IF NOT EXISTS payment_reconciliation_logs (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  order_id VARCHAR(255) NOT NULL,
+  local_total BIGINT DEFAULT 0,
+  provider_status VARCHAR(50) DEFAULT NULL,     -- Midtrans transaction_status, 'not_found' if unknown
+  provider_amount VARCHAR(50) DEFAULT NULL,
+  action VARCHAR(20) NOT NULL,                  -- SETTLE, FAIL, EXPIRE, REVIEW, ERROR
+  is_discrepancy BOOLEAN DEFAULT FALSE,
+  note TEXT,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_reconcile_order (order_id),
+  INDEX idx_reconcile_discrepancy (is_discrepancy, created_at)
+);
+
+-- Speeds up the stale PENDING order scan
+CREATE INDEX idx_purchases_status_time ON purchases (status, purchased_at);
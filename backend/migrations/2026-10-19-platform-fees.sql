@@ -0,0 +1,27 @@
+-- Platform Fee & Tax Engine
+-- Created: 2026-10-19
+
+-- Global platform settings (key/value, editable by admin)
+CREATE TABLE IF NOT EXISTS platform_settings (
+  setting_key VARCHAR(100) PRIMARY KEY,
+  setting_value VARCHAR(255) NOT NULL,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+INSERT IGNORE INTO platform_settings (setting_key, setting_value) VALUES
+  ('platform_fee_percent', '0'),
+  ('tax_enabled', '0'),
+  ('tax_percent', '11');
+
+-- Per-organization override (NULL = use global platform_fee_percent)
+ALTER TABLE organizations ADD COLUMN platform_fee_percent DECIMAL(5,2) DEFAULT NULL;
+
+-- Fee breakdown stored per order item (filled when the purchase is paid)
+ALTER TABLE purchases ADD COLUMN tax_amount BIGINT DEFAULT 0;
+ALTER TABLE purchases ADD COLUMN platform_fee BIGINT DEFAULT 0;
+ALTER TABLE purchases ADD COLUMN affiliate_commission BIGINT DEFAULT 0;
+ALTER TABLE purchases ADD COLUMN org_amount BIGINT DEFAULT 0;
+
+-- Allow tax entries in the financial ledger
+ALTER TABLE financial_transactions
+  MODIFY transaction_type ENUM('SALE', 'AFFILIATE_CREDIT', 'PLATFORM_FEE', 'TAX', 'WITHDRAWAL') NOT NULL;
@@ -0,0 +1,26 @@
+-- Playback sessions for paid videos: concurrent stream limit and forensic watermarks
+-- Created: 2026-10-19
+
+-- One row per viewing, opened when a signed video URL is minted. Stream requests
+-- keep last_seen_at fresh; a playback idle for longer than PLAYBACK_IDLE_SECONDS
+-- no longer holds one of the account's PLAYBACK_MAX_CONCURRENT slots.
+CREATE TABLE IF NOT EXISTS playback_sessions (
+  id CHAR(32) PRIMARY KEY,
+  user_id BIGINT NOT NULL,
+  video_id BIGINT NOT NULL,
+  session_id BIGINT NOT NULL,
+  -- Shown in the player overlay, traces a leaked recording back to this row
+  watermark_code CHAR(14) NOT NULL,
+  ip_address VARCHAR(45) NOT NULL,
+  last_ip VARCHAR(45) NOT NULL,
+  user_agent VARCHAR(255) DEFAULT NULL,
+  started_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+  last_seen_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+  ended_at DATETIME DEFAULT NULL,
+  end_reason ENUM('CLOSED', 'REPLACED') DEFAULT NULL,
+  UNIQUE KEY uniq_playback_watermark (watermark_code),
+  INDEX idx_playback_user_active (user_id, ended_at, last_seen_at),
+  INDEX idx_playback_started (started_at),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  FOREIGN KEY (video_id) REFERENCES session_videos(id) ON DELETE CASCADE
+);
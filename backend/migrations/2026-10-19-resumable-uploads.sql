@@ -0,0 +1,31 @@
+-- Resumable uploads for session videos
+-- Created: 2026-10-19
+
+-- One row per upload; chunks are appended to a staging file until offset_bytes
+-- reaches size_bytes. Uploads idle past expires_at are cleaned up by a job.
+CREATE TABLE IF NOT EXISTS resumable_uploads (
+  id CHAR(32) PRIMARY KEY,
+  user_id BIGINT NOT NULL,
+  organization_id BIGINT NOT NULL,
+  session_id BIGINT NOT NULL,
+  filename VARCHAR(255) NOT NULL,
+  title VARCHAR(255) NOT NULL,
+  description TEXT,
+  content_type VARCHAR(100) NOT NULL DEFAULT 'application/octet-stream',
+  size_bytes BIGINT NOT NULL,
+  offset_bytes BIGINT NOT NULL DEFAULT 0,
+  checksum_sha256 CHAR(64) DEFAULT NULL,
+  status ENUM('UPLOADING', 'COMPLETED', 'ABORTED', 'EXPIRED') NOT NULL DEFAULT 'UPLOADING',
+  video_id BIGINT DEFAULT NULL,
+  expires_at DATETIME NOT NULL,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  INDEX idx_resumable_uploads_org (organization_id, status),
+  INDEX idx_resumable_uploads_expiry (status, expires_at)
+);
+
+-- Per-organization storage quota in bytes (NULL = platform default)
+ALTER TABLE organizations ADD COLUMN storage_quota_bytes BIGINT DEFAULT NULL;
+
+INSERT IGNORE INTO platform_settings (setting_key, setting_value) VALUES
+  ('storage_quota_bytes', '10737418240');
@@ -0,0 +1,49 @@
+-- Per-Organization Storage and Bandwidth Accounting
+-- Created: 2026-10-19
+
+-- Hosting tiers; an organization's own storage_quota_bytes / bandwidth_quota_bytes
+-- override its plan, and organizations without a plan use the platform settings
+CREATE TABLE IF NOT EXISTS storage_plans (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  code VARCHAR(30) NOT NULL UNIQUE,
+  name VARCHAR(100) NOT NULL,
+  storage_quota_bytes BIGINT NOT NULL,          -- 0 = unlimited
+  bandwidth_quota_bytes BIGINT NOT NULL,        -- per calendar month, 0 = unlimited
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT IGNORE INTO storage_plans (code, name, storage_quota_bytes, bandwidth_quota_bytes) VALUES
+  ('FREE', 'Free', 10737418240, 107374182400),              -- 10 GB / 100 GB
+  ('PRO', 'Pro', 107374182400, 1099511627776),              -- 100 GB / 1 TB
+  ('BUSINESS', 'Business', 1099511627776, 10995116277760);  -- 1 TB / 10 TB
+
+ALTER TABLE organizations ADD COLUMN storage_plan_id BIGINT DEFAULT NULL;
+ALTER TABLE organizations ADD COLUMN bandwidth_quota_bytes BIGINT DEFAULT NULL;
+
+INSERT IGNORE INTO platform_settings (setting_key, setting_value) VALUES
+  ('bandwidth_quota_bytes', '107374182400');
+
+-- One row per stored object with its size and the organization it is billed to
+-- (NULL for platform content such as ads, profile images and report photos)
+CREATE TABLE IF NOT EXISTS storage_objects (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  storage VARCHAR(20) NOT NULL,                 -- public, private, legacy
+  object_key VARCHAR(512) NOT NULL,
+  organization_id BIGINT DEFAULT NULL,
+  size_bytes BIGINT NOT NULL DEFAULT 0,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY uniq_storage_object (storage, object_key),
+  INDEX idx_storage_objects_org (organization_id)
+);
+
+-- Bytes streamed to viewers, per organization, session and day
+CREATE TABLE IF NOT EXISTS bandwidth_usage (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  organization_id BIGINT NOT NULL,
+  session_id BIGINT NOT NULL DEFAULT 0,         -- 0 = not tied to a session
+  usage_date DATE NOT NULL,
+  bytes_sent BIGINT NOT NULL DEFAULT 0,
+  requests INT NOT NULL DEFAULT 0,
+  UNIQUE KEY uniq_bandwidth_usage (organization_id, session_id, usage_date),
+  INDEX idx_bandwidth_usage_date (usage_date)
+);
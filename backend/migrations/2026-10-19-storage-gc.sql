@@ -0,0 +1,28 @@
+-- Orphaned Storage Garbage Collection
+-- Created: 2026-10-19
+
+-- Mode of the daily collector run: report (list only), quarantine or delete.
+-- Objects younger than the grace period are never collected; quarantined
+-- objects are purged once they are older than the retention.
+INSERT IGNORE INTO platform_settings (setting_key, setting_value) VALUES
+  ('storage_gc_mode', 'report'),
+  ('storage_gc_grace_hours', '24'),
+  ('storage_gc_quarantine_days', '30');
+
+-- Summary of every collector run; aborted_reason is set when the stored URLs
+-- could not be read and nothing was touched
+CREATE TABLE IF NOT EXISTS storage_gc_runs (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  mode VARCHAR(20) NOT NULL,                    -- report, quarantine, delete
+  scanned INT NOT NULL DEFAULT 0,
+  orphans INT NOT NULL DEFAULT 0,
+  orphan_bytes BIGINT NOT NULL DEFAULT 0,
+  quarantined INT NOT NULL DEFAULT 0,
+  deleted INT NOT NULL DEFAULT 0,
+  purged INT NOT NULL DEFAULT 0,
+  failed INT NOT NULL DEFAULT 0,
+  aborted_reason TEXT,
+  started_at DATETIME NOT NULL,
+  finished_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_storage_gc_started (started_at)
+);
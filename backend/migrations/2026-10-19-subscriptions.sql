@@ -0,0 +1,63 @@
+-- Subscription Plans (membership to an organization's catalog)
+-- Created: 2026-10-19
+
+-- A plan unlocks every published event of one organization for a billing period.
+CREATE TABLE IF NOT EXISTS subscription_plans (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  organization_id BIGINT NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  description TEXT,
+  billing_interval ENUM('MONTHLY', 'ANNUAL') NOT NULL DEFAULT 'MONTHLY',
+  price BIGINT NOT NULL,
+  grace_days INT NOT NULL DEFAULT 3,               -- access kept after the period ends while waiting for renewal
+  is_active TINYINT(1) DEFAULT 1,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+  INDEX idx_subscription_plans_org (organization_id, is_active)
+);
+
+-- One row per subscriber and plan; renewals move the current period forward
+CREATE TABLE IF NOT EXISTS subscriptions (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  plan_id BIGINT NOT NULL,
+  user_id BIGINT NOT NULL,
+  organization_id BIGINT NOT NULL,
+  status ENUM('PENDING', 'ACTIVE', 'PAST_DUE', 'CANCELLED', 'EXPIRED') DEFAULT 'PENDING',
+  current_period_start TIMESTAMP NULL DEFAULT NULL,
+  current_period_end TIMESTAMP NULL DEFAULT NULL,
+  cancel_at_period_end TINYINT(1) DEFAULT 0,
+  cancelled_at TIMESTAMP NULL DEFAULT NULL,
+  reminder_sent_at TIMESTAMP NULL DEFAULT NULL,    -- renewal reminder of the current period
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  UNIQUE KEY unique_subscription (user_id, plan_id),
+  FOREIGN KEY (plan_id) REFERENCES subscription_plans(id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+  INDEX idx_subscriptions_status (status, current_period_end)
+);
+
+-- Every billing period is paid (and recognized as org revenue) separately
+CREATE TABLE IF NOT EXISTS subscription_payments (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  subscription_id BIGINT NOT NULL,
+  order_id VARCHAR(255) NOT NULL,
+  amount BIGINT NOT NULL,
+  period_start TIMESTAMP NULL DEFAULT NULL,        -- set when paid, a late renewal starts on the payment day
+  period_end TIMESTAMP NULL DEFAULT NULL,
+  status ENUM('PENDING', 'PAID', 'FAILED') DEFAULT 'PENDING',
+  snap_token VARCHAR(255) DEFAULT NULL,
+  tax_amount BIGINT DEFAULT 0,
+  platform_fee BIGINT DEFAULT 0,
+  org_amount BIGINT DEFAULT 0,
+  currency CHAR(3) NOT NULL DEFAULT 'IDR',
+  paid_at TIMESTAMP NULL DEFAULT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY unique_subscription_order (order_id),
+  FOREIGN KEY (subscription_id) REFERENCES subscriptions(id) ON DELETE CASCADE
+);
+
+-- Subscriptions are organization-level entitlements: every published event of the org
+ALTER TABLE entitlements ADD COLUMN organization_id BIGINT DEFAULT NULL;
+CREATE INDEX idx_entitlements_organization ON entitlements (user_id, organization_id);
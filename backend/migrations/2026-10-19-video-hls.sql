@@ -0,0 +1,18 @@
+-- HLS transcoding for session videos
+-- Created: 2026-10-19
+
+-- Every video is queued for transcoding; existing videos are backfilled by the
+-- background job. The original upload stays in video_url and is streamed until
+-- the HLS renditions are READY.
+ALTER TABLE session_videos
+  ADD COLUMN hls_status ENUM('PENDING', 'PROCESSING', 'READY', 'FAILED') NOT NULL DEFAULT 'PENDING',
+  ADD COLUMN hls_prefix VARCHAR(255) DEFAULT NULL,
+  ADD COLUMN hls_renditions VARCHAR(255) DEFAULT NULL,
+  ADD COLUMN duration_seconds INT DEFAULT NULL,
+  ADD COLUMN width INT DEFAULT NULL,
+  ADD COLUMN height INT DEFAULT NULL,
+  ADD COLUMN poster_url VARCHAR(500) DEFAULT NULL,
+  ADD COLUMN transcode_error TEXT DEFAULT NULL,
+  ADD COLUMN transcoded_at DATETIME DEFAULT NULL;
+
+CREATE INDEX idx_session_videos_hls_status ON session_videos(hls_status);
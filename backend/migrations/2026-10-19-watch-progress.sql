@@ -0,0 +1,27 @@
+-- Video watch progress and watch-based quiz/certificate requirements
+-- Created: 2026-10-19
+
+-- Playback heartbeats per viewer and video. watched_seconds only grows with
+-- forward playback, position_seconds is where the player resumes.
+CREATE TABLE IF NOT EXISTS video_watch_progress (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  user_id BIGINT NOT NULL,
+  video_id BIGINT NOT NULL,
+  session_id BIGINT NOT NULL,
+  position_seconds DECIMAL(10,2) NOT NULL DEFAULT 0,
+  watched_seconds DECIMAL(10,2) NOT NULL DEFAULT 0,
+  duration_seconds DECIMAL(10,2) NOT NULL DEFAULT 0,
+  percent DECIMAL(5,2) NOT NULL DEFAULT 0,
+  completed_at DATETIME DEFAULT NULL,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  UNIQUE KEY uniq_watch_progress (user_id, video_id),
+  INDEX idx_watch_progress_session (user_id, session_id),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  FOREIGN KEY (video_id) REFERENCES session_videos(id) ON DELETE CASCADE
+);
+
+-- Minimum share of each session's videos to watch before its quiz unlocks and
+-- before a certificate is issued; 0 disables the rule
+ALTER TABLE event_certificates
+  ADD COLUMN min_watch_percent INT NOT NULL DEFAULT 0 AFTER min_score_percent;
@@ -0,0 +1,29 @@
+-- Wishlists and wishlist alerts
+-- Created: 2026-10-19
+
+-- A wishlist item is a whole event (session_id NULL) or one session of it.
+CREATE TABLE IF NOT EXISTS wishlists (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  user_id BIGINT NOT NULL,
+  event_id BIGINT NOT NULL,                        -- also set for session items
+  session_id BIGINT DEFAULT NULL,
+  item_key BIGINT AS (COALESCE(session_id, 0)) VIRTUAL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY unique_wishlist_item (user_id, event_id, item_key),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE,
+  FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE,
+  INDEX idx_wishlists_session (session_id)
+);
+
+-- Alerts already sent, so a wishlist item gets each alert only once
+-- (ref = new price for price drops, coupon ID for coupons)
+CREATE TABLE IF NOT EXISTS wishlist_alerts (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  wishlist_id BIGINT NOT NULL,
+  alert_type ENUM('PUBLISHED', 'PRICE_DROP', 'COUPON') NOT NULL,
+  ref VARCHAR(64) NOT NULL DEFAULT '',
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY unique_wishlist_alert (wishlist_id, alert_type, ref),
+  FOREIGN KEY (wishlist_id) REFERENCES wishlists(id) ON DELETE CASCADE
+);
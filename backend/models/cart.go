@@ -18,7 +18,7 @@ type CartItem struct {
 	ItemType  string    `db:"item_type" json:"item_type"` // SESSION or EVENT_PACKAGE
 	SessionID *int64    `db:"session_id" json:"session_id"`
 	EventID   *int64    `db:"event_id" json:"event_id"`
-	Price     float64   `db:"price" json:"price"`
+	Price     int64     `db:"price" json:"price"`
 	AddedAt   time.Time `db:"added_at" json:"added_at"`
 }
 
@@ -34,5 +34,5 @@ type CartItemDetail struct {
 type CartWithItems struct {
 	Cart
 	Items      []CartItemDetail `json:"items"`
-	TotalPrice float64          `json:"total_price"`
+	TotalPrice int64            `json:"total_price"`
 }
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Coupon represents an organization voucher that lowers the price of cart items
+type Coupon struct {
+	ID             int64      `db:"id" json:"id"`
+	OrganizationID int64      `db:"organization_id" json:"organization_id"`
+	Code           string     `db:"code" json:"code"`
+	Description    *string    `db:"description" json:"description"`
+	DiscountType   string     `db:"discount_type" json:"discount_type"` // PERCENT or FIXED
+	DiscountValue  float64    `db:"discount_value" json:"discount_value"`
+	MaxDiscount    *int64     `db:"max_discount" json:"max_discount"`
+	Scope          string     `db:"scope" json:"scope"` // ORGANIZATION, EVENT or SESSION
+	EventID        *int64     `db:"event_id" json:"event_id"`
+	SessionID      *int64     `db:"session_id" json:"session_id"`
+	MinCartTotal   int64      `db:"min_cart_total" json:"min_cart_total"`
+	UsageLimit     *int       `db:"usage_limit" json:"usage_limit"`
+	PerUserLimit   *int       `db:"per_user_limit" json:"per_user_limit"`
+	StartsAt       *time.Time `db:"starts_at" json:"starts_at"`
+	ExpiresAt      *time.Time `db:"expires_at" json:"expires_at"`
+	IsActive       bool       `db:"is_active" json:"is_active"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+}
+
+// CouponRedemption records a coupon used in one checkout
+type CouponRedemption struct {
+	ID             int64      `db:"id" json:"id"`
+	CouponID       int64      `db:"coupon_id" json:"coupon_id"`
+	UserID         int64      `db:"user_id" json:"user_id"`
+	OrderID        string     `db:"order_id" json:"order_id"`
+	DiscountAmount int64      `db:"discount_amount" json:"discount_amount"`
+	Status         string     `db:"status" json:"status"` // PENDING, USED or CANCELLED
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	UsedAt         *time.Time `db:"used_at" json:"used_at"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Entitlement grants one user access to one session, a whole event, or every
+// published event of an organization (subscriptions).
+// Access no longer depends on the purchases table directly: purchases,
+// packages, gifts, subscriptions and manual admin grants all produce entitlements.
+type Entitlement struct {
+	ID             int64      `db:"id" json:"id"`
+	UserID         int64      `db:"user_id" json:"user_id"`
+	SessionID      *int64     `db:"session_id" json:"session_id"`           // NULL for event-level grants
+	EventID        *int64     `db:"event_id" json:"event_id"`               // whole event, including future sessions
+	OrganizationID *int64     `db:"organization_id" json:"organization_id"` // every published event of the org
+	Source         string     `db:"source" json:"source"`                   // PURCHASE, PACKAGE, GIFT, ADMIN, SUBSCRIPTION, LICENSE
+	SourceRef      string     `db:"source_ref" json:"source_ref"`           // order ID, gift code, subscription ID, ...
+	StartsAt       time.Time  `db:"starts_at" json:"starts_at"`
+	ExpiresAt      *time.Time `db:"expires_at" json:"expires_at"` // NULL = lifetime access
+	GrantedBy      *int64     `db:"granted_by" json:"granted_by"`
+	Note           *string    `db:"note" json:"note"`
+	RevokedAt      *time.Time `db:"revoked_at" json:"revoked_at"`
+	RevokedBy      *int64     `db:"revoked_by" json:"revoked_by"`
+	RevokeReason   *string    `db:"revoke_reason" json:"revoke_reason"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+}
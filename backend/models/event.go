@@ -37,6 +37,11 @@ type SessionVideo struct {
 	SizeBytes   int64     `db:"size_bytes" json:"size_bytes"`
 	OrderIndex  int       `db:"order_index" json:"order_index"`
 	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+
+	// HLS transcoding (PENDING, PROCESSING, READY or FAILED)
+	HLSStatus       string  `db:"hls_status" json:"hls_status"`
+	DurationSeconds *int    `db:"duration_seconds" json:"duration_seconds"`
+	PosterURL       *string `db:"poster_url" json:"poster_url"`
 }
 
 type SessionFile struct {
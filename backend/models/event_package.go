@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// EventPackage is a priced bundle of a whole event. Buyers get access to every
+// session of the event, including sessions added or published later.
+type EventPackage struct {
+	ID          int64     `db:"id" json:"id"`
+	EventID     int64     `db:"event_id" json:"event_id"`
+	Title       *string   `db:"title" json:"title"`
+	Description *string   `db:"description" json:"description"`
+	Price       int64     `db:"price" json:"price"`
+	IsActive    bool      `db:"is_active" json:"is_active"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
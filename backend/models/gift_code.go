@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// GiftCode is one gifted seat of a session or event package. Whoever redeems
+// the code first gets the access, so codes can be handed on freely.
+type GiftCode struct {
+	ID             int64      `db:"id" json:"id"`
+	Code           string     `db:"code" json:"code"`
+	PurchaseID     int64      `db:"purchase_id" json:"purchase_id"`
+	OrderID        string     `db:"order_id" json:"order_id"`
+	BuyerUserID    int64      `db:"buyer_user_id" json:"buyer_user_id"`
+	SessionID      *int64     `db:"session_id" json:"session_id"` // NULL = whole event package
+	EventID        int64      `db:"event_id" json:"event_id"`
+	RecipientEmail *string    `db:"recipient_email" json:"recipient_email"`
+	Status         string     `db:"status" json:"status"` // UNREDEEMED, REDEEMED, REVOKED
+	RedeemedBy     *int64     `db:"redeemed_by" json:"redeemed_by"`
+	RedeemedAt     *time.Time `db:"redeemed_at" json:"redeemed_at"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// GuestOrder is a checkout placed without an account; the account is matched or created on payment
+type GuestOrder struct {
+	ID              int64      `db:"id" json:"id"`
+	OrderID         string     `db:"order_id" json:"order_id"`
+	MidtransOrderID *string    `db:"midtrans_order_id" json:"midtrans_order_id"`
+	Email           string     `db:"email" json:"email"`
+	Name            string     `db:"name" json:"name"`
+	Phone           string     `db:"phone" json:"phone"`
+	AffiliateCode   *string    `db:"affiliate_code" json:"affiliate_code"`
+	Total           int64      `db:"total" json:"total"`
+	Status          string     `db:"status" json:"status"`
+	UserID          *int64     `db:"user_id" json:"user_id"`
+	AccountCreated  bool       `db:"account_created" json:"account_created"`
+	PaymentMethod   *string    `db:"payment_method" json:"payment_method"`
+	PaymentFee      int64      `db:"payment_fee" json:"payment_fee"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	PaidAt          *time.Time `db:"paid_at" json:"paid_at"`
+}
+
+// GuestOrderItem is one session or event package line of a guest order
+type GuestOrderItem struct {
+	ID           int64  `db:"id" json:"id"`
+	GuestOrderID int64  `db:"guest_order_id" json:"guest_order_id"`
+	ItemType     string `db:"item_type" json:"item_type"`
+	SessionID    *int64 `db:"session_id" json:"session_id"`
+	EventID      int64  `db:"event_id" json:"event_id"`
+	PackageID    *int64 `db:"package_id" json:"package_id"`
+	Price        int64  `db:"price" json:"price"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// LicensePool is a block of event seats sold to one buyer account at a
+// negotiated price. The buyer assigns the seats to people after paying.
+type LicensePool struct {
+	ID             int64      `db:"id" json:"id"`
+	OrganizationID int64      `db:"organization_id" json:"organization_id"`
+	OwnerUserID    int64      `db:"owner_user_id" json:"owner_user_id"`
+	EventID        int64      `db:"event_id" json:"event_id"`
+	Name           string     `db:"name" json:"name"`
+	SeatsTotal     int        `db:"seats_total" json:"seats_total"`
+	UnitPrice      int64      `db:"unit_price" json:"unit_price"`
+	Status         string     `db:"status" json:"status"` // OFFERED, ACTIVE, CANCELLED
+	OrderID        *string    `db:"order_id" json:"order_id"`
+	ExpiresAt      *time.Time `db:"expires_at" json:"expires_at"` // NULL = lifetime seats
+	Note           *string    `db:"note" json:"note"`
+	CreatedBy      *int64     `db:"created_by" json:"created_by"`
+	PaidAt         *time.Time `db:"paid_at" json:"paid_at"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// LicenseSeat assigns one seat of a pool to a user
+type LicenseSeat struct {
+	ID           int64      `db:"id" json:"id"`
+	PoolID       int64      `db:"pool_id" json:"pool_id"`
+	UserID       int64      `db:"user_id" json:"user_id"`
+	AssignedBy   *int64     `db:"assigned_by" json:"assigned_by"`
+	AssignedAt   time.Time  `db:"assigned_at" json:"assigned_at"`
+	UnassignedAt *time.Time `db:"unassigned_at" json:"unassigned_at"` // NULL = seat in use
+}
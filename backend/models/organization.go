@@ -19,5 +19,6 @@ type Organization struct {
 	Address    string `db:"address" json:"address"`
 
 	IsOfficial bool      `db:"is_official" json:"is_official"`
+	Currency   string    `db:"currency" json:"currency"` // display currency, payments stay in rupiah
 	CreatedAt  time.Time `db:"created_at" json:"created_at"`
 }
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// SubscriptionPlan is a membership that unlocks every published event of one
+// organization for as long as the subscriber keeps paying each billing period
+type SubscriptionPlan struct {
+	ID              int64     `db:"id" json:"id"`
+	OrganizationID  int64     `db:"organization_id" json:"organization_id"`
+	Name            string    `db:"name" json:"name"`
+	Description     *string   `db:"description" json:"description"`
+	BillingInterval string    `db:"billing_interval" json:"billing_interval"` // MONTHLY, ANNUAL
+	Price           int64     `db:"price" json:"price"`
+	GraceDays       int       `db:"grace_days" json:"grace_days"`
+	IsActive        bool      `db:"is_active" json:"is_active"`
+	CreatedAt       time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// Subscription is one user's membership of a plan
+type Subscription struct {
+	ID                 int64      `db:"id" json:"id"`
+	PlanID             int64      `db:"plan_id" json:"plan_id"`
+	UserID             int64      `db:"user_id" json:"user_id"`
+	OrganizationID     int64      `db:"organization_id" json:"organization_id"`
+	Status             string     `db:"status" json:"status"` // PENDING, ACTIVE, PAST_DUE, CANCELLED, EXPIRED
+	CurrentPeriodStart *time.Time `db:"current_period_start" json:"current_period_start"`
+	CurrentPeriodEnd   *time.Time `db:"current_period_end" json:"current_period_end"`
+	CancelAtPeriodEnd  bool       `db:"cancel_at_period_end" json:"cancel_at_period_end"`
+	CancelledAt        *time.Time `db:"cancelled_at" json:"cancelled_at"`
+	ReminderSentAt     *time.Time `db:"reminder_sent_at" json:"reminder_sent_at"`
+	CreatedAt          time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt          time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// SubscriptionPayment is the payment (and recognized revenue) of one billing period
+type SubscriptionPayment struct {
+	ID             int64      `db:"id" json:"id"`
+	SubscriptionID int64      `db:"subscription_id" json:"subscription_id"`
+	OrderID        string     `db:"order_id" json:"order_id"`
+	Amount         int64      `db:"amount" json:"amount"`
+	PeriodStart    *time.Time `db:"period_start" json:"period_start"`
+	PeriodEnd      *time.Time `db:"period_end" json:"period_end"`
+	Status         string     `db:"status" json:"status"` // PENDING, PAID, FAILED
+	TaxAmount      int64      `db:"tax_amount" json:"tax_amount"`
+	PlatformFee    int64      `db:"platform_fee" json:"platform_fee"`
+	OrgAmount      int64      `db:"org_amount" json:"org_amount"`
+	PaidAt         *time.Time `db:"paid_at" json:"paid_at"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// WishlistItem is an event (SessionID nil) or a single session a user wants to follow
+type WishlistItem struct {
+	ID        int64     `db:"id" json:"id"`
+	UserID    int64     `db:"user_id" json:"user_id"`
+	EventID   int64     `db:"event_id" json:"event_id"`
+	SessionID *int64    `db:"session_id" json:"session_id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
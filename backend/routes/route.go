@@ -25,14 +25,23 @@ func RegisterRoutes(r *gin.Engine) {
 
 		api.GET("/user/sessions/video/:filename", controllers.StreamSessionVideo)
 		api.GET("/user/sessions/file/:filename", controllers.StreamSessionFile)
+		api.GET("/user/sessions/hls/:videoID/*path", controllers.StreamSessionHLS)
+		api.GET("/storage/*key", controllers.ServeSignedStorageObject)
 
 		api.GET("/config/midtrans", controllers.GetMidtransConfig)
+		api.GET("/payment-methods", controllers.GetPaymentMethods)
+		api.GET("/currencies", controllers.GetCurrencies)
 		api.POST("/webhook/midtrans", controllers.HandleMidtransNotification)
 
 		// Public endpoints
 		api.GET("/organizations/public", controllers.GetPublicOrganizations)
+		api.GET("/organizations/:id/subscription-plans", controllers.GetOrganizationSubscriptionPlans)
 		api.GET("/featured-events", controllers.GetFeaturedEvents)
 
+		// Guest checkout (account is matched or created on payment)
+		api.POST("/guest/checkout", controllers.GuestCheckout)
+		api.GET("/guest/orders/:orderID", controllers.GetGuestOrderStatus)
+
 		// SANDBOX ONLY - Public endpoint for testing payment
 		api.POST("/sandbox/simulate-payment", controllers.SimulatePaymentSuccess)
 
@@ -60,6 +69,8 @@ func RegisterRoutes(r *gin.Engine) {
 			controllers.GetUserSessionMedia,
 		)
 		userGroup.GET("/sessions/signed-video/:filename", controllers.GetSignedVideoURL)
+		userGroup.GET("/playbacks", controllers.GetMyPlaybacks)
+		userGroup.DELETE("/playbacks/:playbackID", controllers.EndPlayback)
 		userGroup.GET("/sessions/signed-file/:filename", controllers.GetSignedFileURL)
 
 		userGroup.GET("/notifications", controllers.GetMyNotifications)
@@ -74,6 +85,7 @@ func RegisterRoutes(r *gin.Engine) {
 		userGroup.GET("/certificates", controllers.GetMyCertificates)
 		userGroup.GET("/payments", controllers.GetMyPayments)
 		userGroup.PUT("/payments/:id/cancel", controllers.CancelPayment)
+		userGroup.GET("/payments/:id/invoice", controllers.DownloadPaymentInvoice)
 
 		// Quiz & Certificate for users
 		userGroup.GET("/events/:eventID/progress", controllers.GetUserEventProgress)
@@ -81,6 +93,11 @@ func RegisterRoutes(r *gin.Engine) {
 		userGroup.POST("/sessions/:sessionID/quiz/submit", controllers.SubmitQuiz)
 		userGroup.GET("/events/:eventID/certificate", controllers.GetUserCertificate)
 
+		// Video watch progress
+		userGroup.POST("/videos/:videoID/progress", controllers.RecordWatchProgress)
+		userGroup.GET("/videos/:videoID/progress", controllers.GetWatchProgress)
+		userGroup.GET("/sessions/:sessionID/watch-progress", controllers.GetSessionWatchProgress)
+
 		// Reports/Pengaduan
 		userGroup.POST("/reports", controllers.SubmitReport)
 
@@ -92,8 +109,33 @@ func RegisterRoutes(r *gin.Engine) {
 		userGroup.DELETE("/cart", controllers.ClearCart)
 		userGroup.POST("/cart/clear-code", controllers.ClearAffiliateCode)
 		userGroup.DELETE("/cart/clear-code", controllers.ClearAffiliateCode)
+		userGroup.POST("/cart/apply-coupon", controllers.ApplyCoupon)
+		userGroup.POST("/cart/clear-coupon", controllers.ClearCoupon)
+		userGroup.DELETE("/cart/clear-coupon", controllers.ClearCoupon)
 		userGroup.POST("/cart/checkout", controllers.CheckoutCart)
 
+		// Gift codes
+		userGroup.POST("/redeem", controllers.RedeemGiftCode)
+		userGroup.GET("/gifts", controllers.GetMyGiftCodes)
+
+		// License pools (bulk seats bought for a team)
+		userGroup.GET("/license-pools", controllers.GetMyLicensePools)
+		userGroup.GET("/license-pools/:id", controllers.GetLicensePoolDetail)
+		userGroup.POST("/license-pools/:id/seats", controllers.AssignLicenseSeats)
+		userGroup.DELETE("/license-pools/:id/seats/:userID", controllers.UnassignLicenseSeat)
+
+		// Wishlist
+		userGroup.GET("/wishlist", controllers.GetMyWishlist)
+		userGroup.POST("/wishlist", controllers.AddToWishlist)
+		userGroup.DELETE("/wishlist/:id", controllers.RemoveFromWishlist)
+
+		// Subscriptions (organization catalog membership)
+		userGroup.GET("/subscriptions", controllers.GetMySubscriptions)
+		userGroup.POST("/subscriptions", controllers.Subscribe)
+		userGroup.POST("/subscriptions/:id/renew", controllers.RenewSubscription)
+		userGroup.PUT("/subscriptions/:id/cancel", controllers.CancelSubscription)
+		userGroup.PUT("/subscriptions/:id/resume", controllers.ResumeSubscription)
+
 		// Withdrawal Requests History
 		userGroup.GET("/withdrawal-requests", controllers.GetMyWithdrawalRequests)
 	}
@@ -151,9 +193,11 @@ func RegisterRoutes(r *gin.Engine) {
 	{
 		org.GET("/profile", controllers.GetOrganizationProfile)
 		org.PUT("/profile", controllers.UpdateOrganizationProfile)
+		org.PUT("/currency", controllers.UpdateOrganizationCurrency)
 		org.POST("/profile/logo", controllers.UploadOrganizationLogo)
 		org.GET("/report", controllers.GetOrganizationReport)
 		org.GET("/events/:eventID/buyers", controllers.GetEventBuyers)
+		org.GET("/events/:eventID/buyers/:purchaseID/invoice", controllers.DownloadBuyerInvoice)
 
 		org.POST("/events", controllers.CreateEvent)
 		org.PUT("/events/:eventID", controllers.UpdateEvent)
@@ -167,6 +211,24 @@ func RegisterRoutes(r *gin.Engine) {
 		org.PUT("/events/:eventID/unpublish", controllers.UnpublishEvent)
 		org.PUT("/events/:eventID/schedule", controllers.SchedulePublish)
 
+		// Event package (bundle price, covers sessions added later)
+		org.GET("/events/:eventID/package", controllers.GetEventPackage)
+		org.PUT("/events/:eventID/package", controllers.UpsertEventPackage)
+		org.DELETE("/events/:eventID/package", controllers.DeleteEventPackage)
+		org.GET("/events/:eventID/session-revenue", controllers.GetEventSessionRevenue)
+
+		// License pools offered to B2B buyers
+		org.GET("/license-pools", controllers.OrgGetLicensePools)
+		org.POST("/license-pools", controllers.OrgCreateLicensePool)
+		org.PUT("/license-pools/:id/cancel", controllers.OrgCancelLicensePool)
+
+		// Subscription plans
+		org.GET("/subscription-plans", controllers.OrgGetSubscriptionPlans)
+		org.POST("/subscription-plans", controllers.OrgCreateSubscriptionPlan)
+		org.PUT("/subscription-plans/:id", controllers.OrgUpdateSubscriptionPlan)
+		org.GET("/subscribers", controllers.OrgGetSubscribers)
+		org.GET("/subscription-revenue", controllers.OrgGetSubscriptionRevenue)
+
 		org.POST("/events/:eventID/sessions", controllers.CreateSession)
 		org.PUT("/sessions/:sessionID/publish", controllers.PublishSession)
 		org.PUT("/sessions/:sessionID/unpublish", controllers.UnpublishSession)
@@ -179,6 +241,15 @@ func RegisterRoutes(r *gin.Engine) {
 		org.PUT("/sessions/:sessionID/videos/:mediaID", controllers.UpdateSessionVideo)
 		org.PUT("/sessions/:sessionID/files/:mediaID", controllers.UpdateSessionFile)
 		org.DELETE("/sessions/:sessionID/videos/:mediaID", controllers.DeleteSessionVideo)
+		org.POST("/sessions/:sessionID/videos/:mediaID/transcode", controllers.RetrySessionVideoTranscode)
+
+		// Resumable video uploads (create -> PATCH chunks -> complete)
+		org.POST("/sessions/:sessionID/uploads", controllers.CreateResumableUpload)
+		org.GET("/uploads/:uploadID", controllers.GetResumableUpload)
+		org.PATCH("/uploads/:uploadID", controllers.AppendResumableUpload)
+		org.POST("/uploads/:uploadID/complete", controllers.CompleteResumableUpload)
+		org.DELETE("/uploads/:uploadID", controllers.AbortResumableUpload)
+		org.GET("/storage-usage", controllers.GetOrganizationStorageUsage)
 		org.DELETE("/sessions/:sessionID/files/:mediaID", controllers.DeleteSessionFile)
 
 		org.GET("/sessions/:sessionID/media", controllers.GetSessionMedia)
@@ -210,6 +281,13 @@ func RegisterRoutes(r *gin.Engine) {
 		org.DELETE("/affiliate-requests/:id", controllers.DeleteAffiliatePartnership)
 		org.GET("/affiliate-stats", controllers.GetOrgAffiliateStats)
 		org.GET("/analytics", controllers.GetOrgAnalytics)
+
+		// Coupons / discount codes
+		org.GET("/coupons", controllers.GetOrgCoupons)
+		org.POST("/coupons", controllers.CreateCoupon)
+		org.PUT("/coupons/:id", controllers.UpdateCoupon)
+		org.PUT("/coupons/:id/toggle-active", controllers.ToggleCouponActive)
+		org.DELETE("/coupons/:id", controllers.DeleteCoupon)
 	}
 
 	// ==========================================
@@ -233,6 +311,7 @@ func RegisterRoutes(r *gin.Engine) {
 		admin.GET("/organizations", controllers.GetAllOrganizations)
 		admin.GET("/organizations/:id", controllers.GetOrganizationDetailAdmin)
 		admin.PUT("/organizations/:id", controllers.UpdateOrganizationByAdmin)
+		admin.PUT("/organizations/:id/storage-plan", controllers.UpdateOrganizationStoragePlan)
 		admin.GET("/organizations/:id/sessions/:sessionId/media", controllers.GetSessionMediaAdmin)
 		admin.DELETE("/organizations/:id", controllers.DeleteOrganization)
 
@@ -314,5 +393,39 @@ func RegisterRoutes(r *gin.Engine) {
 		admin.GET("/withdrawal-requests", controllers.GetAllWithdrawalRequests)
 		admin.PUT("/withdrawal-requests/:id/approve", controllers.ApproveWithdrawalRequest)
 		admin.PUT("/withdrawal-requests/:id/reject", controllers.RejectWithdrawalRequest)
+
+		// Platform Fee & Tax Settings
+		admin.GET("/fee-settings", controllers.GetFeeSettings)
+		admin.PUT("/fee-settings", controllers.UpdateFeeSettings)
+		admin.PUT("/organizations/:id/platform-fee", controllers.UpdateOrganizationFee)
+		admin.GET("/payment-methods", controllers.GetPaymentMethodSettings)
+		admin.PUT("/payment-methods", controllers.UpdatePaymentMethodSettings)
+		admin.PUT("/organizations/:id/payment-methods", controllers.UpdateOrganizationPaymentMethods)
+		admin.GET("/exchange-rates", controllers.GetExchangeRates)
+		admin.PUT("/exchange-rates/:currency", controllers.UpdateExchangeRate)
+		admin.DELETE("/exchange-rates/:currency", controllers.DeleteExchangeRate)
+		admin.GET("/free-claim-settings", controllers.GetFreeClaimSettings)
+		admin.PUT("/free-claim-settings", controllers.UpdateFreeClaimSettings)
+		admin.POST("/payments/reconcile", controllers.RunPaymentReconciliation)
+		admin.GET("/payments/reconciliation-logs", controllers.GetPaymentReconciliationLogs)
+
+		// Storage
+		admin.POST("/storage/migrate-private-media", controllers.MigrateMediaToPrivate)
+		admin.POST("/storage/process-images", controllers.ProcessMissingImageVariants)
+		admin.POST("/storage/gc", controllers.RunStorageGC)
+		admin.GET("/storage/gc-runs", controllers.GetStorageGCRuns)
+		admin.GET("/storage/usage", controllers.AdminGetStorageUsage)
+		admin.POST("/storage/usage/rebuild", controllers.RebuildStorageUsage)
+
+		// Paid video playback
+		admin.GET("/playbacks/suspicious", controllers.AdminGetSuspiciousPlaybacks)
+		admin.GET("/playbacks/watermarks/:code", controllers.AdminLookupWatermark)
+
+		// Session access (entitlements)
+		admin.GET("/entitlements", controllers.AdminGetEntitlements)
+		admin.POST("/entitlements", controllers.AdminGrantEntitlement)
+		admin.PUT("/entitlements/:id/revoke", controllers.AdminRevokeEntitlement)
+		admin.GET("/gift-codes", controllers.AdminGetGiftCodes)
+		admin.POST("/subscriptions/run-billing", controllers.AdminRunSubscriptionBilling)
 	}
 }
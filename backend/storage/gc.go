@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"strings"
+	"time"
+)
+
+// QuarantinePrefix holds orphaned objects that were moved aside instead of
+// deleted, under one folder per day: quarantine/20261019/events/a.png
+const QuarantinePrefix = "quarantine/"
+
+// References is the set of keys (and key prefixes, e.g. a video's HLS folder)
+// the database still points at in one storage
+type References struct {
+	keys     map[string]bool
+	prefixes []string
+}
+
+// NewReferences returns an empty reference set
+func NewReferences() *References {
+	return &References{keys: map[string]bool{}}
+}
+
+// AddKey marks one object as in use
+func (r *References) AddKey(key string) {
+	r.keys[key] = true
+}
+
+// AddPrefix marks every object below a folder as in use
+func (r *References) AddPrefix(prefix string) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" {
+		return
+	}
+	r.prefixes = append(r.prefixes, prefix+"/")
+}
+
+// Has reports whether key is referenced directly or through a prefix
+func (r *References) Has(key string) bool {
+	if r.keys[key] {
+		return true
+	}
+	for _, p := range r.prefixes {
+		if strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Len is the number of referenced keys and prefixes
+func (r *References) Len() int {
+	return len(r.keys) + len(r.prefixes)
+}
+
+// IsOrphan reports whether a listed object can be garbage collected: nothing
+// references it, it is not already quarantined, and it is older than cutoff so
+// an upload whose database row is still being written is never collected.
+func IsOrphan(obj ObjectInfo, refs *References, cutoff time.Time) bool {
+	if IsQuarantined(obj.Key) || refs.Has(obj.Key) {
+		return false
+	}
+	return !obj.ModTime.IsZero() && obj.ModTime.Before(cutoff)
+}
+
+// IsQuarantined reports whether key lives in the quarantine folder
+func IsQuarantined(key string) bool {
+	return strings.HasPrefix(key, QuarantinePrefix)
+}
+
+// QuarantineKey is where an orphan is moved on the given day
+func QuarantineKey(key string, now time.Time) string {
+	return QuarantinePrefix + now.UTC().Format("20060102") + "/" + key
+}
+
+// QuarantinedAt returns the day a quarantined key was moved aside and its original key
+func QuarantinedAt(key string) (time.Time, string, bool) {
+	rest, ok := strings.CutPrefix(key, QuarantinePrefix)
+	if !ok {
+		return time.Time{}, "", false
+	}
+	day, original, ok := strings.Cut(rest, "/")
+	if !ok || original == "" {
+		return time.Time{}, "", false
+	}
+	t, err := time.Parse("20060102", day)
+	if err != nil {
+		return time.Time{}, "", false
+	}
+	return t, original, true
+}
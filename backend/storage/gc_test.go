@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReferences(t *testing.T) {
+	refs := NewReferences()
+	refs.AddKey("events/a.png")
+	refs.AddPrefix("hls/7/")
+	refs.AddPrefix("")
+
+	for key, want := range map[string]bool{
+		"events/a.png":        true,
+		"events/b.png":        false,
+		"hls/7/master.m3u8":   true,
+		"hls/7/720p/seg1.ts":  true,
+		"hls/70/master.m3u8":  false,
+		"videos/anything.mp4": false,
+	} {
+		if got := refs.Has(key); got != want {
+			t.Errorf("Has(%q) = %v, want %v", key, got, want)
+		}
+	}
+	if refs.Len() != 2 {
+		t.Errorf("Expected an empty prefix to be ignored, got %d references", refs.Len())
+	}
+}
+
+func TestIsOrphan(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	cutoff := now.Add(-24 * time.Hour)
+	refs := NewReferences()
+	refs.AddKey("events/used.png")
+
+	cases := []struct {
+		obj  ObjectInfo
+		want bool
+	}{
+		{ObjectInfo{Key: "events/old.png", ModTime: now.Add(-48 * time.Hour)}, true},
+		{ObjectInfo{Key: "events/used.png", ModTime: now.Add(-48 * time.Hour)}, false},
+		{ObjectInfo{Key: "events/fresh.png", ModTime: now.Add(-time.Hour)}, false},
+		{ObjectInfo{Key: "events/unknown-age.png"}, false},
+		{ObjectInfo{Key: "quarantine/20261001/events/x.png", ModTime: now.Add(-48 * time.Hour)}, false},
+	}
+	for _, c := range cases {
+		if got := IsOrphan(c.obj, refs, cutoff); got != c.want {
+			t.Errorf("IsOrphan(%s) = %v, want %v", c.obj.Key, got, c.want)
+		}
+	}
+}
+
+func TestQuarantineKey(t *testing.T) {
+	key := QuarantineKey("events/a.png", time.Date(2026, 10, 19, 23, 0, 0, 0, time.FixedZone("WIB", 7*3600)))
+	if key != "quarantine/20261019/events/a.png" {
+		t.Fatalf("Unexpected quarantine key %q", key)
+	}
+
+	day, original, ok := QuarantinedAt(key)
+	if !ok || original != "events/a.png" || !day.Equal(time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected to read back the day and key, got %v %q %v", day, original, ok)
+	}
+	for _, bad := range []string{"events/a.png", "quarantine/notaday/a.png", "quarantine/20261019"} {
+		if _, _, ok := QuarantinedAt(bad); ok {
+			t.Errorf("Expected %q not to parse", bad)
+		}
+	}
+}
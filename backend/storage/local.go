@@ -0,0 +1,218 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Local stores objects under a directory on the server's disk
+type Local struct {
+	Root          string // e.g. "uploads"
+	BaseURL       string // URL prefix objects are stored under in the database, e.g. "uploads"
+	SignedBaseURL string // endpoint that serves signed URLs, e.g. "/api/storage"
+	Secret        []byte // HMAC key for signed URLs
+}
+
+// NewLocal creates a local disk storage
+func NewLocal(root, baseURL, signedBaseURL string, secret []byte) *Local {
+	return &Local{
+		Root:          root,
+		BaseURL:       strings.TrimRight(baseURL, "/"),
+		SignedBaseURL: strings.TrimRight(signedBaseURL, "/"),
+		Secret:        secret,
+	}
+}
+
+func (l *Local) path(key string) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(l.Root, filepath.FromSlash(key)), nil
+}
+
+// Put writes to a temporary file first so readers never see a partial object
+func (l *Local) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (l *Local) Get(ctx context.Context, key string, rng *Range) (io.ReadCloser, ObjectInfo, error) {
+	info, err := l.Stat(ctx, key)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	p, _ := l.path(key)
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	if rng == nil {
+		return f, info, nil
+	}
+
+	if rng.Start >= info.Size {
+		f.Close()
+		return nil, ObjectInfo{}, ErrInvalidRange
+	}
+	if _, err := f.Seek(rng.Start, io.SeekStart); err != nil {
+		f.Close()
+		return nil, ObjectInfo{}, err
+	}
+	return limitedReadCloser{io.LimitReader(f, rng.Length(info.Size)), f}, info, nil
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (l *Local) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	st, err := os.Stat(p)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && st.IsDir()) {
+		return ObjectInfo{}, ErrNotFound
+	}
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	return ObjectInfo{Key: key, Size: st.Size(), ContentType: localContentType(key), ModTime: st.ModTime()}, nil
+}
+
+// List walks the upload root; temporary files of unfinished Puts are skipped
+func (l *Local) List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	return filepath.WalkDir(l.Root, func(p string, d fs.DirEntry, err error) error {
+		// A missing root just means nothing was uploaded yet
+		if p == l.Root && errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+		rel, err := filepath.Rel(l.Root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		st, err := d.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil // deleted while walking
+		}
+		if err != nil {
+			return err
+		}
+		return fn(ObjectInfo{Key: key, Size: st.Size(), ContentType: localContentType(key), ModTime: st.ModTime()})
+	})
+}
+
+func localContentType(key string) string {
+	if contentType := mime.TypeByExtension(path.Ext(key)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// SignedURL points at the signed-URL endpoint; VerifySignature checks it
+func (l *Local) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	if _, err := CleanKey(key); err != nil {
+		return "", err
+	}
+	exp := time.Now().Add(ttl).Unix()
+	return fmt.Sprintf("%s/%s?exp=%d&sig=%s", l.SignedBaseURL, escapeKey(key), exp, l.signature(key, exp)), nil
+}
+
+// VerifySignature checks a signature made by SignedURL and that it has not expired
+func (l *Local) VerifySignature(key string, exp int64, sig string) bool {
+	if time.Now().Unix() > exp {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(l.signature(key, exp)))
+}
+
+func (l *Local) signature(key string, exp int64) string {
+	h := hmac.New(sha256.New, l.Secret)
+	fmt.Fprintf(h, "%s|%d", key, exp)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (l *Local) URL(key string) string {
+	return l.BaseURL + "/" + key
+}
+
+// KeyFromURL also accepts paths under the root, as stored by older uploads
+func (l *Local) KeyFromURL(u string) (string, bool) {
+	for _, prefix := range []string{l.BaseURL + "/", filepath.ToSlash(l.Root) + "/", "/" + filepath.ToSlash(l.Root) + "/"} {
+		if key, ok := strings.CutPrefix(u, prefix); ok {
+			if key, err := CleanKey(key); err == nil {
+				return key, true
+			}
+		}
+	}
+	return "", false
+}
+
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// escapeKey URL-escapes every segment of a key
+func escapeKey(key string) string {
+	parts := strings.Split(key, "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return strings.Join(parts, "/")
+}
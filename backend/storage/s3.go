@@ -0,0 +1,408 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3Config configures any S3-compatible service (AWS S3, MinIO, Cloudflare R2, ...)
+type S3Config struct {
+	Endpoint  string // e.g. https://s3.ap-southeast-1.amazonaws.com or http://localhost:9000
+	Region    string // e.g. ap-southeast-1 ("auto" for R2)
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PublicURL string // optional public/CDN base URL for stored URLs
+	PathStyle bool   // endpoint/bucket/key instead of bucket.endpoint/key (MinIO)
+}
+
+// S3 talks to an S3-compatible API with AWS Signature Version 4
+type S3 struct {
+	cfg    S3Config
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewS3 creates an S3-compatible storage
+func NewS3(cfg S3Config) *S3 {
+	cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+	cfg.PublicURL = strings.TrimRight(cfg.PublicURL, "/")
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	return &S3{cfg: cfg, client: &http.Client{}, now: time.Now}
+}
+
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+func (s *S3) objectURL(key string) (*url.URL, error) {
+	u, err := url.Parse(s.cfg.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if s.cfg.PathStyle {
+		u.Path = "/" + s.cfg.Bucket + "/" + key
+	} else {
+		u.Host = s.cfg.Bucket + "." + u.Host
+		u.Path = "/" + key
+	}
+	return u, nil
+}
+
+func (s *S3) newRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	u, err := s.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+	return http.NewRequestWithContext(ctx, method, u.String(), body)
+}
+
+func (s *S3) do(req *http.Request) (*http.Response, error) {
+	s.sign(req)
+	return s.client.Do(req)
+}
+
+// Put streams the body; S3 needs the size up front
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if size < 0 {
+		return fmt.Errorf("storage: s3 upload of %s needs a known size", key)
+	}
+	req, err := s.newRequest(ctx, http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := s.do(req)
+	if err != nil {
+		return fmt.Errorf("s3 upload request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	return s3Error(resp, "upload")
+}
+
+func (s *S3) Get(ctx context.Context, key string, rng *Range) (io.ReadCloser, ObjectInfo, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	if rng != nil {
+		req.Header.Set("Range", rng.Header())
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, ObjectInfo{}, fmt.Errorf("s3 get request failed: %v", err)
+	}
+	if err := s3Error(resp, "get"); err != nil {
+		resp.Body.Close()
+		return nil, ObjectInfo{}, err
+	}
+	return resp.Body, objectInfoFromHeaders(key, resp), nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return fmt.Errorf("s3 delete request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if err := s3Error(resp, "delete"); err != nil && err != ErrNotFound {
+		return err
+	}
+	return nil
+}
+
+func (s *S3) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	req, err := s.newRequest(ctx, http.MethodHead, key, nil)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("s3 stat request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if err := s3Error(resp, "stat"); err != nil {
+		return ObjectInfo{}, err
+	}
+	return objectInfoFromHeaders(key, resp), nil
+}
+
+// SignedURL presigns a GET; SigV4 allows at most 7 days
+func (s *S3) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	u, err := s.objectURL(key)
+	if err != nil {
+		return "", err
+	}
+	seconds := int64(ttl / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	if seconds > 604800 {
+		seconds = 604800
+	}
+
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	scope := s.scope(now)
+
+	q := url.Values{}
+	q.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	q.Set("X-Amz-Credential", s.cfg.AccessKey+"/"+scope)
+	q.Set("X-Amz-Date", amzDate)
+	q.Set("X-Amz-Expires", strconv.FormatInt(seconds, 10))
+	q.Set("X-Amz-SignedHeaders", "host")
+
+	canonical := strings.Join([]string{
+		http.MethodGet,
+		canonicalPath(u.Path),
+		canonicalQuery(q),
+		"host:" + u.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n")
+	q.Set("X-Amz-Signature", s.signature(now, amzDate, scope, canonical))
+
+	u.RawQuery = canonicalQuery(q)
+	return u.String(), nil
+}
+
+func (s *S3) URL(key string) string {
+	if s.cfg.PublicURL != "" {
+		return s.cfg.PublicURL + "/" + key
+	}
+	u, err := s.objectURL(key)
+	if err != nil {
+		return key
+	}
+	return u.String()
+}
+
+func (s *S3) KeyFromURL(u string) (string, bool) {
+	prefix := strings.TrimSuffix(s.URL("x"), "x")
+	key, ok := strings.CutPrefix(u, prefix)
+	if !ok {
+		return "", false
+	}
+	if key, err := CleanKey(key); err == nil {
+		return key, true
+	}
+	return "", false
+}
+
+// s3ListResult is one page of a ListObjectsV2 response
+type s3ListResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List pages through ListObjectsV2, 1000 keys at a time
+func (s *S3) List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	token := ""
+	for {
+		u, err := s.objectURL("")
+		if err != nil {
+			return err
+		}
+		q := url.Values{}
+		q.Set("list-type", "2")
+		if prefix != "" {
+			q.Set("prefix", prefix)
+		}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		u.RawQuery = canonicalQuery(q)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return err
+		}
+		resp, err := s.do(req)
+		if err != nil {
+			return fmt.Errorf("s3 list request failed: %v", err)
+		}
+		var page s3ListResult
+		err = s3Error(resp, "list")
+		if err == nil {
+			if decodeErr := xml.NewDecoder(resp.Body).Decode(&page); decodeErr != nil {
+				err = fmt.Errorf("s3 list error: %v", decodeErr)
+			}
+		}
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		for _, obj := range page.Contents {
+			if err := fn(ObjectInfo{Key: obj.Key, Size: obj.Size, ModTime: obj.LastModified}); err != nil {
+				return err
+			}
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// sign adds the SigV4 Authorization header; the payload itself is not hashed
+// so uploads can stream
+func (s *S3) sign(req *http.Request) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	scope := s.scope(now)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	signed := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	if req.Header.Get("Range") != "" {
+		signed = append(signed, "range")
+	}
+	sort.Strings(signed)
+
+	var headers strings.Builder
+	for _, h := range signed {
+		value := req.Header.Get(h)
+		if h == "host" {
+			value = req.URL.Host
+		}
+		headers.WriteString(h + ":" + strings.TrimSpace(value) + "\n")
+	}
+	signedHeaders := strings.Join(signed, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		canonicalPath(req.URL.Path),
+		canonicalQuery(req.URL.Query()),
+		headers.String(),
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.AccessKey, scope, signedHeaders, s.signature(now, amzDate, scope, canonical)))
+}
+
+func (s *S3) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.cfg.Region + "/s3/aws4_request"
+}
+
+func (s *S3) signature(now time.Time, amzDate, scope, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), now.Format("20060102"))
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// sigV4Escape is RFC 3986 escaping as required by SigV4
+func sigV4Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func canonicalPath(p string) string {
+	parts := strings.Split(p, "/")
+	for i, part := range parts {
+		parts[i] = sigV4Escape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), q[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, sigV4Escape(k)+"="+sigV4Escape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func s3Error(resp *http.Response, op string) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		return ErrInvalidRange
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("s3 %s error (status %d): %s", op, resp.StatusCode, string(body))
+}
+
+// objectInfoFromHeaders reads object metadata from a GET/HEAD response; for
+// range responses the full size comes from Content-Range
+func objectInfoFromHeaders(key string, resp *http.Response) ObjectInfo {
+	info := ObjectInfo{Key: key, Size: resp.ContentLength, ContentType: resp.Header.Get("Content-Type")}
+	if cr := resp.Header.Get("Content-Range"); cr != "" {
+		if i := strings.LastIndex(cr, "/"); i >= 0 {
+			if total, err := strconv.ParseInt(cr[i+1:], 10, 64); err == nil {
+				info.Size = total
+			}
+		}
+	}
+	if t, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.ModTime = t
+	}
+	return info
+}
@@ -0,0 +1,156 @@
+// Package storage abstracts where uploaded media lives: local disk, an
+// S3-compatible bucket or Supabase Storage. Keys are slash-separated paths
+// inside the bucket (or upload root), e.g. "videos/session_1_1700000000.mp4".
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when a key does not exist
+var ErrNotFound = errors.New("storage: object not found")
+
+// ErrInvalidRange is returned when a requested byte range cannot be served
+var ErrInvalidRange = errors.New("storage: invalid range")
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Key         string
+	Size        int64 // full object size, also when only a range was read
+	ContentType string
+	ModTime     time.Time
+}
+
+// Range is an inclusive byte range; End < 0 means up to the end of the object
+type Range struct {
+	Start int64
+	End   int64
+}
+
+// Storage is implemented by every storage driver
+type Storage interface {
+	// Put streams r into key, replacing any existing object; size is -1 when unknown
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens key, limited to rng when it is not nil
+	Get(ctx context.Context, key string, rng *Range) (io.ReadCloser, ObjectInfo, error)
+	// Delete removes key; deleting a missing key is not an error
+	Delete(ctx context.Context, key string) error
+	// Stat returns the metadata of key without reading it
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+	// SignedURL returns a URL that grants read access to key until ttl passes
+	SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+	// URL is the stable URL stored in the database for key
+	URL(key string) string
+	// KeyFromURL reverses URL; ok is false for URLs that belong elsewhere
+	KeyFromURL(url string) (key string, ok bool)
+	// List calls fn for every object whose key starts with prefix ("" lists
+	// the whole bucket); an error from fn stops the listing and is returned
+	List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error
+}
+
+// CleanKey validates a key: no empty segments, no "." or "..", no leading slash
+func CleanKey(key string) (string, error) {
+	key = strings.TrimSpace(key)
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", fmt.Errorf("storage: invalid key %q", key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return "", fmt.Errorf("storage: invalid key %q", key)
+		}
+	}
+	return key, nil
+}
+
+// ParseRange parses a single-range HTTP Range header ("bytes=0-99", "bytes=100-",
+// "bytes=-500") against an object size. An empty header returns nil.
+func ParseRange(header string, size int64) (*Range, error) {
+	if header == "" {
+		return nil, nil
+	}
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return nil, ErrInvalidRange
+	}
+	startStr, endStr, ok := strings.Cut(spec, "-")
+	if !ok {
+		return nil, ErrInvalidRange
+	}
+
+	var r Range
+	if startStr == "" {
+		// Suffix range: the last n bytes
+		n, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil || n <= 0 {
+			return nil, ErrInvalidRange
+		}
+		if n > size {
+			n = size
+		}
+		r = Range{Start: size - n, End: size - 1}
+	} else {
+		start, err := strconv.ParseInt(startStr, 10, 64)
+		if err != nil || start < 0 {
+			return nil, ErrInvalidRange
+		}
+		end := size - 1
+		if endStr != "" {
+			if end, err = strconv.ParseInt(endStr, 10, 64); err != nil || end < start {
+				return nil, ErrInvalidRange
+			}
+			if end > size-1 {
+				end = size - 1
+			}
+		}
+		r = Range{Start: start, End: end}
+	}
+
+	if r.Start >= size {
+		return nil, ErrInvalidRange
+	}
+	return &r, nil
+}
+
+// Header renders the range as an HTTP Range header value
+func (r Range) Header() string {
+	if r.End < 0 {
+		return fmt.Sprintf("bytes=%d-", r.Start)
+	}
+	return fmt.Sprintf("bytes=%d-%d", r.Start, r.End)
+}
+
+// Length is the number of bytes in the range for an object of the given size
+func (r Range) Length(size int64) int64 {
+	end := r.End
+	if end < 0 || end > size-1 {
+		end = size - 1
+	}
+	return end - r.Start + 1
+}
+
+// PutFileHeader streams a multipart upload (as returned by c.FormFile) into key
+// and returns the URL to store
+func PutFileHeader(ctx context.Context, s Storage, key string, fh *multipart.FileHeader) (string, error) {
+	file, err := fh.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	contentType := fh.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	if err := s.Put(ctx, key, file, fh.Size, contentType); err != nil {
+		return "", err
+	}
+	return s.URL(key), nil
+}
@@ -0,0 +1,293 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SupabaseConfig holds Supabase Storage configuration
+type SupabaseConfig struct {
+	URL    string // e.g. https://xxxx.supabase.co
+	Key    string // service_role key
+	Bucket string // bucket name
+	// Private buckets are only readable with the service key or a signed URL;
+	// their stored URLs use the authenticated object path instead of the public one
+	Private bool
+}
+
+// Supabase stores objects in a Supabase Storage bucket
+type Supabase struct {
+	cfg    SupabaseConfig
+	client *http.Client
+}
+
+// NewSupabase creates a Supabase Storage driver
+func NewSupabase(cfg SupabaseConfig) *Supabase {
+	cfg.URL = strings.TrimRight(cfg.URL, "/")
+	return &Supabase{cfg: cfg, client: &http.Client{}}
+}
+
+func (s *Supabase) endpoint(kind, key string) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	// e.g. https://<project>.supabase.co/storage/v1/object/authenticated/<bucket>/<path>
+	base := s.cfg.URL + "/storage/v1/object"
+	if kind != "" {
+		base += "/" + kind
+	}
+	return fmt.Sprintf("%s/%s/%s", base, s.cfg.Bucket, escapeKey(key)), nil
+}
+
+func (s *Supabase) do(ctx context.Context, method, kind, key string, body io.Reader, headers map[string]string) (*http.Response, error) {
+	if s.cfg.URL == "" || s.cfg.Key == "" {
+		return nil, fmt.Errorf("supabase not configured")
+	}
+	u, err := s.endpoint(kind, key)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.cfg.Key)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if br, ok := body.(*bytes.Reader); ok {
+		req.ContentLength = int64(br.Len())
+	}
+	return s.client.Do(req)
+}
+
+// Put streams the body to Supabase, overwriting an existing object
+func (s *Supabase) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if s.cfg.URL == "" || s.cfg.Key == "" {
+		return fmt.Errorf("supabase not configured")
+	}
+	u, err := s.endpoint("", key)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, r)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	if size >= 0 {
+		req.ContentLength = size
+	}
+	req.Header.Set("Authorization", "Bearer "+s.cfg.Key)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("x-upsert", "true") // overwrite if exists
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("upload request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	return supabaseError(resp, "upload")
+}
+
+func (s *Supabase) Get(ctx context.Context, key string, rng *Range) (io.ReadCloser, ObjectInfo, error) {
+	headers := map[string]string{}
+	if rng != nil {
+		headers["Range"] = rng.Header()
+	}
+	resp, err := s.do(ctx, http.MethodGet, "authenticated", key, nil, headers)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	if err := supabaseError(resp, "get"); err != nil {
+		resp.Body.Close()
+		return nil, ObjectInfo{}, err
+	}
+	return resp.Body, objectInfoFromHeaders(key, resp), nil
+}
+
+func (s *Supabase) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, "", key, nil, nil)
+	if err != nil {
+		return fmt.Errorf("delete request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if err := supabaseError(resp, "delete"); err != nil && err != ErrNotFound {
+		return err
+	}
+	return nil
+}
+
+func (s *Supabase) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	resp, err := s.do(ctx, http.MethodHead, "authenticated", key, nil, nil)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	defer resp.Body.Close()
+	if err := supabaseError(resp, "stat"); err != nil {
+		return ObjectInfo{}, err
+	}
+	return objectInfoFromHeaders(key, resp), nil
+}
+
+// SignedURL asks Supabase for a signed download URL
+func (s *Supabase) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	seconds := int64(ttl / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	payload, _ := json.Marshal(map[string]int64{"expiresIn": seconds})
+
+	resp, err := s.do(ctx, http.MethodPost, "sign", key, bytes.NewReader(payload), map[string]string{"Content-Type": "application/json"})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err := supabaseError(resp, "sign"); err != nil {
+		return "", err
+	}
+
+	var out struct {
+		SignedURL string `json:"signedURL"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil || out.SignedURL == "" {
+		return "", fmt.Errorf("supabase sign error: unexpected response")
+	}
+	// The returned path is relative to /storage/v1
+	return s.cfg.URL + "/storage/v1" + out.SignedURL, nil
+}
+
+// access is the object path segment stored URLs use for this bucket
+func (s *Supabase) access() string {
+	if s.cfg.Private {
+		return "authenticated"
+	}
+	return "public"
+}
+
+// URL is the object URL; for a private bucket it only works with the service key
+func (s *Supabase) URL(key string) string {
+	return fmt.Sprintf("%s/storage/v1/object/%s/%s/%s", s.cfg.URL, s.access(), s.cfg.Bucket, key)
+}
+
+// KeyFromURL extracts the key from an object URL
+// e.g. "https://xxx.supabase.co/storage/v1/object/public/bucket/path/file.jpg" → "path/file.jpg"
+func (s *Supabase) KeyFromURL(u string) (string, bool) {
+	prefix := fmt.Sprintf("%s/storage/v1/object/%s/%s/", s.cfg.URL, s.access(), s.cfg.Bucket)
+	key, ok := strings.CutPrefix(u, prefix)
+	if !ok {
+		return "", false
+	}
+	if key, err := CleanKey(key); err == nil {
+		return key, true
+	}
+	return "", false
+}
+
+// supabaseListEntry is one row of a folder listing; folders have no id
+type supabaseListEntry struct {
+	Name      string    `json:"name"`
+	ID        *string   `json:"id"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Metadata  struct {
+		Size     int64  `json:"size"`
+		Mimetype string `json:"mimetype"`
+	} `json:"metadata"`
+}
+
+// supabaseListPage is how many entries one list call returns
+const supabaseListPage = 1000
+
+// List walks the bucket folder by folder; Supabase only lists one level at a time
+func (s *Supabase) List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	if s.cfg.URL == "" || s.cfg.Key == "" {
+		return fmt.Errorf("supabase not configured")
+	}
+	return s.listFolder(ctx, "", prefix, fn)
+}
+
+func (s *Supabase) listFolder(ctx context.Context, folder, prefix string, fn func(ObjectInfo) error) error {
+	for offset := 0; ; offset += supabaseListPage {
+		entries, err := s.listPage(ctx, folder, offset)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			key := e.Name
+			if folder != "" {
+				key = folder + "/" + e.Name
+			}
+			if e.ID == nil {
+				// Only descend into folders that can hold keys under prefix
+				if strings.HasPrefix(key+"/", prefix) || strings.HasPrefix(prefix, key+"/") {
+					if err := s.listFolder(ctx, key, prefix, fn); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			// Supabase keeps a placeholder object in folders created from the dashboard
+			if e.Name == ".emptyFolderPlaceholder" || !strings.HasPrefix(key, prefix) {
+				continue
+			}
+			if err := fn(ObjectInfo{Key: key, Size: e.Metadata.Size, ContentType: e.Metadata.Mimetype, ModTime: e.UpdatedAt}); err != nil {
+				return err
+			}
+		}
+		if len(entries) < supabaseListPage {
+			return nil
+		}
+	}
+}
+
+func (s *Supabase) listPage(ctx context.Context, folder string, offset int) ([]supabaseListEntry, error) {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"prefix": folder,
+		"limit":  supabaseListPage,
+		"offset": offset,
+		"sortBy": map[string]string{"column": "name", "order": "asc"},
+	})
+	u := fmt.Sprintf("%s/storage/v1/object/list/%s", s.cfg.URL, s.cfg.Bucket)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.cfg.Key)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("list request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if err := supabaseError(resp, "list"); err != nil {
+		return nil, err
+	}
+
+	var entries []supabaseListEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("supabase list error: %v", err)
+	}
+	return entries, nil
+}
+
+func supabaseError(resp *http.Response, op string) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	if resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		return ErrInvalidRange
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	// Supabase reports missing objects as 404, or 400 with a not_found body
+	if resp.StatusCode == http.StatusNotFound || (resp.StatusCode == http.StatusBadRequest && bytes.Contains(bytes.ToLower(body), []byte("not_found"))) {
+		return ErrNotFound
+	}
+	return fmt.Errorf("supabase %s error (status %d): %s", op, resp.StatusCode, string(body))
+}
@@ -21,7 +21,7 @@ func TestCheckoutCart_Success(t *testing.T) {
 	db.MustExec(`INSERT INTO events (id, organization_id, title, publish_status) VALUES (1, 1, 'Event 1', 'PUBLISHED')`)
 	db.MustExec(`INSERT INTO sessions (id, event_id, title, price, publish_status) VALUES (1, 1, 'Session 1', 100000, 'PUBLISHED')`)
 	db.MustExec(`INSERT INTO carts (id, user_id) VALUES (1, 1)`)
-	db.MustExec(`INSERT INTO cart_items (id, cart_id, session_id) VALUES (1, 1, 1)`)
+	db.MustExec(`INSERT INTO cart_items (id, cart_id, session_id, price) VALUES (1, 1, 1, 100000)`)
 
 	c, w := testutils.CreateTestContextWithUserID(1)
 	controllers.CheckoutCart(c)
@@ -62,3 +62,321 @@ func TestCheckoutCart_NoCart(t *testing.T) {
 		t.Errorf("Expected status 400 or 404, got %d", w.Code)
 	}
 }
+
+// ================================
+// CART VALIDATION TESTS
+// ================================
+
+func TestCheckoutCart_StalePriceStopsCheckout(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedEntitlementSession()
+	db.MustExec(`INSERT INTO carts (id, user_id) VALUES (1, 1)`)
+	db.MustExec(`INSERT INTO cart_items (id, cart_id, item_type, session_id, price) VALUES (1, 1, 'SESSION', 1, 80000)`)
+
+	c, w := testutils.CreateTestContextWithUserID(1)
+	controllers.CheckoutCart(c)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusConflict, w.Code, w.Body.String())
+	}
+	if changed, _ := testutils.GetJSONResponse(w)["cart_changed"].(bool); !changed {
+		t.Error("Expected cart_changed in the response")
+	}
+
+	var price float64
+	db.Get(&price, `SELECT price FROM cart_items WHERE id = 1`)
+	if price != 100000 {
+		t.Errorf("Expected cart item repriced to 100000, got %.0f", price)
+	}
+
+	var purchases int
+	db.Get(&purchases, `SELECT COUNT(*) FROM purchases WHERE user_id = 1`)
+	if purchases != 0 {
+		t.Errorf("Expected no order for a stale cart, got %d purchase(s)", purchases)
+	}
+}
+
+func TestGetCart_RemovesStaleItems(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedEntitlementSession()
+	db.MustExec(`INSERT INTO sessions (id, event_id, title, price, publish_status) VALUES (2, 1, 'Draft Session', 50000, 'DRAFT'), (3, 1, 'Owned Session', 50000, 'PUBLISHED')`)
+	db.MustExec(`INSERT INTO entitlements (user_id, session_id, source) VALUES (1, 3, 'ADMIN')`)
+	db.MustExec(`INSERT INTO carts (id, user_id) VALUES (1, 1)`)
+	db.MustExec(`
+		INSERT INTO cart_items (id, cart_id, item_type, session_id, price) VALUES
+		(1, 1, 'SESSION', 1, 100000), (2, 1, 'SESSION', 2, 50000), (3, 1, 'SESSION', 3, 50000)
+	`)
+
+	c, w := testutils.CreateTestContextWithUserID(1)
+	controllers.GetCart(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	response := testutils.GetJSONResponse(w)
+	if warnings, _ := response["warnings"].([]interface{}); len(warnings) != 2 {
+		t.Errorf("Expected 2 warnings, got %v", response["warnings"])
+	}
+	if count, _ := response["item_count"].(float64); count != 1 {
+		t.Errorf("Expected 1 item left in the cart, got %v", response["item_count"])
+	}
+}
+
+// ================================
+// FREE ORDER TESTS
+// ================================
+
+func TestCheckoutCart_FreeCartSkipsPayment(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+
+	db.MustExec(`INSERT INTO users (id, name, email, password_hash) VALUES (1, 'User', 'user@test.com', 'hash')`)
+	db.MustExec(`INSERT INTO organizations (id, user_id, owner_user_id, name) VALUES (1, 1, 1, 'Test Org')`)
+	db.MustExec(`INSERT INTO events (id, organization_id, title, publish_status) VALUES (1, 1, 'Event 1', 'PUBLISHED')`)
+	db.MustExec(`INSERT INTO sessions (id, event_id, title, price, publish_status) VALUES (1, 1, 'Intro Gratis', 0, 'PUBLISHED')`)
+	db.MustExec(`INSERT INTO carts (id, user_id) VALUES (1, 1)`)
+	db.MustExec(`INSERT INTO cart_items (id, cart_id, item_type, session_id, price) VALUES (1, 1, 'SESSION', 1, 0)`)
+
+	c, w := testutils.CreateTestContextWithUserID(1)
+	controllers.CheckoutCart(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var status string
+	var pricePaid float64
+	db.QueryRow(`SELECT status, price_paid FROM purchases WHERE user_id = 1 AND session_id = 1`).Scan(&status, &pricePaid)
+	if status != "PAID" || pricePaid != 0 {
+		t.Errorf("Expected PAID purchase with price 0, got status=%s price=%.0f", status, pricePaid)
+	}
+
+	var cartItems int
+	db.Get(&cartItems, `SELECT COUNT(*) FROM cart_items WHERE cart_id = 1`)
+	if cartItems != 0 {
+		t.Errorf("Expected cart to be cleared, got %d items", cartItems)
+	}
+}
+
+func TestCheckoutCart_FullyDiscountedByCoupon(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+
+	db.MustExec(`INSERT INTO users (id, name, email, password_hash) VALUES (1, 'User', 'user@test.com', 'hash')`)
+	db.MustExec(`INSERT INTO organizations (id, user_id, owner_user_id, name) VALUES (1, 1, 1, 'Test Org')`)
+	db.MustExec(`INSERT INTO events (id, organization_id, title, publish_status) VALUES (1, 1, 'Event 1', 'PUBLISHED')`)
+	db.MustExec(`INSERT INTO sessions (id, event_id, title, price, publish_status) VALUES (1, 1, 'Session 1', 50000, 'PUBLISHED')`)
+	db.MustExec(`INSERT INTO coupons (id, organization_id, code, discount_type, discount_value, scope) VALUES (1, 1, 'GRATIS', 'PERCENT', 100, 'ORGANIZATION')`)
+	db.MustExec(`INSERT INTO carts (id, user_id, coupon_code) VALUES (1, 1, 'GRATIS')`)
+	db.MustExec(`INSERT INTO cart_items (id, cart_id, item_type, session_id, price) VALUES (1, 1, 'SESSION', 1, 50000)`)
+
+	c, w := testutils.CreateTestContextWithUserID(1)
+	controllers.CheckoutCart(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var redemptionStatus string
+	db.Get(&redemptionStatus, `SELECT status FROM coupon_redemptions WHERE coupon_id = 1`)
+	if redemptionStatus != "USED" {
+		t.Errorf("Expected coupon redemption USED, got %q", redemptionStatus)
+	}
+}
+
+func TestCheckoutCart_ReCheckoutReleasesReplacedCoupon(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+
+	db.MustExec(`INSERT INTO users (id, name, email, password_hash) VALUES (1, 'User', 'user@test.com', 'hash')`)
+	db.MustExec(`INSERT INTO organizations (id, user_id, owner_user_id, name) VALUES (1, 1, 1, 'Test Org')`)
+	db.MustExec(`INSERT INTO events (id, organization_id, title, publish_status) VALUES (1, 1, 'Event 1', 'PUBLISHED')`)
+	db.MustExec(`INSERT INTO sessions (id, event_id, title, price, publish_status) VALUES (1, 1, 'Session 1', 50000, 'PUBLISHED')`)
+	db.MustExec(`INSERT INTO coupons (id, organization_id, code, discount_type, discount_value, scope) VALUES (1, 1, 'GRATIS', 'PERCENT', 100, 'ORGANIZATION')`)
+	// An abandoned checkout of the same session still holds a coupon use
+	db.MustExec(`INSERT INTO purchases (user_id, session_id, amount, price_paid, status, order_id) VALUES (1, 1, 0, 0, 'PENDING', 'CART-1-1-1')`)
+	db.MustExec(`INSERT INTO coupon_redemptions (coupon_id, user_id, order_id, discount_amount, status) VALUES (1, 1, 'CART-1-1-1', 50000, 'PENDING')`)
+	db.MustExec(`INSERT INTO carts (id, user_id, coupon_code) VALUES (1, 1, 'GRATIS')`)
+	db.MustExec(`INSERT INTO cart_items (id, cart_id, item_type, session_id, price) VALUES (1, 1, 'SESSION', 1, 50000)`)
+
+	c, w := testutils.CreateTestContextWithUserID(1)
+	controllers.CheckoutCart(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var replaced string
+	db.Get(&replaced, `SELECT status FROM coupon_redemptions WHERE order_id = 'CART-1-1-1'`)
+	if replaced != "CANCELLED" {
+		t.Errorf("Expected the replaced order's redemption CANCELLED, got %q", replaced)
+	}
+	var used int
+	db.Get(&used, `SELECT COUNT(*) FROM coupon_redemptions WHERE coupon_id = 1 AND status = 'USED'`)
+	if used != 1 {
+		t.Errorf("Expected 1 USED redemption for the new order, got %d", used)
+	}
+}
+
+func TestCheckoutCart_PackageReCheckoutReleasesReplacedCoupon(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+
+	db.MustExec(`INSERT INTO users (id, name, email, password_hash) VALUES (1, 'User', 'user@test.com', 'hash')`)
+	db.MustExec(`INSERT INTO organizations (id, user_id, owner_user_id, name) VALUES (1, 1, 1, 'Test Org')`)
+	db.MustExec(`INSERT INTO events (id, organization_id, title, publish_status) VALUES (1, 1, 'Event 1', 'PUBLISHED')`)
+	db.MustExec(`INSERT INTO sessions (id, event_id, title, price, publish_status) VALUES (1, 1, 'Session 1', 50000, 'PUBLISHED')`)
+	db.MustExec(`INSERT INTO event_packages (id, event_id, price, is_active) VALUES (1, 1, 40000, 1)`)
+	db.MustExec(`INSERT INTO coupons (id, organization_id, code, discount_type, discount_value, scope) VALUES (1, 1, 'GRATIS', 'PERCENT', 100, 'ORGANIZATION')`)
+	// An abandoned package checkout still holds a coupon use
+	db.MustExec(`INSERT INTO purchases (user_id, session_id, package_id, event_id, amount, price_paid, status, order_id) VALUES (1, NULL, 1, 1, 0, 0, 'PENDING', 'CART-1-1-1')`)
+	db.MustExec(`INSERT INTO coupon_redemptions (coupon_id, user_id, order_id, discount_amount, status) VALUES (1, 1, 'CART-1-1-1', 40000, 'PENDING')`)
+	db.MustExec(`INSERT INTO carts (id, user_id, coupon_code) VALUES (1, 1, 'GRATIS')`)
+	db.MustExec(`INSERT INTO cart_items (id, cart_id, item_type, event_id, price) VALUES (1, 1, 'EVENT_PACKAGE', 1, 40000)`)
+
+	c, w := testutils.CreateTestContextWithUserID(1)
+	controllers.CheckoutCart(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var replaced string
+	db.Get(&replaced, `SELECT status FROM coupon_redemptions WHERE order_id = 'CART-1-1-1'`)
+	if replaced != "CANCELLED" {
+		t.Errorf("Expected the replaced order's redemption CANCELLED, got %q", replaced)
+	}
+	var oldLine string
+	db.Get(&oldLine, `SELECT status FROM purchases WHERE order_id = 'CART-1-1-1'`)
+	if oldLine != "CANCELLED" {
+		t.Errorf("Expected the replaced package line CANCELLED, got %q", oldLine)
+	}
+}
+
+func TestCheckoutCart_CouponLimitCountsUnpaidOrders(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+
+	db.MustExec(`INSERT INTO users (id, name, email, password_hash) VALUES (1, 'User', 'user@test.com', 'hash')`)
+	db.MustExec(`INSERT INTO organizations (id, user_id, owner_user_id, name) VALUES (1, 1, 1, 'Test Org')`)
+	db.MustExec(`INSERT INTO events (id, organization_id, title, publish_status) VALUES (1, 1, 'Event 1', 'PUBLISHED')`)
+	db.MustExec(`INSERT INTO sessions (id, event_id, title, price, publish_status) VALUES (1, 1, 'Session 1', 50000, 'PUBLISHED')`)
+	db.MustExec(`INSERT INTO coupons (id, organization_id, code, discount_type, discount_value, scope, per_user_limit) VALUES (1, 1, 'GRATIS', 'PERCENT', 100, 'ORGANIZATION', 1)`)
+	// An earlier order with the coupon is still waiting for payment
+	db.MustExec(`INSERT INTO coupon_redemptions (coupon_id, user_id, order_id, discount_amount, status) VALUES (1, 1, 'CART-1-1-1', 50000, 'PENDING')`)
+	db.MustExec(`INSERT INTO carts (id, user_id, coupon_code) VALUES (1, 1, 'GRATIS')`)
+	db.MustExec(`INSERT INTO cart_items (id, cart_id, item_type, session_id, price) VALUES (1, 1, 'SESSION', 1, 50000)`)
+
+	c, w := testutils.CreateTestContextWithUserID(1)
+	controllers.CheckoutCart(c)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+
+	var redemptions int
+	db.Get(&redemptions, `SELECT COUNT(*) FROM coupon_redemptions WHERE coupon_id = 1`)
+	if redemptions != 1 {
+		t.Errorf("Expected the unpaid order to keep the only redemption, got %d", redemptions)
+	}
+	var pending string
+	db.Get(&pending, `SELECT status FROM coupon_redemptions WHERE order_id = 'CART-1-1-1'`)
+	if pending != "PENDING" {
+		t.Errorf("Expected the earlier redemption to stay PENDING, got %q", pending)
+	}
+}
+
+func TestCheckoutCart_FreeClaimLimit(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+
+	db.MustExec(`INSERT INTO users (id, name, email, password_hash) VALUES (1, 'User', 'user@test.com', 'hash')`)
+	db.MustExec(`INSERT INTO organizations (id, user_id, owner_user_id, name) VALUES (1, 1, 1, 'Test Org')`)
+	db.MustExec(`INSERT INTO events (id, organization_id, title, publish_status) VALUES (1, 1, 'Event 1', 'PUBLISHED')`)
+	db.MustExec(`INSERT INTO sessions (id, event_id, title, price, publish_status) VALUES (1, 1, 'Gratis 1', 0, 'PUBLISHED'), (2, 1, 'Gratis 2', 0, 'PUBLISHED')`)
+	db.MustExec(`INSERT INTO platform_settings (setting_key, setting_value) VALUES ('free_claim_daily_limit', '1')`)
+	db.MustExec(`INSERT INTO purchases (user_id, session_id, amount, price_paid, status, order_id) VALUES (1, 1, 0, 0, 'PAID', 'FREE-1-1-1')`)
+	db.MustExec(`INSERT INTO carts (id, user_id) VALUES (1, 1)`)
+	db.MustExec(`INSERT INTO cart_items (id, cart_id, item_type, session_id, price) VALUES (1, 1, 'SESSION', 2, 0)`)
+
+	c, w := testutils.CreateTestContextWithUserID(1)
+	controllers.CheckoutCart(c)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d. Body: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+}
+
+// ================================
+// PAYMENT SETTLEMENT TESTS
+// ================================
+
+func TestProcessCartPayment_Idempotent(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+
+	db.MustExec(`INSERT INTO users (id, name, email, password_hash) VALUES (1, 'User', 'user@test.com', 'hash')`)
+	db.MustExec(`INSERT INTO organizations (id, user_id, owner_user_id, name) VALUES (1, 1, 1, 'Test Org')`)
+	db.MustExec(`INSERT INTO events (id, organization_id, title, publish_status) VALUES (1, 1, 'Event 1', 'PUBLISHED')`)
+	db.MustExec(`INSERT INTO sessions (id, event_id, title, price, publish_status) VALUES (1, 1, 'Session 1', 100000, 'PUBLISHED')`)
+	db.MustExec(`INSERT INTO purchases (user_id, session_id, amount, price_paid, status, order_id) VALUES (1, 1, 100000, 100000, 'PENDING', 'CART-1760000000-1-1')`)
+
+	// Webhook and reconciler both report the same settlement
+	for i := 0; i < 2; i++ {
+		if err := controllers.ProcessCartPayment("CART-1760000000-1-1", "100000.00"); err != nil {
+			t.Fatalf("Expected no error on call %d, got %v", i+1, err)
+		}
+	}
+
+	var sales int
+	db.Get(&sales, `SELECT COUNT(*) FROM financial_transactions WHERE reference_id = 'CART-1760000000-1-1' AND entity_type = 'ORGANIZATION'`)
+	if sales != 1 {
+		t.Errorf("Expected organization to be credited once, got %d transactions", sales)
+	}
+}
+
+// ================================
+// PAYMENT METHOD TESTS
+// ================================
+
+func TestCheckoutCart_PaymentMethodRestrictedByOrganization(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedEntitlementSession()
+	db.MustExec(`INSERT INTO platform_settings (setting_key, setting_value) VALUES ('payment_methods_enabled', 'gopay,bca_va')`)
+	db.MustExec(`UPDATE organizations SET payment_methods = 'gopay' WHERE id = 1`)
+	db.MustExec(`INSERT INTO carts (id, user_id) VALUES (1, 1)`)
+	db.MustExec(`INSERT INTO cart_items (id, cart_id, item_type, session_id, price) VALUES (1, 1, 'SESSION', 1, 100000)`)
+
+	c, w := testutils.CreateTestContextWithUserAndBody(1, map[string]interface{}{"payment_method": "bca_va"})
+	controllers.CheckoutCart(c)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+
+	var purchases int
+	db.Get(&purchases, `SELECT COUNT(*) FROM purchases WHERE user_id = 1`)
+	if purchases != 0 {
+		t.Errorf("Expected no order for a method the organization does not accept, got %d purchase(s)", purchases)
+	}
+}
+
+func TestProcessCartPayment_BooksPaymentFee(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedEntitlementSession()
+	db.MustExec(`INSERT INTO purchases (user_id, session_id, amount, price_paid, status, order_id, payment_method, payment_fee) VALUES (1, 1, 100000, 100000, 'PENDING', 'CART-1760000000-1-1', 'bca_va', 4000)`)
+
+	if err := controllers.ProcessCartPayment("CART-1760000000-1-1", "104000.00"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var fee float64
+	db.Get(&fee, `SELECT COALESCE(SUM(amount), 0) FROM financial_transactions WHERE reference_id = 'CART-1760000000-1-1' AND transaction_type = 'PAYMENT_FEE'`)
+	if fee != 4000 {
+		t.Errorf("Expected payment fee of 4000 booked, got %.0f", fee)
+	}
+}
@@ -0,0 +1,84 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+
+	"BACKEND/controllers"
+	"BACKEND/test/testutils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ================================
+// CURRENCY TESTS
+// ================================
+
+func TestUpdateExchangeRate_Upserts(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedEntitlementSession()
+
+	for _, rate := range []float64{16000, 16250} {
+		c, w := testutils.CreateTestContextWithUserParamsAndBody(1, gin.Params{{Key: "currency", Value: "usd"}}, map[string]interface{}{"rupiah_per_unit": rate})
+		controllers.UpdateExchangeRate(c)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+	}
+
+	var rate float64
+	db.Get(&rate, `SELECT rupiah_per_unit FROM exchange_rates WHERE currency = 'USD'`)
+	if rate != 16250 {
+		t.Errorf("Expected the latest rate 16250, got %.2f", rate)
+	}
+}
+
+func TestUpdateOrganizationCurrency_RequiresRate(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedEntitlementSession()
+
+	c, w := testutils.CreateTestContextWithUserAndBody(1, map[string]interface{}{"currency": "SGD"})
+	controllers.UpdateOrganizationCurrency(c)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status %d without a rate, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	db.MustExec(`INSERT INTO exchange_rates (currency, rupiah_per_unit) VALUES ('SGD', 12000)`)
+	c, w = testutils.CreateTestContextWithUserAndBody(1, map[string]interface{}{"currency": "SGD"})
+	controllers.UpdateOrganizationCurrency(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var currency string
+	db.Get(&currency, `SELECT currency FROM organizations WHERE id = 1`)
+	if currency != "SGD" {
+		t.Errorf("Expected organization currency SGD, got %s", currency)
+	}
+}
+
+func TestListPublicEvents_DisplaysOrganizationCurrency(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedEntitlementSession()
+	db.MustExec(`INSERT INTO exchange_rates (currency, rupiah_per_unit) VALUES ('USD', 16000)`)
+	db.MustExec(`UPDATE organizations SET currency = 'USD' WHERE id = 1`)
+
+	c, w := testutils.CreateTestContext()
+	controllers.ListPublicEvents(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	events, _ := testutils.GetJSONResponse(w)["events"].([]interface{})
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 published event, got %d", len(events))
+	}
+	price, _ := events[0].(map[string]interface{})["display_min_price"].(map[string]interface{})
+	// Rp 100.000 / 16.000 = US$ 6,25
+	if price["currency"] != "USD" || price["amount"] != float64(625) || price["formatted"] != "US$ 6,25" {
+		t.Errorf("Expected 625 cents (US$ 6,25), got %v", price)
+	}
+}
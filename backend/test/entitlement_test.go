@@ -0,0 +1,117 @@
+package test
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"BACKEND/config"
+	"BACKEND/controllers"
+	"BACKEND/entitlements"
+	"BACKEND/test/testutils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ================================
+// ENTITLEMENT TESTS
+// ================================
+
+func seedEntitlementSession() {
+	config.DB.MustExec(`INSERT INTO users (id, name, email, password_hash) VALUES (1, 'User', 'user@test.com', 'hash')`)
+	config.DB.MustExec(`INSERT INTO organizations (id, user_id, owner_user_id, name) VALUES (1, 1, 1, 'Test Org')`)
+	config.DB.MustExec(`INSERT INTO events (id, organization_id, title, publish_status) VALUES (1, 1, 'Event 1', 'PUBLISHED')`)
+	config.DB.MustExec(`INSERT INTO sessions (id, event_id, title, price, publish_status) VALUES (1, 1, 'Session 1', 100000, 'PUBLISHED')`)
+}
+
+func TestHasAccess_PendingPurchaseHasNoAccess(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedEntitlementSession()
+
+	db.MustExec(`INSERT INTO purchases (user_id, session_id, amount, price_paid, status, order_id) VALUES (1, 1, 100000, 100000, 'PENDING', 'CART-1-1-1')`)
+	if entitlements.HasAccess(1, 1) {
+		t.Fatal("Expected no access for a PENDING purchase")
+	}
+
+	if err := controllers.ProcessCartPayment("CART-1-1-1", "100000.00"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !entitlements.HasAccess(1, 1) {
+		t.Error("Expected access after the order is paid")
+	}
+}
+
+func TestAdminRevokeEntitlement(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedEntitlementSession()
+
+	err := entitlements.GrantAccess(db, entitlements.Grant{UserID: 1, SessionID: 1, Source: entitlements.SourceAdmin})
+	if err != nil || !entitlements.HasAccess(1, 1) {
+		t.Fatalf("Expected admin grant to give access, err=%v", err)
+	}
+
+	var id int64
+	db.Get(&id, `SELECT id FROM entitlements WHERE user_id = 1 AND session_id = 1`)
+
+	c, w := testutils.CreateTestContextWithUserParamsAndBody(1, gin.Params{{Key: "id", Value: strconv.FormatInt(id, 10)}}, map[string]string{"reason": "Salah input"})
+	controllers.AdminRevokeEntitlement(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if entitlements.HasAccess(1, 1) {
+		t.Error("Expected no access after revoke")
+	}
+}
+
+func TestHasAccess_ExpiredEntitlement(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedEntitlementSession()
+
+	db.MustExec(`INSERT INTO entitlements (user_id, session_id, source, starts_at, expires_at) VALUES (1, 1, 'ADMIN', DATE_SUB(NOW(), INTERVAL 2 DAY), DATE_SUB(NOW(), INTERVAL 1 DAY))`)
+	if entitlements.HasAccess(1, 1) {
+		t.Error("Expected no access with an expired entitlement")
+	}
+}
+
+func TestPackagePurchase_CoversSessionsAddedLater(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedEntitlementSession()
+
+	db.MustExec(`INSERT INTO sessions (id, event_id, title, price, publish_status) VALUES (2, 1, 'Session 2', 300000, 'PUBLISHED')`)
+	db.MustExec(`INSERT INTO event_packages (id, event_id, price, is_active) VALUES (1, 1, 200000, 1)`)
+	db.MustExec(`INSERT INTO purchases (user_id, session_id, package_id, event_id, amount, price_paid, status, order_id) VALUES (1, NULL, 1, 1, 200000, 200000, 'PENDING', 'CART-2-1-1')`)
+
+	if err := controllers.ProcessCartPayment("CART-2-1-1", "200000.00"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Revenue is split over the sessions by their own price (1:3)
+	var allocated []float64
+	db.Select(&allocated, `SELECT amount FROM package_revenue_allocations ORDER BY session_id`)
+	if len(allocated) != 2 || allocated[0] != 50000 || allocated[1] != 150000 {
+		t.Errorf("Expected allocations [50000 150000], got %v", allocated)
+	}
+
+	// A session added after the purchase is covered by the package
+	db.MustExec(`INSERT INTO sessions (id, event_id, title, price, publish_status) VALUES (3, 1, 'Session 3', 50000, 'PUBLISHED')`)
+	for _, sessionID := range []int64{1, 2, 3} {
+		if !entitlements.HasAccess(1, sessionID) {
+			t.Errorf("Expected package access to session %d", sessionID)
+		}
+	}
+
+	// Draft sessions stay locked until they are published
+	db.MustExec(`INSERT INTO sessions (id, event_id, title, price, publish_status) VALUES (4, 1, 'Session 4', 50000, 'DRAFT')`)
+	if entitlements.HasAccess(1, 4) {
+		t.Error("Expected no package access to a draft session")
+	}
+	db.MustExec(`UPDATE sessions SET publish_status = 'PUBLISHED' WHERE id = 4`)
+	if !entitlements.HasAccess(1, 4) {
+		t.Error("Expected package access once the session is published")
+	}
+}
@@ -0,0 +1,87 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+
+	"BACKEND/config"
+	"BACKEND/controllers"
+	"BACKEND/entitlements"
+	"BACKEND/test/testutils"
+)
+
+// ================================
+// GIFT CODE TESTS
+// ================================
+
+func seedGiftOrder() {
+	seedEntitlementSession()
+	config.DB.MustExec(`INSERT INTO users (id, name, email, password_hash) VALUES (2, 'Employee', 'employee@test.com', 'hash')`)
+	config.DB.MustExec(`
+		INSERT INTO purchases (user_id, session_id, amount, price_paid, status, order_id, is_gift, quantity, gift_recipients)
+		VALUES (1, 1, 300000, 300000, 'PENDING', 'CART-3-1-1', 1, 3, 'employee@test.com')
+	`)
+}
+
+func TestGiftPurchase_IssuesCodesWithoutBuyerAccess(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedGiftOrder()
+
+	if err := controllers.ProcessCartPayment("CART-3-1-1", "300000.00"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if entitlements.HasAccess(1, 1) {
+		t.Error("Expected the gift buyer not to get access from gifted seats")
+	}
+
+	var codes int
+	db.Get(&codes, `SELECT COUNT(*) FROM gift_codes WHERE order_id = 'CART-3-1-1' AND status = 'UNREDEEMED'`)
+	if codes != 3 {
+		t.Errorf("Expected 3 unredeemed codes, got %d", codes)
+	}
+
+	var invited int
+	db.Get(&invited, `SELECT COUNT(*) FROM gift_codes WHERE recipient_email = 'employee@test.com'`)
+	if invited != 1 {
+		t.Errorf("Expected 1 code assigned to the invited recipient, got %d", invited)
+	}
+
+	// Settling the same order again must not issue more seats
+	controllers.ProcessCartPayment("CART-3-1-1", "300000.00")
+	db.Get(&codes, `SELECT COUNT(*) FROM gift_codes WHERE order_id = 'CART-3-1-1'`)
+	if codes != 3 {
+		t.Errorf("Expected still 3 codes after a repeated settlement, got %d", codes)
+	}
+}
+
+func TestRedeemGiftCode(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedGiftOrder()
+
+	if err := controllers.ProcessCartPayment("CART-3-1-1", "300000.00"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var code string
+	db.Get(&code, `SELECT code FROM gift_codes ORDER BY id LIMIT 1`)
+
+	c, w := testutils.CreateTestContextWithUserAndBody(2, map[string]string{"code": code})
+	controllers.RedeemGiftCode(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if !entitlements.HasAccess(2, 1) {
+		t.Error("Expected access after redeeming the gift code")
+	}
+
+	// The same code cannot be used twice
+	c, w = testutils.CreateTestContextWithUserAndBody(1, map[string]string{"code": code})
+	controllers.RedeemGiftCode(c)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a used code, got %d", http.StatusBadRequest, w.Code)
+	}
+}
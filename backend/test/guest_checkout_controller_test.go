@@ -0,0 +1,184 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+
+	"BACKEND/config"
+	"BACKEND/controllers"
+	"BACKEND/entitlements"
+	"BACKEND/test/testutils"
+)
+
+// ================================
+// GUEST CHECKOUT TESTS
+// ================================
+
+// seedGuestOrder creates an unpaid guest order for session 1 placed with the given email
+func seedGuestOrder(email string) {
+	seedEntitlementSession()
+	config.DB.MustExec(`
+		INSERT INTO guest_orders (id, order_id, midtrans_order_id, email, name, phone, total)
+		VALUES (1, 'GUEST-1-1', 'GUEST-1-1', ?, 'Tamu', '081234567890', 100000)
+	`, email)
+	config.DB.MustExec(`INSERT INTO guest_order_items (guest_order_id, item_type, session_id, event_id, price) VALUES (1, 'SESSION', 1, 1, 100000)`)
+}
+
+func TestProcessGuestPayment_CreatesAccount(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedGuestOrder("tamu@test.com")
+
+	if err := controllers.ProcessGuestPayment("GUEST-1-1", "100000.00"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var userID int64
+	if err := db.Get(&userID, `SELECT id FROM users WHERE email = 'tamu@test.com'`); err != nil {
+		t.Fatalf("Expected an account for the guest, got %v", err)
+	}
+	if !entitlements.HasAccess(userID, 1) {
+		t.Error("Expected the new account to have access to the paid session")
+	}
+
+	var tokens int
+	db.Get(&tokens, `SELECT COUNT(*) FROM password_reset_tokens WHERE user_id = ? AND expires_at > NOW()`, userID)
+	if tokens != 1 {
+		t.Errorf("Expected 1 set-password code, got %d", tokens)
+	}
+
+	var order struct {
+		Status         string `db:"status"`
+		AccountCreated bool   `db:"account_created"`
+	}
+	db.Get(&order, `SELECT status, account_created FROM guest_orders WHERE id = 1`)
+	if order.Status != "PAID" || !order.AccountCreated {
+		t.Errorf("Expected order PAID with a created account, got %+v", order)
+	}
+}
+
+func TestProcessGuestPayment_MatchesExistingAccount(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedGuestOrder("USER@test.com")
+
+	if err := controllers.ProcessGuestPayment("GUEST-1-1", "100000.00"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var users int
+	db.Get(&users, `SELECT COUNT(*) FROM users`)
+	if users != 1 {
+		t.Errorf("Expected the existing account to be reused, got %d users", users)
+	}
+
+	var tokens int
+	db.Get(&tokens, `SELECT COUNT(*) FROM password_reset_tokens`)
+	if tokens != 0 {
+		t.Errorf("Expected no set-password code for an existing account, got %d", tokens)
+	}
+
+	var buyer int64
+	db.Get(&buyer, `SELECT user_id FROM purchases WHERE order_id = 'GUEST-1-1' AND status = 'PAID'`)
+	if buyer != 1 {
+		t.Errorf("Expected the paid purchase on user 1, got %d", buyer)
+	}
+}
+
+func TestProcessGuestPayment_IsIdempotent(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedGuestOrder("tamu@test.com")
+
+	for i := 0; i < 2; i++ {
+		if err := controllers.ProcessGuestPayment("GUEST-1-1", "100000.00"); err != nil {
+			t.Fatalf("Expected no error on call %d, got %v", i+1, err)
+		}
+	}
+
+	var purchases, users int
+	db.Get(&purchases, `SELECT COUNT(*) FROM purchases WHERE order_id = 'GUEST-1-1'`)
+	db.Get(&users, `SELECT COUNT(*) FROM users WHERE email = 'tamu@test.com'`)
+	if purchases != 1 || users != 1 {
+		t.Errorf("Expected 1 purchase and 1 account, got %d purchases and %d accounts", purchases, users)
+	}
+}
+
+func TestGuestCheckout_RejectsFreeItems(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedEntitlementSession()
+	db.MustExec(`INSERT INTO sessions (id, event_id, title, price, publish_status) VALUES (2, 1, 'Intro Gratis', 0, 'PUBLISHED')`)
+
+	c, w := testutils.CreateTestContextWithBody(map[string]interface{}{
+		"name":  "Tamu",
+		"email": "tamu@test.com",
+		"phone": "081234567890",
+		"items": []map[string]int64{{"session_id": 2}},
+	})
+	controllers.GuestCheckout(c)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d. Body: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+
+	var orders int
+	db.Get(&orders, `SELECT COUNT(*) FROM guest_orders`)
+	if orders != 0 {
+		t.Errorf("Expected no guest order, got %d", orders)
+	}
+}
+
+func TestProcessGuestPayment_KeepsOwnersPaidPurchase(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedGuestOrder("user@test.com")
+
+	// The owner bought the session after the guest order was placed
+	db.MustExec(`INSERT INTO purchases (user_id, session_id, amount, price_paid, status, order_id) VALUES (1, 1, 100000, 90000, 'PAID', 'CART-1-1-1')`)
+	entitlements.GrantAccess(db, entitlements.Grant{UserID: 1, SessionID: 1, Source: entitlements.SourcePurchase, SourceRef: "CART-1-1-1"})
+
+	if err := controllers.ProcessGuestPayment("GUEST-1-1", "100000.00"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var purchase struct {
+		OrderID   string  `db:"order_id"`
+		PricePaid float64 `db:"price_paid"`
+	}
+	db.Get(&purchase, `SELECT order_id, price_paid FROM purchases WHERE user_id = 1 AND session_id = 1`)
+	if purchase.OrderID != "CART-1-1-1" || purchase.PricePaid != 90000 {
+		t.Errorf("Expected the earlier paid purchase to stay untouched, got %+v", purchase)
+	}
+
+	var flagged int
+	db.Get(&flagged, `SELECT COUNT(*) FROM payment_reconciliation_logs WHERE order_id = 'GUEST-1-1' AND action = 'REVIEW' AND is_discrepancy = 1`)
+	if flagged != 1 {
+		t.Errorf("Expected the owned line to be flagged for a refund, got %d logs", flagged)
+	}
+}
+
+func TestGuestCheckout_RejectsItemsTheAccountOwns(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedEntitlementSession()
+	entitlements.GrantAccess(db, entitlements.Grant{UserID: 1, SessionID: 1, Source: entitlements.SourcePurchase, SourceRef: "CART-1-1-1"})
+
+	c, w := testutils.CreateTestContextWithBody(map[string]interface{}{
+		"name":  "Tamu",
+		"email": "user@test.com",
+		"phone": "081234567890",
+		"items": []map[string]int64{{"session_id": 1}},
+	})
+	controllers.GuestCheckout(c)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d, got %d. Body: %s", http.StatusConflict, w.Code, w.Body.String())
+	}
+
+	var orders int
+	db.Get(&orders, `SELECT COUNT(*) FROM guest_orders`)
+	if orders != 0 {
+		t.Errorf("Expected no guest order, got %d", orders)
+	}
+}
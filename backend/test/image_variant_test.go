@@ -0,0 +1,114 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"testing"
+
+	"BACKEND/config"
+	"BACKEND/controllers"
+	"BACKEND/test/testutils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ================================
+// IMAGE VARIANT TESTS
+// ================================
+
+func testImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{30, 90, 200, 255})
+		}
+	}
+	return img
+}
+
+func TestUploadEventThumbnail_ReturnsVariants(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedMediaSession()
+
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, testImage(2000, 1200), nil)
+
+	c, w := testutils.CreateTestContextWithUserParamsAndFile(1, gin.Params{{Key: "eventID", Value: "1"}}, "thumbnail", "poster.jpg", buf.Bytes())
+	controllers.UploadEventThumbnail(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Thumbnail controllers.ImageInfo `json:"thumbnail"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Thumbnail.Width != 2000 || resp.Thumbnail.Blurhash == "" || resp.Thumbnail.DominantColor != "#1e5ac8" {
+		t.Errorf("Unexpected image info %+v", resp.Thumbnail)
+	}
+	for _, name := range []string{"card", "hero"} {
+		url := resp.Thumbnail.Variants[name]
+		key, ok := config.Storage.KeyFromURL(url)
+		if !ok {
+			t.Fatalf("Expected a %s variant in storage, got %q", name, url)
+		}
+		if _, err := config.Storage.Stat(context.Background(), key); err != nil {
+			t.Errorf("Expected %s to exist: %v", key, err)
+		}
+	}
+
+	// The public listing carries the same variants
+	c, w = testutils.CreateTestContext()
+	controllers.ListPublicEvents(c)
+	var list struct {
+		Events []struct {
+			Thumbnail *controllers.ImageInfo `json:"thumbnail"`
+		} `json:"events"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list.Events) != 1 || list.Events[0].Thumbnail == nil || list.Events[0].Thumbnail.Variants["card"] != resp.Thumbnail.Variants["card"] {
+		t.Errorf("Expected the listing to include variants. Body: %s", w.Body.String())
+	}
+}
+
+func TestProcessMissingImageVariants_Backfills(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedMediaSession()
+
+	var buf bytes.Buffer
+	png.Encode(&buf, testImage(600, 300))
+	config.Storage.Put(context.Background(), "organization/old_logo.png", &buf, int64(buf.Len()), "image/png")
+	logoURL := config.Storage.URL("organization/old_logo.png")
+	db.MustExec(`UPDATE organizations SET logo_url = ? WHERE id = 1`, logoURL)
+
+	c, w := testutils.CreateTestContextWithUserAndBody(1, map[string]interface{}{"limit": 10})
+	controllers.ProcessMissingImageVariants(c)
+	if processed := testutils.GetJSONResponse(w)["processed"]; processed != float64(1) {
+		t.Fatalf("Expected 1 processed image, got %v. Body: %s", processed, w.Body.String())
+	}
+
+	var variant struct {
+		URL    string `db:"url"`
+		Width  int    `db:"width"`
+		Height int    `db:"height"`
+	}
+	db.Get(&variant, "SELECT url, width, height FROM image_variants WHERE source_url = ? AND name = 'avatar'", logoURL)
+	if variant.Width != 256 || variant.Height != 128 {
+		t.Errorf("Expected the logo to fit 256x128 without cropping, got %+v", variant)
+	}
+
+	// Nothing left to do on a second run
+	c, w = testutils.CreateTestContextWithUserAndBody(1, nil)
+	controllers.ProcessMissingImageVariants(c)
+	if processed := testutils.GetJSONResponse(w)["processed"]; processed != float64(0) {
+		t.Errorf("Expected nothing to process, got %v", processed)
+	}
+}
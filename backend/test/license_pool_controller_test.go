@@ -0,0 +1,103 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+
+	"BACKEND/config"
+	"BACKEND/controllers"
+	"BACKEND/entitlements"
+	"BACKEND/test/testutils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ================================
+// LICENSE POOL TESTS
+// ================================
+
+// seedPaidLicensePool creates a 2-seat pool bought by user 1 and settles its order
+func seedPaidLicensePool(t *testing.T) {
+	seedEntitlementSession()
+	config.DB.MustExec(`INSERT INTO users (id, name, email, password_hash) VALUES (2, 'Employee A', 'a@test.com', 'hash')`)
+	config.DB.MustExec(`INSERT INTO users (id, name, email, password_hash) VALUES (3, 'Employee B', 'b@test.com', 'hash')`)
+	config.DB.MustExec(`INSERT INTO users (id, name, email, password_hash) VALUES (4, 'Employee C', 'c@test.com', 'hash')`)
+	config.DB.MustExec(`
+		INSERT INTO license_pools (id, organization_id, owner_user_id, event_id, name, seats_total, unit_price, status, order_id)
+		VALUES (1, 1, 1, 1, 'Tim Sales', 2, 50000, 'OFFERED', 'CART-4-1-1')
+	`)
+	config.DB.MustExec(`
+		INSERT INTO purchases (user_id, session_id, event_id, license_pool_id, amount, price_paid, status, order_id, quantity)
+		VALUES (1, NULL, 1, 1, 100000, 100000, 'PENDING', 'CART-4-1-1', 2)
+	`)
+
+	if err := controllers.ProcessCartPayment("CART-4-1-1", "100000.00"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+}
+
+func TestLicensePool_ActivatedOnPaymentWithoutBuyerAccess(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedPaidLicensePool(t)
+
+	var status string
+	db.Get(&status, `SELECT status FROM license_pools WHERE id = 1`)
+	if status != "ACTIVE" {
+		t.Errorf("Expected pool to be ACTIVE after payment, got %s", status)
+	}
+	if entitlements.HasAccess(1, 1) {
+		t.Error("Expected the pool buyer not to get access without a seat")
+	}
+}
+
+func TestAssignLicenseSeats_StopsAtCapacity(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedPaidLicensePool(t)
+
+	body := map[string][]string{"emails": {"a@test.com", "b@test.com", "c@test.com"}}
+	c, w := testutils.CreateTestContextWithUserParamsAndBody(1, gin.Params{{Key: "id", Value: "1"}}, body)
+	controllers.AssignLicenseSeats(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if !entitlements.HasAccess(2, 1) || !entitlements.HasAccess(3, 1) {
+		t.Error("Expected the first two employees to get access")
+	}
+	if entitlements.HasAccess(4, 1) {
+		t.Error("Expected the third employee to get no seat")
+	}
+
+	response := testutils.GetJSONResponse(w)
+	if noSeat, _ := response["no_seat"].([]interface{}); len(noSeat) != 1 {
+		t.Errorf("Expected 1 email without a seat, got %v", response["no_seat"])
+	}
+}
+
+func TestUnassignLicenseSeat_RevokesAccessAndFreesSeat(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedPaidLicensePool(t)
+
+	params := gin.Params{{Key: "id", Value: "1"}}
+	c, _ := testutils.CreateTestContextWithUserParamsAndBody(1, params, map[string][]string{"emails": {"a@test.com", "b@test.com"}})
+	controllers.AssignLicenseSeats(c)
+
+	c, w := testutils.CreateTestContextWithUserParamsAndBody(1, gin.Params{{Key: "id", Value: "1"}, {Key: "userID", Value: "2"}}, nil)
+	controllers.UnassignLicenseSeat(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if entitlements.HasAccess(2, 1) {
+		t.Error("Expected access to be revoked after unassigning the seat")
+	}
+
+	// The freed seat can go to someone else
+	c, _ = testutils.CreateTestContextWithUserParamsAndBody(1, params, map[string][]string{"emails": {"c@test.com"}})
+	controllers.AssignLicenseSeats(c)
+	if !entitlements.HasAccess(4, 1) {
+		t.Error("Expected the freed seat to be assignable again")
+	}
+}
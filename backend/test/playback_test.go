@@ -0,0 +1,180 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"BACKEND/config"
+	"BACKEND/controllers"
+	"BACKEND/entitlements"
+	"BACKEND/test/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// ================================
+// PLAYBACK SESSION TESTS
+// ================================
+
+func seedPlaybackVideo(t *testing.T, db *sqlx.DB) {
+	t.Helper()
+	seedMediaSession()
+	config.MediaStorage.Put(context.Background(), "videos/session_1_1.mp4", strings.NewReader("video-bytes"), 11, "video/mp4")
+	db.MustExec(`INSERT INTO session_videos (id, session_id, title, video_url) VALUES (1, 1, 'Video 1', ?)`, config.MediaStorage.URL("videos/session_1_1.mp4"))
+	if err := entitlements.GrantAccess(db, entitlements.Grant{UserID: 2, SessionID: 1, Source: entitlements.SourceAdmin}); err != nil {
+		t.Fatalf("GrantAccess: %v", err)
+	}
+}
+
+// requestPlayback asks for a signed video URL from an IP, optionally replacing an earlier playback
+func requestPlayback(userID int64, ip, replace string) *httptest.ResponseRecorder {
+	c, w := testutils.CreateTestContextWithUserID(userID)
+	target := "/api/user/sessions/signed-video/session_1_1.mp4"
+	if replace != "" {
+		target += "?playback_id=" + replace
+	}
+	c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+	c.Request.RemoteAddr = ip + ":40000"
+	c.Params = gin.Params{{Key: "filename", Value: "session_1_1.mp4"}}
+	controllers.GetSignedVideoURL(c)
+	return w
+}
+
+// streamVideo plays a signed video URL as returned by the API
+func streamVideo(t *testing.T, signed, ip string) *httptest.ResponseRecorder {
+	t.Helper()
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("Bad URL %q", signed)
+	}
+	c, w := testutils.CreateTestContext()
+	c.Request = httptest.NewRequest(http.MethodGet, signed, nil)
+	c.Request.RemoteAddr = ip + ":40000"
+	c.Params = gin.Params{{Key: "filename", Value: strings.TrimPrefix(u.Path, "/api/user/sessions/video/")}}
+	controllers.StreamSessionVideo(c)
+	return w
+}
+
+func TestPlayback_ConcurrentStreamLimit(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedPlaybackVideo(t, db)
+	config.PlaybackMaxConcurrent = 2
+
+	first := testutils.GetJSONResponse(requestPlayback(2, "10.0.0.1", ""))
+	second := testutils.GetJSONResponse(requestPlayback(2, "10.0.0.2", ""))
+	if first["playback_id"] == nil || second["playback_id"] == nil {
+		t.Fatalf("Expected two playbacks, got %v and %v", first, second)
+	}
+
+	w := requestPlayback(2, "10.0.0.3", "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected the third stream to be refused, got %d: %s", w.Code, w.Body.String())
+	}
+	if active, _ := testutils.GetJSONResponse(w)["active_playbacks"].([]interface{}); len(active) != 2 {
+		t.Errorf("Expected the two active playbacks to be listed, got %v", active)
+	}
+
+	// Reloading the player hands its slot to the new playback
+	w = requestPlayback(2, "10.0.0.1", first["playback_id"].(string))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected a reload to reuse its slot, got %d: %s", w.Code, w.Body.String())
+	}
+	reloaded := testutils.GetJSONResponse(w)
+	if w := streamVideo(t, first["url"].(string), "10.0.0.1"); w.Code != http.StatusForbidden {
+		t.Errorf("Expected the replaced playback to stop streaming, got %d", w.Code)
+	}
+	if w := streamVideo(t, reloaded["url"].(string), "10.0.0.1"); w.Code != http.StatusOK || w.Body.String() != "video-bytes" {
+		t.Errorf("Expected the new playback to stream, got %d %q", w.Code, w.Body.String())
+	}
+
+	// A signature only covers its own playback
+	forged := strings.Replace(reloaded["url"].(string), reloaded["playback_id"].(string), second["playback_id"].(string), 1)
+	if w := streamVideo(t, forged, "10.0.0.1"); w.Code != http.StatusForbidden {
+		t.Errorf("Expected a forged playback ID to be rejected, got %d", w.Code)
+	}
+
+	// A player left idle loses its slot once another device takes it
+	db.MustExec(`UPDATE playback_sessions SET last_seen_at = DATE_SUB(NOW(), INTERVAL 10 MINUTE) WHERE id = ?`, second["playback_id"])
+	if w := requestPlayback(2, "10.0.0.4", ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected the idle slot to be reused, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := streamVideo(t, second["url"].(string), "10.0.0.2"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected the idle player to be refused, got %d", w.Code)
+	}
+
+	// Ending a playback frees its slot
+	c, w := testutils.CreateTestContextWithUserParamsAndBody(2, gin.Params{{Key: "playbackID", Value: reloaded["playback_id"].(string)}}, nil)
+	controllers.EndPlayback(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected the playback to end, got %d", w.Code)
+	}
+	if w := requestPlayback(2, "10.0.0.5", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected a free slot after ending a playback, got %d", w.Code)
+	}
+}
+
+func TestPlayback_WatermarkTracesAccount(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedPlaybackVideo(t, db)
+
+	resp := testutils.GetJSONResponse(requestPlayback(2, "10.0.0.1", ""))
+	watermark, _ := resp["watermark"].(map[string]interface{})
+	code, _ := watermark["code"].(string)
+	if code == "" || !strings.Contains(watermark["text"].(string), "buyer@test.com") || !strings.Contains(watermark["text"].(string), code) {
+		t.Fatalf("Expected a watermark naming the buyer, got %v", watermark)
+	}
+	if positions, _ := watermark["positions"].([]interface{}); len(positions) == 0 {
+		t.Errorf("Expected overlay positions, got %v", watermark["positions"])
+	}
+
+	// Admins type the code as read off a recording
+	typed := strings.ToLower(strings.ReplaceAll(code, "-", ""))
+	c, w := testutils.CreateTestContextWithUserParamsAndBody(1, gin.Params{{Key: "code", Value: typed}}, nil)
+	controllers.AdminLookupWatermark(c)
+	playback, _ := testutils.GetJSONResponse(w)["playback"].(map[string]interface{})
+	if playback["user_email"] != "buyer@test.com" || playback["ip_address"] != "10.0.0.1" {
+		t.Errorf("Expected the buyer's playback, got %d %v", w.Code, playback)
+	}
+}
+
+func TestAdminGetSuspiciousPlaybacks(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedPlaybackVideo(t, db)
+
+	db.MustExec(`INSERT INTO playback_sessions (id, user_id, video_id, session_id, watermark_code, ip_address, last_ip) VALUES
+		('p1', 2, 1, 1, 'AAAA-AAAA-AAA1', '10.0.0.1', '10.0.0.1'),
+		('p2', 2, 1, 1, 'AAAA-AAAA-AAA2', '10.0.0.2', '10.0.0.2'),
+		('p3', 2, 1, 1, 'AAAA-AAAA-AAA3', '10.0.0.3', '10.0.0.3'),
+		('p4', 1, 1, 1, 'AAAA-AAAA-AAA4', '10.0.1.1', '10.0.1.1'),
+		('p5', 1, 1, 1, 'AAAA-AAAA-AAA5', '10.0.1.1', '10.0.1.1')`)
+
+	c, w := testutils.CreateTestContextWithUserID(1)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/admin/playbacks/suspicious?min_ips=3", nil)
+	controllers.AdminGetSuspiciousPlaybacks(c)
+	accounts, _ := testutils.GetJSONResponse(w)["accounts"].([]interface{})
+	if len(accounts) != 1 {
+		t.Fatalf("Expected only the buyer to be flagged, got %s", w.Body.String())
+	}
+	account := accounts[0].(map[string]interface{})
+	if account["user_id"] != float64(2) || account["ip_count"] != float64(3) || account["ips"] != "10.0.0.1,10.0.0.2,10.0.0.3" {
+		t.Errorf("Unexpected account %v", account)
+	}
+
+	// A stream URL that moved to another IP is flagged even below min_ips
+	db.MustExec(`UPDATE playback_sessions SET last_ip = '10.9.9.9' WHERE id = 'p4'`)
+	c, w = testutils.CreateTestContextWithUserID(1)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/admin/playbacks/suspicious?min_ips=5", nil)
+	controllers.AdminGetSuspiciousPlaybacks(c)
+	accounts, _ = testutils.GetJSONResponse(w)["accounts"].([]interface{})
+	if len(accounts) != 1 || accounts[0].(map[string]interface{})["ip_switches"] != float64(1) {
+		t.Errorf("Expected the organizer's moved playback to be flagged, got %s", w.Body.String())
+	}
+}
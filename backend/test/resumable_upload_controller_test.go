@@ -0,0 +1,236 @@
+package test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"BACKEND/config"
+	"BACKEND/controllers"
+	"BACKEND/test/testutils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ================================
+// RESUMABLE UPLOAD TESTS
+// ================================
+
+func createUpload(t *testing.T, body map[string]interface{}) (string, *httptest.ResponseRecorder) {
+	t.Helper()
+	c, w := testutils.CreateTestContextWithUserParamsAndBody(1, gin.Params{{Key: "sessionID", Value: "1"}}, body)
+	controllers.CreateResumableUpload(c)
+	id, _ := testutils.GetJSONResponse(w)["upload_id"].(string)
+	return id, w
+}
+
+func appendChunk(uploadID string, offset int64, chunk, checksum string) *httptest.ResponseRecorder {
+	c, w := testutils.CreateTestContextWithUserID(1)
+	c.Request = httptest.NewRequest(http.MethodPatch, "/api/organization/uploads/"+uploadID, strings.NewReader(chunk))
+	c.Request.Header.Set("Upload-Offset", strconv.FormatInt(offset, 10))
+	if checksum != "" {
+		c.Request.Header.Set("Upload-Checksum", checksum)
+	}
+	c.Params = gin.Params{{Key: "uploadID", Value: uploadID}}
+	controllers.AppendResumableUpload(c)
+	return w
+}
+
+// testVideo is a minimal MP4 (ftyp box) so content sniffing accepts it
+const testVideo = "\x00\x00\x00\x18ftypisom\x00\x00\x02\x00isomiso2" + "0123456789ABCDEF"
+
+func chunkChecksum(chunk string) string {
+	sum := sha256.Sum256([]byte(chunk))
+	return "sha256 " + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func TestResumableUpload_ChunksResumeAndComplete(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedMediaSession()
+
+	first, second := testVideo[:20], testVideo[20:]
+	whole := sha256.Sum256([]byte(testVideo))
+	id, w := createUpload(t, map[string]interface{}{
+		"filename":        "kuliah.mp4",
+		"size":            len(testVideo),
+		"content_type":    "video/mp4",
+		"title":           "Kuliah 1",
+		"checksum_sha256": hex.EncodeToString(whole[:]),
+	})
+	if w.Code != http.StatusCreated || id == "" {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	if w := appendChunk(id, 0, first, chunkChecksum(first)); w.Code != http.StatusOK {
+		t.Fatalf("Expected first chunk to be accepted, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Replaying the first chunk is refused with the current offset
+	w = appendChunk(id, 0, first, "")
+	if w.Code != http.StatusConflict || w.Header().Get("Upload-Offset") != "20" {
+		t.Errorf("Expected a conflict at offset 20, got %d (%s)", w.Code, w.Header().Get("Upload-Offset"))
+	}
+
+	// A corrupted chunk is discarded
+	w = appendChunk(id, 20, strings.ToLower(second), chunkChecksum(second))
+	if w.Code != 460 {
+		t.Errorf("Expected checksum mismatch, got %d", w.Code)
+	}
+
+	c, w := testutils.CreateTestContextWithUserID(1)
+	c.Params = gin.Params{{Key: "uploadID", Value: id}}
+	controllers.GetResumableUpload(c)
+	progress := testutils.GetJSONResponse(w)
+	if progress["offset"].(float64) != 20 || progress["progress"].(float64) != 50 {
+		t.Fatalf("Expected to resume at 50%%, got %v", progress)
+	}
+
+	if w := appendChunk(id, 20, second, chunkChecksum(second)); w.Code != http.StatusOK {
+		t.Fatalf("Expected second chunk to be accepted, got %d: %s", w.Code, w.Body.String())
+	}
+
+	c, w = testutils.CreateTestContextWithUserID(1)
+	c.Params = gin.Params{{Key: "uploadID", Value: id}}
+	controllers.CompleteResumableUpload(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected complete to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var video struct {
+		VideoURL  string `db:"video_url"`
+		SizeBytes int64  `db:"size_bytes"`
+		Title     string `db:"title"`
+	}
+	db.Get(&video, "SELECT video_url, size_bytes, title FROM session_videos WHERE session_id = 1")
+	if video.SizeBytes != int64(len(testVideo)) || video.Title != "Kuliah 1" {
+		t.Fatalf("Unexpected video row %+v", video)
+	}
+	key, ok := config.MediaStorage.KeyFromURL(video.VideoURL)
+	if !ok {
+		t.Fatalf("Expected the video in private storage, got %s", video.VideoURL)
+	}
+	rc, _, err := config.MediaStorage.Get(context.Background(), key, nil)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != testVideo {
+		t.Errorf("Expected the assembled file, got %q", data)
+	}
+
+	var status string
+	db.Get(&status, "SELECT status FROM resumable_uploads WHERE id = ?", id)
+	if status != "COMPLETED" {
+		t.Errorf("Expected COMPLETED, got %s", status)
+	}
+}
+
+func TestResumableUpload_EnforcesQuota(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedMediaSession()
+
+	db.MustExec(`UPDATE organizations SET storage_quota_bytes = 100 WHERE id = 1`)
+	db.MustExec(`INSERT INTO storage_objects (storage, object_key, organization_id, size_bytes) VALUES ('private', 'videos/old.mp4', 1, 60)`)
+
+	if _, w := createUpload(t, map[string]interface{}{"filename": "a.mp4", "size": 30}); w.Code != http.StatusCreated {
+		t.Fatalf("Expected 30 bytes to fit, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The unfinished upload above reserves its size
+	_, w := createUpload(t, map[string]interface{}{"filename": "b.mp4", "size": 20})
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected the quota to be exceeded, got %d", w.Code)
+	}
+}
+
+func TestResumableUpload_RechecksQuotaOnComplete(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedMediaSession()
+
+	db.MustExec(`UPDATE organizations SET storage_quota_bytes = 100 WHERE id = 1`)
+	id, w := createUpload(t, map[string]interface{}{"filename": "kuliah.mp4", "size": len(testVideo)})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected the upload to fit, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := appendChunk(id, 0, testVideo, ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected the chunk to be accepted, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Other files filled the quota while the upload was in progress
+	db.MustExec(`INSERT INTO storage_objects (storage, object_key, organization_id, size_bytes) VALUES ('private', 'videos/other.mp4', 1, 80)`)
+
+	c, w := testutils.CreateTestContextWithUserID(1)
+	c.Params = gin.Params{{Key: "uploadID", Value: id}}
+	controllers.CompleteResumableUpload(c)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Expected the quota to be re-checked, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var videos int
+	var status string
+	db.Get(&videos, "SELECT COUNT(*) FROM session_videos")
+	db.Get(&status, "SELECT status FROM resumable_uploads WHERE id = ?", id)
+	if videos != 0 || status != "UPLOADING" {
+		t.Errorf("Expected no video and a resumable upload, got %d videos and %s", videos, status)
+	}
+}
+
+func TestCleanupAbandonedUploads(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedMediaSession()
+
+	id, _ := createUpload(t, map[string]interface{}{"filename": "a.mp4", "size": 30})
+	db.MustExec(`UPDATE resumable_uploads SET expires_at = DATE_SUB(NOW(), INTERVAL 1 HOUR) WHERE id = ?`, id)
+
+	if n := controllers.CleanupAbandonedUploads(); n != 1 {
+		t.Fatalf("Expected 1 upload to be cleaned up, got %d", n)
+	}
+	var status string
+	db.Get(&status, "SELECT status FROM resumable_uploads WHERE id = ?", id)
+	if status != "EXPIRED" {
+		t.Errorf("Expected EXPIRED, got %s", status)
+	}
+
+	if w := appendChunk(id, 0, "x", ""); w.Code != http.StatusGone {
+		t.Errorf("Expected an expired upload to refuse chunks, got %d", w.Code)
+	}
+}
+
+func TestResumableUpload_RejectsNonVideoContent(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedMediaSession()
+
+	payload := "MZ\x90\x00 this is not a video"
+	id, _ := createUpload(t, map[string]interface{}{"filename": "kuliah.mp4", "size": len(payload)})
+	if w := appendChunk(id, 0, payload, ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected the chunk to be accepted, got %d: %s", w.Code, w.Body.String())
+	}
+
+	c, w := testutils.CreateTestContextWithUserID(1)
+	c.Params = gin.Params{{Key: "uploadID", Value: id}}
+	controllers.CompleteResumableUpload(c)
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("Expected the content to be rejected, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var status string
+	var videos int
+	db.Get(&status, "SELECT status FROM resumable_uploads WHERE id = ?", id)
+	db.Get(&videos, "SELECT COUNT(*) FROM session_videos")
+	if status != "ABORTED" || videos != 0 {
+		t.Errorf("Expected the upload to be aborted without a video, got %s and %d videos", status, videos)
+	}
+}
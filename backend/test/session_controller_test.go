@@ -1,10 +1,16 @@
 package test
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"BACKEND/config"
 	"BACKEND/controllers"
+	"BACKEND/helpers"
 	"BACKEND/test/testutils"
 
 	"github.com/gin-gonic/gin"
@@ -159,3 +165,40 @@ func TestDeleteSession_WithContent(t *testing.T) {
 		t.Error("Session and its content should be deleted")
 	}
 }
+
+// ================================
+// STREAM SESSION FILE TESTS
+// ================================
+
+func TestStreamSessionFile_ServesRangeFromStorage(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+
+	db.MustExec(`INSERT INTO users (id, name, email, password_hash) VALUES (1, 'Org User', 'org@test.com', 'hash')`)
+	db.MustExec(`INSERT INTO organizations (id, user_id, owner_user_id, name) VALUES (1, 1, 1, 'Test Org')`)
+	db.MustExec(`INSERT INTO events (id, organization_id, title, publish_status) VALUES (1, 1, 'Event 1', 'PUBLISHED')`)
+	db.MustExec(`INSERT INTO sessions (id, event_id, title, price, publish_status) VALUES (1, 1, 'Session 1', 0, 'PUBLISHED')`)
+
+	ctx := context.Background()
+	if err := config.Storage.Put(ctx, "files/modul.pdf", strings.NewReader("0123456789"), 10, "application/pdf"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	db.MustExec(`INSERT INTO session_files (id, session_id, title, file_url) VALUES (1, 1, 'Modul', ?)`, config.Storage.URL("files/modul.pdf"))
+
+	token, exp := helpers.GenerateSignedToken(1, "modul.pdf")
+	c, w := testutils.CreateTestContext()
+	c.Request = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/user/sessions/file/modul.pdf?token=%s&exp=%d&uid=1", token, exp), nil)
+	c.Request.Header.Set("Range", "bytes=2-5")
+	c.Params = gin.Params{{Key: "filename", Value: "modul.pdf"}}
+	controllers.StreamSessionFile(c)
+
+	if w.Code != http.StatusPartialContent {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusPartialContent, w.Code, w.Body.String())
+	}
+	if w.Body.String() != "2345" {
+		t.Errorf("Expected bytes 2-5, got %q", w.Body.String())
+	}
+	if got := w.Header().Get("Content-Range"); got != "bytes 2-5/10" {
+		t.Errorf("Unexpected Content-Range %q", got)
+	}
+}
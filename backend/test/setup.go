@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -11,6 +12,7 @@ import (
 	"github.com/joho/godotenv"
 
 	"BACKEND/config"
+	"BACKEND/storage"
 )
 
 // SetupTestDB initializes the test database using MySQL
@@ -46,6 +48,15 @@ func SetupTestDB() *sqlx.DB {
 	// Set the global DB to this test database
 	config.DB = db
 
+	// Uploads go to a throwaway directory on local disk
+	storageRoot, err := os.MkdirTemp("", "webbinar-storage-")
+	if err != nil {
+		log.Fatal("Failed to create test storage:", err)
+	}
+	config.Storage = storage.NewLocal(filepath.Join(storageRoot, "uploads"), "uploads", "/api/storage", []byte("test-secret"))
+	config.MediaStorage = storage.NewLocal(filepath.Join(storageRoot, "private"), "private", "/api/storage", []byte("test-secret"))
+	os.Setenv("STORAGE_UPLOAD_STAGING", filepath.Join(storageRoot, "staging"))
+
 	// Create schema
 	createTestSchema(db)
 
@@ -56,6 +67,42 @@ func SetupTestDB() *sqlx.DB {
 func createTestSchema(db *sqlx.DB) {
 	// Drop all tables first for clean state
 	tables := []string{
+		"bandwidth_usage",
+		"storage_objects",
+		"storage_plans",
+		"storage_gc_runs",
+		"affiliate_submission_files",
+		"affiliate_submission_videos",
+		"playback_sessions",
+		"video_watch_progress",
+		"user_certificates",
+		"quiz_attempts",
+		"quiz_questions",
+		"session_quizzes",
+		"event_certificates",
+		"image_variants",
+		"image_assets",
+		"resumable_uploads",
+		"exchange_rates",
+		"guest_order_items",
+		"guest_orders",
+		"wishlist_alerts",
+		"wishlists",
+		"subscription_payments",
+		"subscriptions",
+		"subscription_plans",
+		"license_seats",
+		"license_pools",
+		"gift_codes",
+		"package_revenue_allocations",
+		"event_packages",
+		"entitlements",
+		"payment_reconciliation_logs",
+		"invoices",
+		"invoice_sequences",
+		"coupon_redemptions",
+		"coupons",
+		"platform_settings",
 		"password_reset_tokens",
 		"featured_events",
 		"ad_banners",
@@ -102,6 +149,7 @@ func createTestSchema(db *sqlx.DB) {
 			gender VARCHAR(20),
 			birth_date DATE,
 			avatar_url VARCHAR(500),
+			profile_img VARCHAR(255),
 			address TEXT,
 			admin_level INT DEFAULT 0,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
@@ -152,6 +200,12 @@ func createTestSchema(db *sqlx.DB) {
 			bank_account VARCHAR(100),
 			bank_account_name VARCHAR(255),
 			is_official TINYINT DEFAULT 0,
+			platform_fee_percent DECIMAL(5,2) DEFAULT NULL,
+			payment_methods VARCHAR(255) DEFAULT NULL,
+			currency CHAR(3) NOT NULL DEFAULT 'IDR',
+			storage_quota_bytes BIGINT DEFAULT NULL,
+			storage_plan_id BIGINT DEFAULT NULL,
+			bandwidth_quota_bytes BIGINT DEFAULT NULL,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 		)
@@ -207,7 +261,7 @@ func createTestSchema(db *sqlx.DB) {
 			event_id BIGINT NOT NULL,
 			title VARCHAR(255) NOT NULL,
 			description TEXT,
-			price DECIMAL(15,2) DEFAULT 0,
+			price BIGINT DEFAULT 0,
 			order_index INT DEFAULT 0,
 			publish_status ENUM('DRAFT', 'SCHEDULED', 'PUBLISHED') DEFAULT 'DRAFT',
 			publish_at TIMESTAMP NULL,
@@ -224,8 +278,18 @@ func createTestSchema(db *sqlx.DB) {
 			title VARCHAR(255),
 			description TEXT,
 			video_url VARCHAR(500) NOT NULL,
+			size_bytes BIGINT DEFAULT 0,
 			duration INT DEFAULT 0,
 			sort_order INT DEFAULT 0,
+			hls_status ENUM('PENDING', 'PROCESSING', 'READY', 'FAILED') NOT NULL DEFAULT 'PENDING',
+			hls_prefix VARCHAR(255) DEFAULT NULL,
+			hls_renditions VARCHAR(255) DEFAULT NULL,
+			duration_seconds INT DEFAULT NULL,
+			width INT DEFAULT NULL,
+			height INT DEFAULT NULL,
+			poster_url VARCHAR(500) DEFAULT NULL,
+			transcode_error TEXT DEFAULT NULL,
+			transcoded_at DATETIME DEFAULT NULL,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
 		)
@@ -239,6 +303,7 @@ func createTestSchema(db *sqlx.DB) {
 			title VARCHAR(255),
 			file_url VARCHAR(500) NOT NULL,
 			file_type VARCHAR(50),
+			size_bytes BIGINT DEFAULT 0,
 			sort_order INT DEFAULT 0,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
@@ -250,15 +315,29 @@ func createTestSchema(db *sqlx.DB) {
 		CREATE TABLE IF NOT EXISTS purchases (
 			id BIGINT AUTO_INCREMENT PRIMARY KEY,
 			user_id BIGINT NOT NULL,
-			session_id BIGINT NOT NULL,
-			amount DECIMAL(15,2) NOT NULL,
-			price_paid DECIMAL(15,2) DEFAULT 0,
+			session_id BIGINT,
+			package_id BIGINT,
+			event_id BIGINT,
+			amount BIGINT NOT NULL,
+			price_paid BIGINT DEFAULT 0,
 			order_id VARCHAR(255),
-			status ENUM('PENDING', 'PAID', 'CANCELLED', 'EXPIRED') DEFAULT 'PENDING',
+			status ENUM('PENDING', 'PAID', 'FAILED', 'CANCELLED', 'EXPIRED') DEFAULT 'PENDING',
 			payment_method VARCHAR(50),
 			midtrans_order_id VARCHAR(255),
 			snap_token VARCHAR(500),
 			affiliate_code VARCHAR(50),
+			tax_amount BIGINT DEFAULT 0,
+			platform_fee BIGINT DEFAULT 0,
+			affiliate_commission BIGINT DEFAULT 0,
+			org_amount BIGINT DEFAULT 0,
+			coupon_code VARCHAR(50),
+			discount_amount BIGINT DEFAULT 0,
+			is_gift BOOLEAN DEFAULT FALSE,
+			quantity INT NOT NULL DEFAULT 1,
+			gift_recipients TEXT,
+			license_pool_id BIGINT,
+			payment_fee BIGINT DEFAULT 0,
+			currency CHAR(3) NOT NULL DEFAULT 'IDR',
 			purchased_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
 			FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
@@ -314,6 +393,7 @@ func createTestSchema(db *sqlx.DB) {
 			id BIGINT AUTO_INCREMENT PRIMARY KEY,
 			user_id BIGINT NOT NULL UNIQUE,
 			affiliate_code VARCHAR(50),
+			coupon_code VARCHAR(50),
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 		)
@@ -324,10 +404,15 @@ func createTestSchema(db *sqlx.DB) {
 		CREATE TABLE IF NOT EXISTS cart_items (
 			id BIGINT AUTO_INCREMENT PRIMARY KEY,
 			cart_id BIGINT NOT NULL,
-			item_type ENUM('SESSION', 'EVENT_PACKAGE') DEFAULT 'SESSION',
+			item_type ENUM('SESSION', 'EVENT_PACKAGE', 'LICENSE_POOL') DEFAULT 'SESSION',
 			session_id BIGINT,
 			event_id BIGINT,
-			price DECIMAL(15,2) DEFAULT 0,
+			price BIGINT DEFAULT 0,
+			currency CHAR(3) NOT NULL DEFAULT 'IDR',
+			quantity INT NOT NULL DEFAULT 1,
+			is_gift BOOLEAN DEFAULT FALSE,
+			recipient_emails TEXT,
+			license_pool_id BIGINT,
 			added_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY (cart_id) REFERENCES carts(id) ON DELETE CASCADE,
 			FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE,
@@ -468,6 +553,7 @@ func createTestSchema(db *sqlx.DB) {
 			organization_id BIGINT,
 			affiliate_id BIGINT,
 			amount DECIMAL(15,2) NOT NULL,
+			currency CHAR(3) NOT NULL DEFAULT 'IDR',
 			description TEXT,
 			reference_id VARCHAR(255),
 			status ENUM('PENDING', 'PROCESSING', 'COMPLETED', 'FAILED') DEFAULT 'PENDING',
@@ -517,11 +603,614 @@ func createTestSchema(db *sqlx.DB) {
 			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 		)
 	`)
+
+	// Platform settings table (fee & tax configuration)
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS platform_settings (
+			setting_key VARCHAR(100) PRIMARY KEY,
+			setting_value VARCHAR(255) NOT NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+		)
+	`)
+
+	// Coupons table (organization discount codes)
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS coupons (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			organization_id BIGINT NOT NULL,
+			code VARCHAR(50) NOT NULL UNIQUE,
+			description VARCHAR(255),
+			discount_type ENUM('PERCENT', 'FIXED') NOT NULL DEFAULT 'PERCENT',
+			discount_value DECIMAL(15,2) NOT NULL,
+			max_discount BIGINT,
+			scope ENUM('ORGANIZATION', 'EVENT', 'SESSION') NOT NULL DEFAULT 'ORGANIZATION',
+			event_id BIGINT,
+			session_id BIGINT,
+			min_cart_total BIGINT DEFAULT 0,
+			usage_limit INT,
+			per_user_limit INT,
+			starts_at DATETIME,
+			expires_at DATETIME,
+			is_active BOOLEAN DEFAULT TRUE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE
+		)
+	`)
+
+	// Coupon redemptions table
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS coupon_redemptions (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			coupon_id BIGINT NOT NULL,
+			user_id BIGINT NOT NULL,
+			order_id VARCHAR(255) NOT NULL,
+			discount_amount BIGINT DEFAULT 0,
+			status ENUM('PENDING', 'USED', 'CANCELLED') DEFAULT 'PENDING',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			used_at TIMESTAMP NULL,
+			FOREIGN KEY (coupon_id) REFERENCES coupons(id) ON DELETE CASCADE
+		)
+	`)
+
+	// Invoice number sequence per year
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS invoice_sequences (
+			year INT PRIMARY KEY,
+			last_number BIGINT NOT NULL DEFAULT 0
+		)
+	`)
+
+	// Invoices table (one per paid order)
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS invoices (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			invoice_number VARCHAR(30) NOT NULL UNIQUE,
+			invoice_year INT NOT NULL,
+			sequence_number BIGINT NOT NULL,
+			order_id VARCHAR(255) NOT NULL UNIQUE,
+			user_id BIGINT NOT NULL,
+			buyer_name VARCHAR(255),
+			buyer_email VARCHAR(255),
+			buyer_phone VARCHAR(50),
+			subtotal BIGINT DEFAULT 0,
+			discount_amount BIGINT DEFAULT 0,
+			total_amount BIGINT DEFAULT 0,
+			tax_amount BIGINT DEFAULT 0,
+			currency CHAR(3) NOT NULL DEFAULT 'IDR',
+			issued_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			emailed_at TIMESTAMP NULL
+		)
+	`)
+
+	// Pending order reconciliation outcomes
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS payment_reconciliation_logs (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			order_id VARCHAR(255) NOT NULL,
+			local_total BIGINT DEFAULT 0,
+			provider_status VARCHAR(50),
+			provider_amount VARCHAR(50),
+			action VARCHAR(20) NOT NULL,
+			is_discrepancy BOOLEAN DEFAULT FALSE,
+			note TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+
+	// Entitlements table (who may access which session)
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS entitlements (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			user_id BIGINT NOT NULL,
+			session_id BIGINT,
+			event_id BIGINT,
+			source ENUM('PURCHASE', 'PACKAGE', 'GIFT', 'ADMIN', 'SUBSCRIPTION', 'LICENSE') NOT NULL,
+			source_ref VARCHAR(255) NOT NULL DEFAULT '',
+			starts_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			expires_at TIMESTAMP NULL,
+			granted_by BIGINT,
+			note VARCHAR(255),
+			revoked_at TIMESTAMP NULL,
+			revoked_by BIGINT,
+			revoke_reason VARCHAR(255),
+			organization_id BIGINT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE KEY unique_entitlement (user_id, session_id, source, source_ref),
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+			FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE,
+			FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE
+		)
+	`)
+
+	// Event packages table (one priced bundle per event)
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS event_packages (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			event_id BIGINT NOT NULL UNIQUE,
+			title VARCHAR(255),
+			description TEXT,
+			price BIGINT NOT NULL,
+			is_active BOOLEAN DEFAULT TRUE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE
+		)
+	`)
+
+	// Package revenue allocated per session
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS package_revenue_allocations (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			purchase_id BIGINT NOT NULL,
+			session_id BIGINT NOT NULL,
+			amount BIGINT NOT NULL DEFAULT 0,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE KEY unique_allocation (purchase_id, session_id),
+			FOREIGN KEY (purchase_id) REFERENCES purchases(id) ON DELETE CASCADE,
+			FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+		)
+	`)
+
+	// Gift codes (one per gifted seat)
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS gift_codes (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			code VARCHAR(32) NOT NULL UNIQUE,
+			purchase_id BIGINT NOT NULL,
+			order_id VARCHAR(255) NOT NULL,
+			buyer_user_id BIGINT NOT NULL,
+			session_id BIGINT,
+			event_id BIGINT NOT NULL,
+			recipient_email VARCHAR(255),
+			status ENUM('UNREDEEMED', 'REDEEMED', 'REVOKED') DEFAULT 'UNREDEEMED',
+			redeemed_by BIGINT,
+			redeemed_at TIMESTAMP NULL DEFAULT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (purchase_id) REFERENCES purchases(id) ON DELETE CASCADE,
+			FOREIGN KEY (buyer_user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`)
+
+	// License pools (bulk seats for B2B buyers)
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS license_pools (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			organization_id BIGINT NOT NULL,
+			owner_user_id BIGINT NOT NULL,
+			event_id BIGINT NOT NULL,
+			name VARCHAR(255) NOT NULL,
+			seats_total INT NOT NULL,
+			unit_price BIGINT NOT NULL,
+			status ENUM('OFFERED', 'ACTIVE', 'CANCELLED') DEFAULT 'OFFERED',
+			order_id VARCHAR(255),
+			expires_at TIMESTAMP NULL DEFAULT NULL,
+			note TEXT,
+			created_by BIGINT,
+			paid_at TIMESTAMP NULL DEFAULT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			FOREIGN KEY (owner_user_id) REFERENCES users(id) ON DELETE CASCADE,
+			FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE
+		)
+	`)
+
+	// License seat assignments
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS license_seats (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			pool_id BIGINT NOT NULL,
+			user_id BIGINT NOT NULL,
+			assigned_by BIGINT,
+			assigned_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			unassigned_at TIMESTAMP NULL DEFAULT NULL,
+			UNIQUE KEY unique_license_seat (pool_id, user_id),
+			FOREIGN KEY (pool_id) REFERENCES license_pools(id) ON DELETE CASCADE,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`)
+
+	// Subscription plans (membership to an organization's catalog)
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS subscription_plans (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			organization_id BIGINT NOT NULL,
+			name VARCHAR(255) NOT NULL,
+			description TEXT,
+			billing_interval ENUM('MONTHLY', 'ANNUAL') NOT NULL DEFAULT 'MONTHLY',
+			price BIGINT NOT NULL,
+			grace_days INT NOT NULL DEFAULT 3,
+			is_active TINYINT(1) DEFAULT 1,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE
+		)
+	`)
+
+	// Subscriptions
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS subscriptions (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			plan_id BIGINT NOT NULL,
+			user_id BIGINT NOT NULL,
+			organization_id BIGINT NOT NULL,
+			status ENUM('PENDING', 'ACTIVE', 'PAST_DUE', 'CANCELLED', 'EXPIRED') DEFAULT 'PENDING',
+			current_period_start TIMESTAMP NULL DEFAULT NULL,
+			current_period_end TIMESTAMP NULL DEFAULT NULL,
+			cancel_at_period_end TINYINT(1) DEFAULT 0,
+			cancelled_at TIMESTAMP NULL DEFAULT NULL,
+			reminder_sent_at TIMESTAMP NULL DEFAULT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			UNIQUE KEY unique_subscription (user_id, plan_id),
+			FOREIGN KEY (plan_id) REFERENCES subscription_plans(id) ON DELETE CASCADE,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`)
+
+	// Subscription billing periods
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS subscription_payments (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			subscription_id BIGINT NOT NULL,
+			order_id VARCHAR(255) NOT NULL,
+			amount BIGINT NOT NULL,
+			period_start TIMESTAMP NULL DEFAULT NULL,
+			period_end TIMESTAMP NULL DEFAULT NULL,
+			status ENUM('PENDING', 'PAID', 'FAILED') DEFAULT 'PENDING',
+			snap_token VARCHAR(255),
+			tax_amount BIGINT DEFAULT 0,
+			platform_fee BIGINT DEFAULT 0,
+			org_amount BIGINT DEFAULT 0,
+			currency CHAR(3) NOT NULL DEFAULT 'IDR',
+			paid_at TIMESTAMP NULL DEFAULT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE KEY unique_subscription_order (order_id),
+			FOREIGN KEY (subscription_id) REFERENCES subscriptions(id) ON DELETE CASCADE
+		)
+	`)
+
+	// Wishlists
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS wishlists (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			user_id BIGINT NOT NULL,
+			event_id BIGINT NOT NULL,
+			session_id BIGINT,
+			item_key BIGINT AS (COALESCE(session_id, 0)) VIRTUAL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE KEY unique_wishlist_item (user_id, event_id, item_key),
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+			FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE,
+			FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+		)
+	`)
+
+	// Wishlist alerts already sent
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS wishlist_alerts (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			wishlist_id BIGINT NOT NULL,
+			alert_type ENUM('PUBLISHED', 'PRICE_DROP', 'COUPON') NOT NULL,
+			ref VARCHAR(64) NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE KEY unique_wishlist_alert (wishlist_id, alert_type, ref),
+			FOREIGN KEY (wishlist_id) REFERENCES wishlists(id) ON DELETE CASCADE
+		)
+	`)
+
+	// Guest checkout orders
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS guest_orders (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			order_id VARCHAR(255) NOT NULL UNIQUE,
+			midtrans_order_id VARCHAR(255),
+			email VARCHAR(255) NOT NULL,
+			name VARCHAR(255) NOT NULL,
+			phone VARCHAR(50) NOT NULL,
+			affiliate_code VARCHAR(50),
+			total BIGINT NOT NULL DEFAULT 0,
+			status ENUM('PENDING', 'PAID', 'FAILED', 'EXPIRED') DEFAULT 'PENDING',
+			snap_token VARCHAR(500),
+			user_id BIGINT DEFAULT NULL,
+			account_created BOOLEAN DEFAULT FALSE,
+			payment_method VARCHAR(50) DEFAULT NULL,
+			payment_fee BIGINT DEFAULT 0,
+			currency CHAR(3) NOT NULL DEFAULT 'IDR',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			paid_at TIMESTAMP NULL DEFAULT NULL,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+		)
+	`)
+
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS guest_order_items (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			guest_order_id BIGINT NOT NULL,
+			item_type ENUM('SESSION', 'EVENT_PACKAGE') NOT NULL,
+			session_id BIGINT DEFAULT NULL,
+			event_id BIGINT NOT NULL,
+			package_id BIGINT DEFAULT NULL,
+			price BIGINT NOT NULL,
+			FOREIGN KEY (guest_order_id) REFERENCES guest_orders(id) ON DELETE CASCADE
+		)
+	`)
+
+	// Exchange rates (rupiah per unit, display only)
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS exchange_rates (
+			currency CHAR(3) PRIMARY KEY,
+			rupiah_per_unit DECIMAL(18,6) NOT NULL,
+			updated_by BIGINT DEFAULT NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+		)
+	`)
+
+	// Resumable uploads (chunks staged on disk until complete)
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS resumable_uploads (
+			id CHAR(32) PRIMARY KEY,
+			user_id BIGINT NOT NULL,
+			organization_id BIGINT NOT NULL,
+			session_id BIGINT NOT NULL,
+			filename VARCHAR(255) NOT NULL,
+			title VARCHAR(255) NOT NULL,
+			description TEXT,
+			content_type VARCHAR(100) NOT NULL DEFAULT 'application/octet-stream',
+			size_bytes BIGINT NOT NULL,
+			offset_bytes BIGINT NOT NULL DEFAULT 0,
+			checksum_sha256 CHAR(64) DEFAULT NULL,
+			status ENUM('UPLOADING', 'COMPLETED', 'ABORTED', 'EXPIRED') NOT NULL DEFAULT 'UPLOADING',
+			video_id BIGINT DEFAULT NULL,
+			expires_at DATETIME NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+		)
+	`)
+
+	// Image variants (resized copies of thumbnails, logos, profile images and banners)
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS image_assets (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			source_url VARCHAR(512) NOT NULL,
+			width INT NOT NULL,
+			height INT NOT NULL,
+			blurhash VARCHAR(64) NOT NULL,
+			dominant_color CHAR(7) NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE KEY uniq_image_assets_source (source_url)
+		)
+	`)
+
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS image_variants (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			source_url VARCHAR(512) NOT NULL,
+			name VARCHAR(20) NOT NULL,
+			url VARCHAR(512) NOT NULL,
+			width INT NOT NULL,
+			height INT NOT NULL,
+			size_bytes BIGINT NOT NULL DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE KEY uniq_image_variants (source_url, name)
+		)
+	`)
+
+	// Quizzes and certificates
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS event_certificates (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			event_id BIGINT NOT NULL UNIQUE,
+			is_enabled TINYINT(1) DEFAULT 0,
+			min_score_percent INT DEFAULT 80,
+			min_watch_percent INT NOT NULL DEFAULT 0,
+			certificate_title VARCHAR(255),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS session_quizzes (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			session_id BIGINT NOT NULL UNIQUE,
+			title VARCHAR(255),
+			is_enabled TINYINT(1) DEFAULT 1,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS quiz_questions (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			quiz_id BIGINT NOT NULL,
+			question_text TEXT NOT NULL,
+			option_a VARCHAR(500) NOT NULL,
+			option_b VARCHAR(500) NOT NULL,
+			option_c VARCHAR(500),
+			option_d VARCHAR(500),
+			correct_option CHAR(1) NOT NULL,
+			order_index INT DEFAULT 0
+		)
+	`)
+
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS quiz_attempts (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			user_id BIGINT NOT NULL,
+			quiz_id BIGINT NOT NULL,
+			score_percent DECIMAL(5,2) NOT NULL,
+			answers JSON,
+			passed TINYINT(1) DEFAULT 0,
+			attempted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS user_certificates (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			user_id BIGINT NOT NULL,
+			event_id BIGINT NOT NULL,
+			total_score_percent DECIMAL(5,2) NOT NULL,
+			certificate_code VARCHAR(50) UNIQUE,
+			issued_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE KEY unique_cert (user_id, event_id)
+		)
+	`)
+
+	// Video watch progress (playback heartbeats)
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS video_watch_progress (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			user_id BIGINT NOT NULL,
+			video_id BIGINT NOT NULL,
+			session_id BIGINT NOT NULL,
+			position_seconds DECIMAL(10,2) NOT NULL DEFAULT 0,
+			watched_seconds DECIMAL(10,2) NOT NULL DEFAULT 0,
+			duration_seconds DECIMAL(10,2) NOT NULL DEFAULT 0,
+			percent DECIMAL(5,2) NOT NULL DEFAULT 0,
+			completed_at DATETIME DEFAULT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			UNIQUE KEY uniq_watch_progress (user_id, video_id),
+			INDEX idx_watch_progress_session (user_id, session_id)
+		)
+	`)
+
+	// Playback sessions (concurrent stream limit, watermarks)
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS playback_sessions (
+			id CHAR(32) PRIMARY KEY,
+			user_id BIGINT NOT NULL,
+			video_id BIGINT NOT NULL,
+			session_id BIGINT NOT NULL,
+			watermark_code CHAR(14) NOT NULL,
+			ip_address VARCHAR(45) NOT NULL,
+			last_ip VARCHAR(45) NOT NULL,
+			user_agent VARCHAR(255) DEFAULT NULL,
+			started_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			last_seen_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			ended_at DATETIME DEFAULT NULL,
+			end_reason ENUM('CLOSED', 'REPLACED') DEFAULT NULL,
+			UNIQUE KEY uniq_playback_watermark (watermark_code),
+			INDEX idx_playback_user_active (user_id, ended_at, last_seen_at),
+			INDEX idx_playback_started (started_at)
+		)
+	`)
+
+	// Affiliate submission materials (up to 3 videos and 3 files each)
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS affiliate_submission_videos (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			submission_id BIGINT NOT NULL,
+			title VARCHAR(255),
+			url VARCHAR(500) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS affiliate_submission_files (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			submission_id BIGINT NOT NULL,
+			title VARCHAR(255),
+			url VARCHAR(500) NOT NULL,
+			original_name VARCHAR(255),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+
+	// Storage garbage collection runs
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS storage_gc_runs (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			mode VARCHAR(20) NOT NULL,
+			scanned INT NOT NULL DEFAULT 0,
+			orphans INT NOT NULL DEFAULT 0,
+			orphan_bytes BIGINT NOT NULL DEFAULT 0,
+			quarantined INT NOT NULL DEFAULT 0,
+			deleted INT NOT NULL DEFAULT 0,
+			purged INT NOT NULL DEFAULT 0,
+			failed INT NOT NULL DEFAULT 0,
+			aborted_reason TEXT,
+			started_at DATETIME NOT NULL,
+			finished_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+
+	// Storage plans, per-object byte accounting and streamed bandwidth
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS storage_plans (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			code VARCHAR(30) NOT NULL UNIQUE,
+			name VARCHAR(100) NOT NULL,
+			storage_quota_bytes BIGINT NOT NULL,
+			bandwidth_quota_bytes BIGINT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS storage_objects (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			storage VARCHAR(20) NOT NULL,
+			object_key VARCHAR(512) NOT NULL,
+			organization_id BIGINT DEFAULT NULL,
+			size_bytes BIGINT NOT NULL DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE KEY uniq_storage_object (storage, object_key),
+			INDEX idx_storage_objects_org (organization_id)
+		)
+	`)
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS bandwidth_usage (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			organization_id BIGINT NOT NULL,
+			session_id BIGINT NOT NULL DEFAULT 0,
+			usage_date DATE NOT NULL,
+			bytes_sent BIGINT NOT NULL DEFAULT 0,
+			requests INT NOT NULL DEFAULT 0,
+			UNIQUE KEY uniq_bandwidth_usage (organization_id, session_id, usage_date)
+		)
+	`)
 }
 
 // CleanupTestDB clears all data from tables for fresh tests
 func CleanupTestDB(db *sqlx.DB) {
 	tables := []string{
+		"bandwidth_usage",
+		"storage_objects",
+		"storage_plans",
+		"storage_gc_runs",
+		"affiliate_submission_files",
+		"affiliate_submission_videos",
+		"playback_sessions",
+		"video_watch_progress",
+		"user_certificates",
+		"quiz_attempts",
+		"quiz_questions",
+		"session_quizzes",
+		"event_certificates",
+		"image_variants",
+		"image_assets",
+		"resumable_uploads",
+		"exchange_rates",
+		"guest_order_items",
+		"guest_orders",
+		"wishlist_alerts",
+		"wishlists",
+		"subscription_payments",
+		"subscriptions",
+		"subscription_plans",
+		"license_seats",
+		"license_pools",
+		"gift_codes",
+		"package_revenue_allocations",
+		"event_packages",
+		"entitlements",
+		"payment_reconciliation_logs",
+		"invoices",
+		"invoice_sequences",
+		"coupon_redemptions",
+		"coupons",
+		"platform_settings",
 		"password_reset_tokens",
 		"featured_events",
 		"ad_banners",
@@ -0,0 +1,157 @@
+package test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"BACKEND/config"
+	"BACKEND/controllers"
+	"BACKEND/entitlements"
+	"BACKEND/storage"
+	"BACKEND/test/testutils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ================================
+// PROTECTED MEDIA TESTS
+// ================================
+
+func seedMediaSession() {
+	config.DB.MustExec(`INSERT INTO users (id, name, email, password_hash) VALUES (1, 'Org User', 'org@test.com', 'hash'), (2, 'Buyer', 'buyer@test.com', 'hash')`)
+	config.DB.MustExec(`INSERT INTO organizations (id, user_id, owner_user_id, name) VALUES (1, 1, 1, 'Test Org')`)
+	config.DB.MustExec(`INSERT INTO events (id, organization_id, title, publish_status) VALUES (1, 1, 'Event 1', 'PUBLISHED')`)
+	config.DB.MustExec(`INSERT INTO sessions (id, event_id, title, price, publish_status) VALUES (1, 1, 'Session 1', 100000, 'PUBLISHED')`)
+}
+
+func TestGetSignedVideoURL_RequiresEntitlement(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedMediaSession()
+	db.MustExec(`INSERT INTO session_videos (id, session_id, title, video_url) VALUES (1, 1, 'Video 1', ?)`, config.MediaStorage.URL("videos/session_1_1.mp4"))
+
+	params := gin.Params{{Key: "filename", Value: "session_1_1.mp4"}}
+	c, w := testutils.CreateTestContextWithUserParamsAndBody(2, params, nil)
+	controllers.GetSignedVideoURL(c)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Expected status %d without a purchase, got %d", http.StatusForbidden, w.Code)
+	}
+
+	if err := entitlements.GrantAccess(db, entitlements.Grant{UserID: 2, SessionID: 1, Source: entitlements.SourceAdmin}); err != nil {
+		t.Fatalf("GrantAccess: %v", err)
+	}
+	c, w = testutils.CreateTestContextWithUserParamsAndBody(2, params, nil)
+	controllers.GetSignedVideoURL(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	url, _ := testutils.GetJSONResponse(w)["url"].(string)
+	if !strings.HasPrefix(url, "/api/user/sessions/video/session_1_1.mp4?token=") {
+		t.Errorf("Expected a signed stream URL, got %q", url)
+	}
+
+	// The organizer can preview their own session
+	c, w = testutils.CreateTestContextWithUserParamsAndBody(1, params, nil)
+	controllers.GetSignedVideoURL(c)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected the owner to get a URL, got %d", w.Code)
+	}
+}
+
+func TestMigrateMediaToPrivate_MovesPublicObjects(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedMediaSession()
+
+	ctx := context.Background()
+	config.Storage.Put(ctx, "videos/old.mp4", strings.NewReader("video"), 5, "video/mp4")
+	config.Storage.Put(ctx, "files/old.pdf", strings.NewReader("modul"), 5, "application/pdf")
+	db.MustExec(`INSERT INTO session_videos (id, session_id, title, video_url) VALUES (1, 1, 'Video 1', ?)`, config.Storage.URL("videos/old.mp4"))
+	db.MustExec(`INSERT INTO session_files (id, session_id, title, file_url) VALUES (1, 1, 'File 1', ?), (2, 1, 'Hilang', 'https://example.com/unknown.pdf')`, config.Storage.URL("files/old.pdf"))
+
+	// Dry run only reports
+	c, w := testutils.CreateTestContextWithBody(map[string]interface{}{})
+	controllers.MigrateMediaToPrivate(c)
+	report := testutils.GetJSONResponse(w)["report"].(map[string]interface{})
+	if report["dry_run"] != true || report["migrated"].(float64) != 2 || report["failed"].(float64) != 1 {
+		t.Fatalf("Unexpected dry run report: %v", report)
+	}
+	var videoURL string
+	db.Get(&videoURL, "SELECT video_url FROM session_videos WHERE id = 1")
+	if videoURL != config.Storage.URL("videos/old.mp4") {
+		t.Fatalf("Dry run must not rewrite URLs, got %s", videoURL)
+	}
+
+	c, w = testutils.CreateTestContextWithBody(map[string]interface{}{"dry_run": false})
+	controllers.MigrateMediaToPrivate(c)
+	report = testutils.GetJSONResponse(w)["report"].(map[string]interface{})
+	if report["migrated"].(float64) != 2 || report["sources_deleted"].(float64) != 2 {
+		t.Fatalf("Unexpected report: %v", report)
+	}
+
+	db.Get(&videoURL, "SELECT video_url FROM session_videos WHERE id = 1")
+	if videoURL != config.MediaStorage.URL("videos/old.mp4") {
+		t.Errorf("Expected the URL to point at private storage, got %s", videoURL)
+	}
+	rc, _, err := config.MediaStorage.Get(ctx, "videos/old.mp4", nil)
+	if err != nil {
+		t.Fatalf("Expected the video in private storage: %v", err)
+	}
+	body, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(body) != "video" {
+		t.Errorf("Unexpected private copy %q", body)
+	}
+	if _, err := config.Storage.Stat(ctx, "videos/old.mp4"); err != storage.ErrNotFound {
+		t.Errorf("Expected the public copy to be deleted, got %v", err)
+	}
+
+	// A second run has nothing left to move
+	c, w = testutils.CreateTestContextWithBody(map[string]interface{}{"dry_run": false})
+	controllers.MigrateMediaToPrivate(c)
+	report = testutils.GetJSONResponse(w)["report"].(map[string]interface{})
+	if report["migrated"].(float64) != 0 || report["already_private"].(float64) != 2 {
+		t.Errorf("Expected an idempotent second run, got %v", report)
+	}
+}
+
+func TestMigrateMediaToPrivate_KeepsSourceOfFailedRow(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedMediaSession()
+
+	// Rewriting the file row fails, the video row sharing its URL succeeds
+	db.MustExec(`DROP TRIGGER IF EXISTS fail_session_file_update`)
+	db.MustExec(`
+		CREATE TRIGGER fail_session_file_update BEFORE UPDATE ON session_files FOR EACH ROW
+		BEGIN
+			SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'update rejected';
+		END
+	`)
+	defer db.MustExec(`DROP TRIGGER IF EXISTS fail_session_file_update`)
+
+	ctx := context.Background()
+	config.Storage.Put(ctx, "videos/shared.mp4", strings.NewReader("video"), 5, "video/mp4")
+	sharedURL := config.Storage.URL("videos/shared.mp4")
+	db.MustExec(`INSERT INTO session_videos (id, session_id, title, video_url) VALUES (1, 1, 'Video 1', ?)`, sharedURL)
+	db.MustExec(`INSERT INTO session_files (id, session_id, title, file_url) VALUES (1, 1, 'File 1', ?)`, sharedURL)
+
+	c, w := testutils.CreateTestContextWithBody(map[string]interface{}{"dry_run": false})
+	controllers.MigrateMediaToPrivate(c)
+	report := testutils.GetJSONResponse(w)["report"].(map[string]interface{})
+	if report["migrated"].(float64) != 1 || report["failed"].(float64) != 1 || report["sources_deleted"].(float64) != 0 {
+		t.Fatalf("Unexpected report: %v", report)
+	}
+
+	var fileURL string
+	db.Get(&fileURL, "SELECT file_url FROM session_files WHERE id = 1")
+	if fileURL != sharedURL {
+		t.Fatalf("Expected the failed row to keep its URL, got %s", fileURL)
+	}
+	if _, err := config.Storage.Stat(ctx, "videos/shared.mp4"); err != nil {
+		t.Errorf("Expected the public copy to stay for the failed row, got %v", err)
+	}
+}
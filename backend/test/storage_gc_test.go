@@ -0,0 +1,185 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"BACKEND/config"
+	"BACKEND/controllers"
+	"BACKEND/storage"
+	"BACKEND/test/testutils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ================================
+// STORAGE GARBAGE COLLECTION TESTS
+// ================================
+
+// putAgedObject stores an object on local test storage and backdates it
+func putAgedObject(t *testing.T, s storage.Storage, key string, age time.Duration) {
+	t.Helper()
+	if err := s.Put(context.Background(), key, strings.NewReader("data"), 4, ""); err != nil {
+		t.Fatalf("Put %s: %v", key, err)
+	}
+	modTime := time.Now().Add(-age)
+	os.Chtimes(filepath.Join(s.(*storage.Local).Root, filepath.FromSlash(key)), modTime, modTime)
+}
+
+func objectExists(s storage.Storage, key string) bool {
+	_, err := s.Stat(context.Background(), key)
+	return err == nil
+}
+
+// seedStorageGC stores referenced objects, old orphans and one fresh orphan
+func seedStorageGC(t *testing.T) {
+	t.Helper()
+	seedMediaSession()
+	old := 48 * time.Hour
+
+	putAgedObject(t, config.Storage, "events/used.png", old)
+	putAgedObject(t, config.Storage, "events/orphan.png", old)
+	putAgedObject(t, config.Storage, "events/fresh.png", time.Minute)
+	putAgedObject(t, config.MediaStorage, "videos/session_1_1.mp4", old)
+	putAgedObject(t, config.MediaStorage, "hls/1/720p/seg0.ts", old)
+	putAgedObject(t, config.MediaStorage, "videos/deleted.mp4", old)
+
+	config.DB.MustExec(`UPDATE events SET thumbnail_url = ? WHERE id = 1`, config.Storage.URL("events/used.png"))
+	config.DB.MustExec(`INSERT INTO session_videos (id, session_id, title, video_url, hls_prefix) VALUES (1, 1, 'Video 1', ?, 'hls/1')`,
+		config.MediaStorage.URL("videos/session_1_1.mp4"))
+}
+
+func runStorageGC(mode string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	c, w := testutils.CreateTestContextWithUserParamsAndBody(1, nil, map[string]interface{}{"mode": mode})
+	controllers.RunStorageGC(c)
+	report, _ := testutils.GetJSONResponse(w)["report"].(map[string]interface{})
+	return w, report
+}
+
+func TestStorageGC_ReportListsOrphansOnly(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedStorageGC(t)
+
+	w, report := runStorageGC("")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if report["mode"] != "report" || report["orphans"] != float64(2) || report["orphan_bytes"] != float64(8) {
+		t.Fatalf("Expected two orphans in a dry run, got %v", report)
+	}
+	keys := []string{}
+	for _, item := range report["orphan_items"].([]interface{}) {
+		keys = append(keys, item.(map[string]interface{})["key"].(string))
+	}
+	if strings.Join(keys, ",") != "events/orphan.png,videos/deleted.mp4" {
+		t.Errorf("Unexpected orphans %v", keys)
+	}
+	if !objectExists(config.Storage, "events/orphan.png") || !objectExists(config.MediaStorage, "videos/deleted.mp4") {
+		t.Error("Expected a dry run to leave every object in place")
+	}
+
+	var runs int
+	db.Get(&runs, "SELECT COUNT(*) FROM storage_gc_runs WHERE mode = 'report' AND orphans = 2")
+	if runs != 1 {
+		t.Errorf("Expected the run to be logged, got %d", runs)
+	}
+}
+
+func TestStorageGC_QuarantineAndDelete(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedStorageGC(t)
+	putAgedObject(t, config.Storage, "quarantine/20200101/events/ancient.png", 0)
+
+	w, report := runStorageGC("quarantine")
+	if w.Code != http.StatusOK || report["quarantined"] != float64(2) || report["purged"] != float64(1) {
+		t.Fatalf("Expected two quarantined and one purged object, got %d %v", w.Code, report)
+	}
+	quarantined := storage.QuarantineKey("events/orphan.png", time.Now())
+	if objectExists(config.Storage, "events/orphan.png") || !objectExists(config.Storage, quarantined) {
+		t.Errorf("Expected the orphan to move to %s", quarantined)
+	}
+	if objectExists(config.Storage, "quarantine/20200101/events/ancient.png") {
+		t.Error("Expected an expired quarantine copy to be purged")
+	}
+	for _, key := range []string{"events/used.png", "events/fresh.png"} {
+		if !objectExists(config.Storage, key) {
+			t.Errorf("Expected %s to be kept", key)
+		}
+	}
+	for _, key := range []string{"videos/session_1_1.mp4", "hls/1/720p/seg0.ts"} {
+		if !objectExists(config.MediaStorage, key) {
+			t.Errorf("Expected %s to be kept", key)
+		}
+	}
+
+	// Deleting an orphan removes it outright; fresh quarantine copies are not orphans
+	putAgedObject(t, config.Storage, "reports/orphan.jpg", 48*time.Hour)
+	_, report = runStorageGC("delete")
+	if report["deleted"] != float64(1) || objectExists(config.Storage, "reports/orphan.jpg") || !objectExists(config.Storage, quarantined) {
+		t.Errorf("Expected only the new orphan to be deleted, got %v", report)
+	}
+
+	if w, _ := runStorageGC("everything"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected an unknown mode to be rejected, got %d", w.Code)
+	}
+}
+
+func TestStorageGC_AbortsWhenReferencesCannotBeRead(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedStorageGC(t)
+	db.MustExec("DROP TABLE reports")
+
+	w, report := runStorageGC("delete")
+	if w.Code != http.StatusInternalServerError || !strings.Contains(report["aborted"].(string), "reports.photo_url") {
+		t.Fatalf("Expected the run to abort, got %d %v", w.Code, report)
+	}
+	if !objectExists(config.Storage, "events/orphan.png") {
+		t.Error("Expected an aborted run to delete nothing")
+	}
+}
+
+func TestUploadEventThumbnail_RemovesReplacedImage(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedMediaSession()
+
+	putAgedObject(t, config.Storage, "events/old.png", 0)
+	putAgedObject(t, config.Storage, "events/shared.png", 0)
+	db.MustExec(`UPDATE events SET thumbnail_url = ? WHERE id = 1`, config.Storage.URL("events/old.png"))
+	db.MustExec(`INSERT INTO events (id, organization_id, title, publish_status, thumbnail_url) VALUES (2, 1, 'Event 2', 'PUBLISHED', ?)`,
+		config.Storage.URL("events/shared.png"))
+	db.MustExec(`INSERT INTO events (id, organization_id, title, publish_status, thumbnail_url) VALUES (3, 1, 'Event 3', 'PUBLISHED', ?)`,
+		config.Storage.URL("events/shared.png"))
+
+	upload := func(eventID string) {
+		var buf bytes.Buffer
+		jpeg.Encode(&buf, testImage(64, 64), nil)
+		c, w := testutils.CreateTestContextWithUserParamsAndFile(1, gin.Params{{Key: "eventID", Value: eventID}}, "thumbnail", "poster.jpg", buf.Bytes())
+		controllers.UploadEventThumbnail(c)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+	}
+
+	upload("1")
+	if objectExists(config.Storage, "events/old.png") {
+		t.Error("Expected the replaced thumbnail to be deleted")
+	}
+
+	// Another event still shows the old image
+	upload("2")
+	if !objectExists(config.Storage, "events/shared.png") {
+		t.Error("Expected a thumbnail still used elsewhere to be kept")
+	}
+}
@@ -0,0 +1,218 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"BACKEND/config"
+	"BACKEND/controllers"
+	"BACKEND/test/testutils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ================================
+// STORAGE & BANDWIDTH USAGE TESTS
+// ================================
+
+const testPDF = "%PDF-1.4\n%%EOF\n"
+
+func uploadSessionPDF() *httptest.ResponseRecorder {
+	c, w := testutils.CreateTestContextWithUserParamsAndFile(1, gin.Params{{Key: "sessionID", Value: "1"}}, "file", "modul.pdf", []byte(testPDF))
+	controllers.UploadSessionFile(c)
+	return w
+}
+
+func TestUploadSessionFile_RecordsUsage(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedMediaSession()
+
+	if w := uploadSessionPDF(); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var size int64
+	db.Get(&size, "SELECT size_bytes FROM session_files WHERE session_id = 1")
+	if size != int64(len(testPDF)) {
+		t.Errorf("Expected size_bytes %d, got %d", len(testPDF), size)
+	}
+
+	var object struct {
+		Storage        string `db:"storage"`
+		OrganizationID int64  `db:"organization_id"`
+		Size           int64  `db:"size_bytes"`
+	}
+	if err := db.Get(&object, "SELECT storage, organization_id, size_bytes FROM storage_objects"); err != nil {
+		t.Fatalf("Expected the upload to be recorded: %v", err)
+	}
+	if object.Storage != "private" || object.OrganizationID != 1 || object.Size != int64(len(testPDF)) {
+		t.Errorf("Unexpected storage object %+v", object)
+	}
+}
+
+func TestUploadSessionFile_EnforcesPlanQuota(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedMediaSession()
+
+	db.MustExec(`INSERT INTO storage_plans (id, code, name, storage_quota_bytes, bandwidth_quota_bytes) VALUES (1, 'TINY', 'Tiny', 20, 1000)`)
+	db.MustExec(`UPDATE organizations SET storage_plan_id = 1 WHERE id = 1`)
+
+	if w := uploadSessionPDF(); w.Code != http.StatusOK {
+		t.Fatalf("Expected the first file to fit, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := uploadSessionPDF(); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Expected the plan quota to be exceeded, got %d: %s", w.Code, w.Body.String())
+	}
+	var count int
+	db.Get(&count, "SELECT COUNT(*) FROM session_files")
+	if count != 1 {
+		t.Errorf("Expected the refused file not to be saved, got %d rows", count)
+	}
+
+	// An organization's own quota overrides its plan (0 = unlimited)
+	db.MustExec(`UPDATE organizations SET storage_quota_bytes = 0 WHERE id = 1`)
+	if w := uploadSessionPDF(); w.Code != http.StatusOK {
+		t.Errorf("Expected an unlimited override to accept the file, got %d", w.Code)
+	}
+}
+
+func TestStreamSessionVideo_RecordsBandwidth(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedPlaybackVideo(t, db)
+
+	resp := testutils.GetJSONResponse(requestPlayback(2, "10.0.0.1", ""))
+	for i := 0; i < 2; i++ {
+		if w := streamVideo(t, resp["url"].(string), "10.0.0.1"); w.Code != http.StatusOK {
+			t.Fatalf("Expected the video to stream, got %d", w.Code)
+		}
+	}
+
+	var usage struct {
+		Bytes    int64 `db:"bytes_sent"`
+		Requests int   `db:"requests"`
+	}
+	if err := db.Get(&usage, "SELECT bytes_sent, requests FROM bandwidth_usage WHERE organization_id = 1 AND session_id = 1"); err != nil {
+		t.Fatalf("Expected bandwidth to be recorded: %v", err)
+	}
+	if usage.Bytes != 22 || usage.Requests != 2 {
+		t.Errorf("Expected 22 bytes over 2 requests, got %+v", usage)
+	}
+}
+
+func TestGetSignedVideoURL_EnforcesBandwidthQuota(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedPlaybackVideo(t, db)
+
+	db.MustExec(`UPDATE organizations SET bandwidth_quota_bytes = 500 WHERE id = 1`)
+	db.MustExec(`INSERT INTO bandwidth_usage (organization_id, session_id, usage_date, bytes_sent, requests) VALUES (1, 1, CURDATE(), 400, 2)`)
+	if w := requestPlayback(2, "10.0.0.1", ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected playback within the quota, got %d: %s", w.Code, w.Body.String())
+	}
+
+	db.MustExec(`UPDATE bandwidth_usage SET bytes_sent = 600 WHERE organization_id = 1`)
+	w := requestPlayback(2, "10.0.0.1", "")
+	if w.Code != http.StatusForbidden || testutils.GetJSONResponse(w)["code"] != "bandwidth_quota_exceeded" {
+		t.Errorf("Expected the bandwidth quota to block new playbacks, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestOrganizationStorageUsage_FallsBackBeforeBackfill(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedMediaSession()
+
+	// Rows from before storage accounting, nothing in storage_objects yet
+	db.MustExec(`INSERT INTO session_videos (id, session_id, title, video_url, size_bytes) VALUES (1, 1, 'Video 1', 'private/videos/a.mp4', 700)`)
+	db.MustExec(`INSERT INTO session_files (session_id, file_url, size_bytes) VALUES (1, 'private/files/a.pdf', 50)`)
+
+	c, w := testutils.CreateTestContextWithUserID(1)
+	controllers.GetOrganizationStorageUsage(c)
+	stored, _ := testutils.GetJSONResponse(w)["storage"].(map[string]interface{})
+	if stored["used_bytes"] != float64(750) {
+		t.Errorf("Expected the recorded file sizes before the backfill, got %v", stored)
+	}
+}
+
+func TestGetOrganizationStorageUsage(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedMediaSession()
+
+	db.MustExec(`INSERT INTO storage_plans (id, code, name, storage_quota_bytes, bandwidth_quota_bytes) VALUES (1, 'PRO', 'Pro', 1000, 500)`)
+	db.MustExec(`UPDATE organizations SET storage_plan_id = 1 WHERE id = 1`)
+	db.MustExec(`INSERT INTO storage_objects (storage, object_key, organization_id, size_bytes) VALUES
+		('private', 'videos/a.mp4', 1, 200), ('private', 'hls/1/720p/seg0.ts', 1, 50),
+		('public', 'events/a.png', 1, 50), ('public', 'ads/b.png', NULL, 999)`)
+	db.MustExec(`INSERT INTO bandwidth_usage (organization_id, session_id, usage_date, bytes_sent, requests) VALUES
+		(1, 1, CURDATE(), 600, 3), (1, 1, DATE_SUB(CURDATE(), INTERVAL 3 MONTH), 900, 1)`)
+
+	c, w := testutils.CreateTestContextWithUserID(1)
+	controllers.GetOrganizationStorageUsage(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	resp := testutils.GetJSONResponse(w)
+
+	if plan, _ := resp["plan"].(map[string]interface{}); plan["code"] != "PRO" {
+		t.Errorf("Expected the PRO plan, got %v", resp["plan"])
+	}
+	stored := resp["storage"].(map[string]interface{})
+	if stored["used_bytes"] != float64(300) || stored["percent"] != float64(30) || stored["over_quota"] != false {
+		t.Errorf("Unexpected storage usage %v", stored)
+	}
+	if categories, _ := stored["by_category"].([]interface{}); len(categories) != 3 {
+		t.Errorf("Expected videos, hls and events categories, got %v", stored["by_category"])
+	}
+
+	bandwidth := resp["bandwidth"].(map[string]interface{})
+	if bandwidth["month"] != time.Now().Format("2006-01") || bandwidth["used_bytes"] != float64(600) || bandwidth["over_quota"] != true {
+		t.Errorf("Expected this month's 600 bytes over a 500 byte quota, got %v", bandwidth)
+	}
+	if sessions, _ := bandwidth["top_sessions"].([]interface{}); len(sessions) != 1 {
+		t.Errorf("Expected one session in the breakdown, got %v", bandwidth["top_sessions"])
+	}
+}
+
+func TestRebuildStorageUsage_AttributesObjects(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedMediaSession()
+
+	putAgedObject(t, config.Storage, "events/thumb.png", 0)
+	putAgedObject(t, config.Storage, "ads/banner.png", 0)
+	putAgedObject(t, config.MediaStorage, "videos/session_1_1.mp4", 0)
+	putAgedObject(t, config.MediaStorage, "hls/1/720p/seg0.ts", 0)
+	putAgedObject(t, config.Storage, "quarantine/20200101/events/old.png", 0)
+
+	db.MustExec(`UPDATE events SET thumbnail_url = ? WHERE id = 1`, config.Storage.URL("events/thumb.png"))
+	db.MustExec(`INSERT INTO session_videos (id, session_id, title, video_url, hls_prefix) VALUES (1, 1, 'Video 1', ?, 'hls/1')`,
+		config.MediaStorage.URL("videos/session_1_1.mp4"))
+	// A stale row is replaced by the rebuild
+	db.MustExec(`INSERT INTO storage_objects (storage, object_key, organization_id, size_bytes) VALUES ('public', 'events/gone.png', 1, 1000)`)
+
+	c, w := testutils.CreateTestContextWithUserParamsAndBody(1, nil, nil)
+	controllers.RebuildStorageUsage(c)
+	report, _ := testutils.GetJSONResponse(w)["report"].(map[string]interface{})
+	if w.Code != http.StatusOK || report["objects"] != float64(4) || report["attributed"] != float64(3) || report["sizes_filled"] != float64(1) {
+		t.Fatalf("Unexpected rebuild %d %v", w.Code, report)
+	}
+
+	var orgBytes, platformBytes int64
+	db.Get(&orgBytes, "SELECT COALESCE(SUM(size_bytes), 0) FROM storage_objects WHERE organization_id = 1")
+	db.Get(&platformBytes, "SELECT COALESCE(SUM(size_bytes), 0) FROM storage_objects WHERE organization_id IS NULL")
+	if orgBytes != 12 || platformBytes != 4 {
+		t.Errorf("Expected 12 bytes for the organization and 4 for the platform, got %d and %d", orgBytes, platformBytes)
+	}
+
+	var size int64
+	db.Get(&size, "SELECT size_bytes FROM session_videos WHERE id = 1")
+	if size != 4 {
+		t.Errorf("Expected the video size to be filled in, got %d", size)
+	}
+}
@@ -0,0 +1,125 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"BACKEND/config"
+	"BACKEND/controllers"
+	"BACKEND/entitlements"
+	"BACKEND/test/testutils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ================================
+// SUBSCRIPTION TESTS
+// ================================
+
+// seedSubscriptionOrder creates a monthly plan of org 1 and an unpaid first period for user 2
+func seedSubscriptionOrder() {
+	seedEntitlementSession()
+	config.DB.MustExec(`INSERT INTO users (id, name, email, password_hash) VALUES (2, 'Member', 'member@test.com', 'hash')`)
+	config.DB.MustExec(`INSERT INTO events (id, organization_id, title, publish_status) VALUES (2, 1, 'Draft Event', 'DRAFT')`)
+	config.DB.MustExec(`INSERT INTO sessions (id, event_id, title, price, publish_status) VALUES (2, 2, 'Draft Session', 100000, 'DRAFT')`)
+	config.DB.MustExec(`
+		INSERT INTO subscription_plans (id, organization_id, name, billing_interval, price, grace_days)
+		VALUES (1, 1, 'Member Bulanan', 'MONTHLY', 99000, 3)
+	`)
+	config.DB.MustExec(`INSERT INTO subscriptions (id, plan_id, user_id, organization_id, status) VALUES (1, 1, 2, 1, 'PENDING')`)
+	config.DB.MustExec(`INSERT INTO subscription_payments (subscription_id, order_id, amount) VALUES (1, 'SUB-1-1-2', 99000)`)
+}
+
+func TestSubscriptionPayment_UnlocksPublishedCatalog(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedSubscriptionOrder()
+
+	if entitlements.HasAccess(2, 1) {
+		t.Fatal("Expected no access before the first period is paid")
+	}
+
+	if err := controllers.ProcessSubscriptionPayment("SUB-1-1-2", "99000.00"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !entitlements.HasAccess(2, 1) {
+		t.Error("Expected access to a published event of the organization")
+	}
+	if entitlements.HasAccess(2, 2) {
+		t.Error("Expected no access to an unpublished event")
+	}
+
+	// A draft session of a published event stays locked
+	db.MustExec(`UPDATE events SET publish_status = 'PUBLISHED' WHERE id = 2`)
+	if entitlements.HasAccess(2, 2) {
+		t.Error("Expected no access to an unpublished session of a published event")
+	}
+
+	// Events published later are covered by the same subscription
+	db.MustExec(`UPDATE sessions SET publish_status = 'PUBLISHED' WHERE id = 2`)
+	if !entitlements.HasAccess(2, 2) {
+		t.Error("Expected access once the event and session are published")
+	}
+
+	var status string
+	db.Get(&status, `SELECT status FROM subscriptions WHERE id = 1`)
+	if status != "ACTIVE" {
+		t.Errorf("Expected subscription ACTIVE, got %s", status)
+	}
+}
+
+func TestSubscriptionPayment_IsIdempotent(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedSubscriptionOrder()
+
+	controllers.ProcessSubscriptionPayment("SUB-1-1-2", "99000.00")
+	var firstEnd time.Time
+	db.Get(&firstEnd, `SELECT current_period_end FROM subscriptions WHERE id = 1`)
+
+	// A repeated notification must not add another period
+	if err := controllers.ProcessSubscriptionPayment("SUB-1-1-2", "99000.00"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var secondEnd time.Time
+	db.Get(&secondEnd, `SELECT current_period_end FROM subscriptions WHERE id = 1`)
+	if !firstEnd.Equal(secondEnd) {
+		t.Errorf("Expected period end unchanged, got %v then %v", firstEnd, secondEnd)
+	}
+
+	var entitlementCount int
+	db.Get(&entitlementCount, `SELECT COUNT(*) FROM entitlements WHERE user_id = 2 AND source = 'SUBSCRIPTION'`)
+	if entitlementCount != 1 {
+		t.Errorf("Expected 1 subscription entitlement, got %d", entitlementCount)
+	}
+}
+
+func TestCancelSubscription_KeepsAccessUntilPeriodEnd(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedSubscriptionOrder()
+	controllers.ProcessSubscriptionPayment("SUB-1-1-2", "99000.00")
+
+	c, w := testutils.CreateTestContextWithUserParamsAndBody(2, gin.Params{{Key: "id", Value: "1"}}, nil)
+	controllers.CancelSubscription(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	if !entitlements.HasAccess(2, 1) {
+		t.Error("Expected access to remain until the paid period ends")
+	}
+
+	// Cancelled subscriptions get no grace period
+	var expiresMatchesPeriod bool
+	db.Get(&expiresMatchesPeriod, `
+		SELECT e.expires_at = s.current_period_end FROM entitlements e
+		JOIN subscriptions s ON s.id = 1
+		WHERE e.user_id = 2 AND e.source = 'SUBSCRIPTION'
+	`)
+	if !expiresMatchesPeriod {
+		t.Error("Expected access to end exactly at the period end")
+	}
+}
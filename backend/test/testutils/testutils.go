@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -117,6 +118,25 @@ func CreateTestContextWithUserParamsAndBody(userID int64, params gin.Params, bod
 	return c, w
 }
 
+// CreateTestContextWithUserParamsAndFile creates context with user, params and a multipart file upload
+func CreateTestContextWithUserParamsAndFile(userID int64, params gin.Params, field, filename string, content []byte) (*gin.Context, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("user_id", userID)
+	c.Params = params
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile(field, filename)
+	part.Write(content)
+	mw.Close()
+
+	c.Request, _ = http.NewRequest(http.MethodPost, "/", &body)
+	c.Request.Header.Set("Content-Type", mw.FormDataContentType())
+
+	return c, w
+}
+
 // CreateTestContextWithMethod creates context with specific HTTP method
 func CreateTestContextWithMethod(method string, body interface{}) (*gin.Context, *httptest.ResponseRecorder) {
 	w := httptest.NewRecorder()
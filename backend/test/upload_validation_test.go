@@ -0,0 +1,85 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/jpeg"
+	"io"
+	"net/http"
+	"testing"
+
+	"BACKEND/config"
+	"BACKEND/controllers"
+	"BACKEND/test/testutils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ================================
+// UPLOAD VALIDATION TESTS
+// ================================
+
+func TestUploadSessionFile_SniffsContent(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedMediaSession()
+
+	params := gin.Params{{Key: "sessionID", Value: "1"}}
+
+	// An executable renamed to .pdf is refused before it reaches storage
+	c, w := testutils.CreateTestContextWithUserParamsAndFile(1, params, "file", "modul.pdf", []byte("MZ\x90\x00\x03\x00\x00\x00 not a pdf"))
+	controllers.UploadSessionFile(c)
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusUnsupportedMediaType, w.Code, w.Body.String())
+	}
+
+	var count int
+	db.Get(&count, "SELECT COUNT(*) FROM session_files")
+	if count != 0 {
+		t.Errorf("Expected no file row, got %d", count)
+	}
+
+	c, w = testutils.CreateTestContextWithUserParamsAndFile(1, params, "file", "modul.pdf", []byte("%PDF-1.4\n%%EOF\n"))
+	controllers.UploadSessionFile(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected a real PDF to be accepted, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestUploadProfileImage_StripsMetadata(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedMediaSession()
+
+	var img bytes.Buffer
+	jpeg.Encode(&img, image.NewRGBA(image.Rect(0, 0, 8, 8)), nil)
+	comment := []byte{0xFF, 0xFE, 0x00, 0x12}
+	comment = append(comment, []byte("GPS -6.2,106.8 !")...)
+	withComment := append(append([]byte{0xFF, 0xD8}, comment...), img.Bytes()[2:]...)
+
+	c, w := testutils.CreateTestContextWithUserParamsAndFile(2, nil, "profile_img", "me.jpg", withComment)
+	controllers.UploadProfileImage(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var profileURL string
+	db.Get(&profileURL, "SELECT profile_img FROM users WHERE id = 2")
+	key, ok := config.Storage.KeyFromURL(profileURL)
+	if !ok {
+		t.Fatalf("Expected the image in public storage, got %s", profileURL)
+	}
+	rc, info, err := config.Storage.Get(context.Background(), key, nil)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	stored, _ := io.ReadAll(rc)
+	rc.Close()
+	if bytes.Contains(stored, []byte("106.8")) {
+		t.Error("Expected the metadata to be stripped before storing")
+	}
+	if info.ContentType != "image/jpeg" {
+		t.Errorf("Expected the sniffed content type, got %s", info.ContentType)
+	}
+}
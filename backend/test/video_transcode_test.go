@@ -0,0 +1,179 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"BACKEND/config"
+	"BACKEND/controllers"
+	"BACKEND/media"
+	"BACKEND/test/testutils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeTranscoder writes a two-segment playlist per rendition instead of running ffmpeg
+type fakeTranscoder struct {
+	probe    media.Probe
+	probeErr error
+}
+
+func (f fakeTranscoder) Probe(ctx context.Context, input string) (media.Probe, error) {
+	return f.probe, f.probeErr
+}
+
+func (f fakeTranscoder) Transcode(ctx context.Context, input, outDir string, renditions []media.Rendition) error {
+	for _, r := range renditions {
+		dir := filepath.Join(outDir, r.Name)
+		os.MkdirAll(dir, os.ModePerm)
+		os.WriteFile(filepath.Join(dir, "index.m3u8"), []byte("#EXTM3U\n#EXTINF:6.0,\nseg_00000.ts\n#EXTINF:6.0,\nseg_00001.ts\n#EXT-X-ENDLIST\n"), 0o644)
+		os.WriteFile(filepath.Join(dir, "seg_00000.ts"), []byte(r.Name+"-0"), 0o644)
+		os.WriteFile(filepath.Join(dir, "seg_00001.ts"), []byte(r.Name+"-1"), 0o644)
+	}
+	return nil
+}
+
+func (f fakeTranscoder) Thumbnail(ctx context.Context, input, output string, at float64) error {
+	return os.WriteFile(output, []byte("jpeg"), 0o644)
+}
+
+// getHLS requests a signed HLS URL as returned by the API
+func getHLS(t *testing.T, signed string) *httptest.ResponseRecorder {
+	t.Helper()
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("Bad URL %q", signed)
+	}
+	rest := strings.TrimPrefix(u.Path, "/api/user/sessions/hls/")
+	videoID, rel, _ := strings.Cut(rest, "/")
+
+	c, w := testutils.CreateTestContext()
+	c.Request = httptest.NewRequest(http.MethodGet, signed, nil)
+	c.Params = gin.Params{{Key: "videoID", Value: videoID}, {Key: "path", Value: "/" + rel}}
+	controllers.StreamSessionHLS(c)
+	return w
+}
+
+func TestTranscodeQueue_ProducesSignedHLS(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedMediaSession()
+
+	config.Transcoder = fakeTranscoder{probe: media.Probe{Duration: 12.4, Width: 1280, Height: 720}}
+	defer func() { config.Transcoder = nil }()
+
+	config.MediaStorage.Put(context.Background(), "videos/session_1_1.mp4", strings.NewReader("raw"), 3, "video/mp4")
+	db.MustExec(`INSERT INTO session_videos (id, session_id, title, video_url) VALUES (1, 1, 'Video 1', ?)`, config.MediaStorage.URL("videos/session_1_1.mp4"))
+
+	controllers.RunVideoTranscodeQueue()
+
+	var video struct {
+		HLSStatus       string  `db:"hls_status"`
+		HLSRenditions   *string `db:"hls_renditions"`
+		DurationSeconds *int    `db:"duration_seconds"`
+		PosterURL       *string `db:"poster_url"`
+	}
+	db.Get(&video, "SELECT hls_status, hls_renditions, duration_seconds, poster_url FROM session_videos WHERE id = 1")
+	if video.HLSStatus != "READY" || video.DurationSeconds == nil || *video.DurationSeconds != 12 {
+		t.Fatalf("Expected a READY 12s video, got %+v", video)
+	}
+	if video.HLSRenditions == nil || *video.HLSRenditions != "360p,480p,720p" {
+		t.Errorf("Expected renditions up to the source height, got %v", video.HLSRenditions)
+	}
+	if video.PosterURL == nil || *video.PosterURL != config.Storage.URL("video_posters/video_1.jpg") {
+		t.Errorf("Expected a poster, got %v", video.PosterURL)
+	}
+
+	// Buyer asks for a playback URL
+	db.MustExec(`INSERT INTO entitlements (user_id, session_id, source) VALUES (2, 1, 'ADMIN')`)
+	c, w := testutils.CreateTestContextWithUserParamsAndBody(2, gin.Params{{Key: "filename", Value: "session_1_1.mp4"}}, nil)
+	controllers.GetSignedVideoURL(c)
+	hlsURL, _ := testutils.GetJSONResponse(w)["hls_url"].(string)
+	if hlsURL == "" {
+		t.Fatalf("Expected an hls_url. Body: %s", w.Body.String())
+	}
+
+	// Master playlist lists signed variant playlists
+	w = getHLS(t, hlsURL)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "RESOLUTION=1280x720") {
+		t.Fatalf("Unexpected master playlist (%d): %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `#EXT-X-SESSION-DATA:DATA-ID="com.webbinar.watermark",VALUE="buyer@test.com · #2 · `) {
+		t.Errorf("Expected the buyer's watermark in the master playlist:\n%s", w.Body.String())
+	}
+	var variantURL string
+	for _, line := range strings.Split(w.Body.String(), "\n") {
+		if strings.HasPrefix(line, "/api/user/sessions/hls/1/720p/index.m3u8?token=") {
+			variantURL = line
+		}
+	}
+	if variantURL == "" {
+		t.Fatalf("Expected a signed 720p playlist URL:\n%s", w.Body.String())
+	}
+
+	// Variant playlist lists signed segments
+	w = getHLS(t, variantURL)
+	var segmentURL string
+	for _, line := range strings.Split(w.Body.String(), "\n") {
+		if strings.HasPrefix(line, "/api/user/sessions/hls/1/720p/seg_00001.ts?token=") {
+			segmentURL = line
+		}
+	}
+	if segmentURL == "" {
+		t.Fatalf("Expected signed segment URLs:\n%s", w.Body.String())
+	}
+
+	w = getHLS(t, segmentURL)
+	if w.Code != http.StatusOK || w.Body.String() != "720p-1" {
+		t.Errorf("Expected the segment, got %d %q", w.Code, w.Body.String())
+	}
+
+	// A signature only covers the file it was minted for
+	w = getHLS(t, strings.Replace(segmentURL, "seg_00001.ts", "seg_00000.ts", 1))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected a forged segment URL to be rejected, got %d", w.Code)
+	}
+
+	// Revoking access stops playlist delivery
+	db.MustExec(`UPDATE entitlements SET revoked_at = NOW() WHERE user_id = 2`)
+	w = getHLS(t, variantURL)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected playlists to be refused after revocation, got %d", w.Code)
+	}
+}
+
+func TestTranscodeQueue_RecordsFailure(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedMediaSession()
+
+	config.Transcoder = fakeTranscoder{probeErr: errors.New("moov atom not found")}
+	defer func() { config.Transcoder = nil }()
+
+	config.MediaStorage.Put(context.Background(), "videos/broken.mp4", strings.NewReader("x"), 1, "video/mp4")
+	db.MustExec(`INSERT INTO session_videos (id, session_id, title, video_url) VALUES (1, 1, 'Rusak', ?)`, config.MediaStorage.URL("videos/broken.mp4"))
+
+	controllers.RunVideoTranscodeQueue()
+
+	var status, msg string
+	db.QueryRow("SELECT hls_status, COALESCE(transcode_error, '') FROM session_videos WHERE id = 1").Scan(&status, &msg)
+	if status != "FAILED" || !strings.Contains(msg, "moov atom not found") {
+		t.Errorf("Expected FAILED with the probe error, got %s %q", status, msg)
+	}
+
+	// The owner can queue it again
+	c, w := testutils.CreateTestContextWithUserParamsAndBody(1, gin.Params{{Key: "sessionID", Value: "1"}, {Key: "mediaID", Value: "1"}}, nil)
+	config.Transcoder = nil // keep the retry from running in the background
+	controllers.RetrySessionVideoTranscode(c)
+	db.Get(&status, "SELECT hls_status FROM session_videos WHERE id = 1")
+	if w.Code != http.StatusOK || status != "PENDING" {
+		t.Errorf("Expected the video to be queued again, got %d %s", w.Code, status)
+	}
+}
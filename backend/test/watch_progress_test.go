@@ -0,0 +1,158 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"BACKEND/controllers"
+	"BACKEND/entitlements"
+	"BACKEND/test/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// ================================
+// WATCH PROGRESS TESTS
+// ================================
+
+// seedWatchSession adds a 100 second video to session 1 and gives user 2 access
+func seedWatchSession(t *testing.T, db *sqlx.DB) {
+	t.Helper()
+	seedMediaSession()
+	db.MustExec(`INSERT INTO session_videos (id, session_id, title, video_url, duration_seconds) VALUES (1, 1, 'Video 1', 'private/videos/a.mp4', 100)`)
+	if err := entitlements.GrantAccess(db, entitlements.Grant{UserID: 2, SessionID: 1, Source: entitlements.SourceAdmin}); err != nil {
+		t.Fatalf("GrantAccess: %v", err)
+	}
+}
+
+func heartbeat(userID int64, position float64) *httptest.ResponseRecorder {
+	c, w := testutils.CreateTestContextWithUserParamsAndBody(userID, gin.Params{{Key: "videoID", Value: "1"}}, map[string]interface{}{"position_seconds": position})
+	controllers.RecordWatchProgress(c)
+	return w
+}
+
+func watchedSeconds(db *sqlx.DB) float64 {
+	var watched float64
+	db.Get(&watched, "SELECT watched_seconds FROM video_watch_progress WHERE user_id = 2 AND video_id = 1")
+	return watched
+}
+
+// backdateHeartbeat pretends the previous heartbeat was sent seconds ago
+func backdateHeartbeat(db *sqlx.DB, seconds int) {
+	db.MustExec(`UPDATE video_watch_progress SET updated_at = DATE_SUB(NOW(), INTERVAL ? SECOND) WHERE user_id = 2 AND video_id = 1`, seconds)
+}
+
+func TestRecordWatchProgress_CreditsOnlyPlayback(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedWatchSession(t, db)
+
+	if w := heartbeat(2, 10); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	backdateHeartbeat(db, 15)
+	heartbeat(2, 25)
+	if watched := watchedSeconds(db); watched != 25 {
+		t.Fatalf("Expected 25 watched seconds, got %v", watched)
+	}
+
+	// Seeking ahead moves the resume position but earns nothing
+	heartbeat(2, 90)
+	if watched := watchedSeconds(db); watched != 25 {
+		t.Errorf("Expected seeking to earn nothing, got %v", watched)
+	}
+
+	c, w := testutils.CreateTestContextWithUserParamsAndBody(2, gin.Params{{Key: "videoID", Value: "1"}}, nil)
+	controllers.GetWatchProgress(c)
+	resp := testutils.GetJSONResponse(w)
+	if resp["resume_seconds"] != float64(90) {
+		t.Errorf("Expected to resume at 90s, got %v", resp["resume_seconds"])
+	}
+	if progress := resp["progress"].(map[string]interface{}); progress["percent"] != float64(25) || progress["completed"] != false {
+		t.Errorf("Unexpected progress %v", progress)
+	}
+
+	// Other users cannot report progress on a session they do not own
+	db.MustExec(`INSERT INTO users (id, name, email, password_hash) VALUES (3, 'Stranger', 'x@test.com', 'hash')`)
+	if w := heartbeat(3, 10); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestWatchRequirement_GatesQuizAndCertificate(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedWatchSession(t, db)
+
+	db.MustExec(`INSERT INTO event_certificates (event_id, is_enabled, min_score_percent, min_watch_percent) VALUES (1, 1, 80, 50)`)
+	db.MustExec(`INSERT INTO session_quizzes (id, session_id, title, is_enabled) VALUES (1, 1, 'Kuis 1', 1)`)
+	db.MustExec(`INSERT INTO quiz_questions (id, quiz_id, question_text, option_a, option_b, correct_option) VALUES (1, 1, '1+1?', '2', '3', 'A')`)
+
+	params := gin.Params{{Key: "sessionID", Value: "1"}}
+	c, w := testutils.CreateTestContextWithUserParamsAndBody(2, params, nil)
+	controllers.GetQuizForUser(c)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Expected the quiz to be locked, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// A quiz passed before the rule applied still does not earn a certificate
+	db.MustExec(`INSERT INTO quiz_attempts (user_id, quiz_id, score_percent, passed) VALUES (2, 1, 100, 1)`)
+	c, w = testutils.CreateTestContextWithUserParamsAndBody(2, gin.Params{{Key: "eventID", Value: "1"}}, nil)
+	controllers.GetUserCertificate(c)
+	if resp := testutils.GetJSONResponse(w); resp["has_certificate"] != false || resp["min_watch_percent"] != float64(50) {
+		t.Fatalf("Expected the certificate to wait for watching, got %v", resp)
+	}
+
+	heartbeat(2, 5)
+	backdateHeartbeat(db, 60)
+	heartbeat(2, 60)
+
+	c, w = testutils.CreateTestContextWithUserParamsAndBody(2, params, nil)
+	controllers.GetSessionWatchProgress(c)
+	if resp := testutils.GetJSONResponse(w); resp["watch_percent"] != float64(60) || resp["quiz_unlocked"] != true {
+		t.Errorf("Expected 60%% watched and the quiz unlocked, got %v", resp)
+	}
+
+	c, w = testutils.CreateTestContextWithUserParamsAndBody(2, params, nil)
+	controllers.GetQuizForUser(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected the quiz to unlock, got %d: %s", w.Code, w.Body.String())
+	}
+
+	c, w = testutils.CreateTestContextWithUserParamsAndBody(2, gin.Params{{Key: "eventID", Value: "1"}}, nil)
+	controllers.GetUserCertificate(c)
+	if resp := testutils.GetJSONResponse(w); resp["has_certificate"] != true {
+		t.Errorf("Expected a certificate, got %v", resp)
+	}
+}
+
+func TestUpdateCertificateSettings_KeepsWatchRuleWhenOmitted(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedMediaSession()
+
+	params := gin.Params{{Key: "eventID", Value: "1"}}
+	c, w := testutils.CreateTestContextWithUserParamsAndBody(1, params, map[string]interface{}{"is_enabled": true, "min_score_percent": 70, "min_watch_percent": 80})
+	controllers.UpdateCertificateSettings(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	// Older clients do not send the field
+	c, _ = testutils.CreateTestContextWithUserParamsAndBody(1, params, map[string]interface{}{"is_enabled": true, "min_score_percent": 90})
+	controllers.UpdateCertificateSettings(c)
+
+	var minWatch int
+	db.Get(&minWatch, "SELECT min_watch_percent FROM event_certificates WHERE event_id = 1")
+	if minWatch != 80 {
+		t.Errorf("Expected the watch rule to stay at 80, got %d", minWatch)
+	}
+
+	c, w = testutils.CreateTestContextWithUserParamsAndBody(1, params, map[string]interface{}{"min_watch_percent": 150})
+	controllers.UpdateCertificateSettings(c)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
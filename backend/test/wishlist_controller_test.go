@@ -0,0 +1,76 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+
+	"BACKEND/config"
+	"BACKEND/controllers"
+	"BACKEND/test/testutils"
+)
+
+// ================================
+// WISHLIST TESTS
+// ================================
+
+func seedWishlistEvent() {
+	seedEntitlementSession()
+	config.DB.MustExec(`INSERT INTO events (id, organization_id, title, publish_status) VALUES (2, 1, 'Coming Soon', 'SCHEDULED')`)
+}
+
+func countNotifications(userID int64, notifType string) int {
+	var count int
+	config.DB.Get(&count, `SELECT COUNT(*) FROM notifications WHERE user_id = ? AND type = ?`, userID, notifType)
+	return count
+}
+
+func TestAddToWishlist_ScheduledEventOnce(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedWishlistEvent()
+
+	for i := 0; i < 2; i++ {
+		c, w := testutils.CreateTestContextWithUserAndBody(1, map[string]int64{"event_id": 2})
+		controllers.AddToWishlist(c)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+	}
+
+	var count int
+	db.Get(&count, `SELECT COUNT(*) FROM wishlists WHERE user_id = 1`)
+	if count != 1 {
+		t.Errorf("Expected 1 wishlist item after adding twice, got %d", count)
+	}
+}
+
+func TestNotifyWishlistPublished_SendsOnce(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedWishlistEvent()
+	db.MustExec(`INSERT INTO wishlists (user_id, event_id) VALUES (1, 2)`)
+
+	db.MustExec(`UPDATE events SET publish_status = 'PUBLISHED' WHERE id = 2`)
+	controllers.NotifyWishlistPublished([]int64{2}, nil)
+	controllers.NotifyWishlistPublished([]int64{2}, nil)
+
+	if got := countNotifications(1, "wishlist_published"); got != 1 {
+		t.Errorf("Expected 1 publish notification, got %d", got)
+	}
+}
+
+func TestNotifyWishlistPriceDrop_OnlyOnDrop(t *testing.T) {
+	db := SetupTestDB()
+	defer TeardownTestDB(db)
+	seedEntitlementSession()
+	db.MustExec(`INSERT INTO users (id, name, email, password_hash) VALUES (2, 'Fan', 'fan@test.com', 'hash')`)
+	db.MustExec(`INSERT INTO wishlists (user_id, event_id, session_id) VALUES (2, 1, 1)`)
+
+	// Price goes up first (no alert), then down (alert)
+	controllers.NotifyWishlistPriceDrop(1, 100000, 150000)
+	controllers.NotifyWishlistPriceDrop(1, 150000, 75000)
+
+	if got := countNotifications(2, "wishlist_price_drop"); got != 1 {
+		t.Errorf("Expected 1 price drop notification, got %d", got)
+	}
+}
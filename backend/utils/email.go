@@ -2,19 +2,30 @@ package utils
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html"
 	"io"
 	"net/http"
 	"os"
+	"strings"
 )
 
 // BrevoEmailRequest represents Brevo API v3 send email request
 type BrevoEmailRequest struct {
-	Sender      BrevoContact   `json:"sender"`
-	To          []BrevoContact `json:"to"`
-	Subject     string         `json:"subject"`
-	HtmlContent string         `json:"htmlContent"`
+	Sender      BrevoContact      `json:"sender"`
+	To          []BrevoContact    `json:"to"`
+	Subject     string            `json:"subject"`
+	HtmlContent string            `json:"htmlContent"`
+	Attachment  []BrevoAttachment `json:"attachment,omitempty"`
+}
+
+// BrevoAttachment is a file attached to an email (content is base64 encoded)
+type BrevoAttachment struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
 }
 
 type BrevoContact struct {
@@ -25,6 +36,18 @@ type BrevoContact struct {
 // SendEmail sends an email using Brevo HTTP API (v3)
 // This bypasses SMTP port restrictions on Railway
 func SendEmail(to, subject, htmlBody string) error {
+	return sendBrevoEmail(to, subject, htmlBody, nil)
+}
+
+// SendEmailWithAttachment sends an email with one file attached (e.g. an invoice PDF)
+func SendEmailWithAttachment(to, subject, htmlBody, fileName string, content []byte) error {
+	return sendBrevoEmail(to, subject, htmlBody, []BrevoAttachment{
+		{Name: fileName, Content: base64.StdEncoding.EncodeToString(content)},
+	})
+}
+
+// sendBrevoEmail posts the email (and optional attachments) to the Brevo API
+func sendBrevoEmail(to, subject, htmlBody string, attachments []BrevoAttachment) error {
 	apiKey := os.Getenv("BREVO_API_KEY")
 
 	// Fallback: use SMTP_PASS as API key if BREVO_API_KEY not set
@@ -53,6 +76,7 @@ func SendEmail(to, subject, htmlBody string) error {
 		},
 		Subject:     subject,
 		HtmlContent: htmlBody,
+		Attachment:  attachments,
 	}
 
 	jsonData, err := json.Marshal(emailReq)
@@ -87,7 +111,7 @@ func SendEmail(to, subject, htmlBody string) error {
 
 	errMsg := fmt.Sprintf("Brevo API error (status %d): %s", resp.StatusCode, string(body))
 	fmt.Printf("❌ %s\n", errMsg)
-	return fmt.Errorf(errMsg)
+	return errors.New(errMsg)
 }
 
 // SendPasswordResetEmail sends a password reset email with verification code
@@ -161,3 +185,227 @@ func SendPasswordResetEmail(to, resetCode, userName string) error {
 
 	return SendEmail(to, subject, htmlBody)
 }
+
+// SendInvoiceEmail sends the invoice PDF for a paid order to the buyer
+func SendInvoiceEmail(to, userName, invoiceNumber, totalPaid string, pdf []byte) error {
+	subject := fmt.Sprintf("🧾 Invoice %s - Webbinar", invoiceNumber)
+
+	htmlBody := fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+</head>
+<body style="margin: 0; padding: 0; font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif; background-color: #f4f7fa;">
+    <table width="100%%" cellpadding="0" cellspacing="0" style="background-color: #f4f7fa; padding: 40px 20px;">
+        <tr>
+            <td align="center">
+                <table width="100%%" cellpadding="0" cellspacing="0" style="max-width: 600px; background-color: #ffffff; border-radius: 16px; box-shadow: 0 4px 6px rgba(0, 0, 0, 0.05); overflow: hidden;">
+                    <!-- Header -->
+                    <tr>
+                        <td style="background: linear-gradient(135deg, #3b82f6 0%%, #1e40af 100%%); padding: 40px 30px; text-align: center;">
+                            <h1 style="color: #ffffff; margin: 0; font-size: 28px; font-weight: 700;">🧾 Pembayaran Berhasil</h1>
+                            <p style="color: rgba(255,255,255,0.9); margin: 10px 0 0 0; font-size: 16px;">Webbinar Learning Platform</p>
+                        </td>
+                    </tr>
+                    
+                    <!-- Content -->
+                    <tr>
+                        <td style="padding: 40px 30px;">
+                            <p style="color: #1e293b; font-size: 18px; margin: 0 0 10px 0;">Halo <strong>%s</strong>,</p>
+                            <p style="color: #64748b; font-size: 16px; line-height: 1.6; margin: 0 0 20px 0;">
+                                Terima kasih, pembayaran Anda telah kami terima. Invoice terlampir pada email ini.
+                            </p>
+                            <table width="100%%" cellpadding="0" cellspacing="0" style="background-color: #f8fafc; border-radius: 12px; padding: 20px;">
+                                <tr>
+                                    <td style="color: #64748b; font-size: 14px; padding: 6px 0;">No. Invoice</td>
+                                    <td style="color: #1e293b; font-size: 14px; font-weight: 700; text-align: right;">%s</td>
+                                </tr>
+                                <tr>
+                                    <td style="color: #64748b; font-size: 14px; padding: 6px 0;">Total Dibayar</td>
+                                    <td style="color: #1e40af; font-size: 16px; font-weight: 700; text-align: right;">%s</td>
+                                </tr>
+                            </table>
+                            <p style="color: #64748b; font-size: 14px; line-height: 1.6; margin: 30px 0 0 0; text-align: center;">
+                                Invoice juga dapat diunduh kapan saja dari menu Riwayat Pembayaran.
+                            </p>
+                        </td>
+                    </tr>
+                    
+                    <!-- Footer -->
+                    <tr>
+                        <td style="background-color: #f8fafc; padding: 24px 30px; border-top: 1px solid #e2e8f0;">
+                            <p style="color: #94a3b8; font-size: 13px; margin: 0; text-align: center;">
+                                © 2026 Webbinar. All rights reserved.<br>
+                                Email ini dikirim secara otomatis, mohon tidak membalas email ini.
+                            </p>
+                        </td>
+                    </tr>
+                </table>
+            </td>
+        </tr>
+    </table>
+</body>
+</html>
+`, userName, invoiceNumber, totalPaid)
+
+	fileName := strings.ReplaceAll(invoiceNumber, "/", "-") + ".pdf"
+	return SendEmailWithAttachment(to, subject, htmlBody, fileName, pdf)
+}
+
+// GiftCodeLine is one code listed in the gift summary email to the buyer
+type GiftCodeLine struct {
+	Code           string
+	ItemTitle      string
+	RecipientEmail string // empty when the buyer shares the code themselves
+}
+
+// emailLayout wraps email content in the shared Webbinar email layout
+func emailLayout(title, content string) string {
+	return fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+</head>
+<body style="margin: 0; padding: 0; font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif; background-color: #f4f7fa;">
+    <table width="100%%" cellpadding="0" cellspacing="0" style="background-color: #f4f7fa; padding: 40px 20px;">
+        <tr>
+            <td align="center">
+                <table width="100%%" cellpadding="0" cellspacing="0" style="max-width: 600px; background-color: #ffffff; border-radius: 16px; box-shadow: 0 4px 6px rgba(0, 0, 0, 0.05); overflow: hidden;">
+                    <!-- Header -->
+                    <tr>
+                        <td style="background: linear-gradient(135deg, #3b82f6 0%%, #1e40af 100%%); padding: 40px 30px; text-align: center;">
+                            <h1 style="color: #ffffff; margin: 0; font-size: 28px; font-weight: 700;">%s</h1>
+                            <p style="color: rgba(255,255,255,0.9); margin: 10px 0 0 0; font-size: 16px;">Webbinar Learning Platform</p>
+                        </td>
+                    </tr>
+                    
+                    <!-- Content -->
+                    <tr>
+                        <td style="padding: 40px 30px;">
+%s
+                        </td>
+                    </tr>
+                    
+                    <!-- Footer -->
+                    <tr>
+                        <td style="background-color: #f8fafc; padding: 24px 30px; border-top: 1px solid #e2e8f0;">
+                            <p style="color: #94a3b8; font-size: 13px; margin: 0; text-align: center;">
+                                © 2026 Webbinar. All rights reserved.<br>
+                                Email ini dikirim secara otomatis, mohon tidak membalas email ini.
+                            </p>
+                        </td>
+                    </tr>
+                </table>
+            </td>
+        </tr>
+    </table>
+</body>
+</html>
+`, title, content)
+}
+
+// SendGiftInviteEmail sends one gift code to the person it was bought for
+func SendGiftInviteEmail(to, senderName, itemTitle, code string) error {
+	subject := "🎁 Anda mendapat hadiah kelas - Webbinar"
+
+	content := fmt.Sprintf(`
+                            <p style="color: #1e293b; font-size: 18px; margin: 0 0 10px 0;">Halo,</p>
+                            <p style="color: #64748b; font-size: 16px; line-height: 1.6; margin: 0 0 20px 0;">
+                                <strong>%s</strong> memberikan Anda akses ke <strong>%s</strong>. Masuk ke Webbinar lalu tukarkan kode berikut di menu Tukar Kode:
+                            </p>
+                            <div style="text-align: center; padding: 20px 0;">
+                                <span style="display: inline-block; background: #f0f9ff; border: 2px dashed #3b82f6; border-radius: 12px; padding: 16px 32px; font-size: 24px; font-weight: 700; letter-spacing: 2px; color: #1e40af; font-family: monospace;">%s</span>
+                            </div>
+                            <p style="color: #64748b; font-size: 14px; line-height: 1.6; margin: 20px 0 0 0; text-align: center;">
+                                Kode hanya dapat digunakan satu kali.
+                            </p>`, html.EscapeString(senderName), html.EscapeString(itemTitle), code)
+
+	return SendEmail(to, subject, emailLayout("🎁 Hadiah Untuk Anda", content))
+}
+
+// SendGiftCodesEmail sends the buyer every gift code of a paid order
+func SendGiftCodesEmail(to, userName string, codes []GiftCodeLine) error {
+	subject := fmt.Sprintf("🎁 %d kode hadiah Anda - Webbinar", len(codes))
+
+	var rows strings.Builder
+	for _, gc := range codes {
+		note := "Bagikan kode ini kepada penerima"
+		if gc.RecipientEmail != "" {
+			note = "Sudah dikirim ke " + html.EscapeString(gc.RecipientEmail)
+		}
+		fmt.Fprintf(&rows, `
+                                <tr>
+                                    <td style="color: #1e293b; font-size: 14px; padding: 8px 0; font-family: monospace; font-weight: 700;">%s</td>
+                                    <td style="color: #64748b; font-size: 13px; padding: 8px 0; text-align: right;">%s<br>%s</td>
+                                </tr>`, gc.Code, html.EscapeString(gc.ItemTitle), note)
+	}
+
+	content := fmt.Sprintf(`
+                            <p style="color: #1e293b; font-size: 18px; margin: 0 0 10px 0;">Halo <strong>%s</strong>,</p>
+                            <p style="color: #64748b; font-size: 16px; line-height: 1.6; margin: 0 0 20px 0;">
+                                Terima kasih atas pembelian hadiah Anda. Setiap kode berikut memberikan satu akses dan dapat ditukarkan oleh siapa saja melalui menu Tukar Kode.
+                            </p>
+                            <table width="100%%" cellpadding="0" cellspacing="0" style="background-color: #f8fafc; border-radius: 12px; padding: 20px;">%s
+                            </table>
+                            <p style="color: #64748b; font-size: 14px; line-height: 1.6; margin: 30px 0 0 0; text-align: center;">
+                                Status penukaran dapat dilihat kapan saja di menu Hadiah Saya.
+                            </p>`, html.EscapeString(userName), rows.String())
+
+	return SendEmail(to, subject, emailLayout("🎁 Kode Hadiah", content))
+}
+
+// SendSubscriptionRenewalEmail reminds a subscriber that the current period is about to end
+func SendSubscriptionRenewalEmail(to, userName, planName, orgName, periodEnd, price string) error {
+	subject := fmt.Sprintf("🔔 Langganan %s segera berakhir - Webbinar", planName)
+
+	content := fmt.Sprintf(`
+                            <p style="color: #1e293b; font-size: 18px; margin: 0 0 10px 0;">Halo <strong>%s</strong>,</p>
+                            <p style="color: #64748b; font-size: 16px; line-height: 1.6; margin: 0 0 20px 0;">
+                                Langganan <strong>%s</strong> dari <strong>%s</strong> berakhir pada <strong>%s</strong>.
+                                Perpanjang sekarang seharga <strong>%s</strong> dari menu Langganan agar akses Anda tidak terputus.
+                            </p>
+                            <p style="color: #64748b; font-size: 14px; line-height: 1.6; margin: 20px 0 0 0;">
+                                Jika Anda tidak ingin melanjutkan, abaikan email ini. Akses berhenti setelah masa tenggang berakhir.
+                            </p>`, html.EscapeString(userName), html.EscapeString(planName), html.EscapeString(orgName), periodEnd, price)
+
+	return SendEmail(to, subject, emailLayout("🔔 Perpanjang Langganan", content))
+}
+
+// SendWishlistAlertEmail tells a user that something on their wishlist changed
+func SendWishlistAlertEmail(to, userName, heading, message string) error {
+	subject := heading + " - Webbinar"
+
+	content := fmt.Sprintf(`
+                            <p style="color: #1e293b; font-size: 18px; margin: 0 0 10px 0;">Halo <strong>%s</strong>,</p>
+                            <p style="color: #64748b; font-size: 16px; line-height: 1.6; margin: 0 0 20px 0;">%s</p>
+                            <p style="color: #64748b; font-size: 14px; line-height: 1.6; margin: 20px 0 0 0;">
+                                Anda menerima email ini karena item tersebut ada di wishlist Anda.
+                            </p>`, html.EscapeString(userName), html.EscapeString(message))
+
+	return SendEmail(to, subject, emailLayout("💙 "+html.EscapeString(heading), content))
+}
+
+// SendGuestAccountEmail tells a guest buyer that an account was created for their order
+// and gives them the code to set its password
+func SendGuestAccountEmail(to, userName, code string) error {
+	subject := "🔑 Akun Webbinar Anda sudah siap"
+
+	content := fmt.Sprintf(`
+                            <p style="color: #1e293b; font-size: 18px; margin: 0 0 10px 0;">Halo <strong>%s</strong>,</p>
+                            <p style="color: #64748b; font-size: 16px; line-height: 1.6; margin: 0 0 20px 0;">
+                                Terima kasih atas pembelian Anda. Kami sudah membuatkan akun Webbinar untuk <strong>%s</strong> agar Anda dapat mengakses konten yang dibeli.
+                                Buka halaman Reset Password, masukkan email ini dan kode berikut untuk membuat password:
+                            </p>
+                            <div style="text-align: center; padding: 20px 0;">
+                                <span style="display: inline-block; background: #f0f9ff; border: 2px dashed #3b82f6; border-radius: 12px; padding: 16px 32px; font-size: 24px; font-weight: 700; letter-spacing: 4px; color: #1e40af; font-family: monospace;">%s</span>
+                            </div>
+                            <p style="color: #64748b; font-size: 14px; line-height: 1.6; margin: 20px 0 0 0; text-align: center;">
+                                ⏰ Kode ini berlaku selama <strong>3 hari</strong>. Setelah itu gunakan menu Lupa Password.
+                            </p>`, html.EscapeString(userName), html.EscapeString(to), code)
+
+	return SendEmail(to, subject, emailLayout("🔑 Akun Anda Sudah Siap", content))
+}